@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"nofx/logger"
+	"nofx/store"
+)
+
+// validateAlertConfig 校验交易员预警配置
+func validateAlertConfig(cfg *store.TraderAlertConfig) []FieldError {
+	var errs []FieldError
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for i, t := range cfg.DisabledTypes {
+		valid := false
+		for _, v := range store.ValidAlertTypes {
+			if t == v {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			add(fmt.Sprintf("disabled_types[%d]", i), "unknown alert type %q", t)
+		}
+	}
+	if cfg.ConsecutiveLossWarning < 0 {
+		add("consecutive_loss_warning", "consecutive_loss_warning must not be negative")
+	}
+	if cfg.ConsecutiveLossCritical < 0 {
+		add("consecutive_loss_critical", "consecutive_loss_critical must not be negative")
+	}
+	if cfg.MinWinRatePct < 0 || cfg.MinWinRatePct > 100 {
+		add("min_win_rate_pct", "min_win_rate_pct must be between 0 and 100")
+	}
+	if cfg.MinTradesForWinRate < 0 {
+		add("min_trades_for_win_rate", "min_trades_for_win_rate must not be negative")
+	}
+	if cfg.MaxDrawdownWarningPct < 0 {
+		add("max_drawdown_warning_pct", "max_drawdown_warning_pct must not be negative")
+	}
+	if cfg.MaxDrawdownCriticalPct < 0 {
+		add("max_drawdown_critical_pct", "max_drawdown_critical_pct must not be negative")
+	}
+	if cfg.APIErrorsPerHour < 0 {
+		add("api_errors_per_hour", "api_errors_per_hour must not be negative")
+	}
+
+	// warning 阈值不能高于 critical（按填充默认值后的实际生效值比较）
+	eff := cfg.WithDefaults()
+	if eff.ConsecutiveLossWarning > eff.ConsecutiveLossCritical {
+		add("consecutive_loss_warning", "consecutive_loss_warning (%d) must not exceed consecutive_loss_critical (%d)",
+			eff.ConsecutiveLossWarning, eff.ConsecutiveLossCritical)
+	}
+	if eff.MaxDrawdownWarningPct > eff.MaxDrawdownCriticalPct {
+		add("max_drawdown_warning_pct", "max_drawdown_warning_pct (%.1f) must not exceed max_drawdown_critical_pct (%.1f)",
+			eff.MaxDrawdownWarningPct, eff.MaxDrawdownCriticalPct)
+	}
+	return errs
+}
+
+// handleGetTraderAlertConfig 获取交易员风险预警配置（已填充默认阈值）
+func (s *Server) handleGetTraderAlertConfig(c *gin.Context) {
+	traderID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	cfg, err := s.store.Trader().GetAlertConfig(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "trader not found"})
+		return
+	}
+	c.JSON(http.StatusOK, cfg)
+}
+
+// handleUpdateTraderAlertConfig 更新交易员风险预警配置（启用类型与阈值）
+func (s *Server) handleUpdateTraderAlertConfig(c *gin.Context) {
+	traderID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var req store.TraderAlertConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "invalid alert config",
+			"errors": bindingFieldErrors(err, &req),
+		})
+		return
+	}
+	if errs := validateAlertConfig(&req); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "invalid alert config",
+			"errors": errs,
+		})
+		return
+	}
+
+	if err := s.store.Trader().UpdateAlertConfig(userID, traderID, req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to update alert config: %v", err)})
+		return
+	}
+
+	logger.Infof("✓ Trader %s alert config updated (disabled=%v)", traderID, req.DisabledTypes)
+	c.JSON(http.StatusOK, req.WithDefaults())
+}
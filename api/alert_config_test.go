@@ -0,0 +1,96 @@
+package api
+
+import (
+	"testing"
+
+	"nofx/store"
+)
+
+// alertsOfType 按类型筛选指定交易员的预警
+func alertsOfType(alerts []RiskAlert, traderID, alertType string) []RiskAlert {
+	var out []RiskAlert
+	for _, a := range alerts {
+		if a.TraderID == traderID && a.Type == alertType {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// TestRiskAlertsPerTraderConfig 每个交易员的预警类型与阈值可单独配置
+func TestRiskAlertsPerTraderConfig(t *testing.T) {
+	s := newDashboardTestServer(t, 2)
+	traders := s.store.Trader()
+
+	// 测试数据：胜率 40%，最近连续亏损 2 笔，默认阈值下不触发
+	alerts := s.calculateRiskAlerts()
+	if got := alertsOfType(alerts, "trader-00", store.AlertTypeLowWinRate); len(got) != 0 {
+		t.Fatalf("default low_win_rate alerts = %+v, want none", got)
+	}
+	if got := alertsOfType(alerts, "trader-00", store.AlertTypeConsecutiveLoss); len(got) != 0 {
+		t.Fatalf("default consecutive_loss alerts = %+v, want none", got)
+	}
+
+	// 收紧 trader-00 的阈值
+	if err := traders.UpdateAlertConfig("default", "trader-00", store.TraderAlertConfig{
+		MinWinRatePct:          50,
+		ConsecutiveLossWarning: 2,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	alerts = s.calculateRiskAlerts()
+	if got := alertsOfType(alerts, "trader-00", store.AlertTypeLowWinRate); len(got) != 1 || got[0].Value != 40 {
+		t.Fatalf("low_win_rate alerts = %+v, want one at 40%%", got)
+	}
+	if got := alertsOfType(alerts, "trader-00", store.AlertTypeConsecutiveLoss); len(got) != 1 || got[0].Level != "warning" {
+		t.Fatalf("consecutive_loss alerts = %+v, want one warning", got)
+	}
+	// 其他交易员仍使用默认阈值
+	if got := alertsOfType(alerts, "trader-01", store.AlertTypeLowWinRate); len(got) != 0 {
+		t.Fatalf("trader-01 low_win_rate alerts = %+v, want none", got)
+	}
+
+	// 禁用胜率预警（如高频策略胜率天然偏低）
+	if err := traders.UpdateAlertConfig("default", "trader-00", store.TraderAlertConfig{
+		DisabledTypes:          []string{store.AlertTypeLowWinRate},
+		MinWinRatePct:          50,
+		ConsecutiveLossWarning: 2,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	alerts = s.calculateRiskAlerts()
+	if got := alertsOfType(alerts, "trader-00", store.AlertTypeLowWinRate); len(got) != 0 {
+		t.Fatalf("disabled low_win_rate alerts = %+v, want none", got)
+	}
+	if got := alertsOfType(alerts, "trader-00", store.AlertTypeConsecutiveLoss); len(got) != 1 {
+		t.Fatalf("consecutive_loss alerts = %+v, want one", got)
+	}
+
+	cfg, err := traders.GetAlertConfig("default", "trader-00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MinTradesForWinRate != 10 || cfg.ConsecutiveLossCritical != 5 || cfg.IsEnabled(store.AlertTypeLowWinRate) {
+		t.Errorf("stored config = %+v", cfg)
+	}
+}
+
+func TestValidateAlertConfig(t *testing.T) {
+	errs := validateAlertConfig(&store.TraderAlertConfig{
+		DisabledTypes:          []string{"bogus"},
+		MinWinRatePct:          120,
+		ConsecutiveLossWarning: 8,
+	})
+	fields := map[string]bool{}
+	for _, e := range errs {
+		fields[e.Field] = true
+	}
+	for _, f := range []string{"disabled_types[0]", "min_win_rate_pct", "consecutive_loss_warning"} {
+		if !fields[f] {
+			t.Errorf("missing error for %s: %+v", f, errs)
+		}
+	}
+	if errs := validateAlertConfig(&store.TraderAlertConfig{DisabledTypes: []string{store.AlertTypeAPIError}}); len(errs) != 0 {
+		t.Errorf("valid config rejected: %+v", errs)
+	}
+}
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"nofx/copytrade"
@@ -33,8 +35,21 @@ func (h *CopyTradeHandler) RegisterRoutes(group *gin.RouterGroup) {
 		copyTrade.DELETE("/config/:trader_id", h.DeleteConfig)
 		copyTrade.POST("/start/:trader_id", h.Start)
 		copyTrade.POST("/stop/:trader_id", h.Stop)
+		copyTrade.POST("/pause/:trader_id", h.Pause)
+		copyTrade.POST("/resume/:trader_id", h.Resume)
 		copyTrade.GET("/stats/:trader_id", h.GetStats)
 		copyTrade.GET("/logs/:trader_id", h.GetLogs)
+		copyTrade.GET("/warnings/:trader_id", h.GetWarnings)
+		copyTrade.GET("/leader-status", h.GetLeaderStatus)
+		copyTrade.GET("/leader-orders", h.GetLeaderOrders)
+		copyTrade.GET("/selftest/:trader_id", h.SelfTest)
+		copyTrade.GET("/debug/:trader_id", h.GetDebug)
+		copyTrade.GET("/shadow/:trader_id", h.GetShadowComparison)
+		copyTrade.GET("/maintenance", h.GetMaintenance)
+		copyTrade.POST("/maintenance", h.SetMaintenance)
+		copyTrade.POST("/adopt/:trader_id", h.AdoptPosition)
+		copyTrade.POST("/simulate/:trader_id", h.Simulate)
+		copyTrade.POST("/symbol/:trader_id", h.SetSymbolEnabled)
 	}
 }
 
@@ -47,7 +62,9 @@ type CopyTradeConfigRequest struct {
 	SyncMarginMode bool    `json:"sync_margin_mode"`
 	MinTradeWarn   float64 `json:"min_trade_warn"`
 	MaxTradeWarn   float64 `json:"max_trade_warn"`
-	Enabled        bool    `json:"enabled"`
+	Enabled        *bool   `json:"enabled"` // 未提交时保持原值（新配置默认不启用）
+
+	Options *store.CopyTradeOptions `json:"options"` // 高级选项，未提交时保持原值
 }
 
 // GetConfig 获取跟单配置
@@ -85,11 +102,32 @@ func (h *CopyTradeHandler) SaveConfig(c *gin.Context) {
 
 	var req CopyTradeConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "invalid copy trade config",
+			"errors": bindingFieldErrors(err, &req),
+		})
+		return
+	}
+	if errs := validateCopyTradeConfig(&req); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "invalid copy trade config",
+			"errors": errs,
+		})
+		return
+	}
+	var leaders []store.CopyTradeLeaderSpec
+	if req.Options != nil {
+		leaders = req.Options.Leaders
+	}
+	if errs := validateCopyTradeLeaders(req.ProviderType, req.LeaderID, leaders); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "leader not found",
+			"errors": errs,
+		})
 		return
 	}
 
-	// 构造配置
+	// 构造配置：未提交的 enabled / options 沿用已保存的配置（如币种开关、多领航员等高级选项）
 	config := &store.CopyTradeConfig{
 		TraderID:       traderID,
 		ProviderType:   req.ProviderType,
@@ -99,7 +137,30 @@ func (h *CopyTradeHandler) SaveConfig(c *gin.Context) {
 		SyncMarginMode: req.SyncMarginMode,
 		MinTradeWarn:   req.MinTradeWarn,
 		MaxTradeWarn:   req.MaxTradeWarn,
-		Enabled:        req.Enabled,
+	}
+	existing, err := h.store.CopyTrade().GetByTraderID(traderID)
+	if err != nil {
+		existing = nil
+	}
+	if existing != nil {
+		config.Enabled = existing.Enabled
+		config.Options = existing.Options
+	}
+	if req.Enabled != nil {
+		config.Enabled = *req.Enabled
+	}
+	if req.Options != nil {
+		config.Options = *req.Options
+	}
+
+	// 存在活跃跟单映射时不允许切换反向跟单（映射按原方向记录，切换后无法随领航员减仓/平仓）
+	if err := copytrade.CheckInverseChange(h.store, existing, config); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, copytrade.ErrInverseWithActiveMappings) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
 	}
 
 	// 保存配置
@@ -110,7 +171,7 @@ func (h *CopyTradeHandler) SaveConfig(c *gin.Context) {
 	}
 
 	// 更新 trader 的决策模式
-	if req.Enabled {
+	if config.Enabled {
 		h.store.CopyTrade().UpdateDecisionMode(traderID, "copy_trade")
 	} else {
 		h.store.CopyTrade().UpdateDecisionMode(traderID, "ai")
@@ -119,10 +180,23 @@ func (h *CopyTradeHandler) SaveConfig(c *gin.Context) {
 	logger.Infof("✓ Saved copy trade config for trader %s: provider=%s leader=%s ratio=%.0f%%",
 		traderID, req.ProviderType, req.LeaderID, req.CopyRatio*100)
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"message": "config saved",
 		"config":  config,
-	})
+	}
+
+	// 运行中的跟单立即应用新配置（非结构性配置热更新，不影响已有仓位映射）
+	if copytrade.IsCopyTradingRunning(traderID) {
+		reload, err := copytrade.ReloadCopyTradingConfig(traderID)
+		if err != nil {
+			logger.Errorf("Failed to reload copy trade config for trader %s: %v", traderID, err)
+			resp["reload_error"] = err.Error()
+		} else {
+			resp["reload"] = reload
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
 }
 
 // DeleteConfig 删除跟单配置
@@ -166,6 +240,20 @@ func (h *CopyTradeHandler) Start(c *gin.Context) {
 		return
 	}
 
+	// 启动前确认领航员存在（地址填错时引擎会静默地收不到任何成交）
+	config, err := h.store.CopyTrade().GetByTraderID(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "copy trade config not found"})
+		return
+	}
+	if errs := validateCopyTradeLeaders(config.ProviderType, config.LeaderID, config.Options.Leaders); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "leader not found",
+			"errors": errs,
+		})
+		return
+	}
+
 	// 获取 AutoTrader
 	autoTrader, err := h.traderManager.GetTrader(traderID)
 	if err != nil {
@@ -222,6 +310,48 @@ func (h *CopyTradeHandler) Stop(c *gin.Context) {
 	})
 }
 
+// Pause 暂停跟单（保持连接和状态同步，不生成决策）
+// @Summary 暂停跟单（不断开 WebSocket，暂停期间的成交恢复后不会重放）
+// @Tags CopyTrade
+// @Param trader_id path string true "Trader ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/copytrade/pause/{trader_id} [post]
+func (h *CopyTradeHandler) Pause(c *gin.Context) {
+	traderID := c.Param("trader_id")
+
+	if err := copytrade.PauseCopyTradingForTrader(traderID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	logger.Infof("⏸️ Copy trading paused for trader %s", traderID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "copy trading paused",
+		"status":  "paused",
+	})
+}
+
+// Resume 恢复已暂停的跟单
+// @Summary 恢复跟单
+// @Tags CopyTrade
+// @Param trader_id path string true "Trader ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/copytrade/resume/{trader_id} [post]
+func (h *CopyTradeHandler) Resume(c *gin.Context) {
+	traderID := c.Param("trader_id")
+
+	if err := copytrade.ResumeCopyTradingForTrader(traderID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	logger.Infof("▶️ Copy trading resumed for trader %s", traderID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "copy trading resumed",
+		"status":  "running",
+	})
+}
+
 // GetStats 获取跟单统计
 // @Summary 获取跟单统计
 // @Tags CopyTrade
@@ -238,8 +368,258 @@ func (h *CopyTradeHandler) GetStats(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"stats":   stats,
-		"running": copytrade.IsCopyTradingRunning(traderID),
+		"stats":       stats,
+		"health":      copytrade.GetCopyTradingHealth(traderID),
+		"running":     copytrade.IsCopyTradingRunning(traderID),
+		"maintenance": copytrade.GetMaintenance(),
+	})
+}
+
+// MaintenanceRequest 维护模式请求
+type MaintenanceRequest struct {
+	Enabled         bool   `json:"enabled"`
+	Reason          string `json:"reason"`
+	DurationMinutes int    `json:"duration_minutes"` // 到期自动解除（0=手动解除）
+}
+
+// GetMaintenance 获取全局维护模式状态
+// @Summary 获取跟单维护模式状态
+// @Tags CopyTrade
+// @Success 200 {object} copytrade.MaintenanceState
+// @Router /api/copytrade/maintenance [get]
+func (h *CopyTradeHandler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, copytrade.GetMaintenance())
+}
+
+// SetMaintenance 开启/关闭全局维护模式
+// @Summary 所有跟单引擎暂停开仓/加仓，继续同步状态并跟随减仓/平仓
+// @Tags CopyTrade
+// @Param request body MaintenanceRequest true "维护模式"
+// @Success 200 {object} copytrade.MaintenanceState
+// @Router /api/copytrade/maintenance [post]
+func (h *CopyTradeHandler) SetMaintenance(c *gin.Context) {
+	var req MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.DurationMinutes < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "duration_minutes must not be negative"})
+		return
+	}
+
+	state := copytrade.SetMaintenance(req.Enabled, req.Reason, time.Duration(req.DurationMinutes)*time.Minute)
+	c.JSON(http.StatusOK, state)
+}
+
+// AdoptPositionRequest 接管持仓请求
+type AdoptPositionRequest struct {
+	PosID string `json:"pos_id" binding:"required"` // 领航员仓位 ID（Hyperliquid 为 BTCUSDT_long 格式）
+}
+
+// AdoptPosition 接管跟随者已有持仓
+// @Summary 将领航员仓位与跟随者手动开的持仓关联，之后按实际持仓跟随减仓/平仓
+// @Tags CopyTrade
+// @Param trader_id path string true "Trader ID"
+// @Param request body AdoptPositionRequest true "接管请求"
+// @Success 200 {object} store.CopyTradePositionMapping
+// @Router /api/copytrade/adopt/{trader_id} [post]
+func (h *CopyTradeHandler) AdoptPosition(c *gin.Context) {
+	traderID := c.Param("trader_id")
+
+	var req AdoptPositionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !copytrade.IsCopyTradingRunning(traderID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "copy trading not running"})
+		return
+	}
+
+	mapping, err := copytrade.AdoptCopyTradingPosition(traderID, req.PosID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, mapping)
+}
+
+// Simulate 模拟一笔领航员成交
+// @Summary 把假设成交交给匹配与仓位计算，返回匹配结果和将会生成的决策（不执行、不写数据库）
+// @Tags CopyTrade
+// @Param trader_id path string true "Trader ID"
+// @Param request body copytrade.SimulateRequest true "模拟成交（可选 leader_state 覆盖领航员状态）"
+// @Success 200 {object} copytrade.SimulateResult
+// @Router /api/copytrade/simulate/{trader_id} [post]
+func (h *CopyTradeHandler) Simulate(c *gin.Context) {
+	traderID := c.Param("trader_id")
+
+	var req copytrade.SimulateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !copytrade.IsCopyTradingRunning(traderID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "copy trading not running"})
+		return
+	}
+
+	result, err := copytrade.SimulateCopyTradingFill(traderID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// SymbolToggleRequest 按币种开关跟随请求
+type SymbolToggleRequest struct {
+	Symbol  string `json:"symbol" binding:"required"`
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Close   bool   `json:"close"` // 暂停时同时主动平掉该币种的活跃跟单仓位
+}
+
+// SetSymbolEnabled 运行时按币种开启/暂停跟随
+// @Summary 暂停或恢复跟随某个币种（写入配置并热更新；close=true 时暂停同时平掉该币种的跟单仓位）
+// @Tags CopyTrade
+// @Param trader_id path string true "Trader ID"
+// @Param request body SymbolToggleRequest true "币种开关"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/copytrade/symbol/{trader_id} [post]
+func (h *CopyTradeHandler) SetSymbolEnabled(c *gin.Context) {
+	traderID := c.Param("trader_id")
+
+	var req SymbolToggleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	symbol := copytrade.NormalizeSymbol(req.Symbol)
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid symbol"})
+		return
+	}
+
+	config, err := h.store.CopyTrade().GetByTraderID(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "copy trade config not found"})
+		return
+	}
+
+	// 只记录暂停的币种，恢复时删除（未列出=跟随）
+	if *req.Enabled {
+		delete(config.Options.SymbolEnabled, symbol)
+	} else {
+		if config.Options.SymbolEnabled == nil {
+			config.Options.SymbolEnabled = make(map[string]bool)
+		}
+		config.Options.SymbolEnabled[symbol] = false
+	}
+	if err := h.store.CopyTrade().Update(config); err != nil {
+		logger.Errorf("Failed to save symbol toggle for trader %s: %v", traderID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save config"})
+		return
+	}
+	logger.Infof("✓ Copy trade symbol %s enabled=%v for trader %s (close=%v)", symbol, *req.Enabled, traderID, req.Close)
+
+	resp := gin.H{
+		"symbol":         symbol,
+		"enabled":        *req.Enabled,
+		"symbol_enabled": config.Options.SymbolEnabled,
+	}
+
+	if copytrade.IsCopyTradingRunning(traderID) {
+		reload, err := copytrade.ReloadCopyTradingConfig(traderID)
+		if err != nil {
+			logger.Errorf("Failed to reload copy trade config for trader %s: %v", traderID, err)
+			resp["reload_error"] = err.Error()
+		} else {
+			resp["reload"] = reload
+		}
+	}
+
+	// 暂停且要求平仓：为该币种的活跃映射推送平仓决策
+	if !*req.Enabled && req.Close {
+		closes, err := copytrade.CloseCopyTradingSymbol(traderID, symbol)
+		if err != nil {
+			resp["close_error"] = err.Error()
+		} else {
+			resp["closes"] = closes
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetDebug 获取跟单引擎性能诊断数据
+// @Summary 获取关键路径耗时统计（需开启 options.profiling）
+// @Tags CopyTrade
+// @Param trader_id path string true "Trader ID"
+// @Success 200 {object} map[string]copytrade.TimingStat
+// @Router /api/copytrade/debug/{trader_id} [get]
+func (h *CopyTradeHandler) GetDebug(c *gin.Context) {
+	traderID := c.Param("trader_id")
+
+	if !copytrade.IsCopyTradingRunning(traderID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "copy trading not running"})
+		return
+	}
+
+	timings := copytrade.GetCopyTradingTimings(traderID)
+	c.JSON(http.StatusOK, gin.H{
+		"profiling": timings != nil,
+		"timings":   timings,
+	})
+}
+
+// GetShadowComparison 对比实盘与影子跟单的假设盈亏
+// @Summary 影子跟单对比（需配置 options.shadow）
+// @Tags CopyTrade
+// @Param trader_id path string true "Trader ID"
+// @Param limit query int false "最近决策条数" default(50)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/copytrade/shadow/{trader_id} [get]
+func (h *CopyTradeHandler) GetShadowComparison(c *gin.Context) {
+	traderID := c.Param("trader_id")
+	limit := 50
+	if l := c.Query("limit"); l != "" {
+		if parsed, ok := parseInt(l); ok {
+			limit = parsed
+		}
+	}
+
+	summaries, err := h.store.CopyTrade().GetShadowComparison(traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get shadow comparison"})
+		return
+	}
+	decisions, err := h.store.CopyTrade().GetRecentShadowDecisions(traderID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get shadow decisions"})
+		return
+	}
+
+	// 影子相对实盘的假设盈亏差
+	var live, shadow *store.ShadowSummary
+	for i := range summaries {
+		switch summaries[i].Variant {
+		case store.ShadowVariantLive:
+			live = &summaries[i]
+		case store.ShadowVariantShadow:
+			shadow = &summaries[i]
+		}
+	}
+	var pnlDiff float64
+	if live != nil && shadow != nil {
+		pnlDiff = shadow.RealizedPnL - live.RealizedPnL
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"live":      live,
+		"shadow":    shadow,
+		"pnl_diff":  pnlDiff,
+		"decisions": decisions,
 	})
 }
 
@@ -267,12 +647,153 @@ func (h *CopyTradeHandler) GetLogs(c *gin.Context) {
 		return
 	}
 
+	// 平仓来源分布（止盈/止损/强平/手动），统计范围为未压缩的原始日志
+	closeTriggers, err := h.store.CopyTrade().CountCloseTriggers(traderID)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 统计平仓来源失败: %v", traderID, err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"logs":  logs,
-		"count": len(logs),
+		"logs":           logs,
+		"count":          len(logs),
+		"close_triggers": closeTriggers,
 	})
 }
 
+// GetWarnings 获取跟单预警记录
+// @Summary 获取最近的跟单预警（金额过小/过大、余额不足、未上架币种等）
+// @Tags CopyTrade
+// @Param trader_id path string true "Trader ID"
+// @Param limit query int false "Limit" default(50)
+// @Param type query string false "Warning type filter"
+// @Success 200 {array} store.CopyTradeWarning
+// @Router /api/copytrade/warnings/{trader_id} [get]
+func (h *CopyTradeHandler) GetWarnings(c *gin.Context) {
+	traderID := c.Param("trader_id")
+	limit := 50 // 默认值
+
+	if l := c.Query("limit"); l != "" {
+		if parsed, ok := parseInt(l); ok && parsed > 0 {
+			limit = parsed
+		}
+	}
+	if limit > store.DefaultWarningHistoryLimit {
+		limit = store.DefaultWarningHistoryLimit
+	}
+
+	warnings, err := h.store.CopyTrade().GetRecentWarnings(traderID, c.Query("type"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get warnings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"warnings": warnings,
+		"count":    len(warnings),
+	})
+}
+
+// GetLeaderStatus 查询领航员数据新鲜度（跟单前诊断）
+// @Summary 查询领航员最近成交与持仓情况
+// @Tags CopyTrade
+// @Param provider query string true "hyperliquid | okx"
+// @Param leader query string true "Leader ID"
+// @Success 200 {object} copytrade.LeaderStatus
+// @Router /api/copytrade/leader-status [get]
+func (h *CopyTradeHandler) GetLeaderStatus(c *gin.Context) {
+	provider := c.Query("provider")
+	leader := c.Query("leader")
+
+	var errs []FieldError
+	if provider != string(copytrade.ProviderHyperliquid) && provider != string(copytrade.ProviderOKX) {
+		errs = append(errs, FieldError{Field: "provider", Message: "provider must be one of: hyperliquid, okx"})
+	}
+	if leader == "" {
+		errs = append(errs, FieldError{Field: "leader", Message: "leader is required"})
+	}
+	if len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query", "errors": errs})
+		return
+	}
+
+	status, err := copytrade.CheckLeaderStatus(copytrade.ProviderType(provider), leader)
+	if err != nil {
+		logger.Warnf("Failed to check leader status %s:%s: %v", provider, leader, err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":  "failed to query leader data",
+			"status": status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": status})
+}
+
+// GetLeaderOrders 查询领航员当前挂单
+// @Summary 查询领航员未成交的挂单（只读，不需要启动跟单；目前仅支持 Hyperliquid）
+// @Tags CopyTrade
+// @Param provider query string true "Provider type (hyperliquid)"
+// @Param leader query string true "Leader ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/copytrade/leader-orders [get]
+func (h *CopyTradeHandler) GetLeaderOrders(c *gin.Context) {
+	provider := c.Query("provider")
+	leader := c.Query("leader")
+
+	var errs []FieldError
+	if provider != string(copytrade.ProviderHyperliquid) && provider != string(copytrade.ProviderOKX) {
+		errs = append(errs, FieldError{Field: "provider", Message: "provider must be one of: hyperliquid, okx"})
+	}
+	if leader == "" {
+		errs = append(errs, FieldError{Field: "leader", Message: "leader is required"})
+	}
+	if len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query", "errors": errs})
+		return
+	}
+
+	orders, err := copytrade.GetLeaderOpenOrders(copytrade.ProviderType(provider), leader)
+	if errors.Is(err, copytrade.ErrOpenOrdersUnsupported) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Warnf("Failed to get leader open orders %s:%s: %v", provider, leader, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to query leader orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"orders": orders, "count": len(orders)})
+}
+
+// SelfTest 跟单链路自检
+// @Summary 检查数据源、执行器、数据库与决策通道是否可用（不下单）
+// @Tags CopyTrade
+// @Param trader_id path string true "Trader ID"
+// @Success 200 {object} copytrade.SelfTestReport
+// @Router /api/copytrade/selftest/{trader_id} [get]
+func (h *CopyTradeHandler) SelfTest(c *gin.Context) {
+	traderID := c.Param("trader_id")
+
+	config, err := h.store.CopyTrade().GetByTraderID(traderID)
+	if err != nil {
+		config = nil
+	}
+
+	var executor copytrade.DecisionExecutor
+	if h.traderManager != nil {
+		if autoTrader, err := h.traderManager.GetTrader(traderID); err == nil {
+			executor = autoTrader
+		}
+	}
+
+	report := copytrade.RunSelfTest(traderID, config, executor, h.store)
+	if !report.Passed {
+		logger.Warnf("Copy trade self-test failed for %s: %+v", traderID, report.Checks)
+	}
+	c.JSON(http.StatusOK, report)
+}
+
 // parseInt 简单整数解析
 func parseInt(s string) (int, bool) {
 	var n int
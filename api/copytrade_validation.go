@@ -0,0 +1,436 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"nofx/copytrade"
+	"nofx/store"
+)
+
+// 跟单比例上限（10 = 1000%）
+const maxCopyRatio = 10.0
+
+// FieldError 字段级校验错误，供前端在对应输入框旁展示
+type FieldError struct {
+	Field   string `json:"field"`   // JSON 字段名，如 copy_ratio、options.fixed_notional
+	Message string `json:"message"` // 面向用户的错误说明
+}
+
+// bindingFieldErrors 将 Gin 绑定错误转换为字段级错误
+func bindingFieldErrors(err error, req interface{}) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		result := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			field := jsonFieldName(req, fe.StructField())
+			result = append(result, FieldError{Field: field, Message: validationMessage(field, fe)})
+		}
+		return result
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("%s must be a %s", typeErr.Field, typeErr.Type.String()),
+		}}
+	}
+
+	return []FieldError{{Field: "", Message: "invalid request body: " + err.Error()}}
+}
+
+// validationMessage 单条校验规则的提示文案
+func validationMessage(field string, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return field + " is required"
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, fe.Param())
+	case "gte":
+		return fmt.Sprintf("%s must be at least %s", field, fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.ReplaceAll(fe.Param(), " ", ", "))
+	default:
+		return field + " is invalid"
+	}
+}
+
+// jsonFieldName 根据结构体字段名查找 json tag
+func jsonFieldName(req interface{}, structField string) string {
+	t := reflect.TypeOf(req)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if f, ok := t.FieldByName(structField); ok {
+		if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return structField
+}
+
+// validateCopyTradeConfig 校验跨字段约束（绑定规则无法表达的部分）
+func validateCopyTradeConfig(req *CopyTradeConfigRequest) []FieldError {
+	var errs []FieldError
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if req.CopyRatio > maxCopyRatio {
+		add("copy_ratio", "copy_ratio must not exceed %.0f (%.0f%%)", maxCopyRatio, maxCopyRatio*100)
+	}
+	if req.MinTradeWarn < 0 {
+		add("min_trade_warn", "min_trade_warn must not be negative")
+	}
+	if req.MaxTradeWarn < 0 {
+		add("max_trade_warn", "max_trade_warn must not be negative")
+	}
+	if req.MinTradeWarn > 0 && req.MaxTradeWarn > 0 && req.MinTradeWarn > req.MaxTradeWarn {
+		add("max_trade_warn", "max_trade_warn must be greater than or equal to min_trade_warn")
+	}
+
+	var opts store.CopyTradeOptions // 未提交 options 时沿用已保存的配置，不再校验
+	if req.Options != nil {
+		opts = *req.Options
+	}
+	switch opts.ZeroEquityPolicy {
+	case "", copytrade.ZeroEquitySkip:
+	case copytrade.ZeroEquityFixedNotional:
+		if opts.FixedNotional <= 0 {
+			add("options.fixed_notional", "options.fixed_notional must be greater than 0 when zero_equity_policy is %s", opts.ZeroEquityPolicy)
+		}
+	case copytrade.ZeroEquityOverride:
+		if opts.LeaderEquityOverride <= 0 {
+			add("options.leader_equity_override", "options.leader_equity_override must be greater than 0 when zero_equity_policy is %s", opts.ZeroEquityPolicy)
+		}
+	default:
+		add("options.zero_equity_policy", "options.zero_equity_policy must be one of: %s, %s, %s",
+			copytrade.ZeroEquitySkip, copytrade.ZeroEquityFixedNotional, copytrade.ZeroEquityOverride)
+	}
+
+	switch opts.ReduceMode {
+	case "", copytrade.ReduceModeRatio, copytrade.ReduceModeScaledAbsolute:
+	default:
+		add("options.reduce_mode", "options.reduce_mode must be one of: %s, %s",
+			copytrade.ReduceModeRatio, copytrade.ReduceModeScaledAbsolute)
+	}
+
+	switch opts.OutOfWindowFills {
+	case "", copytrade.OutOfWindowDrop, copytrade.OutOfWindowKeep:
+	default:
+		add("options.out_of_window_fills", "options.out_of_window_fills must be one of: %s, %s",
+			copytrade.OutOfWindowDrop, copytrade.OutOfWindowKeep)
+	}
+
+	if opts.FixedNotional < 0 {
+		add("options.fixed_notional", "options.fixed_notional must not be negative")
+	}
+	if opts.LeaderEquityOverride < 0 {
+		add("options.leader_equity_override", "options.leader_equity_override must not be negative")
+	}
+	if opts.MaxHoldHours < 0 {
+		add("options.max_hold_hours", "options.max_hold_hours must not be negative")
+	}
+	if opts.TrialTradeLimit < 0 {
+		add("options.trial_trade_limit", "options.trial_trade_limit must not be negative")
+	}
+	if opts.LeaderBudget < 0 {
+		add("options.leader_budget", "options.leader_budget must not be negative")
+	}
+	if opts.DuplicateDecisionWindowSec < 0 {
+		add("options.duplicate_decision_window_sec", "options.duplicate_decision_window_sec must not be negative")
+	}
+	if opts.ReopenCooldownSeconds < 0 {
+		add("options.reopen_cooldown_seconds", "options.reopen_cooldown_seconds must not be negative")
+	}
+	switch opts.StateUnavailablePolicy {
+	case "", copytrade.StateUnavailableRefuse, copytrade.StateUnavailableDegraded:
+	default:
+		add("options.state_unavailable_policy", "options.state_unavailable_policy must be one of: %s, %s",
+			copytrade.StateUnavailableRefuse, copytrade.StateUnavailableDegraded)
+	}
+	for i, w := range opts.TradingWindows {
+		window := copytrade.TimeWindow{Weekdays: w.Weekdays, Start: w.Start, End: w.End}
+		if err := window.Validate(); err != nil {
+			add(fmt.Sprintf("options.trading_windows[%d]", i), "options.trading_windows[%d]: %v", i, err)
+		}
+	}
+	if _, err := copytrade.LoadTradingLocation(opts.Timezone); err != nil {
+		add("options.timezone", "options.timezone %q is not a valid IANA time zone", opts.Timezone)
+	}
+	switch opts.EntryOrderType {
+	case "", copytrade.EntryOrderMarket, copytrade.EntryOrderLimit:
+	default:
+		add("options.entry_order_type", "options.entry_order_type must be one of: %s, %s",
+			copytrade.EntryOrderMarket, copytrade.EntryOrderLimit)
+	}
+	switch opts.LimitTimeoutAction {
+	case "", copytrade.LimitTimeoutCancel, copytrade.LimitTimeoutMarket:
+	default:
+		add("options.limit_timeout_action", "options.limit_timeout_action must be one of: %s, %s",
+			copytrade.LimitTimeoutCancel, copytrade.LimitTimeoutMarket)
+	}
+	if opts.LimitTimeoutSeconds < 0 {
+		add("options.limit_timeout_seconds", "options.limit_timeout_seconds must not be negative")
+	}
+	switch opts.UnlistedSymbolPolicy {
+	case "", copytrade.UnlistedSymbolSkip, copytrade.UnlistedSymbolAttempt:
+	default:
+		add("options.unlisted_symbol_policy", "options.unlisted_symbol_policy must be one of: %s, %s",
+			copytrade.UnlistedSymbolSkip, copytrade.UnlistedSymbolAttempt)
+	}
+	switch opts.NegativeEquityPolicy {
+	case "", copytrade.NegativeEquityExitsOnly, copytrade.NegativeEquityPauseAll:
+	default:
+		add("options.negative_equity_policy", "options.negative_equity_policy must be one of: %s, %s",
+			copytrade.NegativeEquityExitsOnly, copytrade.NegativeEquityPauseAll)
+	}
+	switch opts.DedupScope {
+	case "", copytrade.DedupScopeEngine, copytrade.DedupScopeAccount:
+	default:
+		add("options.dedup_scope", "options.dedup_scope must be one of: %s, %s",
+			copytrade.DedupScopeEngine, copytrade.DedupScopeAccount)
+	}
+	switch opts.InitialSync {
+	case "", copytrade.InitialSyncIgnore, copytrade.InitialSyncMirror:
+	default:
+		add("options.initial_sync", "options.initial_sync must be one of: %s, %s",
+			copytrade.InitialSyncIgnore, copytrade.InitialSyncMirror)
+	}
+	switch opts.ReconnectReconcile {
+	case "", copytrade.ReconnectReconcileOn, copytrade.ReconnectReconcileOff:
+	default:
+		add("options.reconnect_reconcile", "options.reconnect_reconcile must be one of: %s, %s",
+			copytrade.ReconnectReconcileOn, copytrade.ReconnectReconcileOff)
+	}
+	switch opts.ReduceMinPolicy {
+	case "", copytrade.ReduceMinBump, copytrade.ReduceMinClose:
+	default:
+		add("options.reduce_min_policy", "options.reduce_min_policy must be one of: %s, %s",
+			copytrade.ReduceMinBump, copytrade.ReduceMinClose)
+	}
+	switch opts.EmptyStatePolicy {
+	case "", copytrade.EmptyStateRefetch, copytrade.EmptyStateTrust:
+	default:
+		add("options.empty_state_policy", "options.empty_state_policy must be one of: %s, %s",
+			copytrade.EmptyStateRefetch, copytrade.EmptyStateTrust)
+	}
+	switch opts.CloseMatchStrategy {
+	case "", copytrade.CloseMatchBestFit, copytrade.CloseMatchFirst:
+	default:
+		add("options.close_match_strategy", "options.close_match_strategy must be one of: %s, %s",
+			copytrade.CloseMatchBestFit, copytrade.CloseMatchFirst)
+	}
+	switch opts.CopyMode {
+	case "", copytrade.CopyModeProportional:
+	case copytrade.CopyModeFixed:
+		if opts.FixedTradeUSD <= 0 {
+			add("options.fixed_trade_usd", "options.fixed_trade_usd must be greater than 0 when copy_mode is %s", opts.CopyMode)
+		}
+	default:
+		add("options.copy_mode", "options.copy_mode must be one of: %s, %s",
+			copytrade.CopyModeProportional, copytrade.CopyModeFixed)
+	}
+	if opts.FixedTradeUSD < 0 {
+		add("options.fixed_trade_usd", "options.fixed_trade_usd must not be negative")
+	}
+	switch opts.AddSizingMode {
+	case "", copytrade.AddSizingFillValue, copytrade.AddSizingMatchIncreaseRatio:
+	default:
+		add("options.add_sizing_mode", "options.add_sizing_mode must be one of: %s, %s",
+			copytrade.AddSizingFillValue, copytrade.AddSizingMatchIncreaseRatio)
+	}
+	switch opts.PhantomClosePolicy {
+	case "", copytrade.PhantomCloseMirror, copytrade.PhantomCloseIgnore:
+	default:
+		add("options.phantom_close_policy", "options.phantom_close_policy must be one of: %s, %s",
+			copytrade.PhantomCloseMirror, copytrade.PhantomCloseIgnore)
+	}
+	if w := opts.HealthWeights; w != nil {
+		if w.ReconnectPenalty < 0 || w.ReconnectMax < 0 || w.StaleAfterSeconds < 0 || w.StaleMax < 0 ||
+			w.MinExecutions < 0 || w.FailureMax < 0 || w.DedupPenalty < 0 || w.DedupMax < 0 {
+			add("options.health_weights", "options.health_weights values must not be negative")
+		}
+	}
+	if opts.MaxMarginFraction < 0 || opts.MaxMarginFraction > 1 {
+		add("options.max_margin_fraction", "options.max_margin_fraction must be between 0 and 1")
+	}
+	switch opts.MarginCapPolicy {
+	case "", copytrade.MarginCapClamp, copytrade.MarginCapSkip:
+	default:
+		add("options.margin_cap_policy", "options.margin_cap_policy must be one of: %s, %s",
+			copytrade.MarginCapClamp, copytrade.MarginCapSkip)
+	}
+	if opts.CopyDelaySeconds < 0 || opts.CopyDelaySeconds > copytrade.MaxCopyDelaySeconds {
+		add("options.copy_delay_seconds", "options.copy_delay_seconds must be between 0 and %d", copytrade.MaxCopyDelaySeconds)
+	}
+	if opts.MaxOpenPositions < 0 {
+		add("options.max_open_positions", "options.max_open_positions must not be negative")
+	}
+	if opts.MaxLeverage < 0 {
+		add("options.max_leverage", "options.max_leverage must not be negative")
+	}
+	// 反向跟单同步领航员杠杆时必须限制杠杆上限：领航员的高杠杆仓位反向持有风险更高
+	if opts.Inverse && req.SyncLeverage && (opts.MaxLeverage == 0 || opts.MaxLeverage > copytrade.InverseMaxLeverage) {
+		add("options.max_leverage", "options.max_leverage must be between 1 and %d when inverse mode syncs leader leverage",
+			copytrade.InverseMaxLeverage)
+	}
+	if len(opts.Leaders) > copytrade.MaxLeaders {
+		add("options.leaders", "options.leaders must not contain more than %d leaders", copytrade.MaxLeaders)
+	}
+	seenLeaders := make(map[string]bool, len(opts.Leaders))
+	for i, l := range opts.Leaders {
+		field := fmt.Sprintf("options.leaders[%d]", i)
+		if strings.TrimSpace(l.LeaderID) == "" {
+			add(field+".leader_id", "%s.leader_id is required", field)
+		}
+		if _, err := copytrade.GetProviderCapabilities(copytrade.ProviderType(l.ProviderType)); err != nil {
+			add(field+".provider_type", "%s.provider_type %q is not supported", field, l.ProviderType)
+		}
+		if l.Weight < 0 {
+			add(field+".weight", "%s.weight must not be negative", field)
+		}
+		key := l.ProviderType + ":" + l.LeaderID
+		if seenLeaders[key] {
+			add(field+".leader_id", "%s.leader_id %q is duplicated", field, l.LeaderID)
+		}
+		seenLeaders[key] = true
+	}
+	if opts.PollIntervalSeconds < 0 {
+		add("options.poll_interval_seconds", "options.poll_interval_seconds must not be negative")
+	}
+	if opts.LeaderOrdersPollSeconds < 0 {
+		add("options.leader_orders_poll_seconds", "options.leader_orders_poll_seconds must not be negative")
+	}
+	if opts.DecisionStallSeconds < 0 {
+		add("options.decision_stall_seconds", "options.decision_stall_seconds must not be negative")
+	}
+	switch opts.DecisionStallPolicy {
+	case "", copytrade.DecisionStallAlert, copytrade.DecisionStallPause:
+	default:
+		add("options.decision_stall_policy", "options.decision_stall_policy must be one of: %s, %s",
+			copytrade.DecisionStallAlert, copytrade.DecisionStallPause)
+	}
+	if opts.ProtectiveStopPct < 0 || opts.ProtectiveStopPct >= 1 {
+		add("options.protective_stop_pct", "options.protective_stop_pct must be between 0 and 1")
+	}
+	if opts.MaxSlippagePct < 0 || opts.MaxSlippagePct >= 1 {
+		add("options.max_slippage_pct", "options.max_slippage_pct must be between 0 and 1")
+	}
+	if opts.CrossCheckIntervalSeconds < 0 {
+		add("options.cross_check_interval_seconds", "options.cross_check_interval_seconds must not be negative")
+	}
+	if opts.CrossCheckThreshold < 0 || opts.CrossCheckThreshold >= 1 {
+		add("options.cross_check_threshold", "options.cross_check_threshold must be between 0 and 1")
+	}
+	if opts.VolatilityReference < 0 {
+		add("options.volatility_reference", "options.volatility_reference must not be negative")
+	}
+	symbols := make([]string, 0, len(opts.SymbolMaxBaseSize))
+	for symbol := range opts.SymbolMaxBaseSize {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		size := opts.SymbolMaxBaseSize[symbol]
+		if symbol == "" {
+			add("options.symbol_max_base_size", "options.symbol_max_base_size keys must not be empty")
+		} else if size <= 0 {
+			add(fmt.Sprintf("options.symbol_max_base_size.%s", symbol), "options.symbol_max_base_size.%s must be greater than 0", symbol)
+		}
+	}
+	for _, list := range []struct {
+		field   string
+		symbols []string
+	}{{"options.symbol_whitelist", opts.SymbolWhitelist}, {"options.symbol_blacklist", opts.SymbolBlacklist}} {
+		for _, symbol := range list.symbols {
+			if copytrade.NormalizeSymbol(symbol) == "" {
+				add(list.field, "%s contains invalid symbol %q", list.field, symbol)
+			}
+		}
+	}
+	for symbol := range opts.SymbolEnabled {
+		if copytrade.NormalizeSymbol(symbol) != symbol {
+			add("options.symbol_enabled", "options.symbol_enabled keys must be USDT symbols such as BTCUSDT")
+			break
+		}
+	}
+	instIDs := make([]string, 0, len(opts.OKXInstrumentMap))
+	for instID := range opts.OKXInstrumentMap {
+		instIDs = append(instIDs, instID)
+	}
+	sort.Strings(instIDs)
+	for _, instID := range instIDs {
+		symbol := strings.ToUpper(opts.OKXInstrumentMap[instID])
+		if instID == "" {
+			add("options.okx_instrument_map", "options.okx_instrument_map keys must not be empty")
+		} else if base, ok := strings.CutSuffix(symbol, "USDT"); !ok || base == "" {
+			add(fmt.Sprintf("options.okx_instrument_map.%s", instID), "options.okx_instrument_map.%s must be a USDT symbol such as BTCUSDT", instID)
+		}
+	}
+	if ar := opts.AdaptiveRatio; ar != nil {
+		if ar.WindowHours < 0 {
+			add("options.adaptive_ratio.window_hours", "options.adaptive_ratio.window_hours must not be negative")
+		}
+		if ar.Sensitivity < 0 {
+			add("options.adaptive_ratio.sensitivity", "options.adaptive_ratio.sensitivity must not be negative")
+		}
+		if ar.MinMultiplier < 0 {
+			add("options.adaptive_ratio.min_multiplier", "options.adaptive_ratio.min_multiplier must not be negative")
+		}
+		if ar.MaxMultiplier < 0 {
+			add("options.adaptive_ratio.max_multiplier", "options.adaptive_ratio.max_multiplier must not be negative")
+		} else if ar.MaxMultiplier > 0 && ar.MinMultiplier > ar.MaxMultiplier {
+			add("options.adaptive_ratio.max_multiplier", "options.adaptive_ratio.max_multiplier must be greater than or equal to min_multiplier")
+		}
+	}
+	if opts.Shadow != nil {
+		if opts.Shadow.CopyRatio <= 0 {
+			add("options.shadow.copy_ratio", "options.shadow.copy_ratio must be greater than 0")
+		} else if opts.Shadow.CopyRatio > maxCopyRatio {
+			add("options.shadow.copy_ratio", "options.shadow.copy_ratio must not exceed %.0f (%.0f%%)", maxCopyRatio, maxCopyRatio*100)
+		}
+		if opts.Shadow.MinTradeWarn < 0 {
+			add("options.shadow.min_trade_warn", "options.shadow.min_trade_warn must not be negative")
+		}
+	}
+
+	return errs
+}
+
+// lookupLeader 领航员存在性校验（测试中替换以避免访问外部接口）
+var lookupLeader = copytrade.ValidateLeader
+
+// validateCopyTradeLeaders 确认配置中的领航员存在（多领航员配置逐个校验，会请求数据源接口）
+func validateCopyTradeLeaders(providerType, leaderID string, leaders []store.CopyTradeLeaderSpec) []FieldError {
+	var errs []FieldError
+	if len(leaders) == 0 {
+		if err := lookupLeader(copytrade.ProviderType(providerType), leaderID); err != nil {
+			errs = append(errs, FieldError{Field: "leader_id", Message: leaderLookupMessage(leaderID, err)})
+		}
+		return errs
+	}
+	for i, l := range leaders {
+		if err := lookupLeader(copytrade.ProviderType(l.ProviderType), l.LeaderID); err != nil {
+			errs = append(errs, FieldError{
+				Field:   fmt.Sprintf("options.leaders[%d].leader_id", i),
+				Message: leaderLookupMessage(l.LeaderID, err),
+			})
+		}
+	}
+	return errs
+}
+
+// leaderLookupMessage 领航员校验失败的提示文案
+func leaderLookupMessage(leaderID string, err error) string {
+	if errors.Is(err, copytrade.ErrInvalidLeaderAddress) {
+		return fmt.Sprintf("leader_id %q is not a valid Hyperliquid address (0x followed by 40 hex characters)", leaderID)
+	}
+	return fmt.Sprintf("leader %q could not be resolved, check the address/uniqueName: %v", leaderID, err)
+}
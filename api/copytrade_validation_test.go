@@ -0,0 +1,370 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"nofx/copytrade"
+	"nofx/store"
+)
+
+func TestSaveCopyTradeConfigValidationErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantFields []string
+	}{
+		{
+			name:       "binding errors map to json field names",
+			body:       `{"provider_type":"binance","copy_ratio":0}`,
+			wantFields: []string{"provider_type", "leader_id", "copy_ratio"},
+		},
+		{
+			name:       "type mismatch",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":"1"}`,
+			wantFields: []string{"copy_ratio"},
+		},
+		{
+			name:       "cross-field constraints",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":20,"min_trade_warn":100,"max_trade_warn":10,"options":{"zero_equity_policy":"fixed_notional"}}`,
+			wantFields: []string{"copy_ratio", "max_trade_warn", "options.fixed_notional"},
+		},
+		{
+			name:       "trading windows",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"trading_windows":[{"start":"08:00","end":"20:00"},{"start":"25:00","end":"02:00","weekdays":[7]}],"timezone":"Mars/Olympus"}}`,
+			wantFields: []string{"options.trading_windows[1]", "options.timezone"},
+		},
+		{
+			name:       "limit entry",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"entry_order_type":"stop","limit_timeout_seconds":-1,"limit_timeout_action":"retry"}}`,
+			wantFields: []string{"options.entry_order_type", "options.limit_timeout_seconds", "options.limit_timeout_action"},
+		},
+		{
+			name:       "symbol max base size",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"symbol_max_base_size":{"BTCUSDT":0.5,"ETHUSDT":0,"SOLUSDT":-1}}}`,
+			wantFields: []string{"options.symbol_max_base_size.ETHUSDT", "options.symbol_max_base_size.SOLUSDT"},
+		},
+		{
+			name:       "invalid dedup scope",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"dedup_scope":"global"}}`,
+			wantFields: []string{"options.dedup_scope"},
+		},
+		{
+			name:       "invalid initial sync",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"initial_sync":"all"}}`,
+			wantFields: []string{"options.initial_sync"},
+		},
+		{
+			name:       "invalid reconnect reconcile",
+			body:       `{"provider_type":"hyperliquid","leader_id":"0xabc","copy_ratio":1,"options":{"reconnect_reconcile":"replay"}}`,
+			wantFields: []string{"options.reconnect_reconcile"},
+		},
+		{
+			name:       "invalid reduce min policy",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"reduce_min_policy":"skip"}}`,
+			wantFields: []string{"options.reduce_min_policy"},
+		},
+		{
+			name:       "negative volatility reference",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"volatility_leverage_scaling":true,"volatility_reference":-0.1}}`,
+			wantFields: []string{"options.volatility_reference"},
+		},
+		{
+			name:       "okx instrument map target not USDT",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"okx_instrument_map":{"BTC-USD-SWAP":"BTCUSDT","ETH-USD-SWAP":"ETHUSD"}}}`,
+			wantFields: []string{"options.okx_instrument_map.ETH-USD-SWAP"},
+		},
+		{
+			name:       "invalid empty state policy",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"empty_state_policy":"ignore"}}`,
+			wantFields: []string{"options.empty_state_policy"},
+		},
+		{
+			name:       "adaptive ratio bounds inverted",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"adaptive_ratio":{"min_multiplier":2,"max_multiplier":1,"sensitivity":-1}}}`,
+			wantFields: []string{"options.adaptive_ratio.sensitivity", "options.adaptive_ratio.max_multiplier"},
+		},
+		{
+			name:       "invalid close match strategy",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"close_match_strategy":"random"}}`,
+			wantFields: []string{"options.close_match_strategy"},
+		},
+		{
+			name:       "invalid add sizing mode",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"add_sizing_mode":"double"}}`,
+			wantFields: []string{"options.add_sizing_mode"},
+		},
+		{
+			name:       "negative health weight",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"health_weights":{"stale_max":-1}}}`,
+			wantFields: []string{"options.health_weights"},
+		},
+		{
+			name:       "invalid phantom close policy",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"phantom_close_policy":"skip"}}`,
+			wantFields: []string{"options.phantom_close_policy"},
+		},
+		{
+			name:       "invalid cross check threshold",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"cross_check_threshold":1.5}}`,
+			wantFields: []string{"options.cross_check_threshold"},
+		},
+		{
+			name:       "invalid margin cap",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"max_margin_fraction":2,"margin_cap_policy":"warn"}}`,
+			wantFields: []string{"options.max_margin_fraction", "options.margin_cap_policy"},
+		},
+		{
+			name:       "invalid protective stop",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"protective_stop_pct":1.5}}`,
+			wantFields: []string{"options.protective_stop_pct"},
+		},
+		{
+			name:       "invalid max slippage",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"max_slippage_pct":-0.01}}`,
+			wantFields: []string{"options.max_slippage_pct"},
+		},
+		{
+			name:       "invalid symbol toggle",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"symbol_enabled":{"btc":false}}}`,
+			wantFields: []string{"options.symbol_enabled"},
+		},
+		{
+			name:       "invalid decision stall",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"decision_stall_seconds":-1,"decision_stall_policy":"stop"}}`,
+			wantFields: []string{"options.decision_stall_seconds", "options.decision_stall_policy"},
+		},
+		{
+			name:       "negative leader orders poll",
+			body:       `{"provider_type":"hyperliquid","leader_id":"0xabc","copy_ratio":1,"options":{"mirror_leader_orders":true,"leader_orders_poll_seconds":-5}}`,
+			wantFields: []string{"options.leader_orders_poll_seconds"},
+		},
+		{
+			name:       "negative poll interval",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"poll_interval_seconds":-3}}`,
+			wantFields: []string{"options.poll_interval_seconds"},
+		},
+		{
+			name:       "fixed copy mode without amount",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"copy_mode":"fixed"}}`,
+			wantFields: []string{"options.fixed_trade_usd"},
+		},
+		{
+			name:       "unknown copy mode",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"copy_mode":"flat"}}`,
+			wantFields: []string{"options.copy_mode"},
+		},
+		{
+			name:       "invalid whitelist symbol",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"symbol_whitelist":["btc","usdt"]}}`,
+			wantFields: []string{"options.symbol_whitelist"},
+		},
+		{
+			name:       "negative max leverage",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"max_leverage":-5}}`,
+			wantFields: []string{"options.max_leverage"},
+		},
+		{
+			name:       "copy delay out of range",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"copy_delay_seconds":600}}`,
+			wantFields: []string{"options.copy_delay_seconds"},
+		},
+		{
+			name:       "negative max open positions",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"max_open_positions":-1}}`,
+			wantFields: []string{"options.max_open_positions"},
+		},
+		{
+			name:       "inverse with uncapped synced leverage",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"sync_leverage":true,"options":{"inverse":true,"max_leverage":50}}`,
+			wantFields: []string{"options.max_leverage"},
+		},
+		{
+			name:       "leaders",
+			body:       `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"leaders":[{"leader_id":"","provider_type":"hyperliquid"},{"leader_id":"x","provider_type":"foo","weight":-1},{"leader_id":"x","provider_type":"foo"}]}}`,
+			wantFields: []string{"options.leaders[0].leader_id", "options.leaders[1].provider_type", "options.leaders[1].weight", "options.leaders[2].leader_id"},
+		},
+	}
+
+	h := NewCopyTradeHandler(nil, nil)
+	router := gin.New()
+	router.POST("/config/:trader_id", h.SaveConfig)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/config/t1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", w.Code)
+			}
+
+			var resp struct {
+				Errors []FieldError `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]string)
+			for _, fe := range resp.Errors {
+				got[fe.Field] = fe.Message
+			}
+			for _, field := range tt.wantFields {
+				if got[field] == "" {
+					t.Errorf("missing error for %s, got %+v", field, resp.Errors)
+				}
+			}
+		})
+	}
+}
+
+// TestSaveCopyTradeConfigLeaderNotFound 领航员无法解析时返回 400 并指出对应字段
+func TestSaveCopyTradeConfigLeaderNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	original := lookupLeader
+	t.Cleanup(func() { lookupLeader = original })
+	lookupLeader = func(providerType copytrade.ProviderType, leaderID string) error {
+		if providerType == copytrade.ProviderHyperliquid {
+			return copytrade.ValidateHLAddress(leaderID) // 只校验格式，不访问接口
+		}
+		if leaderID == "ghost" {
+			return copytrade.ErrLeaderNotFound
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name      string
+		body      string
+		wantField string
+	}{
+		{
+			name:      "okx unknown uniqueName",
+			body:      `{"provider_type":"okx","leader_id":"ghost","copy_ratio":1}`,
+			wantField: "leader_id",
+		},
+		{
+			name:      "hyperliquid malformed address",
+			body:      `{"provider_type":"hyperliquid","leader_id":"0x123","copy_ratio":1}`,
+			wantField: "leader_id",
+		},
+		{
+			name:      "multi leader",
+			body:      `{"provider_type":"okx","leader_id":"abc","copy_ratio":1,"options":{"leaders":[{"leader_id":"abc","provider_type":"okx"},{"leader_id":"ghost","provider_type":"okx"}]}}`,
+			wantField: "options.leaders[1].leader_id",
+		},
+	}
+
+	h := NewCopyTradeHandler(nil, nil)
+	router := gin.New()
+	router.POST("/config/:trader_id", h.SaveConfig)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/config/t1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", w.Code)
+			}
+			var resp struct {
+				Error  string       `json:"error"`
+				Errors []FieldError `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Error != "leader not found" || len(resp.Errors) != 1 || resp.Errors[0].Field != tt.wantField {
+				t.Errorf("resp = %+v, want single error on %s", resp, tt.wantField)
+			}
+		})
+	}
+}
+
+// TestSaveCopyTradeConfigPartial 只提交核心字段时保留已保存的 enabled 和高级选项
+func TestSaveCopyTradeConfigPartial(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	original := lookupLeader
+	t.Cleanup(func() { lookupLeader = original })
+	lookupLeader = func(copytrade.ProviderType, string) error { return nil }
+
+	st, err := store.New(filepath.Join(t.TempDir(), "copytrade.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+	if _, err := st.DB().Exec(`INSERT INTO traders (id, name, ai_model_id, exchange_id, initial_balance) VALUES ('t1', 'T', 'deepseek', 'binance', 1000)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.CopyTrade().Upsert(&store.CopyTradeConfig{
+		TraderID: "t1", ProviderType: "okx", LeaderID: "abc", CopyRatio: 1, Enabled: true,
+		Options: store.CopyTradeOptions{LeaderBudget: 500, Inverse: true, SymbolEnabled: map[string]bool{"BTC": false}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewCopyTradeHandler(st, nil)
+	router := gin.New()
+	router.POST("/config/:trader_id", h.SaveConfig)
+	save := func(body string) *store.CopyTradeConfig {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/config/t1", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+		}
+		saved, err := st.CopyTrade().GetByTraderID("t1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return saved
+	}
+
+	saved := save(`{"provider_type":"okx","leader_id":"abc","copy_ratio":0.5}`)
+	if saved.CopyRatio != 0.5 || !saved.Enabled {
+		t.Errorf("core fields = ratio %v enabled %v, want 0.5 / kept true", saved.CopyRatio, saved.Enabled)
+	}
+	if saved.Options.LeaderBudget != 500 || !saved.Options.Inverse || saved.Options.SymbolEnabled["BTC"] {
+		t.Errorf("options not kept: %+v", saved.Options)
+	}
+
+	// 显式提交时覆盖
+	saved = save(`{"provider_type":"okx","leader_id":"abc","copy_ratio":0.5,"enabled":false,"options":{"leader_budget":100}}`)
+	if saved.Enabled || saved.Options.LeaderBudget != 100 || saved.Options.Inverse || len(saved.Options.SymbolEnabled) != 0 {
+		t.Errorf("explicit save = enabled %v options %+v", saved.Enabled, saved.Options)
+	}
+
+	// 存在活跃映射时切换反向跟单：拒绝保存
+	if err := st.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+		TraderID: "t1", LeaderPosID: "p1", LeaderID: "abc", Symbol: "BTCUSDT", Side: "long", OpenedAt: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/config/t1", strings.NewReader(`{"provider_type":"okx","leader_id":"abc","copy_ratio":0.5,"options":{"inverse":true}}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("inverse toggle with active mapping: status = %d, want 409", w.Code)
+	}
+	if saved, _ := st.CopyTrade().GetByTraderID("t1"); saved.Options.Inverse || saved.Options.LeaderBudget != 100 {
+		t.Errorf("config changed by rejected save: %+v", saved.Options)
+	}
+}
@@ -2,14 +2,22 @@ package api
 
 import (
 	"database/sql"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"nofx/config"
+	"nofx/copytrade"
 	"nofx/logger"
+	"nofx/store"
 )
 
 // ========== 缓存结构 ==========
@@ -17,29 +25,49 @@ import (
 // dashboardCache 大屏数据缓存
 type dashboardCache struct {
 	sync.RWMutex
-	summary        *DashboardSummary
-	summaryTime    time.Time
-	traders        []TraderDashboardStats
-	tradersTime    time.Time
-	cacheDuration  time.Duration
+	summary     *DashboardSummary
+	summaryTime time.Time
+	traders     []TraderDashboardStats
+	tradersTime time.Time
+	symbols     map[string]symbolStatsCacheEntry // trader_id|range → 按币种统计
 }
 
-// 全局缓存实例
-var dbCache = &dashboardCache{
-	cacheDuration: 30 * time.Second, // 30秒缓存
+// symbolStatsCacheEntry 按币种盈亏统计缓存
+type symbolStatsCacheEntry struct {
+	stats []SymbolPnLStats
+	at    time.Time
+}
+
+// 全局缓存实例（缓存时长取自配置，默认 30 秒）
+var dbCache = &dashboardCache{}
+
+// ttl 缓存时长（DASHBOARD_CACHE_SECONDS，0 = 不缓存）
+func (c *dashboardCache) ttl() time.Duration {
+	return time.Duration(config.Get().DashboardCacheSeconds) * time.Second
+}
+
+// invalidate 清空全部缓存，下次请求重新查询
+func (c *dashboardCache) invalidate() {
+	c.Lock()
+	defer c.Unlock()
+	c.summary = nil
+	c.summaryTime = time.Time{}
+	c.traders = nil
+	c.tradersTime = time.Time{}
+	c.symbols = nil
 }
 
 // isCacheValid 检查缓存是否有效
 func (c *dashboardCache) isSummaryValid() bool {
 	c.RLock()
 	defer c.RUnlock()
-	return c.summary != nil && time.Since(c.summaryTime) < c.cacheDuration
+	return c.summary != nil && time.Since(c.summaryTime) < c.ttl()
 }
 
 func (c *dashboardCache) isTradersValid() bool {
 	c.RLock()
 	defer c.RUnlock()
-	return c.traders != nil && time.Since(c.tradersTime) < c.cacheDuration
+	return c.traders != nil && time.Since(c.tradersTime) < c.ttl()
 }
 
 // getSummary 获取缓存的汇总数据
@@ -72,6 +100,32 @@ func (c *dashboardCache) setTraders(t []TraderDashboardStats) {
 	c.tradersTime = time.Now()
 }
 
+// getSymbols 获取缓存的按币种统计（未缓存或已过期时返回 false）
+func (c *dashboardCache) getSymbols(key string) ([]SymbolPnLStats, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	entry, ok := c.symbols[key]
+	if !ok || time.Since(entry.at) >= c.ttl() {
+		return nil, false
+	}
+	return entry.stats, true
+}
+
+// setSymbols 设置按币种统计缓存（顺带清理过期条目）
+func (c *dashboardCache) setSymbols(key string, stats []SymbolPnLStats) {
+	c.Lock()
+	defer c.Unlock()
+	if c.symbols == nil {
+		c.symbols = make(map[string]symbolStatsCacheEntry)
+	}
+	for k, entry := range c.symbols {
+		if time.Since(entry.at) >= c.ttl() {
+			delete(c.symbols, k)
+		}
+	}
+	c.symbols[key] = symbolStatsCacheEntry{stats: stats, at: time.Now()}
+}
+
 // ========== 数据结构 ==========
 
 // DashboardSummary 全局汇总统计
@@ -112,6 +166,8 @@ type TraderDashboardStats struct {
 	LossTrades     int     `json:"loss_trades"`
 	ProfitFactor   float64 `json:"profit_factor"`   // 盈亏比
 	MaxDrawdown    float64 `json:"max_drawdown"`    // 最大回撤 %
+	SharpeRatio    float64 `json:"sharpe_ratio"`    // 年化夏普比率（按日盈亏）
+	SortinoRatio   float64 `json:"sortino_ratio"`   // 年化索提诺比率（按日盈亏）
 	TotalFees      float64 `json:"total_fees"`      // 总手续费
 	
 	// 当前状态
@@ -136,6 +192,7 @@ type SystemMonitor struct {
 	TodayExecuted   int     `json:"today_executed"`    // 执行成功
 	TodaySkipped    int     `json:"today_skipped"`     // 跳过
 	TodayFailed     int     `json:"today_failed"`      // 失败
+	TodayDryRun     int     `json:"today_dry_run"`     // 模拟运行（dry run，不计入执行率）
 	ExecutionRate   float64 `json:"execution_rate"`    // 执行率 %
 	
 	// API 错误统计 (最近24小时)
@@ -156,7 +213,7 @@ type SystemMonitor struct {
 // RiskAlert 风险预警
 type RiskAlert struct {
 	Level      string  `json:"level"`       // critical | warning | info
-	Type       string  `json:"type"`        // consecutive_loss | max_drawdown | api_error | low_win_rate
+	Type       string  `json:"type"`        // consecutive_loss | max_drawdown | api_error | low_win_rate | mapping_drift | copytrade_degraded | state_divergence | decision_stall
 	TraderID   string  `json:"trader_id"`
 	TraderName string  `json:"trader_name"`
 	Message    string  `json:"message"`
@@ -164,6 +221,90 @@ type RiskAlert struct {
 	Timestamp  string  `json:"timestamp"`
 }
 
+// CopyTradeDashboardStats 跟单独立统计（只含跟单仓位，不混入 AI 交易）
+type CopyTradeDashboardStats struct {
+	TraderID           string                        `json:"trader_id"`
+	ActivePositions    int                           `json:"active_positions"`
+	ClosedPositions    int                           `json:"closed_positions"`
+	WinRate            float64                       `json:"win_rate"`
+	RealizedPnL        float64                       `json:"realized_pnl"`
+	EstimatedPositions int                           `json:"estimated_positions"` // 盈亏含估算成交的已平仓仓位数
+	Leaders            []*store.CopyTradeLeaderStats `json:"leaders"`             // 按领航员拆分
+	// 按领航员的已实现收益率分布（领航员 ClosedPnL / 开仓价值）
+	LeaderReturns []*store.CopyTradeLeaderReturnDistribution `json:"leader_returns"`
+	UpdatedAt     string                                     `json:"updated_at"`
+}
+
+// SymbolPnLStats 交易员按币种的已平仓盈亏统计
+type SymbolPnLStats struct {
+	Symbol         string  `json:"symbol"`
+	Trades         int     `json:"trades"`
+	WinRate        float64 `json:"win_rate"` // 百分比
+	TotalPnL       float64 `json:"total_pnl"`
+	TotalFees      float64 `json:"total_fees"`
+	AvgHoldMinutes float64 `json:"avg_hold_minutes"`
+}
+
+// 净值曲线粒度
+const (
+	EquityGranularityAuto   = "auto"
+	EquityGranularityRaw    = "raw"
+	EquityGranularityMinute = "minute"
+	EquityGranularityHour   = "hour"
+	EquityGranularityDay    = "day"
+)
+
+const (
+	// maxEquityCurvePoints auto 粒度下返回点数的上限，超过则逐级放大分桶
+	maxEquityCurvePoints = 500
+	// defaultEquityCurveRange 未指定 from 时默认回看的时间范围
+	defaultEquityCurveRange = 7 * 24 * time.Hour
+)
+
+// EquityCurvePoint 净值曲线上的一个点（分桶时取桶内最后一条快照）
+type EquityCurvePoint struct {
+	Time          string  `json:"time"`
+	Equity        float64 `json:"equity"`
+	Balance       float64 `json:"balance"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+	PositionCount int     `json:"position_count"`
+}
+
+// EquityCurve 净值曲线导出结果
+type EquityCurve struct {
+	TraderID       string             `json:"trader_id"`
+	From           string             `json:"from"`
+	To             string             `json:"to"`
+	Granularity    string             `json:"granularity"` // 实际使用的粒度（auto 会被解析为具体值）
+	RawCount       int                `json:"raw_count"`   // 范围内原始快照数
+	StartEquity    float64            `json:"start_equity"`
+	EndEquity      float64            `json:"end_equity"`
+	ReturnPct      float64            `json:"return_pct"`
+	MaxDrawdownPct float64            `json:"max_drawdown_pct"` // 基于原始快照计算，不受降采样影响
+	Points         []EquityCurvePoint `json:"points"`
+}
+
+// ClosedPositionExport 已平仓记录导出行
+type ClosedPositionExport struct {
+	Symbol      string  `json:"symbol"`
+	Side        string  `json:"side"`
+	EntryPrice  float64 `json:"entry_price"`
+	ExitPrice   float64 `json:"exit_price"`
+	RealizedPnL float64 `json:"realized_pnl"`
+	Fee         float64 `json:"fee"`
+	EntryTime   string  `json:"entry_time"` // RFC3339
+	ExitTime    string  `json:"exit_time"`  // RFC3339
+}
+
+// closedPositionCSVHeader CSV 表头（与 ClosedPositionExport 的 json 字段一致）
+var closedPositionCSVHeader = []string{"symbol", "side", "entry_price", "exit_price", "realized_pnl", "fee", "entry_time", "exit_time"}
+
+// csvRecord 转换为 CSV 行
+func (p *ClosedPositionExport) csvRecord() []string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return []string{p.Symbol, p.Side, f(p.EntryPrice), f(p.ExitPrice), f(p.RealizedPnL), f(p.Fee), p.EntryTime, p.ExitTime}
+}
+
 // ========== 辅助函数 ==========
 
 // getTimeRangeStart 获取时间范围起始时间
@@ -186,6 +327,62 @@ func getTimeRangeStart(timeRange string) time.Time {
 	}
 }
 
+// equitySnapshotLayouts 快照时间戳可能的格式（EquityStore 写 RFC3339，历史迁移数据为本地时间）
+var equitySnapshotLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05-07:00",
+}
+
+// parseEquitySnapshotTime 解析快照时间戳，无时区信息的按本地时间处理
+func parseEquitySnapshotTime(s string) (time.Time, bool) {
+	for _, layout := range equitySnapshotLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseEquityCurveTime 解析查询参数中的时间（RFC3339 / 日期时间 / 日期）
+func parseEquityCurveTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无效的时间格式: %s（支持 RFC3339 或 2006-01-02）", s)
+}
+
+// equityBucketStart 返回 t 所在桶的起点，raw 粒度返回 t 本身
+func equityBucketStart(t time.Time, granularity string) time.Time {
+	switch granularity {
+	case EquityGranularityMinute:
+		return t.Truncate(time.Minute)
+	case EquityGranularityHour:
+		return t.Truncate(time.Hour)
+	case EquityGranularityDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	default:
+		return t
+	}
+}
+
+// resolveEquityGranularity auto 粒度：选择能让点数不超过上限的最细粒度
+func resolveEquityGranularity(rawCount int, span time.Duration) string {
+	if rawCount <= maxEquityCurvePoints {
+		return EquityGranularityRaw
+	}
+	if span/time.Minute <= maxEquityCurvePoints {
+		return EquityGranularityMinute
+	}
+	if span/time.Hour <= maxEquityCurvePoints {
+		return EquityGranularityHour
+	}
+	return EquityGranularityDay
+}
+
 // ========== 数据查询 ==========
 
 // getDashboardSummary 获取全局汇总统计
@@ -194,7 +391,7 @@ func (s *Server) getDashboardSummary() (*DashboardSummary, error) {
 		UpdatedAt: time.Now().Format("2006-01-02 15:04:05"),
 	}
 	
-	db := s.store.DB()
+	db := s.store.ReadDB()
 	
 	// 全局统计
 	err := db.QueryRow(`
@@ -278,29 +475,16 @@ func (s *Server) getTraderDashboardStats(traderID string) (*TraderDashboardStats
 		TraderID: traderID,
 	}
 	
-	db := s.store.DB()
+	db := s.store.ReadDB()
 	
 	// 获取交易员基本信息
 	var name, exchange, decisionMode, aiModel sql.NullString
 	var initialBalance sql.NullFloat64
 	err := db.QueryRow(`
-		SELECT name, exchange, decision_mode, initial_balance, ai_model FROM traders WHERE id = ?
+		SELECT name, exchange_id, COALESCE(decision_mode, ''), initial_balance, ai_model_id FROM traders WHERE id = ?
 	`, traderID).Scan(&name, &exchange, &decisionMode, &initialBalance, &aiModel)
 	if err == nil {
-		// 优先使用 name，如果为空则尝试构建友好名称
-		if name.String != "" {
-			stats.TraderName = name.String
-		} else {
-			// 尝试用 ai_model + exchange 构建名称，如 "DEEPSEEK + HYPERLIQUID"
-			if aiModel.String != "" && exchange.String != "" {
-				stats.TraderName = aiModel.String + " + " + exchange.String
-			} else if len(traderID) >= 8 {
-				// 最后使用 trader_id 前8位
-				stats.TraderName = traderID[:8]
-			} else {
-				stats.TraderName = traderID
-			}
-		}
+		stats.TraderName = dashboardTraderName(traderID, name.String, aiModel.String, exchange.String)
 		stats.Exchange = exchange.String
 		stats.Mode = decisionMode.String
 		if stats.Mode == "" {
@@ -398,13 +582,24 @@ func (s *Server) getTraderDashboardStats(traderID string) (*TraderDashboardStats
 	
 	// 计算最大回撤（简化版：使用累计 PnL）
 	stats.MaxDrawdown = s.calculateMaxDrawdown(traderID)
+
+	// 风险调整收益（按日盈亏）
+	if trend, err := s.getPnLTrend(traderID, 0); err != nil {
+		logger.Warnf("Dashboard: 查询日盈亏失败: %v", err)
+	} else {
+		daily := make([]float64, len(trend))
+		for i, p := range trend {
+			daily[i] = p.PnL
+		}
+		stats.SharpeRatio, stats.SortinoRatio = riskAdjustedRatios(daily, stats.InitialBalance, config.Get().DashboardRiskFreeRate)
+	}
 	
 	return stats, nil
 }
 
 // calculateMaxDrawdown 计算最大回撤
 func (s *Server) calculateMaxDrawdown(traderID string) float64 {
-	db := s.store.DB()
+	db := s.store.ReadDB()
 	
 	rows, err := db.Query(`
 		SELECT realized_pnl FROM trader_positions
@@ -416,12 +611,21 @@ func (s *Server) calculateMaxDrawdown(traderID string) float64 {
 	}
 	defer rows.Close()
 	
-	var cumPnL, peak, maxDrawdown float64
+	var pnls []float64
 	for rows.Next() {
 		var pnl float64
 		if err := rows.Scan(&pnl); err != nil {
 			continue
 		}
+		pnls = append(pnls, pnl)
+	}
+	return maxDrawdownPct(pnls)
+}
+
+// maxDrawdownPct 按平仓顺序的已实现盈亏计算最大回撤 %（简化版：使用累计 PnL）
+func maxDrawdownPct(pnls []float64) float64 {
+	var cumPnL, peak, maxDrawdown float64
+	for _, pnl := range pnls {
 		cumPnL += pnl
 		if cumPnL > peak {
 			peak = cumPnL
@@ -438,35 +642,263 @@ func (s *Server) calculateMaxDrawdown(traderID string) float64 {
 	return 0
 }
 
+// tradingDaysPerYear 年化天数（加密货币全年交易）
+const tradingDaysPerYear = 365
+
+// riskAdjustedRatios 按日盈亏序列计算年化夏普/索提诺比率
+// 日收益率 = 日盈亏 / 初始资金（初始资金未知时直接使用日盈亏，无风险利率按 0 处理）；
+// 少于 2 个数据点、波动率或下行偏差为 0 时返回 0，保证 JSON 中不出现 NaN/Inf
+func riskAdjustedRatios(dailyPnL []float64, initialBalance, annualRiskFree float64) (sharpe, sortino float64) {
+	n := len(dailyPnL)
+	if n < 2 {
+		return 0, 0
+	}
+
+	dailyRiskFree := 0.0
+	if initialBalance > 0 {
+		dailyRiskFree = annualRiskFree / tradingDaysPerYear
+	}
+	excess := make([]float64, n)
+	var mean float64
+	for i, pnl := range dailyPnL {
+		r := pnl
+		if initialBalance > 0 {
+			r = pnl / initialBalance
+		}
+		excess[i] = r - dailyRiskFree
+		mean += excess[i]
+	}
+	mean /= float64(n)
+
+	var variance, downside float64
+	for _, r := range excess {
+		variance += (r - mean) * (r - mean)
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	annualize := math.Sqrt(tradingDaysPerYear)
+	if std := math.Sqrt(variance / float64(n-1)); std > 0 {
+		sharpe = mean / std * annualize
+	}
+	if dd := math.Sqrt(downside / float64(n)); dd > 0 {
+		sortino = mean / dd * annualize
+	}
+	return sharpe, sortino
+}
+
+// dashboardTraderName 交易员展示名称：优先 name，否则 ai_model + exchange，最后 trader_id 前8位
+func dashboardTraderName(traderID, name, aiModel, exchange string) string {
+	if name != "" {
+		return name
+	}
+	if aiModel != "" && exchange != "" {
+		return aiModel + " + " + exchange
+	}
+	if len(traderID) >= 8 {
+		return traderID[:8]
+	}
+	return traderID
+}
+
 // getAllTradersDashboardStats 获取所有交易员统计
+// 使用按 trader_id 分组的集合查询（固定 5 条 SQL），不随交易员数量增加
+// 单个交易员详情仍使用 getTraderDashboardStats
 func (s *Server) getAllTradersDashboardStats() ([]TraderDashboardStats, error) {
-	db := s.store.DB()
+	db := s.store.ReadDB()
 	
-	// 获取所有交易员 ID
-	rows, err := db.Query(`SELECT DISTINCT id FROM traders`)
+	// 1. 交易员基本信息
+	rows, err := db.Query(`
+		SELECT id, name, exchange_id, COALESCE(decision_mode, ''), initial_balance, ai_model_id
+		FROM traders ORDER BY created_at ASC
+	`)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	
-	var traderIDs []string
+	var result []TraderDashboardStats
+	index := make(map[string]int)
 	for rows.Next() {
 		var id string
-		if err := rows.Scan(&id); err != nil {
+		var name, exchange, decisionMode, aiModel sql.NullString
+		var initialBalance sql.NullFloat64
+		if err := rows.Scan(&id, &name, &exchange, &decisionMode, &initialBalance, &aiModel); err != nil {
 			continue
 		}
-		traderIDs = append(traderIDs, id)
+		if _, exists := index[id]; exists {
+			continue
+		}
+		stats := TraderDashboardStats{
+			TraderID:       id,
+			TraderName:     dashboardTraderName(id, name.String, aiModel.String, exchange.String),
+			Exchange:       exchange.String,
+			Mode:           decisionMode.String,
+			InitialBalance: initialBalance.Float64,
+		}
+		if stats.Mode == "" {
+			stats.Mode = "ai"
+		}
+		stats.IsRunning = s.isTraderRunningWithMode(id, decisionMode.String)
+		index[id] = len(result)
+		result = append(result, stats)
+	}
+	rows.Close()
+	
+	// 2. 已平仓统计（全部 + 今日/本周/本月）
+	todayStr := getTimeRangeStart("today").Format("2006-01-02 15:04:05")
+	weekStr := getTimeRangeStart("week").Format("2006-01-02 15:04:05")
+	monthStr := getTimeRangeStart("month").Format("2006-01-02 15:04:05")
+	totalWin := make(map[string]float64)
+	totalLoss := make(map[string]float64)
+	rows, err = db.Query(`
+		SELECT 
+			trader_id,
+			COALESCE(SUM(realized_pnl), 0),
+			COALESCE(SUM(fee), 0),
+			COUNT(*),
+			COALESCE(SUM(CASE WHEN realized_pnl > 0 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN realized_pnl < 0 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN realized_pnl > 0 THEN realized_pnl ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN realized_pnl < 0 THEN ABS(realized_pnl) ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN exit_time >= ? THEN realized_pnl ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN exit_time >= ? THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN exit_time >= ? THEN realized_pnl ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN exit_time >= ? THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN exit_time >= ? THEN realized_pnl ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN exit_time >= ? THEN 1 ELSE 0 END), 0)
+		FROM trader_positions
+		WHERE status = 'CLOSED'
+		GROUP BY trader_id
+	`, todayStr, todayStr, weekStr, weekStr, monthStr, monthStr)
+	if err != nil {
+		logger.Warnf("Dashboard: 查询交易员统计失败: %v", err)
+	} else {
+		for rows.Next() {
+			var id string
+			var st TraderDashboardStats
+			var win, loss float64
+			if err := rows.Scan(&id, &st.TotalPnL, &st.TotalFees, &st.TotalTrades,
+				&st.WinTrades, &st.LossTrades, &win, &loss,
+				&st.TodayPnL, &st.TodayTrades, &st.WeekPnL, &st.WeekTrades, &st.MonthPnL, &st.MonthTrades); err != nil {
+				continue
+			}
+			i, ok := index[id]
+			if !ok {
+				continue
+			}
+			stats := &result[i]
+			stats.TotalPnL, stats.TotalFees, stats.TotalTrades = st.TotalPnL, st.TotalFees, st.TotalTrades
+			stats.WinTrades, stats.LossTrades = st.WinTrades, st.LossTrades
+			stats.TodayPnL, stats.TodayTrades = st.TodayPnL, st.TodayTrades
+			stats.WeekPnL, stats.WeekTrades = st.WeekPnL, st.WeekTrades
+			stats.MonthPnL, stats.MonthTrades = st.MonthPnL, st.MonthTrades
+			totalWin[id], totalLoss[id] = win, loss
+		}
+		rows.Close()
 	}
 	
-	// 获取每个交易员的统计
-	var result []TraderDashboardStats
-	for _, id := range traderIDs {
-		stats, err := s.getTraderDashboardStats(id)
-		if err != nil {
-			logger.Warnf("Dashboard: 获取交易员 %s 统计失败: %v", id, err)
-			continue
+	// 3. 当前持仓数
+	rows, err = db.Query(`
+		SELECT trader_id, COUNT(*) FROM trader_positions WHERE status = 'OPEN' GROUP BY trader_id
+	`)
+	if err != nil {
+		logger.Warnf("Dashboard: 查询持仓数失败: %v", err)
+	} else {
+		for rows.Next() {
+			var id string
+			var count int
+			if rows.Scan(&id, &count) == nil {
+				if i, ok := index[id]; ok {
+					result[i].PositionCount = count
+				}
+			}
+		}
+		rows.Close()
+	}
+	
+	// 4. 当前净值（每个交易员最新快照）
+	rows, err = db.Query(`
+		SELECT trader_id, total_equity FROM (
+			SELECT trader_id, total_equity,
+				ROW_NUMBER() OVER (PARTITION BY trader_id ORDER BY timestamp DESC) AS rn
+			FROM trader_equity_snapshots
+		) WHERE rn = 1
+	`)
+	if err != nil {
+		logger.Warnf("Dashboard: 查询净值失败: %v", err)
+	} else {
+		for rows.Next() {
+			var id string
+			var equity float64
+			if rows.Scan(&id, &equity) == nil {
+				if i, ok := index[id]; ok {
+					result[i].CurrentEquity = equity
+				}
+			}
+		}
+		rows.Close()
+	}
+	
+	// 5. 最大回撤：一次扫描所有已平仓记录，按交易员分别累计
+	rows, err = db.Query(`
+		SELECT trader_id, realized_pnl FROM trader_positions
+		WHERE status = 'CLOSED'
+		ORDER BY trader_id, exit_time ASC
+	`)
+	if err != nil {
+		logger.Warnf("Dashboard: 查询回撤数据失败: %v", err)
+	} else {
+		pnls := make(map[string][]float64)
+		for rows.Next() {
+			var id string
+			var pnl float64
+			if rows.Scan(&id, &pnl) == nil {
+				pnls[id] = append(pnls[id], pnl)
+			}
+		}
+		rows.Close()
+		for id, series := range pnls {
+			if i, ok := index[id]; ok {
+				result[i].MaxDrawdown = maxDrawdownPct(series)
+			}
+		}
+	}
+	
+	// 6. 日盈亏（夏普/索提诺）：按交易员、日期聚合，一次查询
+	dailyPnL := make(map[string][]float64)
+	rows, err = db.Query(`
+		SELECT trader_id, COALESCE(SUM(realized_pnl), 0)
+		FROM trader_positions
+		WHERE status = 'CLOSED'
+		GROUP BY trader_id, DATE(exit_time)
+		ORDER BY trader_id, DATE(exit_time) ASC
+	`)
+	if err != nil {
+		logger.Warnf("Dashboard: 查询日盈亏失败: %v", err)
+	} else {
+		for rows.Next() {
+			var id string
+			var pnl float64
+			if rows.Scan(&id, &pnl) == nil {
+				dailyPnL[id] = append(dailyPnL[id], pnl)
+			}
 		}
-		result = append(result, *stats)
+		rows.Close()
+	}
+	riskFree := config.Get().DashboardRiskFreeRate
+
+	// 派生指标
+	for i := range result {
+		stats := &result[i]
+		if stats.TotalTrades > 0 {
+			stats.WinRate = float64(stats.WinTrades) / float64(stats.TotalTrades) * 100
+		}
+		if loss := totalLoss[stats.TraderID]; loss > 0 {
+			stats.ProfitFactor = totalWin[stats.TraderID] / loss
+		}
+		if stats.InitialBalance > 0 {
+			stats.ReturnRate = (stats.CurrentEquity - stats.InitialBalance) / stats.InitialBalance * 100
+		}
+		stats.SharpeRatio, stats.SortinoRatio = riskAdjustedRatios(dailyPnL[stats.TraderID], stats.InitialBalance, riskFree)
 	}
 	
 	return result, nil
@@ -479,37 +911,26 @@ func (s *Server) getSystemMonitor() (*SystemMonitor, error) {
 		Alerts:    []RiskAlert{},
 	}
 	
-	db := s.store.DB()
+	db := s.store.ReadDB()
 	todayStart := getTimeRangeStart("today")
-	todayStr := todayStart.Format("2006-01-02 15:04:05")
 	
 	// ========== 跟单信号统计 (今日) ==========
-	// 总信号数
-	db.QueryRow(`
-		SELECT COUNT(*) FROM copy_trade_signal_logs WHERE created_at >= ?
-	`, todayStr).Scan(&monitor.TodaySignals)
-	
-	// 执行成功
-	db.QueryRow(`
-		SELECT COUNT(*) FROM copy_trade_signal_logs 
-		WHERE created_at >= ? AND status = 'executed'
-	`, todayStr).Scan(&monitor.TodayExecuted)
-	
-	// 跳过
-	db.QueryRow(`
-		SELECT COUNT(*) FROM copy_trade_signal_logs 
-		WHERE created_at >= ? AND status = 'skipped'
-	`, todayStr).Scan(&monitor.TodaySkipped)
-	
-	// 失败
-	db.QueryRow(`
-		SELECT COUNT(*) FROM copy_trade_signal_logs 
-		WHERE created_at >= ? AND status = 'failed'
-	`, todayStr).Scan(&monitor.TodayFailed)
-	
-	// 执行率
-	if monitor.TodaySignals > 0 {
-		monitor.ExecutionRate = float64(monitor.TodayExecuted) / float64(monitor.TodaySignals) * 100
+	// 原始日志 + 已压缩的小时汇总，原始日志被清理后计数依然准确
+	if counts, err := s.store.CopyTrade().CountSignalsByStatus(todayStart); err != nil {
+		logger.Warnf("Dashboard: 统计今日信号失败: %v", err)
+	} else {
+		for _, n := range counts {
+			monitor.TodaySignals += n
+		}
+		monitor.TodayExecuted = counts["executed"]
+		monitor.TodaySkipped = counts["skipped"]
+		monitor.TodayFailed = counts["failed"]
+		monitor.TodayDryRun = counts[copytrade.SignalStatusDryRun]
+	}
+	
+	// 执行率（模拟运行的信号不参与）
+	if live := monitor.TodaySignals - monitor.TodayDryRun; live > 0 {
+		monitor.ExecutionRate = float64(monitor.TodayExecuted) / float64(live) * 100
 	}
 	
 	// ========== API 错误统计 (最近24小时) ==========
@@ -578,24 +999,33 @@ func (s *Server) getSystemMonitor() (*SystemMonitor, error) {
 // calculateRiskAlerts 计算风险预警
 func (s *Server) calculateRiskAlerts() []RiskAlert {
 	var alerts []RiskAlert
-	db := s.store.DB()
+	db := s.store.ReadDB()
 	
-	// 获取所有交易员
-	rows, err := db.Query(`SELECT DISTINCT id FROM traders`)
+	// 获取所有交易员及其预警配置
+	rows, err := db.Query(`SELECT id, COALESCE(alert_config, '') FROM traders`)
 	if err != nil {
 		return alerts
 	}
 	defer rows.Close()
 	
 	var traderIDs []string
+	alertConfigs := make(map[string]store.TraderAlertConfig)
 	for rows.Next() {
-		var id string
-		if rows.Scan(&id) == nil {
-			traderIDs = append(traderIDs, id)
+		var id, rawConfig string
+		if rows.Scan(&id, &rawConfig) != nil {
+			continue
 		}
+		cfg, err := store.ParseTraderAlertConfig(rawConfig)
+		if err != nil {
+			logger.Warnf("⚠️ 交易员 %s 预警配置解析失败，使用默认阈值: %v", id, err)
+		}
+		traderIDs = append(traderIDs, id)
+		alertConfigs[id] = cfg
 	}
+	last1h := time.Now().Add(-1 * time.Hour).Format("2006-01-02 15:04:05")
 	
 	for _, traderID := range traderIDs {
+		alertCfg := alertConfigs[traderID]
 		// 获取交易员名称
 		var traderName string
 		var name, aiModel, exchange sql.NullString
@@ -610,21 +1040,27 @@ func (s *Server) calculateRiskAlerts() []RiskAlert {
 			traderName = traderID
 		}
 		
-		// 1. 检查连续亏损 (最近5笔交易)
+		// 1. 检查连续亏损 (最近 N 笔交易，N 取 warning/critical 阈值较大者)
+		lossWindow := alertCfg.ConsecutiveLossCritical
+		if alertCfg.ConsecutiveLossWarning > lossWindow {
+			lossWindow = alertCfg.ConsecutiveLossWarning
+		}
 		recentPnLs := []float64{}
-		pnlRows, err := db.Query(`
-			SELECT realized_pnl FROM trader_positions 
-			WHERE trader_id = ? AND status = 'CLOSED'
-			ORDER BY exit_time DESC LIMIT 5
-		`, traderID)
-		if err == nil {
-			for pnlRows.Next() {
-				var pnl float64
-				if pnlRows.Scan(&pnl) == nil {
-					recentPnLs = append(recentPnLs, pnl)
+		if alertCfg.IsEnabled(store.AlertTypeConsecutiveLoss) {
+			pnlRows, err := db.Query(`
+				SELECT realized_pnl FROM trader_positions 
+				WHERE trader_id = ? AND status = 'CLOSED'
+				ORDER BY exit_time DESC LIMIT ?
+			`, traderID, lossWindow)
+			if err == nil {
+				for pnlRows.Next() {
+					var pnl float64
+					if pnlRows.Scan(&pnl) == nil {
+						recentPnLs = append(recentPnLs, pnl)
+					}
 				}
+				pnlRows.Close()
 			}
-			pnlRows.Close()
 		}
 		
 		// 计算连续亏损次数
@@ -637,9 +1073,9 @@ func (s *Server) calculateRiskAlerts() []RiskAlert {
 			}
 		}
 		
-		if consecutiveLosses >= 3 {
+		if consecutiveLosses > 0 && consecutiveLosses >= alertCfg.ConsecutiveLossWarning {
 			level := "warning"
-			if consecutiveLosses >= 5 {
+			if consecutiveLosses >= alertCfg.ConsecutiveLossCritical {
 				level = "critical"
 			}
 			alerts = append(alerts, RiskAlert{
@@ -653,16 +1089,18 @@ func (s *Server) calculateRiskAlerts() []RiskAlert {
 			})
 		}
 		
-		// 2. 检查胜率过低 (至少10笔交易)
+		// 2. 检查胜率过低 (至少 MinTradesForWinRate 笔交易)
 		var totalTrades, winTrades int
-		db.QueryRow(`
-			SELECT COUNT(*), COALESCE(SUM(CASE WHEN realized_pnl > 0 THEN 1 ELSE 0 END), 0)
-			FROM trader_positions WHERE trader_id = ? AND status = 'CLOSED'
-		`, traderID).Scan(&totalTrades, &winTrades)
+		if alertCfg.IsEnabled(store.AlertTypeLowWinRate) {
+			db.QueryRow(`
+				SELECT COUNT(*), COALESCE(SUM(CASE WHEN realized_pnl > 0 THEN 1 ELSE 0 END), 0)
+				FROM trader_positions WHERE trader_id = ? AND status = 'CLOSED'
+			`, traderID).Scan(&totalTrades, &winTrades)
+		}
 		
-		if totalTrades >= 10 {
+		if totalTrades > 0 && totalTrades >= alertCfg.MinTradesForWinRate {
 			winRate := float64(winTrades) / float64(totalTrades) * 100
-			if winRate < 30 {
+			if winRate < alertCfg.MinWinRatePct {
 				alerts = append(alerts, RiskAlert{
 					Level:      "warning",
 					Type:       "low_win_rate",
@@ -676,10 +1114,13 @@ func (s *Server) calculateRiskAlerts() []RiskAlert {
 		}
 		
 		// 3. 检查最大回撤
-		maxDrawdown := s.calculateMaxDrawdown(traderID)
-		if maxDrawdown > 20 {
+		var maxDrawdown float64
+		if alertCfg.IsEnabled(store.AlertTypeMaxDrawdown) {
+			maxDrawdown = s.calculateMaxDrawdown(traderID)
+		}
+		if maxDrawdown > 0 && maxDrawdown > alertCfg.MaxDrawdownWarningPct {
 			level := "warning"
-			if maxDrawdown > 40 {
+			if maxDrawdown > alertCfg.MaxDrawdownCriticalPct {
 				level = "critical"
 			}
 			alerts = append(alerts, RiskAlert{
@@ -692,34 +1133,100 @@ func (s *Server) calculateRiskAlerts() []RiskAlert {
 				Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
 			})
 		}
+
+		// 4. 检查跟单仓位映射漂移（交易所持仓与映射不一致）
+		if drifts := copytrade.GetCopyTradingMappingDrift(traderID); len(drifts) > 0 {
+			details := make([]string, 0, len(drifts))
+			for _, d := range drifts {
+				if d.Kind == copytrade.DriftUntracked {
+					details = append(details, fmt.Sprintf("%s %s 无映射", d.Symbol, d.Side))
+				} else {
+					details = append(details, fmt.Sprintf("%s %s 无持仓", d.Symbol, d.Side))
+				}
+			}
+			alerts = append(alerts, RiskAlert{
+				Level:      "warning",
+				Type:       "mapping_drift",
+				TraderID:   traderID,
+				TraderName: traderName,
+				Message:    fmt.Sprintf("跟单仓位映射不一致: %s", strings.Join(details, ", ")),
+				Value:      float64(len(drifts)),
+				Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+			})
+		}
+
+		// 5. 检查跟单降级模式（领航员状态不可用，暂停跟单）
+		if stats := copytrade.GetCopyTradingStats(traderID); stats != nil && stats.Degraded {
+			alerts = append(alerts, RiskAlert{
+				Level:      "critical",
+				Type:       "copytrade_degraded",
+				TraderID:   traderID,
+				TraderName: traderName,
+				Message:    fmt.Sprintf("跟单降级运行，领航员状态不可用: %s", stats.DegradedReason),
+				Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+			})
+		}
+
+		// 6. 检查决策消费者失效（决策通道持续满载，决策生成但未执行）
+		if stats := copytrade.GetCopyTradingStats(traderID); stats != nil && stats.DecisionStalled {
+			alerts = append(alerts, RiskAlert{
+				Level:      "critical",
+				Type:       "decision_stall",
+				TraderID:   traderID,
+				TraderName: traderName,
+				Message:    fmt.Sprintf("跟单决策通道堵塞，决策未被执行（已丢弃 %d 条）", stats.DecisionsDropped),
+				Value:      float64(stats.DecisionsDropped),
+				Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+			})
+		}
+
+		// 7. 检查领航员流式状态与 REST 状态不一致（WebSocket 失步）
+		if divergences := copytrade.GetCopyTradingStateDivergence(traderID); len(divergences) > 0 {
+			details := make([]string, 0, len(divergences))
+			for _, d := range divergences {
+				details = append(details, fmt.Sprintf("%s %s WS=%.4f REST=%.4f", d.Symbol, d.Side, d.StreamSize, d.RESTSize))
+			}
+			alerts = append(alerts, RiskAlert{
+				Level:      "warning",
+				Type:       "state_divergence",
+				TraderID:   traderID,
+				TraderName: traderName,
+				Message:    fmt.Sprintf("领航员状态不一致: %s", strings.Join(details, ", ")),
+				Value:      float64(len(divergences)),
+				Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+			})
+		}
+
+		// 8. 检查 API 错误频繁（最近1小时跟单失败次数）
+		if alertCfg.IsEnabled(store.AlertTypeAPIError) {
+			var recentErrors int
+			db.QueryRow(`
+				SELECT COUNT(*) FROM copy_trade_signal_logs 
+				WHERE trader_id = ? AND created_at >= ? AND status = 'failed'
+			`, traderID, last1h).Scan(&recentErrors)
+			if recentErrors > 0 && recentErrors >= alertCfg.APIErrorsPerHour {
+				alerts = append(alerts, RiskAlert{
+					Level:      "warning",
+					Type:       "api_error",
+					TraderID:   traderID,
+					TraderName: traderName,
+					Message:    fmt.Sprintf("最近1小时内 %d 次跟单失败", recentErrors),
+					Value:      float64(recentErrors),
+					Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+				})
+			}
+		}
 	}
-	
-	// 4. 检查 API 错误频繁
-	var recentErrors int
-	last1h := time.Now().Add(-1 * time.Hour).Format("2006-01-02 15:04:05")
-	db.QueryRow(`
-		SELECT COUNT(*) FROM copy_trade_signal_logs 
-		WHERE created_at >= ? AND status = 'failed'
-	`, last1h).Scan(&recentErrors)
-	
-	if recentErrors >= 5 {
-		alerts = append(alerts, RiskAlert{
-			Level:      "warning",
-			Type:       "api_error",
-			TraderID:   "",
-			TraderName: "系统",
-			Message:    fmt.Sprintf("最近1小时内 %d 次跟单失败", recentErrors),
-			Value:      float64(recentErrors),
-			Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
-		})
-	}
+
+	// critical 预警推送通知（异步，不影响大屏响应）
+	s.notifyCriticalAlerts(alerts)
 	
 	return alerts
 }
 
 // getPnLTrend 获取盈亏趋势（按天）
 func (s *Server) getPnLTrend(traderID string, days int) ([]PnLTrendPoint, error) {
-	db := s.store.DB()
+	db := s.store.ReadDB()
 	
 	// 构建查询
 	query := `
@@ -767,6 +1274,201 @@ func (s *Server) getPnLTrend(traderID string, days int) ([]PnLTrendPoint, error)
 	return result, nil
 }
 
+// getCopyTradeDashboardStats 获取跟单独立统计（基于仓位映射表）
+func (s *Server) getCopyTradeDashboardStats(traderID string) (*CopyTradeDashboardStats, error) {
+	leaders, err := s.store.CopyTrade().GetLeaderStats(traderID)
+	if err != nil {
+		return nil, err
+	}
+
+	leaderReturns, err := s.store.CopyTrade().GetLeaderReturnDistribution(traderID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &CopyTradeDashboardStats{
+		TraderID:      traderID,
+		Leaders:       leaders,
+		LeaderReturns: leaderReturns,
+		UpdatedAt:     time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	var winPositions int
+	for _, l := range leaders {
+		stats.ActivePositions += l.ActivePositions
+		stats.ClosedPositions += l.ClosedPositions
+		stats.RealizedPnL += l.RealizedPnL
+		stats.EstimatedPositions += l.EstimatedPositions
+		winPositions += l.WinPositions
+	}
+	if stats.ClosedPositions > 0 {
+		stats.WinRate = float64(winPositions) / float64(stats.ClosedPositions) * 100
+	}
+
+	return stats, nil
+}
+
+// getTraderSymbolStats 按币种统计交易员已平仓盈亏（timeRange: today | week | month，空为全部），按总盈亏降序
+func (s *Server) getTraderSymbolStats(traderID, timeRange string) ([]SymbolPnLStats, error) {
+	query := `
+		SELECT
+			symbol,
+			COUNT(*),
+			COALESCE(SUM(CASE WHEN realized_pnl > 0 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(realized_pnl), 0),
+			COALESCE(SUM(fee), 0),
+			COALESCE(AVG((julianday(exit_time) - julianday(entry_time)) * 24 * 60), 0)
+		FROM trader_positions
+		WHERE trader_id = ? AND status = 'CLOSED'`
+	args := []interface{}{traderID}
+	if start := getTimeRangeStart(timeRange); !start.IsZero() {
+		query += ` AND exit_time >= ?`
+		args = append(args, start.Format("2006-01-02 15:04:05"))
+	}
+	query += `
+		GROUP BY symbol
+		ORDER BY 4 DESC, symbol ASC`
+
+	rows, err := s.store.ReadDB().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []SymbolPnLStats{}
+	for rows.Next() {
+		var st SymbolPnLStats
+		var wins int
+		if err := rows.Scan(&st.Symbol, &st.Trades, &wins, &st.TotalPnL, &st.TotalFees, &st.AvgHoldMinutes); err != nil {
+			return nil, err
+		}
+		if st.Trades > 0 {
+			st.WinRate = float64(wins) / float64(st.Trades) * 100
+		}
+		result = append(result, st)
+	}
+	return result, rows.Err()
+}
+
+// queryClosedPositions 按平仓时间升序查询已平仓记录（days > 0 时只取最近 days 天，与趋势接口一致）
+// 返回游标由调用方逐行读取，大量历史记录不会一次性载入内存
+func (s *Server) queryClosedPositions(traderID string, days int) (*sql.Rows, error) {
+	query := `
+		SELECT symbol, side, entry_price, COALESCE(exit_price, 0), COALESCE(realized_pnl, 0), COALESCE(fee, 0),
+			entry_time, COALESCE(exit_time, '')
+		FROM trader_positions
+		WHERE trader_id = ? AND status = 'CLOSED'`
+	args := []interface{}{traderID}
+	if days > 0 {
+		query += ` AND DATE(exit_time) >= ?`
+		args = append(args, time.Now().AddDate(0, 0, -days).Format("2006-01-02"))
+	}
+	query += ` ORDER BY exit_time ASC, id ASC`
+	return s.store.ReadDB().Query(query, args...)
+}
+
+// scanClosedPosition 读取一行已平仓记录（时间统一格式化为 RFC3339，无法解析时原样输出）
+func scanClosedPosition(rows *sql.Rows) (*ClosedPositionExport, error) {
+	var p ClosedPositionExport
+	if err := rows.Scan(&p.Symbol, &p.Side, &p.EntryPrice, &p.ExitPrice, &p.RealizedPnL, &p.Fee, &p.EntryTime, &p.ExitTime); err != nil {
+		return nil, err
+	}
+	if t, ok := parseEquitySnapshotTime(p.EntryTime); ok {
+		p.EntryTime = t.Format(time.RFC3339)
+	}
+	if t, ok := parseEquitySnapshotTime(p.ExitTime); ok {
+		p.ExitTime = t.Format(time.RFC3339)
+	}
+	return &p, nil
+}
+
+// getEquityCurve 从 trader_equity_snapshots 读取净值曲线并按粒度降采样
+func (s *Server) getEquityCurve(traderID string, from, to time.Time, granularity string) (*EquityCurve, error) {
+	db := s.store.ReadDB()
+
+	// 时间戳格式/时区不统一，SQL 只按日期前缀粗筛（前后各放宽一天），精确范围在内存里过滤
+	rows, err := db.Query(`
+		SELECT timestamp, total_equity, COALESCE(balance, 0),
+		       COALESCE(unrealized_pnl, 0), COALESCE(position_count, 0)
+		FROM trader_equity_snapshots
+		WHERE trader_id = ? AND timestamp >= ? AND timestamp < ?
+	`, traderID, from.AddDate(0, 0, -1).Format("2006-01-02"), to.AddDate(0, 0, 2).Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type snapshot struct {
+		t time.Time
+		p EquityCurvePoint
+	}
+	var snaps []snapshot
+	for rows.Next() {
+		var ts string
+		var p EquityCurvePoint
+		if err := rows.Scan(&ts, &p.Equity, &p.Balance, &p.UnrealizedPnL, &p.PositionCount); err != nil {
+			continue
+		}
+		t, ok := parseEquitySnapshotTime(ts)
+		if !ok || t.Before(from) || t.After(to) {
+			continue
+		}
+		snaps = append(snaps, snapshot{t: t, p: p})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// 两种格式的字符串排序不一致，按解析后的时间排序
+	sort.SliceStable(snaps, func(i, j int) bool { return snaps[i].t.Before(snaps[j].t) })
+
+	curve := &EquityCurve{
+		TraderID:    traderID,
+		From:        from.Format(time.RFC3339),
+		To:          to.Format(time.RFC3339),
+		Granularity: granularity,
+		RawCount:    len(snaps),
+		Points:      []EquityCurvePoint{},
+	}
+	if len(snaps) == 0 {
+		if curve.Granularity == EquityGranularityAuto {
+			curve.Granularity = EquityGranularityRaw
+		}
+		return curve, nil
+	}
+	if curve.Granularity == EquityGranularityAuto {
+		curve.Granularity = resolveEquityGranularity(len(snaps), snaps[len(snaps)-1].t.Sub(snaps[0].t))
+	}
+
+	var peak float64
+	for i, sn := range snaps {
+		if sn.p.Equity > peak {
+			peak = sn.p.Equity
+		}
+		if peak > 0 {
+			if dd := (peak - sn.p.Equity) / peak * 100; dd > curve.MaxDrawdownPct {
+				curve.MaxDrawdownPct = dd
+			}
+		}
+
+		// 分桶：同一桶内后来的快照覆盖前面的，即取桶内最后一条
+		bucket := equityBucketStart(sn.t, curve.Granularity)
+		sn.p.Time = bucket.Format(time.RFC3339)
+		if curve.Granularity != EquityGranularityRaw && i > 0 &&
+			equityBucketStart(snaps[i-1].t, curve.Granularity).Equal(bucket) {
+			curve.Points[len(curve.Points)-1] = sn.p
+			continue
+		}
+		curve.Points = append(curve.Points, sn.p)
+	}
+
+	curve.StartEquity = snaps[0].p.Equity
+	curve.EndEquity = snaps[len(snaps)-1].p.Equity
+	if curve.StartEquity > 0 {
+		curve.ReturnPct = (curve.EndEquity - curve.StartEquity) / curve.StartEquity * 100
+	}
+	return curve, nil
+}
+
 // ========== API Handler ==========
 
 // handleDashboardSummary 处理全局汇总请求（带缓存）
@@ -839,6 +1541,38 @@ func (s *Server) handleDashboardTrader(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// handleDashboardTraderSymbols 处理交易员按币种盈亏统计请求（带缓存）
+// GET /api/dashboard/trader/:id/symbols?range=today|week|month
+func (s *Server) handleDashboardTraderSymbols(c *gin.Context) {
+	traderID := c.Param("id")
+	if traderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 trader_id"})
+		return
+	}
+	timeRange := c.Query("range")
+	switch timeRange {
+	case "", "today", "week", "month":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "range 必须为 today、week 或 month"})
+		return
+	}
+
+	key := traderID + "|" + timeRange
+	if stats, ok := dbCache.getSymbols(key); ok {
+		c.JSON(http.StatusOK, stats)
+		return
+	}
+
+	stats, err := s.getTraderSymbolStats(traderID, timeRange)
+	if err != nil {
+		logger.Warnf("Dashboard: 查询币种统计失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取币种统计失败"})
+		return
+	}
+	dbCache.setSymbols(key, stats)
+	c.JSON(http.StatusOK, stats)
+}
+
 // handleDashboardTrend 处理盈亏趋势请求
 func (s *Server) handleDashboardTrend(c *gin.Context) {
 	traderID := c.Query("trader_id") // 可选，为空则全局
@@ -859,6 +1593,26 @@ func (s *Server) handleDashboardTrend(c *gin.Context) {
 	c.JSON(http.StatusOK, trend)
 }
 
+// handleDashboardCopyTrade 处理跟单独立统计请求
+func (s *Server) handleDashboardCopyTrade(c *gin.Context) {
+	traderID := c.Param("id")
+	if traderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "缺少 trader_id",
+		})
+		return
+	}
+
+	stats, err := s.getCopyTradeDashboardStats(traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取跟单统计失败",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
 // handleDashboardMonitor 处理系统监控请求
 func (s *Server) handleDashboardMonitor(c *gin.Context) {
 	monitor, err := s.getSystemMonitor()
@@ -871,6 +1625,169 @@ func (s *Server) handleDashboardMonitor(c *gin.Context) {
 	c.JSON(http.StatusOK, monitor)
 }
 
+// handleDashboardEquityCurve 处理净值曲线导出请求
+// GET /api/dashboard/equity-curve?trader_id=&from=&to=&granularity=
+func (s *Server) handleDashboardEquityCurve(c *gin.Context) {
+	traderID := c.Query("trader_id")
+	if traderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 trader_id"})
+		return
+	}
+
+	to := time.Now()
+	if v := c.Query("to"); v != "" {
+		t, err := parseEquityCurveTime(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// 仅给出日期时包含当天
+		if len(v) == len("2006-01-02") {
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		to = t
+	}
+	from := to.Add(-defaultEquityCurveRange)
+	if v := c.Query("from"); v != "" {
+		t, err := parseEquityCurveTime(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		from = t
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from 不能晚于 to"})
+		return
+	}
+
+	granularity := strings.ToLower(c.DefaultQuery("granularity", EquityGranularityAuto))
+	switch granularity {
+	case EquityGranularityAuto, EquityGranularityRaw, EquityGranularityMinute,
+		EquityGranularityHour, EquityGranularityDay:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "granularity 必须为 auto、raw、minute、hour 或 day"})
+		return
+	}
+
+	curve, err := s.getEquityCurve(traderID, from, to, granularity)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取净值曲线失败"})
+		return
+	}
+	c.JSON(http.StatusOK, curve)
+}
+
+// handleDashboardTraderExport 导出交易员已平仓记录（format = csv/json，默认 csv；days 可选）
+// 逐行写出响应，不在内存中缓存全部记录
+func (s *Server) handleDashboardTraderExport(c *gin.Context) {
+	traderID := c.Param("id")
+	if traderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 trader_id"})
+		return
+	}
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format 必须为 csv 或 json"})
+		return
+	}
+	days := 0 // 默认全部
+	if d := c.Query("days"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days 必须为非负整数"})
+			return
+		}
+		days = parsed
+	}
+
+	rows, err := s.queryClosedPositions(traderID, days)
+	if err != nil {
+		logger.Warnf("Dashboard: 查询已平仓记录失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导出交易记录失败"})
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("%s_closed_positions_%s.%s", exportFileToken(traderID), time.Now().Format("20060102"), format)
+	contentType := "text/csv; charset=utf-8"
+	if format == "json" {
+		contentType = "application/json; charset=utf-8"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	// 响应头已发出，之后的错误只能记录日志（客户端收到截断的文件）
+	count := 0
+	if format == "csv" {
+		w := csv.NewWriter(c.Writer)
+		err = w.Write(closedPositionCSVHeader)
+		for err == nil && rows.Next() {
+			var p *ClosedPositionExport
+			if p, err = scanClosedPosition(rows); err == nil {
+				err = w.Write(p.csvRecord())
+				count++
+				if count%500 == 0 {
+					w.Flush()
+					err = w.Error()
+				}
+			}
+		}
+		w.Flush()
+		if err == nil {
+			err = w.Error()
+		}
+	} else {
+		enc := json.NewEncoder(c.Writer)
+		_, err = c.Writer.WriteString("[")
+		for err == nil && rows.Next() {
+			var p *ClosedPositionExport
+			if p, err = scanClosedPosition(rows); err == nil {
+				if count > 0 {
+					_, err = c.Writer.WriteString(",")
+				}
+				if err == nil {
+					err = enc.Encode(p)
+				}
+				count++
+			}
+		}
+		if err == nil {
+			_, err = c.Writer.WriteString("]")
+		}
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	if err != nil {
+		logger.Warnf("Dashboard: 导出交易员 %s 已平仓记录中断（已写出 %d 条）: %v", traderID, count, err)
+		return
+	}
+	logger.Debugf("📊 Dashboard: 导出交易员 %s 已平仓记录 %d 条（%s）", traderID, count, format)
+}
+
+// exportFileToken 文件名中只保留字母、数字、- 和 _
+func exportFileToken(s string) string {
+	token := strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			return r
+		}
+		return -1
+	}, s)
+	if token == "" {
+		token = "trader"
+	}
+	return token
+}
+
+// handleDashboardRefresh 清空大屏统计缓存（保存配置等手动操作后立即生效）
+func (s *Server) handleDashboardRefresh(c *gin.Context) {
+	dbCache.invalidate()
+	logger.Infof("📊 Dashboard: 缓存已由用户 %s 手动清空", c.GetString("user_id"))
+	c.JSON(http.StatusOK, gin.H{"message": "Dashboard cache cleared"})
+}
+
 // ========== 路由注册 ==========
 
 // RegisterDashboardRoutes 注册大屏路由（在 setupRoutes 中调用）
@@ -880,15 +1797,25 @@ func (s *Server) RegisterDashboardRoutes(api *gin.RouterGroup) {
 		dashboard.GET("/summary", s.handleDashboardSummary)
 		dashboard.GET("/traders", s.handleDashboardTraders)
 		dashboard.GET("/trader/:id", s.handleDashboardTrader)
+		dashboard.GET("/trader/:id/symbols", s.handleDashboardTraderSymbols)
+		dashboard.GET("/trader/:id/export", s.handleDashboardTraderExport)
 		dashboard.GET("/trend", s.handleDashboardTrend)
+		dashboard.GET("/equity-curve", s.handleDashboardEquityCurve)
 		dashboard.GET("/monitor", s.handleDashboardMonitor)
+		dashboard.GET("/copytrade/:id", s.handleDashboardCopyTrade)
+		dashboard.POST("/refresh", s.authMiddleware(), s.handleDashboardRefresh)
 	}
 	
 	logger.Infof("📊 Dashboard API 路由已注册:")
 	logger.Infof("  • GET /api/dashboard/summary   - 全局汇总统计")
 	logger.Infof("  • GET /api/dashboard/traders   - 所有交易员统计")
 	logger.Infof("  • GET /api/dashboard/trader/:id - 单个交易员统计")
+	logger.Infof("  • GET /api/dashboard/trader/:id/symbols - 单个交易员按币种盈亏")
+	logger.Infof("  • GET /api/dashboard/trader/:id/export - 导出已平仓记录（CSV/JSON）")
 	logger.Infof("  • GET /api/dashboard/trend     - 盈亏趋势数据")
+	logger.Infof("  • GET /api/dashboard/equity-curve - 净值曲线（按快照，支持降采样）")
 	logger.Infof("  • GET /api/dashboard/monitor   - 系统监控与风险预警")
+	logger.Infof("  • GET /api/dashboard/copytrade/:id - 跟单独立统计（按领航员）")
+	logger.Infof("  • POST /api/dashboard/refresh  - 清空统计缓存（需登录）")
 }
 
@@ -0,0 +1,463 @@
+package api
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"nofx/auth"
+	"nofx/manager"
+	"nofx/store"
+)
+
+// countingConn 统计经过连接的 SQL 语句数
+type countingConn struct {
+	driver.Conn
+	queries *int64
+}
+
+func (c countingConn) Prepare(query string) (driver.Stmt, error) {
+	atomic.AddInt64(c.queries, 1)
+	return c.Conn.Prepare(query)
+}
+
+type countingDriver struct {
+	driver.Driver
+	queries *int64
+}
+
+func (d countingDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return countingConn{Conn: conn, queries: d.queries}, nil
+}
+
+var (
+	dashboardQueries   int64
+	registerCountingDB sync.Once
+)
+
+// newDashboardTestServer 创建带 SQL 计数的测试 Server，写入 traders 个交易员的测试数据
+func newDashboardTestServer(tb testing.TB, traders int) *Server {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "dashboard.db")
+	st, err := store.New(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	db := st.DB()
+	now := time.Now()
+	for i := 0; i < traders; i++ {
+		id := fmt.Sprintf("trader-%02d", i)
+		mustExec(tb, db, `INSERT INTO traders (id, name, ai_model_id, exchange_id, initial_balance) VALUES (?, ?, 'deepseek', 'binance', 1000)`,
+			id, fmt.Sprintf("T%d", i))
+		for j := 0; j < 20; j++ {
+			pnl := float64((j%5)-2) * float64(i+1)
+			exit := now.Add(-time.Duration(j*30) * time.Hour).Format("2006-01-02 15:04:05")
+			mustExec(tb, db, `INSERT INTO trader_positions (trader_id, symbol, side, quantity, entry_price, entry_time, exit_time, realized_pnl, fee, status)
+				VALUES (?, 'BTCUSDT', 'LONG', 1, 100, ?, ?, ?, 0.1, 'CLOSED')`, id, exit, exit, pnl)
+		}
+		mustExec(tb, db, `INSERT INTO trader_positions (trader_id, symbol, side, quantity, entry_price, entry_time, status)
+			VALUES (?, 'ETHUSDT', 'SHORT', 1, 100, ?, 'OPEN')`, id, now.Format("2006-01-02 15:04:05"))
+		for j := 0; j < 3; j++ {
+			mustExec(tb, db, `INSERT INTO trader_equity_snapshots (trader_id, timestamp, total_equity) VALUES (?, ?, ?)`,
+				id, now.Add(-time.Duration(j)*time.Hour).Format("2006-01-02 15:04:05"), 1000+float64(i*10-j))
+		}
+	}
+	st.Close()
+
+	registerCountingDB.Do(func() {
+		base, err := sql.Open("sqlite", ":memory:")
+		if err != nil {
+			tb.Fatal(err)
+		}
+		sql.Register("sqlite-counting", countingDriver{Driver: base.Driver(), queries: &dashboardQueries})
+		base.Close()
+	})
+	countingDB, err := sql.Open("sqlite-counting", path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	countingDB.SetMaxOpenConns(1)
+	tb.Cleanup(func() { countingDB.Close() })
+
+	return &Server{store: store.NewFromDB(countingDB), traderManager: manager.NewTraderManager()}
+}
+
+func mustExec(tb testing.TB, db *sql.DB, query string, args ...interface{}) {
+	tb.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// perTraderDashboardStats 原实现：逐个交易员查询
+func (s *Server) perTraderDashboardStats(tb testing.TB) []TraderDashboardStats {
+	rows, err := s.store.ReadDB().Query(`SELECT id FROM traders ORDER BY created_at ASC`)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		rows.Scan(&id)
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	var result []TraderDashboardStats
+	for _, id := range ids {
+		stats, err := s.getTraderDashboardStats(id)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		result = append(result, *stats)
+	}
+	return result
+}
+
+// TestAllTradersDashboardStatsMatchesPerTrader 集合查询结果与逐个查询一致
+func TestAllTradersDashboardStatsMatchesPerTrader(t *testing.T) {
+	s := newDashboardTestServer(t, 5)
+
+	want := s.perTraderDashboardStats(t)
+	got, err := s.getAllTradersDashboardStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("traders = %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("trader %s:\n got %+v\nwant %+v", want[i].TraderID, got[i], want[i])
+		}
+	}
+	if got[0].TraderName != "T0" || got[0].TotalTrades != 20 || got[0].PositionCount != 1 || got[0].CurrentEquity != 1000 {
+		t.Errorf("unexpected stats: %+v", got[0])
+	}
+}
+
+// BenchmarkDashboardStats 对比逐个查询与集合查询的 SQL 条数（queries/op）
+func BenchmarkDashboardStats(b *testing.B) {
+	s := newDashboardTestServer(b, 20)
+
+	b.Run("per_trader", func(b *testing.B) {
+		atomic.StoreInt64(&dashboardQueries, 0)
+		for i := 0; i < b.N; i++ {
+			s.perTraderDashboardStats(b)
+		}
+		b.ReportMetric(float64(atomic.LoadInt64(&dashboardQueries))/float64(b.N), "queries/op")
+	})
+	b.Run("consolidated", func(b *testing.B) {
+		atomic.StoreInt64(&dashboardQueries, 0)
+		for i := 0; i < b.N; i++ {
+			if _, err := s.getAllTradersDashboardStats(); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(atomic.LoadInt64(&dashboardQueries))/float64(b.N), "queries/op")
+	})
+}
+
+// TestEquityCurveDownsampling 宽范围自动降采样，桶内取最后一条快照，兼容两种时间戳格式
+func TestEquityCurveDownsampling(t *testing.T) {
+	s := newDashboardTestServer(t, 1)
+	db := s.store.DB()
+
+	base := time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)
+	for i := 0; i < 700; i++ {
+		ts := base.Add(time.Duration(i) * time.Minute)
+		// 一半按 EquityStore 的 RFC3339 写入，一半按历史迁移的本地时间格式写入
+		layout := time.RFC3339
+		if i%2 == 1 {
+			layout = "2006-01-02 15:04:05"
+		}
+		mustExec(t, db, `INSERT INTO trader_equity_snapshots (trader_id, timestamp, total_equity, balance) VALUES (?, ?, ?, ?)`,
+			"curve", ts.Format(layout), 1000+float64(i), 1000)
+	}
+	// 回撤：最高 1699 后跌到 1359.2（-20%）
+	mustExec(t, db, `INSERT INTO trader_equity_snapshots (trader_id, timestamp, total_equity, balance) VALUES (?, ?, ?, ?)`,
+		"curve", base.Add(700*time.Minute).Format(time.RFC3339), 1359.2, 1000)
+
+	from, to := base, base.Add(12*time.Hour)
+
+	raw, err := s.getEquityCurve("curve", from, to, EquityGranularityRaw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw.RawCount != 701 || len(raw.Points) != 701 {
+		t.Fatalf("raw count=%d points=%d, want 701", raw.RawCount, len(raw.Points))
+	}
+
+	auto, err := s.getEquityCurve("curve", from, to, EquityGranularityAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auto.Granularity != EquityGranularityHour {
+		t.Fatalf("auto granularity = %s, want hour", auto.Granularity)
+	}
+	if len(auto.Points) != 12 {
+		t.Fatalf("hourly points = %d, want 12", len(auto.Points))
+	}
+	if got := auto.Points[0].Equity; got != 1059 {
+		t.Fatalf("first bucket equity = %v, want last in bucket 1059", got)
+	}
+	if got := auto.Points[0].Time; got != base.Format(time.RFC3339) {
+		t.Fatalf("first bucket time = %s, want %s", got, base.Format(time.RFC3339))
+	}
+	if auto.StartEquity != 1000 || auto.EndEquity != 1359.2 {
+		t.Fatalf("start/end = %v/%v", auto.StartEquity, auto.EndEquity)
+	}
+	if auto.MaxDrawdownPct < 19.99 || auto.MaxDrawdownPct > 20.01 {
+		t.Fatalf("max drawdown = %v, want 20", auto.MaxDrawdownPct)
+	}
+
+	// 范围过滤
+	narrow, err := s.getEquityCurve("curve", base.Add(10*time.Minute), base.Add(19*time.Minute), EquityGranularityAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if narrow.Granularity != EquityGranularityRaw || len(narrow.Points) != 10 {
+		t.Fatalf("narrow granularity=%s points=%d, want raw/10", narrow.Granularity, len(narrow.Points))
+	}
+}
+
+// TestEquityCurveHandlerValidation 参数校验
+func TestEquityCurveHandlerValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newDashboardTestServer(t, 1)
+	r := gin.New()
+	s.RegisterDashboardRoutes(r.Group("/api"))
+
+	cases := []struct {
+		query string
+		code  int
+	}{
+		{"", http.StatusBadRequest},
+		{"trader_id=trader-00&granularity=week", http.StatusBadRequest},
+		{"trader_id=trader-00&from=yesterday", http.StatusBadRequest},
+		{"trader_id=trader-00&from=2025-03-02&to=2025-03-01", http.StatusBadRequest},
+		{"trader_id=trader-00", http.StatusOK},
+		{"trader_id=trader-00&from=2025-03-01&to=2025-03-01&granularity=day", http.StatusOK},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/dashboard/equity-curve?"+tc.query, nil)
+		r.ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Errorf("%q: code = %d, want %d (%s)", tc.query, w.Code, tc.code, w.Body.String())
+		}
+	}
+}
+
+func TestDashboardTraderSymbols(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newDashboardTestServer(t, 1)
+	now := time.Now()
+	for _, p := range []struct {
+		pnl     float64
+		holdMin int
+	}{{5, 60}, {-1, 0}} {
+		mustExec(t, s.store.DB(), `INSERT INTO trader_positions (trader_id, symbol, side, quantity, entry_price, entry_time, exit_time, realized_pnl, fee, status)
+			VALUES ('trader-00', 'ETHUSDT', 'LONG', 1, 100, ?, ?, ?, 0.5, 'CLOSED')`,
+			now.Add(-time.Duration(p.holdMin)*time.Minute).Format("2006-01-02 15:04:05"), now.Format("2006-01-02 15:04:05"), p.pnl)
+	}
+	r := gin.New()
+	s.RegisterDashboardRoutes(r.Group("/api"))
+
+	get := func(query string) (int, []SymbolPnLStats) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/dashboard/trader/trader-00/symbols"+query, nil))
+		var stats []SymbolPnLStats
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code, stats
+	}
+
+	code, stats := get("")
+	if code != http.StatusOK || len(stats) != 2 {
+		t.Fatalf("code=%d stats=%+v, want 2 symbols", code, stats)
+	}
+	eth, btc := stats[0], stats[1]
+	if eth.Symbol != "ETHUSDT" || eth.Trades != 2 || eth.TotalPnL != 4 || eth.TotalFees != 1 || eth.WinRate != 50 {
+		t.Errorf("ETHUSDT stats = %+v", eth)
+	}
+	if math.Abs(eth.AvgHoldMinutes-30) > 0.1 {
+		t.Errorf("ETHUSDT avg hold = %.2f, want 30", eth.AvgHoldMinutes)
+	}
+	if btc.Symbol != "BTCUSDT" || btc.Trades != 20 || btc.TotalPnL != 0 {
+		t.Errorf("BTCUSDT stats = %+v", btc)
+	}
+
+	if _, stats := get("?range=today"); len(stats) != 2 || stats[1].Symbol != "BTCUSDT" || stats[1].Trades != 1 {
+		t.Errorf("today range should only include today's BTCUSDT trade: %+v", stats)
+	}
+	if code, _ := get("?range=year"); code != http.StatusBadRequest {
+		t.Errorf("invalid range: code = %d, want 400", code)
+	}
+}
+
+func TestRiskAdjustedRatios(t *testing.T) {
+	annualize := math.Sqrt(365)
+	cases := []struct {
+		name                string
+		daily               []float64
+		balance, riskFree   float64
+		wantSharpe, wantSor float64
+	}{
+		{name: "no data"},
+		{name: "single day", daily: []float64{10}, balance: 1000},
+		{name: "no volatility", daily: []float64{5, 5, 5}, balance: 1000},
+		{name: "raw pnl without balance", daily: []float64{1, -1, 2},
+			wantSharpe: (2.0 / 3) / math.Sqrt(21.0/9) * annualize, wantSor: (2.0 / 3) / math.Sqrt(1.0/3) * annualize},
+		{name: "returns on balance", daily: []float64{10, -10, 20}, balance: 10,
+			wantSharpe: (2.0 / 3) / math.Sqrt(21.0/9) * annualize, wantSor: (2.0 / 3) / math.Sqrt(1.0/3) * annualize},
+	}
+	for _, tc := range cases {
+		sharpe, sortino := riskAdjustedRatios(tc.daily, tc.balance, tc.riskFree)
+		if math.Abs(sharpe-tc.wantSharpe) > 1e-9 || math.Abs(sortino-tc.wantSor) > 1e-9 {
+			t.Errorf("%s: sharpe=%v sortino=%v, want %v %v", tc.name, sharpe, sortino, tc.wantSharpe, tc.wantSor)
+		}
+	}
+
+	// 无亏损日：下行偏差为 0，索提诺返回 0
+	if _, sortino := riskAdjustedRatios([]float64{1, 2, 3}, 100, 0); sortino != 0 {
+		t.Errorf("sortino without losing days = %v, want 0", sortino)
+	}
+	// 无风险利率：日超额收益降低
+	base, _ := riskAdjustedRatios([]float64{1, -1, 2}, 100, 0)
+	withRF, _ := riskAdjustedRatios([]float64{1, -1, 2}, 100, 0.5)
+	if withRF >= base {
+		t.Errorf("risk-free rate should lower sharpe: %v >= %v", withRF, base)
+	}
+}
+
+func TestDashboardRefresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newDashboardTestServer(t, 1)
+	r := gin.New()
+	s.RegisterDashboardRoutes(r.Group("/api"))
+	dbCache.invalidate()
+	t.Cleanup(dbCache.invalidate)
+
+	totalTrades := func() int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/dashboard/summary", nil))
+		var summary DashboardSummary
+		if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+			t.Fatalf("summary: %v (%s)", err, w.Body.String())
+		}
+		return summary.TotalTrades
+	}
+	refresh := func(token string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/dashboard/refresh", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	before := totalTrades()
+	mustExec(t, s.store.DB(), `INSERT INTO trader_positions (trader_id, symbol, side, quantity, entry_price, entry_time, exit_time, realized_pnl, status)
+		VALUES ('trader-00', 'ETHUSDT', 'LONG', 1, 100, ?, ?, 1, 'CLOSED')`,
+		time.Now().Format("2006-01-02 15:04:05"), time.Now().Format("2006-01-02 15:04:05"))
+	if got := totalTrades(); got != before {
+		t.Fatalf("summary should be served from cache: total_trades = %d, want %d", got, before)
+	}
+
+	if code := refresh(""); code != http.StatusUnauthorized {
+		t.Fatalf("refresh without token: code = %d, want 401", code)
+	}
+	auth.SetJWTSecret("dashboard-test-secret")
+	token, err := auth.GenerateJWT("user-1", "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := refresh(token); code != http.StatusOK {
+		t.Fatalf("refresh: code = %d, want 200", code)
+	}
+	if got := totalTrades(); got != before+1 {
+		t.Errorf("after refresh total_trades = %d, want %d", got, before+1)
+	}
+}
+
+func TestDashboardTraderExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newDashboardTestServer(t, 1)
+	r := gin.New()
+	s.RegisterDashboardRoutes(r.Group("/api"))
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/dashboard/trader/trader-00/export"+query, nil))
+		return w
+	}
+
+	w := get("")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("csv: code=%d content-type=%q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, `attachment; filename="trader-00_closed_positions_`) || !strings.HasSuffix(cd, `.csv"`) {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 21 || strings.Join(records[0], ",") != "symbol,side,entry_price,exit_price,realized_pnl,fee,entry_time,exit_time" {
+		t.Fatalf("csv should have a header and 20 closed positions, got %d rows: %v", len(records), records[0])
+	}
+	if rec := records[1]; rec[0] != "BTCUSDT" || rec[1] != "LONG" || rec[5] != "0.1" {
+		t.Errorf("first csv row = %v", rec)
+	}
+	if _, err := time.Parse(time.RFC3339, records[1][7]); err != nil {
+		t.Errorf("exit_time should be RFC3339: %v", err)
+	}
+
+	w = get("?format=json&days=3")
+	var rows []ClosedPositionExport
+	if err := json.Unmarshal(w.Body.Bytes(), &rows); err != nil {
+		t.Fatalf("json: %v (%s)", err, w.Body.String())
+	}
+	// 每 30 小时一笔：最近 3 天（按日期）内为 3 或 4 笔，取决于当前时刻
+	if len(rows) < 3 || len(rows) > 4 {
+		t.Errorf("days=3 returned %d rows", len(rows))
+	}
+	for i := 1; i < len(rows); i++ {
+		if rows[i].ExitTime < rows[i-1].ExitTime {
+			t.Errorf("rows should be ordered by exit_time: %s before %s", rows[i-1].ExitTime, rows[i].ExitTime)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/dashboard/trader/nobody/export?format=json", nil))
+	if strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("empty export = %q, want []", w.Body.String())
+	}
+
+	for _, q := range []string{"?format=xml", "?days=-1", "?days=abc"} {
+		if code := get(q).Code; code != http.StatusBadRequest {
+			t.Errorf("%s: code = %d, want 400", q, code)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"nofx/logger"
+	"nofx/notify"
+	"nofx/store"
+)
+
+// criticalAlertThrottle 同一交易员的同类 critical 预警 1 小时内只通知一次
+// （风险预警随大屏刷新重复计算，不节流会在每次刷新时重复发送）
+var criticalAlertThrottle = notify.NewThrottle(time.Hour)
+
+// NotificationConfigRequest 通知配置更新请求（bot token 留空表示保持原值）
+type NotificationConfigRequest struct {
+	Enabled          bool    `json:"enabled"`
+	TelegramBotToken string  `json:"telegram_bot_token"`
+	TelegramChatID   string  `json:"telegram_chat_id"`
+	MinTradeUSD      float64 `json:"min_trade_usd"`
+}
+
+// NotificationConfigResponse 通知配置（不返回 bot token 明文）
+type NotificationConfigResponse struct {
+	Enabled             bool    `json:"enabled"`
+	HasTelegramBotToken bool    `json:"has_telegram_bot_token"`
+	TelegramChatID      string  `json:"telegram_chat_id"`
+	MinTradeUSD         float64 `json:"min_trade_usd"`
+	UpdatedAt           string  `json:"updated_at,omitempty"`
+}
+
+func newNotificationConfigResponse(cfg *store.NotificationConfig) NotificationConfigResponse {
+	resp := NotificationConfigResponse{
+		Enabled:             cfg.Enabled,
+		HasTelegramBotToken: cfg.TelegramBotToken != "",
+		TelegramChatID:      cfg.TelegramChatID,
+		MinTradeUSD:         cfg.MinTradeUSD,
+	}
+	if !cfg.UpdatedAt.IsZero() {
+		resp.UpdatedAt = cfg.UpdatedAt.Format("2006-01-02 15:04:05")
+	}
+	return resp
+}
+
+// validateNotificationConfig 校验通知配置（hasStoredToken: 数据库中已保存 bot token）
+func validateNotificationConfig(req *NotificationConfigRequest, hasStoredToken bool) []FieldError {
+	var errs []FieldError
+	if req.MinTradeUSD < 0 {
+		errs = append(errs, FieldError{Field: "min_trade_usd", Message: "min_trade_usd must not be negative"})
+	}
+	if req.Enabled {
+		if req.TelegramBotToken == "" && !hasStoredToken {
+			errs = append(errs, FieldError{Field: "telegram_bot_token", Message: "telegram_bot_token is required when notifications are enabled"})
+		}
+		if req.TelegramChatID == "" {
+			errs = append(errs, FieldError{Field: "telegram_chat_id", Message: "telegram_chat_id is required when notifications are enabled"})
+		}
+	}
+	return errs
+}
+
+// handleGetNotificationConfig 获取当前用户的通知配置
+func (s *Server) handleGetNotificationConfig(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	cfg, err := s.store.Notification().Get(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get notification config: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, newNotificationConfigResponse(cfg))
+}
+
+// handleUpdateNotificationConfig 更新当前用户的通知配置
+func (s *Server) handleUpdateNotificationConfig(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req NotificationConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "invalid notification config",
+			"errors": bindingFieldErrors(err, &req),
+		})
+		return
+	}
+
+	existing, err := s.store.Notification().Get(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get notification config: %v", err)})
+		return
+	}
+	if errs := validateNotificationConfig(&req, existing.TelegramBotToken != ""); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "invalid notification config",
+			"errors": errs,
+		})
+		return
+	}
+
+	cfg := &store.NotificationConfig{
+		UserID:           userID,
+		Enabled:          req.Enabled,
+		TelegramBotToken: req.TelegramBotToken,
+		TelegramChatID:   req.TelegramChatID,
+		MinTradeUSD:      req.MinTradeUSD,
+	}
+	if err := s.store.Notification().Upsert(cfg); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to update notification config: %v", err)})
+		return
+	}
+
+	saved, err := s.store.Notification().Get(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get notification config: %v", err)})
+		return
+	}
+	logger.Infof("✓ User %s notification config updated (enabled=%v)", userID, saved.Enabled)
+	c.JSON(http.StatusOK, newNotificationConfigResponse(saved))
+}
+
+// handleTestNotification 用已保存的配置发送一条测试消息（同步返回发送结果）
+func (s *Server) handleTestNotification(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	cfg, err := s.store.Notification().Get(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get notification config: %v", err)})
+		return
+	}
+	if cfg.TelegramBotToken == "" || cfg.TelegramChatID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "telegram bot token and chat id are not configured"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), notify.SendTimeout)
+	defer cancel()
+	n := notify.NewTelegramNotifier(cfg.TelegramBotToken, cfg.TelegramChatID)
+	if err := n.Notify(ctx, notify.Message{Level: notify.LevelInfo, Title: "NOFX 通知测试", Text: "通知配置可用"}); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Test notification sent"})
+}
+
+// notifyCriticalAlerts critical 级别风险预警通知交易员所属用户（异步，按交易员+类型节流）
+func (s *Server) notifyCriticalAlerts(alerts []RiskAlert) {
+	for _, alert := range alerts {
+		if alert.Level != "critical" {
+			continue
+		}
+		cfg, err := s.store.Notification().GetByTrader(alert.TraderID)
+		if err != nil || !cfg.Ready() {
+			continue
+		}
+		if !criticalAlertThrottle.Allow(alert.TraderID + "|" + alert.Type) {
+			continue
+		}
+		notify.Dispatch(notify.NewTelegramNotifier(cfg.TelegramBotToken, cfg.TelegramChatID), notify.Message{
+			Level:    notify.LevelCritical,
+			Title:    fmt.Sprintf("风险预警 | %s", alert.TraderName),
+			Text:     fmt.Sprintf("%s\n类型: %s\n时间: %s", alert.Message, alert.Type, alert.Timestamp),
+			TraderID: alert.TraderID,
+		})
+	}
+}
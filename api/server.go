@@ -41,12 +41,36 @@ func (a *CopyTradeExecutorAdapter) GetPositions() ([]map[string]interface{}, err
 	return a.trader.GetPositions()
 }
 
+func (a *CopyTradeExecutorAdapter) SupportsSymbol(symbol string) (bool, error) {
+	return a.trader.SupportsSymbol(symbol)
+}
+
+func (a *CopyTradeExecutorAdapter) MinOrderQuantity(symbol string) (float64, error) {
+	return a.trader.MinOrderQuantity(symbol)
+}
+
+func (a *CopyTradeExecutorAdapter) SetLeverage(symbol string, leverage int) error {
+	return a.trader.SetLeverage(symbol, leverage)
+}
+
+func (a *CopyTradeExecutorAdapter) SetMarginMode(symbol string, isCrossMargin bool) error {
+	return a.trader.SetMarginMode(symbol, isCrossMargin)
+}
+
+func (a *CopyTradeExecutorAdapter) SetProtectiveStop(symbol, side string, stopPrice float64) error {
+	return a.trader.SetProtectiveStop(symbol, side, stopPrice)
+}
+
 // isTraderRunning checks if a trader is running (unified for both AI and copy trade modes)
 // This is the single source of truth for trader running status
 func (s *Server) isTraderRunning(traderID string) bool {
 	// Check decision mode
 	decisionMode, _ := s.store.CopyTrade().GetDecisionMode(traderID)
+	return s.isTraderRunningWithMode(traderID, decisionMode)
+}
 
+// isTraderRunningWithMode checks if a trader is running when its decision mode is already known
+func (s *Server) isTraderRunningWithMode(traderID, decisionMode string) bool {
 	if decisionMode == "copy_trade" {
 		// For copy trade mode, check copy trading engine status
 		return copytrade.IsCopyTradingRunning(traderID)
@@ -188,6 +212,13 @@ func (s *Server) setupRoutes() {
 			protected.POST("/traders/:id/sync-balance", s.handleSyncBalance)
 			protected.POST("/traders/:id/close-position", s.handleClosePosition)
 			protected.PUT("/traders/:id/competition", s.handleToggleCompetition)
+			protected.GET("/traders/:id/alerts", s.handleGetTraderAlertConfig)
+			protected.PUT("/traders/:id/alerts", s.handleUpdateTraderAlertConfig)
+
+			// Notification channel (followed trades and critical alerts)
+			protected.GET("/notifications", s.handleGetNotificationConfig)
+			protected.PUT("/notifications", s.handleUpdateNotificationConfig)
+			protected.POST("/notifications/test", s.handleTestNotification)
 
 			// AI model configuration
 			protected.GET("/models", s.handleGetModelConfigs)
@@ -247,8 +278,10 @@ func (s *Server) setupRoutes() {
 // handleHealth Health check
 func (s *Server) handleHealth(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"status": "ok",
-		"time":   c.Request.Context().Value("time"),
+		"status":                "ok",
+		"time":                  c.Request.Context().Value("time"),
+		"copytrade_maintenance": copytrade.GetMaintenance(),
+		"copytrade_engines":     copytrade.ListCopyTradingHealth(), // sorted by health score, worst first
 	})
 }
 
@@ -451,6 +484,8 @@ type CopyConfigReq struct {
 	CopyRatio      float64 `json:"copy_ratio"`       // Copy ratio (1.0 = 100%)
 	SyncLeverage   bool    `json:"sync_leverage"`    // Whether to sync leverage
 	SyncMarginMode *bool   `json:"sync_margin_mode"` // Whether to sync margin mode (OKX: cross/isolated)
+
+	Options *store.CopyTradeOptions `json:"options"` // Advanced options (nil = keep existing)
 }
 
 type ModelConfig struct {
@@ -757,6 +792,9 @@ func (s *Server) handleCreateTrader(c *gin.Context) {
 			SyncMarginMode: syncMarginMode,
 			Enabled:        false, // Not enabled until explicitly started
 		}
+		if req.CopyConfig.Options != nil {
+			copyConfig.Options = *req.CopyConfig.Options
+		}
 
 		// Validate required fields
 		if copyConfig.ProviderType == "" || copyConfig.LeaderID == "" {
@@ -912,6 +950,18 @@ func (s *Server) handleUpdateTrader(c *gin.Context) {
 		IsRunning:            existingTrader.IsRunning, // Keep original value
 	}
 
+	// Reject toggling inverse copy trading while copy positions are active (before any change is saved)
+	if req.DecisionMode == "copy_trade" && req.CopyConfig != nil && req.CopyConfig.Options != nil {
+		if existing, err := s.store.CopyTrade().GetByTraderID(traderID); err == nil && existing != nil {
+			next := *existing
+			next.Options = *req.CopyConfig.Options
+			if err := copytrade.CheckInverseChange(s.store, existing, &next); err != nil {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+		}
+	}
+
 	// Update database
 	logger.Infof("🔄 Updating trader: ID=%s, Name=%s, AIModelID=%s, StrategyID=%s, req.StrategyID=%s",
 		traderRecord.ID, traderRecord.Name, traderRecord.AIModelID, traderRecord.StrategyID, req.StrategyID)
@@ -946,6 +996,18 @@ func (s *Server) handleUpdateTrader(c *gin.Context) {
 				SyncMarginMode: syncMarginMode,
 			}
 
+			// Keep existing enabled state, warn thresholds and advanced options unless explicitly provided
+			existing, err := s.store.CopyTrade().GetByTraderID(traderID)
+			if err == nil && existing != nil {
+				copyConfig.Enabled = existing.Enabled
+				copyConfig.MinTradeWarn = existing.MinTradeWarn
+				copyConfig.MaxTradeWarn = existing.MaxTradeWarn
+				copyConfig.Options = existing.Options
+			}
+			if req.CopyConfig.Options != nil {
+				copyConfig.Options = *req.CopyConfig.Options
+			}
+
 			// Default copy ratio to 1.0 (100%)
 			if copyConfig.CopyRatio <= 0 {
 				copyConfig.CopyRatio = 1.0
@@ -961,6 +1023,15 @@ func (s *Server) handleUpdateTrader(c *gin.Context) {
 			// If switching to AI mode, disable copy trade
 			s.store.CopyTrade().SetEnabled(traderID, false)
 		}
+
+		// Apply the saved config to running copy trading (hot update, restart on leader change, stop when disabled)
+		if copytrade.IsCopyTradingRunning(traderID) {
+			if reload, err := copytrade.ReloadCopyTradingConfig(traderID); err != nil {
+				logger.Errorf("Failed to reload copy trade config for trader %s: %v", traderID, err)
+			} else {
+				logger.Infof("✓ Copy trade config reloaded for trader %s: %s", traderID, reload)
+			}
+		}
 	}
 
 	// Remove old trader from memory first to ensure fresh config is loaded
@@ -1976,6 +2047,7 @@ func (s *Server) handleGetTraderConfig(c *gin.Context) {
 				"sync_margin_mode": cfg.SyncMarginMode,
 				"min_trade_warn":   cfg.MinTradeWarn,
 				"max_trade_warn":   cfg.MaxTradeWarn,
+				"options":          cfg.Options,
 			}
 		}
 	}
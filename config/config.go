@@ -22,6 +22,23 @@ type Config struct {
 	// TransportEncryption enables browser-side encryption for API keys
 	// Requires HTTPS or localhost. Set to false for HTTP access via IP.
 	TransportEncryption bool
+
+	// Copy trade signal logs older than this are rolled up into hourly counts
+	// and the raw rows are deleted (0 = keep all raw logs, minimum 24)
+	SignalLogRetentionHours int
+
+	// Size of a separate read-only connection pool for dashboard queries
+	// (0 = dashboard shares the main database connection)
+	DashboardReadConns int
+
+	// How long dashboard summary/trader statistics are cached (0 = no caching)
+	DashboardCacheSeconds int
+
+	// Annual risk-free rate used by the dashboard Sharpe/Sortino ratios (e.g. 0.04 = 4%, default 0)
+	DashboardRiskFreeRate float64
+
+	// Requests per second allowed to the OKX copy trade API, shared by all traders (0 = default 5)
+	OKXRequestsPerSecond float64
 }
 
 // Init initializes global configuration (from .env)
@@ -30,6 +47,9 @@ func Init() {
 		APIServerPort:       8080,
 		RegistrationEnabled: true,
 		MaxUsers:            20, // Default: max 20 users allowed (0 = unlimited)
+
+		SignalLogRetentionHours: 72,
+		DashboardCacheSeconds:   30,
 	}
 
 	// Load from environment variables
@@ -62,6 +82,40 @@ func Init() {
 		cfg.TransportEncryption = strings.ToLower(v) == "true"
 	}
 
+	if v := os.Getenv("SIGNAL_LOG_RETENTION_HOURS"); v != "" {
+		if hours, err := strconv.Atoi(v); err == nil && hours >= 0 {
+			// The monitor reads raw error messages for the last 24h
+			if hours > 0 && hours < 24 {
+				hours = 24
+			}
+			cfg.SignalLogRetentionHours = hours
+		}
+	}
+
+	if v := os.Getenv("DASHBOARD_READ_CONNS"); v != "" {
+		if conns, err := strconv.Atoi(v); err == nil && conns >= 0 {
+			cfg.DashboardReadConns = conns
+		}
+	}
+
+	if v := os.Getenv("DASHBOARD_CACHE_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			cfg.DashboardCacheSeconds = seconds
+		}
+	}
+
+	if v := os.Getenv("DASHBOARD_RISK_FREE_RATE"); v != "" {
+		if rate, err := strconv.ParseFloat(v, 64); err == nil && rate >= 0 {
+			cfg.DashboardRiskFreeRate = rate
+		}
+	}
+
+	if v := os.Getenv("OKX_COPYTRADE_RPS"); v != "" {
+		if rps, err := strconv.ParseFloat(v, 64); err == nil && rps > 0 {
+			cfg.OKXRequestsPerSecond = rps
+		}
+	}
+
 	global = cfg
 }
 
@@ -0,0 +1,162 @@
+package copytrade
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"nofx/logger"
+	"nofx/store"
+)
+
+// ============================================================================
+// 按领航员近期表现自适应调整跟单系数
+// ============================================================================
+//
+// 有效系数 = CopyRatio × 表现系数，表现系数 = clamp(1 + 近期收益率 × Sensitivity, MinMultiplier, MaxMultiplier)
+// 近期收益率 = 窗口内领航员成交的平仓盈亏（ClosedPnL）合计 / 领航员当前权益。
+// 盈亏来自引擎收到的领航员成交；启动时拉取一次窗口内的历史成交作为基线。
+// 领航员权益未知或窗口内没有平仓时表现系数为 1。只作用于开仓/加仓金额，默认关闭。
+
+// 自适应系数默认参数
+const (
+	DefaultAdaptiveWindowHours   = 168 // 7 天
+	DefaultAdaptiveSensitivity   = 5.0 // 收益率 +10% → 表现系数 1.5
+	DefaultAdaptiveMinMultiplier = 0.5
+	DefaultAdaptiveMaxMultiplier = 1.5
+)
+
+// AdaptiveRatioConfig 自适应跟单系数参数（0 值使用默认值）
+type AdaptiveRatioConfig struct {
+	WindowHours   int     `json:"window_hours"`   // 统计窗口（小时）
+	Sensitivity   float64 `json:"sensitivity"`    // 收益率敏感度
+	MinMultiplier float64 `json:"min_multiplier"` // 表现系数下限
+	MaxMultiplier float64 `json:"max_multiplier"` // 表现系数上限
+}
+
+// window 统计窗口
+func (c *AdaptiveRatioConfig) window() time.Duration {
+	if c.WindowHours > 0 {
+		return time.Duration(c.WindowHours) * time.Hour
+	}
+	return DefaultAdaptiveWindowHours * time.Hour
+}
+
+// multiplier 按收益率计算表现系数
+func (c *AdaptiveRatioConfig) multiplier(roi float64) float64 {
+	sensitivity, lo, hi := c.Sensitivity, c.MinMultiplier, c.MaxMultiplier
+	if sensitivity <= 0 {
+		sensitivity = DefaultAdaptiveSensitivity
+	}
+	if lo <= 0 {
+		lo = DefaultAdaptiveMinMultiplier
+	}
+	if hi <= 0 {
+		hi = DefaultAdaptiveMaxMultiplier
+	}
+	return math.Max(lo, math.Min(hi, 1+roi*sensitivity))
+}
+
+// toAdaptiveRatioConfig 转换存储的自适应系数参数（未配置返回 nil）
+func toAdaptiveRatioConfig(opts *store.CopyTradeAdaptiveRatioOptions) *AdaptiveRatioConfig {
+	if opts == nil {
+		return nil
+	}
+	return &AdaptiveRatioConfig{
+		WindowHours:   opts.WindowHours,
+		Sensitivity:   opts.Sensitivity,
+		MinMultiplier: opts.MinMultiplier,
+		MaxMultiplier: opts.MaxMultiplier,
+	}
+}
+
+// pnlRecord 领航员一笔平仓盈亏
+type pnlRecord struct {
+	pnl float64
+	at  time.Time
+}
+
+// leaderPnLTracker 领航员近期平仓盈亏（按成交 ID 去重）
+type leaderPnLTracker struct {
+	mu      sync.Mutex
+	records map[string]pnlRecord
+}
+
+// recordLeaderPnL 记录领航员成交的平仓盈亏（未开启自适应系数或无盈亏时忽略）
+func (e *Engine) recordLeaderPnL(fill *Fill) {
+	if e.cfg().AdaptiveRatio == nil || fill.ClosedPnL == 0 || fill.ID == "" {
+		return
+	}
+	at := fill.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	t := &e.leaderPnL
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.records == nil {
+		t.records = make(map[string]pnlRecord)
+	}
+	t.records[fill.ID] = pnlRecord{pnl: fill.ClosedPnL, at: at}
+
+	cutoff := time.Now().Add(-e.cfg().AdaptiveRatio.window())
+	for id, r := range t.records {
+		if r.at.Before(cutoff) {
+			delete(t.records, id)
+		}
+	}
+}
+
+// recentLeaderPnL 窗口内领航员平仓盈亏合计
+func (e *Engine) recentLeaderPnL(window time.Duration) float64 {
+	t := &e.leaderPnL
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cutoff := time.Now().Add(-window)
+	total := 0.0
+	for _, r := range t.records {
+		if !r.at.Before(cutoff) {
+			total += r.pnl
+		}
+	}
+	return total
+}
+
+// seedLeaderPnL 启动时拉取窗口内的历史成交作为近期表现基线
+func (e *Engine) seedLeaderPnL() {
+	cfg := e.cfg().AdaptiveRatio
+	if cfg == nil {
+		return
+	}
+	fills, err := e.provider.GetFills(e.cfg().LeaderID, time.Now().Add(-cfg.window()))
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 拉取领航员历史成交失败，自适应系数从零开始统计: %v", e.traderID, err)
+		return
+	}
+	for i := range fills {
+		e.recordLeaderPnL(&fills[i])
+	}
+	logger.Infof("📈 [%s] 自适应系数基线 | 窗口=%s 历史成交=%d 平仓盈亏=%.2f",
+		e.traderID, cfg.window(), len(fills), e.recentLeaderPnL(cfg.window()))
+}
+
+// effectiveCopyRatio 当前生效的跟单系数（未开启自适应系数时为 CopyRatio）
+func (e *Engine) effectiveCopyRatio(signal *TradeSignal) float64 {
+	cfg := e.cfg().AdaptiveRatio
+	if cfg == nil {
+		return e.cfg().CopyRatio
+	}
+
+	pnl := e.recentLeaderPnL(cfg.window())
+	roi := 0.0
+	if signal.LeaderEquity > 0 {
+		roi = pnl / signal.LeaderEquity
+	}
+	multiplier := cfg.multiplier(roi)
+	ratio := e.cfg().CopyRatio * multiplier
+	logger.Infof("📈 [%s] 自适应系数 | 领航员近期盈亏=%.2f 收益率=%.2f%% 表现系数=%.2f | 基础系数=%.0f%% → 有效系数=%.0f%%",
+		e.traderID, pnl, roi*100, multiplier, e.cfg().CopyRatio*100, ratio*100)
+	return ratio
+}
@@ -0,0 +1,58 @@
+package copytrade
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestAdaptiveRatio 按领航员近期平仓收益率缩放跟单系数，受上下限约束，窗口外盈亏不计入
+func TestAdaptiveRatio(t *testing.T) {
+	// 领航员权益 5000，成交价值 1000，跟随者权益 1000，基础系数 1 → 基础跟单金额 200
+	signal := &TradeSignal{
+		LeaderEquity: 5000,
+		Fill:         &Fill{Symbol: "BTCUSDT", Price: 100, Size: 10, Value: 1000},
+	}
+	match := &SignalMatchResult{ShouldFollow: true, Action: ActionOpen}
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		pnl   []float64
+		at    time.Time
+		want  float64
+		extra *AdaptiveRatioConfig
+	}{
+		{name: "no closes", want: 200},
+		{name: "hot leader", pnl: []float64{300, 200}, at: now, want: 300},                                                // 收益率 10% → ×1.5
+		{name: "cold leader", pnl: []float64{-250}, at: now, want: 150},                                                   // 收益率 -5% → ×0.75
+		{name: "capped", pnl: []float64{5000}, at: now, want: 300},                                                        // ×1.5 上限
+		{name: "floored", pnl: []float64{-5000}, at: now, want: 100},                                                      // ×0.5 下限
+		{name: "outside window", pnl: []float64{500}, at: now.Add(-8 * 24 * time.Hour), want: 200},                        // 默认 7 天窗口外
+		{name: "custom bounds", pnl: []float64{500}, at: now, want: 240, extra: &AdaptiveRatioConfig{MaxMultiplier: 1.2}}, // ×1.2 上限
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.extra
+			if cfg == nil {
+				cfg = &AdaptiveRatioConfig{}
+			}
+			e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 1, AdaptiveRatio: cfg}, 1000)
+			for i, pnl := range tt.pnl {
+				e.recordLeaderPnL(&Fill{ID: string(rune('a' + i)), ClosedPnL: pnl, Timestamp: tt.at})
+			}
+			got, _ := e.calculateCopySizeByPositionChange(signal, match)
+			if math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("copy size = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+
+	// 未开启时不记录、不缩放
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.recordLeaderPnL(&Fill{ID: "x", ClosedPnL: 1000, Timestamp: now})
+	if got, _ := e.calculateCopySizeByPositionChange(signal, match); got != 200 {
+		t.Errorf("disabled copy size = %.2f, want 200", got)
+	}
+}
@@ -0,0 +1,51 @@
+package copytrade
+
+import (
+	"fmt"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 加仓前确认领航员仍持仓
+// ============================================================================
+//
+// 平仓成交与加仓成交先后到达且顺序错乱时，延迟的加仓成交可能在领航员已平仓后才被处理；
+// 缓存/WS 状态尚未更新时仍会匹配为加仓。ConfirmAddHolding 开启时，加仓前绕过缓存拉取领航员状态，
+// 目标仓位不存在或小于匹配时的持仓则放弃加仓。状态拉取失败时照常加仓（不因查询失败漏跟）。
+
+// confirmLeaderHolds 加仓前确认领航员仍持有目标仓位（数量不小于匹配时），返回 false 时附带原因
+func (e *Engine) confirmLeaderHolds(match *SignalMatchResult) (bool, string) {
+	if !e.cfg().ConfirmAddHolding || match.Action != ActionAdd || match.LeaderPosition == nil {
+		return true, ""
+	}
+
+	var state *AccountState
+	var err error
+	if fp, ok := e.provider.(FreshStateProvider); ok {
+		state, err = fp.GetAccountStateFresh(e.cfg().LeaderID)
+	} else {
+		state, err = e.provider.GetAccountState(e.cfg().LeaderID)
+	}
+	state = e.scopeState(state)
+	if err != nil || state == nil {
+		logger.Warnf("⚠️ [%s] 加仓前确认领航员持仓失败: %v（照常加仓）", e.traderID, err)
+		return true, ""
+	}
+
+	expected := match.LeaderPosition.Size
+	for key, pos := range state.Positions {
+		posID := pos.PosID
+		if posID == "" {
+			posID = key
+		}
+		if posID != match.PosID || pos.Side != match.LeaderPosition.Side {
+			continue
+		}
+		if pos.Size+1e-12 < expected {
+			return false, fmt.Sprintf("leader no longer holds, add aborted (posId=%s size %.4f < %.4f)", match.PosID, pos.Size, expected)
+		}
+		return true, ""
+	}
+	return false, fmt.Sprintf("leader no longer holds, add aborted (posId=%s closed)", match.PosID)
+}
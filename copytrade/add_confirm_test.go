@@ -0,0 +1,62 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+
+	"nofx/store"
+)
+
+// staleStateProvider 缓存状态落后于实时状态的领航员数据源
+type staleStateProvider struct {
+	fakeProvider
+	fresh *AccountState
+}
+
+func (p *staleStateProvider) GetAccountStateFresh(leaderID string) (*AccountState, error) {
+	return p.fresh, nil
+}
+
+// TestConfirmLeaderHoldsBeforeAdd 领航员已平仓后才到达的延迟加仓成交不跟随
+func TestConfirmLeaderHoldsBeforeAdd(t *testing.T) {
+	run := func(t *testing.T, confirm bool, freshSize float64) int {
+		t.Helper()
+		st := newTestStore(t)
+		posID := PositionKey("BTCUSDT", SideLong)
+		if err := st.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+			TraderID: "test", LeaderPosID: posID, LeaderID: "leader", Symbol: "BTCUSDT",
+			Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 100, LastKnownSize: 1,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		// 缓存仍是加仓后的持仓 1.5；实时状态：freshSize=0 表示领航员已平仓
+		provider := &staleStateProvider{fresh: &AccountState{TotalEquity: 10000, Positions: map[string]*Position{}}}
+		provider.setSize(1.5)
+		if freshSize > 0 {
+			provider.fresh.Positions[posID] = &Position{Symbol: "BTCUSDT", Side: SideLong, Size: freshSize, MarginMode: "cross"}
+		}
+
+		e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1, ConfirmAddHolding: confirm}, 1000)
+		e.store = st
+		e.provider = provider
+		e.processSignal(e.buildSignal(&Fill{
+			ID: "add", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionAdd,
+			Price: 100, Size: 0.5, Value: 50, Timestamp: time.Now(),
+		}))
+		return len(e.decisionCh)
+	}
+
+	if n := run(t, true, 0); n != 0 {
+		t.Errorf("stale add after leader close: %d decisions, want 0", n)
+	}
+	if n := run(t, true, 1.2); n != 0 {
+		t.Errorf("leader position smaller than matched: %d decisions, want 0", n)
+	}
+	if n := run(t, true, 1.5); n != 1 {
+		t.Errorf("leader still holds: %d decisions, want 1", n)
+	}
+	if n := run(t, false, 0); n != 1 {
+		t.Errorf("confirmation disabled: %d decisions, want 1", n)
+	}
+}
@@ -0,0 +1,59 @@
+package copytrade
+
+import (
+	"nofx/logger"
+)
+
+// ============================================================================
+// 加仓金额计算方式
+// ============================================================================
+//
+// 默认按领航员加仓成交价值占其权益的比例计算跟单金额（fill_value）。
+// 跟随者底仓与领航员不成比例时（如启动后才跟上、最小金额被提升过），连续加仓后偏差会一直保留。
+// match_increase_ratio：加仓倍数 = 领航员加仓后持仓 / 加仓前持仓（lastKnownSize），
+// 跟随者按同样倍数放大自己的现有仓位，跟单金额 = 跟随者持仓 × (倍数 - 1) × 成交价。
+// 缺少加仓前持仓、跟随者无持仓或倍数 <= 1 时回退到 fill_value。
+
+// 加仓金额计算方式
+const (
+	AddSizingFillValue          = "fill_value"           // 按成交价值占权益比例（默认）
+	AddSizingMatchIncreaseRatio = "match_increase_ratio" // 按领航员持仓放大倍数放大跟随者持仓
+)
+
+// increaseRatioAddSize 按领航员加仓倍数计算跟单金额
+// 返回值：跟单金额、领航员本次加仓价值、是否适用（false 时按 fill_value 计算）
+func (e *Engine) increaseRatioAddSize(signal *TradeSignal, match *SignalMatchResult) (float64, float64, bool) {
+	if e.cfg().AddSizingMode != AddSizingMatchIncreaseRatio || match.Action != ActionAdd {
+		return 0, 0, false
+	}
+	fill := signal.Fill
+	if match.LeaderPosition == nil || e.store == nil || fill.Price <= 0 || e.getFollowerBalance() <= 0 {
+		logger.Infof("📊 [%s] 加仓倍数不可用（无持仓信息/价格/余额），按成交价值计算 | %s", e.traderID, fill.Symbol)
+		return 0, 0, false
+	}
+
+	mapping, err := e.store.CopyTrade().GetMapping(e.traderID, match.PosID)
+	if err != nil || mapping == nil || mapping.LastKnownSize <= 0 {
+		logger.Infof("📊 [%s] 加仓倍数不可用（缺少加仓前持仓），按成交价值计算 | %s posId=%s", e.traderID, fill.Symbol, match.PosID)
+		return 0, 0, false
+	}
+	increaseRatio := match.LeaderPosition.Size / mapping.LastKnownSize
+	if increaseRatio <= 1 {
+		logger.Infof("📊 [%s] 加仓倍数 %.4f <= 1（持仓未增加），按成交价值计算 | %s posId=%s",
+			e.traderID, increaseRatio, fill.Symbol, match.PosID)
+		return 0, 0, false
+	}
+
+	followerSize := e.followerPositionSize(fill.Symbol, fill.PositionSide, match.MarginMode)
+	if followerSize <= 0 {
+		logger.Infof("📊 [%s] 跟随者无 %s %s 持仓，按成交价值计算", e.traderID, fill.Symbol, fill.PositionSide)
+		return 0, 0, false
+	}
+
+	copySize := followerSize * (increaseRatio - 1) * fill.Price
+	leaderValue := (match.LeaderPosition.Size - mapping.LastKnownSize) * fill.Price
+	logger.Infof("📊 [%s] 加仓倍数计算 | %s 领航员: %.4f → %.4f 倍数=%.4f | 跟随者持仓=%.4f 价格=%.4f → 跟单=%.2f",
+		e.traderID, fill.Symbol, mapping.LastKnownSize, match.LeaderPosition.Size, increaseRatio,
+		followerSize, fill.Price, copySize)
+	return copySize, leaderValue, true
+}
@@ -0,0 +1,70 @@
+package copytrade
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"nofx/store"
+)
+
+// TestAddSizingMatchIncreaseRatio 连续加仓：跟随者按领航员持仓放大倍数放大自己的持仓，始终保持同一比例
+func TestAddSizingMatchIncreaseRatio(t *testing.T) {
+	st := newTestStore(t)
+	posID := PositionKey("BTCUSDT", SideLong)
+	if err := st.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+		TraderID: "test", LeaderPosID: posID, LeaderID: "leader", Symbol: "BTCUSDT",
+		Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 100, LastKnownSize: 1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 跟随者底仓 0.3（与领航员 1 不成比例），领航员权益很大：按成交价值会算出很小的金额
+	followerSize := 0.3
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 1, AddSizingMode: AddSizingMatchIncreaseRatio}, 1000)
+	e.store = st
+	e.getFollowerPositions = func() map[string]*Position {
+		return map[string]*Position{posID: {Symbol: "BTCUSDT", Side: SideLong, Size: followerSize, MarginMode: "cross"}}
+	}
+
+	// 领航员 1 → 2 → 3 → 6，跟随者应依次 0.3 → 0.6 → 0.9 → 1.8
+	lastKnown := 1.0
+	for _, leaderSize := range []float64{2, 3, 6} {
+		fill := &Fill{Symbol: "BTCUSDT", PositionSide: SideLong, Action: ActionOpen, Price: 100,
+			Size: leaderSize - lastKnown, Value: (leaderSize - lastKnown) * 100}
+		signal := &TradeSignal{Fill: fill, LeaderEquity: 1_000_000}
+		match := &SignalMatchResult{Action: ActionAdd, PosID: posID, MarginMode: "cross",
+			LeaderPosition: &Position{Symbol: "BTCUSDT", Side: SideLong, Size: leaderSize, MarginMode: "cross"}}
+
+		got, _ := e.calculateCopySizeByPositionChange(signal, match)
+		want := followerSize * (leaderSize/lastKnown - 1) * 100
+		if math.Abs(got-want) > 1e-9 {
+			t.Fatalf("leader %.0f → %.0f: copy size = %.4f, want %.4f", lastKnown, leaderSize, got, want)
+		}
+
+		followerSize += got / 100
+		lastKnown = leaderSize
+		if err := st.CopyTrade().UpdateLastKnownSize("test", posID, leaderSize); err != nil {
+			t.Fatal(err)
+		}
+		if ratio := followerSize / leaderSize; math.Abs(ratio-0.3) > 1e-9 {
+			t.Fatalf("follower/leader = %.4f after add to %.0f, want 0.3", ratio, leaderSize)
+		}
+	}
+
+	// fill_value（默认）：按成交价值占权益比例，金额极小被提升到最小阈值
+	withConfig(e, func(c *CopyConfig) { c.AddSizingMode = "" })
+	fill := &Fill{Symbol: "BTCUSDT", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 6, Value: 600}
+	match := &SignalMatchResult{Action: ActionAdd, PosID: posID, MarginMode: "cross",
+		LeaderPosition: &Position{Symbol: "BTCUSDT", Side: SideLong, Size: 12, MarginMode: "cross"}}
+	if got, _ := e.calculateCopySizeByPositionChange(&TradeSignal{Fill: fill, LeaderEquity: 1_000_000}, match); got != DefaultMinTradeAmount {
+		t.Errorf("fill_value copy size = %.4f, want %.2f", got, DefaultMinTradeAmount)
+	}
+
+	// 跟随者无持仓时回退到 fill_value
+	withConfig(e, func(c *CopyConfig) { c.AddSizingMode = AddSizingMatchIncreaseRatio })
+	e.getFollowerPositions = func() map[string]*Position { return map[string]*Position{} }
+	if got, _ := e.calculateCopySizeByPositionChange(&TradeSignal{Fill: fill, LeaderEquity: 1_000_000}, match); got != DefaultMinTradeAmount {
+		t.Errorf("fallback copy size = %.4f, want %.2f", got, DefaultMinTradeAmount)
+	}
+}
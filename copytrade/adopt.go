@@ -0,0 +1,97 @@
+package copytrade
+
+import (
+	"fmt"
+	"time"
+
+	"nofx/logger"
+	"nofx/store"
+)
+
+// ============================================================================
+// 接管跟随者已有持仓
+// ============================================================================
+//
+// 启动时领航员已有的仓位被标记为 ignored，即使用户手动按任意数量开了对应的仓位，
+// 领航员之后的减仓/平仓也不会跟随。接管把该仓位转为 active 映射，同时记录
+// 按比例应有的持仓（expected）和跟随者实际持仓（adopted）：
+//   - 比例减仓（默认）本来就作用于实际持仓，不受影响
+//   - 绝对数量减仓（scaled_absolute）的系数按 实际/应有 校正，使减仓数量相对实际持仓成比例
+
+// AdoptPosition 接管领航员仓位 posID 对应的跟随者已有持仓
+func (e *Engine) AdoptPosition(posID string) (*store.CopyTradePositionMapping, error) {
+	if e.store == nil {
+		return nil, fmt.Errorf("store not configured")
+	}
+	e.cfgMu.RLock()
+	defer e.cfgMu.RUnlock()
+
+	if err := e.syncLeaderState(); err != nil {
+		logger.Warnf("⚠️ [%s] 接管前同步领航员状态失败: %v（使用缓存）", e.traderID, err)
+	}
+	leaderPos, ok := e.buildLeaderPosMap()[posID]
+	if !ok || leaderPos.Size <= 0 {
+		return nil, fmt.Errorf("leader position %s not found", posID)
+	}
+
+	existing, err := e.store.CopyTrade().GetActiveMapping(e.traderID, posID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query mapping: %w", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("leader position %s is already followed", posID)
+	}
+
+	actual := e.followerPositionSize(leaderPos.Symbol, leaderPos.Side, leaderPos.MarginMode)
+	if actual <= 0 {
+		return nil, fmt.Errorf("follower has no %s %s position to adopt", leaderPos.Symbol, e.followerSide(leaderPos.Side))
+	}
+
+	// 按当前权益计算应有持仓（权益未知时为 0，减仓退回按比例计算）
+	expected := 0.0
+	e.leaderStateMu.RLock()
+	leaderEquity := 0.0
+	if e.leaderState != nil {
+		leaderEquity = e.leaderState.TotalEquity
+	}
+	e.leaderStateMu.RUnlock()
+	if followerEquity := e.getFollowerBalance(); leaderEquity > 0 && followerEquity > 0 {
+		expected = leaderPos.Size * e.cfg().CopyRatio * followerEquity / leaderEquity
+	}
+
+	price := leaderPos.MarkPrice
+	if price <= 0 {
+		price = leaderPos.EntryPrice
+	}
+	mapping := &store.CopyTradePositionMapping{
+		TraderID:      e.traderID,
+		LeaderPosID:   posID,
+		LeaderID:      e.cfg().LeaderID,
+		Symbol:        leaderPos.Symbol,
+		Side:          string(e.followerSide(leaderPos.Side)),
+		MarginMode:    leaderPos.MarginMode,
+		OpenedAt:      time.Now(),
+		OpenPrice:     leaderPos.EntryPrice,
+		OpenSizeUSD:   actual * price,
+		LastKnownSize: leaderPos.Size,
+		OpenQty:       actual,
+		AvgEntryPrice: price, // 盈亏从接管时开始计算
+		ExpectedSize:  expected,
+		AdoptedSize:   actual,
+	}
+	if err := e.store.CopyTrade().AdoptPositionMapping(mapping); err != nil {
+		return nil, fmt.Errorf("failed to save mapping: %w", err)
+	}
+
+	logger.Infof("🤝 [%s] 接管仓位 | posId=%s %s %s | 应有=%.6f 实际=%.6f 领航员=%.6f",
+		e.traderID, posID, leaderPos.Symbol, leaderPos.Side, expected, actual, leaderPos.Size)
+	return mapping, nil
+}
+
+// adoptedSizeFactor 接管仓位的持仓校正系数（实际/应有），非接管仓位或无法计算时返回 1
+func adoptedSizeFactor(m *store.CopyTradePositionMapping) float64 {
+	if m == nil || m.AdoptedSize <= 0 || m.ExpectedSize <= 0 {
+		return 1
+	}
+	return m.AdoptedSize / m.ExpectedSize
+}
@@ -0,0 +1,54 @@
+package copytrade
+
+import (
+	"math"
+	"testing"
+)
+
+// TestAdoptPositionReduce 接管手动开的持仓后，领航员减仓 50% → 跟随者按实际持仓减仓 50%
+func TestAdoptPositionReduce(t *testing.T) {
+	for _, mode := range []string{ReduceModeRatio, ReduceModeScaledAbsolute} {
+		t.Run(mode, func(t *testing.T) {
+			st := newTestStore(t)
+			provider := &fakeProvider{}
+			provider.setSize(2)
+			e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1, ReduceMode: mode}, 1000)
+			e.store = st
+			e.provider = provider
+			// 用户手动开了 0.3 BTC（按比例应为 2 × 1000/10000 = 0.2）
+			follower := &Position{Symbol: "BTCUSDT", Side: SideLong, Size: 0.3, MarginMode: "cross"}
+			e.getFollowerPositions = func() map[string]*Position {
+				return map[string]*Position{PositionKey("BTCUSDT", SideLong): follower}
+			}
+
+			// 启动时领航员已有仓位 → ignored
+			if err := e.InitIgnoredPositions(); err != nil {
+				t.Fatal(err)
+			}
+			posID := PositionKey("BTCUSDT", SideLong)
+			m, err := e.AdoptPosition(posID)
+			if err != nil {
+				t.Fatalf("AdoptPosition: %v", err)
+			}
+			if math.Abs(m.ExpectedSize-0.2) > 1e-9 || m.AdoptedSize != 0.3 || m.LastKnownSize != 2 {
+				t.Errorf("mapping expected=%.4f adopted=%.4f lastKnown=%.4f, want 0.2/0.3/2", m.ExpectedSize, m.AdoptedSize, m.LastKnownSize)
+			}
+			if _, err := e.AdoptPosition(posID); err == nil {
+				t.Error("adopting an already followed position should fail")
+			}
+
+			// 领航员减仓 50%
+			provider.setSize(1)
+			e.processSignal(&TradeSignal{LeaderEquity: 10000, Fill: &Fill{ID: "r1", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionReduce, Price: 100, Size: 1, Value: 100}})
+			select {
+			case fullDec := <-e.decisionCh:
+				dec := fullDec.Decisions[0]
+				if dec.Action != "reduce_long" || math.Abs(dec.CloseRatio-0.5) > 1e-9 {
+					t.Errorf("decision = %s %.4f, want reduce_long 0.5 of the adopted size", dec.Action, dec.CloseRatio)
+				}
+			default:
+				t.Fatal("reduce on adopted position was not followed")
+			}
+		})
+	}
+}
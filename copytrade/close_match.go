@@ -0,0 +1,62 @@
+package copytrade
+
+import (
+	"math"
+	"sort"
+
+	"nofx/logger"
+	"nofx/store"
+)
+
+// ============================================================================
+// 平仓/减仓成交的映射配对
+// ============================================================================
+//
+// 同一币种同一方向有多个仓位（如 OKX 全仓 + 逐仓、多个 posId）时，领航员在同一批成交里
+// 减仓一个、平仓另一个，按映射顺序取第一个有变化的映射会把成交配给错误的仓位。
+// "best_fit"（默认）按 |映射的持仓减少量 - 成交数量| 从小到大排序候选映射，
+// 让每笔成交配对持仓变化最吻合的仓位；"first" 保持按映射顺序取第一个。
+
+// 平仓/减仓成交的映射配对策略
+const (
+	CloseMatchBestFit = "best_fit" // 按持仓减少量与成交数量最吻合的映射配对（默认）
+	CloseMatchFirst   = "first"    // 按映射顺序取第一个有变化的映射
+)
+
+// rankByFillFit 按持仓减少量与成交数量的差值对候选映射排序
+// 持仓未减少的映射排在最后；posId 消失但没有记录 lastKnownSize 的旧映射排在已知减少量之后
+func (e *Engine) rankByFillFit(mappings []*store.CopyTradePositionMapping, leaderPosMap map[string]*Position, fillSize float64) []*store.CopyTradePositionMapping {
+	if e.cfg().CloseMatchStrategy == CloseMatchFirst || len(mappings) < 2 || fillSize <= 0 {
+		return mappings
+	}
+
+	residual := make(map[string]float64, len(mappings))
+	for _, m := range mappings {
+		pos := leaderPosMap[m.LeaderPosID]
+		if pos != nil && string(e.followerSide(pos.Side)) != m.Side {
+			pos = nil
+		}
+		current := 0.0
+		if pos != nil {
+			current = pos.Size
+		}
+		switch {
+		case pos == nil && m.LastKnownSize <= 0:
+			residual[m.LeaderPosID] = math.MaxFloat64 / 2
+		case m.LastKnownSize > current:
+			residual[m.LeaderPosID] = math.Abs(m.LastKnownSize - current - fillSize)
+		default:
+			residual[m.LeaderPosID] = math.MaxFloat64
+		}
+	}
+
+	ranked := append([]*store.CopyTradePositionMapping(nil), mappings...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return residual[ranked[i].LeaderPosID] < residual[ranked[j].LeaderPosID]
+	})
+	if ranked[0].LeaderPosID != mappings[0].LeaderPosID {
+		logger.Infof("📊 [%s] 多仓位配对 | 成交数量=%.4f → posId=%s（持仓变化最吻合，残差=%.4f）",
+			e.traderID, fillSize, ranked[0].LeaderPosID, residual[ranked[0].LeaderPosID])
+	}
+	return ranked
+}
@@ -0,0 +1,58 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+
+	"nofx/store"
+)
+
+// TestCloseReduceSameBatch 同一批成交里减仓一个仓位、平仓另一个同币种仓位：每笔成交配对持仓变化最吻合的仓位
+func TestCloseReduceSameBatch(t *testing.T) {
+	run := func(t *testing.T, strategy string) []string {
+		t.Helper()
+		st := newTestStore(t)
+		for _, posID := range []string{"A", "B"} {
+			if err := st.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+				TraderID: "test", LeaderPosID: posID, LeaderID: "leader", Symbol: "BTCUSDT",
+				Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 100, LastKnownSize: 1,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// 批次后领航员状态：A 已平，B 从 1 减到 0.7
+		provider := &fakeProvider{state: &AccountState{TotalEquity: 10000, Positions: map[string]*Position{
+			"B": {Symbol: "BTCUSDT", Side: SideLong, Size: 0.7, MarginMode: "cross", PosID: "B"},
+		}}}
+		e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 1, CloseMatchStrategy: strategy}, 1000)
+		e.store = st
+		e.provider = provider
+
+		// 减仓成交（0.3）先到，平仓成交（1.0）后到
+		var got []string
+		for i, size := range []float64{0.3, 1.0} {
+			e.processSignal(e.buildSignal(&Fill{
+				ID: string(rune('a' + i)), Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionClose,
+				Price: 100, Size: size, Value: size * 100, Timestamp: time.Now(),
+			}))
+			if len(e.decisionCh) != 1 {
+				t.Fatalf("fill %.1f: decisions = %d, want 1", size, len(e.decisionCh))
+			}
+			d := (<-e.decisionCh).Decisions[0]
+			got = append(got, d.LeaderPosID+":"+d.Action)
+		}
+		return got
+	}
+
+	got := run(t, "")
+	if got[0] != "B:reduce_long" || got[1] != "A:close_long" {
+		t.Errorf("best fit = %v, want [B:reduce_long A:close_long]", got)
+	}
+
+	// first：按映射顺序，减仓成交被配给已平仓的 A
+	got = run(t, CloseMatchFirst)
+	if got[0] != "A:close_long" {
+		t.Errorf("first = %v, want reduce fill paired with A", got)
+	}
+}
@@ -0,0 +1,138 @@
+package copytrade
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+
+	"nofx/logger"
+	"nofx/store"
+)
+
+// ============================================================================
+// 配置热更新：保存配置时不重启引擎
+// ============================================================================
+//
+// 停止再启动会重新执行 InitIgnoredPositions，把已跟随仓位之外的领航员持仓重新标记为 ignored，
+// 用户只是调整比例/上限也可能错过加仓。因此非结构性配置（比例、预警阈值、过滤条件、上限等）
+// 直接替换引擎配置，不动仓位映射、不重连；只有数据源或领航员变化时才需要重启。
+
+// ErrRestartRequired 结构性配置（数据源/领航员）变化，无法热更新，需重启引擎
+var ErrRestartRequired = errors.New("copy config change requires engine restart")
+
+// ErrInverseWithActiveMappings 存在活跃跟单映射时切换反向跟单
+// 已有映射记录的是切换前的跟随者方向，切换后领航员减仓/平仓将匹配不到这些映射，仓位会失去跟随
+var ErrInverseWithActiveMappings = errors.New("cannot toggle inverse while copy positions are active")
+
+// CheckInverseChange 保存配置前检查反向跟单切换：trader 存在活跃映射时返回 ErrInverseWithActiveMappings
+// old 为 nil（首次保存）或 inverse 未变化时不检查；未运行的跟单同样检查，避免下次启动时映射方向不一致
+func CheckInverseChange(st *store.Store, old, next *store.CopyTradeConfig) error {
+	if old == nil || next == nil || old.Options.Inverse == next.Options.Inverse {
+		return nil
+	}
+	active, err := st.CopyTrade().ListActiveMappings(next.TraderID)
+	if err != nil {
+		return fmt.Errorf("failed to list active mappings: %w", err)
+	}
+	if len(active) > 0 {
+		return fmt.Errorf("%w: %d active mapping(s), close them before switching inverse %v -> %v",
+			ErrInverseWithActiveMappings, len(active), old.Options.Inverse, next.Options.Inverse)
+	}
+	return nil
+}
+
+// UpdateConfig 热更新引擎配置，从下一个信号开始生效
+// 正在处理的信号使用旧配置完成；数据源或领航员变化时返回 ErrRestartRequired，
+// 存在活跃映射时切换反向跟单返回 ErrInverseWithActiveMappings（配置保持不变）
+func (e *Engine) UpdateConfig(cfg *CopyConfig) error {
+	if cfg == nil {
+		return errors.New("copy config is nil")
+	}
+
+	e.cfgMu.Lock()
+	defer e.cfgMu.Unlock()
+
+	old := e.config.Load()
+	if requiresRestart(old, cfg) {
+		return fmt.Errorf("%w: %s:%s -> %s:%s (leaders %d -> %d)", ErrRestartRequired,
+			old.ProviderType, old.LeaderID, cfg.ProviderType, cfg.LeaderID, len(old.Leaders), len(cfg.Leaders))
+	}
+
+	if cfg.Inverse != old.Inverse && e.store != nil {
+		active, err := e.listActiveMappings()
+		if err != nil {
+			return fmt.Errorf("failed to list active mappings: %w", err)
+		}
+		if len(active) > 0 {
+			return fmt.Errorf("%w: %d active mapping(s)", ErrInverseWithActiveMappings, len(active))
+		}
+	}
+
+	next := *cfg
+	e.config.Store(&next)
+
+	// OKX 合约映射：下一次拉取成交/持仓时生效
+	if !maps.Equal(next.OKXInstrumentMap, old.OKXInstrumentMap) {
+		e.applyInstrumentMap()
+	}
+
+	// 试用额度变化：重新计算是否进入只平仓模式（调高额度后恢复开仓）
+	if next.TrialTradeLimit != old.TrialTradeLimit {
+		e.loadTrialProgress()
+	}
+
+	// 模拟运行切换：已有活跃映射不会自动清理，可能与跟随者实际持仓不一致
+	if next.DryRun != old.DryRun {
+		logger.Warnf("⚠️ [%s] dry_run %v → %v，已有活跃映射保持不变，请确认与实际持仓一致", e.traderID, old.DryRun, next.DryRun)
+	}
+
+	if fields := restartOnlyChanges(old, &next); len(fields) > 0 {
+		logger.Warnf("⚠️ [%s] 以下配置需重启跟单后生效: %v", e.traderID, fields)
+	}
+	logger.Infof("🔄 [%s] 跟单配置已热更新 | ratio=%.0f%% 仓位映射保持不变",
+		e.traderID, next.CopyRatio*100)
+	return nil
+}
+
+// Config 返回当前生效配置的副本
+func (e *Engine) Config() CopyConfig {
+	return *e.cfg()
+}
+
+// restartOnlyChanges 已变化但只在引擎启动时读取的配置项（后台任务在 Start 中按配置启动）
+func restartOnlyChanges(old, next *CopyConfig) []string {
+	var fields []string
+	if old.Profiling != next.Profiling {
+		fields = append(fields, "profiling")
+	}
+	if old.DriftCheckMinutes != next.DriftCheckMinutes {
+		fields = append(fields, "drift_check_minutes")
+	}
+	// 最大持仓时间检查协程只在启用时启动；已启用时修改时长即时生效
+	if old.MaxHoldHours <= 0 && next.MaxHoldHours > 0 {
+		fields = append(fields, "max_hold_hours")
+	}
+	// 镜像挂单协程只在启动时开启；运行中关闭即时生效
+	if !old.MirrorLeaderOrders && next.MirrorLeaderOrders {
+		fields = append(fields, "mirror_leader_orders")
+	}
+	if old.PollInterval != next.PollInterval {
+		fields = append(fields, "poll_interval_seconds")
+	}
+	if old.DedupScope != next.DedupScope {
+		fields = append(fields, "dedup_scope")
+	}
+	if old.StateUnavailablePolicy != next.StateUnavailablePolicy {
+		fields = append(fields, "state_unavailable_policy")
+	}
+	if old.InitialSync != next.InitialSync {
+		fields = append(fields, "initial_sync")
+	}
+	return fields
+}
+
+// requiresRestart 配置变化是否需要重启引擎（数据源、领航员或多领航员列表变化）
+func requiresRestart(old, next *CopyConfig) bool {
+	return old.ProviderType != next.ProviderType || old.LeaderID != next.LeaderID ||
+		!sameLeaders(old.Leaders, next.Leaders)
+}
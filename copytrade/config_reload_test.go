@@ -0,0 +1,339 @@
+package copytrade
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"nofx/store"
+)
+
+// TestReloadCopyTradingConfig 运行中保存配置：非结构性配置热更新且不动仓位映射，停用时停止跟单
+func TestReloadCopyTradingConfig(t *testing.T) {
+	st := newTestStore(t)
+	if _, err := st.DB().Exec(`INSERT INTO traders (id, name, ai_model_id, exchange_id, initial_balance) VALUES ('test', 'T', 'deepseek', 'binance', 1000)`); err != nil {
+		t.Fatal(err)
+	}
+	saveConfig := func(ratio float64, leader string, enabled bool) {
+		t.Helper()
+		if err := st.CopyTrade().Upsert(&store.CopyTradeConfig{
+			TraderID: "test", ProviderType: "hyperliquid", LeaderID: leader,
+			CopyRatio: ratio, Enabled: enabled,
+			Options: store.CopyTradeOptions{LeaderBudget: 500},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saveConfig(1.0, "leader", true)
+	if err := st.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+		TraderID: "test", LeaderPosID: "BTCUSDT_long", LeaderID: "leader", Symbol: "BTCUSDT",
+		Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 100,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1.0}, 1000)
+	e.SetStore(st)
+	ti := NewTraderIntegration("test", nil, st)
+	ti.engine = e
+	ti.running.Store(true)
+	setIntegration("test", ti)
+	t.Cleanup(func() { removeIntegration("test") })
+
+	saveConfig(0.5, "leader", true)
+	reload, err := ReloadCopyTradingConfig("test")
+	if err != nil || reload != ConfigReloadUpdated {
+		t.Fatalf("reload = %q, %v; want %q", reload, err, ConfigReloadUpdated)
+	}
+	if e.cfg().CopyRatio != 0.5 || e.cfg().LeaderBudget != 500 {
+		t.Errorf("config not swapped: ratio=%v budget=%v", e.cfg().CopyRatio, e.cfg().LeaderBudget)
+	}
+	if !IsCopyTradingRunning("test") {
+		t.Error("hot reload should not stop the integration")
+	}
+	active, err := st.CopyTrade().ListActiveMappings("test")
+	if err != nil || len(active) != 1 {
+		t.Errorf("active mappings = %d, %v; want untouched", len(active), err)
+	}
+
+	// 领航员变化：引擎拒绝热更新，需要重启
+	if err := e.UpdateConfig(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "other"}); !errors.Is(err, ErrRestartRequired) {
+		t.Errorf("leader change err = %v, want ErrRestartRequired", err)
+	}
+	if e.cfg().LeaderID != "leader" {
+		t.Errorf("leader = %q, rejected update must keep old config", e.cfg().LeaderID)
+	}
+
+	saveConfig(0.5, "leader", false)
+	reload, err = ReloadCopyTradingConfig("test")
+	if err != nil || reload != ConfigReloadStopped {
+		t.Fatalf("reload = %q, %v; want %q", reload, err, ConfigReloadStopped)
+	}
+	if IsCopyTradingRunning("test") {
+		t.Error("disabled config should stop copy trading")
+	}
+}
+
+// TestIntegrationRegistryConcurrent 配置重载、启动/停止与 API 查询并发访问全局集成表（配合 -race 运行）
+func TestIntegrationRegistryConcurrent(t *testing.T) {
+	st := newTestStore(t)
+	if _, err := st.DB().Exec(`INSERT INTO traders (id, name, ai_model_id, exchange_id, initial_balance) VALUES ('test', 'T', 'deepseek', 'binance', 1000)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.CopyTrade().Upsert(&store.CopyTradeConfig{
+		TraderID: "test", ProviderType: "hyperliquid", LeaderID: "leader", CopyRatio: 1, Enabled: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.SetStore(st)
+	ti := NewTraderIntegration("test", nil, st)
+	ti.engine = e
+	ti.running.Store(true)
+	setIntegration("test", ti)
+	t.Cleanup(func() {
+		removeIntegration("test")
+		removeIntegration("churn")
+	})
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(3)
+	go func() { // 配置重载
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			if _, err := ReloadCopyTradingConfig("test"); err != nil {
+				t.Errorf("reload: %v", err)
+				return
+			}
+		}
+	}()
+	go func() { // 其他 trader 启动/停止
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			churn := NewTraderIntegration("churn", nil, st)
+			churn.engine = newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader"}, 1000)
+			setIntegration("churn", churn)
+			StopCopyTradingForTrader("churn")
+		}
+	}()
+	go func() { // API 查询
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			IsCopyTradingRunning("test")
+			GetCopyTradingStats("churn")
+			ListCopyTradingHealth()
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(done)
+	wg.Wait()
+
+	if !IsCopyTradingRunning("test") {
+		t.Error("hot reloads should keep the integration running")
+	}
+}
+
+// TestUpdateConfigRatio 比例热更新：进行中的信号用旧比例完成，下一次计算用新比例，已有映射不变
+func TestUpdateConfigRatio(t *testing.T) {
+	st := newTestStore(t)
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 1.0}, 1000)
+	e.SetStore(st)
+	if err := st.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+		TraderID: "test", LeaderPosID: "123", LeaderID: "leader", Symbol: "BTCUSDT",
+		Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 200,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	signal := &TradeSignal{
+		LeaderEquity: 5000,
+		Fill:         &Fill{Symbol: "BTCUSDT", Price: 100, Size: 10, Value: 1000},
+	}
+	match := &SignalMatchResult{ShouldFollow: true, Action: ActionOpen}
+	if got, _ := e.calculateCopySizeByPositionChange(signal, match); got != 200 {
+		t.Fatalf("copy size = %.2f, want 200", got)
+	}
+
+	// 模拟信号处理中（持有读锁）：更新等待信号处理完成
+	e.cfgMu.RLock()
+	done := make(chan error, 1)
+	go func() {
+		done <- e.UpdateConfig(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 0.5, SyncLeverage: true})
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if got, _ := e.calculateCopySizeByPositionChange(signal, match); got != 200 {
+		t.Errorf("in-flight copy size = %.2f, want old ratio 200", got)
+	}
+	e.cfgMu.RUnlock()
+	if err := <-done; err != nil {
+		t.Fatalf("UpdateConfig: %v", err)
+	}
+
+	if got, _ := e.calculateCopySizeByPositionChange(signal, match); got != 100 {
+		t.Errorf("next copy size = %.2f, want 100", got)
+	}
+	if cfg := e.Config(); !cfg.SyncLeverage {
+		t.Error("sync flag not applied")
+	}
+	m, err := st.CopyTrade().GetMapping("test", "123")
+	if err != nil || m == nil || m.Status != "active" || m.OpenSizeUSD != 200 {
+		t.Errorf("mapping = %+v, %v; want untouched active mapping", m, err)
+	}
+
+	// 结构性字段变化：拒绝并保留原配置
+	for _, cfg := range []*CopyConfig{
+		{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 2},
+		{ProviderType: ProviderOKX, LeaderID: "other", CopyRatio: 2},
+	} {
+		if err := e.UpdateConfig(cfg); !errors.Is(err, ErrRestartRequired) {
+			t.Errorf("UpdateConfig(%s:%s) err = %v, want ErrRestartRequired", cfg.ProviderType, cfg.LeaderID, err)
+		}
+	}
+	if cfg := e.Config(); cfg.CopyRatio != 0.5 {
+		t.Errorf("ratio = %v after rejected update, want 0.5", cfg.CopyRatio)
+	}
+}
+
+// TestUpdateConfigInverseWithActiveMappings 存在活跃映射时拒绝切换反向跟单，映射全部平仓后允许切换
+func TestUpdateConfigInverseWithActiveMappings(t *testing.T) {
+	st := newTestStore(t)
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.SetStore(st)
+	if err := st.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+		TraderID: "test", LeaderPosID: "123", LeaderID: "leader", Symbol: "BTCUSDT",
+		Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 200,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	inverse := &CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 1, Inverse: true}
+	if err := e.UpdateConfig(inverse); !errors.Is(err, ErrInverseWithActiveMappings) {
+		t.Fatalf("UpdateConfig(inverse) err = %v, want ErrInverseWithActiveMappings", err)
+	}
+	if e.Config().Inverse {
+		t.Error("inverse applied despite active mapping")
+	}
+
+	old := &store.CopyTradeConfig{TraderID: "test", ProviderType: "okx", LeaderID: "leader", CopyRatio: 1}
+	next := *old
+	next.Options.Inverse = true
+	if err := CheckInverseChange(st, old, &next); !errors.Is(err, ErrInverseWithActiveMappings) {
+		t.Errorf("CheckInverseChange err = %v, want ErrInverseWithActiveMappings", err)
+	}
+	if err := CheckInverseChange(st, old, old); err != nil {
+		t.Errorf("CheckInverseChange(unchanged) err = %v", err)
+	}
+
+	if err := st.CopyTrade().CloseMapping("test", "123", 100, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckInverseChange(st, old, &next); err != nil {
+		t.Errorf("CheckInverseChange without mappings err = %v", err)
+	}
+	if err := e.UpdateConfig(inverse); err != nil || !e.Config().Inverse {
+		t.Errorf("UpdateConfig(inverse) without mappings err = %v inverse = %v", err, e.Config().Inverse)
+	}
+}
+
+// churnProvider 每次拉取返回一笔新成交，交替开仓/平仓
+type churnProvider struct {
+	*fakeProvider
+	n int
+}
+
+func (p *churnProvider) GetFills(leaderID string, since time.Time) ([]Fill, error) {
+	p.n++
+	fill := Fill{ID: fmt.Sprintf("f%d", p.n), Symbol: "BTCUSDT", PositionSide: SideLong, Price: 100, Size: 1, Value: 100, Timestamp: time.Now()}
+	if p.n%2 == 1 {
+		p.setSize(1)
+		fill.Side, fill.Action = "buy", ActionOpen
+	} else {
+		p.state = &AccountState{TotalEquity: 10000, Positions: map[string]*Position{}}
+		fill.Side, fill.Action = "sell", ActionClose
+	}
+	return []Fill{fill}, nil
+}
+
+// TestUpdateConfigConcurrentWithSignals 热更新与轮询、决策消费并发执行（配合 go test -race）
+func TestUpdateConfigConcurrentWithSignals(t *testing.T) {
+	st := newTestStore(t)
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.store = st
+	e.provider = &churnProvider{fakeProvider: &fakeProvider{}}
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e, executor: &fakeExecutor{}}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			case fullDec := <-e.decisionCh:
+				ti.executeFullDecision(fullDec)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			ratio := 1.0
+			if i%2 == 1 {
+				ratio = 0.5
+			}
+			if err := e.UpdateConfig(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: ratio,
+				OutOfWindowFills: OutOfWindowKeep, DryRun: i%2 == 1}); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 40; i++ {
+		e.poll()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(done)
+	wg.Wait()
+
+	if got := e.Config().CopyRatio; got != 0.5 {
+		t.Errorf("final ratio = %v, want 0.5", got)
+	}
+	if e.stats.SignalsReceived != 40 {
+		t.Errorf("signals received = %d, want 40", e.stats.SignalsReceived)
+	}
+}
+
+// TestUpdateConfigWaitsForSignal 信号处理期间（持配置读锁）热更新等待，信号内始终读到同一份配置
+func TestUpdateConfigWaitsForSignal(t *testing.T) {
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1}, 1000)
+
+	e.cfgMu.RLock()
+	snapshot := e.cfg()
+	updated := make(chan error, 1)
+	go func() {
+		updated <- e.UpdateConfig(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 0.5})
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if e.cfg() != snapshot || e.cfg().CopyRatio != 1 {
+		t.Error("config swapped while a signal holds the read lock")
+	}
+	e.cfgMu.RUnlock()
+
+	if err := <-updated; err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.CopyRatio != 1 || e.cfg().CopyRatio != 0.5 {
+		t.Errorf("snapshot ratio = %v, current = %v; want 1 and 0.5", snapshot.CopyRatio, e.cfg().CopyRatio)
+	}
+}
@@ -0,0 +1,178 @@
+package copytrade
+
+import (
+	"fmt"
+	"time"
+
+	"nofx/decision"
+	"nofx/logger"
+)
+
+// ============================================================================
+// 延迟跟单（开仓防抖）
+// ============================================================================
+//
+// 领航员偶尔会在几秒内开仓又平仓（误操作、测试下单），立即跟随只会白白付出手续费和滑点。
+// CopyDelay > 0 时，跟随的新开仓决策先按 posId 放入待跟随队列，延迟到期后再推送：
+//   - 延迟期内领航员平掉该仓位：撤销待跟随的开仓，记录 debounced 预警
+//   - 延迟期内领航员减仓：按剩余持仓比例缩减待跟随的开仓金额
+//   - 延迟期内领航员加仓：合并到待跟随的开仓（延迟从首次开仓开始计算）
+//   - 到期时领航员已不再持有该仓位（平仓成交尚未收到）：同样撤销
+// 加仓、减仓、平仓已跟随的仓位不受影响，始终立即执行。CopyDelay = 0 时开仓立即执行。
+// 引擎停止时丢弃所有待跟随的开仓。
+
+// MaxCopyDelaySeconds 延迟跟单的最大延迟（秒）
+const MaxCopyDelaySeconds = 300
+
+// WarningTypeDebounced 待跟随的开仓因领航员快速平仓被撤销
+const WarningTypeDebounced = "debounced"
+
+// delayedOpen 待跟随的开仓
+type delayedOpen struct {
+	posID      string
+	fill       *Fill
+	side       SideType
+	leaderSize float64 // 领航员当前持仓数量（减仓时按比例缩减跟单金额）
+	fullDec    *decision.FullDecision
+	timer      *time.Timer
+}
+
+// openDecision 待跟随开仓中的开仓决策（同组的保护性止损等不参与合并/缩减）
+func (d *delayedOpen) openDecision() *decision.Decision {
+	for i := range d.fullDec.Decisions {
+		dec := &d.fullDec.Decisions[i]
+		if dec.LeaderPosID == d.posID && (dec.Action == "open_long" || dec.Action == "open_short") {
+			return dec
+		}
+	}
+	return nil
+}
+
+// delayOpen 开仓决策延迟推送（调用方持有 cfgMu 读锁）
+func (e *Engine) delayOpen(match *SignalMatchResult, fill *Fill, fullDec *decision.FullDecision) {
+	leaderSize := 0.0
+	if match.LeaderPosition != nil {
+		leaderSize = match.LeaderPosition.Size
+	}
+
+	e.delayedMu.Lock()
+	defer e.delayedMu.Unlock()
+	if e.delayedOpens == nil {
+		e.delayedOpens = make(map[string]*delayedOpen)
+	}
+
+	// 延迟期内再次开仓（加仓）：合并到已有的待跟随开仓
+	if d := e.delayedOpens[match.PosID]; d != nil {
+		pending, added := d.openDecision(), openDecisionOf(fullDec, match.PosID)
+		if pending != nil && added != nil {
+			pending.PositionSizeUSD += added.PositionSizeUSD
+			pending.LeaderPosSize = added.LeaderPosSize
+			d.leaderSize = leaderSize
+			logger.Infof("⏳ [%s] 延迟跟单合并加仓 | posId=%s 金额=%.2f", e.traderID, match.PosID, pending.PositionSizeUSD)
+			return
+		}
+	}
+
+	d := &delayedOpen{posID: match.PosID, fill: fill, side: fill.PositionSide, leaderSize: leaderSize, fullDec: fullDec}
+	d.timer = time.AfterFunc(e.cfg().CopyDelay, func() { e.fireDelayedOpen(d) })
+	e.delayedOpens[match.PosID] = d
+	logger.Infof("⏳ [%s] 延迟跟单 | posId=%s %s 延迟 %s 后开仓", e.traderID, match.PosID, fill.Symbol, e.cfg().CopyDelay)
+}
+
+// openDecisionOf 决策组中 posId 对应的开仓决策
+func openDecisionOf(fullDec *decision.FullDecision, posID string) *decision.Decision {
+	return (&delayedOpen{posID: posID, fullDec: fullDec}).openDecision()
+}
+
+// fireDelayedOpen 延迟到期：领航员仍持仓时推送开仓决策
+func (e *Engine) fireDelayedOpen(d *delayedOpen) {
+	e.delayedMu.Lock()
+	if e.delayedOpens[d.posID] != d { // 已撤销或引擎已停止
+		e.delayedMu.Unlock()
+		return
+	}
+	delete(e.delayedOpens, d.posID)
+	e.delayedMu.Unlock()
+
+	e.cfgMu.RLock()
+	defer e.cfgMu.RUnlock()
+
+	if e.IsPaused() {
+		e.debounce(d, SkipReasonEnginePaused)
+		return
+	}
+	if pos := e.buildLeaderPosMap()[d.posID]; pos == nil || pos.Side != d.side || pos.Size <= 0 {
+		e.debounce(d, "leader no longer holds the position when the copy delay elapsed")
+		return
+	}
+	if e.pushDecision(d.fullDec) {
+		if dec := d.openDecision(); dec != nil {
+			logger.Infof("⚡ [%s] 延迟跟单到期，决策生成 | %s %s | 金额=%.2f",
+				e.traderID, dec.Action, dec.Symbol, dec.PositionSizeUSD)
+		}
+		e.recordTrialOpen(d.fill)
+	}
+}
+
+// settleDelayedOpens 领航员平仓/减仓成交：撤销已平仓的待跟随开仓，按比例缩减已减仓的
+func (e *Engine) settleDelayedOpens(fill *Fill) {
+	if fill.Action != ActionClose && fill.Action != ActionReduce {
+		return
+	}
+
+	e.delayedMu.Lock()
+	defer e.delayedMu.Unlock()
+	if len(e.delayedOpens) == 0 {
+		return
+	}
+
+	leaderPosMap := e.buildLeaderPosMap()
+	for posID, d := range e.delayedOpens {
+		if d.fill.Symbol != fill.Symbol || d.side != fill.PositionSide {
+			continue
+		}
+		pos := leaderPosMap[posID]
+		if pos == nil || pos.Side != d.side || pos.Size <= 0 {
+			d.timer.Stop()
+			delete(e.delayedOpens, posID)
+			e.debounce(d, "leader closed the position within the copy delay")
+			continue
+		}
+		if d.leaderSize > 0 && pos.Size < d.leaderSize {
+			if dec := d.openDecision(); dec != nil {
+				dec.PositionSizeUSD *= pos.Size / d.leaderSize
+				dec.LeaderPosSize = pos.Size
+				logger.Infof("⏳ [%s] 延迟期内领航员减仓 | posId=%s %.4f → %.4f 待跟随金额=%.2f",
+					e.traderID, posID, d.leaderSize, pos.Size, dec.PositionSizeUSD)
+			}
+			d.leaderSize = pos.Size
+		}
+	}
+}
+
+// debounce 撤销待跟随的开仓并记录预警
+func (e *Engine) debounce(d *delayedOpen, reason string) {
+	copyValue := 0.0
+	if dec := d.openDecision(); dec != nil {
+		copyValue = dec.PositionSizeUSD
+	}
+	e.logWarning(Warning{
+		Timestamp:    time.Now(),
+		Symbol:       d.fill.Symbol,
+		Type:         WarningTypeDebounced,
+		Message:      fmt.Sprintf("delayed open cancelled (posId=%s): %s", d.posID, reason),
+		SignalAction: string(ActionOpen),
+		SignalValue:  d.fill.Value,
+		CopyValue:    copyValue,
+	})
+}
+
+// dropDelayedOpens 丢弃所有待跟随的开仓（引擎停止时调用）
+func (e *Engine) dropDelayedOpens() {
+	e.delayedMu.Lock()
+	defer e.delayedMu.Unlock()
+	for posID, d := range e.delayedOpens {
+		d.timer.Stop()
+		delete(e.delayedOpens, posID)
+	}
+}
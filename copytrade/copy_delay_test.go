@@ -0,0 +1,68 @@
+package copytrade
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestCopyDelay 延迟跟单：延迟期内领航员平仓则撤销开仓，减仓按比例缩减，持仓到期后推送开仓
+func TestCopyDelay(t *testing.T) {
+	newEngine := func() (*Engine, *fakeProvider) {
+		e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1, CopyDelay: 50 * time.Millisecond}, 1000)
+		e.store = newTestStore(t)
+		p := &fakeProvider{}
+		p.setSize(2)
+		e.provider = p
+		e.processSignal(e.buildSignal(&Fill{ID: "open", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong,
+			Action: ActionOpen, Price: 10000, Size: 2, Value: 20000}))
+		if len(e.decisionCh) != 0 {
+			t.Fatal("open should be delayed")
+		}
+		return e, p
+	}
+
+	t.Run("leader closes within delay", func(t *testing.T) {
+		e, p := newEngine()
+		p.state = &AccountState{TotalEquity: 10000, Positions: map[string]*Position{}}
+		e.processSignal(e.buildSignal(&Fill{ID: "close", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong,
+			Action: ActionClose, Price: 10000, Size: 2, Value: 20000}))
+
+		time.Sleep(100 * time.Millisecond)
+		if len(e.decisionCh) != 0 {
+			t.Fatalf("cancelled open was pushed: %+v", (<-e.decisionCh).Decisions[0])
+		}
+		e.warningsMu.Lock()
+		defer e.warningsMu.Unlock()
+		if len(e.warnings) != 1 || e.warnings[0].Type != WarningTypeDebounced {
+			t.Errorf("warnings = %+v, want one %s", e.warnings, WarningTypeDebounced)
+		}
+	})
+
+	t.Run("leader reduces within delay", func(t *testing.T) {
+		e, p := newEngine()
+		p.setSize(1)
+		e.processSignal(e.buildSignal(&Fill{ID: "reduce", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong,
+			Action: ActionReduce, Price: 10000, Size: 1, Value: 10000}))
+
+		select {
+		case fullDec := <-e.decisionCh:
+			dec := fullDec.Decisions[0]
+			if dec.Action != "open_long" || math.Abs(dec.PositionSizeUSD-1000) > 0.01 || dec.LeaderPosSize != 1 {
+				t.Errorf("delayed open = %s %.2f leaderSize=%v, want open_long 1000.00 leaderSize=1",
+					dec.Action, dec.PositionSizeUSD, dec.LeaderPosSize)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("delayed open not pushed")
+		}
+	})
+
+	t.Run("engine stop drops pending opens", func(t *testing.T) {
+		e, _ := newEngine()
+		e.dropDelayedOpens()
+		time.Sleep(100 * time.Millisecond)
+		if len(e.decisionCh) != 0 {
+			t.Error("dropped open was pushed")
+		}
+	})
+}
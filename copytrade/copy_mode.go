@@ -0,0 +1,33 @@
+package copytrade
+
+import "nofx/logger"
+
+// ============================================================================
+// 跟单金额模式
+// ============================================================================
+//
+// proportional（默认）：跟单金额 = 跟单系数 × (领航员交易价值 / 领航员权益) × 跟随者权益
+// fixed：领航员每次开仓/加仓都按固定金额 FixedTradeUSD 跟随，与领航员/跟随者权益无关。
+// 两种模式下减仓/平仓都按领航员持仓变化比例计算，最小金额提升与大额预警照常生效。
+
+// 跟单金额模式
+const (
+	CopyModeProportional = "proportional" // 按比例（默认）
+	CopyModeFixed        = "fixed"        // 固定金额
+)
+
+// fixedTradeSize 固定金额模式下开仓/加仓的跟单金额（未启用或不适用时 ok=false）
+// 跟随者权益 <= 0 时不使用固定金额，交由比例计算给出余额预警并跳过
+func (e *Engine) fixedTradeSize(match *SignalMatchResult) (float64, bool) {
+	if e.cfg().CopyMode != CopyModeFixed || e.cfg().FixedTradeUSD <= 0 {
+		return 0, false
+	}
+	if match.Action != ActionOpen && match.Action != ActionAdd {
+		return 0, false
+	}
+	if e.getFollowerBalance() <= 0 {
+		return 0, false
+	}
+	logger.Infof("📊 [%s] 固定金额 | %s → 跟单=%.2f", e.traderID, match.Action, e.cfg().FixedTradeUSD)
+	return e.cfg().FixedTradeUSD, true
+}
@@ -0,0 +1,35 @@
+package copytrade
+
+import (
+	"math"
+	"testing"
+)
+
+// TestFixedCopyMode 固定金额模式：开仓/加仓按固定金额跟随，与权益无关，最小金额提升照常生效
+func TestFixedCopyMode(t *testing.T) {
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 1,
+		CopyMode: CopyModeFixed, FixedTradeUSD: 50}, 1000)
+
+	// 领航员用 10% 权益开仓，比例模式下应为 100
+	fill := &Fill{Symbol: "BTCUSDT", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 10, Value: 1000}
+	signal := &TradeSignal{Fill: fill, LeaderEquity: 10000}
+	for _, action := range []ActionType{ActionOpen, ActionAdd} {
+		match := &SignalMatchResult{Action: action, PosID: PositionKey("BTCUSDT", SideLong), MarginMode: "cross"}
+		if got, _ := e.calculateCopySizeByPositionChange(signal, match); got != 50 {
+			t.Errorf("fixed %s: copy size = %.2f, want 50", action, got)
+		}
+	}
+
+	// 固定金额低于最小阈值时提升
+	withConfig(e, func(c *CopyConfig) { c.FixedTradeUSD = 5 })
+	match := &SignalMatchResult{Action: ActionOpen, PosID: PositionKey("BTCUSDT", SideLong), MarginMode: "cross"}
+	if got, _ := e.calculateCopySizeByPositionChange(signal, match); got != DefaultMinTradeAmount {
+		t.Errorf("fixed below minimum: copy size = %.2f, want %.2f", got, DefaultMinTradeAmount)
+	}
+
+	// 比例模式
+	withConfig(e, func(c *CopyConfig) { c.CopyMode = CopyModeProportional })
+	if got, _ := e.calculateCopySizeByPositionChange(signal, match); math.Abs(got-100) > 1e-9 {
+		t.Errorf("proportional: copy size = %.2f, want 100", got)
+	}
+}
@@ -0,0 +1,209 @@
+package copytrade
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 流式状态与 REST 状态交叉校验
+// ============================================================================
+//
+// 流式模式下领航员持仓来自 WebSocket 推送，推送丢失或解析错误会让缓存状态与实际持仓不一致，
+// 平仓/加仓匹配随之静默出错。开启 CrossCheckState 后定时通过 REST 拉取一次领航员状态，
+// 按 symbol+side 对比持仓数量，差异超过阈值时上报（仪表盘 state_divergence 预警）。
+//   - 只在流式模式、且缓存状态有持仓或存在 active 映射时拉取（没有仓位时不产生请求）
+//   - 一笔成交可能恰好发生在两次读取之间，连续两次检查都不一致才上报
+
+// 交叉校验默认参数
+const (
+	DefaultCrossCheckInterval  = time.Minute
+	DefaultCrossCheckThreshold = 0.05 // 数量相对差异 5%
+)
+
+// StateDivergence 流式状态与 REST 状态不一致
+type StateDivergence struct {
+	Symbol     string    `json:"symbol"`
+	Side       string    `json:"side"`
+	StreamSize float64   `json:"stream_size"` // WebSocket 缓存状态的持仓数量
+	RESTSize   float64   `json:"rest_size"`   // REST 拉取的持仓数量
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+func (d StateDivergence) key() string {
+	return d.Symbol + "|" + d.Side
+}
+
+// crossCheckState 交叉校验结果
+type crossCheckState struct {
+	mu         sync.Mutex
+	pending    map[string]bool
+	divergence []StateDivergence
+}
+
+// crossCheckInterval 交叉校验间隔（未开启或非流式模式返回 0）
+func (e *Engine) crossCheckInterval() time.Duration {
+	if !e.cfg().CrossCheckState || !e.isStreamingMode {
+		return 0
+	}
+	if e.cfg().CrossCheckIntervalSeconds > 0 {
+		return time.Duration(e.cfg().CrossCheckIntervalSeconds) * time.Second
+	}
+	return DefaultCrossCheckInterval
+}
+
+// crossCheckLoop 定时交叉校验领航员状态
+func (e *Engine) crossCheckLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.stopCh:
+			return
+		case <-ticker.C:
+			e.checkStateDivergence()
+		}
+	}
+}
+
+// checkStateDivergence 交叉校验一次
+func (e *Engine) checkStateDivergence() {
+	e.leaderStateMu.RLock()
+	cached := e.leaderState
+	e.leaderStateMu.RUnlock()
+
+	hasPositions := cached != nil && len(cached.Positions) > 0
+	if !hasPositions && e.store != nil {
+		mappings, err := e.listActiveMappings()
+		hasPositions = err == nil && len(mappings) > 0
+	}
+	if !hasPositions {
+		e.recordStateDivergence(nil)
+		return
+	}
+
+	var rest *AccountState
+	var err error
+	if fp, ok := e.provider.(FreshStateProvider); ok {
+		rest, err = fp.GetAccountStateFresh(e.cfg().LeaderID)
+	} else {
+		rest, err = e.provider.GetAccountState(e.cfg().LeaderID)
+	}
+	rest = e.scopeState(rest)
+	if err != nil || rest == nil {
+		logger.Warnf("⚠️ [%s] 交叉校验拉取 REST 状态失败: %v", e.traderID, err)
+		return
+	}
+
+	threshold := e.cfg().CrossCheckThreshold
+	if threshold <= 0 {
+		threshold = DefaultCrossCheckThreshold
+	}
+	e.recordStateDivergence(diffLeaderStates(cached, rest, threshold, time.Now()))
+}
+
+// recordStateDivergence 只保留连续两次都出现的不一致，新出现时记录日志和预警
+func (e *Engine) recordStateDivergence(found []StateDivergence) {
+	e.crossCheck.mu.Lock()
+	defer e.crossCheck.mu.Unlock()
+
+	reported := make(map[string]bool, len(e.crossCheck.divergence))
+	for _, d := range e.crossCheck.divergence {
+		reported[d.key()] = true
+	}
+
+	pending := make(map[string]bool, len(found))
+	var confirmed []StateDivergence
+	for _, d := range found {
+		pending[d.key()] = true
+		if !e.crossCheck.pending[d.key()] {
+			continue
+		}
+		confirmed = append(confirmed, d)
+		if !reported[d.key()] {
+			logger.Warnf("⚠️ [%s] 领航员状态不一致 | %s %s WebSocket=%.4f REST=%.4f",
+				e.traderID, d.Symbol, d.Side, d.StreamSize, d.RESTSize)
+			e.logWarning(Warning{
+				Timestamp: time.Now(),
+				Symbol:    d.Symbol,
+				Type:      "state_divergence",
+				Message:   fmt.Sprintf("领航员 %s 持仓 WebSocket=%.4f REST=%.4f，流式状态可能失步", d.Side, d.StreamSize, d.RESTSize),
+				Executed:  false,
+			})
+		}
+	}
+	e.crossCheck.pending = pending
+	e.crossCheck.divergence = confirmed
+}
+
+// GetStateDivergence 当前确认的流式/REST 状态不一致
+func (e *Engine) GetStateDivergence() []StateDivergence {
+	e.crossCheck.mu.Lock()
+	defer e.crossCheck.mu.Unlock()
+	return append([]StateDivergence(nil), e.crossCheck.divergence...)
+}
+
+// diffLeaderStates 按 symbol+side 对比两份领航员状态的持仓数量（相对差异超过阈值视为不一致）
+func diffLeaderStates(stream, rest *AccountState, threshold float64, now time.Time) []StateDivergence {
+	sizes := func(state *AccountState) map[string]float64 {
+		m := make(map[string]float64)
+		if state == nil {
+			return m
+		}
+		for _, pos := range state.Positions {
+			m[PositionKey(pos.Symbol, pos.Side)] += pos.Size
+		}
+		return m
+	}
+	streamSizes, restSizes := sizes(stream), sizes(rest)
+
+	keys := make(map[string]bool)
+	for k := range streamSizes {
+		keys[k] = true
+	}
+	for k := range restSizes {
+		keys[k] = true
+	}
+
+	var result []StateDivergence
+	for k := range keys {
+		s, r := streamSizes[k], restSizes[k]
+		base := math.Max(s, r)
+		if base <= 0 || math.Abs(s-r)/base <= threshold {
+			continue
+		}
+		symbol, side := splitPositionKey(k)
+		result = append(result, StateDivergence{Symbol: symbol, Side: side, StreamSize: s, RESTSize: r, DetectedAt: now})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].key() < result[j].key() })
+	return result
+}
+
+// splitPositionKey PositionKey 拆回 symbol 与 side
+func splitPositionKey(key string) (string, string) {
+	for _, side := range []SideType{SideLong, SideShort} {
+		suffix := "_" + string(side)
+		if len(key) > len(suffix) && key[len(key)-len(suffix):] == suffix {
+			return key[:len(key)-len(suffix)], string(side)
+		}
+	}
+	return key, ""
+}
+
+// GetCopyTradingStateDivergence 获取领航员流式/REST 状态不一致
+func GetCopyTradingStateDivergence(traderID string) []StateDivergence {
+	integration, exists := getIntegration(traderID)
+	if !exists || integration.engine == nil {
+		return nil
+	}
+	return integration.engine.GetStateDivergence()
+}
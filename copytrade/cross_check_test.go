@@ -0,0 +1,73 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+)
+
+// TestStateCrossCheck WebSocket 缓存状态与 REST 状态不一致：连续两次检查都不一致才上报
+func TestStateCrossCheck(t *testing.T) {
+	rest := &fakeProvider{state: &AccountState{TotalEquity: 10000, Positions: map[string]*Position{
+		PositionKey("BTCUSDT", SideLong): {Symbol: "BTCUSDT", Side: SideLong, Size: 2},
+		PositionKey("ETHUSDT", SideLong): {Symbol: "ETHUSDT", Side: SideLong, Size: 10.2},
+	}}}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CrossCheckState: true}, 1000)
+	e.provider = rest
+	e.isStreamingMode = true
+	// WebSocket 以为 BTC 只有 1；ETH 差异 2% 在阈值内
+	e.leaderState = &AccountState{TotalEquity: 10000, Positions: map[string]*Position{
+		PositionKey("BTCUSDT", SideLong): {Symbol: "BTCUSDT", Side: SideLong, Size: 1},
+		PositionKey("ETHUSDT", SideLong): {Symbol: "ETHUSDT", Side: SideLong, Size: 10},
+	}}
+
+	if got := e.crossCheckInterval(); got != DefaultCrossCheckInterval {
+		t.Errorf("interval = %v, want %v", got, DefaultCrossCheckInterval)
+	}
+
+	e.checkStateDivergence()
+	if got := e.GetStateDivergence(); len(got) != 0 {
+		t.Fatalf("first check reported %v, want none until confirmed", got)
+	}
+	e.checkStateDivergence()
+	got := e.GetStateDivergence()
+	if len(got) != 1 || got[0].Symbol != "BTCUSDT" || got[0].Side != "long" || got[0].StreamSize != 1 || got[0].RESTSize != 2 {
+		t.Fatalf("divergence = %+v, want BTCUSDT long 1 vs 2", got)
+	}
+
+	// 恢复一致后清除
+	e.leaderState.Positions[PositionKey("BTCUSDT", SideLong)].Size = 2
+	e.checkStateDivergence()
+	if got := e.GetStateDivergence(); len(got) != 0 {
+		t.Errorf("divergence after resync = %v, want none", got)
+	}
+
+	// 缓存状态没有持仓、也没有映射时不拉取 REST（即使 REST 有持仓也不会上报）
+	e.leaderState = &AccountState{TotalEquity: 10000, Positions: map[string]*Position{}}
+	e.checkStateDivergence()
+	e.checkStateDivergence()
+	if got := e.GetStateDivergence(); len(got) != 0 {
+		t.Errorf("divergence without positions = %v, want none", got)
+	}
+
+	// 轮询模式不校验
+	e.isStreamingMode = false
+	if got := e.crossCheckInterval(); got != 0 {
+		t.Errorf("polling interval = %v, want 0", got)
+	}
+}
+
+// TestDiffLeaderStates 对比时按 symbol+side 合并（全仓/逐仓等多个 key）
+func TestDiffLeaderStates(t *testing.T) {
+	stream := &AccountState{Positions: map[string]*Position{
+		"a": {Symbol: "SOLUSDT", Side: SideShort, Size: 3, MarginMode: "cross"},
+		"b": {Symbol: "SOLUSDT", Side: SideShort, Size: 2, MarginMode: "isolated"},
+	}}
+	rest := &AccountState{Positions: map[string]*Position{
+		"x": {Symbol: "SOLUSDT", Side: SideShort, Size: 5},
+		"y": {Symbol: "DOGEUSDT", Side: SideLong, Size: 100},
+	}}
+	got := diffLeaderStates(stream, rest, DefaultCrossCheckThreshold, time.Now())
+	if len(got) != 1 || got[0].Symbol != "DOGEUSDT" || got[0].StreamSize != 0 {
+		t.Errorf("diff = %+v, want only DOGEUSDT missing from stream", got)
+	}
+}
@@ -0,0 +1,182 @@
+package copytrade
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/store"
+)
+
+// ============================================================================
+// 决策组：开仓 + 保护性止损
+// ============================================================================
+//
+// 同一 GroupID 的决策按顺序执行，视为一个整体：
+//   - 组内开仓失败：跳过组内剩余决策（不为不存在的仓位挂止损）
+//   - 保护性止损失败（含执行器不支持）：回滚组内已开仓位（主动平仓，映射转为 ignored），
+//     避免留下无保护的裸仓
+// 交易所不支持原子下单，这里是“尽力而为”的全有或全无：回滚平仓本身失败时记录 group_rollback_failed 预警。
+// ProtectiveStopPct > 0 时，新开仓会生成 开仓 + 止损 决策组，止损价 = 领航员成交价 ×（1 ∓ ProtectiveStopPct）。
+// 开仓决策已附带同步的领航员止损（SyncTPSL）时不再单独挂保护性止损（避免同一仓位两笔止损单），
+// 而是取两者中距入场价更近的一个作为开仓决策的止损价。
+
+// ActionSetStopLoss 保护性止损决策（不下开平仓单、不更新映射）
+const ActionSetStopLoss = "set_stop_loss"
+
+// CloseReasonGroupRollback 决策组回滚导致的主动平仓
+const CloseReasonGroupRollback = "group_rollback"
+
+// errProtectiveStopUnsupported 执行器不支持挂保护性止损
+var errProtectiveStopUnsupported = errors.New("executor does not support protective stop")
+
+// ProtectiveOrderExecutor 执行器可选能力：为当前持仓挂止损单（side: "long" | "short"）
+type ProtectiveOrderExecutor interface {
+	SetProtectiveStop(symbol, side string, stopPrice float64) error
+}
+
+// withProtectiveStop 为新开仓附加保护性止损，返回本次推送的决策列表
+func (e *Engine) withProtectiveStop(match *SignalMatchResult, dec decision.Decision) []decision.Decision {
+	pct := e.cfg().ProtectiveStopPct
+	if pct <= 0 || pct >= 1 || match.Action != ActionOpen || dec.EntryPrice <= 0 {
+		return []decision.Decision{dec}
+	}
+
+	side := "long"
+	stopPrice := dec.EntryPrice * (1 - pct)
+	if dec.Action == "open_short" {
+		side = "short"
+		stopPrice = dec.EntryPrice * (1 + pct)
+	}
+
+	// 开仓已附带止损（同步领航员止盈止损）：只保留更紧的一个，由开仓决策挂单
+	if dec.StopLoss > 0 {
+		if (side == "long" && stopPrice > dec.StopLoss) || (side == "short" && stopPrice < dec.StopLoss) {
+			dec.StopLoss = stopPrice
+		}
+		return []decision.Decision{dec}
+	}
+
+	groupID := fmt.Sprintf("%s-%s-%d", dec.Symbol, side, time.Now().UnixNano())
+	if dec.LeaderPosID != "" {
+		groupID = dec.LeaderPosID + "-" + groupID
+	}
+	dec.GroupID = groupID
+
+	stop := decision.Decision{
+		Symbol:      dec.Symbol,
+		Action:      ActionSetStopLoss,
+		StopLoss:    stopPrice,
+		Reasoning:   fmt.Sprintf("Copy trading: protective stop %.2f%% for %s %s", pct*100, dec.Symbol, side),
+		LeaderPosID: dec.LeaderPosID,
+		MarginMode:  dec.MarginMode,
+		GroupID:     groupID,
+	}
+	return []decision.Decision{dec, stop}
+}
+
+// decisionGroups 单次 FullDecision 执行内的决策组状态
+type decisionGroups struct {
+	aborted map[string]bool                 // 已失败（后续组内决策跳过）
+	opened  map[string][]*decision.Decision // 组内已成功的开仓（回滚用）
+}
+
+func newDecisionGroups() *decisionGroups {
+	return &decisionGroups{
+		aborted: make(map[string]bool),
+		opened:  make(map[string][]*decision.Decision),
+	}
+}
+
+// skip 组已失败时跳过剩余决策
+func (g *decisionGroups) skip(dec *decision.Decision) bool {
+	return dec.GroupID != "" && g.aborted[dec.GroupID]
+}
+
+// record 记录组内开仓/加仓的执行结果
+func (g *decisionGroups) record(dec *decision.Decision, err error) {
+	if dec.GroupID == "" {
+		return
+	}
+	if err != nil {
+		g.aborted[dec.GroupID] = true
+		return
+	}
+	if dec.Action == "open_long" || dec.Action == "open_short" {
+		g.opened[dec.GroupID] = append(g.opened[dec.GroupID], dec)
+	}
+}
+
+// groupDecisionSide 开仓决策的方向
+func groupDecisionSide(dec *decision.Decision) string {
+	if dec.Action == "open_short" {
+		return "short"
+	}
+	return "long"
+}
+
+// applyProtectiveStop 挂保护性止损（方向取自同组开仓）
+func (ti *TraderIntegration) applyProtectiveStop(dec *decision.Decision, groups *decisionGroups) error {
+	setter, ok := ti.executor.(ProtectiveOrderExecutor)
+	if !ok {
+		return errProtectiveStopUnsupported
+	}
+	side := "long"
+	if opened := groups.opened[dec.GroupID]; len(opened) > 0 {
+		side = groupDecisionSide(opened[len(opened)-1])
+	}
+	return setter.SetProtectiveStop(dec.Symbol, side, dec.StopLoss)
+}
+
+// rollbackGroup 回滚组内已成功的开仓（主动平仓，映射转为 ignored），返回执行日志
+func (ti *TraderIntegration) rollbackGroup(groupID string, groups *decisionGroups, cause error) []store.DecisionAction {
+	groups.aborted[groupID] = true
+	opened := groups.opened[groupID]
+	delete(groups.opened, groupID)
+
+	actions := make([]store.DecisionAction, 0, len(opened))
+	for _, open := range opened {
+		closeDec := &decision.Decision{
+			Symbol:      open.Symbol,
+			Action:      "close_" + groupDecisionSide(open),
+			Reasoning:   fmt.Sprintf("Copy trading: rollback %s, protective stop failed: %v", open.Action, cause),
+			EntryPrice:  open.EntryPrice,
+			LeaderPosID: open.LeaderPosID,
+			LeaderID:    open.LeaderID,
+			MarginMode:  open.MarginMode,
+			CloseReason: CloseReasonGroupRollback,
+			GroupID:     groupID,
+		}
+
+		err := ti.executor.ExecuteDecision(closeDec)
+		action := store.DecisionAction{
+			Action:    closeDec.Action,
+			Symbol:    closeDec.Symbol,
+			Price:     closeDec.EntryPrice,
+			Reasoning: closeDec.Reasoning,
+			Timestamp: time.Now(),
+			Success:   err == nil,
+		}
+		if err != nil {
+			action.Error = err.Error()
+			logger.Errorf("🚨 [%s] 决策组回滚失败，仓位无保护止损 | %s %s group=%s error=%v",
+				ti.traderID, open.Action, open.Symbol, groupID, err)
+			if engine := ti.engineFor(closeDec); engine != nil {
+				engine.logWarning(Warning{
+					Type:      "group_rollback_failed",
+					Symbol:    open.Symbol,
+					Message:   fmt.Sprintf("回滚 %s 失败，仓位无保护止损: %v", open.Action, err),
+					Timestamp: time.Now(),
+				})
+			}
+		} else {
+			logger.Warnf("↩️ [%s] 决策组已回滚 | %s %s group=%s 原因: %v",
+				ti.traderID, open.Action, open.Symbol, groupID, cause)
+			ti.updatePositionMapping(closeDec)
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
@@ -0,0 +1,159 @@
+package copytrade
+
+import (
+	"errors"
+	"testing"
+
+	"nofx/decision"
+)
+
+// stopExecutor 支持保护性止损的执行器
+type stopExecutor struct {
+	fakeExecutor
+	stopErr error
+	stops   []float64
+}
+
+func (s *stopExecutor) SetProtectiveStop(symbol, side string, stopPrice float64) error {
+	s.stops = append(s.stops, stopPrice)
+	return s.stopErr
+}
+
+// openWithStop 推送一笔 BTCUSDT 新开多信号（开仓价 100）
+func openWithStop(t *testing.T, e *Engine) {
+	t.Helper()
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "f1", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong,
+		Action: ActionOpen, Price: 100, Size: 1, Value: 100}})
+}
+
+// TestProtectiveStopGroup 开仓 + 保护性止损决策组：止损成功保留仓位，失败时回滚开仓
+func TestProtectiveStopGroup(t *testing.T) {
+	newEngine := func(t *testing.T) *Engine {
+		provider := &fakeProvider{}
+		provider.setSize(1)
+		e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1,
+			ProtectiveStopPct: 0.05}, 1000)
+		e.store = newTestStore(t)
+		e.provider = provider
+		return e
+	}
+	posID := PositionKey("BTCUSDT", SideLong)
+
+	t.Run("decisions", func(t *testing.T) {
+		e := newEngine(t)
+		openWithStop(t, e)
+		fullDec := <-e.decisionCh
+		if len(fullDec.Decisions) != 2 {
+			t.Fatalf("decisions = %d, want 2", len(fullDec.Decisions))
+		}
+		open, stop := fullDec.Decisions[0], fullDec.Decisions[1]
+		if open.Action != "open_long" || stop.Action != ActionSetStopLoss {
+			t.Fatalf("actions = %s, %s", open.Action, stop.Action)
+		}
+		if open.GroupID == "" || open.GroupID != stop.GroupID {
+			t.Errorf("group ids = %q, %q", open.GroupID, stop.GroupID)
+		}
+		if stop.StopLoss != 95 {
+			t.Errorf("stop price = %v, want 95", stop.StopLoss)
+		}
+
+		e = newEngine(t)
+		withConfig(e, func(c *CopyConfig) { c.ProtectiveStopPct = 0 })
+		openWithStop(t, e)
+		if fd := <-e.decisionCh; len(fd.Decisions) != 1 || fd.Decisions[0].GroupID != "" {
+			t.Errorf("disabled: decisions = %+v", fd.Decisions)
+		}
+	})
+
+	t.Run("stop placed", func(t *testing.T) {
+		e := newEngine(t)
+		openWithStop(t, e)
+		exec := &stopExecutor{}
+		ti := &TraderIntegration{traderID: "test", store: e.store, engine: e, executor: exec}
+		ti.executeFullDecision(<-e.decisionCh)
+
+		if len(exec.executed) != 1 || len(exec.stops) != 1 {
+			t.Fatalf("executed = %d stops = %d, want 1/1", len(exec.executed), len(exec.stops))
+		}
+		m, err := e.store.CopyTrade().GetMapping("test", posID)
+		if err != nil || m == nil || m.Status != "active" {
+			t.Fatalf("mapping = %+v err=%v, want active", m, err)
+		}
+	})
+
+	t.Run("stop failed rolls back", func(t *testing.T) {
+		e := newEngine(t)
+		openWithStop(t, e)
+		exec := &stopExecutor{stopErr: errors.New("trigger price invalid")}
+		ti := &TraderIntegration{traderID: "test", store: e.store, engine: e, executor: exec}
+		ti.executeFullDecision(<-e.decisionCh)
+
+		if len(exec.executed) != 2 || exec.executed[1].Action != "close_long" ||
+			exec.executed[1].CloseReason != CloseReasonGroupRollback {
+			t.Fatalf("executed = %+v, want open + rollback close", exec.executed)
+		}
+		m, err := e.store.CopyTrade().GetMapping("test", posID)
+		if err != nil || m == nil || m.Status != "ignored" {
+			t.Fatalf("mapping = %+v err=%v, want ignored", m, err)
+		}
+	})
+
+	t.Run("unsupported executor rolls back", func(t *testing.T) {
+		e := newEngine(t)
+		openWithStop(t, e)
+		exec := &fakeExecutor{}
+		ti := &TraderIntegration{traderID: "test", store: e.store, engine: e, executor: exec}
+		ti.executeFullDecision(<-e.decisionCh)
+
+		if len(exec.executed) != 2 || exec.executed[1].Action != "close_long" {
+			t.Fatalf("executed = %+v, want open + rollback close", exec.executed)
+		}
+	})
+
+	t.Run("open failed skips stop", func(t *testing.T) {
+		e := newEngine(t)
+		openWithStop(t, e)
+		exec := &stopExecutor{fakeExecutor: fakeExecutor{err: errors.New("insufficient margin")}}
+		ti := &TraderIntegration{traderID: "test", store: e.store, engine: e, executor: exec}
+		ti.executeFullDecision(<-e.decisionCh)
+
+		if len(exec.executed) != 1 || len(exec.stops) != 0 {
+			t.Fatalf("executed = %d stops = %d, want 1/0", len(exec.executed), len(exec.stops))
+		}
+	})
+}
+
+// TestProtectiveStopWithSyncedStop 同时开启保护性止损与止盈止损同步：只保留一笔止损（取距入场价更近的）
+func TestProtectiveStopWithSyncedStop(t *testing.T) {
+	open := func(pct float64, inverse bool) []decision.Decision {
+		e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 1,
+			SyncTPSL: true, ProtectiveStopPct: pct, Inverse: inverse}, 1000)
+		e.provider = &fakeProvider{}
+		signal := &TradeSignal{Fill: &Fill{Symbol: "BTCUSDT", PositionSide: SideLong, Action: ActionOpen, Size: 1, Price: 100}}
+		match := &SignalMatchResult{ShouldFollow: true, Action: ActionOpen, PosID: "p1", MarginMode: "cross",
+			LeaderPosition: &Position{Symbol: "BTCUSDT", Side: SideLong, Size: 1, EntryPrice: 100, TakeProfitPrice: 120, StopLossPrice: 90}}
+		return e.withProtectiveStop(match, e.buildDecisionV2(signal, match, 50))
+	}
+
+	cases := []struct {
+		name    string
+		pct     float64
+		inverse bool
+		want    float64
+	}{
+		{"protective tighter", 0.05, false, 95},
+		{"leader tighter", 0.2, false, 90},
+		{"inverse protective tighter", 0.05, true, 105},
+		{"inverse leader tighter", 0.3, true, 120},
+	}
+	for _, c := range cases {
+		decs := open(c.pct, c.inverse)
+		if len(decs) != 1 {
+			t.Errorf("%s: decisions = %d, want 1 (no separate protective stop)", c.name, len(decs))
+			continue
+		}
+		if decs[0].GroupID != "" || decs[0].StopLoss != c.want {
+			t.Errorf("%s: group=%q stop=%v, want no group, stop %v", c.name, decs[0].GroupID, decs[0].StopLoss, c.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package copytrade
+
+import (
+	"fmt"
+	"time"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 决策通道堵塞检测
+// ============================================================================
+//
+// 决策由 TraderIntegration.consumeDecisions 消费。消费者退出（panic、context 被取消）而引擎仍在运行时，
+// decisionCh 会被填满，pushDecision 只能丢弃决策——引擎在生成决策却没有执行，是危险的静默故障。
+// 通道持续满载超过 DecisionStallSeconds（默认 30 秒）判定为消费者失效：
+//   - 记录 decision_stall 预警并在风险预警中显示为 critical
+//   - DecisionStallPolicy = "pause"：同时暂停开仓/加仓（新开仓标记为 ignored），通道恢复后自动继续
+// 丢弃的决策数记录在 EngineStats.DecisionsDropped。
+
+// 决策通道堵塞时的处理方式
+const (
+	DecisionStallAlert = "alert" // 只告警（默认）
+	DecisionStallPause = "pause" // 告警并暂停开仓/加仓
+)
+
+// DefaultDecisionStallSeconds 通道持续满载多久判定为消费者失效（秒）
+const DefaultDecisionStallSeconds = 30
+
+// decisionStallThreshold 判定消费者失效的满载时长
+func (e *Engine) decisionStallThreshold() time.Duration {
+	if e.cfg().DecisionStallSeconds > 0 {
+		return time.Duration(e.cfg().DecisionStallSeconds) * time.Second
+	}
+	return DefaultDecisionStallSeconds * time.Second
+}
+
+// onDecisionDropped 决策通道已满：记录丢弃，持续满载超过阈值时判定消费者失效
+func (e *Engine) onDecisionDropped() {
+	now := time.Now()
+
+	e.mu.Lock()
+	e.stats.DecisionsDropped++
+	if e.decisionFullSince.IsZero() {
+		e.decisionFullSince = now
+	}
+	fullFor := now.Sub(e.decisionFullSince)
+	stalled := !e.stats.DecisionStalled && fullFor >= e.decisionStallThreshold()
+	if stalled {
+		e.stats.DecisionStalled = true
+	}
+	dropped := e.stats.DecisionsDropped
+	e.mu.Unlock()
+
+	if !stalled {
+		return
+	}
+	logger.Errorf("🚨 [%s] 决策通道持续满载 %s，决策消费者可能已失效（已丢弃 %d 条决策，policy=%s）",
+		e.traderID, fullFor.Round(time.Second), dropped, e.decisionStallPolicy())
+	e.logWarning(Warning{
+		Timestamp: now,
+		Type:      "decision_stall",
+		Message: fmt.Sprintf("决策通道持续满载 %s，决策未被执行（已丢弃 %d 条，policy=%s）",
+			fullFor.Round(time.Second), dropped, e.decisionStallPolicy()),
+		Executed: false,
+	})
+}
+
+// onDecisionPushed 决策成功入队：清除满载状态
+func (e *Engine) onDecisionPushed() {
+	e.mu.Lock()
+	wasStalled := e.stats.DecisionStalled
+	e.decisionFullSince = time.Time{}
+	e.stats.DecisionStalled = false
+	e.mu.Unlock()
+
+	if wasStalled {
+		logger.Infof("✅ [%s] 决策通道已恢复，决策消费者重新开始处理", e.traderID)
+	}
+}
+
+// decisionStallPolicy 当前生效的堵塞处理方式
+func (e *Engine) decisionStallPolicy() string {
+	if e.cfg().DecisionStallPolicy == DecisionStallPause {
+		return DecisionStallPause
+	}
+	return DecisionStallAlert
+}
+
+// decisionStallPaused 消费者失效且策略为 pause 时暂停开仓/加仓；通道有空位时自动恢复
+func (e *Engine) decisionStallPaused() bool {
+	if e.decisionStallPolicy() != DecisionStallPause {
+		return false
+	}
+	e.mu.RLock()
+	stalled := e.stats.DecisionStalled
+	e.mu.RUnlock()
+	if !stalled {
+		return false
+	}
+	if len(e.decisionCh) < cap(e.decisionCh) {
+		e.onDecisionPushed()
+		return false
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+
+	"nofx/decision"
+)
+
+// TestDecisionStall 决策通道持续满载超过阈值判定消费者失效；pause 策略下通道恢复后自动继续
+func TestDecisionStall(t *testing.T) {
+	e := newTestEngine(&CopyConfig{LeaderID: "leader", CopyRatio: 1, DecisionStallPolicy: DecisionStallPause}, 1000)
+	e.decisionCh = make(chan *decision.FullDecision, 1)
+
+	if !e.pushDecision(&decision.FullDecision{}) {
+		t.Fatal("first push dropped")
+	}
+	if e.pushDecision(&decision.FullDecision{}) {
+		t.Fatal("push into full channel succeeded")
+	}
+	if e.stats.DecisionsDropped != 1 || e.stats.DecisionStalled {
+		t.Fatalf("dropped=%d stalled=%v, want 1/false", e.stats.DecisionsDropped, e.stats.DecisionStalled)
+	}
+	if e.decisionStallPaused() {
+		t.Fatal("paused before threshold")
+	}
+
+	// 满载超过阈值 → 判定失效并记录预警
+	e.decisionFullSince = time.Now().Add(-(DefaultDecisionStallSeconds + 1) * time.Second)
+	e.pushDecision(&decision.FullDecision{})
+	if !e.stats.DecisionStalled || e.stats.DecisionsDropped != 2 {
+		t.Fatalf("dropped=%d stalled=%v, want 2/true", e.stats.DecisionsDropped, e.stats.DecisionStalled)
+	}
+	if len(e.warnings) != 1 || e.warnings[0].Type != "decision_stall" {
+		t.Fatalf("warnings = %+v, want one decision_stall", e.warnings)
+	}
+	if !e.decisionStallPaused() {
+		t.Fatal("pause policy: not paused while stalled")
+	}
+
+	// 消费者恢复（通道有空位）→ 自动解除
+	<-e.decisionCh
+	if e.decisionStallPaused() || e.stats.DecisionStalled {
+		t.Fatal("still stalled after channel drained")
+	}
+
+	// alert 策略只告警，不暂停
+	withConfig(e, func(c *CopyConfig) { c.DecisionStallPolicy = "" })
+	e.stats.DecisionStalled = true
+	e.decisionCh <- &decision.FullDecision{}
+	if e.decisionStallPaused() {
+		t.Error("alert policy paused the engine")
+	}
+}
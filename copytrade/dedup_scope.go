@@ -0,0 +1,110 @@
+package copytrade
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 去重范围：按引擎（默认）或按跟随者账户
+// ============================================================================
+//
+// seenFills 属于单个引擎：两个 trader 跟随同一领航员时各自处理同一笔成交，这是正确的。
+// 但同一个交易所账户被多个 trader 使用（误配置）时，每个引擎都会下单，造成重复跟单。
+// DedupScope="account" 时同一跟随者账户的引擎共享 (provider, leader, fill_id) 认领记录：
+// 先认领的引擎处理，其余引擎跳过该成交并预警一次。
+
+// 去重范围
+const (
+	DedupScopeEngine  = "engine"  // 每个引擎独立去重（默认）
+	DedupScopeAccount = "account" // 同一跟随者账户的引擎共享去重
+)
+
+// fillClaim 成交认领记录
+type fillClaim struct {
+	traderID string
+	at       time.Time
+}
+
+// accountDedup 同一跟随者账户的成交认领表
+type accountDedup struct {
+	mu     sync.Mutex
+	claims map[string]fillClaim
+}
+
+var (
+	accountDedups   = make(map[string]*accountDedup)
+	accountDedupsMu sync.Mutex
+)
+
+// sharedDedupFor 获取跟随者账户的共享认领表
+func sharedDedupFor(account string) *accountDedup {
+	accountDedupsMu.Lock()
+	defer accountDedupsMu.Unlock()
+
+	d, ok := accountDedups[account]
+	if !ok {
+		d = &accountDedup{claims: make(map[string]fillClaim)}
+		accountDedups[account] = d
+	}
+	return d
+}
+
+// claim 认领成交：未被认领或已由同一 trader 认领返回 true，否则返回认领者
+func (d *accountDedup) claim(key, traderID string, ttl time.Duration) (bool, string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if c, ok := d.claims[key]; ok && now.Sub(c.at) <= ttl && c.traderID != traderID {
+		return false, c.traderID
+	}
+	d.claims[key] = fillClaim{traderID: traderID, at: now}
+
+	// 定期清理过期记录
+	if len(d.claims) > 1000 && len(d.claims)%100 == 0 {
+		for k, c := range d.claims {
+			if now.Sub(c.at) > ttl {
+				delete(d.claims, k)
+			}
+		}
+	}
+	return true, ""
+}
+
+// WithFollowerAccount 设置跟随者账户标识（DedupScope="account" 时用于共享去重）
+func WithFollowerAccount(account string) EngineOption {
+	return func(e *Engine) {
+		e.followerAccount = account
+	}
+}
+
+// claimFill 账户级去重：同一跟随者账户的其他引擎已处理该成交时返回 false
+func (e *Engine) claimFill(fill *Fill) bool {
+	if e.cfg().DedupScope != DedupScopeAccount || e.followerAccount == "" {
+		return true
+	}
+
+	key := fmt.Sprintf("%s|%s|%s", e.cfg().ProviderType, e.cfg().LeaderID, fill.ID)
+	ok, owner := sharedDedupFor(e.followerAccount).claim(key, e.traderID, e.seenTTL)
+	if ok {
+		return true
+	}
+
+	e.stats.DedupAnomalies++
+	logger.Warnf("⚠️ [%s] 跳过成交 %s %s：同一跟随者账户的 trader %s 已跟随（账户级去重）",
+		e.traderID, fill.Symbol, fill.ID, owner)
+	e.dedupWarnOnce.Do(func() {
+		e.logWarning(Warning{
+			Timestamp: time.Now(),
+			Symbol:    fill.Symbol,
+			Type:      "duplicate_follower_account",
+			Message:   fmt.Sprintf("跟随者账户同时被 trader %s 用于跟随同一领航员，重复成交已跳过，请检查配置", owner),
+			Executed:  false,
+		})
+	})
+	return false
+}
@@ -0,0 +1,89 @@
+package copytrade
+
+import (
+	"context"
+	"time"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 降级模式：启动时无法获取领航员状态（WS 缓存为空且 REST 失败）
+// ============================================================================
+//
+// 此时无法标记历史仓位，直接处理成交会把历史仓位的加仓误判为新开仓。
+// StateUnavailablePolicy = "refuse"（默认）：拒绝启动
+// StateUnavailablePolicy = "degraded"：照常启动，但在成功获取领航员状态前丢弃所有成交，
+// 后台持续重试；恢复后将领航员当时的持仓全部标记为 ignored，再开始跟单
+
+// 领航员状态不可用时的启动策略
+const (
+	StateUnavailableRefuse   = "refuse"   // 拒绝启动（安全默认）
+	StateUnavailableDegraded = "degraded" // 降级启动，重试成功前不处理成交
+)
+
+// 降级模式重试间隔（指数退避）
+const (
+	degradedRetryMin = 5 * time.Second
+	degradedRetryMax = 60 * time.Second
+)
+
+// enterDegraded 进入降级模式
+func (e *Engine) enterDegraded(reason error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stats.Degraded = true
+	e.stats.DegradedReason = reason.Error()
+}
+
+// IsDegraded 是否处于降级模式
+func (e *Engine) IsDegraded() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.stats.Degraded
+}
+
+// degradedRecoverLoop 持续重试获取领航员状态，成功后标记历史仓位并退出降级模式
+func (e *Engine) degradedRecoverLoop(ctx context.Context) {
+	delay := degradedRetryMin
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.stopCh:
+			return
+		case <-time.After(delay):
+		}
+
+		if err := e.tryRecoverDegraded(); err != nil {
+			delay *= 2
+			if delay > degradedRetryMax {
+				delay = degradedRetryMax
+			}
+			logger.Warnf("⚠️ [%s] 降级模式：获取领航员状态仍失败，%s 后重试: %v", e.traderID, delay, err)
+			continue
+		}
+		return
+	}
+}
+
+// tryRecoverDegraded 尝试获取领航员状态并标记历史仓位，成功则退出降级模式
+func (e *Engine) tryRecoverDegraded() error {
+	if err := e.InitIgnoredPositions(); err != nil {
+		e.mu.Lock()
+		e.stats.DegradedReason = err.Error()
+		e.mu.Unlock()
+		return err
+	}
+
+	if err := e.syncLeaderState(); err != nil {
+		logger.Warnf("⚠️ [%s] 降级恢复后状态同步失败: %v", e.traderID, err)
+	}
+
+	e.mu.Lock()
+	e.stats.Degraded = false
+	e.stats.DegradedReason = ""
+	e.mu.Unlock()
+	logger.Infof("✅ [%s] 领航员状态已恢复，退出降级模式，开始跟单", e.traderID)
+	return nil
+}
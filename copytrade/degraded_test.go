@@ -0,0 +1,50 @@
+package copytrade
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestDegradedMode 领航员状态不可用时丢弃成交，恢复后历史仓位标记为 ignored
+func TestDegradedMode(t *testing.T) {
+	st := newTestStore(t)
+	provider := &fakeProvider{err: errors.New("REST 获取账户状态失败")}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.store = st
+	e.provider = provider
+
+	if err := e.InitIgnoredPositions(); err == nil {
+		t.Fatal("InitIgnoredPositions should fail while provider is down")
+	}
+	e.enterDegraded(provider.err)
+	if !e.IsDegraded() || e.GetStats().DegradedReason == "" {
+		t.Fatalf("stats = %+v, want degraded with reason", e.GetStats())
+	}
+
+	// 降级期间：领航员开仓的成交被丢弃
+	provider.err = nil
+	provider.setSize(1)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "f1", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 1, Value: 100}})
+	if len(e.decisionCh) != 0 || e.GetStats().SignalsSkipped != 1 {
+		t.Fatalf("fill processed while degraded: decisions=%d skipped=%d", len(e.decisionCh), e.GetStats().SignalsSkipped)
+	}
+
+	// 恢复：降级期间开的仓位视为历史仓位
+	if err := e.tryRecoverDegraded(); err != nil {
+		t.Fatal(err)
+	}
+	if e.IsDegraded() || e.GetStats().DegradedReason != "" {
+		t.Errorf("stats = %+v, want recovered", e.GetStats())
+	}
+	m, _ := st.CopyTrade().GetMapping("test", PositionKey("BTCUSDT", SideLong))
+	if m == nil || m.Status != "ignored" {
+		t.Errorf("position opened while degraded = %+v, want ignored", m)
+	}
+
+	// 之后对该仓位的加仓不跟随
+	provider.setSize(2)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "f2", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionAdd, Price: 100, Size: 1, Value: 100}})
+	if len(e.decisionCh) != 0 {
+		t.Error("add to pre-recovery position followed")
+	}
+}
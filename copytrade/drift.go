@@ -0,0 +1,167 @@
+package copytrade
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"nofx/logger"
+	"nofx/store"
+)
+
+// ============================================================================
+// 仓位映射一致性检查
+// ============================================================================
+
+// DefaultDriftCheckInterval 默认检查间隔
+const DefaultDriftCheckInterval = 5 * time.Minute
+
+// 映射漂移类型
+const (
+	DriftUntracked = "untracked" // 交易所有持仓，但没有对应的 active 映射
+	DriftPhantom   = "phantom"   // 有 active 映射，但交易所没有对应持仓
+)
+
+// MappingDrift 仓位映射与跟随者实际持仓不一致
+type MappingDrift struct {
+	Kind        string    `json:"kind"` // "untracked" | "phantom"
+	Symbol      string    `json:"symbol"`
+	Side        string    `json:"side"`
+	MarginMode  string    `json:"margin_mode,omitempty"`
+	LeaderPosID string    `json:"leader_pos_id,omitempty"` // phantom：对应的映射
+	Size        float64   `json:"size,omitempty"`          // untracked：交易所持仓数量
+	DetectedAt  time.Time `json:"detected_at"`
+}
+
+func (d MappingDrift) key() string {
+	return d.Kind + "|" + d.Symbol + "|" + d.Side + "|" + d.LeaderPosID
+}
+
+// driftCheckInterval 检查间隔（配置 <0 表示关闭）
+func (e *Engine) driftCheckInterval() time.Duration {
+	if e.cfg().DriftCheckMinutes < 0 {
+		return 0
+	}
+	if e.cfg().DriftCheckMinutes == 0 {
+		return DefaultDriftCheckInterval
+	}
+	return time.Duration(e.cfg().DriftCheckMinutes) * time.Minute
+}
+
+// driftLoop 定时对比 active 映射与跟随者实际持仓
+func (e *Engine) driftLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.stopCh:
+			return
+		case <-ticker.C:
+			e.checkMappingDrift()
+		}
+	}
+}
+
+// checkMappingDrift 检查一次映射漂移
+// 刚执行的交易可能处于"已成交未写映射"的瞬间，因此只有连续两次检查都出现的不一致才会上报
+func (e *Engine) checkMappingDrift() {
+	if e.store == nil || e.getFollowerPositions == nil || e.cfg().DryRun {
+		return
+	}
+
+	mappings, err := e.store.CopyTrade().ListActiveMappings(e.traderID)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 查询活跃映射失败: %v", e.traderID, err)
+		return
+	}
+
+	found := diffMappings(mappings, e.getFollowerPositions(), time.Now())
+
+	e.driftMu.Lock()
+	defer e.driftMu.Unlock()
+
+	current := make(map[string]bool, len(found))
+	var confirmed []MappingDrift
+	for _, d := range found {
+		k := d.key()
+		current[k] = true
+		if e.pendingDrift[k] {
+			confirmed = append(confirmed, d)
+		}
+	}
+	e.pendingDrift = current
+
+	if len(confirmed) > 0 && len(e.mappingDrift) == 0 {
+		logger.Warnf("⚠️ [%s] 仓位映射不一致 | %d 项，请检查跟单状态", e.traderID, len(confirmed))
+	}
+	for _, d := range confirmed {
+		logger.Warnf("⚠️ [%s] 映射漂移:%s | %s %s posId=%s size=%.4f",
+			e.traderID, d.Kind, d.Symbol, d.Side, d.LeaderPosID, d.Size)
+	}
+	e.mappingDrift = confirmed
+}
+
+// GetMappingDrift 获取最近一次确认的映射漂移
+func (e *Engine) GetMappingDrift() []MappingDrift {
+	e.driftMu.Lock()
+	defer e.driftMu.Unlock()
+	return append([]MappingDrift(nil), e.mappingDrift...)
+}
+
+// diffMappings 按 symbol+side 对比 active 映射与交易所持仓
+func diffMappings(mappings []*store.CopyTradePositionMapping, positions map[string]*Position, now time.Time) []MappingDrift {
+	type bucket struct {
+		symbol     string
+		side       SideType
+		size       float64
+		marginMode string
+	}
+	held := make(map[string]*bucket)
+	for _, pos := range positions {
+		if pos.Size <= 0 {
+			continue
+		}
+		k := pos.Symbol + "_" + string(pos.Side)
+		if held[k] == nil {
+			held[k] = &bucket{symbol: pos.Symbol, side: pos.Side, marginMode: pos.MarginMode}
+		}
+		held[k].size += pos.Size
+	}
+
+	mapped := make(map[string]bool)
+	var drifts []MappingDrift
+	for _, m := range mappings {
+		k := m.Symbol + "_" + m.Side
+		mapped[k] = true
+		if held[k] == nil {
+			drifts = append(drifts, MappingDrift{
+				Kind:        DriftPhantom,
+				Symbol:      m.Symbol,
+				Side:        m.Side,
+				MarginMode:  m.MarginMode,
+				LeaderPosID: m.LeaderPosID,
+				DetectedAt:  now,
+			})
+		}
+	}
+
+	for k, b := range held {
+		if mapped[k] {
+			continue
+		}
+		drifts = append(drifts, MappingDrift{
+			Kind:       DriftUntracked,
+			Symbol:     b.symbol,
+			Side:       string(b.side),
+			MarginMode: b.marginMode,
+			Size:       b.size,
+			DetectedAt: now,
+		})
+	}
+
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].key() < drifts[j].key() })
+	return drifts
+}
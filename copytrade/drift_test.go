@@ -0,0 +1,63 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+
+	"nofx/store"
+)
+
+// TestMappingDrift 交易所持仓与 active 映射不一致时，连续两次检查后上报 untracked/phantom
+func TestMappingDrift(t *testing.T) {
+	st := newTestStore(t)
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader"}, 1000)
+	e.store = st
+
+	for _, m := range []struct{ posID, symbol, side string }{
+		{"1", "BTCUSDT", "long"},  // 正常
+		{"2", "ETHUSDT", "short"}, // 交易所无持仓 → phantom
+	} {
+		if err := st.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+			TraderID: "test", LeaderPosID: m.posID, LeaderID: "leader", Symbol: m.symbol,
+			Side: m.side, MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 100,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.getFollowerPositions = func() map[string]*Position {
+		return map[string]*Position{
+			"a": {Symbol: "BTCUSDT", Side: SideLong, Size: 0.1, MarginMode: "cross"},
+			"b": {Symbol: "SOLUSDT", Side: SideLong, Size: 5, MarginMode: "cross"}, // 无映射 → untracked
+		}
+	}
+
+	e.checkMappingDrift()
+	if got := e.GetMappingDrift(); len(got) != 0 {
+		t.Fatalf("drift reported after first check: %+v", got)
+	}
+
+	e.checkMappingDrift()
+	got := e.GetMappingDrift()
+	if len(got) != 2 {
+		t.Fatalf("drift = %+v, want 2 items", got)
+	}
+	kinds := map[string]string{}
+	for _, d := range got {
+		kinds[d.Symbol] = d.Kind
+	}
+	if kinds["ETHUSDT"] != DriftPhantom || kinds["SOLUSDT"] != DriftUntracked {
+		t.Errorf("drift kinds = %v", kinds)
+	}
+
+	// 状态恢复后清除
+	e.getFollowerPositions = func() map[string]*Position {
+		return map[string]*Position{
+			"a": {Symbol: "BTCUSDT", Side: SideLong, Size: 0.1},
+			"c": {Symbol: "ETHUSDT", Side: SideShort, Size: 1},
+		}
+	}
+	e.checkMappingDrift()
+	if got := e.GetMappingDrift(); len(got) != 0 {
+		t.Errorf("drift not cleared: %+v", got)
+	}
+}
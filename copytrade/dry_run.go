@@ -0,0 +1,47 @@
+package copytrade
+
+import (
+	"fmt"
+	"time"
+
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/store"
+)
+
+// ============================================================================
+// 模拟运行（dry run）
+// ============================================================================
+//
+// DryRun 开启后照常拉取领航员成交、匹配信号并生成决策，但决策不提交执行器（不下单、不设止损/杠杆）：
+//   - 信号日志状态记为 dry_run（看板单独统计，不计入执行成功数与执行率）
+//   - 仓位映射照常更新，后续加仓/减仓/平仓的匹配与实盘一致；平仓盈亏按领航员开平仓价估算
+//   - 跟随者实际没有持仓，映射漂移检查跳过
+// 适合在投入资金前观察新领航员几天。关闭 dry run 前应清理模拟产生的活跃映射，避免实盘按模拟仓位减仓/平仓。
+
+// SignalStatusDryRun 模拟运行的信号日志状态
+const SignalStatusDryRun = "dry_run"
+
+// isDryRun 当前是否模拟运行（可热更新）
+func (e *Engine) isDryRun() bool {
+	return e.cfg().DryRun
+}
+
+// simulateDecision 模拟执行一条决策：不调用执行器，记录信号日志并更新仓位映射
+func (ti *TraderIntegration) simulateDecision(dec *decision.Decision) store.DecisionAction {
+	logger.Infof("🧪 [%s] 模拟执行（dry run）| %s %s 金额=%.2f 杠杆=%dx",
+		ti.traderID, dec.Action, dec.Symbol, dec.PositionSizeUSD, dec.Leverage)
+	ti.saveSignalLog(dec, SignalStatusDryRun, "")
+	ti.applyPositionMapping(dec, estimatedFill(dec))
+
+	return store.DecisionAction{
+		Action:    dec.Action,
+		Symbol:    dec.Symbol,
+		Leverage:  dec.Leverage,
+		Price:     dec.EntryPrice,
+		StopLoss:  dec.StopLoss,
+		Reasoning: fmt.Sprintf("[dry run] %s", dec.Reasoning),
+		Timestamp: time.Now(),
+		Success:   true,
+	}
+}
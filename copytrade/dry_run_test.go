@@ -0,0 +1,52 @@
+package copytrade
+
+import "testing"
+
+// TestDryRun 模拟运行不调用执行器，信号日志记为 dry_run，仓位映射照常更新以保持后续匹配一致
+func TestDryRun(t *testing.T) {
+	st := newTestStore(t)
+	provider := &fakeProvider{}
+	provider.setSize(1)
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1, DryRun: true}, 1000)
+	e.store = st
+	e.provider = provider
+	exec := &fakeExecutor{}
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e, executor: exec}
+	posID := PositionKey("BTCUSDT", SideLong)
+
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "f1", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong,
+		Action: ActionOpen, Price: 100, Size: 1, Value: 100}})
+	ti.executeFullDecision(<-e.decisionCh)
+	if m, err := st.CopyTrade().GetMapping("test", posID); err != nil || m == nil || m.Status != "active" {
+		t.Fatalf("after open: mapping = %+v err=%v, want active", m, err)
+	}
+
+	// 领航员平仓：按模拟映射匹配为平仓
+	provider.state = &AccountState{TotalEquity: 10000, Positions: map[string]*Position{}}
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "f2", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong,
+		Action: ActionClose, Price: 110, Size: 1, Value: 110}})
+	fullDec := <-e.decisionCh
+	if dec := fullDec.Decisions[0]; dec.Action != "close_long" {
+		t.Fatalf("close decision = %+v", dec)
+	}
+	ti.executeFullDecision(fullDec)
+
+	if len(exec.executed) != 0 {
+		t.Fatalf("executor called in dry run: %+v", exec.executed)
+	}
+	if all, err := st.CopyTrade().ListAllMappings("test", 10); err != nil || len(all) != 1 || all[0].Status != "closed" {
+		t.Fatalf("after close: mappings = %+v err=%v, want one closed", all, err)
+	}
+	logs, err := st.CopyTrade().GetRecentSignalLogs("test", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("signal logs = %d, want 2", len(logs))
+	}
+	for _, l := range logs {
+		if l.Status != SignalStatusDryRun || !l.Followed {
+			t.Errorf("signal log = %+v, want followed dry_run", l)
+		}
+	}
+}
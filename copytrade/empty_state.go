@@ -0,0 +1,74 @@
+package copytrade
+
+import (
+	"time"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 空持仓状态复核
+// ============================================================================
+//
+// 数据源偶尔在短暂异常时返回持仓为空的 AccountState，matchCloseReduceSignal 会认为
+// 所有 posId 已消失而全量平仓。收到平仓/减仓成交时，如果刚同步的状态没有任何持仓、
+// 本地却有多个 active 映射（一笔成交不可能平掉多个仓位），视为可疑状态，绕过缓存重新拉取一次：
+//   - 重新拉取到持仓 → 使用新状态匹配
+//   - 仍为空或拉取失败 → 按空状态处理（领航员确实清仓）
+//
+// 确认为空后 emptyStateConfirmTTL 内不再重复复核，领航员一次性清仓时每笔平仓成交不会都多一次请求。
+
+// 空持仓状态处理策略
+const (
+	EmptyStateRefetch = "refetch" // 可疑空状态重新拉取一次（默认）
+	EmptyStateTrust   = "trust"   // 直接使用空状态
+)
+
+// emptyStateConfirmTTL 空状态确认后的免复核时间
+const emptyStateConfirmTTL = 10 * time.Second
+
+// verifyEmptyLeaderState 平仓/减仓成交遇到可疑空状态时重新拉取一次领航员状态
+func (e *Engine) verifyEmptyLeaderState(fill *Fill) {
+	if e.cfg().EmptyStatePolicy == EmptyStateTrust || e.store == nil {
+		return
+	}
+	if fill.Action != ActionClose && fill.Action != ActionReduce {
+		return
+	}
+
+	e.leaderStateMu.RLock()
+	empty := e.leaderState != nil && len(e.leaderState.Positions) == 0
+	confirmed := !e.emptyStateConfirmedAt.IsZero() && time.Since(e.emptyStateConfirmedAt) < emptyStateConfirmTTL
+	e.leaderStateMu.RUnlock()
+	if !empty || confirmed {
+		return
+	}
+
+	mappings, err := e.listActiveMappings()
+	if err != nil || len(mappings) < 2 {
+		return
+	}
+
+	logger.Warnf("⚠️ [%s] suspicious empty state, refetching | 领航员持仓为空但有 %d 个活跃映射，收到 %s %s %s",
+		e.traderID, len(mappings), fill.Symbol, fill.Action, fill.PositionSide)
+
+	var state *AccountState
+	if fp, ok := e.provider.(FreshStateProvider); ok {
+		state, err = fp.GetAccountStateFresh(e.cfg().LeaderID)
+	} else {
+		state, err = e.provider.GetAccountState(e.cfg().LeaderID)
+	}
+	state = e.scopeState(state)
+
+	e.leaderStateMu.Lock()
+	defer e.leaderStateMu.Unlock()
+	if err != nil || state == nil || len(state.Positions) == 0 {
+		e.emptyStateConfirmedAt = time.Now()
+		logger.Warnf("⚠️ [%s] 重新拉取后领航员持仓仍为空（err=%v），按清仓处理", e.traderID, err)
+		return
+	}
+	e.leaderState = state
+	e.lastStateSync = time.Now()
+	e.emptyStateConfirmedAt = time.Time{}
+	logger.Infof("✅ [%s] 重新拉取到领航员持仓 %d 个，忽略空状态", e.traderID, len(state.Positions))
+}
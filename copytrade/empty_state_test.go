@@ -0,0 +1,56 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+
+	"nofx/decision"
+	"nofx/store"
+)
+
+// TestSuspiciousEmptyStateRefetch 缓存状态异常为空时，一笔减仓成交不被误判为全平
+func TestSuspiciousEmptyStateRefetch(t *testing.T) {
+	run := func(t *testing.T, policy string, fresh map[string]*Position) *decision.Decision {
+		t.Helper()
+		st := newTestStore(t)
+		for _, symbol := range []string{"BTCUSDT", "ETHUSDT"} {
+			if err := st.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+				TraderID: "test", LeaderPosID: PositionKey(symbol, SideLong), LeaderID: "leader", Symbol: symbol,
+				Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 100, LastKnownSize: 1,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		provider := &staleStateProvider{
+			fakeProvider: fakeProvider{state: &AccountState{TotalEquity: 10000, Positions: map[string]*Position{}}},
+			fresh:        &AccountState{TotalEquity: 10000, Positions: fresh},
+		}
+		e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1, EmptyStatePolicy: policy}, 1000)
+		e.store = st
+		e.provider = provider
+		e.processSignal(e.buildSignal(&Fill{
+			ID: "reduce", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionReduce,
+			Price: 100, Size: 0.5, Value: 50, Timestamp: time.Now(),
+		}))
+		if len(e.decisionCh) != 1 {
+			t.Fatalf("decisions = %d, want 1", len(e.decisionCh))
+		}
+		return &(<-e.decisionCh).Decisions[0]
+	}
+
+	// 实时状态：BTC 减仓到 0.5，ETH 仍持有
+	held := map[string]*Position{
+		PositionKey("BTCUSDT", SideLong): {Symbol: "BTCUSDT", Side: SideLong, Size: 0.5, MarginMode: "cross"},
+		PositionKey("ETHUSDT", SideLong): {Symbol: "ETHUSDT", Side: SideLong, Size: 1, MarginMode: "cross"},
+	}
+	if d := run(t, "", held); d.CloseRatio < 0.49 || d.CloseRatio > 0.51 {
+		t.Errorf("refetch: close ratio = %.2f, want 0.5 (partial reduce)", d.CloseRatio)
+	}
+	if d := run(t, EmptyStateTrust, held); d.CloseRatio != 0 {
+		t.Errorf("trust: close ratio = %.2f, want 0 (full close)", d.CloseRatio)
+	}
+	if d := run(t, "", map[string]*Position{}); d.CloseRatio != 0 {
+		t.Errorf("confirmed empty: close ratio = %.2f, want 0 (full close)", d.CloseRatio)
+	}
+}
@@ -1,10 +1,14 @@
 package copytrade
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"nofx/decision"
@@ -15,7 +19,10 @@ import (
 // Engine 跟单引擎
 type Engine struct {
 	traderID string
-	config   *CopyConfig
+	// 当前生效配置（只读快照，热更新时整体替换，通过 cfg() 读取）
+	// 处理信号时持 cfgMu 读锁、UpdateConfig 持写锁，同一信号内 cfg() 始终返回同一份快照
+	config   atomic.Pointer[CopyConfig]
+	cfgMu    sync.RWMutex
 	provider LeaderProvider
 
 	// 流式 Provider（如果支持）
@@ -23,16 +30,25 @@ type Engine struct {
 	isStreamingMode   bool
 
 	// 跟随者账户信息（由外部注入）
-	getFollowerBalance   func() float64
-	getFollowerPositions func() map[string]*Position
+	getFollowerBalance    func() float64
+	getFollowerPositions  func() map[string]*Position
+	getFollowerFreeMargin func() float64 // 可用保证金（nil=使用权益）
 
 	// 数据库存储（用于仓位映射）
 	store *store.Store
 
-	// 去重（使用时间戳过期）
-	seenFills map[string]time.Time
-	seenMu    sync.RWMutex
-	seenTTL   time.Duration
+	// 去重（使用时间戳过期）：内存缓存 + 数据库持久化（重启后仍有效）
+	seenFills     map[string]time.Time
+	seenMu        sync.RWMutex
+	seenTTL       time.Duration
+	seenCleanedAt time.Time // 上次清理数据库去重记录的时间
+
+	// 账户级去重（DedupScope="account"）：跟随者账户标识
+	followerAccount string
+	dedupWarnOnce   sync.Once
+
+	// 重连对账（流式模式）：对账状态时间之前的实时成交已由对账处理
+	reconnect reconnectState
 
 	// 状态缓存
 	leaderState       *AccountState
@@ -40,6 +56,9 @@ type Engine struct {
 	lastStateSync     time.Time
 	stateSyncInterval time.Duration
 
+	// 可疑空持仓状态复核：确认领航员确实清仓的时间
+	emptyStateConfirmedAt time.Time
+
 	// 决策输出
 	decisionCh chan *decision.FullDecision
 
@@ -54,6 +73,66 @@ type Engine struct {
 
 	// 统计
 	stats *EngineStats
+
+	// 执行耗时样本数（AvgExecutionLatencyMs 的累计平均分母）
+	latencySamples int64
+
+	// 轮询模式的 REST 拉取间隔（0=默认 3 秒）
+	pollInterval time.Duration
+
+	// 决策通道开始持续满载的时间（成功入队后清零）
+	decisionFullSince time.Time
+
+	// 已见过的领航员挂单（orderId → 记录），镜像挂单时使用
+	mirroredMu     sync.Mutex
+	mirroredOrders map[string]*mirroredOrder
+
+	// 仓位映射一致性检查
+	mappingDrift []MappingDrift
+	pendingDrift map[string]bool
+	driftMu      sync.Mutex
+
+	// 流式状态与 REST 状态交叉校验
+	crossCheck crossCheckState
+
+	// 性能诊断（Profiling 开启时非 nil）
+	profiler *engineProfiler
+
+	// 最近生成的决策（重复决策保护）
+	recentDecisions map[string]recentDecision
+	recentDecMu     sync.Mutex
+
+	// 跟随者交易所币种上架检查（nil=不检查）
+	supportsSymbol func(symbol string) (bool, error)
+	symbolSupport  map[string]symbolSupport
+	unlistedWarned map[string]bool
+	symbolMu       sync.Mutex
+
+	// 跟随者交易所最小下单量（nil=不检查减仓数量下限）
+	minOrderQty func(symbol string) (float64, error)
+	minQtyCache map[string]minQty
+
+	// 初始同步（InitialSync=mirror）：启动时生成，Start 时推送
+	initialSync *decision.FullDecision
+
+	// 币种价格样本（高波动降杠杆）
+	volatility volatilityTracker
+
+	// 领航员近期平仓盈亏（自适应跟单系数）
+	leaderPnL leaderPnLTracker
+
+	// 模拟成交（simulate 接口）：只匹配和计算，不写数据库
+	simulation bool
+
+	// 延迟跟单：待跟随的开仓（posId → 待推送决策）
+	delayedOpens map[string]*delayedOpen
+	delayedMu    sync.Mutex
+}
+
+// recentDecision 最近一次决策的指纹与时间
+type recentDecision struct {
+	fingerprint []byte
+	at          time.Time
 }
 
 // EngineOption 引擎配置选项
@@ -66,6 +145,20 @@ func WithStreamingMode() EngineOption {
 	}
 }
 
+// WithPollInterval 设置轮询模式的 REST 拉取间隔（覆盖 CopyConfig.PollInterval，流式模式忽略）
+func WithPollInterval(d time.Duration) EngineOption {
+	return func(e *Engine) {
+		e.pollInterval = d
+	}
+}
+
+// WithDecisionChannel 使用外部决策通道（多领航员跟单时各引擎共用一个通道，由同一消费者按顺序执行）
+func WithDecisionChannel(ch chan *decision.FullDecision) EngineOption {
+	return func(e *Engine) {
+		e.decisionCh = ch
+	}
+}
+
 // NewEngine 创建跟单引擎
 func NewEngine(
 	traderID string,
@@ -76,7 +169,6 @@ func NewEngine(
 ) (*Engine, error) {
 	e := &Engine{
 		traderID:             traderID,
-		config:               config,
 		getFollowerBalance:   getBalance,
 		getFollowerPositions: getPositions,
 		seenFills:            make(map[string]time.Time),
@@ -85,40 +177,47 @@ func NewEngine(
 		decisionCh:           make(chan *decision.FullDecision, 10),
 		stopCh:               make(chan struct{}),
 		stats:                &EngineStats{StartTime: time.Now()},
+		pollInterval:         config.PollInterval,
 	}
 
+	e.config.Store(config)
+
 	// 应用选项
 	for _, opt := range opts {
 		opt(e)
 	}
 
-	// 根据配置选择 Provider 类型
-	if e.isStreamingMode {
-		// 尝试创建流式 Provider（目前只有 Hyperliquid 支持）
-		streamingProvider, err := NewStreamingProvider(config.ProviderType)
-		if err != nil {
-			// 不支持流式模式，回退到轮询模式
-			logger.Warnf("⚠️ [%s] %s 不支持流式模式，回退到轮询模式", traderID, config.ProviderType)
-			e.isStreamingMode = false
-		} else {
-			e.streamingProvider = streamingProvider
-			e.provider = streamingProvider // StreamingProvider 也实现了 LeaderProvider
-			logger.Infof("✅ [%s] 使用流式模式 (WebSocket)", traderID)
-			return e, nil
-		}
+	if config.Profiling {
+		e.profiler = newEngineProfiler()
 	}
 
-	// 轮询模式（默认，或流式模式不可用时回退）
-	provider, err := NewProvider(config.ProviderType)
+	// 根据配置与 Provider 能力选择流式或轮询模式
+	provider, caps, err := BuildProvider(config.ProviderType, e.isStreamingMode)
 	if err != nil {
 		return nil, err
 	}
 	e.provider = provider
+	e.applyInstrumentMap()
+	if sp, ok := provider.(StreamingProvider); ok && e.isStreamingMode {
+		e.streamingProvider = sp
+		logger.Infof("✅ [%s] 使用流式模式 (WebSocket)", traderID)
+		return e, nil
+	}
+	if e.isStreamingMode && !caps.Streaming {
+		// 不支持流式模式，回退到轮询模式
+		logger.Warnf("⚠️ [%s] %s 不支持流式模式，回退到轮询模式", traderID, config.ProviderType)
+	}
+	e.isStreamingMode = false
 	logger.Infof("✅ [%s] 使用轮询模式 (REST)", traderID)
 
 	return e, nil
 }
 
+// cfg 当前生效配置快照（不可修改；热更新替换整份配置）
+func (e *Engine) cfg() *CopyConfig {
+	return e.config.Load()
+}
+
 // GetDecisionChannel 获取决策输出通道
 func (e *Engine) GetDecisionChannel() <-chan *decision.FullDecision {
 	return e.decisionCh
@@ -143,10 +242,11 @@ func (e *Engine) InitIgnoredPositions() error {
 	}
 
 	// 获取领航员当前所有持仓
-	state, err := e.provider.GetAccountState(e.config.LeaderID)
+	state, err := e.provider.GetAccountState(e.cfg().LeaderID)
 	if err != nil {
 		return fmt.Errorf("获取领航员持仓失败: %w", err)
 	}
+	state = e.scopeState(state)
 
 	if state == nil || len(state.Positions) == 0 {
 		logger.Infof("📊 [%s] 领航员当前无持仓，无需标记历史仓位", e.traderID)
@@ -167,7 +267,7 @@ func (e *Engine) InitIgnoredPositions() error {
 
 		err := e.store.CopyTrade().SaveIgnoredPosition(
 			e.traderID,
-			e.config.LeaderID,
+			e.cfg().LeaderID,
 			posID,
 			pos.Symbol,
 			string(pos.Side),
@@ -184,6 +284,11 @@ func (e *Engine) InitIgnoredPositions() error {
 	}
 
 	logger.Infof("✅ [%s] 历史仓位初始化完成 | 共标记 %d 个仓位为 ignored", e.traderID, ignoredCount)
+
+	// 初始同步：按比例跟开已有仓位（先标记 ignored，开仓成功后映射转为 active）
+	if e.cfg().InitialSync == InitialSyncMirror {
+		e.initialSync = e.buildInitialMirror(state)
+	}
 	return nil
 }
 
@@ -201,16 +306,71 @@ func (e *Engine) Start(ctx context.Context) error {
 	if e.isStreamingMode {
 		mode = "流式(WebSocket)"
 	}
+	if !e.isStreamingMode {
+		if e.pollInterval > 0 && e.pollInterval < MinPollInterval {
+			logger.Warnf("⚠️ [%s] 轮询间隔 %s 低于 %s，使用默认 %s", e.traderID, e.pollInterval, MinPollInterval, DefaultPollInterval)
+		}
+		mode = fmt.Sprintf("%s poll=%s", mode, e.effectivePollInterval())
+	}
+	if e.cfg().DryRun {
+		mode += " dry_run"
+	}
+	if e.cfg().Inverse {
+		mode += " inverse"
+	}
 	logger.Infof("🚀 [%s] 跟单引擎启动 | provider=%s leader=%s ratio=%.0f%% mode=%s",
-		e.traderID, e.config.ProviderType, e.config.LeaderID, e.config.CopyRatio*100, mode)
+		e.traderID, e.cfg().ProviderType, e.cfg().LeaderID, e.cfg().CopyRatio*100, mode)
 
-	// 流式模式：WebSocket 事件驱动
+	var err error
 	if e.isStreamingMode && e.streamingProvider != nil {
-		return e.startStreamingMode(ctx)
+		// 流式模式：WebSocket 事件驱动
+		err = e.startStreamingMode(ctx)
+	} else {
+		// 轮询模式：REST 定时轮询（OKX 或回退模式）
+		err = e.startPollingMode(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 降级模式：后台重试获取领航员状态
+	if e.IsDegraded() {
+		go e.degradedRecoverLoop(ctx)
+	}
+
+	// 试用模式进度
+	e.loadTrialProgress()
+
+	// 旧映射补齐 lastKnownSize
+	e.backfillLastKnownSize()
+
+	// 自适应跟单系数：领航员近期表现基线
+	e.seedLeaderPnL()
+
+	// 初始同步决策
+	e.pushInitialSync()
+
+	// 最大持仓时间检查
+	if e.cfg().MaxHoldHours > 0 {
+		go e.holdTimeLoop(ctx)
+	}
+
+	// 仓位映射一致性检查
+	if interval := e.driftCheckInterval(); interval > 0 {
+		go e.driftLoop(ctx, interval)
+	}
+
+	// 流式状态与 REST 状态交叉校验
+	if interval := e.crossCheckInterval(); interval > 0 {
+		go e.crossCheckLoop(ctx, interval)
+	}
+
+	// 镜像领航员开仓挂单
+	if interval := e.leaderOrdersPollInterval(); interval > 0 {
+		go e.leaderOrdersLoop(ctx, interval)
 	}
 
-	// 轮询模式：REST 定时轮询（OKX 或回退模式）
-	return e.startPollingMode(ctx)
+	return nil
 }
 
 // startStreamingMode 启动流式模式（WebSocket 事件驱动）
@@ -219,9 +379,19 @@ func (e *Engine) startStreamingMode(ctx context.Context) error {
 	e.streamingProvider.SetOnFill(func(fill Fill) {
 		// 去重检查
 		if e.isSeen(fill.ID) {
+			e.stats.SignalsDeduped++
+			e.stats.DedupAnomalies++ // 流式推送不应重复
 			return
 		}
 		e.markSeen(fill.ID)
+		if !e.claimFill(&fill) {
+			e.stats.SignalsDeduped++
+			return
+		}
+		if e.skipReconciledFill(&fill) {
+			e.stats.SignalsDeduped++
+			return
+		}
 
 		e.stats.SignalsReceived++
 		e.stats.LastSignalTime = time.Now()
@@ -238,13 +408,18 @@ func (e *Engine) startStreamingMode(ctx context.Context) error {
 	// 设置状态更新回调：持仓变化时更新缓存
 	e.streamingProvider.SetOnStateUpdate(func(state *AccountState) {
 		e.leaderStateMu.Lock()
-		e.leaderState = state
+		e.leaderState = e.scopeState(state)
 		e.lastStateSync = time.Now()
 		e.leaderStateMu.Unlock()
 	})
 
+	// 重连回调：补跟断线期间的变化（Provider 不支持时断线期间的变化只能等下一笔成交）
+	if rn, ok := e.streamingProvider.(ReconnectNotifier); ok {
+		rn.SetOnReconnect(e.onReconnect)
+	}
+
 	// 连接并订阅
-	if err := e.streamingProvider.Connect(e.config.LeaderID); err != nil {
+	if err := e.streamingProvider.Connect(e.cfg().LeaderID); err != nil {
 		return fmt.Errorf("streaming provider connect failed: %w", err)
 	}
 
@@ -292,6 +467,7 @@ func (e *Engine) Stop() {
 
 	close(e.stopCh)
 	e.running = false
+	e.dropDelayedOpens()
 
 	logger.Infof("🛑 [%s] 跟单引擎已停止", e.traderID)
 }
@@ -301,7 +477,7 @@ func (e *Engine) Stop() {
 // ============================================================================
 
 func (e *Engine) pollLoop(ctx context.Context) {
-	ticker := time.NewTicker(3 * time.Second)
+	ticker := time.NewTicker(e.effectivePollInterval())
 	defer ticker.Stop()
 
 	for {
@@ -316,14 +492,34 @@ func (e *Engine) pollLoop(ctx context.Context) {
 	}
 }
 
+// effectivePollInterval 轮询间隔（未设置或低于 MinPollInterval 时使用 DefaultPollInterval，防止误配置高频请求）
+func (e *Engine) effectivePollInterval() time.Duration {
+	if e.pollInterval < MinPollInterval {
+		return DefaultPollInterval
+	}
+	return e.pollInterval
+}
+
+// pollWindow 每次轮询拉取成交的回溯窗口（至少 1 分钟，且覆盖 3 个轮询间隔，重叠部分由去重处理）
+func (e *Engine) pollWindow() time.Duration {
+	window := 3 * e.effectivePollInterval()
+	if window < time.Minute {
+		return time.Minute
+	}
+	return window
+}
+
 func (e *Engine) poll() {
-	// 获取最近 1 分钟的成交
-	since := time.Now().Add(-1 * time.Minute)
-	fills, err := e.provider.GetFills(e.config.LeaderID, since)
+	cfg := e.cfg()
+
+	// 获取回溯窗口内的成交（默认最近 1 分钟）
+	since := time.Now().Add(-e.pollWindow())
+	fills, err := e.provider.GetFills(cfg.LeaderID, since)
 	if err != nil {
 		logger.Warnf("⚠️ [%s] 获取成交记录失败: %v", e.traderID, err)
 		return
 	}
+	fills = e.filterFillsSince(fills, since, cfg.OutOfWindowFills)
 
 	// 按时间排序（确保反向开仓按顺序处理）
 	sort.Slice(fills, func(i, j int) bool {
@@ -333,9 +529,16 @@ func (e *Engine) poll() {
 	// 🔑 第一步：过滤出新成交（未处理的）
 	var newFills []Fill
 	for _, fill := range fills {
-		if !e.isSeen(fill.ID) {
-			newFills = append(newFills, fill)
+		if e.isSeen(fill.ID) {
+			e.stats.SignalsDeduped++
+			continue
+		}
+		if !e.claimFill(&fill) {
+			e.markSeen(fill.ID)
+			e.stats.SignalsDeduped++
+			continue
 		}
+		newFills = append(newFills, fill)
 	}
 
 	// 🔑 第二步：有新成交时，强制同步领航员持仓（确保用最新数据判断）
@@ -375,13 +578,45 @@ func (e *Engine) poll() {
 	}
 }
 
+// filterFillsSince 检查数据源是否返回了早于 since 的成交
+// 部分数据源会忽略 since 参数；去重记录清理后这些旧成交会被当作新信号重复处理
+// 时间戳缺失的成交无法判断，保留交给去重处理
+func (e *Engine) filterFillsSince(fills []Fill, since time.Time, policy string) []Fill {
+	kept := fills[:0]
+	var dropped int
+	var oldest time.Time
+	for _, fill := range fills {
+		if !fill.Timestamp.IsZero() && fill.Timestamp.Before(since) {
+			dropped++
+			if oldest.IsZero() || fill.Timestamp.Before(oldest) {
+				oldest = fill.Timestamp
+			}
+			if policy != OutOfWindowKeep {
+				continue
+			}
+		}
+		kept = append(kept, fill)
+	}
+
+	if dropped > 0 {
+		if policy == OutOfWindowKeep {
+			logger.Warnf("⚠️ [%s] 数据源返回 %d 条窗口外成交（最早 %s，since=%s），按配置照常处理",
+				e.traderID, dropped, oldest.Format(time.RFC3339), since.Format(time.RFC3339))
+		} else {
+			logger.Warnf("⚠️ [%s] 数据源返回 %d 条窗口外成交（最早 %s，since=%s），已丢弃",
+				e.traderID, dropped, oldest.Format(time.RFC3339), since.Format(time.RFC3339))
+		}
+	}
+	return kept
+}
+
 func (e *Engine) buildSignal(fill *Fill) *TradeSignal {
 	e.leaderStateMu.RLock()
 	defer e.leaderStateMu.RUnlock()
 
 	signal := &TradeSignal{
-		LeaderID:     e.config.LeaderID,
-		ProviderType: e.config.ProviderType,
+		LeaderID:     e.cfg().LeaderID,
+		ProviderType: e.cfg().ProviderType,
 		Fill:         fill,
 	}
 
@@ -398,12 +633,12 @@ func (e *Engine) buildSignal(fill *Fill) *TradeSignal {
 
 // SignalMatchResult 信号匹配结果
 type SignalMatchResult struct {
-	ShouldFollow   bool       // 是否跟随
-	Reason         string     // 原因
-	Action         ActionType // 实际动作类型
-	PosID          string     // 领航员仓位 ID
-	MarginMode     string     // 保证金模式
-	LeaderPosition *Position  // 领航员仓位（可能为 nil，表示已平仓）
+	ShouldFollow   bool       `json:"should_follow"`   // 是否跟随
+	Reason         string     `json:"reason"`          // 原因
+	Action         ActionType `json:"action"`          // 实际动作类型
+	PosID          string     `json:"pos_id"`          // 领航员仓位 ID
+	MarginMode     string     `json:"margin_mode"`     // 保证金模式
+	LeaderPosition *Position  `json:"leader_position"` // 领航员仓位（可能为 nil，表示已平仓）
 }
 
 // matchSignalWithMapping 统一信号匹配（核心方法）
@@ -415,6 +650,7 @@ type SignalMatchResult struct {
 //
 // ============================================================================
 func (e *Engine) matchSignalWithMapping(signal *TradeSignal) *SignalMatchResult {
+	defer e.traceSpan("matchSignalWithMapping")()
 	fill := signal.Fill
 
 	if e.store == nil {
@@ -468,13 +704,8 @@ func (e *Engine) buildLeaderPosMap() map[string]*Position {
 func (e *Engine) matchOpenAddSignal(signal *TradeSignal, leaderPosMap map[string]*Position) *SignalMatchResult {
 	fill := signal.Fill
 
-	// 收集所有 symbol+side 匹配的仓位
-	var matchedPositions []*Position
-	for _, pos := range leaderPosMap {
-		if pos.Symbol == fill.Symbol && pos.Side == fill.PositionSide {
-			matchedPositions = append(matchedPositions, pos)
-		}
-	}
+	// 收集所有 symbol+side 匹配的仓位（按保证金模式区分 OKX 同币种同方向的全仓/逐仓仓位）
+	matchedPositions, posIDs := e.leaderCandidates(fill, leaderPosMap)
 
 	if len(matchedPositions) == 0 {
 		return &SignalMatchResult{
@@ -483,28 +714,32 @@ func (e *Engine) matchOpenAddSignal(signal *TradeSignal, leaderPosMap map[string
 		}
 	}
 
+	// 一次性批量查询所有候选 posId 的映射，三轮匹配共用
+	mappings, err := e.store.CopyTrade().GetMappingsByPosIDs(e.traderID, posIDs)
+	if err != nil {
+		logger.Errorf("❌ [%s] 查询映射失败: %v", e.traderID, err)
+		return &SignalMatchResult{
+			ShouldFollow: false,
+			Reason:       fmt.Sprintf("查询映射失败: %v", err),
+		}
+	}
+
 	// ============================================================
 	// 第一轮：查找新开仓（无映射或 closed 状态的 posId）
 	// ============================================================
 	var newPosition *Position
+	var newPosID string
 
-	for _, pos := range matchedPositions {
-		posID := pos.PosID
-		if posID == "" {
-			posID = fmt.Sprintf("%s_%s", fill.Symbol, fill.PositionSide)
-		}
-
-		mapping, err := e.store.CopyTrade().GetMapping(e.traderID, posID)
-		if err != nil {
-			logger.Warnf("⚠️ [%s] 查询映射失败: %v (posId=%s)", e.traderID, err, posID)
-			continue
-		}
+	for i, pos := range matchedPositions {
+		posID := posIDs[i]
+		mapping := mappings[posID]
 
 		if mapping == nil {
 			// 无映射 = 新开仓（优先）
+			// 已平仓的映射不会被查出（closed 记录在重新开仓时归档，SavePositionMapping 重置所有开仓字段）
 			logger.Infof("📊 [%s] 发现新 posId | posId=%s mgnMode=%s → 新开仓候选",
 				e.traderID, posID, pos.MarginMode)
-			newPosition = pos
+			newPosition, newPosID = pos, posID
 			break
 		}
 
@@ -512,13 +747,13 @@ func (e *Engine) matchOpenAddSignal(signal *TradeSignal, leaderPosMap map[string
 			// 已关闭 = 可重新开仓
 			logger.Infof("📊 [%s] 仓位已关闭 | posId=%s → 新开仓候选",
 				e.traderID, posID)
-			newPosition = pos
+			newPosition, newPosID = pos, posID
 			break
 		}
 
 		if mapping.Status == "ignored" {
 			// 🔑 关键区分：根据数据源（ProviderType）使用不同的判断逻辑
-			if e.config.ProviderType == "okx" {
+			if e.cfg().ProviderType == "okx" {
 				// OKX: ignored 状态永远不跟
 				// 原因：OKX 的 posId 是真实的，平仓后失效，新开仓会分配新的 posId
 				// 所以 ignored 的 posId 永远不会再被使用，直接跳过
@@ -533,7 +768,7 @@ func (e *Engine) matchOpenAddSignal(signal *TradeSignal, leaderPosMap map[string
 			if fill.Action == ActionOpen {
 				logger.Infof("📊 [%s] 历史仓位重新开仓 | posId=%s (ignored → active) → 跟随新开仓（Hyperliquid）",
 					e.traderID, posID)
-				newPosition = pos
+				newPosition, newPosID = pos, posID
 				break
 			}
 			// ActionAdd = 对历史仓位加仓，继续跳过
@@ -544,9 +779,12 @@ func (e *Engine) matchOpenAddSignal(signal *TradeSignal, leaderPosMap map[string
 
 	// 优先处理新开仓
 	if newPosition != nil {
-		posID := newPosition.PosID
-		if posID == "" {
-			posID = fmt.Sprintf("%s_%s", fill.Symbol, fill.PositionSide)
+		posID := newPosID
+		if result := e.checkReopenCooldown(fill, posID, newPosition); result != nil {
+			return result
+		}
+		if result := e.checkMaxOpenPositions(fill, posID, newPosition); result != nil {
+			return result
 		}
 		logger.Infof("📊 [%s] 新开仓 | posId=%s mgnMode=%s → 跟随开仓",
 			e.traderID, posID, newPosition.MarginMode)
@@ -565,21 +803,13 @@ func (e *Engine) matchOpenAddSignal(signal *TradeSignal, leaderPosMap map[string
 	// 关键：找 currentSize > lastKnownSize 的仓位，说明这个仓位被加仓了
 	// ============================================================
 	var addPosition *Position
+	var addPosID string
 	var addMapping *store.CopyTradePositionMapping
 	var maxSizeIncrease float64
 
-	for _, pos := range matchedPositions {
-		posID := pos.PosID
-		if posID == "" {
-			posID = fmt.Sprintf("%s_%s", fill.Symbol, fill.PositionSide)
-		}
-
-		mapping, err := e.store.CopyTrade().GetMapping(e.traderID, posID)
-		if err != nil || mapping == nil {
-			continue
-		}
-
-		if mapping.Status != "active" {
+	for _, posID := range posIDs {
+		mapping := mappings[posID]
+		if mapping == nil || mapping.Status != "active" {
 			continue
 		}
 
@@ -601,7 +831,7 @@ func (e *Engine) matchOpenAddSignal(signal *TradeSignal, leaderPosMap map[string
 			// 取 size 增加最多的那个仓位（防止多个仓位同时变化时的误判）
 			if sizeIncrease > maxSizeIncrease {
 				maxSizeIncrease = sizeIncrease
-				addPosition = leaderPos
+				addPosition, addPosID = leaderPos, posID
 				addMapping = mapping
 			}
 		}
@@ -609,10 +839,7 @@ func (e *Engine) matchOpenAddSignal(signal *TradeSignal, leaderPosMap map[string
 
 	// 找到了加仓目标
 	if addPosition != nil && addMapping != nil {
-		posID := addPosition.PosID
-		if posID == "" {
-			posID = fmt.Sprintf("%s_%s", fill.Symbol, fill.PositionSide)
-		}
+		posID := addPosID
 		logger.Infof("📊 [%s] 精确匹配加仓 | posId=%s mgnMode=%s size增加=%.4f → 跟随加仓",
 			e.traderID, posID, addMapping.MarginMode, maxSizeIncrease)
 		return &SignalMatchResult{
@@ -627,32 +854,34 @@ func (e *Engine) matchOpenAddSignal(signal *TradeSignal, leaderPosMap map[string
 
 	// ============================================================
 	// 第三轮：兜底 - 只有一个 active 仓位时，直接加仓
+	// StrictAddMatching 时不兜底：该加仓也可能属于状态尚未同步的新仓位
 	// ============================================================
 	var singleActivePos *Position
+	var singleActivePosID string
 	var singleActiveMapping *store.CopyTradePositionMapping
 	activeCount := 0
 
-	for _, pos := range matchedPositions {
-		posID := pos.PosID
-		if posID == "" {
-			posID = fmt.Sprintf("%s_%s", fill.Symbol, fill.PositionSide)
-		}
-
-		mapping, err := e.store.CopyTrade().GetMapping(e.traderID, posID)
-		if err != nil || mapping == nil || mapping.Status != "active" {
+	for i, pos := range matchedPositions {
+		mapping := mappings[posIDs[i]]
+		if mapping == nil || mapping.Status != "active" {
 			continue
 		}
 
 		activeCount++
-		singleActivePos = pos
+		singleActivePos, singleActivePosID = pos, posIDs[i]
 		singleActiveMapping = mapping
 	}
 
-	if activeCount == 1 && singleActivePos != nil {
-		posID := singleActivePos.PosID
-		if posID == "" {
-			posID = fmt.Sprintf("%s_%s", fill.Symbol, fill.PositionSide)
+	if activeCount == 1 && singleActivePos != nil && e.cfg().StrictAddMatching {
+		logger.Warnf("⚠️ [%s] size 无变化，严格匹配模式下不兜底加仓到唯一 active 仓位，跳过", e.traderID)
+		return &SignalMatchResult{
+			ShouldFollow: false,
+			Reason:       fmt.Sprintf("%s %s 加仓目标不确定（严格匹配，不兜底）", fill.Symbol, fill.PositionSide),
 		}
+	}
+
+	if activeCount == 1 && singleActivePos != nil {
+		posID := singleActivePosID
 		logger.Infof("📊 [%s] 唯一 active 仓位 | posId=%s status=active → 加仓",
 			e.traderID, posID)
 		return &SignalMatchResult{
@@ -682,13 +911,108 @@ func (e *Engine) matchOpenAddSignal(signal *TradeSignal, leaderPosMap map[string
 	}
 }
 
-// matchCloseReduceSignal 匹配减仓/平仓信号（反向查找法 + posId 精确匹配）
-// 核心思想：从本地 active 映射出发，通过 size 变化精确确定是哪个 posId 被操作
+// resolveNetModeFill 修正 OKX 单向持仓模式（posSide=net）成交的方向
+// net 模式下 buy 既可能是开多/加多，也可能是平空/减空（sell 同理）。
+// 如果本地存在反方向的活跃映射，说明这笔成交是在平/减该仓位
+func (e *Engine) resolveNetModeFill(fill *Fill) {
+	if !fill.NetMode || e.store == nil {
+		return
+	}
+
+	opposite := SideShort
+	if fill.Side == "sell" {
+		opposite = SideLong
+	}
+
+	mappings, err := e.findActiveBySymbolSide(fill.Symbol, string(e.followerSide(opposite)))
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 查询活跃映射失败: %v", e.traderID, err)
+		return
+	}
+	if len(mappings) == 0 {
+		return
+	}
+
+	logger.Infof("📊 [%s] 单向持仓模式 | %s %s → 平/减%s仓", e.traderID, fill.Symbol, fill.Side, opposite)
+	fill.PositionSide = opposite
+	fill.Action = ActionClose
+}
+
+// applyClosedPnLHint 方向不确定的成交用 ClosedPnL 作为辅助判断
+// 非零 ClosedPnL 说明是平仓/减仓：单向持仓模式下无本地映射的反向平仓、或未知 dir，
+// 原本会被当作开仓跟随；ClosedPnL 为零时保持开仓/加仓判断（保本平仓无法区分，以映射为准）
+func (e *Engine) applyClosedPnLHint(fill *Fill) {
+	if !e.cfg().ClosedPnLInference || fill.ClosedPnL == 0 {
+		return
+	}
+	if !fill.NetMode && !fill.AmbiguousDir {
+		return
+	}
+	if fill.Action != ActionOpen && fill.Action != ActionAdd {
+		return
+	}
+
+	// 买入平空，卖出平多
+	closeSide := SideLong
+	if fill.Side == "buy" || fill.Side == "B" {
+		closeSide = SideShort
+	}
+
+	logger.Infof("📊 [%s] ClosedPnL=%.4f ≠ 0 | %s %s → 按平/减%s仓处理",
+		e.traderID, fill.ClosedPnL, fill.Symbol, fill.Side, closeSide)
+	fill.PositionSide = closeSide
+	fill.Action = ActionClose
+}
+
+// checkReopenCooldown 领航员平仓后快速重新开仓同币种同方向时，冷却期内不跟随
+// 跳过的仓位标记为 ignored，避免之后的加仓被当作新开仓跟随
+func (e *Engine) checkReopenCooldown(fill *Fill, posID string, pos *Position) *SignalMatchResult {
+	if e.cfg().ReopenCooldownSeconds <= 0 {
+		return nil
+	}
+
+	last, err := e.store.CopyTrade().FindLastClosedBySymbolSideForLeader(e.traderID, e.mappingLeader(), fill.Symbol, string(e.followerSide(fill.PositionSide)))
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 查询最近平仓映射失败: %v", e.traderID, err)
+		return nil
+	}
+	if last == nil || last.ClosedAt == nil {
+		return nil
+	}
+
+	cooldown := time.Duration(e.cfg().ReopenCooldownSeconds) * time.Second
+	sinceClose := time.Since(*last.ClosedAt)
+	if sinceClose >= cooldown {
+		return nil
+	}
+
+	e.ignorePosition(fill, posID, pos.MarginMode, "reopen cooldown")
+
+	logger.Infof("📊 [%s] 平仓后 %s 内重新开仓 | posId=%s 冷却=%s → 不跟随",
+		e.traderID, sinceClose.Round(time.Second), posID, cooldown)
+	return &SignalMatchResult{
+		ShouldFollow: false,
+		Reason:       fmt.Sprintf("平仓后 %s 内重新开仓（冷却 %s），不跟随", sinceClose.Round(time.Second), cooldown),
+	}
+}
+
+// matchCloseReduceSignal 匹配减仓/平仓信号
+// 不跟随平仓时（FollowCloses=false）匹配结果只用于更新映射，不跟随
 func (e *Engine) matchCloseReduceSignal(signal *TradeSignal, leaderPosMap map[string]*Position) *SignalMatchResult {
+	result := e.matchLeaderCloseReduce(signal, leaderPosMap)
+	if e.cfg().followsCloses() || !result.ShouldFollow {
+		return result
+	}
+	return e.skipCloseFollowing(signal.Fill, result)
+}
+
+// matchLeaderCloseReduce 确定领航员减仓/平仓的仓位（反向查找法 + posId 精确匹配）
+// 核心思想：从本地 active 映射出发，通过 size 变化精确确定是哪个 posId 被操作
+func (e *Engine) matchLeaderCloseReduce(signal *TradeSignal, leaderPosMap map[string]*Position) *SignalMatchResult {
 	fill := signal.Fill
 
 	// 1. 查本地所有 active 映射
-	activeMappings, err := e.store.CopyTrade().FindActiveBySymbolSide(e.traderID, fill.Symbol, string(fill.PositionSide))
+	activeMappings, err := e.findActiveBySymbolSide(fill.Symbol, string(e.followerSide(fill.PositionSide)))
 	if err != nil {
 		logger.Errorf("❌ [%s] 查询活跃映射失败: %v", e.traderID, err)
 		return &SignalMatchResult{
@@ -697,6 +1021,9 @@ func (e *Engine) matchCloseReduceSignal(signal *TradeSignal, leaderPosMap map[st
 		}
 	}
 
+	// 成交带保证金模式时只匹配同模式的映射（OKX 全仓/逐仓是独立仓位）
+	activeMappings = filterByMarginMode(activeMappings, fill.MarginMode)
+
 	if len(activeMappings) == 0 {
 		logger.Infof("📊 [%s] 无活跃映射 | %s %s → 不跟随",
 			e.traderID, fill.Symbol, fill.PositionSide)
@@ -706,10 +1033,15 @@ func (e *Engine) matchCloseReduceSignal(signal *TradeSignal, leaderPosMap map[st
 		}
 	}
 
-	// 2. 遍历映射，通过 posId + size 变化精确匹配
-	for _, mapping := range activeMappings {
+	// 2. 遍历映射，通过 posId + size 变化精确匹配（多个候选时按持仓变化与成交数量的吻合度排序）
+	for _, mapping := range e.rankByFillFit(activeMappings, leaderPosMap, fill.Size) {
 		leaderPos := leaderPosMap[mapping.LeaderPosID]
 
+		// 单向持仓模式下同一 posId 可能直接反手，方向变化视为原仓位已平
+		if leaderPos != nil && string(e.followerSide(leaderPos.Side)) != mapping.Side {
+			leaderPos = nil
+		}
+
 		// 场景 1: posId 消失 = 全平（直接通过 posId 匹配）
 		if leaderPos == nil {
 			logger.Infof("📊 [%s] 领航员已平仓 | posId=%s 不在持仓列表 → 全量平仓",
@@ -809,8 +1141,20 @@ func (e *Engine) matchCloseReduceSignal(signal *TradeSignal, leaderPosMap map[st
 // ============================================================================
 
 func (e *Engine) processSignal(signal *TradeSignal) {
+	defer e.profileLabels()()
+	// 整个信号持配置读锁：热更新等待信号处理完成，信号内各环节（含辅助函数的 e.cfg()）读到同一份配置快照
+	e.cfgMu.RLock()
+	defer e.cfgMu.RUnlock()
+	cfg := e.cfg()
 	fill := signal.Fill
 
+	// 降级模式：领航员状态未知，恢复时会把当时的持仓全部标记为 ignored
+	if e.IsDegraded() {
+		logger.Infof("🎯 [%s] ❌ 跳过 | %s | 原因: 降级模式，领航员状态不可用", e.traderID, fill.Symbol)
+		e.stats.SignalsSkipped++
+		return
+	}
+
 	// ========================================
 	// Step 1: 统一数据准备（只拉取一次）
 	// ========================================
@@ -818,41 +1162,149 @@ func (e *Engine) processSignal(signal *TradeSignal) {
 		logger.Warnf("⚠️ [%s] 领航员状态同步失败: %v", e.traderID, err)
 	}
 
+	// 单向持仓模式：修正成交方向（开仓 vs 平反向仓）
+	e.resolveNetModeFill(fill)
+
+	// 方向仍不确定时，用 ClosedPnL 辅助判断
+	e.applyClosedPnLHint(fill)
+
+	// 平仓/减仓遇到可疑的空持仓状态：重新拉取一次，避免误判全部已平
+	e.verifyEmptyLeaderState(fill)
+
 	// 重新构建 signal 以获取最新的 LeaderEquity
 	signal = e.buildSignal(fill)
+	e.recordPrice(fill.Symbol, fill.Price, fill.Timestamp)
+	e.recordLeaderPnL(fill)
+
+	// 延迟跟单：领航员在延迟期内平仓/减仓，撤销或缩减待跟随的开仓
+	e.settleDelayedOpens(fill)
+
+	// 领航员挂单成交：已按挂单提前建仓，不重复跟随
+	if e.mirroredEntryFill(fill) {
+		logger.Infof("🎯 [%s] ❌ 跳过 | %s | 原因: 已按领航员挂单提前建仓", e.traderID, fill.Symbol)
+		e.stats.SignalsSkipped++
+		return
+	}
 
 	// ========================================
 	// Step 2: 统一信号匹配（核心判断）
 	// ========================================
 	matchResult := e.matchSignalWithMapping(signal)
+	e.recordLeaderClosedPnL(fill, matchResult)
 
 	if !matchResult.ShouldFollow {
 		logger.Infof("🎯 [%s] ❌ 跳过 | %s | 原因: %s", e.traderID, fill.Symbol, matchResult.Reason)
 		e.stats.SignalsSkipped++
 		return
 	}
+
+	// 引擎已暂停：状态和去重照常更新，不生成任何决策
+	if e.IsPaused() {
+		e.skipSignal(fill, matchResult, SkipReasonEnginePaused)
+		return
+	}
+
+	// 开仓/加仓过滤条件（只平仓、维护、币种开关/名单、交易时段等），减仓/平仓始终跟随
+	if matchResult.Action == ActionOpen || matchResult.Action == ActionAdd {
+		if reason := e.entrySkipReason(fill); reason != "" {
+			e.skipSignal(fill, matchResult, reason)
+			return
+		}
+	}
+
+	// 跟随者权益为负（穿仓/大幅亏损）：默认仍跟随减仓/平仓以降低风险，pause_all 时全部暂停
+	if (matchResult.Action == ActionReduce || matchResult.Action == ActionClose) &&
+		cfg.NegativeEquityPolicy == NegativeEquityPauseAll {
+		if equity := e.getFollowerBalance(); equity <= 0 {
+			e.skipSignal(fill, matchResult, fmt.Sprintf("follower equity %.2f <= 0 (negative_equity_policy=%s)", equity, NegativeEquityPauseAll))
+			return
+		}
+	}
+
+	// 加仓前确认领航员仍持仓（防止乱序到达的延迟加仓成交在领航员平仓后被跟随）
+	if ok, reason := e.confirmLeaderHolds(matchResult); !ok {
+		e.skipSignal(fill, matchResult, reason)
+		return
+	}
+
+	// 跟随者持仓已达到按比例的目标仓位：跳过加仓，避免偏差越加越大
+	if above, reason := e.aboveTargetAllocation(signal, matchResult); above {
+		e.skipSignal(fill, matchResult, reason)
+		return
+	}
+
+	// 滑点保护：当前价格相对领航员成交价偏离过大时不开仓/加仓
+	if exceeded, reason := e.slippageExceeded(fill, matchResult); exceeded {
+		e.skipSlippage(fill, matchResult, reason)
+		return
+	}
 	logger.Infof("🎯 [%s] ✅ 跟随 | %s | 原因: %s", e.traderID, fill.Symbol, matchResult.Reason)
-	e.stats.SignalsFollowed++
 
 	// 回填匹配结果到 signal（供后续逻辑使用）
 	signal.LeaderPosID = matchResult.PosID
 	signal.LeaderPosition = matchResult.LeaderPosition
 
+	// 开仓前同步刷新领航员权益（开仓是最关键的定仓时刻；加仓/减仓/平仓使用缓存）
+	if matchResult.Action == ActionOpen && cfg.FreshEquityOnOpen {
+		e.refreshLeaderEquity(signal)
+	}
+
 	// ========================================
 	// Step 3: 计算跟单仓位（基于持仓变化量）
 	// ========================================
-	copySize, warnings := e.calculateCopySizeByPositionChange(signal, matchResult)
+	// 减仓/平仓按比例执行，不计算金额（也不受跟随者权益 <= 0 影响）
+	var copySize float64
+	var warnings []Warning
+	if matchResult.Action == ActionOpen || matchResult.Action == ActionAdd {
+		copySize, warnings = e.calculateCopySizeByPositionChange(signal, matchResult)
+	}
 
 	// 记录所有预警（不阻止交易）
 	for _, w := range warnings {
 		e.logWarning(w)
 	}
 
+	// 单币种最大持仓（基础币数量）：超过交易所持仓上限会被拒单
+	if matchResult.Action == ActionOpen || matchResult.Action == ActionAdd {
+		copySize = e.capSymbolBaseSize(signal, matchResult, copySize)
+		copySize = e.capByFreeMargin(signal, copySize)
+	}
+
+	// 开仓/加仓金额为 0（如余额为零、领航员权益异常）时不生成决策
+	if (matchResult.Action == ActionOpen || matchResult.Action == ActionAdd) && copySize <= 0 {
+		logger.Infof("🎯 [%s] ❌ 跳过 | %s | 原因: 跟单金额为 0", e.traderID, fill.Symbol)
+		e.stats.SignalsSkipped++
+		return
+	}
+
+	// 领航员资金预算
+	if (matchResult.Action == ActionOpen || matchResult.Action == ActionAdd) && !e.withinLeaderBudget(copySize) {
+		logger.Infof("🎯 [%s] ❌ 跳过 | %s | 原因: leader budget exhausted（预算 %.2f）",
+			e.traderID, fill.Symbol, cfg.LeaderBudget)
+		e.stats.SignalsSkipped++
+		return
+	}
+
 	// ========================================
 	// Step 4: 构造 Decision
 	// ========================================
 	dec := e.buildDecisionV2(signal, matchResult, copySize)
 
+	// 重复决策保护（fill-id 去重之外的兜底：重启/映射修复可能重复生成同一决策）
+	if e.isDuplicateDecision(&dec) {
+		logger.Infof("🎯 [%s] ❌ 跳过 | %s | 原因: duplicate decision suppressed（%ds 内相同决策）",
+			e.traderID, fill.Symbol, cfg.DuplicateDecisionWindowSec)
+		e.stats.SignalsSkipped++
+		return
+	}
+	e.stats.SignalsFollowed++
+	if matchResult.Action == ActionOpen || matchResult.Action == ActionAdd {
+		e.stats.TotalCopiedNotional += copySize
+	}
+
+	// 影子跟单：同一信号按影子参数记录假设结果
+	e.recordShadow(signal, matchResult, &dec, copySize)
+
 	// ========================================
 	// Step 5: 推送决策
 	// ========================================
@@ -860,41 +1312,292 @@ func (e *Engine) processSignal(signal *TradeSignal) {
 		SystemPrompt:        e.buildSystemPromptLog(),
 		UserPrompt:          e.buildUserPromptLog(signal),
 		CoTTrace:            e.buildCoTTrace(signal, matchResult.Action, copySize, warnings),
-		Decisions:           []decision.Decision{dec},
-		RawResponse:         fmt.Sprintf("Copy trade signal from %s:%s", e.config.ProviderType, e.config.LeaderID),
+		Decisions:           e.withProtectiveStop(matchResult, dec),
+		RawResponse:         fmt.Sprintf("Copy trade signal from %s:%s", cfg.ProviderType, cfg.LeaderID),
 		Timestamp:           time.Now(),
 		AIRequestDurationMs: 0,
 	}
 
-	select {
-	case e.decisionCh <- fullDec:
-		e.stats.DecisionsGenerated++
+	// 延迟跟单：新开仓延迟推送，延迟期内领航员平仓则撤销
+	if matchResult.Action == ActionOpen && cfg.CopyDelay > 0 && !e.simulation {
+		e.delayOpen(matchResult, fill, fullDec)
+		return
+	}
+
+	if e.pushDecision(fullDec) {
 		logger.Infof("⚡ [%s] 决策生成 | %s %s | 金额=%.2f",
 			e.traderID, dec.Action, dec.Symbol, copySize)
-	default:
-		logger.Warnf("⚠️ [%s] 决策通道已满，丢弃", e.traderID)
+
+		if matchResult.Action == ActionOpen {
+			e.recordTrialOpen(fill)
+		}
 	}
 }
 
-// buildDecisionV2 构建决策（使用统一匹配结果）
-func (e *Engine) buildDecisionV2(signal *TradeSignal, match *SignalMatchResult, copySize float64) decision.Decision {
-	fill := signal.Fill
+// isDuplicateDecision 检查窗口内是否已生成过完全相同的决策（按 symbol+action+posId 缓存）
+func (e *Engine) isDuplicateDecision(dec *decision.Decision) bool {
+	if e.cfg().DuplicateDecisionWindowSec <= 0 {
+		return false
+	}
+	window := time.Duration(e.cfg().DuplicateDecisionWindowSec) * time.Second
 
-	// 获取领航员当前持仓数量（用于 lastKnownSize 追踪）
-	leaderPosSize := float64(0)
-	if match.LeaderPosition != nil {
-		leaderPosSize = match.LeaderPosition.Size
+	fingerprint, err := json.Marshal(dec)
+	if err != nil {
+		return false
 	}
+	key := dec.Symbol + "|" + dec.Action + "|" + dec.LeaderPosID
+	now := time.Now()
 
-	dec := decision.Decision{
-		Symbol:        fill.Symbol,
+	e.recentDecMu.Lock()
+	defer e.recentDecMu.Unlock()
+
+	if e.recentDecisions == nil {
+		e.recentDecisions = make(map[string]recentDecision)
+	}
+	// 清理过期记录
+	for k, r := range e.recentDecisions {
+		if now.Sub(r.at) >= window {
+			delete(e.recentDecisions, k)
+		}
+	}
+
+	if last, ok := e.recentDecisions[key]; ok && bytes.Equal(last.fingerprint, fingerprint) {
+		return true
+	}
+	e.recentDecisions[key] = recentDecision{fingerprint: fingerprint, at: now}
+	return false
+}
+
+// withinLeaderBudget 检查本次开仓/加仓后是否仍在领航员资金预算内
+func (e *Engine) withinLeaderBudget(copySize float64) bool {
+	if e.cfg().LeaderBudget <= 0 || e.store == nil {
+		return true
+	}
+
+	used, err := e.store.CopyTrade().SumActiveOpenSize(e.traderID, e.cfg().LeaderID)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 查询领航员已用预算失败: %v", e.traderID, err)
+		return true
+	}
+
+	if used+copySize > e.cfg().LeaderBudget {
+		logger.Infof("💰 [%s] 领航员预算不足 | 已用=%.2f 本次=%.2f 预算=%.2f",
+			e.traderID, used, copySize, e.cfg().LeaderBudget)
+		return false
+	}
+	return true
+}
+
+// capSymbolBaseSize 按 SymbolMaxBaseSize 限制开仓/加仓数量（含跟随者现有持仓），超出时缩小金额并预警
+func (e *Engine) capSymbolBaseSize(signal *TradeSignal, match *SignalMatchResult, copySize float64) float64 {
+	fill := signal.Fill
+	maxBase, ok := e.cfg().SymbolMaxBaseSize[fill.Symbol]
+	if !ok || maxBase <= 0 || copySize <= 0 || fill.Price <= 0 {
+		return copySize
+	}
+
+	held := float64(0)
+	if e.getFollowerPositions != nil {
+		held = e.followerPositionSize(fill.Symbol, fill.PositionSide, match.MarginMode)
+	}
+	qty := copySize / fill.Price
+	if held+qty <= maxBase {
+		return copySize
+	}
+
+	allowed := math.Max(0, maxBase-held)
+	capped := allowed * fill.Price
+	e.logWarning(Warning{
+		Timestamp:    time.Now(),
+		Symbol:       fill.Symbol,
+		Type:         "symbol_size_capped",
+		Message:      fmt.Sprintf("%s 持仓上限 %.6f：现有 %.6f + 本次 %.6f 超限，本次数量限制为 %.6f", fill.Symbol, maxBase, held, qty, allowed),
+		SignalAction: string(match.Action),
+		SignalValue:  fill.Value,
+		CopyValue:    capped,
+		Executed:     capped > 0,
+	})
+	return capped
+}
+
+// ============================================================================
+// 试用模式
+// ============================================================================
+
+// loadTrialProgress 从数据库恢复试用进度（重启后继续计数）
+func (e *Engine) loadTrialProgress() {
+	if e.cfg().TrialTradeLimit <= 0 || e.store == nil {
+		return
+	}
+
+	count, err := e.store.CopyTrade().CountFollowedOpens(e.traderID, e.cfg().LeaderID)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 查询试用进度失败: %v", e.traderID, err)
+		return
+	}
+
+	e.stats.TrialOpens = count
+	e.stats.CloseOnly = count >= e.cfg().TrialTradeLimit
+	logger.Infof("🧪 [%s] 试用模式 | 已跟随 %d/%d 笔开仓 | 只平仓=%v",
+		e.traderID, count, e.cfg().TrialTradeLimit, e.stats.CloseOnly)
+}
+
+// recordTrialOpen 记录一笔已跟随的开仓，达到试用上限后进入只平仓模式
+func (e *Engine) recordTrialOpen(fill *Fill) {
+	if e.cfg().TrialTradeLimit <= 0 {
+		return
+	}
+
+	e.stats.TrialOpens++
+	if e.stats.TrialOpens < e.cfg().TrialTradeLimit || e.stats.CloseOnly {
+		return
+	}
+
+	e.stats.CloseOnly = true
+	e.logWarning(Warning{
+		Timestamp:    time.Now(),
+		Symbol:       fill.Symbol,
+		Type:         "trial_completed",
+		Message:      fmt.Sprintf("试用额度已用完（%d 笔开仓），已进入只平仓模式，请评估领航员表现后调整 trial_trade_limit 继续跟单", e.cfg().TrialTradeLimit),
+		SignalAction: string(ActionOpen),
+		SignalValue:  fill.Value,
+		Executed:     true,
+	})
+}
+
+// pushDecision 推送决策到输出通道（非阻塞，通道满时丢弃）
+func (e *Engine) pushDecision(fullDec *decision.FullDecision) bool {
+	// 标记决策来源领航员（多领航员跟单时执行器按来源引擎更新映射）
+	for i := range fullDec.Decisions {
+		if fullDec.Decisions[i].LeaderID == "" {
+			fullDec.Decisions[i].LeaderID = e.cfg().LeaderID
+		}
+	}
+
+	select {
+	case e.decisionCh <- fullDec:
+		e.stats.DecisionsGenerated++
+		e.onDecisionPushed()
+		return true
+	default:
+		logger.Warnf("⚠️ [%s] 决策通道已满，丢弃", e.traderID)
+		e.onDecisionDropped()
+		return false
+	}
+}
+
+// ============================================================================
+// 最大持仓时间
+// ============================================================================
+
+// holdTimeLoop 定时检查跟单仓位持仓时间
+func (e *Engine) holdTimeLoop(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	// 已发出平仓决策的 posId（等待执行，避免重复推送）
+	pending := make(map[string]time.Time)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.stopCh:
+			return
+		case <-ticker.C:
+			e.checkMaxHoldTime(pending)
+		}
+	}
+}
+
+// checkMaxHoldTime 扫描 active 映射，超过最大持仓时间的仓位主动平仓
+func (e *Engine) checkMaxHoldTime(pending map[string]time.Time) {
+	e.cfgMu.RLock()
+	defer e.cfgMu.RUnlock()
+
+	if e.store == nil || e.cfg().MaxHoldHours <= 0 {
+		return
+	}
+
+	mappings, err := e.listActiveMappings()
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 查询活跃映射失败: %v", e.traderID, err)
+		return
+	}
+
+	maxHold := time.Duration(e.cfg().MaxHoldHours) * time.Hour
+	leaderPosMap := e.buildLeaderPosMap()
+
+	for _, m := range mappings {
+		if m.OpenedAt.IsZero() || time.Since(m.OpenedAt) < maxHold {
+			continue
+		}
+		// 已推送过且未超过 5 分钟，等待执行结果
+		if sentAt, ok := pending[m.LeaderPosID]; ok && time.Since(sentAt) < 5*time.Minute {
+			continue
+		}
+
+		action := "close_long"
+		if m.Side == string(SideShort) {
+			action = "close_short"
+		}
+
+		// 平仓价格参考领航员当前标记价（如有）
+		closePrice := 0.0
+		if pos := leaderPosMap[m.LeaderPosID]; pos != nil {
+			closePrice = pos.MarkPrice
+		}
+
+		reason := fmt.Sprintf("max hold time reached (%dh)", e.cfg().MaxHoldHours)
+		dec := decision.Decision{
+			Symbol:      m.Symbol,
+			Action:      action,
+			Reasoning:   fmt.Sprintf("Copy trading: close, %s, overriding %s leader %s", reason, e.cfg().ProviderType, e.cfg().LeaderID),
+			EntryPrice:  closePrice,
+			LeaderPosID: m.LeaderPosID,
+			MarginMode:  m.MarginMode,
+			CloseReason: CloseReasonMaxHold,
+		}
+
+		fullDec := &decision.FullDecision{
+			SystemPrompt: e.buildSystemPromptLog(),
+			UserPrompt:   fmt.Sprintf("## Max Hold Time\n\nposId: %s\nOpened At: %s\nMax Hold: %dh\n", m.LeaderPosID, m.OpenedAt.Format("2006-01-02 15:04:05"), e.cfg().MaxHoldHours),
+			CoTTrace:     fmt.Sprintf("# Copy Trading Decision\n\nPosition %s %s held for %s, %s. Closing regardless of leader.\n", m.Symbol, m.Side, time.Since(m.OpenedAt).Round(time.Minute), reason),
+			Decisions:    []decision.Decision{dec},
+			RawResponse:  fmt.Sprintf("Copy trade max hold close for %s:%s", e.cfg().ProviderType, e.cfg().LeaderID),
+			Timestamp:    time.Now(),
+		}
+
+		if e.pushDecision(fullDec) {
+			pending[m.LeaderPosID] = time.Now()
+			logger.Infof("⏰ [%s] 超过最大持仓时间 | posId=%s %s %s 持仓=%s → 主动平仓",
+				e.traderID, m.LeaderPosID, m.Symbol, m.Side, time.Since(m.OpenedAt).Round(time.Minute))
+		}
+	}
+}
+
+// buildDecisionV2 构建决策（使用统一匹配结果）
+func (e *Engine) buildDecisionV2(signal *TradeSignal, match *SignalMatchResult, copySize float64) decision.Decision {
+	fill := signal.Fill
+
+	// 获取领航员当前持仓数量（用于 lastKnownSize 追踪）
+	leaderPosSize := float64(0)
+	if match.LeaderPosition != nil {
+		leaderPosSize = match.LeaderPosition.Size
+	}
+
+	dec := decision.Decision{
+		Symbol:        fill.Symbol,
 		Action:        e.mapAction(match.Action, fill.PositionSide),
-		Reasoning:     fmt.Sprintf("Copy trading: %s following %s leader %s", match.Action, e.config.ProviderType, e.config.LeaderID),
+		Reasoning:     fmt.Sprintf("Copy trading: %s following %s leader %s", match.Action, e.cfg().ProviderType, e.cfg().LeaderID),
 		EntryPrice:    fill.Price,
 		LeaderPosID:   match.PosID,
 		LeaderPosSize: leaderPosSize,    // 传递领航员当前持仓数量
 		MarginMode:    match.MarginMode, // 直接使用匹配结果中的 marginMode
 	}
+	if match.Action == ActionReduce || match.Action == ActionClose {
+		dec.CloseTrigger = fill.CloseTrigger
+	}
 
 	// ============================================================
 	// 开仓/加仓：设置仓位大小和杠杆
@@ -903,6 +1606,21 @@ func (e *Engine) buildDecisionV2(signal *TradeSignal, match *SignalMatchResult,
 		dec.PositionSizeUSD = copySize
 		dec.Leverage = e.getLeaderLeverage(signal)
 		dec.Confidence = 90
+
+		// 限价入场：以领航员成交价挂单（执行器负责超时撤单/转市价）
+		if e.cfg().EntryOrderType == EntryOrderLimit && fill.Price > 0 {
+			dec.OrderType = decision.OrderTypeLimit
+			dec.LimitPrice = fill.Price
+			dec.LimitTimeoutSec = e.cfg().LimitTimeoutSeconds
+			dec.LimitTimeoutAction = e.cfg().LimitTimeoutAction
+			if dec.LimitTimeoutAction == "" {
+				dec.LimitTimeoutAction = LimitTimeoutCancel
+			}
+		}
+		// 同步领航员止盈止损（仅新开仓）
+		if match.Action == ActionOpen && e.cfg().SyncTPSL {
+			dec.TakeProfit, dec.StopLoss = e.followerTPSL(signal, match, fill.Price)
+		}
 		logger.Infof("📊 [%s] %s | 金额=%.2f 杠杆=%dx 模式=%s 入场价=%.4f",
 			e.traderID, match.Action, copySize, dec.Leverage, dec.MarginMode, fill.Price)
 	}
@@ -911,18 +1629,46 @@ func (e *Engine) buildDecisionV2(signal *TradeSignal, match *SignalMatchResult,
 	// 减仓：计算比例
 	// ============================================================
 	if match.Action == ActionReduce {
-		ratio := e.calculateReduceRatioV2(signal, match)
+		leaderRatio := e.calculateReduceRatioV2(signal, match)
+		ratio := leaderRatio
+		if e.cfg().ReduceMode == ReduceModeScaledAbsolute {
+			if absRatio, ok := e.calculateScaledAbsoluteReduceRatio(signal, match); ok {
+				ratio = absRatio
+			}
+		}
+		ratio, full := clampReduceRatio(ratio)
+		if full {
+			dec.Action = e.mapAction(ActionClose, fill.PositionSide)
+			dec.CloseRatio = 0
+			dec.Reasoning = fmt.Sprintf("Copy trading: close (reduce reaches follower position) following %s leader %s",
+				e.cfg().ProviderType, e.cfg().LeaderID)
+			return dec
+		}
+
+		// 分批止盈：本轮累计减仓接近全部，或剩余仓位已是无法再减的零头时，直接平掉剩余仓位
+		if reason, ok := e.scaleOutSnap(signal, match, leaderRatio, ratio); ok {
+			dec.Action = e.mapAction(ActionClose, fill.PositionSide)
+			dec.CloseRatio = 0
+			dec.CloseReason = CloseReasonScaleOut
+			dec.Reasoning = fmt.Sprintf("Copy trading: close remainder (%s) following %s leader %s",
+				reason, e.cfg().ProviderType, e.cfg().LeaderID)
+			logger.Infof("📊 [%s] 分批减仓收尾 | %s → 全量平仓 marginMode=%s", e.traderID, reason, dec.MarginMode)
+			return dec
+		}
+
+		// 减仓数量低于交易所最小下单量时提高或转为全平
+		ratio = e.applyReduceMinimum(signal, match, ratio)
 
 		// 边界保护：减仓超过 95% 时，直接全量平仓
 		if ratio >= 0.95 {
 			logger.Infof("📊 [%s] 减仓比例 %.1f%% ≥ 95%%，转为全量平仓", e.traderID, ratio*100)
 			dec.CloseRatio = 0
 			dec.Reasoning = fmt.Sprintf("Copy trading: close (reduce %.0f%% → full close) following %s leader %s",
-				ratio*100, e.config.ProviderType, e.config.LeaderID)
+				ratio*100, e.cfg().ProviderType, e.cfg().LeaderID)
 		} else {
 			dec.CloseRatio = ratio
 			dec.Reasoning = fmt.Sprintf("Copy trading: reduce %.0f%% following %s leader %s",
-				ratio*100, e.config.ProviderType, e.config.LeaderID)
+				ratio*100, e.cfg().ProviderType, e.cfg().LeaderID)
 			logger.Infof("📊 [%s] 部分平仓 %.1f%% marginMode=%s", e.traderID, ratio*100, dec.MarginMode)
 		}
 	}
@@ -964,6 +1710,138 @@ func (e *Engine) calculateReduceRatioV2(signal *TradeSignal, match *SignalMatchR
 	return ratio
 }
 
+// 分批止盈收尾阈值
+const (
+	scaleOutSnapRemaining = 0.05 // 本轮累计减仓后领航员剩余 ≤ 5% 时平掉剩余仓位
+	scaleOutDustNotional  = 5.0  // 减仓后跟随者剩余名义价值低于此值（USDT，约为交易所最小下单额）时平掉剩余仓位
+)
+
+// scaleOutReducedFraction 本次减仓后该仓位的累计减仓比例（相对本轮起点：开仓或最近一次加仓时的领航员持仓）
+// 优先用 lastKnownSize → 当前持仓计算本次减仓比例，缺失时使用 stepRatio
+func scaleOutReducedFraction(m *store.CopyTradePositionMapping, leaderCurrentSize, stepRatio float64) float64 {
+	remaining := 1.0
+	if m != nil {
+		remaining = 1 - m.ReducedFraction
+		if m.LastKnownSize > 0 && leaderCurrentSize >= 0 && leaderCurrentSize <= m.LastKnownSize {
+			stepRatio = 1 - leaderCurrentSize/m.LastKnownSize
+		}
+	}
+	remaining *= 1 - math.Max(0, math.Min(1, stepRatio))
+	return 1 - math.Max(0, math.Min(1, remaining))
+}
+
+// scaleOutSnap 判断本次减仓是否应转为平掉剩余仓位（分批止盈的最后一笔）
+// 领航员逐笔减仓时各笔独立按比例执行，跟随者仓位较小时取整会留下零头：
+//   - 累计减仓后领航员剩余 ≤ scaleOutSnapRemaining
+//   - 或减仓后跟随者剩余名义价值 < scaleOutDustNotional
+//
+// 之前的减仓保持按比例执行
+func (e *Engine) scaleOutSnap(signal *TradeSignal, match *SignalMatchResult, leaderRatio, ratio float64) (string, bool) {
+	if e.store != nil {
+		leaderCurrentSize := float64(0)
+		if match.LeaderPosition != nil {
+			leaderCurrentSize = match.LeaderPosition.Size
+		}
+		mapping, err := e.store.CopyTrade().GetActiveMapping(e.traderID, match.PosID)
+		if err != nil {
+			logger.Warnf("⚠️ [%s] 查询映射失败: %v (posId=%s)", e.traderID, err, match.PosID)
+		}
+		reduced := scaleOutReducedFraction(mapping, leaderCurrentSize, leaderRatio)
+		if 1-reduced <= scaleOutSnapRemaining+1e-9 {
+			return fmt.Sprintf("累计减仓 %.1f%%", reduced*100), true
+		}
+	}
+
+	if e.getFollowerPositions != nil && signal.Fill.Price > 0 {
+		followerSize := e.followerPositionSize(signal.Fill.Symbol, signal.Fill.PositionSide, match.MarginMode)
+		if followerSize > 0 {
+			remainValue := followerSize * (1 - ratio) * signal.Fill.Price
+			if remainValue < scaleOutDustNotional {
+				return fmt.Sprintf("剩余 %.2f USDT 低于 %.0f USDT", remainValue, scaleOutDustNotional), true
+			}
+		}
+	}
+	return "", false
+}
+
+// clampReduceRatio 限制减仓比例在 [0, 1]，返回 full=true 表示减仓达到全部持仓（调用方直接全量平仓）
+// 比例作用于执行时跟随者的实际持仓，因此比例不超过 1 即保证减仓数量不超过持仓；
+// 按绝对数量计算的比例已在 calculateScaledAbsoluteReduceRatio 中按实时持仓封顶
+func clampReduceRatio(ratio float64) (float64, bool) {
+	if math.IsNaN(ratio) || ratio > 1 {
+		ratio = 1
+	} else if ratio < 0 {
+		ratio = 0
+	}
+	return ratio, ratio >= 1
+}
+
+// calculateScaledAbsoluteReduceRatio 按领航员减仓的绝对数量计算减仓比例（ReduceMode=scaled_absolute）
+// 跟随者减仓数量 = 领航员减仓数量 × 跟单系数（与开仓相同：copyRatio × 跟随者权益 / 领航员权益），
+// 再换算为跟随者实际持仓的比例。适合按固定数量而非百分比减仓的领航员
+func (e *Engine) calculateScaledAbsoluteReduceRatio(signal *TradeSignal, match *SignalMatchResult) (float64, bool) {
+	if e.getFollowerPositions == nil || e.getFollowerBalance == nil {
+		return 0, false
+	}
+
+	followerSize := e.followerPositionSize(signal.Fill.Symbol, signal.Fill.PositionSide, match.MarginMode)
+	followerEquity := e.getFollowerBalance()
+
+	leaderEquity := signal.LeaderEquity
+	if leaderEquity <= 0 && e.cfg().ZeroEquityPolicy == ZeroEquityOverride {
+		leaderEquity = e.cfg().LeaderEquityOverride
+	}
+
+	if followerSize <= 0 || followerEquity <= 0 || leaderEquity <= 0 {
+		logger.Warnf("⚠️ [%s] 绝对数量减仓无法计算（持仓=%.4f 跟随者权益=%.2f 领航员权益=%.2f），回退按比例减仓",
+			e.traderID, followerSize, followerEquity, leaderEquity)
+		return 0, false
+	}
+
+	factor := e.cfg().CopyRatio * followerEquity / leaderEquity
+
+	// 接管仓位：按实际持仓对账（实际/应有），应有持仓未知时回退按比例减仓
+	if e.store != nil {
+		if m, err := e.store.CopyTrade().GetActiveMapping(e.traderID, match.PosID); err == nil && m != nil && m.AdoptedSize > 0 {
+			if m.ExpectedSize <= 0 {
+				logger.Infof("📊 [%s] %s 接管仓位缺少应有持仓，回退按比例减仓", e.traderID, signal.Fill.Symbol)
+				return 0, false
+			}
+			factor *= adoptedSizeFactor(m)
+			logger.Infof("📊 [%s] %s 接管仓位对账 | 应有=%.6f 实际=%.6f", e.traderID, signal.Fill.Symbol, m.ExpectedSize, m.AdoptedSize)
+		}
+	}
+	reduceQty := signal.Fill.Size * factor
+	if reduceQty >= followerSize {
+		// 此前开仓被缩小或跳过，按比例推算的数量超过实际持仓：封顶为实时持仓（全量平仓）
+		logger.Infof("📊 [%s] %s 绝对数量减仓 %.4f ≥ 跟随者持仓 %.4f，按持仓封顶",
+			e.traderID, signal.Fill.Symbol, reduceQty, followerSize)
+		reduceQty = followerSize
+	}
+	ratio := reduceQty / followerSize
+
+	logger.Infof("📊 [%s] %s 绝对数量减仓 | 领航员减仓=%.4f × 系数=%.6f = %.4f | 跟随者持仓=%.4f → %.1f%%",
+		e.traderID, signal.Fill.Symbol, signal.Fill.Size, factor, reduceQty, followerSize, ratio*100)
+	return ratio, true
+}
+
+// followerPositionSize 查询跟随者实际持仓数量（实时，同 symbol+side，指定保证金模式时只统计该模式）
+// side 为领航员方向，反向跟单时统计反方向持仓
+func (e *Engine) followerPositionSize(symbol string, side SideType, marginMode string) float64 {
+	side = e.followerSide(side)
+	size := 0.0
+	for _, pos := range e.getFollowerPositions() {
+		if pos.Symbol != symbol || pos.Side != side {
+			continue
+		}
+		if marginMode != "" && pos.MarginMode != "" && pos.MarginMode != marginMode {
+			continue
+		}
+		size += pos.Size
+	}
+	return size
+}
+
 // ============================================================================
 // 比例计算
 // ============================================================================
@@ -973,18 +1851,34 @@ func (e *Engine) calculateReduceRatioV2(signal *TradeSignal, match *SignalMatchR
 // 解决问题：Hyperliquid 大订单被拆成多个 fills，用 fill.Value 只能捕获第一个 fill 的价值
 // 改进后：不管拆成多少个 fills，只要最终持仓变化正确，跟单金额就准确
 func (e *Engine) calculateCopySizeByPositionChange(signal *TradeSignal, match *SignalMatchResult) (float64, []Warning) {
+	defer e.traceSpan("calculateCopySize")()
+	if size, ok := e.fixedTradeSize(match); ok {
+		return e.applyCopySizeBounds(signal.Fill, size, signal.Fill.Value, e.cfg().MinTradeWarn)
+	}
+	if size, leaderValue, ok := e.increaseRatioAddSize(signal, match); ok {
+		return e.applyCopySizeBounds(signal.Fill, size, leaderValue, e.cfg().MinTradeWarn)
+	}
+	return e.calculateCopySizeWith(signal, match, e.effectiveCopyRatio(signal), e.cfg().MinTradeWarn)
+}
+
+// calculateCopySizeWith 按指定跟单系数与最小金额计算跟单仓位（影子跟单复用）
+func (e *Engine) calculateCopySizeWith(signal *TradeSignal, match *SignalMatchResult, copyRatio, minTradeWarn float64) (float64, []Warning) {
 	var warnings []Warning
 	fill := signal.Fill
 
-	// 领航员的账户权益
-	leaderEquity := signal.LeaderEquity
-	if leaderEquity <= 0 {
-		leaderEquity = 1 // 防止除零
-	}
-
 	// 跟随者账户权益
 	followerEquity := e.getFollowerBalance()
-	if followerEquity <= 0 {
+	if followerEquity < 0 {
+		warnings = append(warnings, Warning{
+			Timestamp: time.Now(),
+			Symbol:    fill.Symbol,
+			Type:      "negative_balance",
+			Message:   fmt.Sprintf("跟随者权益为负 (%.2f)，暂停开仓/加仓，减仓/平仓照常跟随", followerEquity),
+			Executed:  false,
+		})
+		return 0, warnings
+	}
+	if followerEquity == 0 {
 		warnings = append(warnings, Warning{
 			Timestamp: time.Now(),
 			Symbol:    fill.Symbol,
@@ -1002,7 +1896,7 @@ func (e *Engine) calculateCopySizeByPositionChange(signal *TradeSignal, match *S
 
 	// 🔑 OKX: 直接使用 fill.Value（API 返回完整订单价值，不存在拆分问题）
 	// 🔑 Hyperliquid: 使用持仓变化量计算（解决大订单拆分导致金额偏小的问题）
-	if e.config.ProviderType == ProviderOKX {
+	if caps, _ := GetProviderCapabilities(e.cfg().ProviderType); caps.CompleteFillValue {
 		// OKX: 保持原逻辑，直接使用 fill.Value
 		leaderTradeValue = fill.Value
 		logger.Infof("📊 [%s] OKX计算 | 使用 fill.Value=%.2f", e.traderID, fill.Value)
@@ -1050,21 +1944,49 @@ func (e *Engine) calculateCopySizeByPositionChange(signal *TradeSignal, match *S
 		leaderTradeValue = fill.Value
 	}
 
-	// 领航员该笔交易占其账户的比例
-	leaderTradeRatio := leaderTradeValue / leaderEquity
+	var copySize float64
+
+	// 领航员的账户权益
+	leaderEquity := signal.LeaderEquity
+	if leaderEquity <= 0 {
+		// 🔑 权益异常时绝不按 equity=1 计算（会让占比被放大成天文数字）
+		fallbackSize, fallbackEquity, w := e.zeroEquityFallback(fill)
+		warnings = append(warnings, w)
+		switch {
+		case fallbackSize > 0:
+			copySize = fallbackSize
+		case fallbackEquity > 0:
+			leaderEquity = fallbackEquity
+		default:
+			return 0, warnings
+		}
+	}
+
+	if leaderEquity > 0 {
+		// 领航员该笔交易占其账户的比例
+		leaderTradeRatio := leaderTradeValue / leaderEquity
 
-	// 计算跟单金额
-	copySize := e.config.CopyRatio * leaderTradeRatio * followerEquity
+		// 计算跟单金额
+		copySize = copyRatio * leaderTradeRatio * followerEquity
 
-	logger.Infof("📊 [%s] 比例计算 | %s | 领航员: 交易=%.2f 权益=%.2f 占比=%.2f%% | 跟随者: 权益=%.2f 系数=%.0f%% → 跟单=%.2f",
-		e.traderID, fill.Symbol,
-		leaderTradeValue, leaderEquity, leaderTradeRatio*100,
-		followerEquity, e.config.CopyRatio*100, copySize)
+		logger.Infof("📊 [%s] 比例计算 | %s | 领航员: 交易=%.2f 权益=%.2f 占比=%.2f%% | 跟随者: 权益=%.2f 系数=%.0f%% → 跟单=%.2f",
+			e.traderID, fill.Symbol,
+			leaderTradeValue, leaderEquity, leaderTradeRatio*100,
+			followerEquity, copyRatio*100, copySize)
+	}
+
+	copySize, boundWarnings := e.applyCopySizeBounds(fill, copySize, leaderTradeValue, minTradeWarn)
+	return copySize, append(warnings, boundWarnings...)
+}
+
+// applyCopySizeBounds 跟单金额低于最小阈值时提升到阈值，超过 MaxTradeWarn 时记录预警
+func (e *Engine) applyCopySizeBounds(fill *Fill, copySize, leaderTradeValue, minTradeWarn float64) (float64, []Warning) {
+	var warnings []Warning
 
 	// 最小金额检查：如果低于阈值，自动提升到阈值（解决小账户精度问题）
-	minTradeThreshold := e.config.MinTradeWarn
+	minTradeThreshold := minTradeWarn
 	if minTradeThreshold <= 0 {
-		minTradeThreshold = 12.0 // 默认最小 12 USDT，预留精度损失余量
+		minTradeThreshold = DefaultMinTradeAmount // 默认最小 12 USDT，预留精度损失余量
 	}
 	if copySize > 0 && copySize < minTradeThreshold {
 		originalSize := copySize
@@ -1082,12 +2004,12 @@ func (e *Engine) calculateCopySizeByPositionChange(signal *TradeSignal, match *S
 		})
 	}
 
-	if e.config.MaxTradeWarn > 0 && copySize > e.config.MaxTradeWarn {
+	if e.cfg().MaxTradeWarn > 0 && copySize > e.cfg().MaxTradeWarn {
 		warnings = append(warnings, Warning{
 			Timestamp:   time.Now(),
 			Symbol:      fill.Symbol,
 			Type:        "high_value",
-			Message:     fmt.Sprintf("跟单金额较大 (%.2f > %.2f)，仍执行", copySize, e.config.MaxTradeWarn),
+			Message:     fmt.Sprintf("跟单金额较大 (%.2f > %.2f)，仍执行", copySize, e.cfg().MaxTradeWarn),
 			SignalValue: leaderTradeValue,
 			CopyValue:   copySize,
 			Executed:    true,
@@ -1097,11 +2019,77 @@ func (e *Engine) calculateCopySizeByPositionChange(signal *TradeSignal, match *S
 	return copySize, warnings
 }
 
-// getLeaderLeverage 获取领航员杠杆
+// zeroEquityFallback 领航员权益 <= 0 时按配置策略给出回退方案
+// 返回值：固定跟单金额（>0 时直接使用）、替代的领航员权益（>0 时按比例计算）、预警记录
+// 两者都为 0 表示跳过该信号
+func (e *Engine) zeroEquityFallback(fill *Fill) (float64, float64, Warning) {
+	w := Warning{
+		Timestamp:   time.Now(),
+		Symbol:      fill.Symbol,
+		Type:        "zero_leader_equity",
+		SignalValue: fill.Value,
+	}
+
+	switch e.cfg().ZeroEquityPolicy {
+	case ZeroEquityFixedNotional:
+		if e.cfg().FixedNotional > 0 {
+			w.Message = fmt.Sprintf("领航员权益异常，使用固定金额 %.2f USDT", e.cfg().FixedNotional)
+			w.CopyValue = e.cfg().FixedNotional
+			w.Executed = true
+			return e.cfg().FixedNotional, 0, w
+		}
+	case ZeroEquityOverride:
+		if e.cfg().LeaderEquityOverride > 0 {
+			w.Message = fmt.Sprintf("领航员权益异常，使用手动权益 %.2f USDT 计算比例", e.cfg().LeaderEquityOverride)
+			w.Executed = true
+			return 0, e.cfg().LeaderEquityOverride, w
+		}
+	}
+
+	w.Message = "领航员权益异常(<=0)，跳过信号"
+	w.Executed = false
+	return 0, 0, w
+}
+
+// getLeaderLeverage 获取跟单杠杆（领航员杠杆，开启高波动降杠杆时可能更低，不超过 MaxLeverage）
 // 优先级：1.信号中的持仓杠杆 2.缓存的持仓 3.默认值(10x)
+// 超过 MaxLeverage 被截断时记录 leverage_capped 预警
 func (e *Engine) getLeaderLeverage(signal *TradeSignal) int {
+	leverage := e.scaleLeverageForVolatility(signal.Fill.Symbol, e.syncedLeverage(signal))
+	capped := e.capLeverage(leverage)
+	if capped < leverage {
+		logger.Infof("🛑 [%s] %s 杠杆上限 | %dx → %dx", e.traderID, signal.Fill.Symbol, leverage, capped)
+		e.logWarning(Warning{
+			Timestamp:    time.Now(),
+			Symbol:       signal.Fill.Symbol,
+			Type:         "leverage_capped",
+			Message:      fmt.Sprintf("领航员杠杆 %dx 超过上限，已降为 %dx", leverage, capped),
+			SignalAction: string(signal.Fill.Action),
+			SignalValue:  float64(leverage),
+			CopyValue:    float64(capped),
+			Executed:     true,
+		})
+	}
+	return capped
+}
+
+// followerLeverage 跟单杠杆（同 getLeaderLeverage，不记录预警，用于估算保证金）
+func (e *Engine) followerLeverage(signal *TradeSignal) int {
+	return e.capLeverage(e.scaleLeverageForVolatility(signal.Fill.Symbol, e.syncedLeverage(signal)))
+}
+
+// capLeverage 按 MaxLeverage 截断杠杆（0=不限制）
+func (e *Engine) capLeverage(leverage int) int {
+	if e.cfg().MaxLeverage > 0 && leverage > e.cfg().MaxLeverage {
+		return e.cfg().MaxLeverage
+	}
+	return leverage
+}
+
+// syncedLeverage 领航员杠杆（不同步杠杆时为默认值）
+func (e *Engine) syncedLeverage(signal *TradeSignal) int {
 	// 1. 如果不同步杠杆，返回默认值
-	if !e.config.SyncLeverage {
+	if !e.cfg().SyncLeverage {
 		return 10 // 默认 10x
 	}
 
@@ -1126,7 +2114,9 @@ func (e *Engine) getLeaderLeverage(signal *TradeSignal) int {
 	return 10
 }
 
+// mapAction 领航员动作+方向 → 跟随者决策动作（反向跟单时方向取反）
 func (e *Engine) mapAction(action ActionType, side SideType) string {
+	side = e.followerSide(side)
 	switch {
 	case action == ActionOpen && side == SideLong:
 		return "open_long"
@@ -1164,7 +2154,15 @@ Copy Ratio: %.0f%%
 - Only follow new positions (not leader's historical positions)
 - Unconditional execution (warnings are for logging only)
 - Sync Leverage: %v
-`, e.config.ProviderType, e.config.LeaderID, e.config.CopyRatio*100, e.config.SyncLeverage)
+`, e.cfg().ProviderType, e.cfg().LeaderID, e.cfg().CopyRatio*100, e.cfg().SyncLeverage) + e.inversePromptLog() + e.followClosesPromptLog()
+}
+
+// inversePromptLog 反向跟单说明（未开启时为空）
+func (e *Engine) inversePromptLog() string {
+	if !e.cfg().Inverse {
+		return ""
+	}
+	return "- Direction: inverse mode (follower takes the opposite side of every leader position)\n"
 }
 
 func (e *Engine) buildUserPromptLog(signal *TradeSignal) string {
@@ -1195,6 +2193,11 @@ func (e *Engine) buildCoTTrace(signal *TradeSignal, action ActionType, copySize
 		}
 	}
 
+	tradeRatioPct := 0.0
+	if signal.LeaderEquity > 0 {
+		tradeRatioPct = (fill.Value / signal.LeaderEquity) * 100
+	}
+
 	return fmt.Sprintf(`# Copy Trading Decision
 
 ## Signal
@@ -1215,8 +2218,8 @@ Following leader's %s action on %s.
 `,
 		fill.Symbol, fill.Action, action,
 		fill.Price, fill.Value,
-		signal.LeaderEquity, (fill.Value/signal.LeaderEquity)*100,
-		e.getFollowerBalance(), e.config.CopyRatio*100, copySize,
+		signal.LeaderEquity, tradeRatioPct,
+		e.getFollowerBalance(), e.cfg().CopyRatio*100, copySize,
 		warningSection,
 		action, fill.Symbol)
 }
@@ -1226,16 +2229,22 @@ Following leader's %s action on %s.
 // ============================================================================
 
 func (e *Engine) syncLeaderState() error {
-	state, err := e.provider.GetAccountState(e.config.LeaderID)
+	defer e.traceSpan("syncLeaderState")()
+	state, err := e.provider.GetAccountState(e.cfg().LeaderID)
 	if err != nil {
 		return err
 	}
+	state = e.scopeState(state)
 
 	e.leaderStateMu.Lock()
 	e.leaderState = state
 	e.lastStateSync = time.Now()
 	e.leaderStateMu.Unlock()
 
+	for _, pos := range state.Positions {
+		e.recordPrice(pos.Symbol, pos.MarkPrice, time.Time{})
+	}
+
 	logger.Debugf("👁️ [%s] 领航员状态同步 | 权益=%.2f 持仓数=%d",
 		e.traderID, state.TotalEquity, len(state.Positions))
 
@@ -1249,9 +2258,75 @@ func (e *Engine) syncLeaderState() error {
 	// 如果是，标记为 closed，这样后续重新开仓可以跟随
 	e.checkIgnoredPositionsClosed()
 
+	// 🔀 领航员切换仓位保证金模式：更新映射（按配置让跟随者同步切换）
+	e.checkMarginModeChanges()
+
 	return nil
 }
 
+// refreshLeaderEquity 绕过缓存同步拉取领航员权益并更新 signal（失败时保留缓存值）
+func (e *Engine) refreshLeaderEquity(signal *TradeSignal) {
+	defer e.traceSpan("refreshLeaderEquity")()
+	fp, ok := e.provider.(FreshStateProvider)
+	if !ok {
+		logger.Debugf("👁️ [%s] %s 不支持同步刷新，使用缓存权益 %.2f", e.traderID, e.cfg().ProviderType, signal.LeaderEquity)
+		return
+	}
+
+	start := time.Now()
+	state, err := fp.GetAccountStateFresh(e.cfg().LeaderID)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 开仓前刷新领航员权益失败，使用缓存权益 %.2f: %v", e.traderID, signal.LeaderEquity, err)
+		return
+	}
+	logger.Infof("👁️ [%s] 开仓前刷新领航员权益 | 缓存=%.2f 最新=%.2f 耗时=%dms",
+		e.traderID, signal.LeaderEquity, state.TotalEquity, time.Since(start).Milliseconds())
+	signal.LeaderEquity = state.TotalEquity
+}
+
+// backfillLastKnownSize 为 lastKnownSize 缺失（旧版本创建）的 active 映射补齐领航员当前持仓数量
+// 启动时执行一次：加仓/减仓依赖 size 变化匹配，缺失时只能走单仓位兜底逻辑
+func (e *Engine) backfillLastKnownSize() {
+	if e.store == nil {
+		return
+	}
+
+	mappings, err := e.listActiveMappings()
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 查询活跃映射失败: %v", e.traderID, err)
+		return
+	}
+
+	var missing []*store.CopyTradePositionMapping
+	for _, m := range mappings {
+		if m.LastKnownSize <= 0 {
+			missing = append(missing, m)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	if err := e.syncLeaderState(); err != nil {
+		logger.Warnf("⚠️ [%s] 补齐 lastKnownSize 失败（领航员状态同步失败）: %v", e.traderID, err)
+		return
+	}
+
+	leaderPosMap := e.buildLeaderPosMap()
+	for _, m := range missing {
+		pos := leaderPosMap[m.LeaderPosID]
+		if pos == nil || pos.Size <= 0 {
+			continue
+		}
+		if err := e.store.CopyTrade().UpdateLastKnownSize(e.traderID, m.LeaderPosID, pos.Size); err != nil {
+			logger.Warnf("⚠️ [%s] 更新 lastKnownSize 失败: %v", e.traderID, err)
+			continue
+		}
+		logger.Infof("📝 [%s] 补齐 lastKnownSize | posId=%s %s %s size=%.4f",
+			e.traderID, m.LeaderPosID, m.Symbol, m.Side, pos.Size)
+	}
+}
+
 // checkIgnoredPositionsClosed 检查 ignored 仓位是否已被领航员平仓
 // 当历史仓位被领航员平仓后，将状态从 ignored 改为 closed
 // 这样如果领航员重新开仓（即使 posId 被复用），也能正确跟随
@@ -1261,7 +2336,7 @@ func (e *Engine) checkIgnoredPositionsClosed() {
 	}
 
 	// 获取所有 ignored 映射
-	ignoredMappings, err := e.store.CopyTrade().ListIgnoredMappings(e.traderID)
+	ignoredMappings, err := e.store.CopyTrade().ListIgnoredMappingsForLeader(e.traderID, e.mappingLeader())
 	if err != nil {
 		logger.Warnf("⚠️ [%s] 获取 ignored 映射失败: %v", e.traderID, err)
 		return
@@ -1295,7 +2370,7 @@ func (e *Engine) checkIgnoredPositionsClosed() {
 
 func (e *Engine) initSeenFills() {
 	since := time.Now().Add(-5 * time.Minute)
-	fills, err := e.provider.GetFills(e.config.LeaderID, since)
+	fills, err := e.provider.GetFills(e.cfg().LeaderID, since)
 	if err != nil {
 		logger.Warnf("⚠️ [%s] 初始化去重基线失败: %v", e.traderID, err)
 		return
@@ -1308,32 +2383,62 @@ func (e *Engine) initSeenFills() {
 	logger.Infof("🔧 [%s] 去重基线初始化完成 | 已标记 %d 条历史成交", e.traderID, len(fills))
 }
 
+// isSeen 成交是否已处理：先查内存，未命中再查数据库（重启前处理过的成交）
 func (e *Engine) isSeen(id string) bool {
 	e.seenMu.RLock()
-	defer e.seenMu.RUnlock()
-
 	seenTime, exists := e.seenFills[id]
-	if !exists {
+	e.seenMu.RUnlock()
+
+	if exists {
+		return time.Since(seenTime) <= e.seenTTL // 已过期视为未处理
+	}
+	if e.store == nil {
 		return false
 	}
 
-	if time.Since(seenTime) > e.seenTTL {
-		return false // 已过期
+	seen, err := e.store.CopyTrade().IsFillSeen(e.traderID, id, time.Now().Add(-e.seenTTL))
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 查询去重记录失败: %v", e.traderID, err)
+		return false
 	}
-
-	return true
+	if seen {
+		e.seenMu.Lock()
+		e.seenFills[id] = time.Now()
+		e.seenMu.Unlock()
+	}
+	return seen
 }
 
+// markSeen 标记成交已处理（内存 + 数据库）
 func (e *Engine) markSeen(id string) {
-	e.seenMu.Lock()
-	defer e.seenMu.Unlock()
-
-	e.seenFills[id] = time.Now()
+	now := time.Now()
 
+	e.seenMu.Lock()
+	e.seenFills[id] = now
 	// 定期清理过期记录
 	if len(e.seenFills) > 1000 && len(e.seenFills)%100 == 0 {
 		e.cleanExpiredFills()
 	}
+	cleanStore := now.Sub(e.seenCleanedAt) > e.seenTTL
+	if cleanStore {
+		e.seenCleanedAt = now
+	}
+	e.seenMu.Unlock()
+
+	if e.store == nil {
+		return
+	}
+	if err := e.store.CopyTrade().MarkFillSeen(e.traderID, id, now); err != nil {
+		logger.Warnf("⚠️ [%s] 保存去重记录失败: %v", e.traderID, err)
+	}
+	// 每个 TTL 周期清理一次数据库中的过期记录
+	if cleanStore {
+		if n, err := e.store.CopyTrade().CleanSeenFills(e.traderID, now.Add(-e.seenTTL)); err != nil {
+			logger.Warnf("⚠️ [%s] 清理去重记录失败: %v", e.traderID, err)
+		} else if n > 0 {
+			logger.Debugf("🧹 [%s] 清理过期去重记录（数据库）%d 条", e.traderID, n)
+		}
+	}
 }
 
 func (e *Engine) cleanExpiredFills() {
@@ -1346,11 +2451,49 @@ func (e *Engine) cleanExpiredFills() {
 	logger.Debugf("🧹 [%s] 清理过期去重记录，剩余 %d 条", e.traderID, len(e.seenFills))
 }
 
+// maxMemoryWarnings 内存中保留的最近预警数（完整记录见 copy_trade_warnings 表）
+const maxMemoryWarnings = 200
+
 func (e *Engine) logWarning(w Warning) {
 	e.warningsMu.Lock()
 	e.warnings = append(e.warnings, w)
+	if len(e.warnings) > maxMemoryWarnings {
+		e.warnings = append(e.warnings[:0:0], e.warnings[len(e.warnings)-maxMemoryWarnings:]...)
+	}
 	e.stats.WarningsCount++
+	count := e.stats.WarningsCount
 	e.warningsMu.Unlock()
 
 	logger.Warnf("⚠️ [%s] 预警:%s | %s | %s", e.traderID, w.Type, w.Symbol, w.Message)
+	e.persistWarning(w, count)
+}
+
+// persistWarning 保存预警记录（WarningHistoryLimit < 0 时不保存），每 100 条裁剪一次历史
+func (e *Engine) persistWarning(w Warning, count int64) {
+	limit := e.cfg().WarningHistoryLimit
+	if e.store == nil || limit < 0 {
+		return
+	}
+	if limit == 0 {
+		limit = store.DefaultWarningHistoryLimit
+	}
+
+	if err := e.store.CopyTrade().SaveWarning(&store.CopyTradeWarning{
+		TraderID:     e.traderID,
+		Type:         w.Type,
+		Symbol:       w.Symbol,
+		Message:      w.Message,
+		SignalAction: w.SignalAction,
+		SignalValue:  w.SignalValue,
+		Executed:     w.Executed,
+		CreatedAt:    w.Timestamp,
+	}); err != nil {
+		logger.Warnf("⚠️ [%s] 保存预警记录失败: %v", e.traderID, err)
+		return
+	}
+	if count%100 == 0 {
+		if _, err := e.store.CopyTrade().TrimWarnings(e.traderID, limit); err != nil {
+			logger.Warnf("⚠️ [%s] 裁剪预警记录失败: %v", e.traderID, err)
+		}
+	}
 }
@@ -0,0 +1,1341 @@
+package copytrade
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nofx/decision"
+	"nofx/store"
+)
+
+// newTestEngine 创建不依赖网络和数据库的测试引擎
+func newTestEngine(config *CopyConfig, followerBalance float64) *Engine {
+	e := &Engine{
+		traderID:             "test",
+		getFollowerBalance:   func() float64 { return followerBalance },
+		getFollowerPositions: func() map[string]*Position { return map[string]*Position{} },
+		seenFills:            make(map[string]time.Time),
+		seenTTL:              time.Hour,
+		decisionCh:           make(chan *decision.FullDecision, 10),
+		stats:                &EngineStats{StartTime: time.Now()},
+	}
+	e.config.Store(config)
+	return e
+}
+
+// withConfig 用修改后的配置副本替换引擎配置（配置快照不可变，不能原地修改 e.cfg()）
+func withConfig(e *Engine, update func(c *CopyConfig)) {
+	cfg := *e.cfg()
+	update(&cfg)
+	e.config.Store(&cfg)
+}
+
+// fakeProvider 可控的领航员数据源
+type fakeProvider struct {
+	state *AccountState
+	err   error
+}
+
+func (p *fakeProvider) GetFills(leaderID string, since time.Time) ([]Fill, error) { return nil, nil }
+func (p *fakeProvider) GetAccountState(leaderID string) (*AccountState, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.state, nil
+}
+func (p *fakeProvider) Type() ProviderType                   { return ProviderHyperliquid }
+func (p *fakeProvider) ValidateLeader(leaderID string) error { return p.err }
+
+// setSize 设置领航员 BTCUSDT 多仓数量
+func (p *fakeProvider) setSize(size float64) {
+	p.state = &AccountState{
+		TotalEquity: 10000,
+		Positions: map[string]*Position{
+			PositionKey("BTCUSDT", SideLong): {Symbol: "BTCUSDT", Side: SideLong, Size: size, MarginMode: "cross", Leverage: 5},
+		},
+	}
+}
+
+// TestCalculateCopySizeZeroLeaderEquity 领航员权益为零时不能按 equity=1 放大跟单金额
+func TestCalculateCopySizeZeroLeaderEquity(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       CopyConfig
+		leaderEquity float64
+		wantSize     float64
+	}{
+		{
+			name:         "default policy skips signal",
+			config:       CopyConfig{},
+			leaderEquity: 0,
+			wantSize:     0,
+		},
+		{
+			name:         "negative equity skips signal",
+			config:       CopyConfig{ZeroEquityPolicy: ZeroEquitySkip},
+			leaderEquity: -100,
+			wantSize:     0,
+		},
+		{
+			name:         "fixed notional fallback",
+			config:       CopyConfig{ZeroEquityPolicy: ZeroEquityFixedNotional, FixedNotional: 50},
+			leaderEquity: 0,
+			wantSize:     50,
+		},
+		{
+			name:         "fixed notional without amount skips",
+			config:       CopyConfig{ZeroEquityPolicy: ZeroEquityFixedNotional},
+			leaderEquity: 0,
+			wantSize:     0,
+		},
+		{
+			name:         "equity override fallback",
+			config:       CopyConfig{ZeroEquityPolicy: ZeroEquityOverride, LeaderEquityOverride: 10000},
+			leaderEquity: 0,
+			wantSize:     100, // 1000 / 10000 * 1000
+		},
+		{
+			name:         "positive equity uses proportional sizing",
+			config:       CopyConfig{},
+			leaderEquity: 5000,
+			wantSize:     200, // 1000 / 5000 * 1000
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.config
+			cfg.ProviderType = ProviderOKX
+			cfg.CopyRatio = 1.0
+			e := newTestEngine(&cfg, 1000)
+
+			signal := &TradeSignal{
+				LeaderEquity: tt.leaderEquity,
+				Fill:         &Fill{Symbol: "BTCUSDT", Price: 100, Size: 10, Value: 1000},
+			}
+			match := &SignalMatchResult{ShouldFollow: true, Action: ActionOpen}
+
+			got, _ := e.calculateCopySizeByPositionChange(signal, match)
+			if got != tt.wantSize {
+				t.Errorf("copy size = %.4f, want %.4f", got, tt.wantSize)
+			}
+			if got > 1000 {
+				t.Errorf("copy size %.2f exceeds follower equity", got)
+			}
+		})
+	}
+}
+
+// TestTrialTradeLimit 试用额度用完后进入只平仓模式并发出一次预警
+func TestTrialTradeLimit(t *testing.T) {
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, TrialTradeLimit: 2}, 1000)
+	fill := &Fill{Symbol: "BTCUSDT", Value: 100}
+
+	e.recordTrialOpen(fill)
+	if e.stats.CloseOnly {
+		t.Fatal("close-only after 1/2 opens")
+	}
+
+	e.recordTrialOpen(fill)
+	if !e.stats.CloseOnly {
+		t.Fatal("expected close-only after 2/2 opens")
+	}
+	if len(e.warnings) != 1 || e.warnings[0].Type != "trial_completed" {
+		t.Errorf("warnings = %+v, want one trial_completed", e.warnings)
+	}
+
+	// 已进入只平仓模式，不再重复预警
+	e.recordTrialOpen(fill)
+	if len(e.warnings) != 1 {
+		t.Errorf("warnings = %d, want 1", len(e.warnings))
+	}
+}
+
+// newTestStore 创建临时数据库
+func newTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("create store: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return st
+}
+
+// TestOKXNetModeClose OKX 单向持仓模式（posSide=net）的平仓/减仓能匹配到映射
+func TestOKXNetModeClose(t *testing.T) {
+	tests := []struct {
+		name       string
+		leaderPos  []OKXPosition // 成交后的领航员持仓
+		fillSize   float64
+		wantAction ActionType
+	}{
+		{
+			name:       "full close removes position",
+			leaderPos:  nil,
+			fillSize:   1,
+			wantAction: ActionClose,
+		},
+		{
+			name:       "partial reduce keeps position",
+			leaderPos:  []OKXPosition{{InstId: "BTC-USDT-SWAP", PosSide: "net", Pos: "0.4", MgnMode: "cross", PosId: "123"}},
+			fillSize:   0.6,
+			wantAction: ActionReduce,
+		},
+		{
+			name:       "reverse to short closes long",
+			leaderPos:  []OKXPosition{{InstId: "BTC-USDT-SWAP", PosSide: "net", Pos: "-0.5", MgnMode: "cross", PosId: "123"}},
+			fillSize:   1.5,
+			wantAction: ActionClose,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newTestStore(t)
+			e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader"}, 1000)
+			e.store = st
+
+			// 开仓时领航员为多头 1 BTC（net 模式正数）
+			opened := parseOKXPositions([]OKXPositionData{{PosData: []OKXPosition{
+				{InstId: "BTC-USDT-SWAP", PosSide: "net", Pos: "1", MgnMode: "cross", PosId: "123"},
+			}}}, normalizeOKXSymbol)
+			pos := opened["123"]
+			if pos == nil || pos.Side != SideLong || pos.Size != 1 {
+				t.Fatalf("net position not normalized: %+v", pos)
+			}
+			if err := st.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+				TraderID:      "test",
+				LeaderPosID:   "123",
+				LeaderID:      "leader",
+				Symbol:        pos.Symbol,
+				Side:          string(pos.Side),
+				MarginMode:    pos.MarginMode,
+				OpenedAt:      time.Now(),
+				OpenPrice:     60000,
+				OpenSizeUSD:   100,
+				LastKnownSize: pos.Size,
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			e.leaderState = &AccountState{
+				TotalEquity: 10000,
+				Positions:   parseOKXPositions([]OKXPositionData{{PosData: tt.leaderPos}}, normalizeOKXSymbol),
+			}
+
+			fill := &Fill{Symbol: "BTCUSDT", Size: tt.fillSize, Price: 61000, Value: tt.fillSize * 61000, NetMode: true}
+			fill.Side, fill.PositionSide, fill.Action = parseOKXDirection("sell", "net")
+			e.resolveNetModeFill(fill)
+
+			if fill.PositionSide != SideLong || fill.Action != ActionClose {
+				t.Fatalf("fill resolved to %s %s, want long close", fill.PositionSide, fill.Action)
+			}
+
+			match := e.matchSignalWithMapping(e.buildSignal(fill))
+			if !match.ShouldFollow || match.Action != tt.wantAction || match.PosID != "123" {
+				t.Errorf("match = %+v, want %s posId=123", match, tt.wantAction)
+			}
+		})
+	}
+}
+
+// TestLeaderBudget 活跃仓位累计开仓金额超过预算时拒绝开仓，平仓后释放
+func TestLeaderBudget(t *testing.T) {
+	st := newTestStore(t)
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", LeaderBudget: 500}, 1000)
+	e.store = st
+	ct := st.CopyTrade()
+
+	if err := ct.SavePositionMapping(&store.CopyTradePositionMapping{
+		TraderID: "test", LeaderPosID: "1", LeaderID: "leader", Symbol: "BTCUSDT",
+		Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 300,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ct.IncrementAddCount("test", "1", 100); err != nil {
+		t.Fatal(err)
+	}
+
+	if !e.withinLeaderBudget(100) {
+		t.Error("400 + 100 should fit budget 500")
+	}
+	if e.withinLeaderBudget(150) {
+		t.Error("400 + 150 should exceed budget 500")
+	}
+
+	if err := ct.CloseMapping("test", "1", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !e.withinLeaderBudget(450) {
+		t.Error("budget should be released after close")
+	}
+}
+
+// TestReduceNeverExceedsFollowerPosition 跟随者持仓少于按比例预期时，减仓数量不超过实际持仓
+func TestReduceNeverExceedsFollowerPosition(t *testing.T) {
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader"}, 1000)
+	followerSize := 0.3 // 按比例应持有 1.0，此前开仓被缩小
+	e.getFollowerPositions = func() map[string]*Position {
+		return map[string]*Position{
+			"BTCUSDT_long": {Symbol: "BTCUSDT", Side: SideLong, Size: followerSize, MarginMode: "cross"},
+		}
+	}
+
+	signal := &TradeSignal{Fill: &Fill{Symbol: "BTCUSDT", PositionSide: SideLong, Action: ActionClose, Size: 1, Price: 100}}
+	match := &SignalMatchResult{
+		ShouldFollow:   true,
+		Action:         ActionReduce,
+		PosID:          "1",
+		MarginMode:     "cross",
+		LeaderPosition: &Position{Symbol: "BTCUSDT", Side: SideLong, Size: 3},
+	}
+
+	dec := e.buildDecisionV2(signal, match, 0)
+	if dec.CloseRatio != 0.25 {
+		t.Errorf("close ratio = %.4f, want 0.25", dec.CloseRatio)
+	}
+	if qty := followerSize * dec.CloseRatio; qty > followerSize {
+		t.Errorf("reduce qty %.4f exceeds follower position %.4f", qty, followerSize)
+	}
+
+	for _, raw := range []float64{1.7, -0.2, math.NaN()} {
+		got, _ := clampReduceRatio(raw)
+		if got < 0 || got > 1 {
+			t.Errorf("clampReduceRatio(%v) = %v, want within [0, 1]", raw, got)
+		}
+	}
+
+	// 绝对数量减仓：领航员 4 → 3 减 1（系数 1 → 应减 1），跟随者只持有 0.3 → 全量平仓
+	withConfig(e, func(c *CopyConfig) { c.ReduceMode = ReduceModeScaledAbsolute })
+	withConfig(e, func(c *CopyConfig) { c.CopyRatio = 1 })
+	signal.LeaderEquity = 1000
+	dec = e.buildDecisionV2(signal, match, 0)
+	if dec.Action != "close_long" || dec.CloseRatio != 0 {
+		t.Errorf("reduce beyond follower position = %s ratio %.4f, want close_long full close", dec.Action, dec.CloseRatio)
+	}
+	if ratio, ok := e.calculateScaledAbsoluteReduceRatio(signal, match); !ok || ratio != 1 {
+		t.Errorf("scaled absolute reduce ratio = %v (ok=%v), want capped at 1", ratio, ok)
+	}
+}
+
+// TestMappingRealizedPnL 映射盈亏只按本映射的开仓/加仓/减仓/平仓计算：减仓盈亏累计，平仓时计入；
+// 同币种同方向其他交易（如 AI 交易）在 trader_positions 中的平仓记录不影响
+func TestMappingRealizedPnL(t *testing.T) {
+	st := newTestStore(t)
+	ti := &TraderIntegration{traderID: "test", store: st, engine: newTestEngine(&CopyConfig{LeaderID: "leader"}, 1000)}
+	ti.engines = []*Engine{ti.engine}
+
+	// 同币种同方向、刚平仓的非跟单仓位
+	other := &store.TraderPosition{TraderID: "test", Symbol: "BTCUSDT", Side: "LONG", Quantity: 1, EntryPrice: 100, EntryTime: time.Now()}
+	if err := st.Position().Create(other); err != nil {
+		t.Fatal(err)
+	}
+
+	apply := func(action string, price, sizeUSD, closeRatio float64) {
+		ti.updatePositionMapping(&decision.Decision{Symbol: "BTCUSDT", Action: action, LeaderPosID: "p1", MarginMode: "cross",
+			EntryPrice: price, PositionSizeUSD: sizeUSD, CloseRatio: closeRatio, LeaderPosSize: 1})
+	}
+	apply("open_long", 100, 100, 0) // 1 @ 100
+	apply("open_long", 200, 100, 0) // +0.5 @ 200 → 1.5 @ 133.33
+	if err := st.Position().ClosePosition(other.ID, 500, "x", 999, 0, "ai_decision"); err != nil {
+		t.Fatal(err)
+	}
+	apply("reduce_long", 150, 0, 0.5) // 0.75 × (150 - 133.33) = 12.5
+
+	m, err := st.CopyTrade().GetActiveMapping("test", "p1")
+	if err != nil || m == nil {
+		t.Fatalf("mapping = %+v (err=%v)", m, err)
+	}
+	if math.Abs(m.OpenQty-0.75) > 1e-9 || math.Abs(m.RealizedPnL-12.5) > 1e-9 {
+		t.Fatalf("after reduce qty=%.4f pnl=%.4f, want 0.75 / 12.5", m.OpenQty, m.RealizedPnL)
+	}
+
+	apply("close_long", 120, 0, 0) // 0.75 × (120 - 133.33) = -10
+	all, err := st.CopyTrade().ListAllMappings("test", 0)
+	if err != nil || len(all) != 1 || all[0].Status != "closed" {
+		t.Fatalf("mappings = %+v (err=%v)", all, err)
+	}
+	if math.Abs(all[0].RealizedPnL-2.5) > 1e-9 {
+		t.Errorf("realized pnl = %.4f, want 2.5 (12.5 reduce + -10 close)", all[0].RealizedPnL)
+	}
+	if !all[0].PnLEstimated {
+		t.Error("executor without confirmed fills: mapping not marked as estimated")
+	}
+}
+
+// fillExecutor 返回确认成交的执行器
+type fillExecutor struct {
+	fakeExecutor
+	price, qty float64
+	ok         bool
+}
+
+func (f *fillExecutor) LastExecutedFill(symbol string) (float64, float64, bool) {
+	return f.price, f.qty, f.ok
+}
+
+// TestMappingExecutedFills 映射数量/均价/盈亏按执行器确认的实际成交记录（不是领航员价格和估算数量）；
+// 确认成交不可用时回退估算并标记 pnl_estimated
+func TestMappingExecutedFills(t *testing.T) {
+	st := newTestStore(t)
+	exec := &fillExecutor{}
+	ti := &TraderIntegration{traderID: "test", store: st, executor: exec, engine: newTestEngine(&CopyConfig{LeaderID: "leader"}, 1000)}
+	ti.engines = []*Engine{ti.engine}
+
+	apply := func(action string, leaderPrice, sizeUSD, closeRatio, fillPrice, fillQty float64, ok bool) {
+		exec.price, exec.qty, exec.ok = fillPrice, fillQty, ok
+		ti.updatePositionMapping(&decision.Decision{Symbol: "BTCUSDT", Action: action, LeaderPosID: "p1", MarginMode: "cross",
+			EntryPrice: leaderPrice, PositionSizeUSD: sizeUSD, CloseRatio: closeRatio, LeaderPosSize: 1})
+	}
+	apply("open_long", 100, 100, 0, 101, 0.98, true)   // 领航员 100，跟随者实际 0.98 @ 101
+	apply("reduce_long", 105, 0, 0.5, 110, 0.49, true) // 0.49 × (110 - 101) = 4.41
+	apply("close_long", 115, 0, 0, 120, 0.6, true)     // 实际平仓 0.6 含非本映射持仓 → 按剩余 0.49 计：0.49 × 19 = 9.31
+
+	all, err := st.CopyTrade().ListAllMappings("test", 0)
+	if err != nil || len(all) != 1 {
+		t.Fatalf("mappings = %+v (err=%v)", all, err)
+	}
+	m := all[0]
+	if math.Abs(m.RealizedPnL-13.72) > 1e-9 || m.ClosePrice != 115 || m.PnLEstimated {
+		t.Errorf("closed mapping pnl=%.4f close=%v estimated=%v, want 13.72 / leader 115 / false", m.RealizedPnL, m.ClosePrice, m.PnLEstimated)
+	}
+
+	// 确认成交不可用：按领航员价格和跟单金额估算，并标记为估算
+	apply("open_long", 100, 100, 0, 0, 0, false)
+	m, err = st.CopyTrade().GetActiveMapping("test", "p1")
+	if err != nil || m == nil {
+		t.Fatalf("mapping = %+v (err=%v)", m, err)
+	}
+	if m.OpenQty != 1 || m.AvgEntryPrice != 100 || !m.PnLEstimated {
+		t.Errorf("estimated open qty=%v avg=%v estimated=%v, want 1 / 100 / true", m.OpenQty, m.AvgEntryPrice, m.PnLEstimated)
+	}
+}
+
+// TestMappingReopenSamePosID 同一 posId 开仓→平仓→重新开仓：新映射重置为 active，旧映射归档保留
+func TestMappingReopenSamePosID(t *testing.T) {
+	ct := newTestStore(t).CopyTrade()
+	posID := "BTCUSDT_long" // Hyperliquid 虚拟 posId
+
+	open := func(price, size float64) {
+		t.Helper()
+		if err := ct.SavePositionMapping(&store.CopyTradePositionMapping{
+			TraderID: "test", LeaderPosID: posID, LeaderID: "leader", Symbol: "BTCUSDT",
+			Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenPrice: price, OpenSizeUSD: size, LastKnownSize: 1,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	open(100, 50)
+	if err := ct.IncrementAddCount("test", posID, 25); err != nil {
+		t.Fatal(err)
+	}
+	if err := ct.CloseMapping("test", posID, 110, 7.5); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := ct.GetMapping("test", posID); m != nil {
+		t.Fatalf("closed mapping still visible: %+v", m)
+	}
+
+	open(200, 80)
+	m, err := ct.GetActiveMapping("test", posID)
+	if err != nil || m == nil {
+		t.Fatalf("reopened mapping not active: %v", err)
+	}
+	if m.OpenPrice != 200 || m.OpenSizeUSD != 80 || m.AddCount != 0 || m.RealizedPnL != 0 || m.ClosedAt != nil {
+		t.Errorf("reopened mapping has stale data: %+v", m)
+	}
+
+	// 旧仓位归档后仍计入历史统计
+	all, err := ct.ListAllMappings("test", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("mappings = %d, want 2 (archived + active)", len(all))
+	}
+	if n, _ := ct.CountFollowedOpens("test", "leader"); n != 2 {
+		t.Errorf("followed opens = %d, want 2", n)
+	}
+
+	// 重启时领航员仍持有该仓位：active 映射不被覆盖为 ignored
+	if err := ct.SaveIgnoredPosition("test", "leader", posID, "BTCUSDT", "long", "cross"); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := ct.GetActiveMapping("test", posID); m == nil {
+		t.Error("active mapping overwritten on restart")
+	}
+}
+
+// TestReduceModes 同一笔领航员减仓在 ratio 与 scaled_absolute 模式下的减仓比例
+func TestReduceModes(t *testing.T) {
+	// 领航员：权益 10000，持仓 3 → 2（减仓 1）；跟随者：权益 1000，系数 100% → 跟单系数 0.1
+	tests := []struct {
+		name         string
+		mode         string
+		followerSize float64
+		wantRatio    float64
+	}{
+		{"ratio with proportional holding", ReduceModeRatio, 0.3, 1.0 / 3},
+		{"scaled absolute with proportional holding", ReduceModeScaledAbsolute, 0.3, 1.0 / 3},
+		{"ratio with boosted holding", ReduceModeRatio, 0.5, 1.0 / 3},
+		{"scaled absolute with boosted holding", ReduceModeScaledAbsolute, 0.5, 0.2}, // 0.1 / 0.5
+		{"scaled absolute clamps to holdings", ReduceModeScaledAbsolute, 0.05, 0},    // 0.1 > 0.05 → 全平
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 1, ReduceMode: tt.mode}, 1000)
+			e.getFollowerPositions = func() map[string]*Position {
+				return map[string]*Position{"p": {Symbol: "BTCUSDT", Side: SideLong, Size: tt.followerSize, MarginMode: "cross"}}
+			}
+
+			signal := &TradeSignal{
+				LeaderEquity: 10000,
+				Fill:         &Fill{Symbol: "BTCUSDT", PositionSide: SideLong, Action: ActionClose, Size: 1, Price: 100},
+			}
+			match := &SignalMatchResult{
+				ShouldFollow:   true,
+				Action:         ActionReduce,
+				PosID:          "1",
+				MarginMode:     "cross",
+				LeaderPosition: &Position{Symbol: "BTCUSDT", Side: SideLong, Size: 2},
+			}
+
+			dec := e.buildDecisionV2(signal, match, 0)
+			if math.Abs(dec.CloseRatio-tt.wantRatio) > 1e-9 {
+				t.Errorf("close ratio = %.4f, want %.4f", dec.CloseRatio, tt.wantRatio)
+			}
+			if qty := dec.CloseRatio * tt.followerSize; qty > tt.followerSize {
+				t.Errorf("reduce qty %.4f exceeds holdings %.4f", qty, tt.followerSize)
+			}
+		})
+	}
+}
+
+// TestLastKnownSizeAddReduce lastKnownSize 补齐后，加仓/减仓通过 size 变化端到端匹配并更新映射
+func TestLastKnownSizeAddReduce(t *testing.T) {
+	st := newTestStore(t)
+	ct := st.CopyTrade()
+	provider := &fakeProvider{}
+	provider.setSize(1.0)
+
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.store = st
+	e.provider = provider
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e}
+
+	posID := PositionKey("BTCUSDT", SideLong)
+	// 旧版本创建的映射：lastKnownSize 缺失
+	if err := ct.SavePositionMapping(&store.CopyTradePositionMapping{
+		TraderID: "test", LeaderPosID: posID, LeaderID: "leader", Symbol: "BTCUSDT",
+		Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenPrice: 100, OpenSizeUSD: 10,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	e.backfillLastKnownSize()
+	if m, _ := ct.GetActiveMapping("test", posID); m == nil || m.LastKnownSize != 1.0 {
+		t.Fatalf("lastKnownSize not backfilled: %+v", m)
+	}
+
+	// 执行决策并按集成逻辑更新映射
+	run := func(fill *Fill) decision.Decision {
+		t.Helper()
+		e.processSignal(&TradeSignal{Fill: fill})
+		select {
+		case fullDec := <-e.decisionCh:
+			dec := fullDec.Decisions[0]
+			ti.updatePositionMapping(&dec)
+			return dec
+		default:
+			t.Fatalf("no decision for %s %s", fill.Action, fill.Symbol)
+			return decision.Decision{}
+		}
+	}
+
+	// 加仓：1.0 → 1.5
+	provider.setSize(1.5)
+	dec := run(&Fill{ID: "f1", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionAdd, Price: 100, Size: 0.5, Value: 50})
+	if dec.Action != "open_long" || dec.LeaderPosSize != 1.5 {
+		t.Errorf("add decision = %s size=%.4f, want open_long 1.5", dec.Action, dec.LeaderPosSize)
+	}
+	m, _ := ct.GetActiveMapping("test", posID)
+	if m.LastKnownSize != 1.5 || m.AddCount != 1 {
+		t.Errorf("after add: lastKnownSize=%.4f addCount=%d", m.LastKnownSize, m.AddCount)
+	}
+
+	// 减仓：1.5 → 0.6（减少 60%）
+	provider.setSize(0.6)
+	dec = run(&Fill{ID: "f2", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionClose, Price: 100, Size: 0.9, Value: 90})
+	if dec.Action != "reduce_long" || math.Abs(dec.CloseRatio-0.6) > 1e-9 {
+		t.Errorf("reduce decision = %s ratio=%.4f, want reduce_long 0.6", dec.Action, dec.CloseRatio)
+	}
+	m, _ = ct.GetActiveMapping("test", posID)
+	if m.LastKnownSize != 0.6 || m.ReduceCount != 1 {
+		t.Errorf("after reduce: lastKnownSize=%.4f reduceCount=%d", m.LastKnownSize, m.ReduceCount)
+	}
+}
+
+// TestFilterFillsSince 数据源忽略 since 时丢弃窗口外成交
+func TestFilterFillsSince(t *testing.T) {
+	since := time.Now().Add(-time.Minute)
+	newFills := func() []Fill {
+		return []Fill{
+			{ID: "old", Timestamp: since.Add(-time.Hour)},
+			{ID: "new", Timestamp: since.Add(10 * time.Second)},
+			{ID: "no-ts"},
+		}
+	}
+	ids := func(fills []Fill) []string {
+		var out []string
+		for _, f := range fills {
+			out = append(out, f.ID)
+		}
+		return out
+	}
+
+	e := newTestEngine(&CopyConfig{}, 1000)
+	got := ids(e.filterFillsSince(newFills(), since, e.cfg().OutOfWindowFills))
+	if len(got) != 2 || got[0] != "new" || got[1] != "no-ts" {
+		t.Errorf("drop policy kept %v, want [new no-ts]", got)
+	}
+
+	e = newTestEngine(&CopyConfig{OutOfWindowFills: OutOfWindowKeep}, 1000)
+	if got := e.filterFillsSince(newFills(), since, e.cfg().OutOfWindowFills); len(got) != 3 {
+		t.Errorf("keep policy kept %v, want all 3", ids(got))
+	}
+}
+
+// fillsProvider 每次轮询返回同一批成交
+type fillsProvider struct {
+	*fakeProvider
+	fills []Fill
+}
+
+func (p *fillsProvider) GetFills(leaderID string, since time.Time) ([]Fill, error) {
+	return p.fills, nil
+}
+
+// TestPollDedupStats 轮询窗口重叠时统计被去重的成交
+func TestPollDedupStats(t *testing.T) {
+	now := time.Now()
+	provider := &fillsProvider{fakeProvider: &fakeProvider{}, fills: []Fill{
+		{ID: "a", Symbol: "BTCUSDT", Timestamp: now},
+		{ID: "b", Symbol: "BTCUSDT", Timestamp: now},
+	}}
+	provider.setSize(1)
+	e := newTestEngine(&CopyConfig{}, 1000)
+	e.provider = provider
+	// 降级模式下 processSignal 直接返回，只验证去重统计
+	e.stats.Degraded = true
+
+	e.poll()
+	if e.stats.SignalsReceived != 2 || e.stats.SignalsDeduped != 0 {
+		t.Fatalf("first poll: received=%d deduped=%d, want 2/0", e.stats.SignalsReceived, e.stats.SignalsDeduped)
+	}
+
+	provider.fills = append(provider.fills, Fill{ID: "c", Symbol: "BTCUSDT", Timestamp: now})
+	e.poll()
+	if e.stats.SignalsReceived != 3 || e.stats.SignalsDeduped != 2 {
+		t.Fatalf("second poll: received=%d deduped=%d, want 3/2", e.stats.SignalsReceived, e.stats.SignalsDeduped)
+	}
+}
+
+// TestMatchOpenAddSignalBatchLookup 批量查询映射后匹配语义保持不变
+func TestMatchOpenAddSignalBatchLookup(t *testing.T) {
+	st := newTestStore(t)
+	ct := st.CopyTrade()
+
+	saveActive := func(posID string, lastKnown float64) {
+		t.Helper()
+		if err := ct.SavePositionMapping(&store.CopyTradePositionMapping{
+			TraderID: "test", LeaderPosID: posID, LeaderID: "leader", Symbol: "BTCUSDT",
+			Side: "long", MarginMode: "cross", OpenedAt: time.Now(), LastKnownSize: lastKnown,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setLeader := func(e *Engine, sizes map[string]float64) {
+		positions := make(map[string]*Position)
+		for posID, size := range sizes {
+			positions[posID] = &Position{PosID: posID, Symbol: "BTCUSDT", Side: SideLong, Size: size, MarginMode: "cross"}
+		}
+		e.leaderState = &AccountState{TotalEquity: 10000, Positions: positions}
+	}
+	match := func(e *Engine, action ActionType) *SignalMatchResult {
+		return e.matchSignalWithMapping(&TradeSignal{Fill: &Fill{Symbol: "BTCUSDT", PositionSide: SideLong, Action: action}})
+	}
+
+	// OKX：多个 posId 并存
+	okx := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader"}, 1000)
+	okx.store = st
+	saveActive("A", 1)
+	saveActive("B", 2)
+	if err := ct.SaveIgnoredPosition("test", "leader", "C", "BTCUSDT", "long", "cross"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 加仓：取 size 增加的仓位
+	setLeader(okx, map[string]float64{"A": 1, "B": 3, "C": 5})
+	if r := match(okx, ActionAdd); !r.ShouldFollow || r.Action != ActionAdd || r.PosID != "B" {
+		t.Errorf("size increase: got %+v, want add B", r)
+	}
+
+	// 新开仓：无映射的 posId 优先
+	setLeader(okx, map[string]float64{"A": 1, "B": 3, "C": 5, "D": 1})
+	if r := match(okx, ActionOpen); !r.ShouldFollow || r.Action != ActionOpen || r.PosID != "D" {
+		t.Errorf("new posId: got %+v, want open D", r)
+	}
+
+	// 兜底：只有一个 active 仓位（ignored 不计入）
+	setLeader(okx, map[string]float64{"A": 1, "C": 5})
+	if r := match(okx, ActionAdd); !r.ShouldFollow || r.Action != ActionAdd || r.PosID != "A" {
+		t.Errorf("single active: got %+v, want add A", r)
+	}
+
+	// 严格匹配：唯一 active 仓位 size 无变化时不兜底
+	withConfig(okx, func(c *CopyConfig) { c.StrictAddMatching = true })
+	if r := match(okx, ActionAdd); r.ShouldFollow {
+		t.Errorf("strict single active: got %+v, want skip", r)
+	}
+	setLeader(okx, map[string]float64{"A": 2, "C": 5})
+	if r := match(okx, ActionAdd); !r.ShouldFollow || r.PosID != "A" {
+		t.Errorf("strict size increase: got %+v, want add A", r)
+	}
+	withConfig(okx, func(c *CopyConfig) { c.StrictAddMatching = false })
+
+	// 多个 active 且无 size 变化：不跟随
+	setLeader(okx, map[string]float64{"A": 1, "B": 2})
+	if r := match(okx, ActionAdd); r.ShouldFollow {
+		t.Errorf("ambiguous add: got %+v, want skip", r)
+	}
+
+	// OKX：ignored posId 永不跟随
+	setLeader(okx, map[string]float64{"C": 6})
+	if r := match(okx, ActionOpen); r.ShouldFollow {
+		t.Errorf("okx ignored: got %+v, want skip", r)
+	}
+
+	// Hyperliquid：ignored 虚拟 posId 重新开仓跟随，加仓不跟随
+	hl := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader"}, 1000)
+	hl.store = st
+	hlKey := PositionKey("ETHUSDT", SideLong)
+	if err := ct.SaveIgnoredPosition("test", "leader", hlKey, "ETHUSDT", "long", "cross"); err != nil {
+		t.Fatal(err)
+	}
+	hl.leaderState = &AccountState{TotalEquity: 10000, Positions: map[string]*Position{
+		hlKey: {Symbol: "ETHUSDT", Side: SideLong, Size: 1, MarginMode: "cross"},
+	}}
+	ethFill := func(action ActionType) *TradeSignal {
+		return &TradeSignal{Fill: &Fill{Symbol: "ETHUSDT", PositionSide: SideLong, Action: action}}
+	}
+	if r := hl.matchSignalWithMapping(ethFill(ActionOpen)); !r.ShouldFollow || r.Action != ActionOpen || r.PosID != hlKey {
+		t.Errorf("hl ignored reopen: got %+v, want open %s", r, hlKey)
+	}
+	if r := hl.matchSignalWithMapping(ethFill(ActionAdd)); r.ShouldFollow {
+		t.Errorf("hl ignored add: got %+v, want skip", r)
+	}
+}
+
+// TestRapidCloseReopen 领航员平仓后立即同方向重新开仓（Hyperliquid 复用虚拟 posId）
+func TestRapidCloseReopen(t *testing.T) {
+	posID := PositionKey("BTCUSDT", SideLong)
+	openFill := func(id string) *Fill {
+		return &Fill{ID: id, Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 1, Value: 100}
+	}
+	closeFill := &Fill{ID: "close", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionClose, Price: 110, Size: 1, Value: 110}
+
+	setup := func(cooldown int) (*Engine, *TraderIntegration, *fakeProvider, *store.CopyTradeStore) {
+		st := newTestStore(t)
+		provider := &fakeProvider{}
+		e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1, ReopenCooldownSeconds: cooldown}, 1000)
+		e.store = st
+		e.provider = provider
+		return e, &TraderIntegration{traderID: "test", store: st, engine: e}, provider, st.CopyTrade()
+	}
+	run := func(e *Engine, ti *TraderIntegration, fill *Fill) *decision.Decision {
+		e.processSignal(&TradeSignal{Fill: fill})
+		select {
+		case fullDec := <-e.decisionCh:
+			dec := fullDec.Decisions[0]
+			ti.updatePositionMapping(&dec)
+			return &dec
+		default:
+			return nil
+		}
+	}
+	openClose := func(e *Engine, ti *TraderIntegration, provider *fakeProvider) {
+		t.Helper()
+		provider.setSize(1)
+		if dec := run(e, ti, openFill("open1")); dec == nil || dec.Action != "open_long" {
+			t.Fatalf("first open = %+v", dec)
+		}
+		provider.state.Positions = map[string]*Position{}
+		if dec := run(e, ti, closeFill); dec == nil || dec.Action != "close_long" {
+			t.Fatalf("close = %+v", dec)
+		}
+	}
+
+	// 默认：立即重新开仓跟随，产生两条独立的生命周期记录
+	e, ti, provider, ct := setup(0)
+	openClose(e, ti, provider)
+	provider.setSize(2)
+	if dec := run(e, ti, openFill("open2")); dec == nil || dec.Action != "open_long" {
+		t.Fatalf("reopen = %+v, want open_long", dec)
+	}
+	all, err := ct.ListAllMappings("test", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("mappings = %d, want 2 lifecycle records", len(all))
+	}
+	active, _ := ct.GetActiveMapping("test", posID)
+	if active == nil || active.LastKnownSize != 2 || active.ClosedAt != nil || active.AddCount != 0 {
+		t.Errorf("reopened mapping not reset: %+v", active)
+	}
+
+	// 冷却期内：不跟随重新开仓，之后的加仓也不跟随
+	e, ti, provider, ct = setup(60)
+	openClose(e, ti, provider)
+	provider.setSize(1)
+	if dec := run(e, ti, openFill("open2")); dec != nil {
+		t.Fatalf("reopen within cooldown followed: %+v", dec)
+	}
+	provider.setSize(2)
+	if dec := run(e, ti, &Fill{ID: "add", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionAdd, Price: 100, Size: 1, Value: 100}); dec != nil {
+		t.Fatalf("add after skipped reopen followed: %+v", dec)
+	}
+	if m, _ := ct.GetMapping("test", posID); m == nil || m.Status != "ignored" {
+		t.Errorf("skipped reopen mapping = %+v, want ignored", m)
+	}
+}
+
+// TestDuplicateDecisionSuppressed 窗口内完全相同的决策只推送一次
+func TestDuplicateDecisionSuppressed(t *testing.T) {
+	emit := func(window int, prices ...float64) int {
+		e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1, DuplicateDecisionWindowSec: window}, 1000)
+		e.store = newTestStore(t)
+		provider := &fakeProvider{}
+		provider.setSize(1)
+		e.provider = provider
+		for i, price := range prices {
+			// 不同 fill id（fill 去重无法拦截），映射未更新 → 同一逻辑开仓被重复生成
+			e.processSignal(&TradeSignal{Fill: &Fill{ID: fmt.Sprintf("f%d", i), Symbol: "BTCUSDT", Side: "buy",
+				PositionSide: SideLong, Action: ActionOpen, Price: price, Size: 1, Value: price}})
+		}
+		return len(e.decisionCh)
+	}
+
+	if n := emit(0, 100, 100); n != 2 {
+		t.Errorf("window off: decisions = %d, want 2", n)
+	}
+	if n := emit(30, 100, 100); n != 1 {
+		t.Errorf("identical decisions: decisions = %d, want 1", n)
+	}
+	if n := emit(30, 100, 101); n != 2 {
+		t.Errorf("different entry price: decisions = %d, want 2", n)
+	}
+}
+
+// TestClosedPnLInference 方向不确定的成交由 ClosedPnL 判断开/平
+func TestClosedPnLInference(t *testing.T) {
+	run := func(inference bool, closedPnL float64) string {
+		st := newTestStore(t)
+		provider := &fakeProvider{}
+		e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1, ClosedPnLInference: inference}, 1000)
+		e.store = st
+		e.provider = provider
+		if err := st.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+			TraderID: "test", LeaderPosID: PositionKey("BTCUSDT", SideLong), LeaderID: "leader", Symbol: "BTCUSDT",
+			Side: "long", MarginMode: "cross", OpenedAt: time.Now(), LastKnownSize: 2,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		// 领航员多仓 2 → 1，但 dir 未知：provider 兜底解析为卖出加空
+		provider.setSize(1)
+		fill := &Fill{ID: "f1", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideShort, Action: ActionAdd,
+			AmbiguousDir: true, ClosedPnL: closedPnL, Price: 100, Size: 1, Value: 100}
+		e.processSignal(&TradeSignal{Fill: fill})
+		select {
+		case fullDec := <-e.decisionCh:
+			return fullDec.Decisions[0].Action
+		default:
+			return ""
+		}
+	}
+
+	if action := run(false, 3.5); action != "" {
+		t.Errorf("inference off: action = %q, want skipped", action)
+	}
+	if action := run(true, 3.5); action != "reduce_long" {
+		t.Errorf("non-zero ClosedPnL: action = %q, want reduce_long", action)
+	}
+	if action := run(true, 0); action != "" {
+		t.Errorf("zero ClosedPnL keeps open/add: action = %q, want skipped", action)
+	}
+
+	// 方向明确的成交不受影响
+	e := newTestEngine(&CopyConfig{ClosedPnLInference: true}, 1000)
+	fill := &Fill{Side: "buy", PositionSide: SideShort, Action: ActionAdd, ClosedPnL: -2}
+	e.applyClosedPnLHint(fill)
+	if fill.Action != ActionAdd || fill.PositionSide != SideShort {
+		t.Errorf("unambiguous fill changed: %+v", fill)
+	}
+	// 单向持仓：买入 + 非零 ClosedPnL = 平空
+	fill = &Fill{Side: "buy", PositionSide: SideLong, Action: ActionOpen, NetMode: true, ClosedPnL: 1}
+	e.applyClosedPnLHint(fill)
+	if fill.Action != ActionClose || fill.PositionSide != SideShort {
+		t.Errorf("net mode buy with pnl = %+v, want close short", fill)
+	}
+}
+
+// fakeExecutor 可控的决策执行器
+type fakeExecutor struct {
+	err      error
+	executed []decision.Decision
+}
+
+func (f *fakeExecutor) ExecuteDecision(dec *decision.Decision) error {
+	f.executed = append(f.executed, *dec)
+	return f.err
+}
+func (f *fakeExecutor) GetAccountInfo() (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+func (f *fakeExecutor) GetPositions() ([]map[string]interface{}, error) { return nil, nil }
+
+// TestLimitEntry 限价入场：开仓决策携带领航员成交价；超时未成交的新开仓标记为 ignored
+func TestLimitEntry(t *testing.T) {
+	st := newTestStore(t)
+	provider := &fakeProvider{}
+	provider.setSize(1)
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1,
+		EntryOrderType: EntryOrderLimit, LimitTimeoutSeconds: 15}, 1000)
+	e.store = st
+	e.provider = provider
+
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "f1", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong,
+		Action: ActionOpen, Price: 101.5, Size: 1, Value: 101.5}})
+	fullDec := <-e.decisionCh
+	dec := fullDec.Decisions[0]
+	if dec.OrderType != decision.OrderTypeLimit || dec.LimitPrice != 101.5 || dec.LimitTimeoutSec != 15 ||
+		dec.LimitTimeoutAction != LimitTimeoutCancel {
+		t.Fatalf("limit fields = %+v", dec)
+	}
+
+	// 执行器报告限价未成交 → 不建立映射，仓位标记为 ignored，信号记为 skipped
+	exec := &fakeExecutor{err: fmt.Errorf("okx: %w", decision.ErrLimitNotFilled)}
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e, executor: exec}
+	ti.executeFullDecision(fullDec)
+
+	posID := PositionKey("BTCUSDT", SideLong)
+	m, err := st.CopyTrade().GetMapping("test", posID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m.Status != "ignored" {
+		t.Fatalf("mapping = %+v, want ignored", m)
+	}
+	logs, err := st.CopyTrade().GetRecentSignalLogs("test", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].Status != "skipped" {
+		t.Fatalf("signal logs = %+v, want one skipped", logs)
+	}
+
+	// 后续加仓不再跟随
+	provider.setSize(2)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "f2", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong,
+		Action: ActionAdd, Price: 102, Size: 1, Value: 102}})
+	if n := len(e.decisionCh); n != 0 {
+		t.Errorf("add after missed limit entry: decisions = %d, want 0", n)
+	}
+
+	// 市价（默认）不携带限价字段
+	withConfig(e, func(c *CopyConfig) { c.EntryOrderType = "" })
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "f3", Symbol: "ETHUSDT", Side: "buy", PositionSide: SideLong,
+		Action: ActionOpen, Price: 10, Size: 1, Value: 10}})
+	select {
+	case fd := <-e.decisionCh:
+		if fd.Decisions[0].OrderType != "" || fd.Decisions[0].LimitPrice != 0 {
+			t.Errorf("market decision has limit fields: %+v", fd.Decisions[0])
+		}
+	default:
+	}
+}
+
+// freshProvider 支持同步刷新的领航员数据源（统计刷新次数）
+type freshProvider struct {
+	*fakeProvider
+	freshEquity  float64
+	freshFetches int
+}
+
+func (p *freshProvider) GetAccountStateFresh(leaderID string) (*AccountState, error) {
+	p.freshFetches++
+	return &AccountState{TotalEquity: p.freshEquity}, nil
+}
+
+// TestFreshEquityOnOpen 开仓前同步刷新领航员权益，加仓/减仓/平仓不刷新
+func TestFreshEquityOnOpen(t *testing.T) {
+	st := newTestStore(t)
+	provider := &freshProvider{fakeProvider: &fakeProvider{}, freshEquity: 5000}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1, FreshEquityOnOpen: true}, 1000)
+	e.store = st
+	e.provider = provider
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e}
+
+	run := func(fill *Fill) decision.Decision {
+		t.Helper()
+		e.processSignal(&TradeSignal{Fill: fill})
+		select {
+		case fullDec := <-e.decisionCh:
+			dec := fullDec.Decisions[0]
+			ti.updatePositionMapping(&dec)
+			return dec
+		default:
+			t.Fatalf("no decision for %s", fill.ID)
+			return decision.Decision{}
+		}
+	}
+
+	// 开仓：缓存权益 10000，刷新后 5000 → 领航员 1 BTC @100 占 2%，跟单 20 USDT
+	provider.setSize(1)
+	dec := run(&Fill{ID: "open", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 1, Value: 100})
+	if provider.freshFetches != 1 {
+		t.Fatalf("fresh fetches after open = %d, want 1", provider.freshFetches)
+	}
+	if math.Abs(dec.PositionSizeUSD-20) > 1e-9 {
+		t.Errorf("open size = %.4f, want 20 (fresh equity)", dec.PositionSizeUSD)
+	}
+
+	// 加仓 / 减仓 / 平仓使用缓存
+	provider.setSize(3)
+	run(&Fill{ID: "add", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionAdd, Price: 100, Size: 2, Value: 200})
+	provider.setSize(1)
+	run(&Fill{ID: "reduce", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionReduce, Price: 100, Size: 2, Value: 200})
+	provider.state.Positions = map[string]*Position{}
+	run(&Fill{ID: "close", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionClose, Price: 100, Size: 1, Value: 100})
+	if provider.freshFetches != 1 {
+		t.Errorf("fresh fetches = %d, want 1 (opens only)", provider.freshFetches)
+	}
+
+	// 关闭选项后开仓也不刷新
+	withConfig(e, func(c *CopyConfig) { c.FreshEquityOnOpen = false })
+	provider.setSize(1)
+	run(&Fill{ID: "reopen", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 1, Value: 100})
+	if provider.freshFetches != 1 {
+		t.Errorf("fresh fetches with option off = %d, want 1", provider.freshFetches)
+	}
+}
+
+// TestScaleOutProportional 领航员分批止盈：前几笔按比例减仓，最后留下零头时平掉剩余仓位
+func TestScaleOutProportional(t *testing.T) {
+	scenario := func(t *testing.T, followerSize float64, leaderSizes []float64) []decision.Decision {
+		t.Helper()
+		st := newTestStore(t)
+		provider := &fakeProvider{}
+		e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1}, 1000)
+		e.store = st
+		e.provider = provider
+		follower := &Position{Symbol: "BTCUSDT", Side: SideLong, MarginMode: "cross"}
+		e.getFollowerPositions = func() map[string]*Position {
+			return map[string]*Position{PositionKey("BTCUSDT", SideLong): follower}
+		}
+		ti := &TraderIntegration{traderID: "test", store: st, engine: e}
+
+		// 开仓后跟随者持有 followerSize
+		provider.setSize(leaderSizes[0])
+		e.processSignal(&TradeSignal{Fill: &Fill{ID: "open", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: leaderSizes[0], Value: leaderSizes[0] * 100}})
+		open := (<-e.decisionCh).Decisions[0]
+		ti.updatePositionMapping(&open)
+		follower.Size = followerSize
+
+		var decs []decision.Decision
+		for i := 1; i < len(leaderSizes); i++ {
+			prev, cur := leaderSizes[i-1], leaderSizes[i]
+			action := ActionReduce
+			if cur == 0 {
+				action = ActionClose
+				provider.state.Positions = map[string]*Position{}
+			} else {
+				provider.setSize(cur)
+			}
+			e.processSignal(&TradeSignal{Fill: &Fill{ID: fmt.Sprintf("r%d", i), Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: action, Price: 100, Size: prev - cur, Value: (prev - cur) * 100}})
+			select {
+			case fullDec := <-e.decisionCh:
+				dec := fullDec.Decisions[0]
+				ti.updatePositionMapping(&dec)
+				decs = append(decs, dec)
+				// 模拟执行：按比例减少跟随者持仓，平仓清零
+				if dec.CloseRatio > 0 {
+					follower.Size *= 1 - dec.CloseRatio
+				} else {
+					follower.Size = 0
+				}
+			default:
+			}
+		}
+		return decs
+	}
+
+	// 25/25/25/25 分批止盈，跟随者只有 10 USDT：第三笔后剩余 2.5 USDT 为零头 → 平掉剩余，最后一笔不再跟随
+	decs := scenario(t, 0.1, []float64{4, 3, 2, 1, 0})
+	if len(decs) != 3 {
+		t.Fatalf("decisions = %d, want 3 (reduce, reduce, close remainder)", len(decs))
+	}
+	if decs[0].Action != "reduce_long" || math.Abs(decs[0].CloseRatio-0.25) > 1e-9 {
+		t.Errorf("first reduce = %s %.4f, want reduce_long 0.25", decs[0].Action, decs[0].CloseRatio)
+	}
+	if decs[1].Action != "reduce_long" || math.Abs(decs[1].CloseRatio-1.0/3) > 1e-9 {
+		t.Errorf("second reduce = %s %.4f, want reduce_long 0.3333", decs[1].Action, decs[1].CloseRatio)
+	}
+	if decs[2].Action != "close_long" || decs[2].CloseReason != CloseReasonScaleOut {
+		t.Errorf("third reduce = %s reason=%q, want close_long scale_out", decs[2].Action, decs[2].CloseReason)
+	}
+
+	// 跟随者仓位足够大：按比例跟随到底，最后一笔随领航员平仓
+	decs = scenario(t, 10, []float64{4, 3, 2, 1, 0})
+	if len(decs) != 4 {
+		t.Fatalf("large follower decisions = %d, want 4", len(decs))
+	}
+	for i, want := range []float64{0.25, 1.0 / 3, 0.5} {
+		if decs[i].Action != "reduce_long" || math.Abs(decs[i].CloseRatio-want) > 1e-9 {
+			t.Errorf("reduce %d = %s %.4f, want reduce_long %.4f", i, decs[i].Action, decs[i].CloseRatio, want)
+		}
+	}
+	if decs[3].Action != "close_long" || decs[3].CloseReason != "" {
+		t.Errorf("final = %s reason=%q, want close_long following leader", decs[3].Action, decs[3].CloseReason)
+	}
+
+	// 40/30/25：单笔比例未到 95%，但本轮累计减仓 95% → 平掉剩余
+	decs = scenario(t, 10, []float64{20, 12, 6, 1})
+	if len(decs) != 3 {
+		t.Fatalf("cumulative decisions = %d, want 3", len(decs))
+	}
+	if decs[1].Action != "reduce_long" || math.Abs(decs[1].CloseRatio-0.5) > 1e-9 {
+		t.Errorf("second reduce = %s %.4f, want reduce_long 0.5", decs[1].Action, decs[1].CloseRatio)
+	}
+	if decs[2].Action != "close_long" || decs[2].CloseReason != CloseReasonScaleOut {
+		t.Errorf("final reduce = %s reason=%q, want close_long scale_out", decs[2].Action, decs[2].CloseReason)
+	}
+}
+
+// TestNegativeEquityExits 跟随者权益为负：不开仓/加仓，减仓/平仓照常跟随（pause_all 时全部暂停）
+func TestNegativeEquityExits(t *testing.T) {
+	st := newTestStore(t)
+	provider := &fakeProvider{}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.store = st
+	e.provider = provider
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e}
+	equity := 1000.0
+	e.getFollowerBalance = func() float64 { return equity }
+
+	next := func() *decision.Decision {
+		select {
+		case fullDec := <-e.decisionCh:
+			dec := fullDec.Decisions[0]
+			ti.updatePositionMapping(&dec)
+			return &dec
+		default:
+			return nil
+		}
+	}
+
+	provider.setSize(4)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "open", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 4, Value: 400}})
+	if dec := next(); dec == nil || dec.Action != "open_long" {
+		t.Fatalf("open = %+v, want open_long", dec)
+	}
+
+	// 权益转负：加仓跳过并预警
+	equity = -50
+	provider.setSize(5)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "add", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionAdd, Price: 100, Size: 1, Value: 100}})
+	if dec := next(); dec != nil {
+		t.Fatalf("add with negative equity = %+v, want skip", dec)
+	}
+	warnings := e.warnings
+	if len(warnings) == 0 || warnings[len(warnings)-1].Type != "negative_balance" {
+		t.Fatalf("warnings = %+v, want negative_balance", warnings)
+	}
+
+	// 减仓照常跟随
+	provider.setSize(2)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "reduce", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionReduce, Price: 100, Size: 2, Value: 200}})
+	if dec := next(); dec == nil || dec.Action != "reduce_long" {
+		t.Fatalf("reduce with negative equity = %+v, want reduce_long", dec)
+	}
+
+	// pause_all：平仓也不跟随
+	withConfig(e, func(c *CopyConfig) { c.NegativeEquityPolicy = NegativeEquityPauseAll })
+	provider.state.Positions = map[string]*Position{}
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "close", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionClose, Price: 100, Size: 2, Value: 200}})
+	if dec := next(); dec != nil {
+		t.Fatalf("close with pause_all = %+v, want skip", dec)
+	}
+
+	// 默认策略：平仓照常跟随
+	withConfig(e, func(c *CopyConfig) { c.NegativeEquityPolicy = "" })
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "close2", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionClose, Price: 100, Size: 2, Value: 200}})
+	if dec := next(); dec == nil || dec.Action != "close_long" {
+		t.Fatalf("close with negative equity = %+v, want close_long", dec)
+	}
+}
+
+// TestSymbolMaxBaseSize 单币种最大持仓：按现有持仓 + 本次数量限制开仓/加仓并预警
+func TestSymbolMaxBaseSize(t *testing.T) {
+	st := newTestStore(t)
+	provider := &fakeProvider{}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1,
+		SymbolMaxBaseSize: map[string]float64{"BTCUSDT": 1.5}}, 10000)
+	e.store = st
+	e.provider = provider
+	follower := &Position{Symbol: "BTCUSDT", Side: SideLong, MarginMode: "cross"}
+	e.getFollowerPositions = func() map[string]*Position {
+		return map[string]*Position{PositionKey("BTCUSDT", SideLong): follower}
+	}
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e}
+
+	next := func() *decision.Decision {
+		select {
+		case fullDec := <-e.decisionCh:
+			dec := fullDec.Decisions[0]
+			ti.updatePositionMapping(&dec)
+			return &dec
+		default:
+			return nil
+		}
+	}
+
+	// 领航员 10 BTC @100（权益 10000，占比 10%）→ 跟单 1000 USDT = 10 BTC，限制到 1.5 BTC
+	provider.setSize(10)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "open", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 10, Value: 1000}})
+	dec := next()
+	if dec == nil || math.Abs(dec.PositionSizeUSD-150) > 1e-9 {
+		t.Fatalf("open = %+v, want 150 USDT (1.5 BTC)", dec)
+	}
+	if len(e.warnings) == 0 || e.warnings[len(e.warnings)-1].Type != "symbol_size_capped" {
+		t.Fatalf("warnings = %+v, want symbol_size_capped", e.warnings)
+	}
+
+	// 已达上限：加仓不跟随
+	follower.Size = 1.5
+	provider.setSize(12)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "add", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionAdd, Price: 100, Size: 2, Value: 200}})
+	if dec := next(); dec != nil {
+		t.Fatalf("add at cap = %+v, want skip", dec)
+	}
+
+	// 其他币种不受影响
+	if got := e.capSymbolBaseSize(&TradeSignal{Fill: &Fill{Symbol: "ETHUSDT", PositionSide: SideLong, Price: 10}}, &SignalMatchResult{}, 500); got != 500 {
+		t.Errorf("uncapped symbol size = %.2f, want 500", got)
+	}
+}
+
+// TestAccountDedupScope 同一跟随者账户的两个引擎跟随同一领航员：account 范围只有一个引擎处理成交
+func TestAccountDedupScope(t *testing.T) {
+	newEngine := func(traderID, account, scope string) *Engine {
+		e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", DedupScope: scope}, 1000)
+		e.traderID = traderID
+		WithFollowerAccount(account)(e)
+		return e
+	}
+	fill := &Fill{ID: "fill-1", Symbol: "BTCUSDT"}
+
+	// 默认（engine 范围）：各自处理
+	a, b := newEngine("a", "acct-engine", ""), newEngine("b", "acct-engine", "")
+	if !a.claimFill(fill) || !b.claimFill(fill) {
+		t.Error("engine scope: both engines should process the fill")
+	}
+
+	// account 范围：先认领者处理，同一引擎重复认领不受影响
+	a, b = newEngine("a", "acct-shared", DedupScopeAccount), newEngine("b", "acct-shared", DedupScopeAccount)
+	if !a.claimFill(fill) {
+		t.Fatal("first engine should claim the fill")
+	}
+	if b.claimFill(fill) {
+		t.Error("second engine on the same account should skip the fill")
+	}
+	if !a.claimFill(fill) {
+		t.Error("claiming engine should keep the fill")
+	}
+	if b.claimFill(fill) {
+		t.Error("second engine should still skip")
+	}
+	if len(b.warnings) != 1 || b.warnings[0].Type != "duplicate_follower_account" {
+		t.Errorf("warnings = %+v, want one duplicate_follower_account", b.warnings)
+	}
+
+	// 不同账户互不影响
+	c := newEngine("c", "acct-other", DedupScopeAccount)
+	if !c.claimFill(fill) {
+		t.Error("engine on another account should process the fill")
+	}
+}
+
+// TestLogWarningBoundedAndPersisted 内存预警有上限，完整记录写入数据库；WarningHistoryLimit<0 时不写入
+func TestLogWarningBoundedAndPersisted(t *testing.T) {
+	st := newTestStore(t)
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader"}, 1000)
+	e.store = st
+
+	for i := 0; i < maxMemoryWarnings+50; i++ {
+		e.logWarning(Warning{Timestamp: time.Now(), Symbol: "BTCUSDT", Type: "low_value", Message: fmt.Sprintf("w%d", i)})
+	}
+	if len(e.warnings) != maxMemoryWarnings || e.warnings[0].Message != "w50" {
+		t.Errorf("in-memory warnings = %d (first %s), want %d starting at w50", len(e.warnings), e.warnings[0].Message, maxMemoryWarnings)
+	}
+	if e.stats.WarningsCount != maxMemoryWarnings+50 {
+		t.Errorf("WarningsCount = %d, want %d", e.stats.WarningsCount, maxMemoryWarnings+50)
+	}
+	saved, err := st.CopyTrade().GetRecentWarnings("test", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != maxMemoryWarnings+50 || saved[0].Message != fmt.Sprintf("w%d", maxMemoryWarnings+49) {
+		t.Errorf("persisted warnings = %d, want %d newest first", len(saved), maxMemoryWarnings+50)
+	}
+
+	withConfig(e, func(c *CopyConfig) { c.WarningHistoryLimit = -1 })
+	e.logWarning(Warning{Timestamp: time.Now(), Type: "low_value", Message: "not saved"})
+	if after, _ := st.CopyTrade().GetRecentWarnings("test", "", 1000); len(after) != len(saved) {
+		t.Errorf("persisted with limit<0: %d, want %d", len(after), len(saved))
+	}
+}
+
+// TestEffectivePollInterval 未设置或低于 1 秒时使用默认 3 秒；WithPollInterval 覆盖配置
+func TestEffectivePollInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		want     time.Duration
+		window   time.Duration
+	}{
+		{"unset", 0, DefaultPollInterval, time.Minute},
+		{"below minimum", 200 * time.Millisecond, DefaultPollInterval, time.Minute},
+		{"custom", 10 * time.Second, 10 * time.Second, time.Minute},
+		{"slow poll widens window", 30 * time.Second, 30 * time.Second, 90 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEngine(&CopyConfig{}, 1000)
+			WithPollInterval(tt.interval)(e)
+			if got := e.effectivePollInterval(); got != tt.want {
+				t.Errorf("effectivePollInterval() = %s, want %s", got, tt.want)
+			}
+			if got := e.pollWindow(); got != tt.window {
+				t.Errorf("pollWindow() = %s, want %s", got, tt.window)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package copytrade
+
+import (
+	"sync"
+	"time"
+
+	"nofx/logger"
+	"nofx/store"
+)
+
+// ============================================================================
+// 权益快照合并写入
+// ============================================================================
+//
+// 每次执行决策后都会保存一条权益快照，领航员连续成交时会写入大量几乎相同的快照，加剧 SQLite 写竞争。
+// 快照按间隔合并：距上次写入超过间隔立即写入；否则只保留最新一条，到期后由定时器写入。
+// 停止跟单时写入尚未落库的快照，净值曲线不会缺少最后的状态。
+
+// DefaultEquitySnapshotInterval 权益快照默认最小写入间隔
+const DefaultEquitySnapshotInterval = 10 * time.Second
+
+// equitySnapshotBatcher 权益快照合并写入器
+type equitySnapshotBatcher struct {
+	mu        sync.Mutex
+	lastWrite time.Time
+	pending   *store.EquitySnapshot
+	timer     *time.Timer
+}
+
+// equitySnapshotInterval 权益快照最小写入间隔（配置 <0 时每次都写入）
+func equitySnapshotInterval(cfg *CopyConfig) time.Duration {
+	switch {
+	case cfg == nil || cfg.EquitySnapshotIntervalSeconds == 0:
+		return DefaultEquitySnapshotInterval
+	case cfg.EquitySnapshotIntervalSeconds < 0:
+		return 0
+	default:
+		return time.Duration(cfg.EquitySnapshotIntervalSeconds) * time.Second
+	}
+}
+
+// submit 提交快照：到达间隔立即写入，否则替换待写入快照并在间隔到期后写入
+func (b *equitySnapshotBatcher) submit(snapshot *store.EquitySnapshot, interval time.Duration, save func(*store.EquitySnapshot)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wait := interval - time.Since(b.lastWrite)
+	if interval <= 0 || wait <= 0 {
+		b.pending = nil
+		b.stopTimer()
+		b.lastWrite = time.Now()
+		save(snapshot)
+		return
+	}
+
+	b.pending = snapshot
+	if b.timer == nil {
+		b.timer = time.AfterFunc(wait, func() { b.flush(save) })
+	}
+}
+
+// flush 写入待写入的快照（没有时忽略）
+func (b *equitySnapshotBatcher) flush(save func(*store.EquitySnapshot)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stopTimer()
+	if b.pending == nil {
+		return
+	}
+	snapshot := b.pending
+	b.pending = nil
+	b.lastWrite = time.Now()
+	save(snapshot)
+}
+
+// stopTimer 取消定时写入（调用方持有锁）
+func (b *equitySnapshotBatcher) stopTimer() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+}
+
+// writeEquitySnapshot 写入权益快照
+func (ti *TraderIntegration) writeEquitySnapshot(snapshot *store.EquitySnapshot) {
+	if err := ti.store.Equity().Save(snapshot); err != nil {
+		logger.Warnf("⚠️ [%s] 保存权益快照失败: %v", ti.traderID, err)
+	} else {
+		logger.Debugf("💾 [%s] 权益快照已保存: equity=%.2f", ti.traderID, snapshot.TotalEquity)
+	}
+}
+
+// flushEquitySnapshot 写入合并中尚未落库的权益快照
+func (ti *TraderIntegration) flushEquitySnapshot() {
+	if ti.store == nil {
+		return
+	}
+	ti.equitySnapshots.flush(ti.writeEquitySnapshot)
+}
@@ -0,0 +1,70 @@
+package copytrade
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nofx/store"
+)
+
+// TestEquitySnapshotBatching 连续执行多次决策：间隔内的快照合并为一条，停止时写入最后一条
+func TestEquitySnapshotBatching(t *testing.T) {
+	st := newTestStore(t)
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", EquitySnapshotIntervalSeconds: 3600}, 1000)
+	e.running = true
+	e.stopCh = make(chan struct{})
+	_, cancel := context.WithCancel(context.Background())
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e, cancel: cancel}
+	ti.running.Store(true)
+
+	for i := 0; i < 20; i++ {
+		ti.saveEquitySnapshot(1000+float64(i), 500, 0, 1)
+	}
+	if n, _ := st.Equity().GetCount("test"); n != 1 {
+		t.Fatalf("snapshots after burst = %d, want 1", n)
+	}
+
+	ti.Stop()
+	snaps, err := st.Equity().GetLatest("test", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 2 {
+		t.Fatalf("snapshots after stop = %d, want 2", len(snaps))
+	}
+	latest := snaps[0]
+	if snaps[1].TotalEquity > latest.TotalEquity {
+		latest = snaps[1]
+	}
+	if latest.TotalEquity != 1019 {
+		t.Errorf("flushed equity = %.0f, want latest 1019", latest.TotalEquity)
+	}
+
+	// 间隔到期后由定时器写入
+	var b equitySnapshotBatcher
+	saved := make(chan float64, 10)
+	save := func(s *store.EquitySnapshot) { saved <- s.TotalEquity }
+	for i := 0; i < 5; i++ {
+		b.submit(&store.EquitySnapshot{TotalEquity: float64(i)}, 50*time.Millisecond, save)
+	}
+	if got := <-saved; got != 0 {
+		t.Errorf("first write = %.0f, want 0", got)
+	}
+	select {
+	case got := <-saved:
+		if got != 4 {
+			t.Errorf("timer write = %.0f, want latest 4", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pending snapshot not written after interval")
+	}
+	if len(saved) != 0 {
+		t.Errorf("extra writes = %d", len(saved))
+	}
+
+	// <0：每次都写入
+	if got := equitySnapshotInterval(&CopyConfig{EquitySnapshotIntervalSeconds: -1}); got != 0 {
+		t.Errorf("disabled interval = %v, want 0", got)
+	}
+}
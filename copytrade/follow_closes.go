@@ -0,0 +1,62 @@
+package copytrade
+
+import (
+	"nofx/logger"
+)
+
+// ============================================================================
+// 只跟开仓（不跟随领航员平仓）
+// ============================================================================
+//
+// FollowCloses=false 时跟随者只用领航员的开仓/加仓信号入场，出场自行管理：
+//   - 减仓/平仓照常匹配映射，但不跟随（原因 close following disabled）
+//   - 减仓：更新映射的 last_known_size，之后的加仓仍按 size 变化精确匹配
+//   - 平仓：映射标记为 detached（跟随者仍持仓），不再匹配该仓位的任何操作；
+//     与 closed 一样不会被映射查询返回，Hyperliquid 复用 posId 重新开仓时视为新开仓（旧映射归档）
+// detached 映射不计入活跃仓位，跟随者手中的仓位在映射漂移检查中显示为 untracked。
+
+// SkipReasonCloseFollowingDisabled 不跟随平仓
+const SkipReasonCloseFollowingDisabled = "close following disabled"
+
+// MappingStatusDetached 领航员已平仓、跟随者自行管理的映射状态
+const MappingStatusDetached = "detached"
+
+// followsCloses 是否跟随领航员减仓/平仓（未配置 = 跟随）
+func (c *CopyConfig) followsCloses() bool {
+	return c.FollowCloses == nil || *c.FollowCloses
+}
+
+// skipCloseFollowing 不跟随减仓/平仓：更新映射反映领航员的操作，返回不跟随的匹配结果
+func (e *Engine) skipCloseFollowing(fill *Fill, match *SignalMatchResult) *SignalMatchResult {
+	if !e.simulation { // 模拟成交不写映射
+		switch match.Action {
+		case ActionClose:
+			if err := e.store.CopyTrade().DetachMapping(e.traderID, match.PosID, fill.Price); err != nil {
+				logger.Warnf("⚠️ [%s] 标记 detached 映射失败: %v (posId=%s)", e.traderID, err, match.PosID)
+			}
+		case ActionReduce:
+			if match.LeaderPosition != nil {
+				if err := e.store.CopyTrade().UpdateLastKnownSize(e.traderID, match.PosID, match.LeaderPosition.Size); err != nil {
+					logger.Warnf("⚠️ [%s] 更新 lastKnownSize 失败: %v (posId=%s)", e.traderID, err, match.PosID)
+				}
+			}
+		}
+	}
+
+	logger.Infof("📊 [%s] 领航员%s | posId=%s → 不跟随（%s）", e.traderID, match.Action, match.PosID, SkipReasonCloseFollowingDisabled)
+	return &SignalMatchResult{
+		ShouldFollow: false,
+		Reason:       SkipReasonCloseFollowingDisabled,
+		Action:       match.Action,
+		PosID:        match.PosID,
+		MarginMode:   match.MarginMode,
+	}
+}
+
+// followClosesPromptLog 只跟开仓模式说明（跟随平仓时为空）
+func (e *Engine) followClosesPromptLog() string {
+	if e.cfg().followsCloses() {
+		return ""
+	}
+	return "- Exits: open-only mode (leader reduces/closes are not followed, follower manages exits)\n"
+}
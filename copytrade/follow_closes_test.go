@@ -0,0 +1,94 @@
+package copytrade
+
+import (
+	"strings"
+	"testing"
+
+	"nofx/decision"
+)
+
+// TestFollowClosesDisabled 只跟开仓：减仓/平仓不跟随，减仓更新 lastKnownSize，平仓后映射为 detached，重新开仓照常跟随
+func TestFollowClosesDisabled(t *testing.T) {
+	st := newTestStore(t)
+	provider := &fakeProvider{}
+	followCloses := false
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1, FollowCloses: &followCloses}, 1000)
+	e.store = st
+	e.provider = provider
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e}
+	posID := PositionKey("BTCUSDT", SideLong)
+
+	next := func() *decision.Decision {
+		select {
+		case fullDec := <-e.decisionCh:
+			dec := fullDec.Decisions[0]
+			ti.updatePositionMapping(&dec)
+			return &dec
+		default:
+			return nil
+		}
+	}
+
+	if !strings.Contains(e.buildSystemPromptLog(), "open-only mode") {
+		t.Errorf("system prompt should mention open-only mode:\n%s", e.buildSystemPromptLog())
+	}
+
+	provider.setSize(2)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "open", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 2, Value: 200}})
+	if dec := next(); dec == nil || dec.Action != "open_long" {
+		t.Fatalf("open = %+v, want open_long", dec)
+	}
+
+	// 减仓不跟随，lastKnownSize 跟随领航员
+	provider.setSize(1)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "reduce", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionReduce, Price: 100, Size: 1, Value: 100}})
+	if dec := next(); dec != nil {
+		t.Fatalf("reduce = %+v, want skip", dec)
+	}
+	m, err := st.CopyTrade().GetMapping("test", posID)
+	if err != nil || m == nil || m.Status != "active" || m.LastKnownSize != 1 {
+		t.Fatalf("mapping after reduce = %+v (err=%v), want active lastKnownSize=1", m, err)
+	}
+
+	// 加仓照常跟随（按 size 变化匹配）
+	provider.setSize(3)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "add", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionAdd, Price: 100, Size: 2, Value: 200}})
+	if dec := next(); dec == nil || dec.Action != "open_long" {
+		t.Fatalf("add = %+v, want open_long", dec)
+	}
+
+	// 平仓不跟随，映射转为 detached
+	provider.state.Positions = map[string]*Position{}
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "close", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionClose, Price: 110, Size: 3, Value: 330}})
+	if dec := next(); dec != nil {
+		t.Fatalf("close = %+v, want skip", dec)
+	}
+	all, err := st.CopyTrade().ListAllMappings("test", 0)
+	if err != nil || len(all) != 1 || all[0].Status != MappingStatusDetached || all[0].ClosePrice != 110 {
+		t.Fatalf("mappings after close = %+v (err=%v), want one detached at 110", all, err)
+	}
+
+	// 复用 posId 重新开仓：视为新开仓
+	provider.setSize(1)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "reopen", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 1, Value: 100}})
+	if dec := next(); dec == nil || dec.Action != "open_long" {
+		t.Fatalf("reopen = %+v, want open_long", dec)
+	}
+	// detached 映射归档保留，新映射为 active
+	all, err = st.CopyTrade().ListAllMappings("test", 0)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("mappings after reopen = %+v (err=%v), want archived detached + active", all, err)
+	}
+	m, err = st.CopyTrade().GetMapping("test", posID)
+	if err != nil || m == nil || m.Status != "active" {
+		t.Fatalf("mapping after reopen = %+v (err=%v), want active", m, err)
+	}
+
+	// 默认（未配置）跟随平仓
+	withConfig(e, func(c *CopyConfig) { c.FollowCloses = nil })
+	provider.state.Positions = map[string]*Position{}
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "close2", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionClose, Price: 100, Size: 1, Value: 100}})
+	if dec := next(); dec == nil || dec.Action != "close_long" {
+		t.Fatalf("close with default config = %+v, want close_long", dec)
+	}
+}
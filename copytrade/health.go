@@ -0,0 +1,190 @@
+package copytrade
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"nofx/store"
+)
+
+// ============================================================================
+// 单引擎健康度评分
+// ============================================================================
+//
+// 仪表盘的 HealthScore 是全局指标，多个引擎同时运行时无法看出哪个需要处理。
+// 每个引擎按以下四项扣分（满分 100，最低 0），各项权重可通过 health_weights 调整：
+//
+//	重连       每次重连扣 ReconnectPenalty，最多扣 ReconnectMax（启动以来累计）
+//	状态过期   领航员状态超过 StaleAfterSeconds 未更新时开始扣分，再过同样时长扣满 StaleMax；降级模式直接扣满
+//	执行失败率 执行次数达到 MinExecutions 后按 失败次数/执行次数 × FailureMax 扣分
+//	去重异常   每次异常扣 DedupPenalty，最多扣 DedupMax（账户级去重冲突、流式模式重复推送；轮询窗口重叠的正常去重不计）
+
+// 健康度默认权重（四项上限合计 100）
+const (
+	DefaultHealthReconnectPenalty  = 5.0
+	DefaultHealthReconnectMax      = 20.0
+	DefaultHealthStaleAfterSeconds = 120
+	DefaultHealthStaleMax          = 30.0
+	DefaultHealthMinExecutions     = 3
+	DefaultHealthFailureMax        = 30.0
+	DefaultHealthDedupPenalty      = 2.0
+	DefaultHealthDedupMax          = 20.0
+)
+
+// HealthWeights 健康度评分权重（0 值使用默认值）
+type HealthWeights struct {
+	ReconnectPenalty  float64 `json:"reconnect_penalty"`   // 每次重连扣分
+	ReconnectMax      float64 `json:"reconnect_max"`       // 重连最多扣分
+	StaleAfterSeconds int     `json:"stale_after_seconds"` // 领航员状态多久未更新开始扣分
+	StaleMax          float64 `json:"stale_max"`           // 状态过期最多扣分
+	MinExecutions     int     `json:"min_executions"`      // 计算失败率的最少执行次数
+	FailureMax        float64 `json:"failure_max"`         // 失败率 100% 时扣分
+	DedupPenalty      float64 `json:"dedup_penalty"`       // 每次去重异常扣分
+	DedupMax          float64 `json:"dedup_max"`           // 去重异常最多扣分
+}
+
+// withDefaults 补齐未配置的权重
+func (w *HealthWeights) withDefaults() HealthWeights {
+	r := HealthWeights{}
+	if w != nil {
+		r = *w
+	}
+	setDefault := func(v *float64, d float64) {
+		if *v <= 0 {
+			*v = d
+		}
+	}
+	setDefault(&r.ReconnectPenalty, DefaultHealthReconnectPenalty)
+	setDefault(&r.ReconnectMax, DefaultHealthReconnectMax)
+	setDefault(&r.StaleMax, DefaultHealthStaleMax)
+	setDefault(&r.FailureMax, DefaultHealthFailureMax)
+	setDefault(&r.DedupPenalty, DefaultHealthDedupPenalty)
+	setDefault(&r.DedupMax, DefaultHealthDedupMax)
+	if r.StaleAfterSeconds <= 0 {
+		r.StaleAfterSeconds = DefaultHealthStaleAfterSeconds
+	}
+	if r.MinExecutions <= 0 {
+		r.MinExecutions = DefaultHealthMinExecutions
+	}
+	return r
+}
+
+// toHealthWeights 转换存储的健康度权重（未配置返回 nil）
+func toHealthWeights(opts *store.CopyTradeHealthWeightsOptions) *HealthWeights {
+	if opts == nil {
+		return nil
+	}
+	return &HealthWeights{
+		ReconnectPenalty:  opts.ReconnectPenalty,
+		ReconnectMax:      opts.ReconnectMax,
+		StaleAfterSeconds: opts.StaleAfterSeconds,
+		StaleMax:          opts.StaleMax,
+		MinExecutions:     opts.MinExecutions,
+		FailureMax:        opts.FailureMax,
+		DedupPenalty:      opts.DedupPenalty,
+		DedupMax:          opts.DedupMax,
+	}
+}
+
+// EngineHealth 引擎健康度及各项扣分
+type EngineHealth struct {
+	TraderID         string  `json:"trader_id"`
+	Score            int     `json:"score"` // 0-100
+	ReconnectPenalty float64 `json:"reconnect_penalty"`
+	StalePenalty     float64 `json:"stale_penalty"`
+	FailurePenalty   float64 `json:"failure_penalty"`
+	DedupPenalty     float64 `json:"dedup_penalty"`
+	StateAgeSeconds  float64 `json:"state_age_seconds"` // 领航员状态距上次更新（-1=从未同步）
+	FailureRate      float64 `json:"failure_rate"`      // 执行失败率（0-1）
+}
+
+// HealthScore 引擎健康度（0-100）
+func (e *Engine) HealthScore() int {
+	return e.Health().Score
+}
+
+// Health 引擎健康度及各项扣分
+func (e *Engine) Health() *EngineHealth {
+	w := e.cfg().HealthWeights.withDefaults()
+
+	e.leaderStateMu.RLock()
+	lastSync := e.lastStateSync
+	e.leaderStateMu.RUnlock()
+
+	stats := e.stats
+	h := &EngineHealth{TraderID: e.traderID, StateAgeSeconds: -1}
+
+	h.ReconnectPenalty = math.Min(float64(stats.Reconnects)*w.ReconnectPenalty, w.ReconnectMax)
+
+	staleAfter := time.Duration(w.StaleAfterSeconds) * time.Second
+	switch {
+	case stats.Degraded || lastSync.IsZero():
+		h.StalePenalty = w.StaleMax
+	default:
+		age := time.Since(lastSync)
+		if over := age - staleAfter; over > 0 {
+			h.StalePenalty = w.StaleMax * math.Min(1, float64(over)/float64(staleAfter))
+		}
+	}
+	if !lastSync.IsZero() {
+		h.StateAgeSeconds = time.Since(lastSync).Seconds()
+	}
+
+	executions := stats.ExecutionsSucceeded + stats.ExecutionsFailed
+	if executions > 0 {
+		h.FailureRate = float64(stats.ExecutionsFailed) / float64(executions)
+	}
+	if executions >= int64(w.MinExecutions) {
+		h.FailurePenalty = h.FailureRate * w.FailureMax
+	}
+
+	h.DedupPenalty = math.Min(float64(stats.DedupAnomalies)*w.DedupPenalty, w.DedupMax)
+
+	score := 100 - h.ReconnectPenalty - h.StalePenalty - h.FailurePenalty - h.DedupPenalty
+	h.Score = int(math.Round(math.Max(0, score)))
+	return h
+}
+
+// recordExecution 记录一次决策执行结果（健康度失败率）
+func (e *Engine) recordExecution(err error) {
+	if err != nil {
+		e.stats.ExecutionsFailed++
+	} else {
+		e.stats.ExecutionsSucceeded++
+	}
+}
+
+// recordExecutionLatency 记录一次成功执行的下单耗时（累计平均）
+func (e *Engine) recordExecutionLatency(d time.Duration) {
+	e.latencySamples++
+	ms := float64(d) / float64(time.Millisecond)
+	e.stats.AvgExecutionLatencyMs += (ms - e.stats.AvgExecutionLatencyMs) / float64(e.latencySamples)
+}
+
+// GetCopyTradingHealth 获取指定 trader 的引擎健康度（未运行返回 nil）
+func GetCopyTradingHealth(traderID string) *EngineHealth {
+	integration, exists := getIntegration(traderID)
+	if !exists || integration.engine == nil {
+		return nil
+	}
+	return integration.engine.Health()
+}
+
+// ListCopyTradingHealth 所有引擎的健康度，按分数从低到高排列（最需要处理的在前）
+func ListCopyTradingHealth() []*EngineHealth {
+	all := listIntegrations()
+	list := make([]*EngineHealth, 0, len(all))
+	for _, integration := range all {
+		if integration.engine != nil {
+			list = append(list, integration.engine.Health())
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Score != list[j].Score {
+			return list[i].Score < list[j].Score
+		}
+		return list[i].TraderID < list[j].TraderID
+	})
+	return list
+}
@@ -0,0 +1,124 @@
+package copytrade
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestEngineHealthScore 重连、状态过期、执行失败率、去重异常分别扣分；权重可配置
+func TestEngineHealthScore(t *testing.T) {
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader"}, 1000)
+
+	// 从未同步领航员状态：状态项扣满
+	if got := e.HealthScore(); got != 100-int(DefaultHealthStaleMax) {
+		t.Errorf("never synced score = %d, want %d", got, 100-int(DefaultHealthStaleMax))
+	}
+
+	e.lastStateSync = time.Now()
+	if got := e.HealthScore(); got != 100 {
+		t.Errorf("healthy score = %d, want 100", got)
+	}
+
+	// 重连 2 次 -10；执行 4 次失败 1 次 -7.5；去重异常 3 次 -6
+	e.stats.Reconnects = 2
+	for i := 0; i < 4; i++ {
+		var err error
+		if i == 0 {
+			err = fmt.Errorf("rejected")
+		}
+		e.recordExecution(err)
+	}
+	e.stats.DedupAnomalies = 3
+	h := e.Health()
+	if h.ReconnectPenalty != 10 || h.FailurePenalty != 7.5 || h.DedupPenalty != 6 || h.StalePenalty != 0 {
+		t.Errorf("penalties = %+v", h)
+	}
+	if h.Score != 77 { // 100 - 10 - 7.5 - 6 = 76.5
+		t.Errorf("score = %d, want 77", h.Score)
+	}
+
+	// 状态过期一半（超出阈值 60s，阈值 120s）：扣 StaleMax 的一半
+	e.lastStateSync = time.Now().Add(-3 * time.Minute)
+	if got := e.Health().StalePenalty; got < 14.9 || got > 15.1 {
+		t.Errorf("stale penalty = %.2f, want ~15", got)
+	}
+
+	// 重连扣分有上限
+	e.stats.Reconnects = 100
+	if got := e.Health().ReconnectPenalty; got != DefaultHealthReconnectMax {
+		t.Errorf("reconnect penalty = %.2f, want cap %.0f", got, DefaultHealthReconnectMax)
+	}
+
+	// 自定义权重：不计重连
+	withConfig(e, func(c *CopyConfig) { c.HealthWeights = &HealthWeights{ReconnectMax: 0.001} })
+	if got := e.Health().ReconnectPenalty; got > 0.01 {
+		t.Errorf("custom reconnect penalty = %.4f, want ~0", got)
+	}
+
+	// 执行次数不足 MinExecutions 时不计失败率
+	e2 := newTestEngine(&CopyConfig{}, 1000)
+	e2.lastStateSync = time.Now()
+	e2.recordExecution(fmt.Errorf("rejected"))
+	if got := e2.HealthScore(); got != 100 {
+		t.Errorf("single failure score = %d, want 100", got)
+	}
+
+	// 降级模式：状态项扣满
+	e2.stats.Degraded = true
+	if got := e2.Health().StalePenalty; got != DefaultHealthStaleMax {
+		t.Errorf("degraded stale penalty = %.2f, want %.0f", got, DefaultHealthStaleMax)
+	}
+}
+
+// TestListCopyTradingHealth 按健康度从低到高排列
+func TestListCopyTradingHealth(t *testing.T) {
+	saved := integrations
+	defer func() { integrations = saved }()
+
+	healthy := newTestEngine(&CopyConfig{}, 1000)
+	healthy.lastStateSync = time.Now()
+	sick := newTestEngine(&CopyConfig{}, 1000)
+	sick.traderID = "sick"
+	integrations = map[string]*TraderIntegration{
+		"test": {traderID: "test", engine: healthy},
+		"sick": {traderID: "sick", engine: sick},
+	}
+
+	list := ListCopyTradingHealth()
+	if len(list) != 2 || list[0].TraderID != "sick" || list[1].Score != 100 {
+		t.Errorf("list = %+v %+v", list[0], list[1])
+	}
+}
+
+// TestCopiedNotionalAndLatency 累计跟随的开仓/加仓金额（减仓不计入）和执行耗时累计平均
+func TestCopiedNotionalAndLatency(t *testing.T) {
+	st := newTestStore(t)
+	provider := &fakeProvider{}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.store = st
+	e.provider = provider
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e, executor: &fakeExecutor{}}
+
+	provider.setSize(2)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "open", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 2, Value: 200}})
+	open := <-e.decisionCh
+	ti.executeFullDecision(open)
+	provider.setSize(1)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "reduce", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionReduce, Price: 100, Size: 1, Value: 100}})
+	<-e.decisionCh
+
+	if want := open.Decisions[0].PositionSizeUSD; want <= 0 || e.GetStats().TotalCopiedNotional != want {
+		t.Errorf("total copied notional = %v, want %v", e.GetStats().TotalCopiedNotional, want)
+	}
+	if e.latencySamples != 1 {
+		t.Errorf("latency samples = %d, want 1", e.latencySamples)
+	}
+
+	e.recordExecutionLatency(100 * time.Millisecond)
+	e.recordExecutionLatency(200 * time.Millisecond)
+	avg := e.GetStats().AvgExecutionLatencyMs
+	if first := avg*3 - 300; first < 0 || first > 50 {
+		t.Errorf("avg latency = %v ms, want (first + 300) / 3", avg)
+	}
+}
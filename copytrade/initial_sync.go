@@ -0,0 +1,181 @@
+package copytrade
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"nofx/decision"
+	"nofx/logger"
+)
+
+// ============================================================================
+// 启动时的初始同步
+// ============================================================================
+//
+// 默认（ignore）启动时领航员已有的仓位全部标记为 ignored，只跟随之后的新开仓。
+// mirror 模式下按当前比例立即跟开领航员已有的仓位（市价），之后的加仓/减仓/平仓照常跟随。
+//
+// 杠杆和保证金模式在持有仓位时通常无法修改，因此开仓前先发出
+// set_margin_mode（SyncMarginMode）/ set_leverage（SyncLeverage）决策。
+// 执行器需实现 PositionSettingsExecutor 才会执行这两类决策；未实现时跳过并照常开仓，
+// 设置失败（如该币种已有持仓）只记录日志，不阻止开仓。
+// 开仓失败的仓位保持 ignored，之后的加仓不会被当作新开仓追入。
+
+// 初始同步模式
+const (
+	InitialSyncIgnore = "ignore" // 标记为 ignored，只跟随新开仓（默认）
+	InitialSyncMirror = "mirror" // 按比例跟开领航员已有仓位
+)
+
+// 初始同步的仓位设置决策
+const (
+	ActionSetLeverage   = "set_leverage"
+	ActionSetMarginMode = "set_margin_mode"
+)
+
+// PositionSettingsExecutor 执行器可选能力：设置币种杠杆与保证金模式
+type PositionSettingsExecutor interface {
+	SetLeverage(symbol string, leverage int) error
+	SetMarginMode(symbol string, isCrossMargin bool) error
+}
+
+// isPositionSettingAction 是否为仓位设置决策（不下单、不更新映射）
+func isPositionSettingAction(action string) bool {
+	return action == ActionSetLeverage || action == ActionSetMarginMode
+}
+
+// buildInitialMirror 为领航员当前持仓生成跟开决策（每个仓位：设置保证金模式 → 设置杠杆 → 开仓）
+func (e *Engine) buildInitialMirror(state *AccountState) *decision.FullDecision {
+	if InMaintenance() {
+		logger.Infof("🔧 [%s] 维护模式中，跳过初始同步（领航员已有仓位保持 ignored）", e.traderID)
+		return nil
+	}
+
+	keys := make([]string, 0, len(state.Positions))
+	for key := range state.Positions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var decisions []decision.Decision
+	for _, key := range keys {
+		pos := state.Positions[key]
+		posID := pos.PosID
+		if posID == "" {
+			posID = key
+		}
+
+		price := pos.MarkPrice
+		if price <= 0 {
+			price = pos.EntryPrice
+		}
+		if pos.Size <= 0 || price <= 0 {
+			logger.Warnf("⚠️ [%s] 初始同步跳过 %s：持仓数量或价格未知", e.traderID, posID)
+			continue
+		}
+
+		value := pos.PositionValue
+		if value <= 0 {
+			value = pos.Size * price
+		}
+		side := "buy"
+		if pos.Side == SideShort {
+			side = "sell"
+		}
+		signal := &TradeSignal{
+			LeaderID:     e.cfg().LeaderID,
+			ProviderType: e.cfg().ProviderType,
+			Fill: &Fill{
+				ID: "initial_sync_" + posID, Symbol: pos.Symbol, Side: side, PositionSide: pos.Side,
+				Action: ActionOpen, Price: price, Size: pos.Size, Value: value, Timestamp: time.Now(),
+			},
+			LeaderEquity:   state.TotalEquity,
+			LeaderPosition: pos,
+			LeaderPosID:    posID,
+		}
+		match := &SignalMatchResult{
+			ShouldFollow: true, Action: ActionOpen, PosID: posID, MarginMode: pos.MarginMode, LeaderPosition: pos,
+		}
+
+		copySize, warnings := e.calculateCopySizeByPositionChange(signal, match)
+		for _, w := range warnings {
+			e.logWarning(w)
+		}
+		copySize = e.capSymbolBaseSize(signal, match, copySize)
+		if copySize <= 0 {
+			logger.Infof("📊 [%s] 初始同步跳过 %s：跟单金额为 0", e.traderID, posID)
+			continue
+		}
+
+		reason := fmt.Sprintf("Copy trading: initial sync following %s leader %s", e.cfg().ProviderType, e.cfg().LeaderID)
+		if e.cfg().SyncMarginMode && pos.MarginMode != "" {
+			decisions = append(decisions, decision.Decision{
+				Symbol: pos.Symbol, Action: ActionSetMarginMode, MarginMode: pos.MarginMode,
+				LeaderPosID: posID, Reasoning: reason,
+			})
+		}
+		if e.cfg().SyncLeverage && pos.Leverage > 0 {
+			decisions = append(decisions, decision.Decision{
+				Symbol: pos.Symbol, Action: ActionSetLeverage, Leverage: e.scaleLeverageForVolatility(pos.Symbol, pos.Leverage),
+				LeaderPosID: posID, Reasoning: reason,
+			})
+		}
+
+		// 初始同步固定市价开仓（已有仓位没有"领航员成交价"可挂）
+		dec := e.buildDecisionV2(signal, match, copySize)
+		dec.OrderType = ""
+		dec.LimitPrice = 0
+		dec.LimitTimeoutSec = 0
+		dec.LimitTimeoutAction = ""
+		dec.Reasoning = fmt.Sprintf("Copy trading: open (initial sync) following %s leader %s", e.cfg().ProviderType, e.cfg().LeaderID)
+		decisions = append(decisions, dec)
+
+		logger.Infof("🪞 [%s] 初始同步 | posId=%s %s %s | 金额=%.2f 杠杆=%dx 模式=%s",
+			e.traderID, posID, pos.Symbol, pos.Side, copySize, dec.Leverage, pos.MarginMode)
+	}
+
+	if len(decisions) == 0 {
+		return nil
+	}
+
+	var summary strings.Builder
+	for _, d := range decisions {
+		fmt.Fprintf(&summary, "- %s %s\n", d.Action, d.Symbol)
+	}
+	return &decision.FullDecision{
+		SystemPrompt: e.buildSystemPromptLog(),
+		UserPrompt:   fmt.Sprintf("## Initial Sync\n\nMirror %d leader position(s) on start\n\n%s", len(keys), summary.String()),
+		Decisions:    decisions,
+		RawResponse:  fmt.Sprintf("Copy trade initial sync from %s:%s", e.cfg().ProviderType, e.cfg().LeaderID),
+		Timestamp:    time.Now(),
+	}
+}
+
+// pushInitialSync 推送启动时生成的初始同步决策（只推送一次）
+func (e *Engine) pushInitialSync() {
+	if e.initialSync == nil {
+		return
+	}
+	fullDec := e.initialSync
+	e.initialSync = nil
+	if e.pushDecision(fullDec) {
+		logger.Infof("🪞 [%s] 初始同步决策已推送 | %d 条", e.traderID, len(fullDec.Decisions))
+	}
+}
+
+// applyPositionSetting 执行仓位设置决策（执行器不支持时跳过，返回 false）
+func (ti *TraderIntegration) applyPositionSetting(dec *decision.Decision) (bool, error) {
+	setter, ok := ti.executor.(PositionSettingsExecutor)
+	if !ok {
+		return false, nil
+	}
+	switch dec.Action {
+	case ActionSetLeverage:
+		return true, setter.SetLeverage(dec.Symbol, dec.Leverage)
+	case ActionSetMarginMode:
+		return true, setter.SetMarginMode(dec.Symbol, dec.MarginMode == "cross")
+	}
+	return false, nil
+}
@@ -0,0 +1,98 @@
+package copytrade
+
+import (
+	"errors"
+	"testing"
+
+	"nofx/decision"
+)
+
+// settingsExecutor 支持设置杠杆/保证金模式的执行器
+type settingsExecutor struct {
+	fakeExecutor
+	leverageErr error
+	calls       []string
+}
+
+func (s *settingsExecutor) SetLeverage(symbol string, leverage int) error {
+	s.calls = append(s.calls, ActionSetLeverage)
+	return s.leverageErr
+}
+
+func (s *settingsExecutor) SetMarginMode(symbol string, isCrossMargin bool) error {
+	s.calls = append(s.calls, ActionSetMarginMode)
+	return nil
+}
+
+// TestInitialSyncMirror 初始同步：每个仓位先设置保证金模式/杠杆再开仓；执行器不支持或设置失败时照常开仓
+func TestInitialSyncMirror(t *testing.T) {
+	initialSync := func(t *testing.T, cfg CopyConfig) (*Engine, *decision.FullDecision) {
+		t.Helper()
+		st := newTestStore(t)
+		provider := &fakeProvider{}
+		provider.setSize(2)
+		provider.state.Positions[PositionKey("BTCUSDT", SideLong)].EntryPrice = 100
+		cfg.ProviderType, cfg.LeaderID, cfg.CopyRatio, cfg.InitialSync = ProviderHyperliquid, "leader", 1, InitialSyncMirror
+		e := newTestEngine(&cfg, 1000)
+		e.store = st
+		e.provider = provider
+		if err := e.InitIgnoredPositions(); err != nil {
+			t.Fatal(err)
+		}
+		e.pushInitialSync()
+		select {
+		case fullDec := <-e.decisionCh:
+			return e, fullDec
+		default:
+			t.Fatal("no initial sync decision")
+		}
+		return nil, nil
+	}
+	actions := func(decs []decision.Decision) []string {
+		var got []string
+		for _, d := range decs {
+			got = append(got, d.Action)
+		}
+		return got
+	}
+
+	e, fullDec := initialSync(t, CopyConfig{SyncLeverage: true, SyncMarginMode: true})
+	got := actions(fullDec.Decisions)
+	want := []string{ActionSetMarginMode, ActionSetLeverage, "open_long"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Fatalf("actions = %v, want %v", got, want)
+	}
+	if lev := fullDec.Decisions[1].Leverage; lev != 5 {
+		t.Errorf("set_leverage = %d, want leader leverage 5", lev)
+	}
+	// 2 BTC × 100 × 1000/10000 = 20 USDT
+	if open := fullDec.Decisions[2]; open.PositionSizeUSD != 20 || open.LeaderPosSize != 2 {
+		t.Errorf("open = %.2f USDT leaderSize=%.2f, want 20 / 2", open.PositionSizeUSD, open.LeaderPosSize)
+	}
+
+	// 设置杠杆失败：记录日志后照常开仓，映射转为 active
+	exec := &settingsExecutor{leverageErr: errors.New("position exists")}
+	ti := &TraderIntegration{traderID: "test", store: e.store, engine: e, executor: exec}
+	ti.executeFullDecision(fullDec)
+	if len(exec.calls) != 2 || len(exec.executed) != 1 || exec.executed[0].Action != "open_long" {
+		t.Errorf("settings calls = %v executed = %v, want 2 settings + open", exec.calls, actions(exec.executed))
+	}
+	m, err := e.store.CopyTrade().GetMapping("test", PositionKey("BTCUSDT", SideLong))
+	if err != nil || m == nil || m.Status != "active" {
+		t.Errorf("mapping = %+v, %v; want active after initial open", m, err)
+	}
+
+	// 执行器不支持设置：跳过设置决策，只执行开仓
+	e, fullDec = initialSync(t, CopyConfig{SyncLeverage: true, SyncMarginMode: true})
+	plain := &fakeExecutor{}
+	(&TraderIntegration{traderID: "test", store: e.store, engine: e, executor: plain}).executeFullDecision(fullDec)
+	if len(plain.executed) != 1 || plain.executed[0].Action != "open_long" {
+		t.Errorf("executed = %v, want only open_long", actions(plain.executed))
+	}
+
+	// 不同步杠杆/保证金模式：只开仓
+	_, fullDec = initialSync(t, CopyConfig{})
+	if got := actions(fullDec.Decisions); len(got) != 1 || got[0] != "open_long" {
+		t.Errorf("actions without sync = %v, want [open_long]", got)
+	}
+}
@@ -2,11 +2,17 @@ package copytrade
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"nofx/decision"
 	"nofx/logger"
+	"nofx/notify"
 	"nofx/store"
 )
 
@@ -18,16 +24,35 @@ type DecisionExecutor interface {
 	GetPositions() ([]map[string]interface{}, error)
 }
 
+// FillReporter 执行器可选能力：返回最近一次 ExecuteDecision 在该币种上经交易所确认的成交均价和数量
+// （ok=false 表示订单未确认成交，映射改用估算值）
+type FillReporter interface {
+	LastExecutedFill(symbol string) (price, qty float64, ok bool)
+}
+
 // TraderIntegration 跟单与交易执行的集成
 type TraderIntegration struct {
 	traderID    string
 	executor    DecisionExecutor
-	engine      *Engine
+	engine      *Engine   // 主引擎（多领航员跟单时为第一个领航员的引擎，统计/健康度以它为准）
+	engines     []*Engine // 所有领航员的引擎（单领航员时只有 engine）
 	store       *store.Store
 	ctx         context.Context
 	cancel      context.CancelFunc
-	running     bool
-	cycleNumber int // 跟单周期计数器
+	running     atomic.Bool // Stop 与 API 查询（IsRunning）并发
+	cycleNumber int         // 跟单周期计数器
+
+	// 决策输出目标（默认只有进程内执行器）
+	sinks []DecisionSink
+
+	// 执行结果通知渠道（nil = 按用户通知配置）
+	notifier notify.Notifier
+
+	// 创建时的选项（配置变化需重启时沿用）
+	opts []IntegrationOption
+
+	// 权益快照合并写入
+	equitySnapshots equitySnapshotBatcher
 }
 
 // NewTraderIntegration 创建交易集成
@@ -35,20 +60,30 @@ func NewTraderIntegration(
 	traderID string,
 	executor DecisionExecutor,
 	st *store.Store,
+	opts ...IntegrationOption,
 ) *TraderIntegration {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &TraderIntegration{
+	ti := &TraderIntegration{
 		traderID: traderID,
 		executor: executor,
 		store:    st,
 		ctx:      ctx,
 		cancel:   cancel,
+		opts:     opts,
+	}
+	ti.sinks = []DecisionSink{&executorSink{ti: ti}}
+
+	// 应用选项
+	for _, opt := range opts {
+		opt(ti)
 	}
+
+	return ti
 }
 
 // StartCopyTrading 启动跟单
 func (ti *TraderIntegration) StartCopyTrading() error {
-	if ti.running {
+	if ti.running.Load() {
 		return fmt.Errorf("copy trading already running for trader %s", ti.traderID)
 	}
 
@@ -63,19 +98,78 @@ func (ti *TraderIntegration) StartCopyTrading() error {
 	}
 
 	// 转换为引擎配置
-	engineConfig := &CopyConfig{
-		ProviderType:   ProviderType(copyConfig.ProviderType),
-		LeaderID:       copyConfig.LeaderID,
-		CopyRatio:      copyConfig.CopyRatio,
-		SyncLeverage:   copyConfig.SyncLeverage,
-		SyncMarginMode: copyConfig.SyncMarginMode,
-		MinTradeWarn:   copyConfig.MinTradeWarn,
-		MaxTradeWarn:   copyConfig.MaxTradeWarn,
+	engineConfig := toEngineConfig(copyConfig)
+
+	// 账户过小：跟单金额普遍被提升到最小值，比例失真，拒绝启动
+	if err := ti.checkMinAccountEquity(engineConfig); err != nil {
+		return err
 	}
 
-	// 创建引擎（Hyperliquid 使用流式模式，OKX 使用轮询模式）
+	// 引擎公共选项
 	var engineOpts []EngineOption
-	if engineConfig.ProviderType == ProviderHyperliquid {
+	if checker, ok := ti.executor.(SymbolSupportChecker); ok {
+		engineOpts = append(engineOpts, WithSymbolChecker(checker.SupportsSymbol))
+	}
+	if checker, ok := ti.executor.(MinQuantityChecker); ok {
+		engineOpts = append(engineOpts, WithMinQuantity(checker.MinOrderQuantity))
+	}
+	engineOpts = append(engineOpts, WithFreeMargin(ti.getFreeMarginFunc()))
+	if engineConfig.DedupScope == DedupScopeAccount {
+		// 同一交易所账户（exchange_id）的 trader 共享去重
+		trader, err := ti.store.Trader().GetByID(ti.traderID)
+		if err != nil || trader.ExchangeID == "" {
+			logger.Warnf("⚠️ [%s] 无法确定跟随者账户，账户级去重未启用: %v", ti.traderID, err)
+		} else {
+			engineOpts = append(engineOpts, WithFollowerAccount(trader.ExchangeID))
+		}
+	}
+
+	// 每个领航员一个引擎；多领航员时共用决策通道，由同一消费者按顺序执行
+	specs := engineConfig.leaderSpecs()
+	if engineConfig.isMultiLeader() {
+		engineOpts = append(engineOpts, WithDecisionChannel(make(chan *decision.FullDecision, 10*len(specs))))
+	}
+	engines := make([]*Engine, 0, len(specs))
+	for _, spec := range specs {
+		engine, err := ti.newLeaderEngine(engineConfig.forLeader(spec), engineOpts)
+		if err != nil {
+			return err
+		}
+		engines = append(engines, engine)
+	}
+
+	ti.engine = engines[0]
+	ti.engines = engines
+
+	// 启动引擎（任一失败时停止已启动的引擎）
+	for i, engine := range engines {
+		if err := engine.Start(ti.ctx); err != nil {
+			for _, started := range engines[:i] {
+				started.Stop()
+			}
+			return fmt.Errorf("failed to start copy trade engine (leader=%s): %w", engine.cfg().LeaderID, err)
+		}
+	}
+
+	// 启动决策消费协程
+	go ti.consumeDecisions()
+
+	ti.running.Store(true)
+	if engineConfig.isMultiLeader() {
+		logger.Infof("🚀 [%s] 跟单集成已启动 | 多领航员 %d 个", ti.traderID, len(specs))
+	} else {
+		logger.Infof("🚀 [%s] 跟单集成已启动 | provider=%s leader=%s",
+			ti.traderID, copyConfig.ProviderType, copyConfig.LeaderID)
+	}
+
+	return nil
+}
+
+// newLeaderEngine 创建单个领航员的引擎并初始化历史仓位
+func (ti *TraderIntegration) newLeaderEngine(engineConfig *CopyConfig, commonOpts []EngineOption) (*Engine, error) {
+	// 支持推送的 Provider 使用流式模式，否则轮询
+	engineOpts := append([]EngineOption(nil), commonOpts...)
+	if caps, err := GetProviderCapabilities(engineConfig.ProviderType); err == nil && caps.Streaming {
 		engineOpts = append(engineOpts, WithStreamingMode())
 	}
 
@@ -87,7 +181,7 @@ func (ti *TraderIntegration) StartCopyTrading() error {
 		engineOpts...,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to create copy trade engine: %w", err)
+		return nil, fmt.Errorf("failed to create copy trade engine (leader=%s): %w", engineConfig.LeaderID, err)
 	}
 
 	// 设置数据库存储（用于仓位映射）
@@ -95,46 +189,201 @@ func (ti *TraderIntegration) StartCopyTrading() error {
 
 	// 🔑 初始化历史仓位：将领航员当前持仓标记为 ignored
 	// 这样后续这些仓位的操作都不会跟随，只跟新开仓
+	// 领航员状态不可用时无法区分历史仓位：默认拒绝启动，或按配置降级启动
 	if err := engine.InitIgnoredPositions(); err != nil {
-		logger.Warnf("⚠️ [%s] 初始化历史仓位失败: %v（继续启动）", ti.traderID, err)
+		if engineConfig.StateUnavailablePolicy != StateUnavailableDegraded {
+			return nil, fmt.Errorf("failed to initialize leader positions (leader=%s): %w", engineConfig.LeaderID, err)
+		}
+		logger.Warnf("⚠️ [%s] 初始化历史仓位失败: %v（降级模式启动，恢复前不处理成交）", ti.traderID, err)
+		engine.enterDegraded(err)
 	}
+	return engine, nil
+}
 
-	ti.engine = engine
-
-	// 启动引擎
-	if err := engine.Start(ti.ctx); err != nil {
-		return fmt.Errorf("failed to start copy trade engine: %w", err)
+// allEngines 所有领航员引擎（未记录引擎列表时为主引擎）
+func (ti *TraderIntegration) allEngines() []*Engine {
+	if len(ti.engines) > 0 {
+		return ti.engines
+	}
+	if ti.engine != nil {
+		return []*Engine{ti.engine}
 	}
+	return nil
+}
 
-	// 启动决策消费协程
-	go ti.consumeDecisions()
+// engineFor 决策的来源引擎（按决策的 LeaderID 匹配，未知时为主引擎）
+func (ti *TraderIntegration) engineFor(dec *decision.Decision) *Engine {
+	if dec != nil && dec.LeaderID != "" {
+		for _, e := range ti.allEngines() {
+			if e.cfg().LeaderID == dec.LeaderID {
+				return e
+			}
+		}
+	}
+	return ti.engine
+}
 
-	ti.running = true
-	logger.Infof("🚀 [%s] 跟单集成已启动 | provider=%s leader=%s",
-		ti.traderID, copyConfig.ProviderType, copyConfig.LeaderID)
+// engineForFull 整组决策的来源引擎（同一组决策来自同一引擎）
+func (ti *TraderIntegration) engineForFull(fullDec *decision.FullDecision) *Engine {
+	if len(fullDec.Decisions) == 0 {
+		return ti.engine
+	}
+	return ti.engineFor(&fullDec.Decisions[0])
+}
 
+// updateEngineConfigs 热更新所有领航员引擎的配置（领航员列表变化时返回 ErrRestartRequired）
+func (ti *TraderIntegration) updateEngineConfigs(cfg *CopyConfig) error {
+	specs := cfg.leaderSpecs()
+	engines := ti.allEngines()
+	if len(specs) != len(engines) {
+		return fmt.Errorf("%w: leaders %d -> %d", ErrRestartRequired, len(engines), len(specs))
+	}
+	// 反向跟单切换按整个 trader 检查活跃映射，避免多领航员时部分引擎已切换、部分被拒绝
+	if len(engines) > 0 && engines[0].cfg().Inverse != cfg.Inverse && ti.store != nil {
+		active, err := ti.store.CopyTrade().ListActiveMappings(ti.traderID)
+		if err != nil {
+			return fmt.Errorf("failed to list active mappings: %w", err)
+		}
+		if len(active) > 0 {
+			return fmt.Errorf("%w: %d active mapping(s)", ErrInverseWithActiveMappings, len(active))
+		}
+	}
+	for i, e := range engines {
+		if err := e.UpdateConfig(cfg.forLeader(specs[i])); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// toEngineConfig 将数据库中的跟单配置转换为引擎配置
+func toEngineConfig(copyConfig *store.CopyTradeConfig) *CopyConfig {
+	return &CopyConfig{
+		ProviderType:   ProviderType(copyConfig.ProviderType),
+		LeaderID:       copyConfig.LeaderID,
+		CopyRatio:      copyConfig.CopyRatio,
+		SyncLeverage:   copyConfig.SyncLeverage,
+		SyncMarginMode: copyConfig.SyncMarginMode,
+		MinTradeWarn:   copyConfig.MinTradeWarn,
+		MaxTradeWarn:   copyConfig.MaxTradeWarn,
+
+		ZeroEquityPolicy:     copyConfig.Options.ZeroEquityPolicy,
+		FixedNotional:        copyConfig.Options.FixedNotional,
+		LeaderEquityOverride: copyConfig.Options.LeaderEquityOverride,
+		MaxHoldHours:         copyConfig.Options.MaxHoldHours,
+		TrialTradeLimit:      copyConfig.Options.TrialTradeLimit,
+		LeaderBudget:         copyConfig.Options.LeaderBudget,
+		DriftCheckMinutes:    copyConfig.Options.DriftCheckMinutes,
+		ReduceMode:           copyConfig.Options.ReduceMode,
+		OutOfWindowFills:     copyConfig.Options.OutOfWindowFills,
+		Profiling:            copyConfig.Options.Profiling,
+
+		ReopenCooldownSeconds: copyConfig.Options.ReopenCooldownSeconds,
+		TradingWindows:        toTimeWindows(copyConfig.Options.TradingWindows),
+		Timezone:              copyConfig.Options.Timezone,
+
+		StateUnavailablePolicy:     copyConfig.Options.StateUnavailablePolicy,
+		DuplicateDecisionWindowSec: copyConfig.Options.DuplicateDecisionWindowSec,
+		ClosedPnLInference:         copyConfig.Options.ClosedPnLInference,
+
+		Shadow: toShadowConfig(copyConfig.Options.Shadow),
+
+		EntryOrderType:      copyConfig.Options.EntryOrderType,
+		LimitTimeoutSeconds: copyConfig.Options.LimitTimeoutSeconds,
+		LimitTimeoutAction:  copyConfig.Options.LimitTimeoutAction,
+		FreshEquityOnOpen:   copyConfig.Options.FreshEquityOnOpen,
+
+		UnlistedSymbolPolicy: copyConfig.Options.UnlistedSymbolPolicy,
+		StrictAddMatching:    copyConfig.Options.StrictAddMatching,
+		NegativeEquityPolicy: copyConfig.Options.NegativeEquityPolicy,
+		SymbolMaxBaseSize:    copyConfig.Options.SymbolMaxBaseSize,
+		DedupScope:           copyConfig.Options.DedupScope,
+		InitialSync:          copyConfig.Options.InitialSync,
+		ReconnectReconcile:   copyConfig.Options.ReconnectReconcile,
+		ReduceMinPolicy:      copyConfig.Options.ReduceMinPolicy,
+		ConfirmAddHolding:    copyConfig.Options.ConfirmAddHolding,
+		WarningHistoryLimit:  copyConfig.Options.WarningHistoryLimit,
+
+		VolatilityLeverageScaling: copyConfig.Options.VolatilityLeverageScaling,
+		VolatilityReference:       copyConfig.Options.VolatilityReference,
+
+		OKXInstrumentMap: copyConfig.Options.OKXInstrumentMap,
+		EmptyStatePolicy: copyConfig.Options.EmptyStatePolicy,
+
+		AdaptiveRatio:      toAdaptiveRatioConfig(copyConfig.Options.AdaptiveRatio),
+		CloseMatchStrategy: copyConfig.Options.CloseMatchStrategy,
+		MinAccountEquity:   copyConfig.Options.MinAccountEquity,
+		AddSizingMode:      copyConfig.Options.AddSizingMode,
+		HealthWeights:      toHealthWeights(copyConfig.Options.HealthWeights),
+		PhantomClosePolicy: copyConfig.Options.PhantomClosePolicy,
+
+		EquitySnapshotIntervalSeconds: copyConfig.Options.EquitySnapshotIntervalSeconds,
+
+		CrossCheckState:           copyConfig.Options.CrossCheckState,
+		CrossCheckIntervalSeconds: copyConfig.Options.CrossCheckIntervalSeconds,
+		CrossCheckThreshold:       copyConfig.Options.CrossCheckThreshold,
+
+		MaxMarginFraction: copyConfig.Options.MaxMarginFraction,
+		MarginCapPolicy:   copyConfig.Options.MarginCapPolicy,
+
+		ProtectiveStopPct: copyConfig.Options.ProtectiveStopPct,
+		SymbolEnabled:     copyConfig.Options.SymbolEnabled,
+
+		DecisionStallSeconds: copyConfig.Options.DecisionStallSeconds,
+		DecisionStallPolicy:  copyConfig.Options.DecisionStallPolicy,
+
+		MirrorLeaderOrders:      copyConfig.Options.MirrorLeaderOrders,
+		LeaderOrdersPollSeconds: copyConfig.Options.LeaderOrdersPollSeconds,
+
+		PollInterval: time.Duration(copyConfig.Options.PollIntervalSeconds) * time.Second,
+
+		CapAtTargetAllocation: copyConfig.Options.CapAtTargetAllocation,
+
+		CopyMode:      copyConfig.Options.CopyMode,
+		FixedTradeUSD: copyConfig.Options.FixedTradeUSD,
+
+		SymbolWhitelist: copyConfig.Options.SymbolWhitelist,
+		SymbolBlacklist: copyConfig.Options.SymbolBlacklist,
+
+		MaxLeverage: copyConfig.Options.MaxLeverage,
+
+		DryRun: copyConfig.Options.DryRun,
+
+		Leaders: toLeaderSpecs(copyConfig.Options.Leaders),
+		Inverse: copyConfig.Options.Inverse,
+
+		MaxOpenPositions: copyConfig.Options.MaxOpenPositions,
+
+		CopyDelay: time.Duration(copyConfig.Options.CopyDelaySeconds) * time.Second,
+
+		SyncTPSL: copyConfig.Options.SyncTPSL,
+
+		FollowCloses: copyConfig.Options.FollowCloses,
+
+		MaxSlippagePct: copyConfig.Options.MaxSlippagePct,
+	}
+}
+
 // Stop 停止跟单
 func (ti *TraderIntegration) Stop() {
-	if !ti.running {
+	if !ti.running.Load() {
 		return
 	}
 
 	ti.cancel()
 
-	if ti.engine != nil {
-		ti.engine.Stop()
+	for _, e := range ti.allEngines() {
+		e.Stop()
 	}
+	ti.flushEquitySnapshot()
 
-	ti.running = false
+	ti.running.Store(false)
 	logger.Infof("🛑 [%s] 跟单集成已停止", ti.traderID)
 }
 
 // IsRunning 检查是否运行中
 func (ti *TraderIntegration) IsRunning() bool {
-	return ti.running
+	return ti.running.Load()
 }
 
 // GetStats 获取统计信息
@@ -157,7 +406,16 @@ func (ti *TraderIntegration) consumeDecisions() {
 			if !ok {
 				return
 			}
-			ti.executeFullDecision(fullDec)
+			ti.publishDecision(fullDec)
+		}
+	}
+}
+
+// publishDecision 将决策发送到所有 Sink（单个 Sink 失败不影响其他 Sink）
+func (ti *TraderIntegration) publishDecision(fullDec *decision.FullDecision) {
+	for _, sink := range ti.sinks {
+		if err := sink.Publish(ti.ctx, fullDec); err != nil {
+			logger.Errorf("❌ [%s] 决策发布失败 | sink=%T error=%v", ti.traderID, sink, err)
 		}
 	}
 }
@@ -169,6 +427,7 @@ func (ti *TraderIntegration) executeFullDecision(fullDec *decision.FullDecision)
 	// 构建决策记录
 	decisionActions := make([]store.DecisionAction, 0, len(fullDec.Decisions))
 	executionLogs := make([]string, 0)
+	groups := newDecisionGroups()
 
 	for i := range fullDec.Decisions {
 		dec := &fullDec.Decisions[i]
@@ -176,9 +435,73 @@ func (ti *TraderIntegration) executeFullDecision(fullDec *decision.FullDecision)
 		// 记录决策日志
 		ti.logDecision(fullDec, dec)
 
+		// 决策组：组内前序决策失败时跳过剩余决策
+		if groups.skip(dec) {
+			logger.Infof("⏭️ [%s] 决策组已失败，跳过 %s %s | group=%s", ti.traderID, dec.Action, dec.Symbol, dec.GroupID)
+			executionLogs = append(executionLogs, fmt.Sprintf("⏭️ %s %s 所在决策组已失败，已跳过", dec.Action, dec.Symbol))
+			continue
+		}
+
+		// 模拟运行：不提交执行器，只记录信号并更新映射
+		engine := ti.engineFor(dec)
+		if engine != nil && engine.isDryRun() {
+			decisionActions = append(decisionActions, ti.simulateDecision(dec))
+			executionLogs = append(executionLogs, fmt.Sprintf("🧪 %s %s 模拟执行（dry run）", dec.Action, dec.Symbol))
+			groups.record(dec, nil)
+			continue
+		}
+
+		// 保护性止损：失败（含执行器不支持）时回滚同组已开仓位
+		if dec.Action == ActionSetStopLoss {
+			err := ti.applyProtectiveStop(dec, groups)
+			action := store.DecisionAction{
+				Action: dec.Action, Symbol: dec.Symbol, StopLoss: dec.StopLoss, Reasoning: dec.Reasoning,
+				Timestamp: time.Now(), Success: err == nil,
+			}
+			if err != nil {
+				action.Error = err.Error()
+				logger.Errorf("❌ [%s] 保护性止损失败，回滚决策组 | %s @ %.4f group=%s error=%v",
+					ti.traderID, dec.Symbol, dec.StopLoss, dec.GroupID, err)
+				executionLogs = append(executionLogs, fmt.Sprintf("❌ %s %s 失败: %v（回滚开仓）", dec.Action, dec.Symbol, err))
+				decisionActions = append(decisionActions, action)
+				decisionActions = append(decisionActions, ti.rollbackGroup(dec.GroupID, groups, err)...)
+				continue
+			}
+			executionLogs = append(executionLogs, fmt.Sprintf("✅ %s %s @ %.4f 成功", dec.Action, dec.Symbol, dec.StopLoss))
+			decisionActions = append(decisionActions, action)
+			continue
+		}
+
+		// 仓位设置（初始同步/跟随保证金模式切换）：执行器不支持时跳过，失败不影响后续开仓
+		if isPositionSettingAction(dec.Action) {
+			applied, err := ti.applyPositionSetting(dec)
+			switch {
+			case !applied:
+				logger.Infof("⏭️ [%s] 执行器不支持 %s，跳过 | %s", ti.traderID, dec.Action, dec.Symbol)
+				executionLogs = append(executionLogs, fmt.Sprintf("⏭️ %s %s 不支持，已跳过", dec.Action, dec.Symbol))
+			case err != nil:
+				logger.Warnf("⚠️ [%s] %s %s 失败: %v（继续开仓）", ti.traderID, dec.Action, dec.Symbol, err)
+				executionLogs = append(executionLogs, fmt.Sprintf("⚠️ %s %s 失败: %v", dec.Action, dec.Symbol, err))
+			default:
+				executionLogs = append(executionLogs, fmt.Sprintf("✅ %s %s 成功", dec.Action, dec.Symbol))
+			}
+			decisionActions = append(decisionActions, store.DecisionAction{
+				Action: dec.Action, Symbol: dec.Symbol, Leverage: dec.Leverage, Reasoning: dec.Reasoning, Timestamp: time.Now(),
+			})
+			continue
+		}
+
 		// 执行交易
 		startTime := time.Now()
 		err := ti.executor.ExecuteDecision(dec)
+		elapsed := time.Since(startTime)
+		if engine != nil && !errors.Is(err, decision.ErrLimitNotFilled) {
+			engine.recordExecution(err)
+			if err == nil {
+				engine.recordExecutionLatency(elapsed)
+			}
+		}
+		groups.record(dec, err)
 
 		// 构建决策动作记录
 		action := store.DecisionAction{
@@ -190,13 +513,21 @@ func (ti *TraderIntegration) executeFullDecision(fullDec *decision.FullDecision)
 			Timestamp: time.Now(),
 		}
 
-		if err != nil {
+		if errors.Is(err, decision.ErrLimitNotFilled) {
+			// 限价入场超时未成交：按配置放弃该笔交易（不是执行故障）
+			logger.Infof("⏱ [%s] 限价入场未成交，放弃跟随 | %s %s | %v",
+				ti.traderID, dec.Action, dec.Symbol, err)
+			executionLogs = append(executionLogs, fmt.Sprintf("⏱ %s %s 限价未成交，已撤单", dec.Action, dec.Symbol))
+			ti.saveSignalLog(dec, "skipped", err.Error())
+			ti.handleLimitNotFilled(dec)
+		} else if err != nil {
 			logger.Errorf("❌ [%s] 跟单执行失败 | %s %s | error=%v",
 				ti.traderID, dec.Action, dec.Symbol, err)
 			executionLogs = append(executionLogs, fmt.Sprintf("❌ %s %s 失败: %v", dec.Action, dec.Symbol, err))
 			ti.saveSignalLog(dec, "failed", err.Error())
+			ti.notifyExecution(dec, err)
 		} else {
-			duration := time.Since(startTime).Milliseconds()
+			duration := elapsed.Milliseconds()
 			logger.Infof("✅ [%s] 跟单执行成功 | %s %s | 耗时=%dms",
 				ti.traderID, dec.Action, dec.Symbol, duration)
 			executionLogs = append(executionLogs, fmt.Sprintf("✅ %s %s 成功 (耗时 %dms)", dec.Action, dec.Symbol, duration))
@@ -204,6 +535,7 @@ func (ti *TraderIntegration) executeFullDecision(fullDec *decision.FullDecision)
 
 			// 执行成功后更新仓位映射
 			ti.updatePositionMapping(dec)
+			ti.notifyExecution(dec, nil)
 		}
 
 		decisionActions = append(decisionActions, action)
@@ -265,14 +597,15 @@ func (ti *TraderIntegration) saveDecisionRecord(fullDec *decision.FullDecision,
 		}
 	}
 
+	cfg := ti.engineForFull(fullDec).cfg()
 	record := &store.DecisionRecord{
 		TraderID:            ti.traderID,
 		CycleNumber:         ti.cycleNumber,
 		Timestamp:           time.Now(),
 		SystemPrompt:        "Copy Trading Mode",
-		InputPrompt:         fmt.Sprintf("跟单领航员: %s (%s)", ti.engine.config.LeaderID, ti.engine.config.ProviderType),
+		InputPrompt:         fmt.Sprintf("跟单领航员: %s (%s)", cfg.LeaderID, cfg.ProviderType),
 		CoTTrace:            cotTrace,
-		DecisionJSON:        fmt.Sprintf(`{"mode":"copy_trade","leader":"%s"}`, ti.engine.config.LeaderID),
+		DecisionJSON:        fmt.Sprintf(`{"mode":"copy_trade","leader":"%s"}`, cfg.LeaderID),
 		CandidateCoins:      []string{},
 		ExecutionLog:        executionLogs,
 		Success:             true,
@@ -292,7 +625,7 @@ func (ti *TraderIntegration) saveDecisionRecord(fullDec *decision.FullDecision,
 	ti.saveEquitySnapshot(totalEquity, availableBalance, unrealizedPnL, len(positions))
 }
 
-// saveEquitySnapshot 保存权益快照（复用 store.Equity() 接口）
+// saveEquitySnapshot 保存权益快照（复用 store.Equity() 接口，按间隔合并写入）
 func (ti *TraderIntegration) saveEquitySnapshot(totalEquity, availableBalance, unrealizedPnL float64, positionCount int) {
 	if ti.store == nil || totalEquity <= 0 {
 		return
@@ -314,20 +647,21 @@ func (ti *TraderIntegration) saveEquitySnapshot(totalEquity, availableBalance, u
 		MarginUsedPct: marginUsedPct,
 	}
 
-	if err := ti.store.Equity().Save(snapshot); err != nil {
-		logger.Warnf("⚠️ [%s] 保存权益快照失败: %v", ti.traderID, err)
-	} else {
-		logger.Debugf("💾 [%s] 权益快照已保存: equity=%.2f", ti.traderID, totalEquity)
+	var cfg *CopyConfig
+	if ti.engine != nil {
+		cfg = ti.engine.cfg()
 	}
+	ti.equitySnapshots.submit(snapshot, equitySnapshotInterval(cfg), ti.writeEquitySnapshot)
 }
 
 // buildCopyTradeCoT 构建跟单的思维链描述
 func (ti *TraderIntegration) buildCopyTradeCoT(fullDec *decision.FullDecision) string {
+	cfg := ti.engineForFull(fullDec).cfg()
 	var cot string
 	cot += "## 📋 跟单决策分析\n\n"
-	cot += fmt.Sprintf("**领航员**: %s\n", ti.engine.config.LeaderID)
-	cot += fmt.Sprintf("**数据源**: %s\n", ti.engine.config.ProviderType)
-	cot += fmt.Sprintf("**跟单比例**: %.0f%%\n\n", ti.engine.config.CopyRatio*100)
+	cot += fmt.Sprintf("**领航员**: %s\n", cfg.LeaderID)
+	cot += fmt.Sprintf("**数据源**: %s\n", cfg.ProviderType)
+	cot += fmt.Sprintf("**跟单比例**: %.0f%%\n\n", cfg.CopyRatio*100)
 
 	for _, dec := range fullDec.Decisions {
 		cot += fmt.Sprintf("### %s %s\n", dec.Action, dec.Symbol)
@@ -355,16 +689,18 @@ func (ti *TraderIntegration) logDecision(fullDec *decision.FullDecision, dec *de
 
 // saveSignalLog 保存信号日志到数据库
 func (ti *TraderIntegration) saveSignalLog(dec *decision.Decision, status, errorMsg string) {
+	cfg := ti.engineFor(dec).cfg()
 	log := &store.CopyTradeSignalLog{
 		TraderID:     ti.traderID,
-		LeaderID:     ti.engine.config.LeaderID,
-		ProviderType: string(ti.engine.config.ProviderType),
+		LeaderID:     cfg.LeaderID,
+		ProviderType: string(cfg.ProviderType),
 		SignalID:     fmt.Sprintf("%s_%d", dec.Symbol, time.Now().UnixNano()),
 		Symbol:       dec.Symbol,
 		Action:       dec.Action,
+		CloseTrigger: dec.CloseTrigger,
 		PositionSide: "", // 从 action 推断
 		CopySize:     dec.PositionSizeUSD,
-		Followed:     status == "executed",
+		Followed:     status == "executed" || status == SignalStatusDryRun,
 		FollowReason: dec.Reasoning,
 		Status:       status,
 		ErrorMessage: errorMsg,
@@ -375,11 +711,51 @@ func (ti *TraderIntegration) saveSignalLog(dec *decision.Decision, status, error
 	}
 }
 
-// updatePositionMapping 更新仓位映射（执行成功后调用）
+// handleLimitNotFilled 限价入场未成交（已撤单）后的映射处理
+// 新开仓：标记为 ignored，后续加仓/减仓/平仓都不跟随；
+// 加仓：更新 lastKnownSize，避免下次加仓把错过的数量一并追单
+func (ti *TraderIntegration) handleLimitNotFilled(dec *decision.Decision) {
+	if dec.LeaderPosID == "" {
+		return
+	}
+	ct := ti.store.CopyTrade()
+
+	existing, err := ct.GetActiveMapping(ti.traderID, dec.LeaderPosID)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 查询映射失败: %v", ti.traderID, err)
+		return
+	}
+	if existing != nil {
+		if dec.LeaderPosSize > 0 {
+			if err := ct.UpdateLastKnownSize(ti.traderID, dec.LeaderPosID, dec.LeaderPosSize); err != nil {
+				logger.Warnf("⚠️ [%s] 更新 lastKnownSize 失败: %v", ti.traderID, err)
+			}
+		}
+		return
+	}
+
+	// ignored 映射记录领航员方向（反向跟单时与决策方向相反）
+	cfg := ti.engineFor(dec).cfg()
+	side := SideLong
+	if dec.Action == "open_short" {
+		side = SideShort
+	}
+	if err := ct.SaveIgnoredPosition(ti.traderID, cfg.LeaderID, dec.LeaderPosID,
+		dec.Symbol, string(cfg.leaderSide(side)), dec.MarginMode); err != nil {
+		logger.Warnf("⚠️ [%s] 标记未成交仓位失败: %v (posId=%s)", ti.traderID, err, dec.LeaderPosID)
+	}
+}
+
+// updatePositionMapping 更新仓位映射（执行成功后调用），数量/均价/盈亏按执行器确认的实际成交记录
+func (ti *TraderIntegration) updatePositionMapping(dec *decision.Decision) {
+	ti.applyPositionMapping(dec, ti.executedFillFor(dec))
+}
+
+// applyPositionMapping 按本次成交更新仓位映射
 // 根据 action 类型执行不同操作：
 //   - open_long/open_short: 保存新映射 或 加仓（根据数据库是否已有映射判断）
 //   - close_long/close_short: 关闭映射 或 减仓（根据是否还有持仓判断）
-func (ti *TraderIntegration) updatePositionMapping(dec *decision.Decision) {
+func (ti *TraderIntegration) applyPositionMapping(dec *decision.Decision, fill executedFill) {
 	// 无 posId 时跳过（Hyperliquid 或其他场景）
 	if dec.LeaderPosID == "" {
 		return
@@ -398,12 +774,21 @@ func (ti *TraderIntegration) updatePositionMapping(dec *decision.Decision) {
 
 		if existingMapping != nil {
 			// 映射已存在 → 加仓：增加加仓次数
-			if err := copyTradeStore.IncrementAddCount(ti.traderID, dec.LeaderPosID); err != nil {
+			if err := copyTradeStore.IncrementAddCount(ti.traderID, dec.LeaderPosID, dec.PositionSizeUSD); err != nil {
 				logger.Warnf("⚠️ [%s] 更新加仓次数失败: %v", ti.traderID, err)
 			} else {
 				logger.Infof("📝 [%s] 加仓次数已更新 | posId=%s %s (第 %d 次加仓)",
 					ti.traderID, dec.LeaderPosID, dec.Symbol, existingMapping.AddCount+1)
 			}
+			// 更新跟随者持仓数量和均价（用于按本映射成交计算已实现盈亏）
+			if fill.qty > 0 && existingMapping.AvgEntryPrice > 0 {
+				total := existingMapping.OpenQty + fill.qty
+				avg := (existingMapping.OpenQty*existingMapping.AvgEntryPrice + fill.qty*fill.price) / total
+				if err := copyTradeStore.UpdateOpenQty(ti.traderID, dec.LeaderPosID, total, avg); err != nil {
+					logger.Warnf("⚠️ [%s] 更新持仓数量失败: %v", ti.traderID, err)
+				}
+				ti.markFillEstimated(dec, fill)
+			}
 			// 更新 lastKnownSize（领航员当前持仓数量）
 			if dec.LeaderPosSize > 0 {
 				if err := copyTradeStore.UpdateLastKnownSize(ti.traderID, dec.LeaderPosID, dec.LeaderPosSize); err != nil {
@@ -420,7 +805,7 @@ func (ti *TraderIntegration) updatePositionMapping(dec *decision.Decision) {
 			mapping := &store.CopyTradePositionMapping{
 				TraderID:      ti.traderID,
 				LeaderPosID:   dec.LeaderPosID,
-				LeaderID:      ti.engine.config.LeaderID,
+				LeaderID:      ti.engineFor(dec).cfg().LeaderID,
 				Symbol:        dec.Symbol,
 				Side:          side,
 				MarginMode:    dec.MarginMode,
@@ -428,6 +813,9 @@ func (ti *TraderIntegration) updatePositionMapping(dec *decision.Decision) {
 				OpenPrice:     dec.EntryPrice,
 				OpenSizeUSD:   dec.PositionSizeUSD,
 				LastKnownSize: dec.LeaderPosSize, // 记录领航员当前持仓数量
+				OpenQty:       fill.qty,
+				AvgEntryPrice: fill.price,
+				PnLEstimated:  fill.estimated,
 			}
 
 			if err := copyTradeStore.SavePositionMapping(mapping); err != nil {
@@ -439,7 +827,20 @@ func (ti *TraderIntegration) updatePositionMapping(dec *decision.Decision) {
 		}
 
 	case "reduce_long", "reduce_short":
-		// 减仓：增加减仓次数
+		// 减仓：增加减仓次数，累计本轮减仓比例（须在更新 lastKnownSize 之前计算），累计该笔减仓的已实现盈亏
+		if existingMapping, err := copyTradeStore.GetActiveMapping(ti.traderID, dec.LeaderPosID); err == nil && existingMapping != nil {
+			reduced := scaleOutReducedFraction(existingMapping, dec.LeaderPosSize, dec.CloseRatio)
+			if err := copyTradeStore.UpdateReducedFraction(ti.traderID, dec.LeaderPosID, reduced); err != nil {
+				logger.Warnf("⚠️ [%s] 更新累计减仓比例失败: %v", ti.traderID, err)
+			}
+			if existingMapping.AvgEntryPrice > 0 {
+				pnl, remaining := mappingFillPnL(existingMapping, fill, dec.CloseRatio)
+				if err := copyTradeStore.RecordReduceFill(ti.traderID, dec.LeaderPosID, remaining, pnl); err != nil {
+					logger.Warnf("⚠️ [%s] 记录减仓盈亏失败: %v", ti.traderID, err)
+				}
+				ti.markFillEstimated(dec, fill)
+			}
+		}
 		if err := copyTradeStore.IncrementReduceCount(ti.traderID, dec.LeaderPosID); err != nil {
 			logger.Warnf("⚠️ [%s] 更新减仓次数失败: %v", ti.traderID, err)
 		}
@@ -451,14 +852,108 @@ func (ti *TraderIntegration) updatePositionMapping(dec *decision.Decision) {
 		}
 
 	case "close_long", "close_short":
-		// 平仓：关闭映射
-		if err := copyTradeStore.CloseMapping(ti.traderID, dec.LeaderPosID, dec.EntryPrice); err != nil {
+		// 平仓：关闭映射，并记录该笔跟单仓位的累计已实现盈亏
+		realizedPnL := ti.mappingRealizedPnL(dec, fill)
+		ti.markFillEstimated(dec, fill)
+
+		// 主动平仓（领航员仍持仓）：转为 ignored，后续该仓位的操作不再跟随
+		if dec.CloseReason != "" {
+			if err := copyTradeStore.ExpireMapping(ti.traderID, dec.LeaderPosID, dec.EntryPrice, realizedPnL); err != nil {
+				logger.Warnf("⚠️ [%s] 更新仓位映射失败: %v", ti.traderID, err)
+			} else {
+				logger.Infof("📝 [%s] 仓位映射已主动平仓 | posId=%s %s 原因=%s 盈亏=%.2f",
+					ti.traderID, dec.LeaderPosID, dec.Symbol, dec.CloseReason, realizedPnL)
+			}
+			return
+		}
+
+		if err := copyTradeStore.CloseMapping(ti.traderID, dec.LeaderPosID, dec.EntryPrice, realizedPnL); err != nil {
 			logger.Warnf("⚠️ [%s] 关闭仓位映射失败: %v", ti.traderID, err)
 		} else {
-			logger.Infof("📝 [%s] 仓位映射已关闭 | posId=%s %s",
-				ti.traderID, dec.LeaderPosID, dec.Symbol)
+			logger.Infof("📝 [%s] 仓位映射已关闭 | posId=%s %s 盈亏=%.2f",
+				ti.traderID, dec.LeaderPosID, dec.Symbol, realizedPnL)
+		}
+	}
+}
+
+// mappingRealizedPnL 跟单仓位平仓时的累计已实现盈亏 = 此前减仓已累计的盈亏 + 本次平掉剩余持仓的盈亏
+// 只按本映射自身的开仓/加仓/减仓/平仓成交计算，不受同币种其他交易（AI、手动、其他领航员）影响
+func (ti *TraderIntegration) mappingRealizedPnL(dec *decision.Decision, fill executedFill) float64 {
+	mapping, err := ti.store.CopyTrade().GetActiveMapping(ti.traderID, dec.LeaderPosID)
+	if err != nil || mapping == nil {
+		return 0
+	}
+	pnl, _ := mappingFillPnL(mapping, fill, 1)
+	return mapping.RealizedPnL + pnl
+}
+
+// executedFill 跟随者一次成交的均价和数量
+// estimated=true 表示执行器未返回确认成交，价格为领航员成交价、数量按跟单金额估算（减仓/平仓数量为 0，按比例计算）
+type executedFill struct {
+	price     float64
+	qty       float64
+	estimated bool
+}
+
+// executedFillFor 本次执行的跟随者成交：优先取执行器确认的实际成交，不可用时按决策估算
+func (ti *TraderIntegration) executedFillFor(dec *decision.Decision) executedFill {
+	if reporter, ok := ti.executor.(FillReporter); ok {
+		if price, qty, ok := reporter.LastExecutedFill(dec.Symbol); ok {
+			return executedFill{price: price, qty: qty}
 		}
 	}
+	return estimatedFill(dec)
+}
+
+// estimatedFill 按决策估算的成交（领航员成交价、跟单金额/成交价）
+func estimatedFill(dec *decision.Decision) executedFill {
+	return executedFill{price: dec.EntryPrice, qty: fillQty(dec), estimated: true}
+}
+
+// markFillEstimated 成交为估算值时标记映射（数量/均价/盈亏不是交易所确认值）
+func (ti *TraderIntegration) markFillEstimated(dec *decision.Decision, fill executedFill) {
+	if !fill.estimated {
+		return
+	}
+	if err := ti.store.CopyTrade().MarkPnLEstimated(ti.traderID, dec.LeaderPosID); err != nil {
+		logger.Warnf("⚠️ [%s] 标记估算盈亏失败: %v", ti.traderID, err)
+	}
+}
+
+// fillQty 按跟单金额和成交价估算跟随者成交数量（与执行器按金额/价格下单一致）
+func fillQty(dec *decision.Decision) float64 {
+	if dec.PositionSizeUSD <= 0 || dec.EntryPrice <= 0 {
+		return 0
+	}
+	return dec.PositionSizeUSD / dec.EntryPrice
+}
+
+// mappingFillPnL 按成交平掉映射部分持仓的已实现盈亏，返回盈亏和剩余数量
+// 平仓数量取确认成交数量（不超过映射剩余持仓），估算成交按剩余持仓的 ratio 比例（0 或 ≥1 = 全部）；
+// 成本为映射持仓均价；旧映射没有均价时，全部平仓按开仓金额和开仓价估算
+func mappingFillPnL(m *store.CopyTradePositionMapping, fill executedFill, ratio float64) (pnl, remainingQty float64) {
+	if ratio <= 0 || ratio >= 1 {
+		ratio = 1
+	}
+	closedQty := m.OpenQty * ratio
+	if !fill.estimated && fill.qty > 0 {
+		closedQty = math.Min(fill.qty, m.OpenQty)
+	}
+	remainingQty = m.OpenQty - closedQty
+	price := fill.price
+	if price <= 0 {
+		return 0, remainingQty
+	}
+	switch {
+	case m.AvgEntryPrice > 0:
+		pnl = (price - m.AvgEntryPrice) * closedQty
+	case ratio == 1 && m.OpenPrice > 0:
+		pnl = m.OpenSizeUSD * (price - m.OpenPrice) / m.OpenPrice
+	}
+	if m.Side == "short" {
+		pnl = -pnl
+	}
+	return pnl, remainingQty
 }
 
 // ============================================================================
@@ -482,6 +977,20 @@ func (ti *TraderIntegration) getBalanceFunc() func() float64 {
 	}
 }
 
+// getFreeMarginFunc 返回获取可用保证金的函数（缺失时返回 0，引擎改用权益）
+func (ti *TraderIntegration) getFreeMarginFunc() func() float64 {
+	return func() float64 {
+		info, err := ti.executor.GetAccountInfo()
+		if err != nil {
+			return 0
+		}
+		if available, ok := info["available_balance"].(float64); ok {
+			return available
+		}
+		return 0
+	}
+}
+
 // getPositionsFunc 返回获取持仓的函数
 func (ti *TraderIntegration) getPositionsFunc() func() map[string]*Position {
 	return func() map[string]*Position {
@@ -621,46 +1130,175 @@ func getIntOrFloatField(m map[string]interface{}, key string) int {
 // ============================================================================
 
 var (
-	// integrations 存储所有跟单集成实例（注：目前只在启动时使用，无并发问题）
-	integrations = make(map[string]*TraderIntegration)
+	// integrations 存储所有跟单集成实例
+	// 启动/停止/配置重载与 API 查询（统计、暂停、模拟、接管等）并发访问，只通过下面的访问函数读写
+	integrations   = make(map[string]*TraderIntegration)
+	integrationsMu sync.RWMutex
+
+	// lifecycleLocks 按 trader 串行化启动/停止/配置重载（traderID -> *sync.Mutex）
+	lifecycleLocks sync.Map
 )
 
+// getIntegration 查询 trader 的跟单集成
+func getIntegration(traderID string) (*TraderIntegration, bool) {
+	integrationsMu.RLock()
+	defer integrationsMu.RUnlock()
+	integration, exists := integrations[traderID]
+	return integration, exists
+}
+
+// setIntegration 登记 trader 的跟单集成
+func setIntegration(traderID string, integration *TraderIntegration) {
+	integrationsMu.Lock()
+	defer integrationsMu.Unlock()
+	integrations[traderID] = integration
+}
+
+// removeIntegration 移除 trader 的跟单集成，返回被移除的实例
+func removeIntegration(traderID string) (*TraderIntegration, bool) {
+	integrationsMu.Lock()
+	defer integrationsMu.Unlock()
+	integration, exists := integrations[traderID]
+	delete(integrations, traderID)
+	return integration, exists
+}
+
+// listIntegrations 所有跟单集成的快照
+func listIntegrations() map[string]*TraderIntegration {
+	integrationsMu.RLock()
+	defer integrationsMu.RUnlock()
+	return maps.Clone(integrations)
+}
+
+// lockLifecycle 锁定 trader 的启动/停止/重载，返回解锁函数
+func lockLifecycle(traderID string) func() {
+	mu, _ := lifecycleLocks.LoadOrStore(traderID, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
 // StartCopyTradingForTrader 为指定 trader 启动跟单
 // 这是外部调用的主入口
 func StartCopyTradingForTrader(
 	traderID string,
 	executor DecisionExecutor,
 	st *store.Store,
+	opts ...IntegrationOption,
 ) error {
-	integration := NewTraderIntegration(traderID, executor, st)
-	integrations[traderID] = integration
-	return integration.StartCopyTrading()
+	defer lockLifecycle(traderID)()
+	return startCopyTrading(traderID, executor, st, opts...)
+}
+
+// startCopyTrading 创建并启动跟单集成（调用方持有该 trader 的生命周期锁）
+func startCopyTrading(traderID string, executor DecisionExecutor, st *store.Store, opts ...IntegrationOption) error {
+	integration := NewTraderIntegration(traderID, executor, st, opts...)
+	err := integration.StartCopyTrading()
+	setIntegration(traderID, integration) // 启动完成后再登记，API 不会读到初始化中的引擎
+	return err
 }
 
 // StopCopyTradingForTrader 停止指定 trader 的跟单
 func StopCopyTradingForTrader(traderID string) error {
-	integration, exists := integrations[traderID]
+	defer lockLifecycle(traderID)()
+	return stopCopyTrading(traderID)
+}
+
+// stopCopyTrading 停止并移除跟单集成（调用方持有该 trader 的生命周期锁）
+func stopCopyTrading(traderID string) error {
+	integration, exists := removeIntegration(traderID)
 	if !exists {
 		return fmt.Errorf("no copy trading integration found for trader %s", traderID)
 	}
 
 	integration.Stop()
-	delete(integrations, traderID)
 	return nil
 }
 
 // GetCopyTradingStats 获取跟单统计
 func GetCopyTradingStats(traderID string) *EngineStats {
-	integration, exists := integrations[traderID]
+	integration, exists := getIntegration(traderID)
 	if !exists {
 		return nil
 	}
 	return integration.GetStats()
 }
 
+// GetCopyTradingMappingDrift 获取仓位映射漂移（交易所持仓与 active 映射不一致）
+func GetCopyTradingMappingDrift(traderID string) []MappingDrift {
+	integration, exists := getIntegration(traderID)
+	if !exists || integration.engine == nil {
+		return nil
+	}
+	return integration.engine.GetMappingDrift()
+}
+
+// GetCopyTradingTimings 获取跟单引擎关键路径耗时（未开启 Profiling 时返回 nil）
+func GetCopyTradingTimings(traderID string) map[string]TimingStat {
+	integration, exists := getIntegration(traderID)
+	if !exists || integration.engine == nil {
+		return nil
+	}
+	return integration.engine.GetTimings()
+}
+
+// 配置重新加载结果
+const (
+	ConfigReloadUpdated   = "updated"   // 热更新（仓位映射不变、不重连）
+	ConfigReloadRestarted = "restarted" // 数据源/领航员变化，已重启
+	ConfigReloadStopped   = "stopped"   // 配置已禁用，已停止
+)
+
+// ReloadCopyTradingConfig 将数据库中的最新配置应用到运行中的跟单（保存配置后调用）
+// 非结构性配置直接热更新引擎；数据源或领航员变化时停止后重新启动（重新初始化历史仓位）
+// 同一 trader 的重载与启动/停止串行执行
+func ReloadCopyTradingConfig(traderID string) (string, error) {
+	defer lockLifecycle(traderID)()
+
+	integration, exists := getIntegration(traderID)
+	if !exists || !integration.IsRunning() || integration.engine == nil {
+		return "", fmt.Errorf("copy trading not running for trader %s", traderID)
+	}
+
+	copyConfig, err := integration.store.CopyTrade().GetByTraderID(traderID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get copy trade config: %w", err)
+	}
+
+	if !copyConfig.Enabled {
+		stopCopyTrading(traderID)
+		logger.Infof("🛑 [%s] 跟单配置已禁用，停止跟单", traderID)
+		return ConfigReloadStopped, nil
+	}
+
+	err = integration.updateEngineConfigs(toEngineConfig(copyConfig))
+	if err == nil {
+		return ConfigReloadUpdated, nil
+	}
+	if !errors.Is(err, ErrRestartRequired) {
+		return "", err
+	}
+
+	logger.Infof("🔄 [%s] 数据源/领航员已变更，重启跟单 | provider=%s leader=%s",
+		traderID, copyConfig.ProviderType, copyConfig.LeaderID)
+	stopCopyTrading(traderID)
+	if err := startCopyTrading(traderID, integration.executor, integration.store, integration.opts...); err != nil {
+		return "", fmt.Errorf("failed to restart copy trading: %w", err)
+	}
+	return ConfigReloadRestarted, nil
+}
+
+// AdoptCopyTradingPosition 接管跟随者已有持仓（领航员之后的减仓/平仓按实际持仓跟随）
+func AdoptCopyTradingPosition(traderID, posID string) (*store.CopyTradePositionMapping, error) {
+	integration, exists := getIntegration(traderID)
+	if !exists || !integration.IsRunning() || integration.engine == nil {
+		return nil, fmt.Errorf("copy trading not running for trader %s", traderID)
+	}
+	return integration.engine.AdoptPosition(posID)
+}
+
 // IsCopyTradingRunning 检查跟单是否运行中
 func IsCopyTradingRunning(traderID string) bool {
-	integration, exists := integrations[traderID]
+	integration, exists := getIntegration(traderID)
 	if !exists {
 		return false
 	}
@@ -669,9 +1307,8 @@ func IsCopyTradingRunning(traderID string) bool {
 
 // StopAllCopyTrading 停止所有跟单
 func StopAllCopyTrading() {
-	for traderID, integration := range integrations {
-		integration.Stop()
+	for traderID := range listIntegrations() {
+		StopCopyTradingForTrader(traderID)
 		logger.Infof("🛑 停止跟单: %s", traderID)
 	}
-	integrations = make(map[string]*TraderIntegration)
 }
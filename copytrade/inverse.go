@@ -0,0 +1,41 @@
+package copytrade
+
+// ============================================================================
+// 反向跟单
+// ============================================================================
+//
+// CopyConfig.Inverse 开启时跟随者持有与领航员相反方向的仓位，用于反向跟随持续亏损的领航员：
+//   - 领航员开多/加多 → 跟随者开空/加空，领航员减多/平多 → 跟随者减空/平空（mapAction 翻转方向）
+//   - 仓位映射的 side 记录跟随者实际方向，LeaderPosID 仍为领航员仓位 ID
+//   - 领航员方向与映射方向比较时统一经 followerSide / leaderSide 转换
+//   - ignored 映射不对应跟随者仓位，仍记录领航员方向
+// 仓位大小、杠杆、保证金模式的计算与正向跟单相同。
+
+// InverseMaxLeverage 反向跟单同步领航员杠杆时允许的最大杠杆上限（max_leverage 必须设置且不超过该值）
+const InverseMaxLeverage = 20
+
+// followerSide 领航员方向对应的跟随者方向（反向跟单时取反）
+func (c *CopyConfig) followerSide(leaderSide SideType) SideType {
+	if c.Inverse {
+		return OppositeSide(leaderSide)
+	}
+	return leaderSide
+}
+
+// leaderSide 跟随者方向（映射记录的方向）对应的领航员方向
+func (c *CopyConfig) leaderSide(followerSide SideType) SideType {
+	if c.Inverse {
+		return OppositeSide(followerSide)
+	}
+	return followerSide
+}
+
+// followerSide 按当前配置转换为跟随者方向
+func (e *Engine) followerSide(leaderSide SideType) SideType {
+	return e.cfg().followerSide(leaderSide)
+}
+
+// leaderSide 按当前配置转换为领航员方向
+func (e *Engine) leaderSide(followerSide SideType) SideType {
+	return e.cfg().leaderSide(followerSide)
+}
@@ -0,0 +1,55 @@
+package copytrade
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestInverseCopy 反向跟单：领航员开多 → 跟随者开空，映射记录跟随者方向，领航员平多 → 跟随者平空
+func TestInverseCopy(t *testing.T) {
+	st := newTestStore(t)
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1, Inverse: true}, 1000)
+	e.store = st
+	p := &fakeProvider{}
+	p.setSize(1)
+	e.provider = p
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e, executor: &fakeExecutor{}}
+
+	if !strings.Contains(e.buildSystemPromptLog(), "inverse mode") {
+		t.Error("system prompt log should mention inverse mode")
+	}
+
+	e.processSignal(e.buildSignal(&Fill{ID: "f1", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong,
+		Action: ActionOpen, Price: 10000, Size: 1, Value: 10000}))
+	if len(e.decisionCh) != 1 {
+		t.Fatal("no open decision")
+	}
+	openDec := <-e.decisionCh
+	if got := openDec.Decisions[0].Action; got != "open_short" {
+		t.Fatalf("open action = %s, want open_short", got)
+	}
+	ti.executeFullDecision(openDec)
+
+	ms, err := st.CopyTrade().ListActiveMappings("test")
+	if err != nil || len(ms) != 1 {
+		t.Fatalf("active mappings = %d err=%v", len(ms), err)
+	}
+	if ms[0].Side != "short" || ms[0].LeaderPosID != "BTCUSDT_long" {
+		t.Fatalf("mapping = %s %s, want short mapping for leader BTCUSDT_long", ms[0].LeaderPosID, ms[0].Side)
+	}
+
+	p.state = &AccountState{TotalEquity: 10000, Positions: map[string]*Position{}}
+	e.processSignal(e.buildSignal(&Fill{ID: "f2", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong,
+		Action: ActionClose, Price: 9900, Size: 1, Value: 9900}))
+	if len(e.decisionCh) != 1 {
+		t.Fatal("no close decision")
+	}
+	closeDec := <-e.decisionCh
+	if got := closeDec.Decisions[0].Action; got != "close_short" {
+		t.Fatalf("close action = %s, want close_short", got)
+	}
+	ti.executeFullDecision(closeDec)
+	if m, _ := st.CopyTrade().GetActiveMapping("test", "BTCUSDT_long"); m != nil {
+		t.Errorf("mapping still active after leader close: %+v", m)
+	}
+}
@@ -0,0 +1,363 @@
+package copytrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"nofx/decision"
+	"nofx/logger"
+)
+
+// ============================================================================
+// 领航员挂单（未成交的限价单）
+// ============================================================================
+//
+// 有的领航员会先挂限价单等待成交。GET /api/copytrade/leader-orders 只读查询领航员当前挂单（Hyperliquid frontendOpenOrders）。
+// MirrorLeaderOrders 开启后按比例提前挂限价单建仓（默认关闭，挂单生命周期跟踪较复杂）：
+//   - 只镜像纯开仓挂单：非 reduce-only、非触发单，且领航员在该币种无持仓（加仓/减仓挂单不镜像）
+//   - 每笔挂单只镜像一次，生成限价开仓决策（价格=领航员挂单价，金额按挂单价值计算，超时按 limit_timeout_* 处理）
+//   - 领航员挂单成交后产生的开仓/加仓成交不再重复跟随（只更新 lastKnownSize）；
+//     挂单消失后保留 mirroredOrderGrace，之后该币种恢复正常跟随
+//   - 领航员撤单无法同步撤销跟随者挂单：跟随者挂单最长等待 limit_timeout_seconds，
+//     若已成交而领航员未成交，由仓位对账处理
+
+// DefaultLeaderOrdersPollSeconds 镜像挂单的默认轮询间隔（秒）
+const DefaultLeaderOrdersPollSeconds = 15
+
+// mirroredOrderGrace 领航员挂单消失后仍视为“已镜像”的时间（等待其成交推送到达）
+const mirroredOrderGrace = time.Minute
+
+// ErrOpenOrdersUnsupported 数据源不支持查询挂单
+var ErrOpenOrdersUnsupported = errors.New("provider does not support open orders")
+
+// OpenOrder 领航员挂单
+type OpenOrder struct {
+	OrderID      string    `json:"order_id"`
+	Symbol       string    `json:"symbol"`
+	Side         string    `json:"side"`          // "buy" | "sell"
+	PositionSide SideType  `json:"position_side"` // 开仓挂单对应的仓位方向（buy=long, sell=short；reduce-only 为被减仓位方向）
+	Price        float64   `json:"price"`
+	Size         float64   `json:"size"`       // 剩余未成交数量
+	OrigSize     float64   `json:"orig_size"`  // 原始数量
+	Value        float64   `json:"value"`      // 剩余价值 (USDT)
+	OrderType    string    `json:"order_type"` // 如 "Limit"、"Stop Market"
+	ReduceOnly   bool      `json:"reduce_only"`
+	IsTrigger    bool      `json:"is_trigger"` // 触发单（止盈止损等）
+	TriggerPrice float64   `json:"trigger_price,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// isEntry 是否为纯开仓挂单（非 reduce-only、非触发单）
+func (o *OpenOrder) isEntry() bool {
+	return !o.ReduceOnly && !o.IsTrigger && o.Price > 0 && o.Size > 0
+}
+
+// OpenOrdersProvider 数据源可选能力：查询领航员当前挂单
+type OpenOrdersProvider interface {
+	GetOpenOrders(leaderID string) ([]OpenOrder, error)
+}
+
+// HLOpenOrderRaw frontendOpenOrders 返回结构
+type HLOpenOrderRaw struct {
+	Coin       string `json:"coin"`
+	Side       string `json:"side"` // "B"=买 | "A"=卖
+	LimitPx    string `json:"limitPx"`
+	Sz         string `json:"sz"`
+	OrigSz     string `json:"origSz"`
+	Oid        int64  `json:"oid"`
+	Timestamp  int64  `json:"timestamp"`
+	OrderType  string `json:"orderType"`
+	ReduceOnly bool   `json:"reduceOnly"`
+	IsTrigger  bool   `json:"isTrigger"`
+	TriggerPx  string `json:"triggerPx"`
+}
+
+// GetOpenOrders 获取领航员当前挂单
+func (p *HyperliquidProvider) GetOpenOrders(leaderID string) ([]OpenOrder, error) {
+	req := map[string]string{
+		"type": "frontendOpenOrders",
+		"user": leaderID,
+	}
+
+	var raw []HLOpenOrderRaw
+	if err := p.post(req, &raw); err != nil {
+		return nil, fmt.Errorf("get open orders failed: %w", err)
+	}
+	return parseHLOpenOrders(raw), nil
+}
+
+// GetOpenOrders 获取领航员当前挂单（REST）
+func (p *HLWebSocketProvider) GetOpenOrders(leaderID string) ([]OpenOrder, error) {
+	if p.restProvider == nil {
+		return nil, ErrOpenOrdersUnsupported
+	}
+	return p.restProvider.GetOpenOrders(leaderID)
+}
+
+// parseHLOpenOrders 转换 Hyperliquid 挂单
+func parseHLOpenOrders(raw []HLOpenOrderRaw) []OpenOrder {
+	orders := make([]OpenOrder, 0, len(raw))
+	for _, r := range raw {
+		symbol := normalizeSymbol(r.Coin)
+		if !isValidFillSymbol(symbol) {
+			continue
+		}
+		o := OpenOrder{
+			OrderID:      fmt.Sprintf("%d", r.Oid),
+			Symbol:       symbol,
+			Price:        parseFloat(r.LimitPx),
+			Size:         parseFloat(r.Sz),
+			OrigSize:     parseFloat(r.OrigSz),
+			OrderType:    r.OrderType,
+			ReduceOnly:   r.ReduceOnly,
+			IsTrigger:    r.IsTrigger,
+			TriggerPrice: parseFloat(r.TriggerPx),
+			Timestamp:    time.UnixMilli(r.Timestamp),
+		}
+		// 开仓挂单：买=多、卖=空；reduce-only 挂单减的是反方向仓位
+		o.Side, o.PositionSide = "buy", SideLong
+		if strings.EqualFold(r.Side, "A") {
+			o.Side, o.PositionSide = "sell", SideShort
+		}
+		if o.ReduceOnly {
+			o.PositionSide = OppositeSide(o.PositionSide)
+		}
+		o.Value = o.Price * o.Size
+		orders = append(orders, o)
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].Timestamp.Before(orders[j].Timestamp) })
+	return orders
+}
+
+// GetLeaderOpenOrders 查询领航员当前挂单（只读，不需要启动跟单）
+func GetLeaderOpenOrders(providerType ProviderType, leaderID string) ([]OpenOrder, error) {
+	provider, _, err := BuildProvider(providerType, false)
+	if err != nil {
+		return nil, err
+	}
+	op, ok := provider.(OpenOrdersProvider)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrOpenOrdersUnsupported, providerType)
+	}
+	return op.GetOpenOrders(leaderID)
+}
+
+// mirroredOrder 已镜像的领航员挂单
+type mirroredOrder struct {
+	order    OpenOrder
+	lastSeen time.Time
+	mirrored bool // 已生成限价开仓决策
+}
+
+// leaderOrdersPollInterval 镜像挂单轮询间隔（未开启或数据源不支持时为 0）
+func (e *Engine) leaderOrdersPollInterval() time.Duration {
+	if !e.cfg().MirrorLeaderOrders {
+		return 0
+	}
+	if _, ok := e.provider.(OpenOrdersProvider); !ok {
+		logger.Warnf("⚠️ [%s] 数据源 %s 不支持查询挂单，mirror_leader_orders 不生效", e.traderID, e.cfg().ProviderType)
+		return 0
+	}
+	if e.cfg().LeaderOrdersPollSeconds > 0 {
+		return time.Duration(e.cfg().LeaderOrdersPollSeconds) * time.Second
+	}
+	return DefaultLeaderOrdersPollSeconds * time.Second
+}
+
+// leaderOrdersLoop 定时拉取领航员挂单并镜像新的开仓挂单
+func (e *Engine) leaderOrdersLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.stopCh:
+			return
+		case <-ticker.C:
+			e.mirrorLeaderOrders()
+		}
+	}
+}
+
+// mirrorLeaderOrders 拉取一次领航员挂单，为新的开仓挂单生成限价开仓决策
+func (e *Engine) mirrorLeaderOrders() {
+	op, ok := e.provider.(OpenOrdersProvider)
+	if !ok {
+		return
+	}
+	orders, err := op.GetOpenOrders(e.cfg().LeaderID)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 获取领航员挂单失败: %v", e.traderID, err)
+		return
+	}
+
+	now := time.Now()
+	fresh := e.trackLeaderOrders(orders, now)
+
+	e.cfgMu.RLock()
+	defer e.cfgMu.RUnlock()
+	if !e.cfg().MirrorLeaderOrders {
+		return // 热更新关闭
+	}
+	leaderPosMap := e.buildLeaderPosMap()
+	for i := range fresh {
+		o := &fresh[i]
+		if reason := e.skipMirrorReason(o, leaderPosMap); reason != "" {
+			logger.Infof("📋 [%s] 领航员挂单不镜像 | %s %s %.4f@%.4f | 原因: %s",
+				e.traderID, o.Symbol, o.Side, o.Size, o.Price, reason)
+			continue
+		}
+		e.pushMirroredOrder(o)
+	}
+}
+
+// trackLeaderOrders 记录当前挂单，返回首次出现的挂单；清理消失超过 mirroredOrderGrace 的记录
+func (e *Engine) trackLeaderOrders(orders []OpenOrder, now time.Time) []OpenOrder {
+	e.mirroredMu.Lock()
+	defer e.mirroredMu.Unlock()
+
+	if e.mirroredOrders == nil {
+		e.mirroredOrders = make(map[string]*mirroredOrder)
+	}
+	var fresh []OpenOrder
+	for _, o := range orders {
+		if m, ok := e.mirroredOrders[o.OrderID]; ok {
+			m.lastSeen = now
+			continue
+		}
+		e.mirroredOrders[o.OrderID] = &mirroredOrder{order: o, lastSeen: now}
+		fresh = append(fresh, o)
+	}
+	for id, m := range e.mirroredOrders {
+		if now.Sub(m.lastSeen) > mirroredOrderGrace {
+			delete(e.mirroredOrders, id)
+		}
+	}
+	return fresh
+}
+
+// skipMirrorReason 不镜像该挂单的原因（空串=镜像，调用方持有 cfgMu 读锁）
+func (e *Engine) skipMirrorReason(o *OpenOrder, leaderPosMap map[string]*Position) string {
+	switch {
+	case !o.isEntry():
+		return "非开仓挂单（reduce-only/触发单）"
+	case InMaintenance():
+		return "maintenance mode"
+	case e.IsPaused():
+		return SkipReasonEnginePaused
+	case e.IsDegraded() || e.stats.CloseOnly:
+		return "引擎暂停开仓（降级/只平仓）"
+	case !e.isSymbolEnabled(o.Symbol):
+		return "symbol disabled"
+	case !e.inTradingWindow(time.Now()):
+		return "outside trading window"
+	}
+	for _, pos := range leaderPosMap {
+		if pos.Symbol == o.Symbol {
+			return "领航员已持有该币种（加仓/反向挂单不镜像）"
+		}
+	}
+	if e.store != nil {
+		if m, err := e.store.CopyTrade().GetMapping(e.traderID, e.positionKey(o.Symbol, o.PositionSide)); err == nil && m != nil && m.Status == "active" {
+			return "跟随者已有该仓位"
+		}
+	}
+	return ""
+}
+
+// pushMirroredOrder 为领航员开仓挂单生成限价开仓决策（调用方持有 cfgMu 读锁）
+func (e *Engine) pushMirroredOrder(o *OpenOrder) {
+	fill := &Fill{
+		ID:           "order-" + o.OrderID,
+		Symbol:       o.Symbol,
+		Side:         o.Side,
+		PositionSide: o.PositionSide,
+		Action:       ActionOpen,
+		Price:        o.Price,
+		Size:         o.Size,
+		Value:        o.Value,
+		Timestamp:    time.Now(),
+	}
+	signal := e.buildSignal(fill)
+	match := &SignalMatchResult{
+		ShouldFollow:   true,
+		Action:         ActionOpen,
+		PosID:          e.positionKey(o.Symbol, o.PositionSide),
+		LeaderPosition: &Position{Symbol: o.Symbol, Side: o.PositionSide, Size: o.Size, EntryPrice: o.Price},
+		Reason:         "领航员开仓挂单",
+	}
+	signal.LeaderPosID = match.PosID
+	signal.LeaderPosition = match.LeaderPosition
+
+	copySize, warnings := e.calculateCopySizeByPositionChange(signal, match)
+	for _, w := range warnings {
+		e.logWarning(w)
+	}
+	copySize = e.capByFreeMargin(signal, copySize)
+	if copySize <= 0 || !e.withinLeaderBudget(copySize) {
+		logger.Infof("📋 [%s] 领航员挂单不镜像 | %s | 原因: 跟单金额为 0 或超出领航员预算", e.traderID, o.Symbol)
+		return
+	}
+
+	dec := e.buildDecisionV2(signal, match, copySize)
+	dec.Reasoning = fmt.Sprintf("Copy trading: pre-position resting %s order %s of %s leader %s",
+		o.Side, o.OrderID, e.cfg().ProviderType, e.cfg().LeaderID)
+	dec.OrderType = decision.OrderTypeLimit
+	dec.LimitPrice = o.Price
+	dec.LimitTimeoutSec = e.cfg().LimitTimeoutSeconds
+	dec.LimitTimeoutAction = LimitTimeoutCancel
+
+	fullDec := &decision.FullDecision{
+		SystemPrompt: e.buildSystemPromptLog(),
+		UserPrompt:   fmt.Sprintf("## Leader Resting Order\n\norderId: %s\n%s %s %.4f @ %.4f\n", o.OrderID, o.Symbol, o.Side, o.Size, o.Price),
+		CoTTrace:     fmt.Sprintf("# Copy Trading Decision\n\nLeader placed a resting %s entry for %s. Pre-positioning with a limit order at the same price.\n", o.Side, o.Symbol),
+		Decisions:    []decision.Decision{dec},
+		RawResponse:  fmt.Sprintf("Copy trade resting order mirror for %s:%s", e.cfg().ProviderType, e.cfg().LeaderID),
+		Timestamp:    time.Now(),
+	}
+	if e.pushDecision(fullDec) {
+		e.mirroredMu.Lock()
+		if m := e.mirroredOrders[o.OrderID]; m != nil {
+			m.mirrored = true
+		}
+		e.mirroredMu.Unlock()
+		logger.Infof("📋 [%s] 镜像领航员挂单 | %s %s %.4f@%.4f → 限价开仓 金额=%.2f",
+			e.traderID, o.Symbol, o.Side, o.Size, o.Price, copySize)
+	}
+}
+
+// mirroredEntryFill 成交来自已镜像的领航员挂单：跟随者已提前建仓，只更新 lastKnownSize，不重复跟随
+func (e *Engine) mirroredEntryFill(fill *Fill) bool {
+	if fill.Action != ActionOpen && fill.Action != ActionAdd {
+		return false
+	}
+
+	e.mirroredMu.Lock()
+	found := false
+	for _, m := range e.mirroredOrders {
+		if m.mirrored && m.order.Symbol == fill.Symbol && m.order.PositionSide == fill.PositionSide {
+			found = true
+			break
+		}
+	}
+	e.mirroredMu.Unlock()
+	if !found || e.store == nil {
+		return found
+	}
+
+	posID := e.positionKey(fill.Symbol, fill.PositionSide)
+	if pos := e.buildLeaderPosMap()[posID]; pos != nil {
+		if m, err := e.store.CopyTrade().GetActiveMapping(e.traderID, posID); err == nil && m != nil {
+			if err := e.store.CopyTrade().UpdateLastKnownSize(e.traderID, posID, pos.Size); err != nil {
+				logger.Warnf("⚠️ [%s] 更新 lastKnownSize 失败: %v", e.traderID, err)
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package copytrade
+
+import (
+	"testing"
+
+	"nofx/decision"
+)
+
+// ordersProvider 带挂单的领航员数据源
+type ordersProvider struct {
+	*fakeProvider
+	orders []OpenOrder
+}
+
+func (p *ordersProvider) GetOpenOrders(leaderID string) ([]OpenOrder, error) { return p.orders, nil }
+
+func TestParseHLOpenOrders(t *testing.T) {
+	orders := parseHLOpenOrders([]HLOpenOrderRaw{
+		{Coin: "ETH", Side: "A", LimitPx: "2000", Sz: "1", Oid: 2, Timestamp: 2000, ReduceOnly: true},
+		{Coin: "BTC", Side: "B", LimitPx: "100", Sz: "2", OrigSz: "3", Oid: 1, Timestamp: 1000, OrderType: "Limit"},
+		{Coin: "", Side: "B", LimitPx: "1", Sz: "1", Oid: 3},
+	})
+	if len(orders) != 2 {
+		t.Fatalf("orders = %+v, want 2", orders)
+	}
+	btc, eth := orders[0], orders[1]
+	if btc.OrderID != "1" || btc.Symbol != "BTCUSDT" || btc.Side != "buy" || btc.PositionSide != SideLong ||
+		btc.Value != 200 || !btc.isEntry() {
+		t.Errorf("btc = %+v", btc)
+	}
+	// reduce-only 卖单减的是多仓
+	if eth.Side != "sell" || eth.PositionSide != SideLong || eth.isEntry() {
+		t.Errorf("eth = %+v", eth)
+	}
+}
+
+// TestMirrorLeaderOrders 镜像开仓挂单为限价开仓，每笔只镜像一次；挂单成交不重复跟随
+func TestMirrorLeaderOrders(t *testing.T) {
+	provider := &ordersProvider{
+		fakeProvider: &fakeProvider{state: &AccountState{TotalEquity: 10000, Positions: map[string]*Position{}}},
+		orders: []OpenOrder{
+			{OrderID: "1", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Price: 100, Size: 2, Value: 200},
+			{OrderID: "2", Symbol: "ETHUSDT", Side: "sell", PositionSide: SideLong, Price: 10, Size: 1, Value: 10, ReduceOnly: true},
+		},
+	}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1,
+		MirrorLeaderOrders: true, LimitTimeoutSeconds: 20}, 10000)
+	e.store = newTestStore(t)
+	e.provider = provider
+	if err := e.syncLeaderState(); err != nil {
+		t.Fatal(err)
+	}
+
+	e.mirrorLeaderOrders()
+	if n := len(e.decisionCh); n != 1 {
+		t.Fatalf("decisions = %d, want 1", n)
+	}
+	dec := (<-e.decisionCh).Decisions[0]
+	if dec.Action != "open_long" || dec.OrderType != decision.OrderTypeLimit || dec.LimitPrice != 100 ||
+		dec.LimitTimeoutSec != 20 || dec.LeaderPosID != PositionKey("BTCUSDT", SideLong) || dec.PositionSizeUSD <= 0 {
+		t.Errorf("mirrored decision = %+v", dec)
+	}
+
+	// 同一挂单不重复镜像
+	e.mirrorLeaderOrders()
+	if n := len(e.decisionCh); n != 0 {
+		t.Fatalf("second poll decisions = %d, want 0", n)
+	}
+
+	// 领航员挂单成交：已提前建仓，不重复跟随
+	provider.fakeProvider.setSize(2)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "f1", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong,
+		Action: ActionOpen, Price: 100, Size: 2, Value: 200}})
+	if n := len(e.decisionCh); n != 0 {
+		t.Errorf("fill of mirrored order followed again: decisions = %d", n)
+	}
+
+	// 关闭后不再镜像新挂单
+	withConfig(e, func(c *CopyConfig) { c.MirrorLeaderOrders = false })
+	provider.orders = append(provider.orders, OpenOrder{OrderID: "3", Symbol: "SOLUSDT", Side: "buy", PositionSide: SideLong, Price: 5, Size: 1, Value: 5})
+	e.mirrorLeaderOrders()
+	if n := len(e.decisionCh); n != 0 {
+		t.Errorf("disabled: decisions = %d, want 0", n)
+	}
+}
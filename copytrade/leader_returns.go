@@ -0,0 +1,27 @@
+package copytrade
+
+import "nofx/logger"
+
+// ============================================================================
+// 领航员已实现收益率
+// ============================================================================
+//
+// 领航员减仓/平仓成交带有 ClosedPnL，按匹配到的跟单仓位映射记录下来，
+// 仓位平仓后由 store.GetLeaderReturnDistribution 结合映射的开仓价计算领航员已实现收益率，
+// 在跟单看板按领航员展示收益率分布。
+
+// recordLeaderClosedPnL 记录领航员减仓/平仓成交的平仓盈亏（无映射、无盈亏或无成交 ID 时忽略）
+func (e *Engine) recordLeaderClosedPnL(fill *Fill, match *SignalMatchResult) {
+	if e.store == nil || match.PosID == "" || fill.ID == "" || fill.ClosedPnL == 0 ||
+		(match.Action != ActionReduce && match.Action != ActionClose) {
+		return
+	}
+	// 平仓数量按成交价值折算为基础币数量（OKX 成交数量可能是合约张数）
+	closedSize := fill.Size
+	if fill.Value > 0 && fill.Price > 0 {
+		closedSize = fill.Value / fill.Price
+	}
+	if err := e.store.CopyTrade().SaveLeaderClosedPnL(e.traderID, match.PosID, fill.ID, fill.ClosedPnL, closedSize); err != nil {
+		logger.Warnf("⚠️ [%s] 记录领航员平仓盈亏失败: %v (posId=%s)", e.traderID, err, match.PosID)
+	}
+}
@@ -0,0 +1,73 @@
+package copytrade
+
+import (
+	"fmt"
+	"time"
+)
+
+// LeaderStatusLookback 查询领航员最近成交的回溯窗口
+const LeaderStatusLookback = 30 * 24 * time.Hour
+
+// LeaderStatus 领航员数据新鲜度诊断（只读，不启动跟单）
+type LeaderStatus struct {
+	ProviderType  ProviderType `json:"provider_type"`
+	LeaderID      string       `json:"leader_id"`
+	LastTradeAt   *time.Time   `json:"last_trade_at"`            // 最近一笔成交时间（回溯窗口内无成交为 nil）
+	RecentFills   int          `json:"recent_fills"`             // 回溯窗口内的成交笔数
+	HasPositions  bool         `json:"has_positions"`            // 当前是否持仓
+	PositionCount int          `json:"position_count"`           // 当前持仓数
+	TotalEquity   float64      `json:"total_equity"`             // 当前权益
+	ProfilePublic *bool        `json:"profile_public,omitempty"` // OKX：主页是否公开可查（Hyperliquid 链上数据始终公开）
+	Active        bool         `json:"active"`                   // 回溯窗口内有成交或当前有持仓
+	Errors        []string     `json:"errors,omitempty"`         // 查询失败原因
+	CheckedAt     time.Time    `json:"checked_at"`
+}
+
+// CheckLeaderStatus 查询领航员最近成交与持仓情况，帮助用户在跟单前识别不活跃或未公开的领航员
+func CheckLeaderStatus(providerType ProviderType, leaderID string) (*LeaderStatus, error) {
+	provider, caps, err := BuildProvider(providerType, false)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &LeaderStatus{
+		ProviderType: providerType,
+		LeaderID:     leaderID,
+		CheckedAt:    time.Now(),
+	}
+
+	fills, fillsErr := provider.GetFills(leaderID, time.Now().Add(-LeaderStatusLookback))
+	if fillsErr != nil {
+		status.Errors = append(status.Errors, fmt.Sprintf("get fills: %v", fillsErr))
+	} else {
+		status.RecentFills = len(fills)
+		for i := range fills {
+			if status.LastTradeAt == nil || fills[i].Timestamp.After(*status.LastTradeAt) {
+				ts := fills[i].Timestamp
+				status.LastTradeAt = &ts
+			}
+		}
+	}
+
+	state, stateErr := provider.GetAccountState(leaderID)
+	if stateErr != nil {
+		status.Errors = append(status.Errors, fmt.Sprintf("get account state: %v", stateErr))
+	} else {
+		status.PositionCount = len(state.Positions)
+		status.HasPositions = status.PositionCount > 0
+		status.TotalEquity = state.TotalEquity
+	}
+
+	// 私密主页的公开接口会返回错误（如 OKX），两个接口都成功才视为公开
+	if caps.PrivateProfiles {
+		public := fillsErr == nil && stateErr == nil
+		status.ProfilePublic = &public
+	}
+
+	if fillsErr != nil && stateErr != nil {
+		return status, fmt.Errorf("leader %s unavailable: %v", leaderID, stateErr)
+	}
+
+	status.Active = status.RecentFills > 0 || status.HasPositions
+	return status, nil
+}
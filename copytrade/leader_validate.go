@@ -0,0 +1,41 @@
+package copytrade
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ============================================================================
+// 领航员校验
+// ============================================================================
+//
+// 领航员地址/uniqueName 填错时引擎照常启动，但永远收不到成交。保存配置和启动跟单前先校验：
+//   - Hyperliquid：地址必须是 0x + 40 位十六进制，再请求一次 clearinghouseState
+//   - OKX：请求一次资产接口，返回非 0 错误码（交易员不存在/主页未公开）视为无效
+// 链上地址无法区分"从未交易的地址"和"填错的地址"，Hyperliquid 只能保证格式正确且接口可查询。
+
+// ErrLeaderNotFound 领航员不存在或无法查询
+var ErrLeaderNotFound = errors.New("leader not found")
+
+// ErrInvalidLeaderAddress Hyperliquid 领航员地址格式错误
+var ErrInvalidLeaderAddress = errors.New("invalid hyperliquid address")
+
+var hlAddressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// ValidateHLAddress 校验 Hyperliquid 地址格式（0x + 40 位十六进制）
+func ValidateHLAddress(address string) error {
+	if !hlAddressPattern.MatchString(address) {
+		return fmt.Errorf("%w: %q (expected 0x followed by 40 hex characters)", ErrInvalidLeaderAddress, address)
+	}
+	return nil
+}
+
+// ValidateLeader 用对应数据源校验领航员是否存在
+func ValidateLeader(providerType ProviderType, leaderID string) error {
+	provider, err := NewProvider(providerType)
+	if err != nil {
+		return err
+	}
+	return provider.ValidateLeader(leaderID)
+}
@@ -0,0 +1,37 @@
+package copytrade
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// TestValidateLeader Hyperliquid 先校验地址格式，OKX 按资产接口错误码判断交易员是否存在
+func TestValidateLeader(t *testing.T) {
+	for _, addr := range []string{"", "0xabc", "1234567890abcdef1234567890abcdef12345678", "0x1234567890abcdef1234567890abcdef1234567g"} {
+		if err := ValidateHLAddress(addr); !errors.Is(err, ErrInvalidLeaderAddress) {
+			t.Errorf("ValidateHLAddress(%q) = %v, want ErrInvalidLeaderAddress", addr, err)
+		}
+	}
+
+	// 格式错误时即使接口可查询也拒绝
+	hl := &HyperliquidProvider{client: &http.Client{Transport: stubTransport{body: `{"marginSummary":{"accountValue":"0"},"withdrawable":"0","assetPositions":[],"time":1700000000000}`}}}
+	if err := hl.ValidateLeader("0xabc"); !errors.Is(err, ErrInvalidLeaderAddress) {
+		t.Errorf("hl invalid address: err = %v", err)
+	}
+	if err := hl.ValidateLeader("0x1234567890ABCDEF1234567890abcdef12345678"); err != nil {
+		t.Errorf("hl valid address: err = %v", err)
+	}
+
+	okx := &OKXProvider{client: &http.Client{Transport: stubTransport{body: `{"code":"0","data":[{"currency":"USDT","amount":"100"}]}`}}, limiter: newRateLimiter(100)}
+	if err := okx.ValidateLeader("TraderA"); err != nil {
+		t.Errorf("okx existing trader: err = %v", err)
+	}
+	okx.client = &http.Client{Transport: stubTransport{body: `{"code":"51000","msg":"Parameter uniqueName error","data":[]}`}}
+	if err := okx.ValidateLeader("nobody"); !errors.Is(err, ErrLeaderNotFound) {
+		t.Errorf("okx unknown trader: err = %v, want ErrLeaderNotFound", err)
+	}
+	if err := okx.ValidateLeader(" "); !errors.Is(err, ErrLeaderNotFound) {
+		t.Errorf("okx empty uniqueName: err = %v, want ErrLeaderNotFound", err)
+	}
+}
@@ -0,0 +1,74 @@
+package copytrade
+
+import (
+	"sync"
+	"time"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 全局维护模式：交易所维护窗口或自身发版期间暂停所有引擎开仓
+// ============================================================================
+//
+// 与停止跟单不同，维护模式下引擎照常运行：继续同步领航员状态、跟随减仓/平仓，
+// 只跳过开仓/加仓。维护期间领航员的新开仓标记为 ignored，结束后其加仓也不跟随。
+// 状态只保存在内存中，进程重启后自动解除。
+
+// MaintenanceState 维护模式状态
+type MaintenanceState struct {
+	Enabled bool       `json:"enabled"`
+	Reason  string     `json:"reason,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+	Until   *time.Time `json:"until,omitempty"` // 自动解除时间（nil=手动解除）
+}
+
+var (
+	maintenance   MaintenanceState
+	maintenanceMu sync.RWMutex
+)
+
+// SetMaintenance 开启/关闭全局维护模式，duration > 0 时到期自动解除
+func SetMaintenance(enabled bool, reason string, duration time.Duration) MaintenanceState {
+	maintenanceMu.Lock()
+	defer maintenanceMu.Unlock()
+
+	if !enabled {
+		maintenance = MaintenanceState{}
+		logger.Infof("🔧 跟单维护模式已关闭，恢复开仓")
+		return maintenance
+	}
+
+	now := time.Now()
+	maintenance = MaintenanceState{Enabled: true, Reason: reason, Since: &now}
+	if duration > 0 {
+		until := now.Add(duration)
+		maintenance.Until = &until
+	}
+	logger.Infof("🔧 跟单维护模式已开启：所有引擎暂停开仓/加仓，继续跟随减仓/平仓 | 原因=%s 持续=%s", reason, duration)
+	return maintenance
+}
+
+// GetMaintenance 获取当前维护模式状态（已到期的自动解除）
+func GetMaintenance() MaintenanceState {
+	maintenanceMu.RLock()
+	state := maintenance
+	maintenanceMu.RUnlock()
+
+	if state.Enabled && state.Until != nil && time.Now().After(*state.Until) {
+		maintenanceMu.Lock()
+		// 双重检查：期间可能已被重新设置
+		if maintenance.Until != nil && time.Now().After(*maintenance.Until) {
+			maintenance = MaintenanceState{}
+			logger.Infof("🔧 跟单维护模式已到期，恢复开仓")
+		}
+		state = maintenance
+		maintenanceMu.Unlock()
+	}
+	return state
+}
+
+// InMaintenance 是否处于维护模式
+func InMaintenance() bool {
+	return GetMaintenance().Enabled
+}
@@ -0,0 +1,82 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+
+	"nofx/decision"
+)
+
+// TestMaintenanceMode 维护模式：跳过开仓/加仓，照常跟随减仓/平仓，到期自动解除
+func TestMaintenanceMode(t *testing.T) {
+	t.Cleanup(func() { SetMaintenance(false, "", 0) })
+
+	st := newTestStore(t)
+	provider := &fakeProvider{}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.store = st
+	e.provider = provider
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e}
+
+	next := func() *decision.Decision {
+		select {
+		case fullDec := <-e.decisionCh:
+			dec := fullDec.Decisions[0]
+			ti.updatePositionMapping(&dec)
+			return &dec
+		default:
+			return nil
+		}
+	}
+
+	// 维护前开仓
+	provider.setSize(2)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "open", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 2, Value: 200}})
+	if dec := next(); dec == nil || dec.Action != "open_long" {
+		t.Fatalf("open before maintenance = %+v, want open_long", dec)
+	}
+
+	SetMaintenance(true, "exchange upgrade", 0)
+
+	// 加仓跳过
+	provider.setSize(3)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "add", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionAdd, Price: 100, Size: 1, Value: 100}})
+	if dec := next(); dec != nil {
+		t.Fatalf("add during maintenance = %+v, want skip", dec)
+	}
+
+	// 减仓照常跟随
+	provider.setSize(1)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "reduce", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionReduce, Price: 100, Size: 2, Value: 200}})
+	if dec := next(); dec == nil || dec.Action != "reduce_long" {
+		t.Fatalf("reduce during maintenance = %+v, want reduce_long", dec)
+	}
+
+	// 平仓照常跟随
+	provider.state.Positions = map[string]*Position{}
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "close", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionClose, Price: 100, Size: 1, Value: 100}})
+	if dec := next(); dec == nil || dec.Action != "close_long" {
+		t.Fatalf("close during maintenance = %+v, want close_long", dec)
+	}
+
+	// 维护期间的新开仓跳过并标记 ignored
+	provider.setSize(1)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "reopen", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 1, Value: 100}})
+	if dec := next(); dec != nil {
+		t.Fatalf("open during maintenance = %+v, want skip", dec)
+	}
+	m, err := st.CopyTrade().GetMapping("test", PositionKey("BTCUSDT", SideLong))
+	if err != nil || m == nil || m.Status != "ignored" {
+		t.Fatalf("mapping after skipped open = %+v (err=%v), want ignored", m, err)
+	}
+
+	// 到期自动解除
+	state := SetMaintenance(true, "deploy", time.Millisecond)
+	if !state.Enabled || state.Until == nil {
+		t.Fatalf("state = %+v, want enabled with until", state)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if InMaintenance() {
+		t.Fatalf("maintenance should expire")
+	}
+}
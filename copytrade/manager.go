@@ -98,6 +98,18 @@ func (m *Manager) RestartEngine(
 	return m.StartEngine(traderID, config, getBalance, getPositions)
 }
 
+// UpdateEngineConfig 热更新指定 trader 的引擎配置（不重启、不影响仓位映射）
+// 数据源或领航员变化时返回 ErrRestartRequired，调用方应改用 RestartEngine
+func (m *Manager) UpdateEngineConfig(traderID string, config *CopyConfig) error {
+	m.mu.RLock()
+	engine, exists := m.engines[traderID]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("engine not found for trader %s", traderID)
+	}
+	return engine.UpdateConfig(config)
+}
+
 // GetEngine 获取指定 trader 的引擎
 func (m *Manager) GetEngine(traderID string) *Engine {
 	m.mu.RLock()
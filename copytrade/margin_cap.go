@@ -0,0 +1,82 @@
+package copytrade
+
+import (
+	"fmt"
+	"time"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 单笔跟单占用保证金上限
+// ============================================================================
+//
+// 跟单系数较大、领航员单笔交易占其（小）账户比例较高时，跟单金额可能超过跟随者整个账户，
+// MaxTradeWarn 只预警不阻止。MaxMarginFraction 限制单笔开仓/加仓占用的保证金：
+// 所需保证金 = 跟单金额 / 杠杆，不得超过 跟随者可用保证金 × MaxMarginFraction。
+//   - clamp（默认）：缩小到上限
+//   - skip：跳过该信号
+// 两种情况都记录 margin_cap 预警。可用保证金来自执行器账户信息（available_balance），缺失时使用账户权益。
+
+// 超出保证金上限的处理方式
+const (
+	MarginCapClamp = "clamp" // 缩小到上限（默认）
+	MarginCapSkip  = "skip"  // 跳过信号
+)
+
+// WithFreeMargin 注入跟随者可用保证金查询（通常来自执行器账户信息）
+func WithFreeMargin(query func() float64) EngineOption {
+	return func(e *Engine) {
+		e.getFollowerFreeMargin = query
+	}
+}
+
+// capByFreeMargin 按 MaxMarginFraction 限制开仓/加仓金额（返回 0 表示跳过）
+func (e *Engine) capByFreeMargin(signal *TradeSignal, copySize float64) float64 {
+	fraction := e.cfg().MaxMarginFraction
+	if fraction <= 0 || copySize <= 0 {
+		return copySize
+	}
+
+	freeMargin := 0.0
+	if e.getFollowerFreeMargin != nil {
+		freeMargin = e.getFollowerFreeMargin()
+	}
+	if freeMargin <= 0 {
+		freeMargin = e.getFollowerBalance()
+	}
+	leverage := e.followerLeverage(signal)
+	if leverage <= 0 {
+		leverage = 1
+	}
+
+	limit := freeMargin * fraction * float64(leverage)
+	if copySize <= limit {
+		return copySize
+	}
+
+	fill := signal.Fill
+	w := Warning{
+		Timestamp:   time.Now(),
+		Symbol:      fill.Symbol,
+		Type:        "margin_cap",
+		SignalValue: fill.Value,
+	}
+	if e.cfg().MarginCapPolicy == MarginCapSkip || limit <= 0 {
+		w.Message = fmt.Sprintf("跟单金额 %.2f 需保证金 %.2f，超过可用保证金 %.2f 的 %.0f%%，跳过",
+			copySize, copySize/float64(leverage), freeMargin, fraction*100)
+		e.logWarning(w)
+		logger.Infof("🛑 [%s] 保证金上限 | %s 跟单=%.2f 上限=%.2f（可用=%.2f × %.0f%% × %dx）→ 跳过",
+			e.traderID, fill.Symbol, copySize, limit, freeMargin, fraction*100, leverage)
+		return 0
+	}
+
+	w.Message = fmt.Sprintf("跟单金额 %.2f 需保证金 %.2f，超过可用保证金 %.2f 的 %.0f%%，缩小到 %.2f",
+		copySize, copySize/float64(leverage), freeMargin, fraction*100, limit)
+	w.CopyValue = limit
+	w.Executed = true
+	e.logWarning(w)
+	logger.Infof("🛑 [%s] 保证金上限 | %s 跟单=%.2f 上限=%.2f（可用=%.2f × %.0f%% × %dx）→ 缩小",
+		e.traderID, fill.Symbol, copySize, limit, freeMargin, fraction*100, leverage)
+	return limit
+}
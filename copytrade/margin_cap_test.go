@@ -0,0 +1,70 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+)
+
+// TestMarginCap 大跟单系数 + 小账户：单笔跟单所需保证金超过可用保证金比例时缩小或跳过
+func TestMarginCap(t *testing.T) {
+	run := func(t *testing.T, policy string) (decisions []float64, warnings []Warning) {
+		t.Helper()
+		// 领航员权益 1000，开仓 8 × 100 = 800（占 80%），杠杆 2x
+		provider := &fakeProvider{state: &AccountState{TotalEquity: 1000, Positions: map[string]*Position{
+			PositionKey("ETHUSDT", SideLong): {Symbol: "ETHUSDT", Side: SideLong, Size: 8, MarginMode: "cross", Leverage: 2},
+		}}}
+		// 跟随者权益 100，系数 5 → 跟单 400（需保证金 200）；上限 = 可用 100 × 50% × 2x = 100
+		e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 5, SyncLeverage: true,
+			MaxMarginFraction: 0.5, MarginCapPolicy: policy, WarningHistoryLimit: -1}, 100)
+		e.store = newTestStore(t)
+		e.provider = provider
+		e.getFollowerFreeMargin = func() float64 { return 100 }
+
+		e.processSignal(e.buildSignal(&Fill{ID: "f1", Symbol: "ETHUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen,
+			Price: 100, Size: 8, Value: 800, Timestamp: time.Now()}))
+		for len(e.decisionCh) > 0 {
+			for _, d := range (<-e.decisionCh).Decisions {
+				decisions = append(decisions, d.PositionSizeUSD)
+			}
+		}
+		for _, w := range e.warnings {
+			if w.Type == "margin_cap" {
+				warnings = append(warnings, w)
+			}
+		}
+		return decisions, warnings
+	}
+
+	decisions, warnings := run(t, "")
+	if len(decisions) != 1 || decisions[0] != 100 {
+		t.Errorf("clamp decisions = %v, want [100]", decisions)
+	}
+	if len(warnings) != 1 || !warnings[0].Executed || warnings[0].CopyValue != 100 {
+		t.Errorf("clamp warnings = %+v, want one executed margin_cap", warnings)
+	}
+
+	decisions, warnings = run(t, MarginCapSkip)
+	if len(decisions) != 0 {
+		t.Errorf("skip decisions = %v, want none", decisions)
+	}
+	if len(warnings) != 1 || warnings[0].Executed {
+		t.Errorf("skip warnings = %+v, want one blocking margin_cap", warnings)
+	}
+}
+
+// TestMarginCapWithinLimit 未超出上限或未配置时金额不变
+func TestMarginCapWithinLimit(t *testing.T) {
+	e := newTestEngine(&CopyConfig{MaxMarginFraction: 0.5}, 1000)
+	signal := &TradeSignal{Fill: &Fill{Symbol: "BTCUSDT", PositionSide: SideLong}}
+	// 无可用保证金查询时使用权益：上限 = 1000 × 50% × 10x（默认杠杆）
+	if got := e.capByFreeMargin(signal, 4000); got != 4000 {
+		t.Errorf("within limit = %.2f, want 4000", got)
+	}
+	if got := e.capByFreeMargin(signal, 6000); got != 5000 {
+		t.Errorf("over limit = %.2f, want 5000", got)
+	}
+	withConfig(e, func(c *CopyConfig) { c.MaxMarginFraction = 0 })
+	if got := e.capByFreeMargin(signal, 60000); got != 60000 {
+		t.Errorf("disabled = %.2f, want 60000", got)
+	}
+}
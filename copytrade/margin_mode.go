@@ -0,0 +1,155 @@
+package copytrade
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/store"
+)
+
+// ============================================================================
+// 跟随领航员保证金模式切换（全仓 ↔ 逐仓）
+// ============================================================================
+//
+// 领航员把已有仓位从全仓切换为逐仓（或反之）后，映射中的 MarginMode 过期，
+// 之后按模式匹配（OKX 全仓/逐仓是独立仓位）和按模式统计跟随者持仓都会出错。
+// 每次同步领航员状态时对比 active 映射与领航员当前仓位的模式：
+//   - 有 posId 的仓位：posId 不变，直接更新映射的模式
+//   - 按模式区分 key 的仓位（无 posId 的 OKX 逐仓）：原 key 消失、另一模式的 key 出现，映射随之改 key
+// 开启 SyncMarginMode 时同时发出 set_margin_mode 决策，让跟随者也切换（跟随者持仓中切换可能被交易所拒绝，只记录日志）。
+
+// checkMarginModeChanges 检测领航员仓位保证金模式变化，更新映射并按配置发出设置决策
+func (e *Engine) checkMarginModeChanges() {
+	if e.store == nil {
+		return
+	}
+	mappings, err := e.listActiveMappings()
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 获取 active 映射失败: %v", e.traderID, err)
+		return
+	}
+	if len(mappings) == 0 {
+		return
+	}
+
+	leaderPosMap := e.buildLeaderPosMap()
+	var decisions []decision.Decision
+	for _, m := range mappings {
+		newPosID, pos := m.LeaderPosID, leaderPosMap[m.LeaderPosID]
+		if pos == nil {
+			// 虚拟 posId 含保证金模式：按去掉领航员前缀的 ID 推算切换后的 ID
+			newPosID, _ = switchedModeKey(nil, e.unscopePosID(m.LeaderPosID), m.Symbol, e.leaderSide(SideType(m.Side)), m.MarginMode)
+			newPosID = e.scopePosID(newPosID)
+			pos = leaderPosMap[newPosID]
+		}
+		if pos == nil || string(e.followerSide(pos.Side)) != m.Side || pos.MarginMode == "" || pos.MarginMode == m.MarginMode {
+			continue
+		}
+
+		if err := e.store.CopyTrade().UpdateMappingMarginMode(e.traderID, m.LeaderPosID, newPosID, pos.MarginMode); err != nil {
+			logger.Warnf("⚠️ [%s] 更新映射保证金模式失败: %v (posId=%s)", e.traderID, err, m.LeaderPosID)
+			continue
+		}
+		logger.Infof("🔀 [%s] 领航员切换保证金模式 | posId=%s %s %s | %s → %s",
+			e.traderID, newPosID, m.Symbol, m.Side, m.MarginMode, pos.MarginMode)
+
+		if e.cfg().SyncMarginMode {
+			decisions = append(decisions, decision.Decision{
+				Symbol: m.Symbol, Action: ActionSetMarginMode, MarginMode: pos.MarginMode, LeaderPosID: newPosID,
+				Reasoning: fmt.Sprintf("Copy trading: %s leader %s switched %s %s from %s to %s",
+					e.cfg().ProviderType, e.cfg().LeaderID, m.Symbol, m.Side, m.MarginMode, pos.MarginMode),
+			})
+		}
+	}
+	if len(decisions) == 0 {
+		return
+	}
+
+	e.pushDecision(&decision.FullDecision{
+		SystemPrompt: e.buildSystemPromptLog(),
+		UserPrompt:   fmt.Sprintf("## Margin Mode Change\n\nLeader switched margin mode on %d position(s)\n", len(decisions)),
+		Decisions:    decisions,
+		RawResponse:  fmt.Sprintf("Copy trade margin mode change from %s:%s", e.cfg().ProviderType, e.cfg().LeaderID),
+		Timestamp:    time.Now(),
+	})
+}
+
+// switchedModeKey 按模式区分 key 的仓位切换模式后的新 key 与仓位（未找到返回 nil）
+func switchedModeKey(leaderPosMap map[string]*Position, posID, symbol string, side SideType, marginMode string) (string, *Position) {
+	if posID != PositionKeyWithMode(symbol, side, marginMode) {
+		return posID, nil // 真实 posId，切换模式不会改变
+	}
+	other := "isolated"
+	if marginMode == "isolated" {
+		other = "cross"
+	}
+	key := PositionKeyWithMode(symbol, side, other)
+	return key, leaderPosMap[key]
+}
+
+// ============================================================================
+// 按保证金模式匹配（OKX 同币种同方向的全仓/逐仓是两个独立仓位）
+// ============================================================================
+//
+// 领航员持仓映射的 key 即仓位 ID：原生 posId，或无 posId 时的 symbol_side[_mgnMode]（PositionKeyWithMode），
+// 全仓与逐仓两条腿各自有独立的 posId 和映射：
+//   - 成交带保证金模式（OKX 成交记录的 mgnMode）时，开仓/加仓只在同模式的仓位中匹配，减仓/平仓只匹配同模式的映射
+//   - 成交不带模式时仍按 posId + size 变化区分两条腿；都是新仓位时优先持仓量与成交量最接近的一条
+
+// sameMarginMode 保证金模式是否相同（空 = 全仓）
+func sameMarginMode(a, b string) bool {
+	if a == "" {
+		a = "cross"
+	}
+	if b == "" {
+		b = "cross"
+	}
+	return a == b
+}
+
+// leaderCandidates 与成交 symbol+side（及保证金模式）匹配的领航员仓位及其 posId
+// 按持仓量与成交量的接近程度排序（相同时按 posId），结果与 map 遍历顺序无关
+func (e *Engine) leaderCandidates(fill *Fill, leaderPosMap map[string]*Position) ([]*Position, []string) {
+	var posIDs []string
+	for key, pos := range leaderPosMap {
+		if pos.Symbol != fill.Symbol || pos.Side != fill.PositionSide {
+			continue
+		}
+		if fill.MarginMode != "" && !sameMarginMode(pos.MarginMode, fill.MarginMode) {
+			continue
+		}
+		posIDs = append(posIDs, key)
+	}
+	sort.Slice(posIDs, func(i, j int) bool {
+		di := math.Abs(leaderPosMap[posIDs[i]].Size - fill.Size)
+		dj := math.Abs(leaderPosMap[posIDs[j]].Size - fill.Size)
+		if di != dj {
+			return di < dj
+		}
+		return posIDs[i] < posIDs[j]
+	})
+
+	positions := make([]*Position, len(posIDs))
+	for i, posID := range posIDs {
+		positions[i] = leaderPosMap[posID]
+	}
+	return positions, posIDs
+}
+
+// filterByMarginMode 只保留与成交保证金模式相同的映射（成交不带模式时原样返回）
+func filterByMarginMode(mappings []*store.CopyTradePositionMapping, marginMode string) []*store.CopyTradePositionMapping {
+	if marginMode == "" {
+		return mappings
+	}
+	filtered := mappings[:0:0]
+	for _, m := range mappings {
+		if sameMarginMode(m.MarginMode, marginMode) {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
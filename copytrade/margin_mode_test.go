@@ -0,0 +1,141 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+
+	"nofx/store"
+)
+
+// TestMarginModeSwitchMidLife 领航员持仓期间切换保证金模式：映射随之更新，之后的减仓仍能匹配
+func TestMarginModeSwitchMidLife(t *testing.T) {
+	tests := []struct {
+		name      string
+		posID     string // 映射中的 posId
+		leaderKey string // 切换后领航员仓位 key
+		leaderPos *Position
+		wantPosID string
+	}{
+		{
+			name: "okx posId", posID: "123", leaderKey: "123",
+			leaderPos: &Position{Symbol: "BTCUSDT", Side: SideLong, Size: 0.5, MarginMode: "isolated", PosID: "123"},
+			wantPosID: "123",
+		},
+		{
+			name: "mode keyed", posID: PositionKey("BTCUSDT", SideLong), leaderKey: PositionKeyWithMode("BTCUSDT", SideLong, "isolated"),
+			leaderPos: &Position{Symbol: "BTCUSDT", Side: SideLong, Size: 0.5, MarginMode: "isolated"},
+			wantPosID: PositionKeyWithMode("BTCUSDT", SideLong, "isolated"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newTestStore(t)
+			if err := st.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+				TraderID: "test", LeaderPosID: tt.posID, LeaderID: "leader", Symbol: "BTCUSDT",
+				Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 100, LastKnownSize: 1,
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			provider := &fakeProvider{state: &AccountState{TotalEquity: 10000, Positions: map[string]*Position{tt.leaderKey: tt.leaderPos}}}
+			e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 1, SyncMarginMode: true}, 1000)
+			e.store = st
+			e.provider = provider
+
+			// 减仓成交到达：先同步状态发现模式切换，再按更新后的映射匹配为减仓（而非误判为全平）
+			e.processSignal(e.buildSignal(&Fill{
+				ID: "reduce", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionReduce,
+				Price: 100, Size: 0.5, Value: 50, Timestamp: time.Now(),
+			}))
+
+			m, err := st.CopyTrade().GetActiveMapping("test", tt.wantPosID)
+			if err != nil || m == nil {
+				t.Fatalf("mapping %s not found: %v", tt.wantPosID, err)
+			}
+			if m.MarginMode != "isolated" {
+				t.Errorf("mapping margin mode = %s, want isolated", m.MarginMode)
+			}
+
+			if len(e.decisionCh) != 2 {
+				t.Fatalf("decisions = %d, want 2 (set_margin_mode, reduce)", len(e.decisionCh))
+			}
+			setMode := (<-e.decisionCh).Decisions[0]
+			if setMode.Action != ActionSetMarginMode || setMode.MarginMode != "isolated" {
+				t.Errorf("first decision = %+v, want set_margin_mode isolated", setMode)
+			}
+			reduce := (<-e.decisionCh).Decisions[0]
+			if reduce.Action != "reduce_long" || reduce.CloseRatio == 0 || reduce.MarginMode != "isolated" {
+				t.Errorf("second decision = %+v, want partial reduce_long on isolated", reduce)
+			}
+
+			// 再次同步不重复发出设置决策
+			if err := e.syncLeaderState(); err != nil {
+				t.Fatal(err)
+			}
+			if len(e.decisionCh) != 0 {
+				t.Errorf("repeated sync pushed %d decisions, want 0", len(e.decisionCh))
+			}
+		})
+	}
+}
+
+// TestCrossIsolatedLegsMatchedIndependently 领航员同时持有 BTCUSDT 多仓的全仓和逐仓两条腿，按保证金模式分别匹配
+func TestCrossIsolatedLegsMatchedIndependently(t *testing.T) {
+	st := newTestStore(t)
+	provider := &fakeProvider{}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.store = st
+	e.provider = provider
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e}
+
+	legs := func(crossSize, isoSize float64) {
+		provider.state = &AccountState{TotalEquity: 10000, Positions: map[string]*Position{}}
+		if crossSize > 0 {
+			provider.state.Positions["p-cross"] = &Position{Symbol: "BTCUSDT", Side: SideLong, Size: crossSize, MarginMode: "cross", PosID: "p-cross", Leverage: 5}
+		}
+		if isoSize > 0 {
+			provider.state.Positions["p-iso"] = &Position{Symbol: "BTCUSDT", Side: SideLong, Size: isoSize, MarginMode: "isolated", PosID: "p-iso", Leverage: 5}
+		}
+	}
+	step := func(id string, action ActionType, side, mode string, size float64, wantAction, wantPosID, wantMode string) {
+		t.Helper()
+		e.processSignal(&TradeSignal{Fill: &Fill{ID: id, Symbol: "BTCUSDT", Side: side, PositionSide: SideLong, Action: action,
+			Price: 100, Size: size, Value: size * 100, MarginMode: mode}})
+		select {
+		case fullDec := <-e.decisionCh:
+			dec := fullDec.Decisions[0]
+			ti.updatePositionMapping(&dec)
+			if dec.Action != wantAction || dec.LeaderPosID != wantPosID || dec.MarginMode != wantMode {
+				t.Fatalf("%s: decision = %s posId=%s mode=%s, want %s posId=%s mode=%s",
+					id, dec.Action, dec.LeaderPosID, dec.MarginMode, wantAction, wantPosID, wantMode)
+			}
+		default:
+			t.Fatalf("%s: no decision, want %s posId=%s", id, wantAction, wantPosID)
+		}
+	}
+
+	legs(1, 0)
+	step("open-cross", ActionOpen, "buy", "cross", 1, "open_long", "p-cross", "cross")
+	legs(1, 2)
+	step("open-iso", ActionOpen, "buy", "isolated", 2, "open_long", "p-iso", "isolated")
+	legs(1.5, 2)
+	step("add-cross", ActionAdd, "buy", "cross", 0.5, "open_long", "p-cross", "cross")
+	// 两条腿同时减仓相同数量（同一轮拉取到两笔成交）：按成交的保证金模式各自匹配
+	legs(1, 1.5)
+	step("reduce-iso", ActionReduce, "sell", "isolated", 0.5, "reduce_long", "p-iso", "isolated")
+	step("reduce-cross", ActionReduce, "sell", "cross", 0.5, "reduce_long", "p-cross", "cross")
+	legs(0, 1.5)
+	step("close-cross", ActionClose, "sell", "cross", 1, "close_long", "p-cross", "cross")
+
+	// 无原生 posId 时按 symbol_side[_mgnMode] 区分两条腿；成交不带模式时取持仓量与成交量最接近的新仓位
+	provider.state = &AccountState{TotalEquity: 10000, Positions: map[string]*Position{
+		PositionKeyWithMode("ETHUSDT", SideLong, "cross"):    {Symbol: "ETHUSDT", Side: SideLong, Size: 1, MarginMode: "cross", Leverage: 5},
+		PositionKeyWithMode("ETHUSDT", SideLong, "isolated"): {Symbol: "ETHUSDT", Side: SideLong, Size: 3, MarginMode: "isolated", Leverage: 5},
+	}}
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "eth-open", Symbol: "ETHUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 10, Size: 3, Value: 30}})
+	dec := (<-e.decisionCh).Decisions[0]
+	if want := PositionKeyWithMode("ETHUSDT", SideLong, "isolated"); dec.LeaderPosID != want || dec.MarginMode != "isolated" {
+		t.Errorf("keyless legs: posId=%s mode=%s, want %s isolated", dec.LeaderPosID, dec.MarginMode, want)
+	}
+}
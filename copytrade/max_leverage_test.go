@@ -0,0 +1,33 @@
+package copytrade
+
+import "testing"
+
+// TestMaxLeverageCap 同步的领航员杠杆超过上限时截断并记录预警；0 表示不限制
+func TestMaxLeverageCap(t *testing.T) {
+	e := newTestEngine(&CopyConfig{LeaderID: "leader", CopyRatio: 1, SyncLeverage: true, MaxLeverage: 20}, 1000)
+	signal := &TradeSignal{
+		Fill:           &Fill{Symbol: "BTCUSDT", PositionSide: SideLong, Action: ActionOpen},
+		LeaderPosition: &Position{Symbol: "BTCUSDT", Side: SideLong, Leverage: 50},
+	}
+
+	if got := e.getLeaderLeverage(signal); got != 20 {
+		t.Fatalf("capped leverage = %d, want 20", got)
+	}
+	if len(e.warnings) != 1 || e.warnings[0].Type != "leverage_capped" ||
+		e.warnings[0].SignalValue != 50 || e.warnings[0].CopyValue != 20 {
+		t.Fatalf("warnings = %+v, want one leverage_capped 50 → 20", e.warnings)
+	}
+
+	// 未超过上限：原样使用，不预警
+	signal.LeaderPosition.Leverage = 10
+	if got := e.getLeaderLeverage(signal); got != 10 || len(e.warnings) != 1 {
+		t.Errorf("under cap: leverage = %d warnings = %d, want 10 / 1", got, len(e.warnings))
+	}
+
+	// 0 = 不限制
+	withConfig(e, func(c *CopyConfig) { c.MaxLeverage = 0 })
+	signal.LeaderPosition.Leverage = 50
+	if got := e.getLeaderLeverage(signal); got != 50 {
+		t.Errorf("no cap: leverage = %d, want 50", got)
+	}
+}
@@ -0,0 +1,49 @@
+package copytrade
+
+import (
+	"fmt"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 最大持仓数
+// ============================================================================
+//
+// MaxOpenPositions > 0 时限制跟随者同时持有的跟单仓位数（按 trader 的活跃映射计数，多领航员跟单时合计）：
+//   - 达到上限后领航员的新开仓不跟随（原因 max open positions reached），写入信号日志并标记为 ignored，
+//     避免仓位数回落后把该仓位之后的加仓当作新开仓跟随
+//   - 已跟随仓位的加仓、减仓、平仓不受影响
+//   - 被拒绝的开仓数记录在 EngineStats.OpensSuppressed
+
+// SkipReasonMaxOpenPositions 已达到最大持仓数
+const SkipReasonMaxOpenPositions = "max open positions reached"
+
+// checkMaxOpenPositions 新开仓前检查活跃跟单仓位数是否已达上限（未达到或未启用时返回 nil）
+func (e *Engine) checkMaxOpenPositions(fill *Fill, posID string, pos *Position) *SignalMatchResult {
+	if e.cfg().MaxOpenPositions <= 0 {
+		return nil
+	}
+
+	active, err := e.store.CopyTrade().ListActiveMappings(e.traderID)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 查询活跃映射失败，跳过最大持仓数检查: %v", e.traderID, err)
+		return nil
+	}
+	if len(active) < e.cfg().MaxOpenPositions {
+		return nil
+	}
+
+	e.ignorePosition(fill, posID, pos.MarginMode, SkipReasonMaxOpenPositions)
+	if !e.simulation {
+		e.saveSkippedSignalLog(fill, SkipReasonMaxOpenPositions)
+	}
+	e.stats.OpensSuppressed++
+
+	logger.Infof("📊 [%s] 已达最大持仓数 | posId=%s 活跃=%d 上限=%d → 不跟随",
+		e.traderID, posID, len(active), e.cfg().MaxOpenPositions)
+	return &SignalMatchResult{
+		ShouldFollow: false,
+		Reason:       fmt.Sprintf("%s (%d/%d)", SkipReasonMaxOpenPositions, len(active), e.cfg().MaxOpenPositions),
+	}
+}
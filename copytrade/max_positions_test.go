@@ -0,0 +1,54 @@
+package copytrade
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"nofx/store"
+)
+
+// TestMaxOpenPositions 达到最大持仓数后不跟随新开仓，已跟随仓位的加仓不受影响
+func TestMaxOpenPositions(t *testing.T) {
+	st := newTestStore(t)
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1, MaxOpenPositions: 1}, 1000)
+	e.store = st
+	p := &fakeProvider{state: &AccountState{TotalEquity: 10000, Positions: map[string]*Position{
+		PositionKey("BTCUSDT", SideLong): {Symbol: "BTCUSDT", Side: SideLong, Size: 1, MarginMode: "cross", Leverage: 5},
+		PositionKey("ETHUSDT", SideLong): {Symbol: "ETHUSDT", Side: SideLong, Size: 2, MarginMode: "cross", Leverage: 5},
+	}}}
+	e.provider = p
+	if err := st.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+		TraderID: "test", LeaderPosID: "ETHUSDT_long", LeaderID: "leader", Symbol: "ETHUSDT",
+		Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 100, LastKnownSize: 1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 新开仓：已有 1 个活跃仓位，达到上限
+	e.processSignal(e.buildSignal(&Fill{ID: "f1", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong,
+		Action: ActionOpen, Price: 10000, Size: 1, Value: 10000}))
+	if len(e.decisionCh) != 0 {
+		t.Fatalf("open followed despite max open positions: %+v", (<-e.decisionCh).Decisions[0])
+	}
+	if e.GetStats().OpensSuppressed != 1 {
+		t.Errorf("opens suppressed = %d, want 1", e.GetStats().OpensSuppressed)
+	}
+	if m, _ := st.CopyTrade().GetMapping("test", "BTCUSDT_long"); m == nil || m.Status != "ignored" {
+		t.Errorf("suppressed open should be marked ignored, got %+v", m)
+	}
+	logs, _ := st.CopyTrade().GetRecentSignalLogs("test", 10)
+	if len(logs) != 1 || !strings.Contains(logs[0].FollowReason, SkipReasonMaxOpenPositions) {
+		t.Errorf("signal logs = %+v, want one %q skip", logs, SkipReasonMaxOpenPositions)
+	}
+
+	// 已跟随仓位加仓：照常跟随
+	e.processSignal(e.buildSignal(&Fill{ID: "f2", Symbol: "ETHUSDT", Side: "buy", PositionSide: SideLong,
+		Action: ActionAdd, Price: 2000, Size: 1, Value: 2000}))
+	if len(e.decisionCh) != 1 {
+		t.Fatal("add to followed position should not be limited")
+	}
+	if dec := (<-e.decisionCh).Decisions[0]; dec.Action != "open_long" || dec.LeaderPosID != "ETHUSDT_long" {
+		t.Errorf("add decision = %s %s", dec.Action, dec.LeaderPosID)
+	}
+}
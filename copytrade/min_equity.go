@@ -0,0 +1,65 @@
+package copytrade
+
+import (
+	"fmt"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 启动跟单的最低账户权益
+// ============================================================================
+//
+// 账户过小时几乎每笔跟单都会被提升到最小金额（size_boosted），仓位比例严重失真。
+// 启动时查询跟随者权益，低于阈值拒绝启动：
+//   - MinAccountEquity > 0：使用该值
+//   - MinAccountEquity = 0（默认）：最小跟单金额（MinTradeWarn，未设置为 12 USDT）× 10，
+//     即最小一笔跟单不超过账户的 10%
+//   - MinAccountEquity < 0：不检查
+// 查询权益失败时不阻止启动（只记录日志），避免交易所接口短暂异常导致无法启动。
+
+// DefaultMinTradeAmount 默认最小跟单金额（USDT，预留精度损失余量）
+const DefaultMinTradeAmount = 12.0
+
+// minEquityTradeMultiple 默认最低权益 = 最小跟单金额 × 该倍数
+const minEquityTradeMultiple = 10
+
+// minAccountEquity 启动跟单所需的最低账户权益（0 = 不检查）
+func minAccountEquity(cfg *CopyConfig) float64 {
+	if cfg.MinAccountEquity < 0 {
+		return 0
+	}
+	if cfg.MinAccountEquity > 0 {
+		return cfg.MinAccountEquity
+	}
+	minTrade := cfg.MinTradeWarn
+	if minTrade <= 0 {
+		minTrade = DefaultMinTradeAmount
+	}
+	return minTrade * minEquityTradeMultiple
+}
+
+// checkMinAccountEquity 跟随者权益低于最低要求时返回错误
+func (ti *TraderIntegration) checkMinAccountEquity(cfg *CopyConfig) error {
+	threshold := minAccountEquity(cfg)
+	if threshold <= 0 {
+		return nil
+	}
+
+	info, err := ti.executor.GetAccountInfo()
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 查询账户权益失败，跳过最低权益检查: %v", ti.traderID, err)
+		return nil
+	}
+	equity, ok := info["total_equity"].(float64)
+	if !ok {
+		logger.Warnf("⚠️ [%s] 账户信息缺少 total_equity，跳过最低权益检查", ti.traderID)
+		return nil
+	}
+	if equity < threshold {
+		return fmt.Errorf("account equity %.2f USDT is below the minimum %.2f USDT required to copy trade "+
+			"(orders would be boosted to the minimum trade size and distort the copy); "+
+			"deposit more funds or lower options.min_account_equity", equity, threshold)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package copytrade
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// equityExecutor 返回指定权益的执行器
+type equityExecutor struct {
+	fakeExecutor
+	equity float64
+	err    error
+}
+
+func (f *equityExecutor) GetAccountInfo() (map[string]interface{}, error) {
+	return map[string]interface{}{"total_equity": f.equity}, f.err
+}
+
+// TestCheckMinAccountEquity 权益低于阈值拒绝启动；默认阈值按最小跟单金额推算；查询失败不阻止
+func TestCheckMinAccountEquity(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     CopyConfig
+		equity  float64
+		err     error
+		wantErr bool
+	}{
+		{name: "default threshold below", equity: 100, wantErr: true}, // 12 × 10 = 120
+		{name: "default threshold above", equity: 150},
+		{name: "min trade warn raises default", cfg: CopyConfig{MinTradeWarn: 20}, equity: 150, wantErr: true}, // 200
+		{name: "explicit threshold", cfg: CopyConfig{MinAccountEquity: 50}, equity: 60},
+		{name: "disabled", cfg: CopyConfig{MinAccountEquity: -1}, equity: 1},
+		{name: "query failure", equity: 0, err: errors.New("timeout")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ti := &TraderIntegration{traderID: "test", executor: &equityExecutor{equity: tt.equity, err: tt.err}}
+			err := ti.checkMinAccountEquity(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "below the minimum") {
+				t.Errorf("error message not descriptive: %v", err)
+			}
+		})
+	}
+}
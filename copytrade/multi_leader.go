@@ -0,0 +1,172 @@
+package copytrade
+
+import (
+	"strings"
+
+	"nofx/logger"
+	"nofx/store"
+)
+
+// ============================================================================
+// 多领航员跟单
+// ============================================================================
+//
+// CopyConfig.Leaders 非空时，一个 trader 同时跟随多个领航员：
+//   - 每个领航员一个引擎（各自的 Provider、领航员状态、去重），共用同一个决策通道和执行器
+//   - 各引擎的跟单系数 = CopyRatio × Weight，其余选项相同
+//   - 仓位 ID 加领航员前缀（"<leaderID>/<posId>"），映射的 leader_id 为对应领航员；
+//     开仓/加仓/减仓/平仓只匹配同一领航员的映射
+//   - 跟随者交易所同币种同方向只有一个持仓，平仓作用于整个持仓：某币种方向已在跟随一个领航员时，
+//     其他领航员在该币种方向的开仓跳过并标记为 ignored，避免一方平仓连带平掉另一方的份额
+//   - 决策带 LeaderID，执行后按来源引擎更新映射和信号日志
+// Leaders 为空时与单领航员跟单完全一致（仓位 ID 不加前缀）。
+// 单领航员与多领航员模式之间切换会改变仓位 ID 格式，应在无活跃跟单仓位时切换。
+
+// MaxLeaders 多领航员跟单最多同时跟随的领航员数
+const MaxLeaders = 5
+
+// leaderPosIDSep 多领航员模式下仓位 ID 中领航员前缀的分隔符
+const leaderPosIDSep = "/"
+
+// SkipReasonHeldForOtherLeader 多领航员跟单时该币种方向已在跟随另一领航员
+const SkipReasonHeldForOtherLeader = "symbol side already followed for another leader"
+
+// toLeaderSpecs 数据库选项转换为引擎配置
+func toLeaderSpecs(specs []store.CopyTradeLeaderSpec) []LeaderSpec {
+	if len(specs) == 0 {
+		return nil
+	}
+	result := make([]LeaderSpec, len(specs))
+	for i, s := range specs {
+		result[i] = LeaderSpec{LeaderID: s.LeaderID, ProviderType: ProviderType(s.ProviderType), Weight: s.Weight}
+	}
+	return result
+}
+
+// weight 生效权重（0 = 1）
+func (s LeaderSpec) weight() float64 {
+	if s.Weight > 0 {
+		return s.Weight
+	}
+	return 1
+}
+
+// isMultiLeader 是否为多领航员跟单
+func (c *CopyConfig) isMultiLeader() bool {
+	return len(c.Leaders) > 0
+}
+
+// leaderSpecs 需要启动引擎的领航员列表（单领航员配置返回顶层领航员）
+func (c *CopyConfig) leaderSpecs() []LeaderSpec {
+	if c.isMultiLeader() {
+		return c.Leaders
+	}
+	return []LeaderSpec{{LeaderID: c.LeaderID, ProviderType: c.ProviderType, Weight: 1}}
+}
+
+// forLeader 单个领航员引擎的配置：领航员/数据源取自 spec，跟单系数乘以权重
+// 单领航员配置原样返回
+func (c *CopyConfig) forLeader(spec LeaderSpec) *CopyConfig {
+	if !c.isMultiLeader() {
+		return c
+	}
+	cfg := *c
+	cfg.LeaderID = spec.LeaderID
+	cfg.ProviderType = spec.ProviderType
+	cfg.CopyRatio = c.CopyRatio * spec.weight()
+	return &cfg
+}
+
+// sameLeaders 领航员列表是否相同（顺序、数据源、权重均一致）
+func sameLeaders(a, b []LeaderSpec) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].LeaderID != b[i].LeaderID || a[i].ProviderType != b[i].ProviderType || a[i].weight() != b[i].weight() {
+			return false
+		}
+	}
+	return true
+}
+
+// mappingLeader 查询映射时的领航员过滤条件（单领航员模式为空 = 不过滤）
+func (e *Engine) mappingLeader() string {
+	if e.cfg().isMultiLeader() {
+		return e.cfg().LeaderID
+	}
+	return ""
+}
+
+// scopePosID 多领航员模式下给仓位 ID 加领航员前缀
+func (e *Engine) scopePosID(posID string) string {
+	if !e.cfg().isMultiLeader() || posID == "" {
+		return posID
+	}
+	prefix := e.cfg().LeaderID + leaderPosIDSep
+	if strings.HasPrefix(posID, prefix) {
+		return posID
+	}
+	return prefix + posID
+}
+
+// unscopePosID 去掉仓位 ID 的领航员前缀
+func (e *Engine) unscopePosID(posID string) string {
+	if !e.cfg().isMultiLeader() {
+		return posID
+	}
+	return strings.TrimPrefix(posID, e.cfg().LeaderID+leaderPosIDSep)
+}
+
+// positionKey 无原生 posId 时的虚拟仓位 ID（symbol_side，多领航员模式加前缀）
+func (e *Engine) positionKey(symbol string, side SideType) string {
+	return e.scopePosID(PositionKey(symbol, side))
+}
+
+// scopeState 多领航员模式下为领航员状态中的持仓填充带前缀的仓位 ID
+// Provider 的状态可能被缓存共享，这里返回副本，不修改原对象
+func (e *Engine) scopeState(state *AccountState) *AccountState {
+	if state == nil || !e.cfg().isMultiLeader() {
+		return state
+	}
+	scoped := *state
+	scoped.Positions = make(map[string]*Position, len(state.Positions))
+	for key, pos := range state.Positions {
+		p := *pos
+		if p.PosID == "" {
+			p.PosID = key
+		}
+		p.PosID = e.scopePosID(p.PosID)
+		scoped.Positions[key] = &p
+	}
+	return &scoped
+}
+
+// listActiveMappings 本引擎领航员的活跃映射
+func (e *Engine) listActiveMappings() ([]*store.CopyTradePositionMapping, error) {
+	return e.store.CopyTrade().ListActiveMappingsForLeader(e.traderID, e.mappingLeader())
+}
+
+// findActiveBySymbolSide 本引擎领航员某 symbol+side 的活跃映射
+func (e *Engine) findActiveBySymbolSide(symbol, side string) ([]*store.CopyTradePositionMapping, error) {
+	return e.store.CopyTrade().FindActiveBySymbolSideForLeader(e.traderID, e.mappingLeader(), symbol, side)
+}
+
+// heldForOtherLeader 多领航员跟单时，跟随者该币种方向的持仓是否已属于另一领航员的活跃映射
+func (e *Engine) heldForOtherLeader(fill *Fill) bool {
+	if !e.cfg().isMultiLeader() || e.store == nil {
+		return false
+	}
+	side := string(e.followerSide(fill.PositionSide))
+	mappings, err := e.store.CopyTrade().FindActiveBySymbolSide(e.traderID, fill.Symbol, side)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 查询 %s %s 活跃映射失败: %v", e.traderID, fill.Symbol, side, err)
+		return false
+	}
+	for _, m := range mappings {
+		if m.LeaderID != e.cfg().LeaderID {
+			return true
+		}
+	}
+	return false
+}
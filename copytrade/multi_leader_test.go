@@ -0,0 +1,150 @@
+package copytrade
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"nofx/decision"
+)
+
+// TestMultiLeaderMappings 多领航员跟单：映射按领航员区分；两个领航员开同币种同方向仓位时只跟随先开仓的领航员，
+// 另一方的仓位标记为 ignored，先开仓领航员平仓时全量平掉跟随者持仓
+func TestMultiLeaderMappings(t *testing.T) {
+	st := newTestStore(t)
+	cfg := &CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "a", CopyRatio: 1, Leaders: []LeaderSpec{
+		{LeaderID: "a", ProviderType: ProviderHyperliquid},
+		{LeaderID: "b", ProviderType: ProviderHyperliquid, Weight: 0.5},
+	}}
+	ti := &TraderIntegration{traderID: "test", store: st, executor: &fakeExecutor{}}
+	providers := make([]*fakeProvider, 0, 2)
+	for _, spec := range cfg.leaderSpecs() {
+		e := newTestEngine(cfg.forLeader(spec), 1000)
+		e.store = st
+		p := &fakeProvider{}
+		p.setSize(1)
+		e.provider = p
+		providers = append(providers, p)
+		ti.engines = append(ti.engines, e)
+	}
+	ti.engine = ti.engines[0]
+	a, b := ti.engines[0], ti.engines[1]
+
+	signal := func(e *Engine, fillID, symbol string, action ActionType) *decision.FullDecision {
+		t.Helper()
+		side := "buy"
+		if action == ActionClose {
+			side = "sell"
+		}
+		e.processSignal(e.buildSignal(&Fill{ID: fillID, Symbol: symbol, Side: side, PositionSide: SideLong,
+			Action: action, Price: 10000, Size: 1, Value: 10000}))
+		if len(e.decisionCh) == 0 {
+			return nil
+		}
+		return <-e.decisionCh
+	}
+
+	// 领航员 a 先开 BTC 多仓：跟随
+	decA := signal(a, "fa", "BTCUSDT", ActionOpen)
+	if decA == nil {
+		t.Fatal("leader a: no open decision")
+	}
+	if da := decA.Decisions[0]; da.LeaderID != "a" || da.LeaderPosID != "a/BTCUSDT_long" {
+		t.Fatalf("decision not tagged by leader: %+v", da)
+	}
+	ti.executeFullDecision(decA)
+
+	// 领航员 b 随后开 BTC 多仓：跟随者该持仓已属于 a → 跳过并标记 ignored
+	if dec := signal(b, "fb", "BTCUSDT", ActionOpen); dec != nil {
+		t.Fatalf("leader b open on shared symbol side = %+v, want skip", dec.Decisions[0])
+	}
+	if m, _ := st.CopyTrade().GetMapping("test", "b/BTCUSDT_long"); m == nil || m.Status != "ignored" || m.LeaderID != "b" {
+		t.Fatalf("leader b mapping = %+v, want ignored", m)
+	}
+
+	// 领航员 b 开其他币种照常跟随，权重 0.5：跟单金额减半
+	providers[1].state.Positions[PositionKey("ETHUSDT", SideLong)] = &Position{Symbol: "ETHUSDT", Side: SideLong, Size: 1, MarginMode: "cross", Leverage: 5}
+	decB := signal(b, "fb-eth", "ETHUSDT", ActionOpen)
+	if decB == nil {
+		t.Fatal("leader b: no open decision for ETHUSDT")
+	}
+	if db := decB.Decisions[0]; db.LeaderID != "b" || db.LeaderPosID != "b/ETHUSDT_long" ||
+		math.Abs(db.PositionSizeUSD-decA.Decisions[0].PositionSizeUSD/2) > 0.01 {
+		t.Fatalf("leader b decision = %+v, want b/ETHUSDT_long at half of %.2f", db, decA.Decisions[0].PositionSizeUSD)
+	}
+	ti.executeFullDecision(decB)
+
+	// 领航员 b 平 BTC：仓位已忽略，不影响 a 的跟单持仓
+	delete(providers[1].state.Positions, PositionKey("BTCUSDT", SideLong))
+	if dec := signal(b, "fb2", "BTCUSDT", ActionClose); dec != nil {
+		t.Fatalf("leader b close on ignored position = %+v, want no decision", dec.Decisions[0])
+	}
+	if m, _ := st.CopyTrade().GetActiveMapping("test", "a/BTCUSDT_long"); m == nil {
+		t.Fatal("leader a mapping closed by leader b's close")
+	}
+
+	// 领航员 a 平 BTC：全量平仓，只关闭 a 的映射
+	delete(providers[0].state.Positions, PositionKey("BTCUSDT", SideLong))
+	closeDec := signal(a, "fa2", "BTCUSDT", ActionClose)
+	if closeDec == nil {
+		t.Fatal("leader a: no close decision")
+	}
+	if dec := closeDec.Decisions[0]; dec.Action != "close_long" || dec.LeaderPosID != "a/BTCUSDT_long" || dec.CloseRatio != 0 {
+		t.Fatalf("close decision = %+v", dec)
+	}
+	ti.executeFullDecision(closeDec)
+
+	if m, _ := st.CopyTrade().GetActiveMapping("test", "a/BTCUSDT_long"); m != nil {
+		t.Errorf("leader a mapping still active: %+v", m)
+	}
+	if ms, _ := st.CopyTrade().ListActiveMappingsForLeader("test", "b"); len(ms) != 1 || ms[0].LeaderPosID != "b/ETHUSDT_long" {
+		t.Fatalf("leader b mappings = %+v, want only b/ETHUSDT_long", ms)
+	}
+	logs, _ := st.CopyTrade().GetRecentSignalLogs("test", 10)
+	leaders := map[string]int{}
+	for _, l := range logs {
+		leaders[l.LeaderID]++
+	}
+	if leaders["a"] != 2 || leaders["b"] != 2 {
+		t.Errorf("signal logs by leader = %v, want a=2 b=2", leaders)
+	}
+}
+
+// TestMultiLeaderConfig 单领航员配置不受影响；领航员列表变化需要重启
+func TestMultiLeaderConfig(t *testing.T) {
+	single := &CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "a", CopyRatio: 0.5}
+	if specs := single.leaderSpecs(); len(specs) != 1 || specs[0].LeaderID != "a" {
+		t.Fatalf("single specs = %+v", specs)
+	}
+	if single.forLeader(single.leaderSpecs()[0]) != single {
+		t.Error("single-leader config should be used as is")
+	}
+	e := newTestEngine(single, 1000)
+	if e.positionKey("BTCUSDT", SideLong) != "BTCUSDT_long" || e.mappingLeader() != "" {
+		t.Error("single-leader position ids must not be scoped")
+	}
+
+	multi := &CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "a", CopyRatio: 0.5, Leaders: []LeaderSpec{
+		{LeaderID: "a", ProviderType: ProviderHyperliquid, Weight: 2},
+		{LeaderID: "b", ProviderType: ProviderOKX},
+	}}
+	cb := multi.forLeader(multi.Leaders[1])
+	if cb.LeaderID != "b" || cb.ProviderType != ProviderOKX || cb.CopyRatio != 0.5 {
+		t.Errorf("leader b config = %+v", cb)
+	}
+	if ca := multi.forLeader(multi.Leaders[0]); ca.CopyRatio != 1 {
+		t.Errorf("weighted copy ratio = %v, want 1", ca.CopyRatio)
+	}
+
+	eb := newTestEngine(cb, 1000)
+	reweighted := *multi
+	reweighted.Leaders = []LeaderSpec{multi.Leaders[0], {LeaderID: "b", ProviderType: ProviderOKX, Weight: 3}}
+	if err := eb.UpdateConfig(reweighted.forLeader(reweighted.Leaders[1])); !errors.Is(err, ErrRestartRequired) {
+		t.Errorf("leader weight change: err = %v, want ErrRestartRequired", err)
+	}
+	ratio := *multi
+	ratio.CopyRatio = 1
+	if err := eb.UpdateConfig(ratio.forLeader(ratio.Leaders[1])); err != nil || eb.cfg().CopyRatio != 1 {
+		t.Errorf("copy ratio hot update: err=%v ratio=%v", err, eb.cfg().CopyRatio)
+	}
+}
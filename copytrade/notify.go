@@ -0,0 +1,77 @@
+package copytrade
+
+import (
+	"fmt"
+
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/notify"
+)
+
+// ============================================================================
+// 跟单执行通知
+// ============================================================================
+//
+// 每笔跟单执行成功/失败后发送通知（Telegram 等），运营可第一时间知道领航员开了大仓或连续执行失败。
+// 通知渠道默认取交易员所属用户的通知配置（notification_configs，/api/notifications 编辑），
+// 也可以通过 WithNotifier 指定。通知经 notify.Dispatch 异步发送，不会延迟下单。
+//   - 执行成功：仓位价值低于配置的 min_trade_usd 时不通知（0 = 全部通知）
+//   - 执行失败：总是通知
+//   - 限价未成交、模拟运行不通知
+
+// WithNotifier 指定通知渠道（替代按用户通知配置发送）
+func WithNotifier(n notify.Notifier) IntegrationOption {
+	return func(ti *TraderIntegration) {
+		ti.notifier = n
+	}
+}
+
+// resolveNotifier 当前生效的通知渠道及成交通知的最小仓位价值；未配置或未启用时返回 nil
+func (ti *TraderIntegration) resolveNotifier() (notify.Notifier, float64) {
+	if ti.notifier != nil {
+		return ti.notifier, 0
+	}
+	if ti.store == nil {
+		return nil, 0
+	}
+	cfg, err := ti.store.Notification().GetByTrader(ti.traderID)
+	if err != nil {
+		logger.Debugf("[%s] 读取通知配置失败: %v", ti.traderID, err)
+		return nil, 0
+	}
+	if !cfg.Ready() {
+		return nil, 0
+	}
+	return notify.NewTelegramNotifier(cfg.TelegramBotToken, cfg.TelegramChatID), cfg.MinTradeUSD
+}
+
+// notifyExecution 跟单执行结果通知（异步）
+func (ti *TraderIntegration) notifyExecution(dec *decision.Decision, execErr error) {
+	n, minTradeUSD := ti.resolveNotifier()
+	if n == nil {
+		return
+	}
+
+	text := fmt.Sprintf("交易员: %s\n仓位价值: %.2f USDT", ti.traderID, dec.PositionSizeUSD)
+	if dec.EntryPrice > 0 {
+		text += fmt.Sprintf("\n领航员成交价: %.4f", dec.EntryPrice)
+	}
+	if dec.Leverage > 0 {
+		text += fmt.Sprintf("\n杠杆: %dx", dec.Leverage)
+	}
+
+	msg := notify.Message{TraderID: ti.traderID}
+	if execErr != nil {
+		msg.Level = notify.LevelWarning
+		msg.Title = fmt.Sprintf("跟单执行失败 | %s %s", dec.Action, dec.Symbol)
+		msg.Text = text + fmt.Sprintf("\n错误: %v", execErr)
+	} else {
+		if minTradeUSD > 0 && dec.PositionSizeUSD < minTradeUSD {
+			return
+		}
+		msg.Level = notify.LevelInfo
+		msg.Title = fmt.Sprintf("跟单执行成功 | %s %s", dec.Action, dec.Symbol)
+		msg.Text = text
+	}
+	notify.Dispatch(n, msg)
+}
@@ -0,0 +1,62 @@
+package copytrade
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"nofx/decision"
+	"nofx/notify"
+)
+
+// TestNotifyExecution 跟单执行成功/失败都发送通知（异步，不阻塞执行）
+func TestNotifyExecution(t *testing.T) {
+	sent := make(chan notify.Message, 4)
+	exec := &fakeExecutor{}
+	st := newTestStore(t)
+	e := newTestEngine(&CopyConfig{LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.store = st
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e, executor: exec}
+	WithNotifier(notify.NotifierFunc(func(ctx context.Context, msg notify.Message) error {
+		sent <- msg
+		return nil
+	}))(ti)
+
+	receive := func() notify.Message {
+		t.Helper()
+		select {
+		case msg := <-sent:
+			return msg
+		case <-time.After(2 * time.Second):
+			t.Fatal("no notification sent")
+		}
+		return notify.Message{}
+	}
+	fullDec := func() *decision.FullDecision {
+		return &decision.FullDecision{Decisions: []decision.Decision{
+			{Action: "open_long", Symbol: "BTCUSDT", PositionSizeUSD: 500, Leverage: 5, LeaderPosID: PositionKey("BTCUSDT", SideLong)},
+		}}
+	}
+
+	ti.executeFullDecision(fullDec())
+	if msg := receive(); msg.Level != notify.LevelInfo || !strings.Contains(msg.Title, "open_long BTCUSDT") || msg.TraderID != "test" {
+		t.Errorf("success notification = %+v", msg)
+	}
+
+	exec.err = errors.New("insufficient margin")
+	ti.executeFullDecision(fullDec())
+	if msg := receive(); msg.Level != notify.LevelWarning || !strings.Contains(msg.Text, "insufficient margin") {
+		t.Errorf("failure notification = %+v", msg)
+	}
+
+	// 限价未成交不是执行故障，不通知
+	exec.err = decision.ErrLimitNotFilled
+	ti.executeFullDecision(fullDec())
+	select {
+	case msg := <-sent:
+		t.Errorf("limit not filled notified: %+v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,74 @@
+package copytrade
+
+import (
+	"strings"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// OKX 非 USDT 计价合约
+// ============================================================================
+//
+// normalizeOKXSymbol 直接拼接 instId 的前两段，币本位合约 BTC-USD-SWAP 会得到 BTCUSD，
+// 跟随者的 USDT 合约执行器无法交易。非 USDT 计价的合约：
+//   - 在 OKXInstrumentMap 中配置了映射（如 {"BTC-USD-SWAP": "BTCUSDT"}）→ 按映射的币种跟单
+//   - 未配置 → 跳过该合约的成交和持仓（每个 instId 记录一次日志）
+//
+// ⚠️ 币本位合约按美元面值计张，映射只换算币种，数量仍按领航员成交/持仓数量（基础币）跟随。
+
+// InstrumentMapper Provider 可选能力：配置合约到跟随者币种的映射
+type InstrumentMapper interface {
+	SetInstrumentMap(m map[string]string)
+}
+
+// SetInstrumentMap 设置 instId → 跟随者币种映射（key 不区分大小写）
+func (p *OKXProvider) SetInstrumentMap(m map[string]string) {
+	mapped := make(map[string]string, len(m))
+	for instID, symbol := range m {
+		mapped[strings.ToUpper(instID)] = strings.ToUpper(symbol)
+	}
+
+	p.instMu.Lock()
+	p.instrumentMap = mapped
+	p.instMu.Unlock()
+}
+
+// symbolOf OKX instId → 跟随者币种（非 USDT 计价且未配置映射时返回空串，调用方跳过）
+func (p *OKXProvider) symbolOf(instID string) string {
+	key := strings.ToUpper(instID)
+
+	p.instMu.Lock()
+	defer p.instMu.Unlock()
+	if symbol, ok := p.instrumentMap[key]; ok {
+		return symbol
+	}
+	if quote := okxQuote(key); quote == "" || quote == "USDT" {
+		return normalizeOKXSymbol(instID)
+	}
+
+	if p.skippedInst == nil {
+		p.skippedInst = make(map[string]bool)
+	}
+	if !p.skippedInst[key] {
+		p.skippedInst[key] = true
+		logger.Infof("⏭️ [OKX] non-USDT instrument skipped: %s（可在 okx_instrument_map 中映射到 USDT 合约）", instID)
+	}
+	return ""
+}
+
+// okxQuote instId 的计价币种（"BTC-USD-SWAP" -> "USD"，无法解析时为空串）
+func okxQuote(instID string) string {
+	parts := strings.Split(instID, "-")
+	if len(parts) < 2 {
+		return ""
+	}
+	return strings.ToUpper(parts[1])
+}
+
+// applyInstrumentMap 把配置的合约映射下发给 Provider（Provider 不支持时忽略）
+func (e *Engine) applyInstrumentMap() {
+	if mapper, ok := e.provider.(InstrumentMapper); ok {
+		mapper.SetInstrumentMap(e.cfg().OKXInstrumentMap)
+	}
+}
@@ -0,0 +1,117 @@
+package copytrade
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// OKX 请求限频
+// ============================================================================
+//
+// 每个 OKX 跟单引擎每个轮询周期都会请求 trade-records / asset / position，多个交易员同时跟单时
+// 很容易触发 OKX 的 429。所有 OKXProvider 默认共用一个进程级令牌桶（okxLimiter），
+// 请求前按配置的速率排队；速率可通过 SetOKXRateLimit（环境变量 OKX_COPYTRADE_RPS）调整。
+// 收到 429 时按 Retry-After（缺失时为 okxDefaultRetryAfter，最长 okxMaxRetryAfter）等待后重试一次。
+
+const (
+	// DefaultOKXRequestsPerSecond 默认每秒允许的 OKX 请求数（所有引擎合计）
+	DefaultOKXRequestsPerSecond = 5.0
+
+	// okxDefaultRetryAfter 429 响应未带 Retry-After 时的等待时间
+	okxDefaultRetryAfter = time.Second
+	// okxMaxRetryAfter 429 重试前最长等待时间（避免服务端返回过长的 Retry-After 阻塞轮询）
+	okxMaxRetryAfter = 10 * time.Second
+)
+
+// okxLimiter 所有 OKXProvider 共用的限频器
+var okxLimiter = newRateLimiter(DefaultOKXRequestsPerSecond)
+
+// SetOKXRateLimit 设置 OKX 请求速率（每秒请求数，所有引擎合计；<= 0 时使用默认值）
+func SetOKXRateLimit(rps float64) {
+	if rps <= 0 {
+		rps = DefaultOKXRequestsPerSecond
+	}
+	okxLimiter.setRate(rps)
+	logger.Infof("🚦 [OKX] 请求限频: %.2f 次/秒", rps)
+}
+
+// rateLimiter 令牌桶限频器（并发安全）
+// 容量 = max(1, 速率)，即最多允许一秒的突发；令牌不足时 wait 预占令牌并休眠到可用时刻
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒补充的令牌数
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rps float64) *rateLimiter {
+	l := &rateLimiter{}
+	l.setRate(rps)
+	l.tokens = l.burst
+	return l
+}
+
+// setRate 修改速率（已预占的令牌保留）
+func (l *rateLimiter) setRate(rps float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	l.rate = rps
+	l.burst = math.Max(1, rps)
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// refill 按经过的时间补充令牌（调用方需持有锁）
+func (l *rateLimiter) refill(now time.Time) {
+	if !l.last.IsZero() && l.rate > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+}
+
+// reserve 预占一个令牌，返回需要等待的时间
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	l.tokens--
+	if l.tokens >= 0 || l.rate <= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// wait 阻塞直到允许发出下一个请求
+func (l *rateLimiter) wait() {
+	if d := l.reserve(); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// retryAfter 解析 429 响应的 Retry-After（秒数或 HTTP 日期），限制在 (0, okxMaxRetryAfter]
+func retryAfter(h http.Header) time.Duration {
+	d := okxDefaultRetryAfter
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			d = time.Duration(secs) * time.Second
+		} else if t, err := http.ParseTime(v); err == nil {
+			d = time.Until(t)
+		}
+	}
+	if d <= 0 {
+		d = okxDefaultRetryAfter
+	}
+	if d > okxMaxRetryAfter {
+		d = okxMaxRetryAfter
+	}
+	return d
+}
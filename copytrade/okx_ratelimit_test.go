@@ -0,0 +1,112 @@
+package copytrade
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(2)
+	var waits []time.Duration
+	for i := 0; i < 4; i++ {
+		waits = append(waits, l.reserve())
+	}
+	// 容量 2：前两个请求立即发出，之后每 0.5 秒一个
+	want := []time.Duration{0, 0, 500 * time.Millisecond, time.Second}
+	for i, w := range waits {
+		if diff := w - want[i]; diff < -10*time.Millisecond || diff > 10*time.Millisecond {
+			t.Errorf("wait[%d] = %v, want %v", i, w, want[i])
+		}
+	}
+
+	// 并发预占：每个请求的等待时间互不相同（不会两个请求拿到同一个令牌）
+	l = newRateLimiter(0.5)
+	var mu sync.Mutex
+	seen := make(map[time.Duration]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d := l.reserve()
+			mu.Lock()
+			defer mu.Unlock()
+			if seen[d.Round(time.Second)] {
+				t.Errorf("two requests reserved the same slot %v", d)
+			}
+			seen[d.Round(time.Second)] = true
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", okxDefaultRetryAfter},
+		{"3", 3 * time.Second},
+		{"0", okxDefaultRetryAfter},
+		{"3600", okxMaxRetryAfter},
+		{"garbage", okxDefaultRetryAfter},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.header != "" {
+			h.Set("Retry-After", tt.header)
+		}
+		if got := retryAfter(h); got != tt.want {
+			t.Errorf("Retry-After %q = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+// sequenceTransport 依次返回给定状态码（用完后返回 200）
+type sequenceTransport struct {
+	mu       sync.Mutex
+	statuses []int
+	calls    int
+}
+
+func (s *sequenceTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := http.StatusOK
+	if s.calls < len(s.statuses) {
+		status = s.statuses[s.calls]
+	}
+	s.calls++
+	h := http.Header{"Content-Type": []string{"application/json"}}
+	if status == http.StatusTooManyRequests {
+		h.Set("Retry-After", "1")
+	}
+	return &http.Response{StatusCode: status, Header: h, Body: io.NopCloser(strings.NewReader(`{"code":"0","data":[]}`))}, nil
+}
+
+// TestOKXGetRetriesOnceOn429 429 时按 Retry-After 等待后只重试一次
+func TestOKXGetRetriesOnceOn429(t *testing.T) {
+	tr := &sequenceTransport{statuses: []int{http.StatusTooManyRequests}}
+	p := &OKXProvider{client: &http.Client{Transport: tr}, limiter: newRateLimiter(100)}
+	start := time.Now()
+	var resp OKXAssetResp
+	if err := p.get("https://okx.test/asset", &resp); err != nil {
+		t.Fatalf("retry after 429 should succeed: %v", err)
+	}
+	if tr.calls != 2 || time.Since(start) < time.Second {
+		t.Errorf("calls = %d after %v, want 2 calls after Retry-After", tr.calls, time.Since(start))
+	}
+
+	tr = &sequenceTransport{statuses: []int{http.StatusTooManyRequests, http.StatusTooManyRequests}}
+	p.client = &http.Client{Transport: tr}
+	if err := p.get("https://okx.test/asset", &resp); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("second 429 should be returned, got %v", err)
+	}
+	if tr.calls != 2 {
+		t.Errorf("calls = %d, want 2 (retry once)", tr.calls)
+	}
+}
@@ -0,0 +1,78 @@
+package copytrade
+
+import (
+	"fmt"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 引擎暂停/恢复
+// ============================================================================
+//
+// Pause 暂停跟单但不停止引擎：WebSocket/轮询、去重和领航员状态同步照常进行，
+// 只是不再跟随领航员生成决策（POST /api/copytrade/pause/:trader_id、/resume/:trader_id）：
+//   - 暂停期间的成交照常标记为已处理，恢复后不会重放
+//   - 开仓、加仓、减仓、平仓全部跳过（原因 engine paused）并写入信号日志；
+//     只想停止开新仓时应使用维护模式或按币种暂停
+//   - 暂停期间领航员的新开仓标记为 ignored，恢复后不把该仓位的加仓当作新开仓追入
+//   - 延迟跟单到期时引擎已暂停则撤销该开仓，领航员挂单不再镜像
+//   - 最大持仓时间等跟随者侧的风控平仓不受暂停影响
+//   - 暂停期间领航员已平掉的仓位，跟随者仍持有，恢复后需在映射漂移中核对并手动处理
+// 暂停状态只保存在内存中，重启跟单后恢复为运行。
+
+// SkipReasonEnginePaused 引擎已暂停
+const SkipReasonEnginePaused = "engine paused"
+
+// Pause 暂停跟单（重复调用无副作用）
+func (e *Engine) Pause() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.stats.Paused {
+		e.stats.Paused = true
+		logger.Infof("⏸️ [%s] 跟单已暂停（连接保持，不生成决策）", e.traderID)
+	}
+}
+
+// Resume 恢复跟单（重复调用无副作用）
+func (e *Engine) Resume() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.stats.Paused {
+		e.stats.Paused = false
+		logger.Infof("▶️ [%s] 跟单已恢复", e.traderID)
+	}
+}
+
+// IsPaused 是否已暂停
+func (e *Engine) IsPaused() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.stats.Paused
+}
+
+// setCopyTradingPaused 暂停/恢复 trader 的所有领航员引擎
+func setCopyTradingPaused(traderID string, paused bool) error {
+	integration, exists := getIntegration(traderID)
+	if !exists || !integration.IsRunning() || integration.engine == nil {
+		return fmt.Errorf("copy trading not running for trader %s", traderID)
+	}
+	for _, e := range integration.allEngines() {
+		if paused {
+			e.Pause()
+		} else {
+			e.Resume()
+		}
+	}
+	return nil
+}
+
+// PauseCopyTradingForTrader 暂停 trader 的跟单（保持连接，不生成决策）
+func PauseCopyTradingForTrader(traderID string) error {
+	return setCopyTradingPaused(traderID, true)
+}
+
+// ResumeCopyTradingForTrader 恢复 trader 的跟单
+func ResumeCopyTradingForTrader(traderID string) error {
+	return setCopyTradingPaused(traderID, false)
+}
@@ -0,0 +1,92 @@
+package copytrade
+
+import (
+	"testing"
+
+	"nofx/decision"
+)
+
+// TestPauseResume 暂停期间跳过所有信号（新开仓标记 ignored），恢复后照常跟随且不重放暂停期间的成交
+func TestPauseResume(t *testing.T) {
+	st := newTestStore(t)
+	provider := &fakeProvider{}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.store = st
+	e.provider = provider
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e}
+
+	next := func() *decision.Decision {
+		select {
+		case fullDec := <-e.decisionCh:
+			dec := fullDec.Decisions[0]
+			ti.updatePositionMapping(&dec)
+			return &dec
+		default:
+			return nil
+		}
+	}
+
+	provider.setSize(2)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "open", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 2, Value: 200}})
+	if dec := next(); dec == nil || dec.Action != "open_long" {
+		t.Fatalf("open before pause = %+v, want open_long", dec)
+	}
+
+	e.Pause()
+	e.Pause()
+	if !e.IsPaused() || !e.GetStats().Paused {
+		t.Fatal("engine should be paused")
+	}
+
+	// 暂停期间减仓也跳过
+	skipped := e.stats.SignalsSkipped
+	provider.setSize(1)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "reduce", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionReduce, Price: 100, Size: 1, Value: 100}})
+	if dec := next(); dec != nil {
+		t.Fatalf("reduce while paused = %+v, want skip", dec)
+	}
+	if e.stats.SignalsSkipped != skipped+1 {
+		t.Errorf("signals skipped = %d, want %d", e.stats.SignalsSkipped, skipped+1)
+	}
+
+	// 暂停期间的新开仓跳过并标记 ignored
+	provider.state.Positions["ETHUSDT_short"] = &Position{Symbol: "ETHUSDT", Side: SideShort, Size: 1, EntryPrice: 10, MarginMode: "cross"}
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "eth-open", Symbol: "ETHUSDT", Side: "sell", PositionSide: SideShort, Action: ActionOpen, Price: 10, Size: 1, Value: 10}})
+	if dec := next(); dec != nil {
+		t.Fatalf("open while paused = %+v, want skip", dec)
+	}
+	m, err := st.CopyTrade().GetMapping("test", PositionKey("ETHUSDT", SideShort))
+	if err != nil || m == nil || m.Status != "ignored" {
+		t.Fatalf("mapping after paused open = %+v (err=%v), want ignored", m, err)
+	}
+	logs, err := st.CopyTrade().GetRecentSignalLogs("test", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, l := range logs {
+		if l.SignalID == "skip_eth-open" && l.FollowReason == SkipReasonEnginePaused {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("paused open not in signal log: %+v", logs)
+	}
+
+	e.Resume()
+	if e.IsPaused() || e.GetStats().Paused {
+		t.Fatal("engine should be resumed")
+	}
+
+	// 恢复后：暂停期间开的仓位加仓不跟随，已跟随仓位照常平仓
+	provider.state.Positions["ETHUSDT_short"].Size = 2
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "eth-add", Symbol: "ETHUSDT", Side: "sell", PositionSide: SideShort, Action: ActionAdd, Price: 10, Size: 1, Value: 10}})
+	if dec := next(); dec != nil {
+		t.Fatalf("add to position opened while paused = %+v, want skip", dec)
+	}
+	delete(provider.state.Positions, PositionKey("BTCUSDT", SideLong))
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "close", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionClose, Price: 100, Size: 1, Value: 100}})
+	if dec := next(); dec == nil || dec.Action != "close_long" {
+		t.Fatalf("close after resume = %+v, want close_long", dec)
+	}
+}
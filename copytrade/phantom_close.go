@@ -0,0 +1,109 @@
+package copytrade
+
+import (
+	"time"
+
+	"nofx/logger"
+	"nofx/store"
+)
+
+// ============================================================================
+// 领航员仓位转移（phantom close）
+// ============================================================================
+//
+// 部分交易所允许把仓位划转到子账户，领航员持仓直接消失，但没有任何平仓成交（无成交价、无 ClosedPnl）。
+// 重连对账时领航员仓位消失会被补跟为平仓；此时拉取一次映射最后更新以来的领航员成交：
+// 期间没有该币种该方向的平仓/减仓成交，也没有产生盈亏的成交 → 判定为仓位转移（phantom close）。
+//   - mirror（默认）：记录日志后仍按平仓跟随
+//   - ignore：记录日志，不平仓，跟随者继续持有（映射保持 active）
+// 拉取成交失败时按真实平仓处理。
+
+// 仓位转移处理策略
+const (
+	PhantomCloseMirror = "mirror" // 视为平仓（默认）
+	PhantomCloseIgnore = "ignore" // 继续持有
+)
+
+// phantomCloseLookback 映射没有更新时间时查找平仓成交的时间范围
+const phantomCloseLookback = 24 * time.Hour
+
+// phantomCloseDetector 一次对账内共用的领航员成交（按需拉取一次）
+type phantomCloseDetector struct {
+	e       *Engine
+	stateAt time.Time
+	fetched bool
+	fills   []Fill
+	err     error
+	since   time.Time
+}
+
+// newPhantomCloseDetector 以所有映射中最早的更新时间作为成交拉取起点
+func (e *Engine) newPhantomCloseDetector(mappings []*store.CopyTradePositionMapping, stateAt time.Time) *phantomCloseDetector {
+	d := &phantomCloseDetector{e: e, stateAt: stateAt, since: stateAt}
+	for _, m := range mappings {
+		if since := phantomCloseSince(m, stateAt); since.Before(d.since) {
+			d.since = since
+		}
+	}
+	return d
+}
+
+// phantomCloseSince 映射最后一次已知变化的时间
+func phantomCloseSince(m *store.CopyTradePositionMapping, stateAt time.Time) time.Time {
+	switch {
+	case !m.UpdatedAt.IsZero():
+		return m.UpdatedAt
+	case !m.OpenedAt.IsZero():
+		return m.OpenedAt
+	default:
+		return stateAt.Add(-phantomCloseLookback)
+	}
+}
+
+// isPhantomClose 映射对应的领航员仓位消失但期间没有平仓成交时返回 true（拉取失败返回 false）
+func (d *phantomCloseDetector) isPhantomClose(m *store.CopyTradePositionMapping) bool {
+	if !d.fetched {
+		d.fetched = true
+		d.fills, d.err = d.e.provider.GetFills(d.e.cfg().LeaderID, d.since)
+	}
+	if d.err != nil {
+		logger.Warnf("⚠️ [%s] 拉取领航员成交失败，无法判断仓位转移，按平仓处理: %v", d.e.traderID, d.err)
+		return false
+	}
+
+	since := phantomCloseSince(m, d.stateAt)
+	for _, f := range d.fills {
+		if f.Symbol != m.Symbol || f.Timestamp.Before(since) || f.Timestamp.After(d.stateAt) {
+			continue
+		}
+		if f.ClosedPnL != 0 && f.Price > 0 {
+			return false
+		}
+		if d.e.followerSide(f.PositionSide) == SideType(m.Side) && (f.Action == ActionClose || f.Action == ActionReduce) && f.Price > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// skipPhantomClose 判定为仓位转移时记录日志，按策略返回是否跳过平仓
+func (e *Engine) skipPhantomClose(d *phantomCloseDetector, m *store.CopyTradePositionMapping) bool {
+	if !d.isPhantomClose(m) {
+		return false
+	}
+	if e.cfg().PhantomClosePolicy == PhantomCloseIgnore {
+		logger.Warnf("👻 [%s] phantom close detected | %s %s posId=%s 消失但无平仓成交（疑似划转到子账户），按配置继续持有",
+			e.traderID, m.Symbol, m.Side, m.LeaderPosID)
+		e.logWarning(Warning{
+			Timestamp: time.Now(),
+			Symbol:    m.Symbol,
+			Type:      "phantom_close",
+			Message:   "领航员仓位消失但无平仓成交（疑似仓位划转），继续持有",
+			Executed:  false,
+		})
+		return true
+	}
+	logger.Warnf("👻 [%s] phantom close detected | %s %s posId=%s 消失但无平仓成交（疑似划转到子账户），按平仓跟随",
+		e.traderID, m.Symbol, m.Side, m.LeaderPosID)
+	return false
+}
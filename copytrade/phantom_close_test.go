@@ -0,0 +1,55 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+
+	"nofx/store"
+)
+
+// TestPhantomClose 重连对账：仓位消失且无平仓成交视为仓位转移，按策略跟随或继续持有；有平仓成交的照常平仓
+func TestPhantomClose(t *testing.T) {
+	run := func(t *testing.T, policy string) []string {
+		t.Helper()
+		st := newTestStore(t)
+		for _, symbol := range []string{"BTCUSDT", "SOLUSDT"} {
+			if err := st.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+				TraderID: "test", LeaderPosID: PositionKey(symbol, SideLong), LeaderID: "leader", Symbol: symbol,
+				Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 100, LastKnownSize: 1,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// 断线期间两个仓位都消失：SOL 有真实平仓成交，BTC 没有任何成交（划转到子账户）
+		provider := &fillsProvider{
+			fakeProvider: &fakeProvider{state: &AccountState{TotalEquity: 10000, Positions: map[string]*Position{}}},
+			fills: []Fill{{ID: "sol-close", Symbol: "SOLUSDT", Side: "sell", PositionSide: SideLong, Action: ActionClose,
+				Price: 150, Size: 1, Value: 150, ClosedPnL: 12, Timestamp: time.Now()}},
+		}
+		e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1, PhantomClosePolicy: policy}, 1000)
+		e.store = st
+		e.provider = provider
+
+		e.onReconnect(time.Now())
+
+		var got []string
+		for len(e.decisionCh) > 0 {
+			for _, d := range (<-e.decisionCh).Decisions {
+				got = append(got, d.Action+" "+d.Symbol)
+			}
+		}
+		return got
+	}
+
+	// mirror（默认）：仓位转移也按平仓跟随
+	if got := run(t, ""); len(got) != 2 {
+		t.Errorf("mirror decisions = %v, want both closed", got)
+	}
+
+	// ignore：只平掉有真实平仓成交的 SOL，BTC 继续持有
+	got := run(t, PhantomCloseIgnore)
+	if len(got) != 1 || got[0] != "close_long SOLUSDT" {
+		t.Errorf("ignore decisions = %v, want [close_long SOLUSDT]", got)
+	}
+}
@@ -0,0 +1,117 @@
+package copytrade
+
+import (
+	"context"
+	"runtime/pprof"
+	"runtime/trace"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// 性能诊断：关键路径耗时统计 + pprof/trace 标签
+// ============================================================================
+//
+// 仅在 CopyConfig.Profiling 开启时统计耗时，关闭时 traceSpan 只返回空函数。
+// 开启后：
+//   - syncLeaderState / matchSignalWithMapping / calculateCopySize 的耗时计入 min/max/avg
+//   - processSignal 所在 goroutine 带 pprof 标签 trader_id，CPU profile 可按 trader 过滤
+//   - 每段都会创建 runtime/trace region，便于 go tool trace 查看
+
+// TimingStat 单个环节的耗时统计（毫秒）
+type TimingStat struct {
+	Count  int64   `json:"count"`
+	MinMs  float64 `json:"min_ms"`
+	MaxMs  float64 `json:"max_ms"`
+	AvgMs  float64 `json:"avg_ms"`
+	LastMs float64 `json:"last_ms"`
+}
+
+type timingAcc struct {
+	count int64
+	total time.Duration
+	min   time.Duration
+	max   time.Duration
+	last  time.Duration
+}
+
+// engineProfiler 引擎耗时统计
+type engineProfiler struct {
+	mu      sync.Mutex
+	timings map[string]*timingAcc
+}
+
+func newEngineProfiler() *engineProfiler {
+	return &engineProfiler{timings: make(map[string]*timingAcc)}
+}
+
+func (p *engineProfiler) record(name string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	acc, ok := p.timings[name]
+	if !ok {
+		acc = &timingAcc{min: d}
+		p.timings[name] = acc
+	}
+	acc.count++
+	acc.total += d
+	acc.last = d
+	if d < acc.min {
+		acc.min = d
+	}
+	if d > acc.max {
+		acc.max = d
+	}
+}
+
+func (p *engineProfiler) snapshot() map[string]TimingStat {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	result := make(map[string]TimingStat, len(p.timings))
+	for name, acc := range p.timings {
+		result[name] = TimingStat{
+			Count:  acc.count,
+			MinMs:  ms(acc.min),
+			MaxMs:  ms(acc.max),
+			AvgMs:  ms(acc.total) / float64(acc.count),
+			LastMs: ms(acc.last),
+		}
+	}
+	return result
+}
+
+// traceSpan 记录一段耗时，用法：defer e.traceSpan("syncLeaderState")()
+func (e *Engine) traceSpan(name string) func() {
+	if e.profiler == nil {
+		return func() {}
+	}
+	region := trace.StartRegion(context.Background(), "copytrade."+name)
+	start := time.Now()
+	return func() {
+		region.End()
+		e.profiler.record(name, time.Since(start))
+	}
+}
+
+// profileLabels 为当前 goroutine 打上 trader_id pprof 标签，返回的函数用于清除
+// 用法：defer e.profileLabels()()
+func (e *Engine) profileLabels() func() {
+	if e.profiler == nil {
+		return func() {}
+	}
+	pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), pprof.Labels("trader_id", e.traderID)))
+	return func() {
+		pprof.SetGoroutineLabels(context.Background())
+	}
+}
+
+// GetTimings 获取关键路径耗时统计（未开启 Profiling 时返回 nil）
+func (e *Engine) GetTimings() map[string]TimingStat {
+	if e.profiler == nil {
+		return nil
+	}
+	return e.profiler.snapshot()
+}
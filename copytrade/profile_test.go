@@ -0,0 +1,28 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEngineProfilerTimings(t *testing.T) {
+	e := newTestEngine(&CopyConfig{}, 1000)
+	e.traceSpan("syncLeaderState")()
+	if e.GetTimings() != nil {
+		t.Fatal("timings should be nil when profiling is disabled")
+	}
+
+	e.profiler = newEngineProfiler()
+	e.profiler.record("syncLeaderState", 10*time.Millisecond)
+	e.profiler.record("syncLeaderState", 30*time.Millisecond)
+	e.traceSpan("matchSignalWithMapping")()
+
+	timings := e.GetTimings()
+	sync := timings["syncLeaderState"]
+	if sync.Count != 2 || sync.MinMs != 10 || sync.MaxMs != 30 || sync.AvgMs != 20 || sync.LastMs != 30 {
+		t.Errorf("syncLeaderState timing = %+v", sync)
+	}
+	if timings["matchSignalWithMapping"].Count != 1 {
+		t.Errorf("matchSignalWithMapping not recorded: %+v", timings)
+	}
+}
@@ -3,11 +3,15 @@ package copytrade
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"nofx/logger"
@@ -27,6 +31,9 @@ type LeaderProvider interface {
 
 	// Type 返回提供者类型
 	Type() ProviderType
+
+	// ValidateLeader 轻量校验领航员是否存在（保存/启动跟单前调用）
+	ValidateLeader(leaderID string) error
 }
 
 // StreamingProvider 流式数据提供者接口（支持 WebSocket 推送）
@@ -50,27 +57,92 @@ type StreamingProvider interface {
 	IsStreaming() bool
 }
 
+// FreshStateProvider 支持绕过缓存同步拉取账户状态（开仓前刷新领航员权益）
+type FreshStateProvider interface {
+	GetAccountStateFresh(leaderID string) (*AccountState, error)
+}
+
+// ProviderCapabilities Provider 能力描述（引擎按能力而不是按类型分支）
+type ProviderCapabilities struct {
+	Streaming         bool // 支持 WebSocket 推送（StreamingProvider）
+	CompleteFillValue bool // fill.Value 为完整订单价值（不存在大单拆分成多笔 fill 的问题）
+	PrivateProfiles   bool // 领航员主页可能未公开（公开接口失败可能是私密主页而非网络问题）
+}
+
+var (
+	// ErrUnsupportedProvider 未知的 Provider 类型
+	ErrUnsupportedProvider = errors.New("unsupported provider type")
+	// ErrStreamingNotSupported Provider 不支持流式模式（调用方应回退到轮询模式）
+	ErrStreamingNotSupported = errors.New("streaming mode not supported")
+)
+
+// providerSpec 单个 Provider 的构造函数与能力（新增 Provider 只需在 providerRegistry 注册）
+type providerSpec struct {
+	caps      ProviderCapabilities
+	rest      func() LeaderProvider
+	streaming func() StreamingProvider // nil = 不支持流式
+}
+
+var providerRegistry = map[ProviderType]providerSpec{
+	ProviderHyperliquid: {
+		caps:      ProviderCapabilities{Streaming: true},
+		rest:      func() LeaderProvider { return NewHyperliquidProvider() },
+		streaming: func() StreamingProvider { return NewHLWebSocketProvider() },
+	},
+	ProviderOKX: {
+		caps: ProviderCapabilities{CompleteFillValue: true, PrivateProfiles: true},
+		rest: func() LeaderProvider { return NewOKXProvider() },
+	},
+}
+
+// lookupProvider 查找 Provider 注册信息
+func lookupProvider(providerType ProviderType) (providerSpec, error) {
+	spec, ok := providerRegistry[providerType]
+	if !ok {
+		return providerSpec{}, fmt.Errorf("%w: %s", ErrUnsupportedProvider, providerType)
+	}
+	return spec, nil
+}
+
+// GetProviderCapabilities 查询 Provider 能力
+func GetProviderCapabilities(providerType ProviderType) (ProviderCapabilities, error) {
+	spec, err := lookupProvider(providerType)
+	if err != nil {
+		return ProviderCapabilities{}, err
+	}
+	return spec.caps, nil
+}
+
+// BuildProvider 统一的 Provider 工厂：返回 Provider 及其能力
+// preferStreaming=true 且支持流式时返回 StreamingProvider（可类型断言），否则返回 REST 轮询 Provider
+func BuildProvider(providerType ProviderType, preferStreaming bool) (LeaderProvider, ProviderCapabilities, error) {
+	spec, err := lookupProvider(providerType)
+	if err != nil {
+		return nil, ProviderCapabilities{}, err
+	}
+	if preferStreaming && spec.streaming != nil {
+		return spec.streaming(), spec.caps, nil
+	}
+	return spec.rest(), spec.caps, nil
+}
+
 // NewProvider 创建 Provider（REST 轮询模式）
 func NewProvider(providerType ProviderType) (LeaderProvider, error) {
-	switch providerType {
-	case ProviderHyperliquid:
-		return NewHyperliquidProvider(), nil
-	case ProviderOKX:
-		return NewOKXProvider(), nil
-	default:
-		return nil, fmt.Errorf("unsupported provider type: %s", providerType)
-	}
+	provider, _, err := BuildProvider(providerType, false)
+	return provider, err
 }
 
 // NewStreamingProvider 创建流式 Provider（WebSocket 事件驱动模式）
-// 目前只有 Hyperliquid 支持
+// 不支持流式的 Provider 返回 ErrStreamingNotSupported（可用 errors.Is 判断）
 func NewStreamingProvider(providerType ProviderType) (StreamingProvider, error) {
-	switch providerType {
-	case ProviderHyperliquid:
-		return NewHLWebSocketProvider(), nil
-	default:
-		return nil, fmt.Errorf("provider %s does not support streaming mode", providerType)
+	spec, err := lookupProvider(providerType)
+	if err != nil {
+		return nil, err
 	}
+	if spec.streaming == nil {
+		return nil, fmt.Errorf("provider %s: %w", providerType, ErrStreamingNotSupported)
+	}
+	return spec.streaming(), nil
 }
 
 // ============================================================================
@@ -83,13 +155,15 @@ const (
 
 // HyperliquidProvider Hyperliquid 数据提供者
 type HyperliquidProvider struct {
-	client *http.Client
+	client     *http.Client
+	stateCache *accountStateCache // GetAccountState 单飞缓存
 }
 
 // NewHyperliquidProvider 创建 Hyperliquid Provider
 func NewHyperliquidProvider() *HyperliquidProvider {
 	return &HyperliquidProvider{
-		client: &http.Client{Timeout: 10 * time.Second},
+		client:     &http.Client{Timeout: 10 * time.Second},
+		stateCache: newAccountStateCache(AccountStateCacheTTL),
 	}
 }
 
@@ -97,15 +171,21 @@ func (p *HyperliquidProvider) Type() ProviderType {
 	return ProviderHyperliquid
 }
 
-// GetFills 获取成交记录
-func (p *HyperliquidProvider) GetFills(leaderID string, since time.Time) ([]Fill, error) {
-	req := map[string]string{
-		"type": "userFills",
-		"user": leaderID,
+// ValidateLeader 校验领航员地址格式并确认 clearinghouseState 可查询
+func (p *HyperliquidProvider) ValidateLeader(leaderID string) error {
+	if err := ValidateHLAddress(leaderID); err != nil {
+		return err
 	}
+	if _, err := p.fetchAccountState(leaderID); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrLeaderNotFound, leaderID, err)
+	}
+	return nil
+}
 
-	var rawFills []HLFillRaw
-	if err := p.post(req, &rawFills); err != nil {
+// GetFills 获取成交记录（按时间分页拉取 since 之后的全部成交）
+func (p *HyperliquidProvider) GetFills(leaderID string, since time.Time) ([]Fill, error) {
+	rawFills, err := p.fetchFillsSince(leaderID, since)
+	if err != nil {
 		return nil, fmt.Errorf("get fills failed: %w", err)
 	}
 
@@ -125,9 +205,17 @@ func (p *HyperliquidProvider) GetFills(leaderID string, since time.Time) ([]Fill
 			ClosedPnL: parseFloat(raw.ClosedPnl),
 			Raw:       raw,
 		}
+		if !isValidFillSymbol(fill.Symbol) {
+			logger.Debugf("⏭️ [HL] unparseable symbol skipped | coin=%q raw=%+v", raw.Coin, raw)
+			continue
+		}
 
 		// 解析方向
 		fill.Side, fill.PositionSide, fill.Action = parseHLDirection(raw.Side, raw.Dir, raw.StartPosition)
+		fill.AmbiguousDir = !isKnownHLDir(raw.Dir)
+		if raw.Liquidation != nil {
+			fill.CloseTrigger = CloseTriggerLiquidation
+		}
 
 		// 计算成交价值
 		fill.Value = fill.Price * fill.Size
@@ -138,8 +226,91 @@ func (p *HyperliquidProvider) GetFills(leaderID string, since time.Time) ([]Fill
 	return fills, nil
 }
 
-// GetAccountState 获取账户状态
+const (
+	// HLFillsPageSize userFillsByTime 单页最多返回的成交数
+	HLFillsPageSize = 2000
+	// HLMaxFillPages 单次 GetFills 最多请求的页数（限制成交爆发时的拉取耗时）
+	HLMaxFillPages = 5
+)
+
+// fetchFillsSince 用 userFillsByTime 分页拉取 since 之后的原始成交
+// userFillsByTime 按时间升序返回 [startTime, now] 内最早的最多 HLFillsPageSize 笔：
+// 满页时以本页最后一笔的时间作为下一页起点继续拉取，直到不满一页。
+// 同一毫秒的成交可能在相邻两页重复出现，按 tid 去重。
+// 达到 HLMaxFillPages 仍未拉完时补拉一次 userFills（最近的成交），保证最新成交不会被更早的成交挤掉。
+func (p *HyperliquidProvider) fetchFillsSince(leaderID string, since time.Time) ([]HLFillRaw, error) {
+	start := since.UnixMilli()
+	if since.IsZero() || start < 0 {
+		start = 0
+	}
+
+	var all []HLFillRaw
+	seen := make(map[int64]bool)
+	add := func(raw []HLFillRaw) {
+		for _, r := range raw {
+			if r.TID != 0 {
+				if seen[r.TID] {
+					continue
+				}
+				seen[r.TID] = true
+			}
+			all = append(all, r)
+		}
+	}
+
+	for page := 0; page < HLMaxFillPages; page++ {
+		req := map[string]interface{}{
+			"type":      "userFillsByTime",
+			"user":      leaderID,
+			"startTime": start,
+		}
+		var raw []HLFillRaw
+		if err := p.post(req, &raw); err != nil {
+			return nil, err
+		}
+		add(raw)
+		if len(raw) < HLFillsPageSize {
+			return all, nil
+		}
+
+		next := start
+		for _, r := range raw {
+			if r.Time > next {
+				next = r.Time
+			}
+		}
+		if next == start {
+			break // 整页成交都在同一毫秒，无法继续按时间翻页
+		}
+		start = next
+	}
+
+	logger.Warnf("⚠️ [HL] 领航员 %s 成交超过 %d 页，补拉最近成交（中间部分成交可能遗漏）", leaderID, HLMaxFillPages)
+	var recent []HLFillRaw
+	if err := p.post(map[string]string{"type": "userFills", "user": leaderID}, &recent); err != nil {
+		return nil, err
+	}
+	add(recent)
+	return all, nil
+}
+
+// GetAccountState 获取账户状态（并发调用合并为一次请求，结果短时缓存）
 func (p *HyperliquidProvider) GetAccountState(leaderID string) (*AccountState, error) {
+	if p.stateCache == nil {
+		return p.fetchAccountState(leaderID)
+	}
+	return p.stateCache.get(leaderID, func() (*AccountState, error) {
+		return p.fetchAccountState(leaderID)
+	})
+}
+
+// GetAccountStateFresh 绕过缓存直接请求账户状态
+func (p *HyperliquidProvider) GetAccountStateFresh(leaderID string) (*AccountState, error) {
+	return p.fetchAccountState(leaderID)
+}
+
+// fetchAccountState 请求账户状态
+func (p *HyperliquidProvider) fetchAccountState(leaderID string) (*AccountState, error) {
 	req := map[string]string{
 		"type": "clearinghouseState",
 		"user": leaderID,
@@ -154,7 +325,7 @@ func (p *HyperliquidProvider) GetAccountState(leaderID string) (*AccountState, e
 		TotalEquity:      parseFloat(raw.MarginSummary.AccountValue),
 		AvailableBalance: parseFloat(raw.Withdrawable),
 		Positions:        make(map[string]*Position),
-		Timestamp:        time.UnixMilli(raw.Time),
+		Timestamp:        stateTimestamp(raw.Time, leaderID),
 	}
 
 	// 解析持仓
@@ -179,7 +350,7 @@ func (p *HyperliquidProvider) GetAccountState(leaderID string) (*AccountState, e
 			Side:          side,
 			Size:          size,
 			EntryPrice:    parseFloat(pos.EntryPx),
-			Leverage:      pos.Leverage.Value,
+			Leverage:      normalizeHLLeverage(pos.Leverage, parseFloat(pos.PositionValue), parseFloat(pos.MarginUsed)),
 			MarginMode:    pos.Leverage.Type,
 			UnrealizedPnL: parseFloat(pos.UnrealizedPnl),
 			PositionValue: parseFloat(pos.PositionValue),
@@ -189,6 +360,21 @@ func (p *HyperliquidProvider) GetAccountState(leaderID string) (*AccountState, e
 	return state, nil
 }
 
+// 早于此时间的交易所时间戳视为无效（如缺失字段解析成 0 → 1970 年）
+var minPlausibleTimestamp = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// stateTimestamp 将交易所返回的毫秒时间转换为状态时间
+// 时间缺失（0）或明显不合理（早于 2020 年、超前本地时钟 1 天以上）时使用本地时间，避免新鲜度判断失效
+func stateTimestamp(ms int64, leaderID string) time.Time {
+	now := time.Now()
+	ts := time.UnixMilli(ms)
+	if ms <= 0 || ts.Before(minPlausibleTimestamp) || ts.After(now.Add(24*time.Hour)) {
+		logger.Debugf("🕐 领航员 %s 状态时间无效 (time=%d)，使用本地时间", leaderID, ms)
+		return now
+	}
+	return ts
+}
+
 func (p *HyperliquidProvider) post(req interface{}, result interface{}) error {
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -248,6 +434,15 @@ func parseHLDirection(side, dir, startPosition string) (tradeSide string, posSid
 	}
 }
 
+// isKnownHLDir dir 是否能明确区分开仓/平仓
+func isKnownHLDir(dir string) bool {
+	switch dir {
+	case "Open Long", "Close Long", "Open Short", "Close Short", "Long > Short", "Short > Long":
+		return true
+	}
+	return false
+}
+
 // ============================================================================
 // OKX Provider
 // ============================================================================
@@ -260,13 +455,21 @@ const (
 
 // OKXProvider OKX 数据提供者
 type OKXProvider struct {
-	client *http.Client
+	client     *http.Client
+	stateCache *accountStateCache // GetAccountState 单飞缓存
+	limiter    *rateLimiter       // 请求限频（nil = 共用 okxLimiter）
+
+	// 非 USDT 计价合约：instId → 跟随者币种映射；未映射的合约跳过（每个 instId 记录一次日志）
+	instrumentMap map[string]string
+	skippedInst   map[string]bool
+	instMu        sync.Mutex
 }
 
 // NewOKXProvider 创建 OKX Provider
 func NewOKXProvider() *OKXProvider {
 	return &OKXProvider{
-		client: &http.Client{Timeout: 10 * time.Second},
+		client:     &http.Client{Timeout: 10 * time.Second},
+		stateCache: newAccountStateCache(AccountStateCacheTTL),
 	}
 }
 
@@ -274,6 +477,22 @@ func (p *OKXProvider) Type() ProviderType {
 	return ProviderOKX
 }
 
+// ValidateLeader 确认 uniqueName 对应的交易员存在且主页公开（只请求资产接口）
+func (p *OKXProvider) ValidateLeader(uniqueName string) error {
+	if strings.TrimSpace(uniqueName) == "" {
+		return fmt.Errorf("%w: uniqueName is empty", ErrLeaderNotFound)
+	}
+	assetURL := fmt.Sprintf("%s?uniqueName=%s&t=%d", OKXAssetAPI, url.QueryEscape(uniqueName), time.Now().UnixMilli())
+	var assetResp OKXAssetResp
+	if err := p.get(assetURL, &assetResp); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrLeaderNotFound, uniqueName, err)
+	}
+	if assetResp.Code != "" && assetResp.Code != "0" {
+		return fmt.Errorf("%w: %s: code=%s msg=%s", ErrLeaderNotFound, uniqueName, assetResp.Code, assetResp.Msg)
+	}
+	return nil
+}
+
 // GetFills 获取成交记录
 func (p *OKXProvider) GetFills(uniqueName string, since time.Time) ([]Fill, error) {
 	now := time.Now()
@@ -299,16 +518,25 @@ func (p *OKXProvider) GetFills(uniqueName string, since time.Time) ([]Fill, erro
 	for _, raw := range resp.Data {
 		fill := Fill{
 			ID:        raw.OrdId,
-			Symbol:    normalizeOKXSymbol(raw.InstId),
+			Symbol:    p.symbolOf(raw.InstId),
 			Price:     parseFloat(raw.AvgPx),
 			Size:      parseFloat(raw.Sz),
 			Value:     parseFloat(raw.Value),
 			Timestamp: time.UnixMilli(parseInt64(raw.FillTime)),
+			ClosedPnL: parseFloat(raw.Pnl),
 			Raw:       raw,
 		}
+		if !isValidFillSymbol(fill.Symbol) {
+			logger.Debugf("⏭️ [OKX] unparseable symbol skipped | instId=%q raw=%+v", raw.InstId, raw)
+			continue
+		}
 
 		// 解析方向
 		fill.Side, fill.PositionSide, fill.Action = parseOKXDirection(raw.Side, raw.PosSide)
+		fill.NetMode = raw.PosSide == "net"
+		fill.MarginMode = raw.MgnMode
+		// 单向持仓模式下开/平在引擎中才确定，这里不按方向过滤（只有平仓/减仓决策会带上）
+		fill.CloseTrigger = closeTriggerFromOKXOrdType(raw.OrdType, fill.ClosedPnL)
 
 		fills = append(fills, fill)
 	}
@@ -316,8 +544,23 @@ func (p *OKXProvider) GetFills(uniqueName string, since time.Time) ([]Fill, erro
 	return fills, nil
 }
 
-// GetAccountState 获取账户状态
+// GetAccountState 获取账户状态（并发调用合并为一次请求，结果短时缓存）
 func (p *OKXProvider) GetAccountState(uniqueName string) (*AccountState, error) {
+	if p.stateCache == nil {
+		return p.fetchAccountState(uniqueName)
+	}
+	return p.stateCache.get(uniqueName, func() (*AccountState, error) {
+		return p.fetchAccountState(uniqueName)
+	})
+}
+
+// GetAccountStateFresh 绕过缓存直接请求账户状态
+func (p *OKXProvider) GetAccountStateFresh(uniqueName string) (*AccountState, error) {
+	return p.fetchAccountState(uniqueName)
+}
+
+// fetchAccountState 请求账户状态
+func (p *OKXProvider) fetchAccountState(uniqueName string) (*AccountState, error) {
 	now := time.Now().UnixMilli()
 
 	// 1. 获取资产
@@ -348,11 +591,26 @@ func (p *OKXProvider) GetAccountState(uniqueName string) (*AccountState, error)
 		}
 	}
 
-	// 解析持仓 (OKX: 使用 posId 作为唯一标识，精确区分每个仓位)
-	for _, pd := range posResp.Data {
+	state.Positions = parseOKXPositions(posResp.Data, p.symbolOf)
+
+	return state, nil
+}
+
+// parseOKXPositions 解析 OKX 持仓 (使用 posId 作为唯一标识，精确区分每个仓位)
+// symbolOf 把 instId 转为跟随者币种，返回空串的合约（如未映射的非 USDT 合约）跳过
+func parseOKXPositions(data []OKXPositionData, symbolOf func(instId string) string) map[string]*Position {
+	positions := make(map[string]*Position)
+
+	for _, pd := range data {
 		for _, pos := range pd.PosData {
-			symbol := normalizeOKXSymbol(pos.InstId)
-			side := SideType(pos.PosSide)
+			symbol := symbolOf(pos.InstId)
+			if symbol == "" {
+				continue
+			}
+			side, size := normalizeOKXPosSide(pos.PosSide, parseFloat(pos.Pos))
+			if size == 0 {
+				continue // 单向持仓模式下空仓
+			}
 			mgnMode := pos.MgnMode // "cross" | "isolated"
 			posId := pos.PosId     // 仓位唯一标识
 
@@ -365,10 +623,10 @@ func (p *OKXProvider) GetAccountState(uniqueName string) (*AccountState, error)
 				key = PositionKeyWithMode(symbol, side, mgnMode) // 回退兼容
 			}
 
-			state.Positions[key] = &Position{
+			positions[key] = &Position{
 				Symbol:        symbol,
 				Side:          side,
-				Size:          parseFloat(pos.Pos),
+				Size:          size,
 				EntryPrice:    parseFloat(pos.AvgPx),
 				MarkPrice:     parseFloat(pos.MarkPx),
 				Leverage:      parseInt(pos.Lever),
@@ -376,18 +634,51 @@ func (p *OKXProvider) GetAccountState(uniqueName string) (*AccountState, error)
 				UnrealizedPnL: parseFloat(pos.Upl),
 				PositionValue: parseFloat(pos.NotionalUsd),
 				PosID:         posId,
+
+				TakeProfitPrice: parseFloat(pos.TpTriggerPx),
+				StopLossPrice:   parseFloat(pos.SlTriggerPx),
 			}
 		}
 	}
 
-	return state, nil
+	return positions
+}
+
+// normalizeOKXPosSide 统一持仓方向
+// 单向持仓模式（posSide="net"）下方向由持仓数量正负决定：正数为多、负数为空
+func normalizeOKXPosSide(posSide string, pos float64) (SideType, float64) {
+	if posSide == "long" || posSide == "short" {
+		return SideType(posSide), pos
+	}
+	if pos < 0 {
+		return SideShort, -pos
+	}
+	return SideLong, pos
 }
 
+// get 限频后发起请求；429 时按 Retry-After 等待后重试一次
 func (p *OKXProvider) get(url string, result interface{}) error {
+	limiter := p.limiter
+	if limiter == nil {
+		limiter = okxLimiter
+	}
+
+	limiter.wait()
 	resp, err := p.client.Get(url)
 	if err != nil {
 		return err
 	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait := retryAfter(resp.Header)
+		resp.Body.Close()
+		logger.Warnf("⚠️ [OKX] 请求被限频 (429)，%v 后重试", wait)
+		time.Sleep(wait)
+
+		limiter.wait()
+		if resp, err = p.client.Get(url); err != nil {
+			return err
+		}
+	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
@@ -413,6 +704,15 @@ func parseOKXDirection(side, posSide string) (tradeSide string, positionSide Sid
 		return "buy", SideShort, ActionClose // 或 reduce
 	}
 
+	// 单向持仓模式（posSide="net"）：buy 可能是开多也可能是平空，
+	// 这里先按开仓处理，由 engine 结合本地映射修正（resolveNetModeFill）
+	if posSide == "net" {
+		if side == "sell" {
+			return "sell", SideShort, ActionOpen
+		}
+		return "buy", SideLong, ActionOpen
+	}
+
 	return side, positionSide, ActionOpen
 }
 
@@ -434,6 +734,15 @@ type HLFillRaw struct {
 	Oid           int64  `json:"oid"`
 	TID           int64  `json:"tid"`
 	FeeToken      string `json:"feeToken"`
+
+	Liquidation *HLFillLiquidation `json:"liquidation,omitempty"` // 强平成交时存在（止盈/止损触发单成交不区分）
+}
+
+// HLFillLiquidation 强平成交信息
+type HLFillLiquidation struct {
+	LiquidatedUser string `json:"liquidatedUser"`
+	MarkPx         string `json:"markPx"`
+	Method         string `json:"method"` // "market" | "backstop"
 }
 
 // HLClearinghouseState clearinghouseState 返回结构
@@ -448,22 +757,29 @@ type HLClearinghouseState struct {
 	AssetPositions []struct {
 		Type     string `json:"type"`
 		Position struct {
-			Coin          string `json:"coin"`
-			Szi           string `json:"szi"`
-			Leverage      struct {
-				Type  string `json:"type"`
-				Value int    `json:"value"`
-			} `json:"leverage"`
-			EntryPx       string `json:"entryPx"`
-			PositionValue string `json:"positionValue"`
-			UnrealizedPnl string `json:"unrealizedPnl"`
-			LiquidationPx string `json:"liquidationPx,omitempty"`
-			MarginUsed    string `json:"marginUsed"`
+			Coin          string     `json:"coin"`
+			Szi           string     `json:"szi"`
+			Leverage      HLLeverage `json:"leverage"`
+			EntryPx       string     `json:"entryPx"`
+			PositionValue string     `json:"positionValue"`
+			UnrealizedPnl string     `json:"unrealizedPnl"`
+			LiquidationPx string     `json:"liquidationPx,omitempty"`
+			MarginUsed    string     `json:"marginUsed"`
 		} `json:"position"`
 	} `json:"assetPositions"`
 	Time int64 `json:"time"`
 }
 
+// HLLeverage 持仓杠杆（REST 与 WebSocket 共用）
+// cross: {"type":"cross","value":20}
+// isolated: {"type":"isolated","value":10,"rawUsd":"-1234.5"}
+// value 按浮点解析，兼容返回小数杠杆的情况
+type HLLeverage struct {
+	Type   string  `json:"type"`
+	Value  float64 `json:"value"`
+	RawUsd string  `json:"rawUsd,omitempty"`
+}
+
 // ============================================================================
 // API 返回结构（OKX）
 // ============================================================================
@@ -485,10 +801,25 @@ type OKXTradeRecord struct {
 	Lever    string `json:"lever"`
 	OrdId    string `json:"ordId"`
 	OrdType  string `json:"ordType"`
-	PosSide  string `json:"posSide"` // "long" | "short"
+	PosSide  string `json:"posSide"` // "long" | "short" | "net"
 	Side     string `json:"side"`    // "buy" | "sell"
 	Sz       string `json:"sz"`
 	Value    string `json:"value"`
+	Pnl      string `json:"pnl"`     // 平仓收益（开仓为 0，字段缺失时同样为 0）
+	MgnMode  string `json:"mgnMode"` // "cross" | "isolated"（缺失时为空，按 posId + size 变化区分）
+}
+
+// closeTriggerFromOKXOrdType 根据 OKX 订单类型识别触发单平仓（止盈/止损按平仓盈亏方向区分）
+func closeTriggerFromOKXOrdType(ordType string, pnl float64) string {
+	switch ordType {
+	case "conditional", "oco", "trigger", "move_order_stop":
+	default:
+		return ""
+	}
+	if pnl > 0 {
+		return CloseTriggerTakeProfit
+	}
+	return CloseTriggerStopLoss
 }
 
 // OKXAssetResp asset 返回结构
@@ -526,7 +857,9 @@ type OKXPosition struct {
 	Pos         string `json:"pos"`
 	PosSide     string `json:"posSide"`
 	Upl         string `json:"upl"`
-	PosId       string `json:"posId"` // 仓位唯一标识
+	PosId       string `json:"posId"`       // 仓位唯一标识
+	TpTriggerPx string `json:"tpTriggerPx"` // 止盈触发价（未设置或接口不返回时为空）
+	SlTriggerPx string `json:"slTriggerPx"` // 止损触发价
 }
 
 // ============================================================================
@@ -542,6 +875,22 @@ func normalizeSymbol(coin string) string {
 	return coin
 }
 
+// isValidFillSymbol 标准化后的币种是否可用（非空基础币 + USDT）
+// 币种为空或无法解析时 normalizeSymbol 得到 "USDT"、normalizeOKXSymbol 得到空串或原样返回，
+// 这些成交在下游匹配/下单时必然失败，应在数据源层丢弃
+func isValidFillSymbol(symbol string) bool {
+	base, ok := strings.CutSuffix(symbol, "USDT")
+	if !ok || base == "" {
+		return false
+	}
+	for _, r := range base {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
 // normalizeOKXSymbol OKX 符号格式化: "BTC-USDT-SWAP" -> "BTCUSDT"
 func normalizeOKXSymbol(instId string) string {
 	parts := strings.Split(instId, "-")
@@ -551,6 +900,26 @@ func normalizeOKXSymbol(instId string) string {
 	return strings.ToUpper(instId)
 }
 
+// normalizeHLLeverage 统一 Hyperliquid 杠杆为整数
+// - 小数杠杆向下取整（不超过领航员实际杠杆），最小为 1
+// - 未返回杠杆时（如部分逐仓数据），用 仓位价值/占用保证金 推算有效杠杆
+func normalizeHLLeverage(lev HLLeverage, positionValue, marginUsed float64) int {
+	value := lev.Value
+	if value <= 0 && positionValue > 0 && marginUsed > 0 {
+		value = positionValue / marginUsed
+	}
+	if value <= 0 {
+		return 0
+	}
+
+	// 容忍浮点误差（如 19.9999999 视为 20）
+	leverage := int(math.Floor(value + 1e-6))
+	if leverage < 1 {
+		leverage = 1
+	}
+	return leverage
+}
+
 // parseFloat 安全解析浮点数
 func parseFloat(s string) float64 {
 	if s == "" {
@@ -589,4 +958,3 @@ func parseInt64(s string) int64 {
 	}
 	return i
 }
-
@@ -3,6 +3,7 @@ package copytrade
 import (
 	"encoding/json"
 	"fmt"
+	"math/rand/v2"
 	"strconv"
 	"sync"
 	"time"
@@ -20,8 +21,11 @@ const (
 	HLWebSocketURL = "wss://api.hyperliquid.xyz/ws"
 	// 心跳间隔（官方要求 60 秒内必须有消息，我们用 30 秒）
 	HLHeartbeatInterval = 30 * time.Second
-	// 重连延迟
-	HLReconnectDelay = 3 * time.Second
+	// 重连延迟（首次重连），连续失败时指数退避到 HLReconnectMaxDelay
+	HLReconnectDelay    = 3 * time.Second
+	HLReconnectMaxDelay = 60 * time.Second
+	// 重连延迟随机抖动幅度（±20%），避免大量连接同时重连
+	HLReconnectJitter = 0.2
 )
 
 // HLWebSocketProvider Hyperliquid WebSocket 数据提供者
@@ -36,6 +40,7 @@ type HLWebSocketProvider struct {
 	// 回调函数
 	onFill        func(Fill)
 	onStateUpdate func(*AccountState)
+	onReconnect   func(stateAt time.Time)
 
 	// 状态缓存（由 REST 获取或 WebSocket 推送更新）
 	latestState *AccountState
@@ -70,6 +75,10 @@ func (p *HLWebSocketProvider) Type() ProviderType {
 	return ProviderHyperliquid
 }
 
+func (p *HLWebSocketProvider) ValidateLeader(leaderID string) error {
+	return p.restProvider.ValidateLeader(leaderID)
+}
+
 func (p *HLWebSocketProvider) IsStreaming() bool {
 	return true
 }
@@ -82,6 +91,11 @@ func (p *HLWebSocketProvider) SetOnStateUpdate(callback func(*AccountState)) {
 	p.onStateUpdate = callback
 }
 
+// SetOnReconnect 设置重连回调（ReconnectNotifier）：重连后刷新状态成功时、恢复读取消息前调用
+func (p *HLWebSocketProvider) SetOnReconnect(callback func(stateAt time.Time)) {
+	p.onReconnect = callback
+}
+
 // Connect 连接并订阅指定领航员
 func (p *HLWebSocketProvider) Connect(leaderID string) error {
 	p.leaderID = leaderID
@@ -169,6 +183,14 @@ func (p *HLWebSocketProvider) GetAccountState(leaderID string) (*AccountState, e
 	return newState, nil
 }
 
+// GetAccountStateFresh 通过 REST 同步拉取最新账户状态（不覆盖 WS 推送的缓存，避免乱序）
+func (p *HLWebSocketProvider) GetAccountStateFresh(leaderID string) (*AccountState, error) {
+	if p.restProvider == nil {
+		return nil, fmt.Errorf("no REST provider")
+	}
+	return p.restProvider.fetchAccountState(leaderID)
+}
+
 // ============================================================================
 // WebSocket 连接管理
 // ============================================================================
@@ -225,9 +247,13 @@ func (p *HLWebSocketProvider) reconnect() {
 		return
 	}
 
-	logger.Warnf("⚠️ [HL-WS] 连接断开，%v 后重连...", HLReconnectDelay)
-	time.Sleep(HLReconnectDelay)
+	disconnectedAt := time.Now()
+	delay := hlReconnectBackoff(0)
+	logger.Warnf("⚠️ [HL-WS] 连接断开，%v 后重连...", delay.Round(time.Millisecond))
+	time.Sleep(delay)
 
+	// 连续失败次数：每次重连成功后归零，下次断开从 HLReconnectDelay 重新开始
+	failures := 0
 	for {
 		p.runningMu.RLock()
 		running := p.running
@@ -238,17 +264,42 @@ func (p *HLWebSocketProvider) reconnect() {
 		}
 
 		if err := p.connect(); err != nil {
-			logger.Warnf("⚠️ [HL-WS] 重连失败: %v，%v 后重试...", err, HLReconnectDelay)
-			time.Sleep(HLReconnectDelay)
+			failures++
+			delay := hlReconnectBackoff(failures)
+			logger.Warnf("⚠️ [HL-WS] 重连失败（连续 %d 次，已断开 %v）: %v，%v 后重试...",
+				failures, time.Since(disconnectedAt).Round(time.Second), err, delay.Round(time.Millisecond))
+			time.Sleep(delay)
 			continue
 		}
 
-		logger.Infof("✅ [HL-WS] 重连成功")
+		logger.Infof("✅ [HL-WS] 重连成功（失败 %d 次，断开 %v）", failures, time.Since(disconnectedAt).Round(time.Second))
+
+		// 断线期间的成交不会再推送：先刷新状态并回调对账，完成后再处理实时成交
+		stateAt := time.Now()
+		if p.refreshAccountState(true) {
+			if p.onReconnect != nil {
+				p.onReconnect(stateAt)
+			}
+		} else {
+			logger.Warnf("⚠️ [HL-WS] 重连后刷新状态失败，跳过对账")
+		}
+
 		go p.readLoop() // 重连成功后重启读取循环
 		return
 	}
 }
 
+// hlReconnectBackoff 第 failures 次失败后的重连等待：HLReconnectDelay × 2^failures，上限 HLReconnectMaxDelay，叠加 ±20% 抖动
+func hlReconnectBackoff(failures int) time.Duration {
+	delay := HLReconnectDelay
+	for i := 0; i < failures && delay < HLReconnectMaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, HLReconnectMaxDelay)
+	jitter := 1 + HLReconnectJitter*(2*rand.Float64()-1)
+	return time.Duration(float64(delay) * jitter)
+}
+
 // ============================================================================
 // 消息处理
 // ============================================================================
@@ -327,12 +378,16 @@ func (p *HLWebSocketProvider) handleUserFills(data json.RawMessage) {
 
 	// 如果有新成交，先通过 REST 获取最新账户状态（解决 WS 时序问题）
 	if len(fillsMsg.Fills) > 0 {
-		p.refreshAccountState()
+		p.refreshAccountState(false)
 	}
 
 	// 处理新成交
 	for _, wsFill := range fillsMsg.Fills {
 		fill := p.convertWsFill(wsFill)
+		if !isValidFillSymbol(fill.Symbol) {
+			logger.Debugf("⏭️ [HL-WS] unparseable symbol skipped | coin=%q raw=%+v", wsFill.Coin, wsFill)
+			continue
+		}
 
 		// 添加到缓存
 		p.addFillToCache(fill)
@@ -349,15 +404,20 @@ func (p *HLWebSocketProvider) handleUserFills(data json.RawMessage) {
 // refreshAccountState 通过 REST 获取最新账户状态（混合模式）
 // 在收到交易信号时调用，确保获取到准确的领航员权益和持仓信息
 // 同时触发 onStateUpdate 回调，让 Engine 也更新 leaderState 缓存
-func (p *HLWebSocketProvider) refreshAccountState() {
+// fresh=true 时绕过 REST 状态缓存（重连对账需要断线后的真实状态），返回是否刷新成功
+func (p *HLWebSocketProvider) refreshAccountState(fresh bool) bool {
 	if p.restProvider == nil || p.leaderID == "" {
-		return
+		return false
 	}
 
-	state, err := p.restProvider.GetAccountState(p.leaderID)
+	get := p.restProvider.GetAccountState
+	if fresh {
+		get = p.restProvider.GetAccountStateFresh
+	}
+	state, err := get(p.leaderID)
 	if err != nil {
 		logger.Warnf("⚠️ [HL-WS] REST 获取账户状态失败: %v", err)
-		return
+		return false
 	}
 
 	// 更新本地缓存
@@ -373,6 +433,7 @@ func (p *HLWebSocketProvider) refreshAccountState() {
 	if p.onStateUpdate != nil {
 		p.onStateUpdate(state)
 	}
+	return true
 }
 
 func (p *HLWebSocketProvider) handleClearinghouseState(data json.RawMessage) {
@@ -448,6 +509,8 @@ type WsFill struct {
 	Crossed       bool   `json:"crossed"`
 	Fee           string `json:"fee"`
 	Tid           int64  `json:"tid"`
+
+	Liquidation *HLFillLiquidation `json:"liquidation,omitempty"`
 }
 
 func (p *HLWebSocketProvider) convertWsFill(raw WsFill) Fill {
@@ -461,6 +524,11 @@ func (p *HLWebSocketProvider) convertWsFill(raw WsFill) Fill {
 	// startPosition>0 + "Open Long/Short" = 加仓
 	action, side := parseHLDirWithStartPos(raw.Dir, startPos)
 
+	closeTrigger := ""
+	if raw.Liquidation != nil {
+		closeTrigger = CloseTriggerLiquidation
+	}
+
 	return Fill{
 		ID:           raw.Hash,
 		Symbol:       raw.Coin + "USDT",
@@ -471,6 +539,8 @@ func (p *HLWebSocketProvider) convertWsFill(raw WsFill) Fill {
 		PositionSide: side,
 		Timestamp:    time.UnixMilli(raw.Time),
 		ClosedPnL:    closedPnl,
+		AmbiguousDir: !isKnownHLDir(raw.Dir),
+		CloseTrigger: closeTrigger,
 		Value:        price * size,
 	}
 }
@@ -480,15 +550,13 @@ type WsClearinghouseState struct {
 	AssetPositions []struct {
 		Type     string `json:"type"`
 		Position struct {
-			Coin          string `json:"coin"`
-			Szi           string `json:"szi"`
-			EntryPx       string `json:"entryPx"`
-			PositionValue string `json:"positionValue"`
-			UnrealizedPnl string `json:"unrealizedPnl"`
-			Leverage      struct {
-				Type  string  `json:"type"`
-				Value float64 `json:"value"`
-			} `json:"leverage"`
+			Coin          string     `json:"coin"`
+			Szi           string     `json:"szi"`
+			EntryPx       string     `json:"entryPx"`
+			PositionValue string     `json:"positionValue"`
+			UnrealizedPnl string     `json:"unrealizedPnl"`
+			MarginUsed    string     `json:"marginUsed"`
+			Leverage      HLLeverage `json:"leverage"`
 		} `json:"position"`
 	} `json:"assetPositions"`
 	MarginSummary struct {
@@ -513,6 +581,7 @@ func (p *HLWebSocketProvider) convertClearinghouseState(state WsClearinghouseSta
 		entryPx, _ := strconv.ParseFloat(pos.EntryPx, 64)
 		posValue, _ := strconv.ParseFloat(pos.PositionValue, 64)
 		upl, _ := strconv.ParseFloat(pos.UnrealizedPnl, 64)
+		marginUsed, _ := strconv.ParseFloat(pos.MarginUsed, 64)
 
 		side := SideLong
 		if szi < 0 {
@@ -526,7 +595,7 @@ func (p *HLWebSocketProvider) convertClearinghouseState(state WsClearinghouseSta
 			Side:          side,
 			Size:          szi,
 			EntryPrice:    entryPx,
-			Leverage:      int(pos.Leverage.Value),
+			Leverage:      normalizeHLLeverage(pos.Leverage, posValue, marginUsed),
 			MarginMode:    pos.Leverage.Type, // "cross" or "isolated"
 			UnrealizedPnL: upl,
 			PositionValue: posValue,
@@ -0,0 +1,458 @@
+package copytrade
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHLLeverageNormalization REST 与 WebSocket 对全仓/逐仓杠杆输出一致的整数
+func TestHLLeverageNormalization(t *testing.T) {
+	tests := []struct {
+		name         string
+		payload      string
+		wantLeverage int
+		wantMode     string
+	}{
+		{
+			name:         "cross integer",
+			payload:      `{"coin":"BTC","szi":"0.5","entryPx":"60000","positionValue":"30000","unrealizedPnl":"0","marginUsed":"1500","leverage":{"type":"cross","value":20}}`,
+			wantLeverage: 20,
+			wantMode:     "cross",
+		},
+		{
+			name:         "isolated with rawUsd",
+			payload:      `{"coin":"ETH","szi":"-2","entryPx":"3000","positionValue":"6000","unrealizedPnl":"0","marginUsed":"600","leverage":{"type":"isolated","value":10,"rawUsd":"6600.0"}}`,
+			wantLeverage: 10,
+			wantMode:     "isolated",
+		},
+		{
+			name:         "fractional leverage floors",
+			payload:      `{"coin":"SOL","szi":"10","entryPx":"150","positionValue":"1500","unrealizedPnl":"0","marginUsed":"600","leverage":{"type":"isolated","value":2.5}}`,
+			wantLeverage: 2,
+			wantMode:     "isolated",
+		},
+		{
+			name:         "missing value derives effective leverage",
+			payload:      `{"coin":"SOL","szi":"10","entryPx":"150","positionValue":"1500","unrealizedPnl":"0","marginUsed":"300","leverage":{"type":"isolated"}}`,
+			wantLeverage: 5,
+			wantMode:     "isolated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// REST
+			var rest HLClearinghouseState
+			restJSON := `{"marginSummary":{"accountValue":"10000"},"withdrawable":"5000","assetPositions":[{"type":"oneWay","position":` + tt.payload + `}],"time":0}`
+			if err := json.Unmarshal([]byte(restJSON), &rest); err != nil {
+				t.Fatalf("REST unmarshal: %v", err)
+			}
+			restPos := rest.AssetPositions[0].Position
+			restLev := normalizeHLLeverage(restPos.Leverage, parseFloat(restPos.PositionValue), parseFloat(restPos.MarginUsed))
+
+			// WebSocket
+			var ws WsClearinghouseState
+			wsJSON := `{"marginSummary":{"accountValue":10000},"withdrawable":5000,"assetPositions":[{"type":"oneWay","position":` + tt.payload + `}]}`
+			if err := json.Unmarshal([]byte(wsJSON), &ws); err != nil {
+				t.Fatalf("WS unmarshal: %v", err)
+			}
+			state := (&HLWebSocketProvider{}).convertClearinghouseState(ws)
+			if len(state.Positions) != 1 {
+				t.Fatalf("WS positions = %d, want 1", len(state.Positions))
+			}
+			var wsPos *Position
+			for _, p := range state.Positions {
+				wsPos = p
+			}
+
+			if restLev != tt.wantLeverage {
+				t.Errorf("REST leverage = %d, want %d", restLev, tt.wantLeverage)
+			}
+			if wsPos.Leverage != tt.wantLeverage {
+				t.Errorf("WS leverage = %d, want %d", wsPos.Leverage, tt.wantLeverage)
+			}
+			if wsPos.MarginMode != tt.wantMode || restPos.Leverage.Type != tt.wantMode {
+				t.Errorf("margin mode = %s/%s, want %s", restPos.Leverage.Type, wsPos.MarginMode, tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestHLAmbiguousDir(t *testing.T) {
+	p := &HLWebSocketProvider{}
+	if f := p.convertWsFill(WsFill{Coin: "BTC", Dir: "Close Long", Px: "1", Sz: "1"}); f.AmbiguousDir {
+		t.Error("Close Long should not be ambiguous")
+	}
+	if f := p.convertWsFill(WsFill{Coin: "BTC", Dir: "Auto-Deleveraging", Px: "1", Sz: "1", ClosedPnl: "2.5"}); !f.AmbiguousDir || f.ClosedPnL != 2.5 {
+		t.Errorf("unknown dir fill = %+v, want ambiguous with ClosedPnL", f)
+	}
+}
+
+// stubTransport 固定返回指定响应体
+type stubTransport struct{ body string }
+
+func (s stubTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(s.body)),
+	}, nil
+}
+
+// TestHLAccountStateZeroTime 交易所未返回时间（0）时状态时间使用本地时间而不是 1970 年
+func TestHLAccountStateZeroTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		time    string
+		wantNow bool
+	}{
+		{name: "zero", time: "0", wantNow: true},
+		{name: "missing", time: "", wantNow: true},
+		{name: "far future", time: "4102444800000", wantNow: true},
+		{name: "valid", time: "1700000000000", wantNow: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"marginSummary":{"accountValue":"10000"},"withdrawable":"5000","assetPositions":[]`
+			if tt.time != "" {
+				body += `,"time":` + tt.time
+			}
+			body += `}`
+			p := &HyperliquidProvider{client: &http.Client{Transport: stubTransport{body: body}}}
+
+			before := time.Now()
+			state, err := p.GetAccountState("0xleader")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantNow {
+				if state.Timestamp.Before(before) || state.Timestamp.After(time.Now()) {
+					t.Errorf("timestamp = %v, want local now", state.Timestamp)
+				}
+			} else if !state.Timestamp.Equal(time.UnixMilli(1700000000000)) {
+				t.Errorf("timestamp = %v, want provider time", state.Timestamp)
+			}
+		})
+	}
+}
+
+// TestProviderFactory 每种 Provider 的工厂输出与能力
+func TestProviderFactory(t *testing.T) {
+	tests := []struct {
+		providerType  ProviderType
+		wantCaps      ProviderCapabilities
+		wantStreaming bool
+	}{
+		{ProviderHyperliquid, ProviderCapabilities{Streaming: true}, true},
+		{ProviderOKX, ProviderCapabilities{CompleteFillValue: true, PrivateProfiles: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.providerType), func(t *testing.T) {
+			rest, caps, err := BuildProvider(tt.providerType, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if caps != tt.wantCaps {
+				t.Errorf("caps = %+v, want %+v", caps, tt.wantCaps)
+			}
+			if rest.Type() != tt.providerType {
+				t.Errorf("rest type = %s", rest.Type())
+			}
+			if _, ok := rest.(StreamingProvider); ok {
+				t.Errorf("REST provider %T implements StreamingProvider", rest)
+			}
+
+			preferred, _, err := BuildProvider(tt.providerType, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, isStreaming := preferred.(StreamingProvider)
+			if isStreaming != tt.wantStreaming {
+				t.Errorf("preferStreaming: got %T, streaming=%v want %v", preferred, isStreaming, tt.wantStreaming)
+			}
+
+			sp, err := NewStreamingProvider(tt.providerType)
+			if tt.wantStreaming {
+				if err != nil || sp == nil || !sp.IsStreaming() {
+					t.Errorf("NewStreamingProvider = %v, %v", sp, err)
+				}
+			} else if !errors.Is(err, ErrStreamingNotSupported) {
+				t.Errorf("NewStreamingProvider err = %v, want ErrStreamingNotSupported", err)
+			}
+
+			// 引擎按能力选择模式：不支持流式时回退到轮询
+			e, err := NewEngine("test", &CopyConfig{ProviderType: tt.providerType, LeaderID: "leader", CopyRatio: 1},
+				nil, nil, WithStreamingMode())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e.isStreamingMode != tt.wantStreaming || (e.streamingProvider != nil) != tt.wantStreaming {
+				t.Errorf("engine streaming = %v, want %v", e.isStreamingMode, tt.wantStreaming)
+			}
+		})
+	}
+
+	if _, _, err := BuildProvider("binance", false); !errors.Is(err, ErrUnsupportedProvider) {
+		t.Errorf("unknown provider err = %v, want ErrUnsupportedProvider", err)
+	}
+	if _, err := NewStreamingProvider("binance"); !errors.Is(err, ErrUnsupportedProvider) {
+		t.Errorf("unknown streaming provider err = %v, want ErrUnsupportedProvider", err)
+	}
+}
+
+// TestInvalidFillSymbolSkipped 币种为空或无法解析的成交在数据源层丢弃，不进入匹配
+func TestInvalidFillSymbolSkipped(t *testing.T) {
+	now := time.Now().UnixMilli()
+
+	t.Run("hyperliquid rest", func(t *testing.T) {
+		body := fmt.Sprintf(`[
+			{"coin":"","px":"1","sz":"1","side":"B","time":%d,"dir":"Open Long","tid":1},
+			{"coin":"@107","px":"1","sz":"1","side":"B","time":%d,"dir":"Open Long","tid":2},
+			{"coin":"BTC","px":"1","sz":"1","side":"B","time":%d,"dir":"Open Long","tid":3}
+		]`, now, now, now)
+		p := &HyperliquidProvider{client: &http.Client{Transport: stubTransport{body: body}}}
+		fills, err := p.GetFills("0xleader", time.Now().Add(-time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fills) != 1 || fills[0].Symbol != "BTCUSDT" {
+			t.Errorf("fills = %+v, want only BTCUSDT", fills)
+		}
+	})
+
+	t.Run("okx rest", func(t *testing.T) {
+		body := fmt.Sprintf(`{"code":"0","msg":"","data":[
+			{"instId":"","ordId":"1","avgPx":"1","sz":"1","side":"buy","posSide":"long","fillTime":"%d"},
+			{"instId":"garbage","ordId":"2","avgPx":"1","sz":"1","side":"buy","posSide":"long","fillTime":"%d"},
+			{"instId":"-USDT-SWAP","ordId":"3","avgPx":"1","sz":"1","side":"buy","posSide":"long","fillTime":"%d"},
+			{"instId":"ETH-USDT-SWAP","ordId":"4","avgPx":"1","sz":"1","side":"buy","posSide":"long","fillTime":"%d"}
+		]}`, now, now, now, now)
+		p := &OKXProvider{client: &http.Client{Transport: stubTransport{body: body}}}
+		fills, err := p.GetFills("leader", time.Now().Add(-time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fills) != 1 || fills[0].Symbol != "ETHUSDT" {
+			t.Errorf("fills = %+v, want only ETHUSDT", fills)
+		}
+	})
+
+	t.Run("hyperliquid websocket", func(t *testing.T) {
+		p := &HLWebSocketProvider{fillsTTL: time.Minute}
+		var got []Fill
+		p.SetOnFill(func(f Fill) { got = append(got, f) })
+		p.handleUserFills(json.RawMessage(fmt.Sprintf(`{"isSnapshot":false,"fills":[
+			{"coin":"","px":"1","sz":"1","side":"B","time":%d,"dir":"Open Long","hash":"a"},
+			{"coin":"SOL","px":"1","sz":"1","side":"B","time":%d,"dir":"Open Long","hash":"b"}
+		]}`, now, now)))
+		if len(got) != 1 || got[0].Symbol != "SOLUSDT" {
+			t.Errorf("callback fills = %+v, want only SOLUSDT", got)
+		}
+	})
+}
+
+// TestCloseTriggerTagging 触发单/强平产生的领航员成交标记平仓来源
+func TestCloseTriggerTagging(t *testing.T) {
+	now := time.Now().UnixMilli()
+
+	t.Run("okx ordType", func(t *testing.T) {
+		body := fmt.Sprintf(`{"code":"0","msg":"","data":[
+			{"instId":"BTC-USDT-SWAP","ordId":"1","ordType":"conditional","avgPx":"1","sz":"1","side":"sell","posSide":"long","pnl":"12.5","fillTime":"%d"},
+			{"instId":"BTC-USDT-SWAP","ordId":"2","ordType":"oco","avgPx":"1","sz":"1","side":"sell","posSide":"long","pnl":"-3","fillTime":"%d"},
+			{"instId":"BTC-USDT-SWAP","ordId":"3","ordType":"market","avgPx":"1","sz":"1","side":"sell","posSide":"long","pnl":"4","fillTime":"%d"}
+		]}`, now, now, now)
+		p := &OKXProvider{client: &http.Client{Transport: stubTransport{body: body}}}
+		fills, err := p.GetFills("leader", time.Now().Add(-time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{CloseTriggerTakeProfit, CloseTriggerStopLoss, ""}
+		if len(fills) != len(want) {
+			t.Fatalf("fills = %d, want %d", len(fills), len(want))
+		}
+		for i, f := range fills {
+			if f.CloseTrigger != want[i] {
+				t.Errorf("fill %d CloseTrigger = %q, want %q", i, f.CloseTrigger, want[i])
+			}
+		}
+	})
+
+	t.Run("hyperliquid liquidation", func(t *testing.T) {
+		body := fmt.Sprintf(`[
+			{"coin":"BTC","px":"1","sz":"1","side":"A","time":%d,"dir":"Close Long","tid":1,
+			 "liquidation":{"liquidatedUser":"0xleader","markPx":"1","method":"market"}},
+			{"coin":"ETH","px":"1","sz":"1","side":"A","time":%d,"dir":"Close Long","tid":2}
+		]`, now, now)
+		p := &HyperliquidProvider{client: &http.Client{Transport: stubTransport{body: body}}}
+		fills, err := p.GetFills("0xleader", time.Now().Add(-time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fills) != 2 || fills[0].CloseTrigger != CloseTriggerLiquidation || fills[1].CloseTrigger != "" {
+			t.Errorf("fills = %+v, want BTC liquidation, ETH untagged", fills)
+		}
+	})
+
+	t.Run("hyperliquid websocket liquidation", func(t *testing.T) {
+		p := &HLWebSocketProvider{fillsTTL: time.Minute}
+		var got []Fill
+		p.SetOnFill(func(f Fill) { got = append(got, f) })
+		p.handleUserFills(json.RawMessage(fmt.Sprintf(`{"isSnapshot":false,"fills":[
+			{"coin":"SOL","px":"1","sz":"1","side":"A","time":%d,"dir":"Close Long","hash":"a",
+			 "liquidation":{"liquidatedUser":"0xleader","markPx":"1","method":"market"}}
+		]}`, now)))
+		if len(got) != 1 || got[0].CloseTrigger != CloseTriggerLiquidation {
+			t.Errorf("callback fills = %+v, want liquidation", got)
+		}
+	})
+}
+
+// TestOKXNonUSDTInstrument 币本位合约未映射时跳过，配置映射后按映射币种跟单
+func TestOKXNonUSDTInstrument(t *testing.T) {
+	now := time.Now().UnixMilli()
+	body := fmt.Sprintf(`{"code":"0","msg":"","data":[
+		{"instId":"ETH-USD-SWAP","ordId":"1","avgPx":"3000","sz":"1","side":"buy","posSide":"long","fillTime":"%d"},
+		{"instId":"BTC-USDT-SWAP","ordId":"2","avgPx":"60000","sz":"1","side":"buy","posSide":"long","fillTime":"%d"}
+	]}`, now, now)
+	positions := []OKXPositionData{{PosData: []OKXPosition{
+		{InstId: "ETH-USD-SWAP", PosSide: "long", Pos: "1", MgnMode: "cross", PosId: "1"},
+		{InstId: "BTC-USDT-SWAP", PosSide: "long", Pos: "1", MgnMode: "cross", PosId: "2"},
+	}}}
+
+	p := &OKXProvider{client: &http.Client{Transport: stubTransport{body: body}}}
+
+	t.Run("skipped by default", func(t *testing.T) {
+		fills, err := p.GetFills("leader", time.Now().Add(-time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fills) != 1 || fills[0].Symbol != "BTCUSDT" {
+			t.Errorf("fills = %+v, want only BTCUSDT", fills)
+		}
+		pos := parseOKXPositions(positions, p.symbolOf)
+		if len(pos) != 1 || pos["2"] == nil {
+			t.Errorf("positions = %+v, want only BTC-USDT-SWAP", pos)
+		}
+	})
+
+	t.Run("remapped", func(t *testing.T) {
+		p.SetInstrumentMap(map[string]string{"eth-usd-swap": "ethusdt"})
+		fills, err := p.GetFills("leader", time.Now().Add(-time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fills) != 2 || fills[0].Symbol != "ETHUSDT" || fills[1].Symbol != "BTCUSDT" {
+			t.Errorf("fills = %+v, want ETHUSDT and BTCUSDT", fills)
+		}
+		pos := parseOKXPositions(positions, p.symbolOf)
+		if len(pos) != 2 || pos["1"] == nil || pos["1"].Symbol != "ETHUSDT" {
+			t.Errorf("positions = %+v, want ETH-USD-SWAP mapped to ETHUSDT", pos)
+		}
+	})
+}
+
+// TestHLReconnectBackoff 重连等待指数增长、上限约 60 秒，并带 ±20% 抖动
+func TestHLReconnectBackoff(t *testing.T) {
+	within := func(d, base time.Duration) bool {
+		return d >= time.Duration(float64(base)*(1-HLReconnectJitter)) && d <= time.Duration(float64(base)*(1+HLReconnectJitter))
+	}
+	for failures, base := range []time.Duration{3 * time.Second, 6 * time.Second, 12 * time.Second, 24 * time.Second, 48 * time.Second, 60 * time.Second} {
+		if d := hlReconnectBackoff(failures); !within(d, base) {
+			t.Errorf("backoff(%d) = %v, want %v ±20%%", failures, d, base)
+		}
+	}
+	if d := hlReconnectBackoff(100); !within(d, HLReconnectMaxDelay) {
+		t.Errorf("backoff(100) = %v, want capped at %v ±20%%", d, HLReconnectMaxDelay)
+	}
+}
+
+// hlFillsTransport 按请求类型和 startTime 返回成交的 Hyperliquid info 接口
+type hlFillsTransport struct {
+	requests []map[string]interface{}
+	byTime   func(start int64) []HLFillRaw
+	recent   []HLFillRaw
+}
+
+func (s *hlFillsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body map[string]interface{}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	s.requests = append(s.requests, body)
+	fills := s.recent
+	if body["type"] == "userFillsByTime" {
+		fills = s.byTime(int64(body["startTime"].(float64)))
+	}
+	data, _ := json.Marshal(fills)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(string(data))),
+	}, nil
+}
+
+// hlFillPage 从 start 毫秒起每毫秒一笔成交（tid = 时间，跨页重复的成交 tid 相同）
+func hlFillPage(start int64, n int) []HLFillRaw {
+	fills := make([]HLFillRaw, n)
+	for i := range fills {
+		ts := start + int64(i)
+		fills[i] = HLFillRaw{Coin: "BTC", Px: "1", Sz: "1", Side: "B", Time: ts, Dir: "Open Long", TID: ts}
+	}
+	return fills
+}
+
+// TestHLGetFillsPagination 成交爆发超过单页上限时按时间翻页，跨页重复的成交去重；超过页数上限时补拉最近成交
+func TestHLGetFillsPagination(t *testing.T) {
+	sinceMs := time.Now().Add(-time.Hour).UnixMilli()
+	since := time.UnixMilli(sinceMs)
+
+	t.Run("pages until short page", func(t *testing.T) {
+		tr := &hlFillsTransport{byTime: func(start int64) []HLFillRaw {
+			if start == sinceMs {
+				return hlFillPage(start, HLFillsPageSize)
+			}
+			return hlFillPage(start, 3) // 第一笔与上一页最后一笔重复
+		}}
+		p := &HyperliquidProvider{client: &http.Client{Transport: tr}}
+		fills, err := p.GetFills("0xleader", since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tr.requests) != 2 {
+			t.Fatalf("requests = %d, want 2", len(tr.requests))
+		}
+		if got := int64(tr.requests[1]["startTime"].(float64)); got != sinceMs+HLFillsPageSize-1 {
+			t.Errorf("second page startTime = %d, want last fill time %d", got, sinceMs+HLFillsPageSize-1)
+		}
+		if len(fills) != HLFillsPageSize+2 {
+			t.Errorf("fills = %d, want %d (boundary fill deduplicated)", len(fills), HLFillsPageSize+2)
+		}
+	})
+
+	t.Run("page cap", func(t *testing.T) {
+		latest := time.Now().UnixMilli()
+		tr := &hlFillsTransport{
+			byTime: func(start int64) []HLFillRaw { return hlFillPage(start, HLFillsPageSize) },
+			recent: []HLFillRaw{{Coin: "ETH", Px: "1", Sz: "1", Side: "B", Time: latest, Dir: "Open Long", TID: latest}},
+		}
+		p := &HyperliquidProvider{client: &http.Client{Transport: tr}}
+		fills, err := p.GetFills("0xleader", since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tr.requests) != HLMaxFillPages+1 || tr.requests[HLMaxFillPages]["type"] != "userFills" {
+			t.Fatalf("requests = %v, want %d pages then userFills", len(tr.requests), HLMaxFillPages)
+		}
+		if last := fills[len(fills)-1]; last.Symbol != "ETHUSDT" {
+			t.Errorf("most recent fill missing after page cap: last = %+v", last)
+		}
+	})
+}
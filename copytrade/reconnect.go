@@ -0,0 +1,203 @@
+package copytrade
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 重连后对账：补齐断线期间错过的开仓/加仓/减仓/平仓
+// ============================================================================
+//
+// WebSocket 重连后先推送成交快照（IsSnapshot，跳过），之后才是实时成交；
+// 断线期间发生的成交只体现在重连后的账户状态里，不会作为成交推送，因此会被漏跟。
+// 重连成功后、恢复读取消息前，Provider 先通过 REST 刷新领航员状态，引擎据此对比本地映射：
+//   - active 映射的仓位消失/变小 → 平仓/减仓
+//   - active 映射的仓位变大 → 加仓
+//   - 没有映射（或已 closed）的领航员仓位 → 开仓
+//
+// 对账生成的合成成交走正常的 processSignal 流程（过滤、维护模式、上限等照常生效）。
+// 对账完成后才开始处理实时成交；早于对账状态时间的实时成交已体现在对账中，直接跳过，
+// 保证同一笔变化不会被对账和实时成交重复跟随。
+
+// 重连对账策略
+const (
+	ReconnectReconcileOn  = "reconcile" // 重连后对账（默认）
+	ReconnectReconcileOff = "off"       // 不对账（断线期间的变化等下一笔成交再跟随）
+)
+
+// ReconnectNotifier 流式 Provider 可选能力：重连成功并刷新领航员状态后回调
+// stateAt 为刷新状态开始的时间，此前的成交都已体现在状态中
+type ReconnectNotifier interface {
+	SetOnReconnect(callback func(stateAt time.Time))
+}
+
+// reconnectState 最近一次重连对账的状态时间
+type reconnectState struct {
+	mu        sync.Mutex
+	reconcile time.Time
+}
+
+// onReconnect 重连回调：对账并记录状态时间（在 Provider 恢复读取消息前同步执行）
+func (e *Engine) onReconnect(stateAt time.Time) {
+	e.stats.Reconnects++
+
+	policy := e.cfg().ReconnectReconcile
+
+	if policy == ReconnectReconcileOff {
+		logger.Infof("🔌 [%s] 重连成功，未启用对账（断线期间的变化不补跟）", e.traderID)
+		return
+	}
+	if e.IsDegraded() {
+		logger.Infof("🔌 [%s] 重连成功，降级模式中跳过对账", e.traderID)
+		return
+	}
+
+	e.reconnect.mu.Lock()
+	e.reconnect.reconcile = stateAt
+	e.reconnect.mu.Unlock()
+
+	actions := e.reconcileAfterReconnect(stateAt)
+	logger.Infof("🔌 [%s] 重连对账完成 | 补跟动作=%d", e.traderID, actions)
+}
+
+// skipReconciledFill 早于最近一次对账状态时间的实时成交已由对账处理，返回 true 表示跳过
+func (e *Engine) skipReconciledFill(fill *Fill) bool {
+	e.reconnect.mu.Lock()
+	at := e.reconnect.reconcile
+	e.reconnect.mu.Unlock()
+
+	if at.IsZero() || fill.Timestamp.IsZero() || !fill.Timestamp.Before(at) {
+		return false
+	}
+	logger.Infof("📊 [%s] 跳过成交 %s %s：早于重连对账状态（%s），已由对账处理",
+		e.traderID, fill.Symbol, fill.ID, at.Format("15:04:05"))
+	return true
+}
+
+// reconcileAfterReconnect 对比本地映射与领航员当前持仓，为断线期间的变化生成合成成交
+// 返回生成的补跟动作数
+func (e *Engine) reconcileAfterReconnect(stateAt time.Time) int {
+	if e.store == nil {
+		return 0
+	}
+	if err := e.syncLeaderState(); err != nil {
+		logger.Warnf("⚠️ [%s] 重连对账失败（领航员状态同步失败）: %v", e.traderID, err)
+		return 0
+	}
+
+	mappings, err := e.listActiveMappings()
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 查询活跃映射失败: %v", e.traderID, err)
+		return 0
+	}
+
+	leaderPosMap := e.buildLeaderPosMap()
+	phantom := e.newPhantomCloseDetector(mappings, stateAt)
+	var fills []*Fill
+
+	// 已跟随仓位：平仓/减仓/加仓
+	mapped := make(map[string]bool, len(mappings))
+	for _, m := range mappings {
+		mapped[m.LeaderPosID] = true
+		side := e.leaderSide(SideType(m.Side))
+		pos := leaderPosMap[m.LeaderPosID]
+		if pos != nil && pos.Side != side {
+			pos = nil // 单向持仓直接反手，原仓位已平
+		}
+
+		switch {
+		case pos == nil || pos.Size <= 0:
+			if e.skipPhantomClose(phantom, m) {
+				continue
+			}
+			fills = append(fills, gapFill(m.LeaderPosID, m.Symbol, side, ActionClose, m.LastKnownSize, 0, stateAt))
+		case m.LastKnownSize > 0 && pos.Size < m.LastKnownSize:
+			fills = append(fills, gapFill(m.LeaderPosID, m.Symbol, side, ActionReduce, m.LastKnownSize-pos.Size, positionPrice(pos), stateAt))
+		case m.LastKnownSize > 0 && pos.Size > m.LastKnownSize:
+			fills = append(fills, gapFill(m.LeaderPosID, m.Symbol, side, ActionAdd, pos.Size-m.LastKnownSize, positionPrice(pos), stateAt))
+		}
+	}
+
+	// 未跟随的领航员仓位：无映射或已 closed 视为断线期间新开仓（ignored 保持不跟）
+	var unmapped []string
+	for posID := range leaderPosMap {
+		if !mapped[posID] {
+			unmapped = append(unmapped, posID)
+		}
+	}
+	sort.Strings(unmapped)
+	existing, err := e.store.CopyTrade().GetMappingsByPosIDs(e.traderID, unmapped)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 查询映射失败，跳过新开仓对账: %v", e.traderID, err)
+		unmapped = nil
+	}
+	for _, posID := range unmapped {
+		if m := existing[posID]; m != nil && m.Status != "closed" {
+			continue
+		}
+		pos := leaderPosMap[posID]
+		if pos.Size <= 0 {
+			continue
+		}
+		fills = append(fills, gapFill(posID, pos.Symbol, pos.Side, ActionOpen, pos.Size, positionPrice(pos), stateAt))
+	}
+
+	// 先平/减再开/加，释放保证金和预算
+	sort.SliceStable(fills, func(i, j int) bool {
+		return reconcileOrder(fills[i].Action) < reconcileOrder(fills[j].Action)
+	})
+	for _, fill := range fills {
+		logger.Infof("🔌 [%s] 重连对账 | %s %s %s 数量=%.4f → 补跟",
+			e.traderID, fill.Symbol, fill.Action, fill.PositionSide, fill.Size)
+		e.stats.ReconcileActions++
+		e.processSignal(e.buildSignal(fill))
+	}
+	return len(fills)
+}
+
+// gapFill 构造断线期间变化对应的合成成交
+func gapFill(posID, symbol string, side SideType, action ActionType, size, price float64, at time.Time) *Fill {
+	// 开多/加多、平空/减空为买入
+	tradeSide := "sell"
+	if (side == SideLong) == (action == ActionOpen || action == ActionAdd) {
+		tradeSide = "buy"
+	}
+	return &Fill{
+		ID:           fmt.Sprintf("reconnect_%s_%s_%d", posID, action, at.UnixMilli()),
+		Symbol:       symbol,
+		Side:         tradeSide,
+		PositionSide: side,
+		Action:       action,
+		Price:        price,
+		Size:         size,
+		Value:        size * price,
+		Timestamp:    at,
+	}
+}
+
+// positionPrice 领航员仓位参考价（标记价优先，缺失时用开仓均价）
+func positionPrice(pos *Position) float64 {
+	if pos.MarkPrice > 0 {
+		return pos.MarkPrice
+	}
+	return pos.EntryPrice
+}
+
+// reconcileOrder 对账动作执行顺序
+func reconcileOrder(action ActionType) int {
+	switch action {
+	case ActionClose:
+		return 0
+	case ActionReduce:
+		return 1
+	case ActionAdd:
+		return 2
+	default:
+		return 3
+	}
+}
@@ -0,0 +1,75 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+
+	"nofx/store"
+)
+
+// TestReconcileAfterReconnect 重连后按映射与领航员持仓的差异补跟断线期间的平仓/减仓/开仓
+func TestReconcileAfterReconnect(t *testing.T) {
+	st := newTestStore(t)
+	ct := st.CopyTrade()
+	for _, m := range []*store.CopyTradePositionMapping{
+		{TraderID: "test", LeaderPosID: PositionKey("BTCUSDT", SideLong), LeaderID: "leader", Symbol: "BTCUSDT",
+			Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 100, LastKnownSize: 2},
+		{TraderID: "test", LeaderPosID: PositionKey("SOLUSDT", SideLong), LeaderID: "leader", Symbol: "SOLUSDT",
+			Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 100, LastKnownSize: 10},
+	} {
+		if err := ct.SavePositionMapping(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 断线期间：BTC 减半、SOL 全平、新开 ETH 空单
+	provider := &fakeProvider{state: &AccountState{
+		TotalEquity: 10000,
+		Positions: map[string]*Position{
+			PositionKey("BTCUSDT", SideLong):  {Symbol: "BTCUSDT", Side: SideLong, Size: 1, MarginMode: "cross", Leverage: 5, MarkPrice: 100},
+			PositionKey("ETHUSDT", SideShort): {Symbol: "ETHUSDT", Side: SideShort, Size: 5, MarginMode: "cross", Leverage: 5, MarkPrice: 100},
+		},
+	}}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.store = st
+	e.provider = provider
+
+	stateAt := time.Now()
+	e.onReconnect(stateAt)
+
+	var got []string
+	for len(e.decisionCh) > 0 {
+		fullDec := <-e.decisionCh
+		for _, d := range fullDec.Decisions {
+			got = append(got, d.Action+" "+d.Symbol)
+		}
+	}
+	want := []string{"close_long SOLUSDT", "reduce_long BTCUSDT", "open_short ETHUSDT"}
+	if len(got) != len(want) {
+		t.Fatalf("decisions = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("decision[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if e.stats.ReconcileActions != 3 {
+		t.Errorf("ReconcileActions = %d, want 3", e.stats.ReconcileActions)
+	}
+
+	// 早于对账状态时间的实时成交已被对账处理
+	if !e.skipReconciledFill(&Fill{ID: "old", Symbol: "BTCUSDT", Timestamp: stateAt.Add(-time.Second)}) {
+		t.Error("fill before reconcile state should be skipped")
+	}
+	if e.skipReconciledFill(&Fill{ID: "new", Symbol: "BTCUSDT", Timestamp: stateAt.Add(time.Second)}) {
+		t.Error("fill after reconcile state should be processed")
+	}
+
+	// 关闭对账：不补跟
+	withConfig(e, func(c *CopyConfig) { c.ReconnectReconcile = ReconnectReconcileOff })
+	e.stats.ReconcileActions = 0
+	e.onReconnect(time.Now())
+	if len(e.decisionCh) != 0 || e.stats.ReconcileActions != 0 {
+		t.Errorf("reconcile off: %d decisions, %d actions; want none", len(e.decisionCh), e.stats.ReconcileActions)
+	}
+}
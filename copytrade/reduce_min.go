@@ -0,0 +1,111 @@
+package copytrade
+
+import (
+	"time"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 减仓数量下限
+// ============================================================================
+//
+// 减仓按比例（CloseRatio）下发，执行器按 跟随者持仓 × 比例 计算数量并按精度取整。
+// 跟随者仓位较小时，小比例减仓取整后可能低于交易所最小下单量，减仓失败导致敞口偏大。
+// 执行器实现 MinQuantityChecker 时，生成减仓决策前检查数量：
+//   - 减仓数量低于最小下单量："bump"(默认) 提高到最小下单量；"close" 直接全量平仓
+//   - 减仓后剩余低于最小下单量：剩余部分之后无法单独减仓，直接全量平仓
+//
+// 最小下单量按币种缓存；查询失败或未知（0）时不调整，由下单结果决定。
+
+// 减仓数量低于最小下单量时的处理
+const (
+	ReduceMinBump  = "bump"  // 提高到最小下单量（默认）
+	ReduceMinClose = "close" // 转为全量平仓
+)
+
+// minQtyTTL 最小下单量缓存时间
+const minQtyTTL = time.Hour
+
+// MinQuantityChecker 执行器可选能力：查询币种最小下单量（基础币数量，0=未知）
+type MinQuantityChecker interface {
+	MinOrderQuantity(symbol string) (float64, error)
+}
+
+// minQty 缓存的最小下单量
+type minQty struct {
+	qty       float64
+	checkedAt time.Time
+}
+
+// WithMinQuantity 注入最小下单量查询（通常来自执行器）
+func WithMinQuantity(query func(symbol string) (float64, error)) EngineOption {
+	return func(e *Engine) {
+		e.minOrderQty = query
+	}
+}
+
+// symbolMinQty 查询币种最小下单量（未注入或查询失败时返回 0）
+func (e *Engine) symbolMinQty(symbol string) float64 {
+	if e.minOrderQty == nil {
+		return 0
+	}
+
+	e.symbolMu.Lock()
+	if cached, ok := e.minQtyCache[symbol]; ok && time.Since(cached.checkedAt) < minQtyTTL {
+		e.symbolMu.Unlock()
+		return cached.qty
+	}
+	e.symbolMu.Unlock()
+
+	qty, err := e.minOrderQty(symbol)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 查询最小下单量失败: %s: %v（不调整减仓数量）", e.traderID, symbol, err)
+		return 0
+	}
+
+	e.symbolMu.Lock()
+	if e.minQtyCache == nil {
+		e.minQtyCache = make(map[string]minQty)
+	}
+	e.minQtyCache[symbol] = minQty{qty: qty, checkedAt: time.Now()}
+	e.symbolMu.Unlock()
+	return qty
+}
+
+// applyReduceMinimum 保证减仓数量不低于最小下单量，返回调整后的比例（1 = 全量平仓）
+func (e *Engine) applyReduceMinimum(signal *TradeSignal, match *SignalMatchResult, ratio float64) float64 {
+	if ratio <= 0 || ratio >= 1 || e.getFollowerPositions == nil {
+		return ratio
+	}
+	minSize := e.symbolMinQty(signal.Fill.Symbol)
+	if minSize <= 0 {
+		return ratio
+	}
+	followerSize := e.followerPositionSize(signal.Fill.Symbol, signal.Fill.PositionSide, match.MarginMode)
+	if followerSize <= 0 {
+		return ratio
+	}
+
+	qty := followerSize * ratio
+	switch {
+	case followerSize-minSize < minSize:
+		// 持仓不足两个最小下单量：任何部分减仓都会留下无法单独减仓的剩余
+		logger.Infof("📊 [%s] %s 持仓 %.6f 不足以部分减仓（最小下单量 %.6f）→ 全量平仓",
+			e.traderID, signal.Fill.Symbol, followerSize, minSize)
+		return 1
+	case qty < minSize && e.cfg().ReduceMinPolicy == ReduceMinClose:
+		logger.Infof("📊 [%s] %s 减仓数量 %.6f 低于最小下单量 %.6f → 全量平仓",
+			e.traderID, signal.Fill.Symbol, qty, minSize)
+		return 1
+	case qty < minSize:
+		logger.Infof("📊 [%s] %s 减仓数量 %.6f 低于最小下单量 %.6f → 提高到最小下单量",
+			e.traderID, signal.Fill.Symbol, qty, minSize)
+		return minSize / followerSize
+	case followerSize-qty < minSize:
+		logger.Infof("📊 [%s] %s 减仓后剩余 %.6f 低于最小下单量 %.6f → 全量平仓",
+			e.traderID, signal.Fill.Symbol, followerSize-qty, minSize)
+		return 1
+	}
+	return ratio
+}
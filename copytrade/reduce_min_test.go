@@ -0,0 +1,50 @@
+package copytrade
+
+import (
+	"math"
+	"testing"
+)
+
+// TestReduceBelowMinimumQuantity 小比例减仓低于交易所最小下单量时提高到最小下单量或转为全平
+func TestReduceBelowMinimumQuantity(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     string
+		leaderSize float64 // 领航员减仓后持仓（减仓前 2）
+		wantAction string
+		wantRatio  float64 // 0 = 全量平仓
+	}{
+		{name: "sub-minimum reduce bumped", policy: "", leaderSize: 1.9, wantAction: "reduce_long", wantRatio: 0.1},
+		{name: "sub-minimum reduce closes", policy: ReduceMinClose, leaderSize: 1.9, wantAction: "reduce_long", wantRatio: 0},
+		{name: "reduce above minimum unchanged", policy: ReduceMinClose, leaderSize: 1, wantAction: "reduce_long", wantRatio: 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1, ReduceMinPolicy: tt.policy}, 1000)
+			e.getFollowerPositions = func() map[string]*Position {
+				return map[string]*Position{"BTCUSDT_long": {Symbol: "BTCUSDT", Side: SideLong, Size: 0.01, MarginMode: "cross"}}
+			}
+			queries := 0
+			e.minOrderQty = func(symbol string) (float64, error) {
+				queries++
+				return 0.001, nil
+			}
+
+			leaderPos := &Position{Symbol: "BTCUSDT", Side: SideLong, Size: tt.leaderSize, MarginMode: "cross"}
+			signal := &TradeSignal{Fill: &Fill{Symbol: "BTCUSDT", PositionSide: SideLong, Action: ActionReduce, Price: 60000, Size: 2 - tt.leaderSize}}
+			match := &SignalMatchResult{ShouldFollow: true, Action: ActionReduce, PosID: "BTCUSDT_long", MarginMode: "cross", LeaderPosition: leaderPos}
+
+			// 领航员减仓 5%：跟随者 0.01 × 5% = 0.0005 < 最小下单量 0.001
+			dec := e.buildDecisionV2(signal, match, 0)
+			if dec.Action != tt.wantAction || math.Abs(dec.CloseRatio-tt.wantRatio) > 1e-9 {
+				t.Errorf("decision = %s ratio=%.4f, want %s ratio=%.4f", dec.Action, dec.CloseRatio, tt.wantAction, tt.wantRatio)
+			}
+
+			e.buildDecisionV2(signal, match, 0)
+			if queries != 1 {
+				t.Errorf("min quantity queried %d times, want 1 (cached)", queries)
+			}
+		})
+	}
+}
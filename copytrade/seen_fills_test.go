@@ -0,0 +1,28 @@
+package copytrade
+
+import "testing"
+
+// TestSeenFillsPersisted 去重记录写入数据库：新引擎（模拟重启）不会重复处理已处理过的成交
+func TestSeenFillsPersisted(t *testing.T) {
+	st := newTestStore(t)
+	e := newTestEngine(&CopyConfig{LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.store = st
+	e.markSeen("f1")
+
+	restarted := newTestEngine(&CopyConfig{LeaderID: "leader", CopyRatio: 1}, 1000)
+	restarted.store = st
+	if !restarted.isSeen("f1") {
+		t.Fatal("fill processed before restart not seen")
+	}
+	if restarted.isSeen("f2") {
+		t.Error("unknown fill seen")
+	}
+
+	// 其他 trader 的去重记录互不影响
+	other := newTestEngine(&CopyConfig{LeaderID: "leader", CopyRatio: 1}, 1000)
+	other.store = st
+	other.traderID = "other"
+	if other.isSeen("f1") {
+		t.Error("fill seen by another trader")
+	}
+}
@@ -0,0 +1,154 @@
+package copytrade
+
+import (
+	"fmt"
+	"time"
+
+	"nofx/decision"
+	"nofx/store"
+)
+
+// ============================================================================
+// 跟单链路自检（不下单）
+// ============================================================================
+//
+// 启用领航员前一键检查配置是否可用：数据源能否拿到领航员状态、执行器能否查询账户和持仓、
+// 数据库能否读写映射、决策通道能否投递。每项独立检查，一项失败不影响其他项。
+
+// 自检项
+const (
+	SelfTestConfig    = "config"
+	SelfTestProvider  = "provider"
+	SelfTestAccount   = "executor_account"
+	SelfTestPositions = "executor_positions"
+	SelfTestDatabase  = "database"
+	SelfTestChannel   = "decision_channel"
+)
+
+// selfTestTraderPrefix 自检写入的临时映射使用独立的 trader_id，不影响真实映射
+const selfTestTraderPrefix = "__selftest__"
+
+// SelfTestCheck 单项自检结果
+type SelfTestCheck struct {
+	Name       string `json:"name"`
+	Passed     bool   `json:"passed"`
+	Message    string `json:"message"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// SelfTestReport 自检报告
+type SelfTestReport struct {
+	TraderID  string          `json:"trader_id"`
+	Passed    bool            `json:"passed"` // 所有检查都通过
+	Checks    []SelfTestCheck `json:"checks"`
+	CheckedAt time.Time       `json:"checked_at"`
+}
+
+// RunSelfTest 检查跟单链路（cfg 为 nil 时跳过数据源检查，executor 为 nil 时执行器检查失败）
+func RunSelfTest(traderID string, cfg *store.CopyTradeConfig, executor DecisionExecutor, st *store.Store) *SelfTestReport {
+	report := &SelfTestReport{TraderID: traderID, Passed: true, CheckedAt: time.Now()}
+	run := func(name string, check func() (string, error)) {
+		start := time.Now()
+		msg, err := check()
+		result := SelfTestCheck{Name: name, Passed: err == nil, Message: msg, DurationMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			result.Message = err.Error()
+			report.Passed = false
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	run(SelfTestConfig, func() (string, error) {
+		if cfg == nil {
+			return "", fmt.Errorf("copy trade config not found")
+		}
+		return fmt.Sprintf("%s leader %s ratio %.0f%%", cfg.ProviderType, cfg.LeaderID, cfg.CopyRatio*100), nil
+	})
+
+	run(SelfTestProvider, func() (string, error) {
+		if cfg == nil {
+			return "", fmt.Errorf("skipped: no config")
+		}
+		provider, err := NewProvider(ProviderType(cfg.ProviderType))
+		if err != nil {
+			return "", err
+		}
+		state, err := provider.GetAccountState(cfg.LeaderID)
+		if err != nil {
+			return "", fmt.Errorf("get leader account state: %w", err)
+		}
+		return fmt.Sprintf("leader equity %.2f, %d position(s)", state.TotalEquity, len(state.Positions)), nil
+	})
+
+	run(SelfTestAccount, func() (string, error) {
+		if executor == nil {
+			return "", fmt.Errorf("trader not loaded")
+		}
+		info, err := executor.GetAccountInfo()
+		if err != nil {
+			return "", fmt.Errorf("get account info: %w", err)
+		}
+		return fmt.Sprintf("total equity %v", info["total_equity"]), nil
+	})
+
+	run(SelfTestPositions, func() (string, error) {
+		if executor == nil {
+			return "", fmt.Errorf("trader not loaded")
+		}
+		positions, err := executor.GetPositions()
+		if err != nil {
+			return "", fmt.Errorf("get positions: %w", err)
+		}
+		return fmt.Sprintf("%d position(s)", len(positions)), nil
+	})
+
+	run(SelfTestDatabase, func() (string, error) {
+		if st == nil {
+			return "", fmt.Errorf("store not configured")
+		}
+		return selfTestDatabase(st.CopyTrade(), traderID)
+	})
+
+	run(SelfTestChannel, func() (string, error) {
+		e := &Engine{traderID: traderID, decisionCh: make(chan *decision.FullDecision, 1), stats: &EngineStats{}}
+		if !e.pushDecision(&decision.FullDecision{RawResponse: "selftest", Timestamp: time.Now()}) {
+			return "", fmt.Errorf("push decision failed")
+		}
+		select {
+		case dec := <-e.GetDecisionChannel():
+			if dec.RawResponse != "selftest" {
+				return "", fmt.Errorf("unexpected decision received")
+			}
+		case <-time.After(time.Second):
+			return "", fmt.Errorf("decision not received")
+		}
+		return "no-op decision pushed and consumed", nil
+	})
+
+	return report
+}
+
+// selfTestDatabase 写入、读回并删除一条临时映射
+func selfTestDatabase(ct *store.CopyTradeStore, traderID string) (string, error) {
+	testTrader := selfTestTraderPrefix + traderID
+	posID := fmt.Sprintf("selftest_%d", time.Now().UnixNano())
+	defer ct.DeletePositionMapping(testTrader, posID)
+
+	if err := ct.SavePositionMapping(&store.CopyTradePositionMapping{
+		TraderID: testTrader, LeaderPosID: posID, LeaderID: "selftest", Symbol: "BTCUSDT",
+		Side: "long", MarginMode: "cross", OpenedAt: time.Now(), LastKnownSize: 1,
+	}); err != nil {
+		return "", fmt.Errorf("write mapping: %w", err)
+	}
+	m, err := ct.GetActiveMapping(testTrader, posID)
+	if err != nil {
+		return "", fmt.Errorf("read mapping: %w", err)
+	}
+	if m == nil || m.LastKnownSize != 1 {
+		return "", fmt.Errorf("read mapping: written mapping not found")
+	}
+	if err := ct.DeletePositionMapping(testTrader, posID); err != nil {
+		return "", fmt.Errorf("delete mapping: %w", err)
+	}
+	return "temp mapping written, read back and deleted", nil
+}
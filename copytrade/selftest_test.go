@@ -0,0 +1,49 @@
+package copytrade
+
+import (
+	"testing"
+)
+
+// TestRunSelfTest 各检查项独立给出结果，数据库临时映射用后删除
+func TestRunSelfTest(t *testing.T) {
+	st := newTestStore(t)
+
+	// 未配置跟单、执行器可用
+	report := RunSelfTest("t1", nil, &fakeExecutor{}, st)
+	want := map[string]bool{
+		SelfTestConfig:    false,
+		SelfTestProvider:  false,
+		SelfTestAccount:   true,
+		SelfTestPositions: true,
+		SelfTestDatabase:  true,
+		SelfTestChannel:   true,
+	}
+	if report.Passed {
+		t.Error("report passed without config")
+	}
+	if len(report.Checks) != len(want) {
+		t.Fatalf("checks = %d, want %d", len(report.Checks), len(want))
+	}
+	for _, c := range report.Checks {
+		if c.Passed != want[c.Name] {
+			t.Errorf("%s passed = %v, want %v (%s)", c.Name, c.Passed, want[c.Name], c.Message)
+		}
+	}
+
+	// 临时映射不残留
+	mappings, err := st.CopyTrade().ListActiveMappings(selfTestTraderPrefix + "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mappings) != 0 {
+		t.Errorf("self-test left %d mapping(s)", len(mappings))
+	}
+
+	// 执行器未加载
+	report = RunSelfTest("t1", nil, nil, st)
+	for _, c := range report.Checks {
+		if (c.Name == SelfTestAccount || c.Name == SelfTestPositions) && c.Passed {
+			t.Errorf("%s passed without executor", c.Name)
+		}
+	}
+}
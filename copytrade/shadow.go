@@ -0,0 +1,118 @@
+package copytrade
+
+import (
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/store"
+)
+
+// recordShadow 影子跟单：按实盘与影子两组参数记录假设决策（按领航员成交价，不下单）
+// 两组记录同口径（都用领航员成交价、按领航员减仓比例），对比结果只反映参数差异
+func (e *Engine) recordShadow(signal *TradeSignal, match *SignalMatchResult, dec *decision.Decision, liveSize float64) {
+	shadow := e.cfg().Shadow
+	if shadow == nil || e.store == nil {
+		return
+	}
+
+	var shadowSize float64
+	if match.Action == ActionOpen || match.Action == ActionAdd {
+		minTrade := shadow.MinTradeWarn
+		if minTrade <= 0 {
+			minTrade = e.cfg().MinTradeWarn
+		}
+		shadowSize, _ = e.calculateCopySizeWith(signal, match, shadow.CopyRatio, minTrade)
+	}
+
+	e.applyShadowDecision(store.ShadowVariantLive, store.ShadowVariantLive, signal, match, dec, liveSize)
+	e.applyShadowDecision(store.ShadowVariantShadow, shadow.Label, signal, match, dec, shadowSize)
+}
+
+// applyShadowDecision 更新单个变体的假设持仓并记录决策
+func (e *Engine) applyShadowDecision(variant, label string, signal *TradeSignal, match *SignalMatchResult, dec *decision.Decision, notional float64) {
+	fill := signal.Fill
+	if fill.Price <= 0 {
+		return
+	}
+	ct := e.store.CopyTrade()
+
+	pos, err := ct.GetShadowPosition(e.traderID, variant, match.PosID)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 影子持仓查询失败: %v (variant=%s posId=%s)", e.traderID, err, variant, match.PosID)
+		return
+	}
+
+	record := &store.ShadowDecision{
+		TraderID:    e.traderID,
+		LeaderID:    e.cfg().LeaderID,
+		Variant:     variant,
+		Label:       label,
+		LeaderPosID: match.PosID,
+		Symbol:      fill.Symbol,
+		Side:        string(e.followerSide(fill.PositionSide)),
+		Action:      string(match.Action),
+		Price:       fill.Price,
+	}
+
+	switch match.Action {
+	case ActionOpen, ActionAdd:
+		if notional <= 0 {
+			return
+		}
+		qty := notional / fill.Price
+		if pos == nil {
+			pos = &store.ShadowPosition{
+				TraderID:    e.traderID,
+				Variant:     variant,
+				LeaderPosID: match.PosID,
+				Symbol:      fill.Symbol,
+				Side:        string(e.followerSide(fill.PositionSide)),
+			}
+		}
+		total := pos.Quantity + qty
+		pos.EntryPrice = (pos.Quantity*pos.EntryPrice + qty*fill.Price) / total
+		pos.Quantity = total
+		record.Notional = notional
+		record.Quantity = qty
+
+	case ActionReduce, ActionClose:
+		if pos == nil || pos.Quantity <= 0 {
+			// 该变体没有对应假设持仓（如影子开仓金额为 0）
+			return
+		}
+		ratio := dec.CloseRatio
+		if match.Action == ActionClose || ratio <= 0 || ratio >= 1 {
+			ratio = 1
+			record.Action = string(ActionClose)
+		}
+		closedQty := pos.Quantity * ratio
+		pnl := (fill.Price - pos.EntryPrice) * closedQty
+		if fill.PositionSide == SideShort {
+			pnl = -pnl
+		}
+		pos.Quantity -= closedQty
+		if ratio == 1 {
+			pos.Quantity = 0
+		}
+		record.Notional = closedQty * fill.Price
+		record.Quantity = closedQty
+		record.RealizedPnL = pnl
+
+	default:
+		return
+	}
+
+	if err := ct.RecordShadowDecision(record, pos); err != nil {
+		logger.Warnf("⚠️ [%s] 影子决策记录失败: %v (variant=%s)", e.traderID, err, variant)
+		return
+	}
+	logger.Infof("👥 [%s] 影子跟单 | %s %s %s | 金额=%.2f 盈亏=%.2f",
+		e.traderID, variant, record.Action, fill.Symbol, record.Notional, record.RealizedPnL)
+}
+
+// toShadowConfig 转换存储的影子跟单参数（未配置返回 nil）
+func toShadowConfig(opts *store.CopyTradeShadowOptions) *ShadowConfig {
+	if opts == nil || opts.CopyRatio <= 0 {
+		return nil
+	}
+	return &ShadowConfig{Label: opts.Label, CopyRatio: opts.CopyRatio, MinTradeWarn: opts.MinTradeWarn}
+}
@@ -0,0 +1,63 @@
+package copytrade
+
+import (
+	"math"
+	"testing"
+
+	"nofx/store"
+)
+
+// TestShadowFollow 影子配置按不同跟单系数记录假设决策，对比盈亏，且不产生额外实盘决策
+func TestShadowFollow(t *testing.T) {
+	st := newTestStore(t)
+	provider := &fakeProvider{}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1,
+		Shadow: &ShadowConfig{Label: "half", CopyRatio: 0.5}}, 1000)
+	e.store = st
+	e.provider = provider
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e}
+
+	run := func(fill *Fill) {
+		t.Helper()
+		e.processSignal(&TradeSignal{Fill: fill})
+		if n := len(e.decisionCh); n != 1 {
+			t.Fatalf("live decisions = %d, want 1", n)
+		}
+		fullDec := <-e.decisionCh
+		dec := fullDec.Decisions[0]
+		ti.updatePositionMapping(&dec)
+	}
+
+	// 领航员开多 10 BTC @100（占权益 10%）：实盘 100 USDT，影子 50 USDT
+	provider.setSize(10)
+	run(&Fill{ID: "open", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 10, Value: 1000})
+
+	// 减仓一半 @110
+	provider.setSize(5)
+	run(&Fill{ID: "reduce", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionReduce, Price: 110, Size: 5, Value: 550})
+
+	// 全部平仓 @120
+	provider.state.Positions = map[string]*Position{}
+	run(&Fill{ID: "close", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionClose, Price: 120, Size: 5, Value: 600})
+
+	summaries, err := st.CopyTrade().GetShadowComparison("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 2 || summaries[0].Variant != store.ShadowVariantLive || summaries[1].Variant != store.ShadowVariantShadow {
+		t.Fatalf("summaries = %+v", summaries)
+	}
+	live, shadow := summaries[0], summaries[1]
+
+	// 实盘：1 BTC，0.5 × (110-100) + 0.5 × (120-100) = 15
+	if live.Decisions != 3 || live.Opens != 1 || live.Closes != 1 || math.Abs(live.TotalNotional-100) > 1e-9 || math.Abs(live.RealizedPnL-15) > 1e-9 {
+		t.Errorf("live summary = %+v", live)
+	}
+	// 影子：0.5 BTC，盈亏减半
+	if shadow.Label != "half" || math.Abs(shadow.TotalNotional-50) > 1e-9 || math.Abs(shadow.RealizedPnL-7.5) > 1e-9 {
+		t.Errorf("shadow summary = %+v", shadow)
+	}
+	if live.OpenPositions != 0 || shadow.OpenPositions != 0 {
+		t.Errorf("positions not closed: live=%d shadow=%d", live.OpenPositions, shadow.OpenPositions)
+	}
+}
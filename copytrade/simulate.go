@@ -0,0 +1,162 @@
+package copytrade
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"nofx/decision"
+	"nofx/logger"
+)
+
+// ============================================================================
+// 模拟成交（调试 / QA）
+// ============================================================================
+//
+// 把一笔假设的领航员成交交给运行中引擎的 matchSignalWithMapping 和 calculateCopySize，
+// 返回匹配结果和将会生成的决策，不执行、不推送、不写数据库。
+// 使用引擎当前配置、真实映射库和跟随者账户；领航员状态默认实时拉取，也可以传入覆盖值复现问题。
+// 只覆盖匹配与仓位计算：维护模式、交易时段、预算等过滤条件不参与。
+
+// SimulatePosition 模拟用的领航员持仓
+type SimulatePosition struct {
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"` // long | short
+	Size       float64 `json:"size"`
+	EntryPrice float64 `json:"entry_price"`
+	MarkPrice  float64 `json:"mark_price"`
+	Leverage   int     `json:"leverage"`
+	MarginMode string  `json:"margin_mode"`
+	PosID      string  `json:"pos_id"`
+}
+
+// SimulateLeaderState 模拟用的领航员账户状态
+type SimulateLeaderState struct {
+	TotalEquity float64            `json:"total_equity"`
+	Positions   []SimulatePosition `json:"positions"`
+}
+
+// SimulateRequest 模拟成交请求
+type SimulateRequest struct {
+	Symbol      string               `json:"symbol" binding:"required"`
+	Side        string               `json:"side" binding:"required,oneof=long short"` // 仓位方向
+	Action      string               `json:"action" binding:"required,oneof=open add reduce close"`
+	Size        float64              `json:"size" binding:"gt=0"`
+	Price       float64              `json:"price" binding:"gt=0"`
+	ClosedPnL   float64              `json:"closed_pnl"`
+	LeaderState *SimulateLeaderState `json:"leader_state,omitempty"` // 领航员状态覆盖（nil=实时拉取）
+}
+
+// SimulateResult 模拟结果
+type SimulateResult struct {
+	Match        *SignalMatchResult `json:"match"`
+	CopySize     float64            `json:"copy_size"`          // 开仓/加仓跟单金额（USDT）
+	Decision     *decision.Decision `json:"decision,omitempty"` // 将会生成的决策（不跟随时为空）
+	Warnings     []Warning          `json:"warnings"`
+	LeaderEquity float64            `json:"leader_equity"`
+	StateSource  string             `json:"state_source"` // "override" | "live"
+}
+
+// toAccountState 转换领航员状态覆盖
+func (s *SimulateLeaderState) toAccountState() *AccountState {
+	state := &AccountState{TotalEquity: s.TotalEquity, Positions: make(map[string]*Position, len(s.Positions))}
+	for _, p := range s.Positions {
+		side := SideType(strings.ToLower(p.Side))
+		pos := &Position{
+			Symbol: strings.ToUpper(p.Symbol), Side: side, Size: p.Size, EntryPrice: p.EntryPrice,
+			MarkPrice: p.MarkPrice, Leverage: p.Leverage, MarginMode: p.MarginMode, PosID: p.PosID,
+		}
+		state.Positions[PositionKeyWithMode(pos.Symbol, side, p.MarginMode)] = pos
+	}
+	return state
+}
+
+// Simulate 模拟一笔领航员成交的匹配与仓位计算（不执行、不写数据库）
+func (e *Engine) Simulate(req *SimulateRequest) (*SimulateResult, error) {
+	result := &SimulateResult{StateSource: "override"}
+	var state *AccountState
+	if req.LeaderState != nil {
+		state = req.LeaderState.toAccountState()
+	} else {
+		var err error
+		if state, err = e.provider.GetAccountState(e.cfg().LeaderID); err != nil {
+			return nil, fmt.Errorf("get leader account state: %w", err)
+		}
+		result.StateSource = "live"
+	}
+
+	sim := e.simulationEngine(state)
+	side := SideType(strings.ToLower(req.Side))
+	action := ActionType(strings.ToLower(req.Action))
+	fill := gapFill("simulate", strings.ToUpper(req.Symbol), side, action, req.Size, req.Price, time.Now())
+	fill.ID = fmt.Sprintf("simulate_%d", time.Now().UnixNano())
+	fill.ClosedPnL = req.ClosedPnL
+
+	logger.Infof("🧪 [%s] 模拟成交 | %s %s %s 数量=%.4f 价格=%.4f 状态=%s",
+		e.traderID, fill.Symbol, fill.Action, fill.PositionSide, fill.Size, fill.Price, result.StateSource)
+
+	sim.resolveNetModeFill(fill)
+	sim.applyClosedPnLHint(fill)
+	signal := sim.buildSignal(fill)
+	result.LeaderEquity = signal.LeaderEquity
+
+	match := sim.matchSignalWithMapping(signal)
+	result.Match = match
+	if match.ShouldFollow {
+		signal.LeaderPosID = match.PosID
+		signal.LeaderPosition = match.LeaderPosition
+		if match.Action == ActionOpen || match.Action == ActionAdd {
+			result.CopySize, result.Warnings = sim.calculateCopySizeByPositionChange(signal, match)
+			result.CopySize = sim.capSymbolBaseSize(signal, match, result.CopySize)
+			result.CopySize = sim.capByFreeMargin(signal, result.CopySize)
+		}
+		if result.CopySize > 0 || match.Action == ActionReduce || match.Action == ActionClose {
+			dec := sim.buildDecisionV2(signal, match, result.CopySize)
+			result.Decision = &dec
+		}
+	}
+	result.Warnings = append(result.Warnings, sim.warnings...) // 仓位上限等直接记录的预警
+	return result, nil
+}
+
+// simulationEngine 复制引擎配置与依赖，使用给定的领航员状态（预警只保存在内存，不写数据库）
+func (e *Engine) simulationEngine(state *AccountState) *Engine {
+	cfg := *e.cfg()
+	cfg.WarningHistoryLimit = -1
+
+	sim := &Engine{
+		traderID:              e.traderID,
+		provider:              e.provider,
+		getFollowerBalance:    e.getFollowerBalance,
+		getFollowerPositions:  e.getFollowerPositions,
+		getFollowerFreeMargin: e.getFollowerFreeMargin,
+		store:                 e.store,
+		leaderState:           state,
+		lastStateSync:         time.Now(),
+		stateSyncInterval:     e.stateSyncInterval,
+		decisionCh:            make(chan *decision.FullDecision, 1),
+		stats:                 &EngineStats{StartTime: time.Now()},
+		supportsSymbol:        e.supportsSymbol,
+		minOrderQty:           e.minOrderQty,
+		simulation:            true,
+	}
+	sim.config.Store(&cfg)
+
+	e.volatility.mu.Lock()
+	sim.volatility.samples = maps.Clone(e.volatility.samples)
+	e.volatility.mu.Unlock()
+	e.leaderPnL.mu.Lock()
+	sim.leaderPnL.records = maps.Clone(e.leaderPnL.records)
+	e.leaderPnL.mu.Unlock()
+	return sim
+}
+
+// SimulateCopyTradingFill 在指定 trader 运行中的引擎上模拟成交
+func SimulateCopyTradingFill(traderID string, req *SimulateRequest) (*SimulateResult, error) {
+	integration, exists := getIntegration(traderID)
+	if !exists || !integration.IsRunning() || integration.engine == nil {
+		return nil, fmt.Errorf("copy trading not running for trader %s", traderID)
+	}
+	return integration.engine.Simulate(req)
+}
@@ -0,0 +1,74 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+
+	"nofx/store"
+)
+
+// TestSimulateFill 模拟成交：按真实映射匹配并计算仓位，不推送决策、不写数据库、不改动引擎缓存状态
+func TestSimulateFill(t *testing.T) {
+	st := newTestStore(t)
+	btc := PositionKey("BTCUSDT", SideLong)
+	if err := st.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+		TraderID: "test", LeaderPosID: btc, LeaderID: "leader", Symbol: "BTCUSDT",
+		Side: "long", MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 100, LastKnownSize: 1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	live := &AccountState{TotalEquity: 10000, Positions: map[string]*Position{
+		btc: {Symbol: "BTCUSDT", Side: SideLong, Size: 1, MarginMode: "cross", Leverage: 5},
+	}}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.store = st
+	e.provider = &fakeProvider{state: live}
+	e.leaderState = live
+
+	// 覆盖状态：领航员新开 ETH 空单 2 × 100 = 200（占权益 2%）→ 跟随者 1000 × 2% = 20
+	res, err := e.Simulate(&SimulateRequest{Symbol: "ethusdt", Side: "short", Action: "open", Size: 2, Price: 100,
+		LeaderState: &SimulateLeaderState{TotalEquity: 10000, Positions: []SimulatePosition{
+			{Symbol: "BTCUSDT", Side: "long", Size: 1, MarginMode: "cross", Leverage: 5},
+			{Symbol: "ETHUSDT", Side: "short", Size: 2, MarginMode: "cross", Leverage: 3},
+		}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Match.ShouldFollow || res.Match.Action != ActionOpen || res.StateSource != "override" {
+		t.Fatalf("match = %+v source=%s, want open from override", res.Match, res.StateSource)
+	}
+	if res.CopySize != 20 || res.Decision == nil || res.Decision.Action != "open_short" || res.Decision.PositionSizeUSD != 20 {
+		t.Errorf("copy size = %.2f decision = %+v, want open_short 20", res.CopySize, res.Decision)
+	}
+
+	// 实时状态：BTC 平仓成交按映射匹配为平仓
+	res, err = e.Simulate(&SimulateRequest{Symbol: "BTCUSDT", Side: "long", Action: "close", Size: 1, Price: 100,
+		LeaderState: &SimulateLeaderState{TotalEquity: 10000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Match.ShouldFollow || res.Match.PosID != btc || res.Decision == nil || res.Decision.Action != "close_long" {
+		t.Errorf("close match = %+v decision = %+v, want close_long %s", res.Match, res.Decision, btc)
+	}
+
+	res, err = e.Simulate(&SimulateRequest{Symbol: "BTCUSDT", Side: "long", Action: "add", Size: 1, Price: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StateSource != "live" {
+		t.Errorf("state source = %s, want live", res.StateSource)
+	}
+
+	// 无副作用
+	if len(e.decisionCh) != 0 {
+		t.Errorf("decisions pushed = %d, want 0", len(e.decisionCh))
+	}
+	if e.leaderState != live || len(live.Positions) != 1 {
+		t.Error("engine leader state changed by simulation")
+	}
+	mappings, _ := st.CopyTrade().ListActiveMappings("test")
+	if len(mappings) != 1 || mappings[0].LastKnownSize != 1 {
+		t.Errorf("mappings changed by simulation: %+v", mappings)
+	}
+}
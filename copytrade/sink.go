@@ -0,0 +1,55 @@
+package copytrade
+
+import (
+	"context"
+
+	"nofx/decision"
+)
+
+// ============================================================================
+// 决策输出（Sink）
+// ============================================================================
+
+// DecisionSink 决策输出目标
+// 默认由进程内执行器直接下单；也可以发布到外部队列（Redis/NATS 等），
+// 由独立的执行服务消费，此时跟单模块只作为信号生成器使用
+type DecisionSink interface {
+	Publish(ctx context.Context, fullDec *decision.FullDecision) error
+}
+
+// DecisionSinkFunc 函数适配器
+type DecisionSinkFunc func(ctx context.Context, fullDec *decision.FullDecision) error
+
+// Publish 实现 DecisionSink
+func (f DecisionSinkFunc) Publish(ctx context.Context, fullDec *decision.FullDecision) error {
+	return f(ctx, fullDec)
+}
+
+// executorSink 默认 Sink：调用进程内执行器下单，并维护仓位映射/决策记录
+type executorSink struct {
+	ti *TraderIntegration
+}
+
+func (s *executorSink) Publish(ctx context.Context, fullDec *decision.FullDecision) error {
+	s.ti.executeFullDecision(fullDec)
+	return nil
+}
+
+// IntegrationOption 集成配置选项
+type IntegrationOption func(*TraderIntegration)
+
+// WithDecisionSink 追加决策输出目标（与直接执行同时生效）
+func WithDecisionSink(sink DecisionSink) IntegrationOption {
+	return func(ti *TraderIntegration) {
+		ti.sinks = append(ti.sinks, sink)
+	}
+}
+
+// WithSignalOnly 纯信号模式：不调用进程内执行器，决策只发布到指定的 Sink
+// ⚠️ 仓位映射在执行成功后才会更新，外部执行方需自行维护
+// copy_trade_position_mappings，否则后续加仓/减仓/平仓无法匹配
+func WithSignalOnly(sinks ...DecisionSink) IntegrationOption {
+	return func(ti *TraderIntegration) {
+		ti.sinks = append([]DecisionSink(nil), sinks...)
+	}
+}
@@ -0,0 +1,38 @@
+package copytrade
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"nofx/decision"
+)
+
+// TestDecisionSinks 默认直接执行；纯信号模式只发布到外部 Sink，单个 Sink 失败不影响其他 Sink
+func TestDecisionSinks(t *testing.T) {
+	ti := NewTraderIntegration("test", nil, nil)
+	if len(ti.sinks) != 1 {
+		t.Fatalf("default sinks = %d, want 1", len(ti.sinks))
+	}
+	if _, ok := ti.sinks[0].(*executorSink); !ok {
+		t.Fatalf("default sink = %T, want *executorSink", ti.sinks[0])
+	}
+
+	var published []*decision.FullDecision
+	failing := DecisionSinkFunc(func(ctx context.Context, fullDec *decision.FullDecision) error {
+		return errors.New("queue unavailable")
+	})
+	recording := DecisionSinkFunc(func(ctx context.Context, fullDec *decision.FullDecision) error {
+		published = append(published, fullDec)
+		return nil
+	})
+
+	// 纯信号模式下没有执行器，若调用 executorSink 会因 nil executor panic
+	ti = NewTraderIntegration("test", nil, nil, WithSignalOnly(failing, recording))
+	fullDec := &decision.FullDecision{Decisions: []decision.Decision{{Symbol: "BTCUSDT", Action: "open_long"}}}
+	ti.publishDecision(fullDec)
+
+	if len(published) != 1 || published[0] != fullDec {
+		t.Errorf("published = %v, want the decision once", published)
+	}
+}
@@ -0,0 +1,73 @@
+package copytrade
+
+import (
+	"time"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 跳过信号
+// ============================================================================
+//
+// 匹配成功但因过滤条件不跟随的信号统一经 skipSignal 处理：记录日志、写入信号日志并计数；
+// 跳过的是新开仓时同时把该仓位标记为 ignored，避免之后的加仓被当作新开仓跟随。
+// 只作用于开仓/加仓的过滤条件集中在 entrySkipReason，新增过滤条件只需增加一个 case。
+// 模拟回放不写数据库（不标记 ignored、不写信号日志）。
+
+// 开仓/加仓的跳过原因（写入信号日志 follow_reason）
+const (
+	SkipReasonTrialExhausted  = "trial limit reached"
+	SkipReasonDecisionStalled = "decision consumer stalled"
+	SkipReasonMaintenance     = "maintenance mode"
+	SkipReasonSymbolDisabled  = "symbol disabled"
+	SkipReasonSymbolNotListed = "symbol not listed on follower exchange"
+	SkipReasonOutsideWindow   = "outside trading window"
+)
+
+// entrySkipReason 开仓/加仓的过滤条件，按顺序返回第一个命中的原因（空串=跟随；调用方持有 cfgMu 读锁）
+// 减仓/平仓不经过这些条件，已有仓位始终可以退出
+func (e *Engine) entrySkipReason(fill *Fill) string {
+	switch {
+	case e.stats.CloseOnly: // 试用额度用完，只平仓
+		return SkipReasonTrialExhausted
+	case e.decisionStallPaused(): // 决策消费者失效且 decision_stall_policy=pause
+		return SkipReasonDecisionStalled
+	case InMaintenance(): // 全局维护模式
+		return SkipReasonMaintenance
+	case !e.isSymbolEnabled(fill.Symbol): // 运行时暂停跟随的币种
+		return SkipReasonSymbolDisabled
+	case e.isSymbolFiltered(fill.Symbol): // 币种白名单/黑名单
+		return SkipReasonSymbolFiltered
+	case !e.isSymbolListed(fill.Symbol): // 跟随者交易所未上架
+		return SkipReasonSymbolNotListed
+	case e.heldForOtherLeader(fill): // 多领航员跟单：该币种方向已在跟随另一领航员
+		return SkipReasonHeldForOtherLeader
+	case !e.inTradingWindow(time.Now()): // 交易时段外
+		return SkipReasonOutsideWindow
+	}
+	return ""
+}
+
+// skipSignal 不跟随已匹配的信号：新开仓标记为 ignored，写入信号日志并计数（调用方持有 cfgMu 读锁）
+func (e *Engine) skipSignal(fill *Fill, match *SignalMatchResult, reason string) {
+	logger.Infof("🎯 [%s] ❌ 跳过 | %s | 原因: %s", e.traderID, fill.Symbol, reason)
+	if match.Action == ActionOpen {
+		e.ignorePosition(fill, match.PosID, match.MarginMode, reason)
+	}
+	if !e.simulation {
+		e.saveSkippedSignalLog(fill, reason)
+	}
+	e.stats.SignalsSkipped++
+}
+
+// ignorePosition 把未跟随的领航员新开仓标记为 ignored（模拟回放不标记）
+func (e *Engine) ignorePosition(fill *Fill, posID, marginMode, reason string) {
+	if e.simulation {
+		return
+	}
+	if err := e.store.CopyTrade().SaveIgnoredPosition(e.traderID, e.cfg().LeaderID, posID,
+		fill.Symbol, string(fill.PositionSide), marginMode); err != nil {
+		logger.Warnf("⚠️ [%s] 标记未跟随仓位失败: %v (posId=%s, 原因: %s)", e.traderID, err, posID, reason)
+	}
+}
@@ -0,0 +1,135 @@
+package copytrade
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 滑点保护
+// ============================================================================
+//
+// 决策以领航员成交价（fill.Price）为入场价，但跟随者执行时行情可能已经走出几个百分点。
+// CopyConfig.MaxSlippagePct > 0 时，开仓/加仓前取当前标记价，与领航员成交价的偏离超过阈值则跳过：
+//   - 标记价优先来自数据源的 GetMarkPrice（MarkPriceProvider），不支持或查询失败时用
+//     领航员持仓的标记价（GetAccountState），都取不到时不拦截
+//   - 偏离按绝对值计算，向有利方向偏离同样跳过（成交价已不具参考意义）
+//   - 跳过的新开仓标记为 ignored 并写入信号日志，同时记录 slippage 预警
+//   - 减仓/平仓不受滑点保护，始终跟随
+//   - 模拟回放使用历史成交，不检查滑点
+
+// WarningTypeSlippage 价格偏离超过滑点保护阈值，开仓/加仓被跳过
+const WarningTypeSlippage = "slippage"
+
+// OKXMarkPriceAPI OKX 公共标记价格接口
+const OKXMarkPriceAPI = "https://www.okx.com/api/v5/public/mark-price"
+
+// MarkPriceProvider Provider 可选能力：查询币种当前标记价（symbol 为 BTCUSDT 格式）
+type MarkPriceProvider interface {
+	GetMarkPrice(symbol string) (float64, error)
+}
+
+// GetMarkPrice 查询币种中间价（allMids）
+func (p *HyperliquidProvider) GetMarkPrice(symbol string) (float64, error) {
+	var mids map[string]string
+	if err := p.post(map[string]string{"type": "allMids"}, &mids); err != nil {
+		return 0, fmt.Errorf("get all mids failed: %w", err)
+	}
+	for coin, px := range mids {
+		if normalizeSymbol(coin) == symbol {
+			if price := parseFloat(px); price > 0 {
+				return price, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("no mid price for %s", symbol)
+}
+
+// GetMarkPrice 查询币种中间价（REST）
+func (p *HLWebSocketProvider) GetMarkPrice(symbol string) (float64, error) {
+	if p.restProvider == nil {
+		return 0, fmt.Errorf("mark price unavailable: no REST provider")
+	}
+	return p.restProvider.GetMarkPrice(symbol)
+}
+
+// OKXMarkPriceResp mark-price 返回结构
+type OKXMarkPriceResp struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+	Data []struct {
+		InstId string `json:"instId"`
+		MarkPx string `json:"markPx"`
+	} `json:"data"`
+}
+
+// GetMarkPrice 查询 USDT 永续合约标记价（BTCUSDT -> BTC-USDT-SWAP）
+func (p *OKXProvider) GetMarkPrice(symbol string) (float64, error) {
+	base, ok := strings.CutSuffix(strings.ToUpper(symbol), "USDT")
+	if !ok || base == "" {
+		return 0, fmt.Errorf("unsupported symbol %s", symbol)
+	}
+	instID := base + "-USDT-SWAP"
+	var resp OKXMarkPriceResp
+	if err := p.get(fmt.Sprintf("%s?instType=SWAP&instId=%s", OKXMarkPriceAPI, url.QueryEscape(instID)), &resp); err != nil {
+		return 0, err
+	}
+	if resp.Code != "" && resp.Code != "0" {
+		return 0, fmt.Errorf("OKX API error: code=%s msg=%s", resp.Code, resp.Msg)
+	}
+	if len(resp.Data) == 0 || parseFloat(resp.Data[0].MarkPx) <= 0 {
+		return 0, fmt.Errorf("no mark price for %s", instID)
+	}
+	return parseFloat(resp.Data[0].MarkPx), nil
+}
+
+// currentMarkPrice 当前标记价（取不到时为 0）
+func (e *Engine) currentMarkPrice(fill *Fill, match *SignalMatchResult) float64 {
+	if mp, ok := e.provider.(MarkPriceProvider); ok {
+		price, err := mp.GetMarkPrice(fill.Symbol)
+		if err == nil && price > 0 {
+			return price
+		}
+		logger.Warnf("⚠️ [%s] 查询 %s 标记价失败: %v（使用领航员持仓标记价）", e.traderID, fill.Symbol, err)
+	}
+	if match.LeaderPosition != nil {
+		return match.LeaderPosition.MarkPrice
+	}
+	return 0
+}
+
+// slippageExceeded 开仓/加仓时检查当前标记价相对领航员成交价的偏离，超过阈值时返回跳过原因
+func (e *Engine) slippageExceeded(fill *Fill, match *SignalMatchResult) (bool, string) {
+	if e.cfg().MaxSlippagePct <= 0 || e.simulation || fill.Price <= 0 ||
+		(match.Action != ActionOpen && match.Action != ActionAdd) {
+		return false, ""
+	}
+	mark := e.currentMarkPrice(fill, match)
+	if mark <= 0 {
+		logger.Debugf("🎯 [%s] %s 无可用标记价，不检查滑点", e.traderID, fill.Symbol)
+		return false, ""
+	}
+	deviation := math.Abs(mark-fill.Price) / fill.Price
+	if deviation <= e.cfg().MaxSlippagePct {
+		return false, ""
+	}
+	return true, fmt.Sprintf("price moved %.2f%% beyond slippage guard", deviation*100)
+}
+
+// skipSlippage 价格偏离过大时跳过开仓/加仓，并记录 slippage 预警
+func (e *Engine) skipSlippage(fill *Fill, match *SignalMatchResult, reason string) {
+	e.skipSignal(fill, match, reason)
+	e.logWarning(Warning{
+		Timestamp:    time.Now(),
+		Symbol:       fill.Symbol,
+		Type:         WarningTypeSlippage,
+		Message:      fmt.Sprintf("%s (leader price %.4f, max %.2f%%)", reason, fill.Price, e.cfg().MaxSlippagePct*100),
+		SignalAction: string(match.Action),
+		SignalValue:  fill.Value,
+	})
+}
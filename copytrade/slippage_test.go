@@ -0,0 +1,114 @@
+package copytrade
+
+import (
+	"net/http"
+	"testing"
+
+	"nofx/decision"
+)
+
+// markProvider 返回固定标记价的数据源
+type markProvider struct {
+	*fakeProvider
+	price float64
+}
+
+func (p *markProvider) GetMarkPrice(symbol string) (float64, error) { return p.price, nil }
+
+func TestGetMarkPrice(t *testing.T) {
+	hl := &HyperliquidProvider{client: &http.Client{Transport: stubTransport{body: `{"BTC":"101.5","kPEPE":"0.01"}`}}}
+	if price, err := hl.GetMarkPrice("BTCUSDT"); err != nil || price != 101.5 {
+		t.Errorf("hl mark price = %v (err=%v), want 101.5", price, err)
+	}
+	if price, err := hl.GetMarkPrice("KPEPEUSDT"); err != nil || price != 0.01 {
+		t.Errorf("hl kPEPE mark price = %v (err=%v), want 0.01", price, err)
+	}
+	if _, err := hl.GetMarkPrice("ETHUSDT"); err == nil {
+		t.Error("hl missing coin should fail")
+	}
+
+	okx := &OKXProvider{client: &http.Client{Transport: stubTransport{body: `{"code":"0","data":[{"instId":"BTC-USDT-SWAP","markPx":"99.5"}]}`}}, limiter: newRateLimiter(100)}
+	if price, err := okx.GetMarkPrice("BTCUSDT"); err != nil || price != 99.5 {
+		t.Errorf("okx mark price = %v (err=%v), want 99.5", price, err)
+	}
+	okx.client = &http.Client{Transport: stubTransport{body: `{"code":"51001","msg":"Instrument ID does not exist","data":[]}`}}
+	if _, err := okx.GetMarkPrice("FOOUSDT"); err == nil {
+		t.Error("okx error code should fail")
+	}
+}
+
+// TestSlippageGuard 开仓/加仓价格偏离超过阈值时跳过（新开仓标记 ignored + slippage 预警），减仓不受影响
+func TestSlippageGuard(t *testing.T) {
+	st := newTestStore(t)
+	base := &fakeProvider{}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1, MaxSlippagePct: 0.02}, 1000)
+	e.store = st
+	e.provider = base
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e}
+
+	next := func() *decision.Decision {
+		select {
+		case fullDec := <-e.decisionCh:
+			dec := fullDec.Decisions[0]
+			ti.updatePositionMapping(&dec)
+			return &dec
+		default:
+			return nil
+		}
+	}
+	setSize := func(size, mark float64) {
+		base.setSize(size)
+		base.state.Positions[PositionKey("BTCUSDT", SideLong)].MarkPrice = mark
+	}
+
+	// 领航员持仓标记价 101（偏离 1%）→ 跟随
+	setSize(2, 101)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "open", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 2, Value: 200}})
+	if dec := next(); dec == nil || dec.Action != "open_long" {
+		t.Fatalf("open within guard = %+v, want open_long", dec)
+	}
+
+	// 数据源标记价优先：103 偏离 3% → 加仓跳过
+	e.provider = &markProvider{fakeProvider: base, price: 103}
+	setSize(3, 100)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "add", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionAdd, Price: 100, Size: 1, Value: 100}})
+	if dec := next(); dec != nil {
+		t.Fatalf("add beyond guard = %+v, want skip", dec)
+	}
+
+	// 减仓不检查滑点
+	setSize(1, 100)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "reduce", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionReduce, Price: 100, Size: 2, Value: 200}})
+	if dec := next(); dec == nil || dec.Action != "reduce_long" {
+		t.Fatalf("reduce = %+v, want reduce_long", dec)
+	}
+
+	// 新开仓向有利方向偏离同样跳过，标记 ignored
+	e.provider = &markProvider{fakeProvider: base, price: 9.5}
+	base.state.Positions["ETHUSDT_short"] = &Position{Symbol: "ETHUSDT", Side: SideShort, Size: 1, EntryPrice: 10, MarginMode: "cross"}
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "eth-open", Symbol: "ETHUSDT", Side: "sell", PositionSide: SideShort, Action: ActionOpen, Price: 10, Size: 1, Value: 10}})
+	if dec := next(); dec != nil {
+		t.Fatalf("open beyond guard = %+v, want skip", dec)
+	}
+	m, err := st.CopyTrade().GetMapping("test", PositionKey("ETHUSDT", SideShort))
+	if err != nil || m == nil || m.Status != "ignored" {
+		t.Fatalf("mapping after slippage skip = %+v (err=%v), want ignored", m, err)
+	}
+	logs, err := st.CopyTrade().GetRecentSignalLogs("test", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, l := range logs {
+		if l.SignalID == "skip_eth-open" && l.FollowReason == "price moved 5.00% beyond slippage guard" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("slippage skip not in signal log: %+v", logs)
+	}
+	warnings, err := st.CopyTrade().GetRecentWarnings("test", WarningTypeSlippage, 10)
+	if err != nil || len(warnings) != 2 {
+		t.Errorf("slippage warnings = %d (err=%v), want 2", len(warnings), err)
+	}
+}
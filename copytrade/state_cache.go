@@ -0,0 +1,65 @@
+package copytrade
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ============================================================================
+// 账户状态短时缓存
+// ============================================================================
+
+// AccountStateCacheTTL 账户状态缓存有效期
+// 足够合并 InitIgnoredPositions / syncLeaderState / refreshAccountState 等近乎同时的调用，
+// 又不会引入超过该窗口的数据延迟
+const AccountStateCacheTTL = 1 * time.Second
+
+// accountStateCache 账户状态单飞缓存
+// 并发的 GetAccountState 调用合并为一次 HTTP 请求，结果在 TTL 内复用
+type accountStateCache struct {
+	group singleflight.Group
+	ttl   time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedAccountState
+}
+
+type cachedAccountState struct {
+	state     *AccountState
+	fetchedAt time.Time
+}
+
+func newAccountStateCache(ttl time.Duration) *accountStateCache {
+	return &accountStateCache{
+		ttl:     ttl,
+		entries: make(map[string]cachedAccountState),
+	}
+}
+
+// get 获取账户状态，缓存未命中时通过 fetch 拉取（同一 leaderID 同时只有一个请求在途）
+func (c *accountStateCache) get(leaderID string, fetch func() (*AccountState, error)) (*AccountState, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[leaderID]; ok && time.Since(entry.fetchedAt) < c.ttl {
+		c.mu.Unlock()
+		return entry.state, nil
+	}
+	c.mu.Unlock()
+
+	v, err, _ := c.group.Do(leaderID, func() (interface{}, error) {
+		state, err := fetch()
+		if err != nil {
+			return nil, err // 错误不缓存，下次调用重新请求
+		}
+
+		c.mu.Lock()
+		c.entries[leaderID] = cachedAccountState{state: state, fetchedAt: time.Now()}
+		c.mu.Unlock()
+		return state, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*AccountState), nil
+}
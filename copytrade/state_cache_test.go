@@ -0,0 +1,76 @@
+package copytrade
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestAccountStateCacheCoalesces 并发调用只触发一次请求
+func TestAccountStateCacheCoalesces(t *testing.T) {
+	c := newAccountStateCache(time.Second)
+
+	var calls int32
+	release := make(chan struct{})
+	fetch := func() (*AccountState, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return &AccountState{TotalEquity: 100}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			state, err := c.get("leader", fetch)
+			if err != nil || state.TotalEquity != 100 {
+				t.Errorf("unexpected result: %v %v", state, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// TTL 内再次调用命中缓存
+	if _, err := c.get("leader", fetch); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("fetch called %d times, want 1", n)
+	}
+}
+
+// TestAccountStateCacheExpiresAndSkipsErrors 过期后重新请求，错误不缓存
+func TestAccountStateCacheExpiresAndSkipsErrors(t *testing.T) {
+	c := newAccountStateCache(10 * time.Millisecond)
+
+	var calls int
+	fail := true
+	fetch := func() (*AccountState, error) {
+		calls++
+		if fail {
+			return nil, errors.New("boom")
+		}
+		return &AccountState{TotalEquity: float64(calls)}, nil
+	}
+
+	if _, err := c.get("leader", fetch); err == nil {
+		t.Fatal("expected error")
+	}
+	fail = false
+	if _, err := c.get("leader", fetch); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	state, err := c.get("leader", fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || state.TotalEquity != 3 {
+		t.Errorf("calls=%d equity=%.0f, want 3/3", calls, state.TotalEquity)
+	}
+}
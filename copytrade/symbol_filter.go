@@ -0,0 +1,68 @@
+package copytrade
+
+import (
+	"fmt"
+	"time"
+
+	"nofx/logger"
+	"nofx/store"
+)
+
+// ============================================================================
+// 币种白名单/黑名单
+// ============================================================================
+//
+// 领航员交易的币种很多、只想跟其中几个时配置 SymbolWhitelist；只想排除个别币种时配置 SymbolBlacklist。
+// 名单不区分大小写，btc / BTCUSDT 都按 BTCUSDT 匹配：
+//   - 白名单非空时只跟随名单内的币种；黑名单优先于白名单
+//   - 被过滤的开仓/加仓跳过（原因 symbol filtered）并写入信号日志，新开仓标记为 ignored
+//   - 已有跟单仓位的减仓/平仓照常跟随，修改名单后不会遗留无人管理的仓位
+
+// SkipReasonSymbolFiltered 币种被白名单/黑名单过滤
+const SkipReasonSymbolFiltered = "symbol filtered"
+
+// isSymbolFiltered 币种是否被白名单/黑名单排除（调用方持有 cfgMu 读锁）
+func (e *Engine) isSymbolFiltered(symbol string) bool {
+	for _, s := range e.cfg().SymbolBlacklist {
+		if NormalizeSymbol(s) == symbol {
+			return true
+		}
+	}
+	if len(e.cfg().SymbolWhitelist) == 0 {
+		return false
+	}
+	for _, s := range e.cfg().SymbolWhitelist {
+		if NormalizeSymbol(s) == symbol {
+			return false
+		}
+	}
+	return true
+}
+
+// saveSkippedSignalLog 未生成决策的跳过信号写入信号日志，便于查看被过滤的交易
+func (e *Engine) saveSkippedSignalLog(fill *Fill, reason string) {
+	if e.store == nil {
+		return
+	}
+	signalID := fill.ID
+	if signalID == "" {
+		signalID = fmt.Sprintf("%s_%d", fill.Symbol, time.Now().UnixNano())
+	}
+	log := &store.CopyTradeSignalLog{
+		TraderID:     e.traderID,
+		LeaderID:     e.cfg().LeaderID,
+		ProviderType: string(e.cfg().ProviderType),
+		SignalID:     "skip_" + signalID,
+		Symbol:       fill.Symbol,
+		Action:       string(fill.Action),
+		PositionSide: string(fill.PositionSide),
+		LeaderPrice:  fill.Price,
+		LeaderValue:  fill.Value,
+		Followed:     false,
+		FollowReason: reason,
+		Status:       "skipped",
+	}
+	if err := e.store.CopyTrade().SaveSignalLog(log); err != nil {
+		logger.Warnf("⚠️ [%s] 保存信号日志失败: %v", e.traderID, err)
+	}
+}
@@ -0,0 +1,55 @@
+package copytrade
+
+import "testing"
+
+// TestSymbolFiltered 白名单/黑名单不区分大小写；黑名单优先
+func TestSymbolFiltered(t *testing.T) {
+	e := newTestEngine(&CopyConfig{LeaderID: "leader", CopyRatio: 1}, 1000)
+	if e.isSymbolFiltered("BTCUSDT") {
+		t.Fatal("no lists: BTCUSDT filtered")
+	}
+
+	withConfig(e, func(c *CopyConfig) { c.SymbolWhitelist = []string{"btc", "ethusdt"} })
+	for symbol, want := range map[string]bool{"BTCUSDT": false, "ETHUSDT": false, "SOLUSDT": true} {
+		if got := e.isSymbolFiltered(symbol); got != want {
+			t.Errorf("whitelist: isSymbolFiltered(%s) = %v, want %v", symbol, got, want)
+		}
+	}
+
+	withConfig(e, func(c *CopyConfig) { c.SymbolBlacklist = []string{"Eth"} })
+	if !e.isSymbolFiltered("ETHUSDT") {
+		t.Error("blacklisted ETHUSDT not filtered")
+	}
+}
+
+// TestSymbolFilterSkipsOpen 被过滤的开仓不跟随，计入跳过并写入信号日志
+func TestSymbolFilterSkipsOpen(t *testing.T) {
+	provider := &fakeProvider{}
+	provider.setSize(1)
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1,
+		SymbolBlacklist: []string{"btc"}}, 1000)
+	e.store = newTestStore(t)
+	e.provider = provider
+
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "f1", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong,
+		Action: ActionOpen, Price: 100, Size: 1, Value: 100}})
+	if n := len(e.decisionCh); n != 0 {
+		t.Fatalf("filtered symbol: decisions = %d, want 0", n)
+	}
+	if e.stats.SignalsSkipped != 1 {
+		t.Errorf("SignalsSkipped = %d, want 1", e.stats.SignalsSkipped)
+	}
+	m, err := e.store.CopyTrade().GetMapping("test", PositionKey("BTCUSDT", SideLong))
+	if err != nil || m == nil || m.Status != "ignored" {
+		t.Errorf("mapping = %+v err=%v, want ignored", m, err)
+	}
+
+	logs, err := e.store.CopyTrade().GetRecentSignalLogs("test", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].Status != "skipped" || logs[0].FollowReason != SkipReasonSymbolFiltered ||
+		logs[0].Symbol != "BTCUSDT" || logs[0].Followed {
+		t.Errorf("signal logs = %+v, want one skipped symbol filtered", logs)
+	}
+}
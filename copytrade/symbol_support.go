@@ -0,0 +1,90 @@
+package copytrade
+
+import (
+	"fmt"
+	"time"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 跟随者交易所未上架的币种
+// ============================================================================
+//
+// 领航员交易的币种在跟随者交易所不存在时，每笔成交都会下单失败。
+// 执行器实现 SymbolSupportChecker 时，开仓/加仓前先检查币种是否上架：
+// 未上架的直接跳过（新开仓标记为 ignored），只发出一次预警；检查结果按币种缓存。
+// 检查本身失败（网络等）时不缓存、照常跟随，由下单结果决定。
+
+// 未上架币种的处理策略
+const (
+	UnlistedSymbolSkip    = "skip"    // 预检查，未上架则跳过（默认）
+	UnlistedSymbolAttempt = "attempt" // 不预检查，照常下单
+)
+
+// symbolSupportTTL 上架检查结果缓存时间（新币上架后最多延迟这么久才开始跟随）
+const symbolSupportTTL = time.Hour
+
+// SymbolSupportChecker 执行器可选能力：检查跟随者交易所是否上架某币种
+type SymbolSupportChecker interface {
+	SupportsSymbol(symbol string) (bool, error)
+}
+
+// symbolSupport 缓存的上架检查结果
+type symbolSupport struct {
+	supported bool
+	checkedAt time.Time
+}
+
+// WithSymbolChecker 注入币种上架检查（通常来自执行器）
+func WithSymbolChecker(check func(symbol string) (bool, error)) EngineOption {
+	return func(e *Engine) {
+		e.supportsSymbol = check
+	}
+}
+
+// isSymbolListed 跟随者交易所是否上架该币种（未注入检查、策略为 attempt 或检查失败时返回 true）
+func (e *Engine) isSymbolListed(symbol string) bool {
+	if e.supportsSymbol == nil || e.cfg().UnlistedSymbolPolicy == UnlistedSymbolAttempt {
+		return true
+	}
+
+	e.symbolMu.Lock()
+	if cached, ok := e.symbolSupport[symbol]; ok && time.Since(cached.checkedAt) < symbolSupportTTL {
+		e.symbolMu.Unlock()
+		return cached.supported
+	}
+	e.symbolMu.Unlock()
+
+	supported, err := e.supportsSymbol(symbol)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 检查币种上架失败: %s: %v（照常跟随）", e.traderID, symbol, err)
+		return true
+	}
+
+	e.symbolMu.Lock()
+	if e.symbolSupport == nil {
+		e.symbolSupport = make(map[string]symbolSupport)
+	}
+	e.symbolSupport[symbol] = symbolSupport{supported: supported, checkedAt: time.Now()}
+	warned := e.unlistedWarned[symbol]
+	if !supported && !warned {
+		if e.unlistedWarned == nil {
+			e.unlistedWarned = make(map[string]bool)
+		}
+		e.unlistedWarned[symbol] = true
+	}
+	e.symbolMu.Unlock()
+
+	// 每个币种只预警一次
+	if !supported && !warned {
+		e.logWarning(Warning{
+			Timestamp: time.Now(),
+			Symbol:    symbol,
+			Type:      "symbol_not_listed",
+			Message:   fmt.Sprintf("%s 未在跟随者交易所上架，该币种的开仓/加仓将被跳过", symbol),
+			Executed:  false,
+		})
+	}
+	return supported
+}
@@ -0,0 +1,63 @@
+package copytrade
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestUnlistedSymbolSkipped 跟随者交易所未上架的币种：跳过开仓并只预警一次，检查结果缓存
+func TestUnlistedSymbolSkipped(t *testing.T) {
+	st := newTestStore(t)
+	provider := &fakeProvider{}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.store = st
+	e.provider = provider
+
+	listed := map[string]bool{"BTCUSDT": false}
+	var checkErr error
+	checks := 0
+	WithSymbolChecker(func(symbol string) (bool, error) {
+		checks++
+		return listed[symbol], checkErr
+	})(e)
+
+	// 开仓：未上架 → 跳过，不生成决策
+	provider.setSize(1)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "open", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 1, Value: 100}})
+	if len(e.decisionCh) != 0 {
+		t.Fatalf("decision generated for unlisted symbol")
+	}
+	if checks != 1 || e.stats.WarningsCount != 1 {
+		t.Fatalf("checks=%d warnings=%d, want 1/1", checks, e.stats.WarningsCount)
+	}
+
+	// 该仓位已标记 ignored：后续加仓不再检查也不跟随
+	provider.setSize(2)
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "add", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong, Action: ActionAdd, Price: 100, Size: 1, Value: 100}})
+	if len(e.decisionCh) != 0 {
+		t.Fatalf("decision generated for add on ignored position")
+	}
+
+	// 缓存：再次检查不调用执行器，也不重复预警
+	if e.isSymbolListed("BTCUSDT") {
+		t.Fatalf("BTCUSDT should be unlisted")
+	}
+	if checks != 1 || e.stats.WarningsCount != 1 {
+		t.Fatalf("after cached check: checks=%d warnings=%d, want 1/1", checks, e.stats.WarningsCount)
+	}
+
+	// 检查失败：照常跟随且不缓存
+	checkErr = errors.New("timeout")
+	if !e.isSymbolListed("ETHUSDT") || !e.isSymbolListed("ETHUSDT") {
+		t.Fatalf("failed check should allow the symbol")
+	}
+	if checks != 3 {
+		t.Fatalf("failed checks should not be cached: checks=%d, want 3", checks)
+	}
+
+	// attempt 策略：不检查
+	withConfig(e, func(c *CopyConfig) { c.UnlistedSymbolPolicy = UnlistedSymbolAttempt })
+	if !e.isSymbolListed("SOLUSDT") || checks != 3 {
+		t.Fatalf("attempt policy should skip the check: checks=%d", checks)
+	}
+}
@@ -0,0 +1,99 @@
+package copytrade
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"nofx/decision"
+	"nofx/logger"
+)
+
+// ============================================================================
+// 按币种运行时开关
+// ============================================================================
+//
+// SymbolEnabled 记录按币种暂停跟随（false=暂停，未列出=跟随），由 POST /api/copytrade/symbol/:trader_id
+// 写入配置后热更新，不需要重启跟单：
+//   - 暂停的币种不再开仓/加仓，新开仓标记为 ignored（恢复后不把该仓位的加仓当作新开仓追入）
+//   - 已有仓位的减仓/平仓照常跟随，避免仓位无人管理
+//   - 暂停时传 close=true：主动平掉该币种的全部活跃跟单仓位（映射转为 ignored）
+
+// CloseReasonSymbolDisabled 暂停跟随币种时主动平仓
+const CloseReasonSymbolDisabled = "symbol_disabled"
+
+// NormalizeSymbol 统一用户输入的币种格式（btc / BTCUSDT → BTCUSDT），无法解析时返回空串
+func NormalizeSymbol(symbol string) string {
+	symbol = normalizeSymbol(strings.TrimSpace(symbol))
+	if !isValidFillSymbol(symbol) {
+		return ""
+	}
+	return symbol
+}
+
+// isSymbolEnabled 币种是否跟随开仓/加仓（调用方持有 cfgMu 读锁）
+func (e *Engine) isSymbolEnabled(symbol string) bool {
+	enabled, ok := e.cfg().SymbolEnabled[symbol]
+	return !ok || enabled
+}
+
+// CloseSymbolPositions 主动平掉某币种的全部活跃跟单仓位，返回已推送的平仓决策数
+func (e *Engine) CloseSymbolPositions(symbol string) (int, error) {
+	e.cfgMu.RLock()
+	defer e.cfgMu.RUnlock()
+
+	mappings, err := e.listActiveMappings()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list active mappings: %w", err)
+	}
+	leaderPosMap := e.buildLeaderPosMap()
+
+	closed := 0
+	for _, m := range mappings {
+		if m.Symbol != symbol {
+			continue
+		}
+
+		action := "close_long"
+		if m.Side == string(SideShort) {
+			action = "close_short"
+		}
+		closePrice := 0.0
+		if pos := leaderPosMap[m.LeaderPosID]; pos != nil {
+			closePrice = pos.MarkPrice
+		}
+
+		dec := decision.Decision{
+			Symbol:      m.Symbol,
+			Action:      action,
+			Reasoning:   fmt.Sprintf("Copy trading: close, symbol disabled, overriding %s leader %s", e.cfg().ProviderType, e.cfg().LeaderID),
+			EntryPrice:  closePrice,
+			LeaderPosID: m.LeaderPosID,
+			MarginMode:  m.MarginMode,
+			CloseReason: CloseReasonSymbolDisabled,
+		}
+		fullDec := &decision.FullDecision{
+			SystemPrompt: e.buildSystemPromptLog(),
+			UserPrompt:   fmt.Sprintf("## Symbol Disabled\n\nposId: %s\nSymbol: %s\n", m.LeaderPosID, m.Symbol),
+			CoTTrace:     fmt.Sprintf("# Copy Trading Decision\n\nFollowing %s was disabled with close=true. Closing %s position regardless of leader.\n", m.Symbol, m.Side),
+			Decisions:    []decision.Decision{dec},
+			RawResponse:  fmt.Sprintf("Copy trade symbol disabled close for %s:%s", e.cfg().ProviderType, e.cfg().LeaderID),
+			Timestamp:    time.Now(),
+		}
+
+		if e.pushDecision(fullDec) {
+			closed++
+			logger.Infof("🔕 [%s] 币种已暂停跟随 | posId=%s %s %s → 主动平仓", e.traderID, m.LeaderPosID, m.Symbol, m.Side)
+		}
+	}
+	return closed, nil
+}
+
+// CloseCopyTradingSymbol 主动平掉运行中跟单某币种的全部活跃仓位
+func CloseCopyTradingSymbol(traderID, symbol string) (int, error) {
+	integration, exists := getIntegration(traderID)
+	if !exists || !integration.IsRunning() || integration.engine == nil {
+		return 0, fmt.Errorf("copy trading not running for trader %s", traderID)
+	}
+	return integration.engine.CloseSymbolPositions(symbol)
+}
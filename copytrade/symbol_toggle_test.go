@@ -0,0 +1,51 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+
+	"nofx/store"
+)
+
+// TestSymbolToggle 暂停的币种不开仓（标记 ignored），close 时为活跃映射推送平仓
+func TestSymbolToggle(t *testing.T) {
+	provider := &fakeProvider{}
+	provider.setSize(1)
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1,
+		SymbolEnabled: map[string]bool{"BTCUSDT": false}}, 1000)
+	e.store = newTestStore(t)
+	e.provider = provider
+
+	e.processSignal(&TradeSignal{Fill: &Fill{ID: "f1", Symbol: "BTCUSDT", Side: "buy", PositionSide: SideLong,
+		Action: ActionOpen, Price: 100, Size: 1, Value: 100}})
+	if n := len(e.decisionCh); n != 0 {
+		t.Fatalf("disabled symbol: decisions = %d, want 0", n)
+	}
+	m, err := e.store.CopyTrade().GetMapping("test", PositionKey("BTCUSDT", SideLong))
+	if err != nil || m == nil || m.Status != "ignored" {
+		t.Fatalf("mapping = %+v err=%v, want ignored", m, err)
+	}
+
+	if err := e.store.CopyTrade().SavePositionMapping(&store.CopyTradePositionMapping{
+		TraderID: "test", LeaderPosID: "eth-pos", LeaderID: "leader", Symbol: "ETHUSDT", Side: "short",
+		MarginMode: "cross", OpenedAt: time.Now(), OpenSizeUSD: 50,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	closed, err := e.CloseSymbolPositions("ETHUSDT")
+	if err != nil || closed != 1 {
+		t.Fatalf("closed = %d err=%v, want 1", closed, err)
+	}
+	dec := (<-e.decisionCh).Decisions[0]
+	if dec.Action != "close_short" || dec.LeaderPosID != "eth-pos" || dec.CloseReason != CloseReasonSymbolDisabled {
+		t.Errorf("close decision = %+v", dec)
+	}
+}
+
+func TestNormalizeSymbol(t *testing.T) {
+	for in, want := range map[string]string{"btc": "BTCUSDT", " ETHUSDT ": "ETHUSDT", "": "", "usdt": ""} {
+		if got := NormalizeSymbol(in); got != want {
+			t.Errorf("NormalizeSymbol(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package copytrade
+
+import (
+	"fmt"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 加仓前检查目标仓位
+// ============================================================================
+//
+// 漏跟减仓/平仓或手动交易后，跟随者持仓可能已超过按比例应持有的仓位，继续按加仓量跟随会不断放大偏差。
+// CapAtTargetAllocation 开启后，每次加仓前计算目标仓位：
+//   目标价值 = 跟单系数 × (领航员加仓后持仓价值 / 领航员权益) × 跟随者权益
+// 跟随者当前持仓价值（按成交价计）已达到目标时跳过该次加仓，原因记为 already above target allocation。
+// 领航员权益、跟随者权益或跟随者持仓不可用时不检查（照常加仓）。
+
+// aboveTargetAllocation 跟随者持仓是否已达到按比例的目标仓位（达到时返回跳过原因）
+func (e *Engine) aboveTargetAllocation(signal *TradeSignal, match *SignalMatchResult) (bool, string) {
+	if !e.cfg().CapAtTargetAllocation || match.Action != ActionAdd || match.LeaderPosition == nil {
+		return false, ""
+	}
+	fill := signal.Fill
+	followerEquity := e.getFollowerBalance()
+	if signal.LeaderEquity <= 0 || followerEquity <= 0 || fill.Price <= 0 {
+		return false, ""
+	}
+
+	followerValue := e.followerPositionSize(fill.Symbol, fill.PositionSide, match.MarginMode) * fill.Price
+	if followerValue <= 0 {
+		return false, ""
+	}
+	leaderValue := match.LeaderPosition.Size * fill.Price
+	target := e.effectiveCopyRatio(signal) * leaderValue / signal.LeaderEquity * followerEquity
+
+	logger.Infof("📊 [%s] 目标仓位检查 | %s | 领航员持仓=%.2f 权益=%.2f → 目标=%.2f | 跟随者持仓=%.2f",
+		e.traderID, fill.Symbol, leaderValue, signal.LeaderEquity, target, followerValue)
+	if followerValue < target {
+		return false, ""
+	}
+	return true, fmt.Sprintf("already above target allocation（持仓 %.2f >= 目标 %.2f）", followerValue, target)
+}
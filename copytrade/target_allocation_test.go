@@ -0,0 +1,52 @@
+package copytrade
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestAboveTargetAllocation 跟随者持仓已超过按比例的目标仓位时跳过加仓
+func TestAboveTargetAllocation(t *testing.T) {
+	posID := PositionKey("BTCUSDT", SideLong)
+	followerSize := 0.5
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 1, CapAtTargetAllocation: true}, 1000)
+	e.getFollowerPositions = func() map[string]*Position {
+		return map[string]*Position{posID: {Symbol: "BTCUSDT", Side: SideLong, Size: followerSize, MarginMode: "cross"}}
+	}
+
+	// 领航员加仓后持仓 2 × 100 = 200，占权益 10000 的 2% → 跟随者目标 1000 × 2% = 20；当前持仓 0.5 × 100 = 50
+	fill := &Fill{Symbol: "BTCUSDT", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 1, Value: 100}
+	signal := &TradeSignal{Fill: fill, LeaderEquity: 10000}
+	match := &SignalMatchResult{Action: ActionAdd, PosID: posID, MarginMode: "cross",
+		LeaderPosition: &Position{Symbol: "BTCUSDT", Side: SideLong, Size: 2, MarginMode: "cross"}}
+
+	above, reason := e.aboveTargetAllocation(signal, match)
+	if !above || !strings.HasPrefix(reason, "already above target allocation") {
+		t.Fatalf("over-allocated: above=%v reason=%q, want skip", above, reason)
+	}
+
+	// 未开启时照常加仓
+	withConfig(e, func(c *CopyConfig) { c.CapAtTargetAllocation = false })
+	if above, _ := e.aboveTargetAllocation(signal, match); above {
+		t.Error("disabled: add skipped")
+	}
+	withConfig(e, func(c *CopyConfig) { c.CapAtTargetAllocation = true })
+
+	// 低于目标仓位照常加仓
+	followerSize = 0.1
+	if above, _ := e.aboveTargetAllocation(signal, match); above {
+		t.Error("under target: add skipped")
+	}
+
+	// 只检查加仓，不影响开仓
+	followerSize = 0.5
+	open := &SignalMatchResult{Action: ActionOpen, PosID: posID, MarginMode: "cross", LeaderPosition: match.LeaderPosition}
+	if above, _ := e.aboveTargetAllocation(signal, open); above {
+		t.Error("open skipped by target allocation check")
+	}
+
+	// 领航员权益未知时不检查
+	if above, _ := e.aboveTargetAllocation(&TradeSignal{Fill: fill}, match); above {
+		t.Error("unknown leader equity: add skipped")
+	}
+}
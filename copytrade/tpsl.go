@@ -0,0 +1,90 @@
+package copytrade
+
+import (
+	"math"
+	"strings"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 止盈止损同步
+// ============================================================================
+//
+// CopyConfig.SyncTPSL 开启时，新开仓决策附带领航员仓位的止盈/止损触发价（decision.TakeProfit / StopLoss），
+// 由执行器在开仓成交后挂止盈止损单：
+//   - OKX：持仓接口的 tpTriggerPx / slTriggerPx（Position.TakeProfitPrice / StopLossPrice）
+//   - Hyperliquid：持仓接口不含止盈止损，开仓时从领航员挂单中取该仓位的 reduce-only 触发单
+//     （"Take Profit ..." 为止盈，"Stop ..." 为止损，多笔时取距入场价最近的一笔）
+//   - 数据源不提供时字段为 0，不附带止盈止损
+// 只同步开仓时已存在的止盈止损：领航员开仓后再设置或修改止盈止损不会同步，加仓也不附带。
+// 反向跟单时领航员的止盈即跟随者的止损（反之亦然）。
+// 触发价不在跟随者入场价正确一侧（多单止盈低于入场价等）的视为失效，不附带。
+
+// leaderTPSL 领航员仓位的止盈/止损价（领航员方向，0=无）
+// 仓位本身不带止盈止损且数据源支持查询挂单时，从挂单中的触发单补全
+func (e *Engine) leaderTPSL(match *SignalMatchResult) (tp, sl float64) {
+	pos := match.LeaderPosition
+	if pos == nil {
+		return 0, 0
+	}
+	if pos.TakeProfitPrice > 0 || pos.StopLossPrice > 0 {
+		return pos.TakeProfitPrice, pos.StopLossPrice
+	}
+	op, ok := e.provider.(OpenOrdersProvider)
+	if !ok {
+		return 0, 0
+	}
+	orders, err := op.GetOpenOrders(e.cfg().LeaderID)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 获取领航员止盈止损挂单失败: %v", e.traderID, err)
+		return 0, 0
+	}
+	p := *pos
+	populateTPSL(&p, orders)
+	return p.TakeProfitPrice, p.StopLossPrice
+}
+
+// followerTPSL 跟随者开仓应附带的止盈/止损价（已按反向跟单转换并校验方向，无效的为 0）
+func (e *Engine) followerTPSL(signal *TradeSignal, match *SignalMatchResult, entryPrice float64) (tp, sl float64) {
+	tp, sl = e.leaderTPSL(match)
+	if tp == 0 && sl == 0 {
+		return 0, 0
+	}
+	if e.cfg().Inverse {
+		tp, sl = sl, tp
+	}
+	side := e.followerSide(signal.Fill.PositionSide)
+	if entryPrice > 0 {
+		if tp > 0 && (side == SideLong) != (tp > entryPrice) {
+			logger.Warnf("⚠️ [%s] 领航员止盈价 %.4f 不在 %s 入场价 %.4f 的盈利一侧，不同步", e.traderID, tp, side, entryPrice)
+			tp = 0
+		}
+		if sl > 0 && (side == SideLong) != (sl < entryPrice) {
+			logger.Warnf("⚠️ [%s] 领航员止损价 %.4f 不在 %s 入场价 %.4f 的亏损一侧，不同步", e.traderID, sl, side, entryPrice)
+			sl = 0
+		}
+	}
+	return tp, sl
+}
+
+// populateTPSL 用领航员挂单中属于该仓位的 reduce-only 触发单填充止盈/止损价
+func populateTPSL(pos *Position, orders []OpenOrder) {
+	for _, o := range orders {
+		if !o.IsTrigger || !o.ReduceOnly || o.TriggerPrice <= 0 || o.Symbol != pos.Symbol || o.PositionSide != pos.Side {
+			continue
+		}
+		var target *float64
+		switch orderType := strings.ToLower(o.OrderType); {
+		case strings.HasPrefix(orderType, "take profit"):
+			target = &pos.TakeProfitPrice
+		case strings.HasPrefix(orderType, "stop"):
+			target = &pos.StopLossPrice
+		default:
+			continue
+		}
+		if *target == 0 || math.Abs(o.TriggerPrice-pos.EntryPrice) < math.Abs(*target-pos.EntryPrice) {
+			*target = o.TriggerPrice
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package copytrade
+
+import "testing"
+
+func TestParseTPSL(t *testing.T) {
+	positions := parseOKXPositions([]OKXPositionData{{PosData: []OKXPosition{
+		{InstId: "BTC-USDT-SWAP", PosSide: "long", Pos: "1", AvgPx: "100", PosId: "p1", TpTriggerPx: "120", SlTriggerPx: "90"},
+		{InstId: "ETH-USDT-SWAP", PosSide: "short", Pos: "1", AvgPx: "10", PosId: "p2"},
+	}}}, normalizeOKXSymbol)
+	if p := positions["p1"]; p.TakeProfitPrice != 120 || p.StopLossPrice != 90 {
+		t.Errorf("okx tp/sl = %v/%v, want 120/90", p.TakeProfitPrice, p.StopLossPrice)
+	}
+	if p := positions["p2"]; p.TakeProfitPrice != 0 || p.StopLossPrice != 0 {
+		t.Errorf("okx position without tp/sl = %v/%v, want 0/0", p.TakeProfitPrice, p.StopLossPrice)
+	}
+
+	orders := parseHLOpenOrders([]HLOpenOrderRaw{
+		{Coin: "BTC", Side: "A", Sz: "1", Oid: 1, OrderType: "Take Profit Market", ReduceOnly: true, IsTrigger: true, TriggerPx: "130"},
+		{Coin: "BTC", Side: "A", Sz: "1", Oid: 2, OrderType: "Take Profit Limit", ReduceOnly: true, IsTrigger: true, TriggerPx: "115"},
+		{Coin: "BTC", Side: "A", Sz: "1", Oid: 3, OrderType: "Stop Market", ReduceOnly: true, IsTrigger: true, TriggerPx: "95"},
+		{Coin: "BTC", Side: "B", Sz: "1", Oid: 4, OrderType: "Stop Market", ReduceOnly: true, IsTrigger: true, TriggerPx: "80"}, // 空仓止损
+		{Coin: "BTC", Side: "A", LimitPx: "140", Sz: "1", Oid: 5, OrderType: "Limit", ReduceOnly: true},
+	})
+	pos := &Position{Symbol: "BTCUSDT", Side: SideLong, EntryPrice: 100}
+	populateTPSL(pos, orders)
+	// 多笔止盈取距入场价最近的一笔；空仓的止损和普通限价单不计入
+	if pos.TakeProfitPrice != 115 || pos.StopLossPrice != 95 {
+		t.Errorf("hl tp/sl = %v/%v, want 115/95", pos.TakeProfitPrice, pos.StopLossPrice)
+	}
+}
+
+// TestSyncTPSL 开启后新开仓附带领航员止盈止损；反向跟单互换；方向错误的不附带；加仓不附带
+func TestSyncTPSL(t *testing.T) {
+	open := func(cfg *CopyConfig, action ActionType, leaderPos *Position, provider LeaderProvider) (float64, float64) {
+		e := newTestEngine(cfg, 1000)
+		e.provider = provider
+		signal := &TradeSignal{Fill: &Fill{Symbol: "BTCUSDT", PositionSide: SideLong, Action: ActionOpen, Size: 1, Price: 100}}
+		match := &SignalMatchResult{ShouldFollow: true, Action: action, PosID: "p1", MarginMode: "cross", LeaderPosition: leaderPos}
+		dec := e.buildDecisionV2(signal, match, 50)
+		return dec.TakeProfit, dec.StopLoss
+	}
+	leaderPos := func() *Position {
+		return &Position{Symbol: "BTCUSDT", Side: SideLong, Size: 1, EntryPrice: 100, TakeProfitPrice: 120, StopLossPrice: 90}
+	}
+	cfg := func(mod func(*CopyConfig)) *CopyConfig {
+		c := &CopyConfig{ProviderType: ProviderOKX, LeaderID: "leader", CopyRatio: 1, SyncTPSL: true}
+		if mod != nil {
+			mod(c)
+		}
+		return c
+	}
+
+	if tp, sl := open(cfg(nil), ActionOpen, leaderPos(), &fakeProvider{}); tp != 120 || sl != 90 {
+		t.Errorf("open: tp/sl = %v/%v, want 120/90", tp, sl)
+	}
+	if tp, sl := open(cfg(func(c *CopyConfig) { c.SyncTPSL = false }), ActionOpen, leaderPos(), &fakeProvider{}); tp != 0 || sl != 0 {
+		t.Errorf("sync disabled: tp/sl = %v/%v, want 0/0", tp, sl)
+	}
+	if tp, sl := open(cfg(nil), ActionAdd, leaderPos(), &fakeProvider{}); tp != 0 || sl != 0 {
+		t.Errorf("add: tp/sl = %v/%v, want 0/0", tp, sl)
+	}
+	// 反向跟单：跟随者开空，领航员止盈 120 是跟随者止损，止损 90 是跟随者止盈
+	if tp, sl := open(cfg(func(c *CopyConfig) { c.Inverse = true }), ActionOpen, leaderPos(), &fakeProvider{}); tp != 90 || sl != 120 {
+		t.Errorf("inverse: tp/sl = %v/%v, want 90/120", tp, sl)
+	}
+	// 多单止损高于入场价（开仓即触发）不附带，止盈保留
+	stale := leaderPos()
+	stale.StopLossPrice = 105
+	if tp, sl := open(cfg(nil), ActionOpen, stale, &fakeProvider{}); tp != 120 || sl != 0 {
+		t.Errorf("wrong-side stop: tp/sl = %v/%v, want 120/0", tp, sl)
+	}
+
+	// 仓位不带止盈止损时从领航员触发单补全（Hyperliquid）
+	provider := &ordersProvider{fakeProvider: &fakeProvider{}, orders: []OpenOrder{
+		{Symbol: "BTCUSDT", PositionSide: SideLong, OrderType: "Stop Market", ReduceOnly: true, IsTrigger: true, TriggerPrice: 92},
+	}}
+	bare := &Position{Symbol: "BTCUSDT", Side: SideLong, Size: 1, EntryPrice: 100}
+	if tp, sl := open(cfg(func(c *CopyConfig) { c.ProviderType = ProviderHyperliquid }), ActionOpen, bare, provider); tp != 0 || sl != 92 {
+		t.Errorf("hl trigger orders: tp/sl = %v/%v, want 0/92", tp, sl)
+	}
+	if bare.StopLossPrice != 0 {
+		t.Error("leader position from provider state must not be modified")
+	}
+}
@@ -0,0 +1,131 @@
+package copytrade
+
+import (
+	"fmt"
+	"time"
+
+	"nofx/logger"
+	"nofx/store"
+)
+
+// ============================================================================
+// 交易时段：只在指定时间窗口内跟随开仓/加仓（平仓/减仓始终跟随）
+// ============================================================================
+
+// TimeWindow 交易时间窗口
+// Start/End 为 "HH:MM"（按 CopyConfig.Timezone 解释），Start > End 表示跨午夜（如 22:00-02:00）
+// Weekdays 为窗口开始当天的星期（0=周日 … 6=周六），为空表示每天
+type TimeWindow struct {
+	Weekdays []int  `json:"weekdays,omitempty"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+}
+
+// parseClock 解析 "HH:MM" 为当天的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Validate 校验窗口格式
+func (w TimeWindow) Validate() error {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return err
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return fmt.Errorf("start and end must differ")
+	}
+	for _, d := range w.Weekdays {
+		if d < 0 || d > 6 {
+			return fmt.Errorf("invalid weekday %d, expected 0-6", d)
+		}
+	}
+	return nil
+}
+
+// Contains 判断 t（已转换到目标时区）是否在窗口内
+func (w TimeWindow) Contains(t time.Time) bool {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if start > end {
+		// 跨午夜：午夜之后的部分属于前一天开始的窗口
+		if minute >= start {
+			return w.matchDay(day)
+		}
+		if minute < end {
+			return w.matchDay((day + 6) % 7)
+		}
+		return false
+	}
+	return minute >= start && minute < end && w.matchDay(day)
+}
+
+func (w TimeWindow) matchDay(day time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range w.Weekdays {
+		if time.Weekday(d) == day {
+			return true
+		}
+	}
+	return false
+}
+
+// toTimeWindows 转换持久化的交易时段配置
+func toTimeWindows(windows []store.CopyTradeTimeWindow) []TimeWindow {
+	if len(windows) == 0 {
+		return nil
+	}
+	result := make([]TimeWindow, len(windows))
+	for i, w := range windows {
+		result[i] = TimeWindow{Weekdays: w.Weekdays, Start: w.Start, End: w.End}
+	}
+	return result
+}
+
+// LoadTradingLocation 解析交易时段时区（空 = UTC）
+func LoadTradingLocation(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(tz)
+}
+
+// inTradingWindow 当前时间是否允许开仓/加仓（未配置窗口 = 始终允许）
+func (e *Engine) inTradingWindow(now time.Time) bool {
+	if len(e.cfg().TradingWindows) == 0 {
+		return true
+	}
+
+	loc, err := LoadTradingLocation(e.cfg().Timezone)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 时区 %q 无效，交易时段按 UTC 计算: %v", e.traderID, e.cfg().Timezone, err)
+		loc = time.UTC
+	}
+
+	local := now.In(loc)
+	for _, w := range e.cfg().TradingWindows {
+		if w.Contains(local) {
+			return true
+		}
+	}
+	return false
+}
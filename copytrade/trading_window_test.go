@@ -0,0 +1,89 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeWindowContains(t *testing.T) {
+	// 2024-01-06 为周六
+	at := func(day int, hhmm string) time.Time {
+		c, _ := time.Parse("15:04", hhmm)
+		return time.Date(2024, 1, day, c.Hour(), c.Minute(), 0, 0, time.UTC)
+	}
+	weekdays := []int{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		name   string
+		window TimeWindow
+		t      time.Time
+		want   bool
+	}{
+		{"inside", TimeWindow{Start: "08:00", End: "20:00"}, at(6, "12:00"), true},
+		{"end exclusive", TimeWindow{Start: "08:00", End: "20:00"}, at(6, "20:00"), false},
+		{"before start", TimeWindow{Start: "08:00", End: "20:00"}, at(6, "07:59"), false},
+		{"overnight before midnight", TimeWindow{Start: "22:00", End: "02:00"}, at(6, "23:30"), true},
+		{"overnight after midnight", TimeWindow{Start: "22:00", End: "02:00"}, at(7, "01:30"), true},
+		{"overnight gap", TimeWindow{Start: "22:00", End: "02:00"}, at(7, "03:00"), false},
+		{"weekday on weekend", TimeWindow{Weekdays: weekdays, Start: "08:00", End: "20:00"}, at(6, "12:00"), false},
+		{"weekday on monday", TimeWindow{Weekdays: weekdays, Start: "08:00", End: "20:00"}, at(8, "12:00"), true},
+		// 周五 22:00 开始的窗口延续到周六凌晨
+		{"overnight from friday", TimeWindow{Weekdays: weekdays, Start: "22:00", End: "02:00"}, at(6, "01:00"), true},
+		{"overnight from saturday", TimeWindow{Weekdays: weekdays, Start: "22:00", End: "02:00"}, at(7, "01:00"), false},
+	}
+	for _, tt := range tests {
+		if got := tt.window.Contains(tt.t); got != tt.want {
+			t.Errorf("%s: Contains(%s) = %v, want %v", tt.name, tt.t.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
+
+// TestTradingWindowSkipsOpens 窗口外跳过开仓，平仓始终跟随
+func TestTradingWindowSkipsOpens(t *testing.T) {
+	st := newTestStore(t)
+	provider := &fakeProvider{}
+	e := newTestEngine(&CopyConfig{ProviderType: ProviderHyperliquid, LeaderID: "leader", CopyRatio: 1}, 1000)
+	e.store = st
+	e.provider = provider
+	ti := &TraderIntegration{traderID: "test", store: st, engine: e}
+
+	run := func(fill *Fill) string {
+		e.processSignal(&TradeSignal{Fill: fill})
+		select {
+		case fullDec := <-e.decisionCh:
+			ti.updatePositionMapping(&fullDec.Decisions[0])
+			return fullDec.Decisions[0].Action
+		default:
+			return ""
+		}
+	}
+	open := func(symbol string) *Fill {
+		return &Fill{ID: "open-" + symbol, Symbol: symbol, Side: "buy", PositionSide: SideLong, Action: ActionOpen, Price: 100, Size: 1, Value: 100}
+	}
+
+	// 窗口内开仓
+	provider.setSize(1)
+	if action := run(open("BTCUSDT")); action != "open_long" {
+		t.Fatalf("open inside window = %q", action)
+	}
+
+	// 窗口外（当前时间之后 1 小时开始的 1 小时窗口）
+	loc, _ := LoadTradingLocation("Asia/Shanghai")
+	start := time.Now().In(loc).Add(time.Hour)
+	withConfig(e, func(c *CopyConfig) { c.Timezone = "Asia/Shanghai" })
+	withConfig(e, func(c *CopyConfig) { c.TradingWindows = []TimeWindow{{Start: start.Format("15:04"), End: start.Add(time.Hour).Format("15:04")}} })
+
+	provider.state.Positions[PositionKey("ETHUSDT", SideLong)] = &Position{Symbol: "ETHUSDT", Side: SideLong, Size: 1, MarginMode: "cross"}
+	if action := run(open("ETHUSDT")); action != "" {
+		t.Errorf("open outside window = %q, want skipped", action)
+	}
+	if m, _ := st.CopyTrade().GetMapping("test", PositionKey("ETHUSDT", SideLong)); m == nil || m.Status != "ignored" {
+		t.Errorf("skipped open mapping = %+v, want ignored", m)
+	}
+
+	// 平仓始终跟随
+	delete(provider.state.Positions, PositionKey("BTCUSDT", SideLong))
+	if action := run(&Fill{ID: "close", Symbol: "BTCUSDT", Side: "sell", PositionSide: SideLong, Action: ActionClose, Price: 110, Size: 1, Value: 110}); action != "close_long" {
+		t.Errorf("close outside window = %q, want close_long", action)
+	}
+}
@@ -4,6 +4,8 @@ package copytrade
 
 import (
 	"time"
+
+	"nofx/decision"
 )
 
 // ProviderType 数据源类型
@@ -44,6 +46,10 @@ type Fill struct {
 	Value        float64    // 成交价值 (USDT)
 	Timestamp    time.Time  // 成交时间
 	ClosedPnL    float64    // 平仓盈亏 (如有)
+	NetMode      bool       // OKX 单向持仓模式（posSide=net），方向需结合本地映射推断
+	AmbiguousDir bool       // 原始方向无法确定开/平（如未知 dir），可结合 ClosedPnL 推断
+	CloseTrigger string     // 平仓/减仓由触发单产生时的来源（"take_profit" | "stop_loss" | "liquidation"），空=手动/未知
+	MarginMode   string     // 成交所属仓位的保证金模式（"cross" | "isolated"），空=数据源未提供
 
 	// 原始数据（调试用）
 	Raw interface{} `json:"-"`
@@ -61,6 +67,10 @@ type Position struct {
 	UnrealizedPnL float64
 	PositionValue float64 // 仓位价值
 	PosID         string   // OKX 仓位唯一标识（用于精确匹配）
+
+	// 止盈/止损触发价（数据源不提供时为 0）
+	TakeProfitPrice float64
+	StopLossPrice   float64
 }
 
 // AccountState 账户状态
@@ -94,8 +104,255 @@ type CopyConfig struct {
 	// 预警阈值（不限制，只记录预警）
 	MinTradeWarn float64 `json:"min_trade_warn"` // 低于此金额记录预警
 	MaxTradeWarn float64 `json:"max_trade_warn"` // 高于此金额记录预警 (0=不预警)
+
+	// 领航员权益 <= 0 时的处理（绝不按 equity=1 计算比例）
+	ZeroEquityPolicy     string  `json:"zero_equity_policy"`     // "skip"(默认) | "fixed_notional" | "equity_override"
+	FixedNotional        float64 `json:"fixed_notional"`         // 固定跟单金额 (USDT)
+	LeaderEquityOverride float64 `json:"leader_equity_override"` // 手动指定的领航员权益 (USDT)
+
+	// 最大持仓时间（小时，0=不限制）
+	// ⚠️ 这是对领航员节奏的覆盖：超时后即使领航员仍持仓也会主动平仓，
+	// 之后该仓位的加仓/减仓不再跟随
+	MaxHoldHours int `json:"max_hold_hours"`
+
+	// 试用模式：只跟随领航员前 N 笔开仓（0=不限制）
+	// 达到上限后进入只平仓模式（不再开仓/加仓），并发出预警提醒用户评估
+	TrialTradeLimit int `json:"trial_trade_limit"`
+
+	// 领航员资金预算（USDT，0=不限制）
+	// 该领航员所有活跃跟单仓位的累计开仓金额（开仓 + 加仓）不超过预算，平仓后释放
+	LeaderBudget float64 `json:"leader_budget"`
+
+	// 仓位映射一致性检查间隔（分钟，0=默认 5 分钟，<0=关闭）
+	DriftCheckMinutes int `json:"drift_check_minutes"`
+
+	// 减仓模式："ratio"(默认，按领航员减仓百分比) | "scaled_absolute"(按领航员减仓数量 × 跟单系数)
+	ReduceMode string `json:"reduce_mode"`
+
+	// 轮询返回窗口外（早于请求的 since）成交的处理："drop"(默认，丢弃并记录日志) | "keep"(照常处理)
+	OutOfWindowFills string `json:"out_of_window_fills"`
+
+	// 性能诊断：统计关键路径耗时并打 pprof 标签（通过调试接口查看）
+	Profiling bool `json:"profiling"`
+
+	// 快速重新开仓冷却（秒，0=不限制）
+	// 领航员平仓后 N 秒内同币种同方向重新开仓时不跟随（震荡行情频繁开平），
+	// 该仓位标记为 ignored，后续加仓/减仓也不跟随
+	ReopenCooldownSeconds int `json:"reopen_cooldown_seconds"`
+
+	// 交易时段（为空=全天）：窗口外不跟随开仓/加仓，平仓/减仓始终跟随
+	TradingWindows []TimeWindow `json:"trading_windows"`
+	Timezone       string       `json:"timezone"` // IANA 时区（如 "Asia/Shanghai"），空=UTC
+
+	// 启动时领航员状态不可用（WS 与 REST 均失败）："refuse"(默认，拒绝启动) | "degraded"(降级启动并重试)
+	StateUnavailablePolicy string `json:"state_unavailable_policy"`
+
+	// 重复决策保护窗口（秒，0=关闭）：窗口内相同 symbol+action+posId 的完全相同决策只执行一次
+	DuplicateDecisionWindowSec int `json:"duplicate_decision_window_sec"`
+
+	// 方向不确定（OKX 单向持仓、HL 未知 dir）时用成交的 ClosedPnL 辅助判断：非零视为平仓/减仓
+	ClosedPnLInference bool `json:"closed_pnl_inference"`
+
+	// 影子跟单（nil=关闭）：用另一组参数并行模拟，只记录假设决策与盈亏，不下单
+	Shadow *ShadowConfig `json:"shadow"`
+
+	// 入场订单类型："market"(默认) | "limit"(以领航员成交价挂限价单，不追价)
+	// ⚠️ 限价单可能不成交：LimitTimeoutAction="cancel" 时错过该笔交易（仓位标记为 ignored），
+	// "market" 时剩余部分转市价（价格已偏离，入场价可能比市价单更差）；执行器不支持限价时回退市价
+	EntryOrderType      string `json:"entry_order_type"`
+	LimitTimeoutSeconds int    `json:"limit_timeout_seconds"` // 限价单等待成交时间（秒，0=默认 30 秒）
+	LimitTimeoutAction  string `json:"limit_timeout_action"`  // 超时处理："cancel"(默认，撤单放弃) | "market"(转市价)
+
+	// 开仓前同步刷新领航员权益（绕过缓存/WS 状态，只对开仓生效，增加一次 REST 请求延迟）
+	FreshEquityOnOpen bool `json:"fresh_equity_on_open"`
+
+	// 跟随者交易所未上架的币种："skip"(默认，开仓/加仓前检查，未上架跳过并预警一次) | "attempt"(不检查，照常下单)
+	UnlistedSymbolPolicy string `json:"unlisted_symbol_policy"`
+
+	// 严格加仓匹配：无法通过 size 变化确定加仓目标时，不兜底加到唯一 active 仓位（宁可漏跟加仓，不误加）
+	StrictAddMatching bool `json:"strict_add_matching"`
+
+	// 跟随者权益 <= 0 时的处理："exits_only"(默认，不开仓/加仓，照常跟随减仓/平仓) | "pause_all"(减仓/平仓也不跟随)
+	NegativeEquityPolicy string `json:"negative_equity_policy"`
+
+	// 单币种最大持仓（基础币数量，如 {"BTCUSDT": 0.5}）：开仓/加仓后跟随者持仓不超过该值，超出部分不跟（避免交易所持仓上限拒单）
+	SymbolMaxBaseSize map[string]float64 `json:"symbol_max_base_size"`
+
+	// 去重范围："engine"(默认，每个引擎独立) | "account"(同一跟随者账户的引擎共享，防止误配置导致重复跟单)
+	DedupScope string `json:"dedup_scope"`
+
+	// 启动时领航员已有仓位："ignore"(默认，标记为 ignored 只跟新开仓) | "mirror"(按比例市价跟开，开仓前按同步设置发出 set_margin_mode/set_leverage)
+	InitialSync string `json:"initial_sync"`
+
+	// WebSocket 重连后："reconcile"(默认，对比映射与领航员持仓，补跟断线期间的开/加/减/平仓) | "off"(不补跟)
+	ReconnectReconcile string `json:"reconnect_reconcile"`
+
+	// 减仓数量低于跟随者交易所最小下单量（执行器支持查询时）："bump"(默认，提高到最小下单量) | "close"(转为全量平仓)
+	ReduceMinPolicy string `json:"reduce_min_policy"`
+
+	// 加仓前绕过缓存确认领航员仍持有目标仓位（数量不小于匹配时），否则放弃加仓（多一次 REST 请求延迟）
+	ConfirmAddHolding bool `json:"confirm_add_holding"`
+
+	// 预警记录持久化：每个 trader 保留最近 N 条（0=默认 1000，<0=不保存，只在内存中保留最近 200 条）
+	WarningHistoryLimit int `json:"warning_history_limit"`
+
+	// 高波动降杠杆：币种近 1 小时价格振幅超过参考值时按 参考值/振幅 降低杠杆（⚠️ 按设计低于领航员杠杆）
+	VolatilityLeverageScaling bool    `json:"volatility_leverage_scaling"`
+	VolatilityReference       float64 `json:"volatility_reference"` // 参考波动率（振幅比例，0=默认 0.05）
+
+	// OKX 非 USDT 计价合约（如币本位 BTC-USD-SWAP）映射到跟随者币种（如 {"BTC-USD-SWAP": "BTCUSDT"}），未映射的跳过
+	OKXInstrumentMap map[string]string `json:"okx_instrument_map"`
+
+	// 平仓/减仓时领航员状态无持仓但有多个 active 映射："refetch"(默认，视为可疑，绕过缓存重新拉取一次) | "trust"(直接使用)
+	EmptyStatePolicy string `json:"empty_state_policy"`
+
+	// 自适应跟单系数（nil=关闭）：按领航员近期平仓收益率放大/缩小 CopyRatio，每个信号记录有效系数
+	AdaptiveRatio *AdaptiveRatioConfig `json:"adaptive_ratio"`
+
+	// 同币种同方向多个仓位时平仓/减仓成交的配对："best_fit"(默认，持仓减少量与成交数量最吻合的仓位) | "first"(按映射顺序)
+	CloseMatchStrategy string `json:"close_match_strategy"`
+
+	// 启动跟单的最低账户权益（USDT，0=默认 最小跟单金额×10，<0=不检查）
+	MinAccountEquity float64 `json:"min_account_equity"`
+
+	// 加仓金额计算方式："fill_value"(默认，按成交价值占权益比例) | "match_increase_ratio"(按领航员持仓放大倍数放大跟随者持仓)
+	AddSizingMode string `json:"add_sizing_mode"`
+
+	// 健康度评分权重（nil=默认权重）
+	HealthWeights *HealthWeights `json:"health_weights"`
+
+	// 领航员仓位消失但无平仓成交（疑似划转到子账户）："mirror"(默认，按平仓跟随) | "ignore"(继续持有)
+	PhantomClosePolicy string `json:"phantom_close_policy"`
+
+	// 权益快照最小写入间隔（秒，0=默认 10 秒，<0=每次执行都写入），间隔内只保留最新一条
+	EquitySnapshotIntervalSeconds int `json:"equity_snapshot_interval_seconds"`
+
+	// 流式模式定时用 REST 拉取领航员状态交叉校验，持仓数量相对差异超过阈值时预警
+	CrossCheckState           bool    `json:"cross_check_state"`
+	CrossCheckIntervalSeconds int     `json:"cross_check_interval_seconds"` // 校验间隔（0=默认 60 秒）
+	CrossCheckThreshold       float64 `json:"cross_check_threshold"`        // 相对差异阈值（0=默认 0.05）
+
+	// 单笔开仓/加仓占用保证金上限（跟随者可用保证金的比例，0=不限制）及超出时处理："clamp"(默认，缩小) | "skip"(跳过)
+	MaxMarginFraction float64 `json:"max_margin_fraction"`
+	MarginCapPolicy   string  `json:"margin_cap_policy"`
+
+	// 新开仓附加保护性止损（距领航员成交价的比例，0=不挂），开仓与止损作为决策组执行，止损失败时回滚开仓
+	ProtectiveStopPct float64 `json:"protective_stop_pct"`
+
+	// 按币种运行时开关（false=暂停开仓/加仓，未列出=跟随），可热更新
+	SymbolEnabled map[string]bool `json:"symbol_enabled"`
+
+	// 决策通道持续满载多久判定为消费者失效（秒，0=默认 30）及处理方式："alert"(默认，只告警) | "pause"(同时暂停开仓/加仓)
+	DecisionStallSeconds int    `json:"decision_stall_seconds"`
+	DecisionStallPolicy  string `json:"decision_stall_policy"`
+
+	// 按领航员开仓挂单提前挂限价单建仓（默认关闭）及挂单轮询间隔（秒，0=默认 15）
+	MirrorLeaderOrders      bool `json:"mirror_leader_orders"`
+	LeaderOrdersPollSeconds int  `json:"leader_orders_poll_seconds"`
+
+	// 轮询模式的 REST 拉取间隔（0 或低于 1 秒时使用默认 3 秒；流式模式忽略）
+	PollInterval time.Duration `json:"poll_interval"`
+
+	// 加仓前检查跟随者持仓是否已达到按比例的目标仓位，达到时跳过加仓
+	CapAtTargetAllocation bool `json:"cap_at_target_allocation"`
+
+	// 跟单金额模式："proportional"(默认，按比例) | "fixed"(开仓/加仓按 FixedTradeUSD 固定金额)
+	CopyMode      string  `json:"copy_mode"`
+	FixedTradeUSD float64 `json:"fixed_trade_usd"` // 固定金额模式下每次开仓/加仓的跟单金额 (USDT)
+
+	// 币种白名单/黑名单（不区分大小写，btc 与 BTCUSDT 等价）：白名单非空时只跟随名单内币种，黑名单优先
+	SymbolWhitelist []string `json:"symbol_whitelist"`
+	SymbolBlacklist []string `json:"symbol_blacklist"`
+
+	// 跟单杠杆上限（0=不限制）：同步领航员杠杆时超过上限的截断为上限，并记录 leverage_capped 预警
+	MaxLeverage int `json:"max_leverage"`
+
+	// 模拟运行：生成决策并更新仓位映射，但不提交执行器下单（信号日志状态为 dry_run）
+	DryRun bool `json:"dry_run"`
+
+	// 多领航员跟单：非空时每个领航员一个引擎（各自的数据源），跟单系数 = CopyRatio × Weight；
+	// 仓位 ID 按领航员区分，减仓/平仓只匹配同一领航员的映射
+	Leaders []LeaderSpec `json:"leaders"`
+
+	// 反向跟单：跟随者持有与领航员相反方向的仓位（领航员开多 → 跟随者开空），映射记录跟随者实际方向
+	Inverse bool `json:"inverse"`
+
+	// 最大持仓数（0=不限制）：活跃跟单仓位数达到上限后不再跟随新开仓，已有仓位的加仓/减仓/平仓不受影响
+	MaxOpenPositions int `json:"max_open_positions"`
+
+	// 延迟跟单（0=立即跟随）：新开仓延迟推送，延迟期内领航员平掉该仓位则撤销（记录 debounced 预警）
+	CopyDelay time.Duration `json:"copy_delay"`
+
+	// 同步止盈止损：新开仓附带领航员仓位的止盈/止损触发价（数据源不提供时不附带）
+	SyncTPSL bool `json:"sync_tpsl"`
+
+	// 跟随领航员减仓/平仓（nil=跟随）：false 时只跟开仓/加仓，领航员平仓后映射标记为 detached，由跟随者自行平仓
+	FollowCloses *bool `json:"follow_closes"`
+
+	// 滑点保护（0=不检查）：开仓/加仓前当前标记价相对领航员成交价的偏离超过该比例（0.02=2%）时跳过，记录 slippage 预警
+	MaxSlippagePct float64 `json:"max_slippage_pct"`
+}
+
+// LeaderSpec 多领航员跟单中的单个领航员
+type LeaderSpec struct {
+	LeaderID     string       `json:"leader_id"`
+	ProviderType ProviderType `json:"provider_type"`
+	Weight       float64      `json:"weight"` // 0 = 1
 }
 
+// ShadowConfig 影子跟单参数（与实盘共用信号匹配与过滤，只替换仓位计算参数）
+type ShadowConfig struct {
+	Label        string  `json:"label"`          // 影子配置名称
+	CopyRatio    float64 `json:"copy_ratio"`     // 影子跟单系数
+	MinTradeWarn float64 `json:"min_trade_warn"` // 影子最小跟单金额（0=与实盘相同）
+}
+
+// 领航员权益为零时的处理策略
+const (
+	ZeroEquitySkip          = "skip"            // 跳过信号（安全默认）
+	ZeroEquityFixedNotional = "fixed_notional"  // 使用 FixedNotional 作为跟单金额
+	ZeroEquityOverride      = "equity_override" // 使用 LeaderEquityOverride 作为领航员权益
+)
+
+// 跟随者权益 <= 0 时的处理策略
+const (
+	NegativeEquityExitsOnly = "exits_only" // 只跟随减仓/平仓，降低风险（默认）
+	NegativeEquityPauseAll  = "pause_all"  // 全部暂停，由用户手动处理
+)
+
+// 减仓模式
+const (
+	ReduceModeRatio          = "ratio"           // 按领航员减仓百分比减仓
+	ReduceModeScaledAbsolute = "scaled_absolute" // 按领航员减仓数量 × 跟单系数减仓（不超过实际持仓）
+)
+
+// 入场订单类型与限价超时处理
+const (
+	EntryOrderMarket   = decision.OrderTypeMarket
+	EntryOrderLimit    = decision.OrderTypeLimit
+	LimitTimeoutCancel = decision.LimitTimeoutCancel
+	LimitTimeoutMarket = decision.LimitTimeoutMarket
+)
+
+// 窗口外成交处理策略
+const (
+	OutOfWindowDrop = "drop" // 丢弃早于 since 的成交（防止去重记录清理后重复处理旧成交）
+	OutOfWindowKeep = "keep" // 照常处理（仍记录日志）
+)
+
+// 跟单主动平仓原因（decision.Decision.CloseReason）
+const (
+	CloseReasonMaxHold  = "max_hold"  // 超过最大持仓时间
+	CloseReasonScaleOut = "scale_out" // 分批止盈收尾：平掉减仓后剩余的零头
+)
+
+// 领航员平仓的触发来源（Fill.CloseTrigger / decision.Decision.CloseTrigger），数据源能识别时才标记
+const (
+	CloseTriggerTakeProfit  = "take_profit" // 止盈触发单
+	CloseTriggerStopLoss    = "stop_loss"   // 止损触发单
+	CloseTriggerLiquidation = "liquidation" // 强平
+)
+
 // Warning 预警记录
 type Warning struct {
 	Timestamp    time.Time `json:"timestamp"`
@@ -108,15 +365,37 @@ type Warning struct {
 	Executed     bool      `json:"executed"` // 预警不阻止执行，始终为 true
 }
 
+// 轮询模式拉取间隔
+const (
+	DefaultPollInterval = 3 * time.Second
+	MinPollInterval     = 1 * time.Second
+)
+
 // EngineStats 引擎统计
 type EngineStats struct {
-	SignalsReceived    int64     `json:"signals_received"`
-	SignalsFollowed    int64     `json:"signals_followed"`
-	SignalsSkipped     int64     `json:"signals_skipped"`
-	DecisionsGenerated int64     `json:"decisions_generated"`
-	WarningsCount      int64     `json:"warnings_count"`
-	LastSignalTime     time.Time `json:"last_signal_time"`
-	StartTime          time.Time `json:"start_time"`
+	SignalsReceived       int64     `json:"signals_received"`
+	SignalsDeduped        int64     `json:"signals_deduped"` // 已处理过被去重丢弃的成交（相对 received 偏高说明轮询窗口重叠或数据源重复推送）
+	SignalsFollowed       int64     `json:"signals_followed"`
+	SignalsSkipped        int64     `json:"signals_skipped"`
+	DecisionsGenerated    int64     `json:"decisions_generated"`
+	WarningsCount         int64     `json:"warnings_count"`
+	TrialOpens            int       `json:"trial_opens"`              // 试用模式下已跟随的开仓数
+	CloseOnly             bool      `json:"close_only"`               // 只平仓模式（试用额度用完）
+	Degraded              bool      `json:"degraded"`                 // 降级模式：领航员状态不可用，暂不处理成交
+	DegradedReason        string    `json:"degraded_reason"`          // 降级原因（最近一次失败）
+	ReconcileActions      int64     `json:"reconcile_actions"`        // 重连对账补跟的动作数（断线期间错过的开/加/减/平仓）
+	Reconnects            int64     `json:"reconnects"`               // 流式连接重连次数
+	ExecutionsSucceeded   int64     `json:"executions_succeeded"`     // 决策执行成功次数
+	ExecutionsFailed      int64     `json:"executions_failed"`        // 决策执行失败次数
+	DedupAnomalies        int64     `json:"dedup_anomalies"`          // 去重异常（账户级去重冲突、流式模式重复推送）
+	DecisionsDropped      int64     `json:"decisions_dropped"`        // 决策通道已满被丢弃的决策数
+	DecisionStalled       bool      `json:"decision_stalled"`         // 决策通道持续满载，决策消费者可能已失效
+	OpensSuppressed       int64     `json:"opens_suppressed"`         // 达到最大持仓数未跟随的开仓数
+	Paused                bool      `json:"paused"`                   // 已暂停：连接和状态同步照常，不生成决策
+	TotalCopiedNotional   float64   `json:"total_copied_notional"`    // 累计跟随的开仓/加仓金额 (USDT)
+	AvgExecutionLatencyMs float64   `json:"avg_execution_latency_ms"` // 执行成功决策的平均下单耗时（毫秒）
+	LastSignalTime        time.Time `json:"last_signal_time"`
+	StartTime             time.Time `json:"start_time"`
 }
 
 // PositionKey 生成仓位的唯一键 (不含保证金模式，向后兼容)
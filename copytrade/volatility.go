@@ -0,0 +1,123 @@
+package copytrade
+
+import (
+	"sync"
+	"time"
+
+	"nofx/logger"
+)
+
+// ============================================================================
+// 高波动降杠杆
+// ============================================================================
+//
+// ⚠️ 开启后跟随者杠杆会按设计低于领航员杠杆（同步杠杆时也一样），仓位金额不变，只降低杠杆。
+//
+// 波动率按币种最近一段时间（volatilityWindow）观察到的价格计算：(最高 - 最低) / 最新价。
+// 价格样本来自领航员成交价和领航员持仓的标记价（每次状态同步时记录）。
+// 波动率超过参考值时，杠杆按 参考值 / 波动率 等比缩小（向下取整，最低 1x）；
+// 样本不足时不调整。
+
+// volatilityWindow 波动率计算窗口
+const volatilityWindow = time.Hour
+
+// volatilityMinSamples 计算波动率所需的最少价格样本数
+const volatilityMinSamples = 3
+
+// volatilityMaxSamples 每个币种最多保留的价格样本数
+const volatilityMaxSamples = 500
+
+// DefaultVolatilityReference 默认参考波动率（窗口内价格振幅 5%）
+const DefaultVolatilityReference = 0.05
+
+// priceSample 价格样本
+type priceSample struct {
+	price float64
+	at    time.Time
+}
+
+// volatilityTracker 按币种记录最近的价格样本
+type volatilityTracker struct {
+	mu      sync.Mutex
+	samples map[string][]priceSample
+}
+
+// recordPrice 记录币种价格样本（丢弃窗口外的旧样本）
+func (e *Engine) recordPrice(symbol string, price float64, at time.Time) {
+	if symbol == "" || price <= 0 {
+		return
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	v := &e.volatility
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.samples == nil {
+		v.samples = make(map[string][]priceSample)
+	}
+
+	cutoff := time.Now().Add(-volatilityWindow)
+	samples := v.samples[symbol]
+	start := 0
+	for start < len(samples) && samples[start].at.Before(cutoff) {
+		start++
+	}
+	samples = append(samples[start:], priceSample{price: price, at: at})
+	if len(samples) > volatilityMaxSamples {
+		samples = samples[len(samples)-volatilityMaxSamples:]
+	}
+	v.samples[symbol] = samples
+}
+
+// symbolVolatility 币种窗口内的价格振幅（(最高-最低)/最新价），样本不足返回 0
+func (e *Engine) symbolVolatility(symbol string) float64 {
+	v := &e.volatility
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	cutoff := time.Now().Add(-volatilityWindow)
+	var high, low, last float64
+	n := 0
+	for _, s := range v.samples[symbol] {
+		if s.at.Before(cutoff) {
+			continue
+		}
+		if n == 0 || s.price > high {
+			high = s.price
+		}
+		if n == 0 || s.price < low {
+			low = s.price
+		}
+		last = s.price
+		n++
+	}
+	if n < volatilityMinSamples || last <= 0 {
+		return 0
+	}
+	return (high - low) / last
+}
+
+// scaleLeverageForVolatility 高波动时按 参考值/波动率 降低杠杆（未开启或波动未超过参考值时原样返回）
+func (e *Engine) scaleLeverageForVolatility(symbol string, leverage int) int {
+	if !e.cfg().VolatilityLeverageScaling || leverage <= 1 {
+		return leverage
+	}
+	reference := e.cfg().VolatilityReference
+	if reference <= 0 {
+		reference = DefaultVolatilityReference
+	}
+	vol := e.symbolVolatility(symbol)
+	if vol <= reference {
+		return leverage
+	}
+
+	scaled := int(float64(leverage) * reference / vol)
+	if scaled < 1 {
+		scaled = 1
+	}
+	logger.Infof("🌊 [%s] %s 高波动降杠杆 | 波动率=%.2f%% 参考=%.2f%% | %dx → %dx",
+		e.traderID, symbol, vol*100, reference*100, leverage, scaled)
+	return scaled
+}
@@ -0,0 +1,65 @@
+package copytrade
+
+import (
+	"testing"
+	"time"
+)
+
+// TestVolatilityLeverageScaling 振幅超过参考值时按比例降杠杆，样本不足/未开启/窗口外样本不调整
+func TestVolatilityLeverageScaling(t *testing.T) {
+	now := time.Now()
+	record := func(e *Engine, symbol string, at time.Time, prices ...float64) {
+		for _, p := range prices {
+			e.recordPrice(symbol, p, at)
+		}
+	}
+
+	e := newTestEngine(&CopyConfig{SyncLeverage: true, VolatilityLeverageScaling: true, VolatilityReference: 0.05}, 1000)
+
+	// 振幅 (110-90)/100 = 20% → 10x × 0.05/0.2 = 2x
+	record(e, "BTCUSDT", now, 100, 110, 90, 100)
+	if got := e.scaleLeverageForVolatility("BTCUSDT", 10); got != 2 {
+		t.Errorf("high volatility leverage = %d, want 2", got)
+	}
+
+	// 振幅 2% 未超过参考值
+	record(e, "ETHUSDT", now, 100, 101, 99, 100)
+	if got := e.scaleLeverageForVolatility("ETHUSDT", 10); got != 10 {
+		t.Errorf("low volatility leverage = %d, want 10", got)
+	}
+
+	// 样本不足
+	record(e, "SOLUSDT", now, 100, 150)
+	if got := e.scaleLeverageForVolatility("SOLUSDT", 10); got != 10 {
+		t.Errorf("insufficient samples leverage = %d, want 10", got)
+	}
+
+	// 窗口外的样本不计入
+	record(e, "XRPUSDT", now.Add(-2*volatilityWindow), 50, 150)
+	record(e, "XRPUSDT", now, 100, 100, 100)
+	if got := e.scaleLeverageForVolatility("XRPUSDT", 10); got != 10 {
+		t.Errorf("stale samples leverage = %d, want 10", got)
+	}
+
+	// 极端波动最低 1x
+	record(e, "DOGEUSDT", now, 100, 300, 50, 100)
+	if got := e.scaleLeverageForVolatility("DOGEUSDT", 5); got != 1 {
+		t.Errorf("extreme volatility leverage = %d, want 1", got)
+	}
+
+	// 未开启
+	withConfig(e, func(c *CopyConfig) { c.VolatilityLeverageScaling = false })
+	if got := e.scaleLeverageForVolatility("BTCUSDT", 10); got != 10 {
+		t.Errorf("disabled leverage = %d, want 10", got)
+	}
+
+	// getLeaderLeverage 使用领航员杠杆后再降
+	withConfig(e, func(c *CopyConfig) { c.VolatilityLeverageScaling = true })
+	signal := &TradeSignal{
+		Fill:           &Fill{Symbol: "BTCUSDT", PositionSide: SideLong},
+		LeaderPosition: &Position{Symbol: "BTCUSDT", Side: SideLong, Leverage: 20},
+	}
+	if got := e.getLeaderLeverage(signal); got != 5 {
+		t.Errorf("getLeaderLeverage = %d, want 5", got)
+	}
+}
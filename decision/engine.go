@@ -2,6 +2,7 @@ package decision
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -150,9 +151,33 @@ type Decision struct {
 
 	// 跟单专用字段
 	LeaderPosID   string  `json:"leader_pos_id,omitempty"`   // 领航员仓位 ID（用于映射追踪）
+	LeaderID      string  `json:"leader_id,omitempty"`       // 领航员 ID（多领航员跟单时区分决策来源）
 	LeaderPosSize float64 `json:"leader_pos_size,omitempty"` // 领航员当前持仓数量（用于 lastKnownSize 追踪）
+	CloseReason   string  `json:"close_reason,omitempty"`    // 跟单主动平仓原因（如 max_hold），为空表示跟随领航员
+	CloseTrigger  string  `json:"close_trigger,omitempty"`   // 领航员平仓的触发来源（take_profit | stop_loss | liquidation），为空表示手动/未知
+
+	// 入场订单类型（为空=市价）：限价时以 LimitPrice 挂单，超时未成交按 LimitTimeoutAction 处理
+	OrderType          string  `json:"order_type,omitempty"`           // "market" | "limit"
+	LimitPrice         float64 `json:"limit_price,omitempty"`          // 限价入场价格
+	LimitTimeoutSec    int     `json:"limit_timeout_sec,omitempty"`    // 限价单等待成交时间（秒）
+	LimitTimeoutAction string  `json:"limit_timeout_action,omitempty"` // 超时处理："cancel"(撤单放弃) | "market"(剩余部分转市价)
+
+	// 决策组：同一 GroupID 的决策作为整体执行（如开仓 + 保护性止损），组内失败时跳过或回滚
+	GroupID string `json:"group_id,omitempty"`
 }
 
+// 入场订单类型与限价超时处理
+const (
+	OrderTypeMarket = "market"
+	OrderTypeLimit  = "limit"
+
+	LimitTimeoutCancel = "cancel"
+	LimitTimeoutMarket = "market"
+)
+
+// ErrLimitNotFilled 限价入场单超时未成交且已撤单（未建立任何仓位）
+var ErrLimitNotFilled = errors.New("limit entry order not filled before timeout")
+
 // FullDecision AI's complete decision (including chain of thought)
 type FullDecision struct {
 	SystemPrompt        string     `json:"system_prompt"`
@@ -5,6 +5,7 @@ import (
 	"nofx/auth"
 	"nofx/backtest"
 	"nofx/config"
+	"nofx/copytrade"
 	"nofx/crypto"
 	"nofx/logger"
 	"nofx/manager"
@@ -57,6 +58,11 @@ func main() {
 	}
 	defer st.Close()
 	backtest.UseDatabase(st.DB())
+	if cfg.DashboardReadConns > 0 {
+		if err := st.OpenReadDB(cfg.DashboardReadConns); err != nil {
+			logger.Warnf("⚠️ Failed to open read-only database, dashboard will share the main connection: %v", err)
+		}
+	}
 
 	// Initialize encryption service
 	logger.Info("🔐 Initializing encryption service...")
@@ -96,6 +102,10 @@ func main() {
 	auth.SetJWTSecret(cfg.JWTSecret)
 	logger.Info("🔑 JWT secret configured")
 
+	if cfg.OKXRequestsPerSecond > 0 {
+		copytrade.SetOKXRateLimit(cfg.OKXRequestsPerSecond)
+	}
+
 	// Start WebSocket market monitor FIRST (before loading traders that may need market data)
 	// This ensures WSMonitorCli is initialized before any trader tries to access it
 	go market.NewWSMonitor(150).Start(nil)
@@ -116,6 +126,11 @@ func main() {
 	positionSyncManager.Start()
 	defer positionSyncManager.Stop()
 
+	// Roll up old copy trade signal logs into hourly counts
+	if cfg.SignalLogRetentionHours > 0 {
+		go compactSignalLogs(st, time.Duration(cfg.SignalLogRetentionHours)*time.Hour)
+	}
+
 	// Load all traders from database to memory (may auto-start traders with IsRunning=true)
 	if err := traderManager.LoadTradersFromStore(st); err != nil {
 		logger.Fatalf("❌ Failed to load traders: %v", err)
@@ -164,6 +179,22 @@ func main() {
 	logger.Info("✅ System shut down safely")
 }
 
+// compactSignalLogs rolls up signal logs older than retention once an hour
+func compactSignalLogs(st *store.Store, retention time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := st.CopyTrade().CompactSignalLogs(time.Now().Add(-retention))
+		if err != nil {
+			logger.Warnf("⚠️ Failed to compact copy trade signal logs: %v", err)
+		} else if deleted > 0 {
+			logger.Infof("🧹 Compacted %d copy trade signal logs into hourly rollups", deleted)
+		}
+		<-ticker.C
+	}
+}
+
 // newSharedMCPClient creates a shared MCP AI client (for backtesting)
 func newSharedMCPClient() mcp.AIClient {
 	apiKey := os.Getenv("DEEPSEEK_API_KEY")
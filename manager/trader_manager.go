@@ -55,6 +55,26 @@ func (a *CopyTradeExecutorAdapter) GetPositions() ([]map[string]interface{}, err
 	return a.autoTrader.GetPositions()
 }
 
+// SupportsSymbol checks whether the exchange lists the symbol (implements copytrade.SymbolSupportChecker)
+func (a *CopyTradeExecutorAdapter) SupportsSymbol(symbol string) (bool, error) {
+	return a.autoTrader.SupportsSymbol(symbol)
+}
+
+// MinOrderQuantity returns the exchange minimum order quantity (implements copytrade.MinQuantityChecker)
+func (a *CopyTradeExecutorAdapter) MinOrderQuantity(symbol string) (float64, error) {
+	return a.autoTrader.MinOrderQuantity(symbol)
+}
+
+// SetLeverage sets the symbol leverage (implements copytrade.PositionSettingsExecutor)
+func (a *CopyTradeExecutorAdapter) SetLeverage(symbol string, leverage int) error {
+	return a.autoTrader.SetLeverage(symbol, leverage)
+}
+
+// SetMarginMode sets the symbol margin mode (implements copytrade.PositionSettingsExecutor)
+func (a *CopyTradeExecutorAdapter) SetMarginMode(symbol string, isCrossMargin bool) error {
+	return a.autoTrader.SetMarginMode(symbol, isCrossMargin)
+}
+
 // CompetitionCache competition data cache
 type CompetitionCache struct {
 	data      map[string]interface{}
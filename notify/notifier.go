@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"nofx/logger"
+)
+
+// Message levels
+const (
+	LevelInfo     = "info"
+	LevelWarning  = "warning"
+	LevelCritical = "critical"
+)
+
+// Message a notification sent to operators
+type Message struct {
+	Level    string // info | warning | critical
+	Title    string
+	Text     string
+	TraderID string
+}
+
+// Notifier delivers notifications to an external channel (Telegram, webhook, ...)
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// NotifierFunc function adapter
+type NotifierFunc func(ctx context.Context, msg Message) error
+
+// Notify implements Notifier
+func (f NotifierFunc) Notify(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// ============================================================================
+// Telegram
+// ============================================================================
+
+// TelegramAPIBase Telegram Bot API endpoint
+const TelegramAPIBase = "https://api.telegram.org"
+
+// TelegramNotifier sends messages through a Telegram bot
+type TelegramNotifier struct {
+	BotToken string
+	ChatID   string
+	APIBase  string // Defaults to TelegramAPIBase (overridable for tests/proxies)
+	Client   *http.Client
+}
+
+// NewTelegramNotifier creates a Telegram notifier
+func NewTelegramNotifier(botToken, chatID string) *TelegramNotifier {
+	return &TelegramNotifier{
+		BotToken: botToken,
+		ChatID:   chatID,
+		APIBase:  TelegramAPIBase,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify implements Notifier
+func (t *TelegramNotifier) Notify(ctx context.Context, msg Message) error {
+	if t.BotToken == "" || t.ChatID == "" {
+		return fmt.Errorf("telegram bot token and chat id are required")
+	}
+	body, err := json.Marshal(map[string]string{
+		"chat_id": t.ChatID,
+		"text":    FormatText(msg),
+	})
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimRight(t.APIBase, "/"), t.BotToken)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Never leak the bot token through the request URL in error messages
+		return fmt.Errorf("telegram request failed: %s", strings.ReplaceAll(err.Error(), t.BotToken, "***"))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("telegram returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+// FormatText renders a message as plain text
+func FormatText(msg Message) string {
+	icon := "ℹ️"
+	switch msg.Level {
+	case LevelWarning:
+		icon = "⚠️"
+	case LevelCritical:
+		icon = "🚨"
+	}
+	text := icon + " " + msg.Title
+	if msg.Text != "" {
+		text += "\n" + msg.Text
+	}
+	return text
+}
+
+// ============================================================================
+// Non-blocking delivery
+// ============================================================================
+
+// SendTimeout upper bound for a single delivery
+const SendTimeout = 15 * time.Second
+
+// maxInFlight deliveries running concurrently; further messages are dropped
+const maxInFlight = 16
+
+var inFlight = make(chan struct{}, maxInFlight)
+
+// Dispatch delivers msg in the background and returns immediately.
+// When too many deliveries are pending the message is dropped, so a slow
+// channel can never delay the caller (e.g. trade execution).
+func Dispatch(n Notifier, msg Message) {
+	if n == nil {
+		return
+	}
+	select {
+	case inFlight <- struct{}{}:
+	default:
+		logger.Warnf("⚠️ Notification dropped (too many pending): %s", msg.Title)
+		return
+	}
+	go func() {
+		defer func() { <-inFlight }()
+		ctx, cancel := context.WithTimeout(context.Background(), SendTimeout)
+		defer cancel()
+		if err := n.Notify(ctx, msg); err != nil {
+			logger.Warnf("⚠️ Notification failed: %v", err)
+		}
+	}()
+}
+
+// ============================================================================
+// Repeat suppression
+// ============================================================================
+
+// Throttle suppresses repeated notifications for the same key within a window.
+// Alerts are recalculated on every dashboard refresh; without throttling an
+// active alert would be sent again on each poll.
+type Throttle struct {
+	mu     sync.Mutex
+	window time.Duration
+	sentAt map[string]time.Time
+}
+
+// NewThrottle creates a throttle with the given suppression window
+func NewThrottle(window time.Duration) *Throttle {
+	return &Throttle{window: window, sentAt: make(map[string]time.Time)}
+}
+
+// Allow reports whether a notification for key may be sent now, and records it
+func (t *Throttle) Allow(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if last, ok := t.sentAt[key]; ok && now.Sub(last) < t.window {
+		return false
+	}
+	t.sentAt[key] = now
+	return true
+}
@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTelegramNotifier(t *testing.T) {
+	var gotPath string
+	var gotBody map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		if gotBody["chat_id"] == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"ok":false,"description":"chat not found"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	n := NewTelegramNotifier("123:abc", "42")
+	n.APIBase = srv.URL
+	err := n.Notify(context.Background(), Message{Level: LevelCritical, Title: "drawdown", Text: "45%"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/bot123:abc/sendMessage" {
+		t.Errorf("path = %s", gotPath)
+	}
+	if gotBody["chat_id"] != "42" || gotBody["text"] != "🚨 drawdown\n45%" {
+		t.Errorf("body = %+v", gotBody)
+	}
+
+	n.ChatID = "bad"
+	if err := n.Notify(context.Background(), Message{Title: "x"}); err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Errorf("err = %v, want telegram error", err)
+	}
+
+	if err := NewTelegramNotifier("", "42").Notify(context.Background(), Message{}); err == nil {
+		t.Error("missing token accepted")
+	}
+}
+
+func TestDispatchDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	slow := NotifierFunc(func(ctx context.Context, msg Message) error {
+		<-release
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < maxInFlight*2; i++ {
+			Dispatch(slow, Message{Title: "x"})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Dispatch blocked on a slow notifier")
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	th := NewThrottle(time.Hour)
+	if !th.Allow("a") {
+		t.Fatal("first notification throttled")
+	}
+	if th.Allow("a") {
+		t.Error("repeat within window allowed")
+	}
+	if !th.Allow("b") {
+		t.Error("different key throttled")
+	}
+}
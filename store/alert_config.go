@@ -0,0 +1,118 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Risk alert types (same as RiskAlert.Type on the dashboard)
+const (
+	AlertTypeConsecutiveLoss = "consecutive_loss"
+	AlertTypeLowWinRate      = "low_win_rate"
+	AlertTypeMaxDrawdown     = "max_drawdown"
+	AlertTypeAPIError        = "api_error"
+)
+
+// TraderAlertConfig per-trader risk alert configuration, stored as JSON in traders.alert_config.
+// Zero-valued thresholds fall back to the defaults, so an empty config behaves as before.
+type TraderAlertConfig struct {
+	DisabledTypes []string `json:"disabled_types,omitempty"` // Alert types that should not fire for this trader
+
+	ConsecutiveLossWarning  int     `json:"consecutive_loss_warning,omitempty"`  // Consecutive losing trades for a warning (default 3)
+	ConsecutiveLossCritical int     `json:"consecutive_loss_critical,omitempty"` // Consecutive losing trades for critical (default 5)
+	MinWinRatePct           float64 `json:"min_win_rate_pct,omitempty"`          // Alert when win rate falls below this % (default 30)
+	MinTradesForWinRate     int     `json:"min_trades_for_win_rate,omitempty"`   // Closed trades required before win rate is checked (default 10)
+	MaxDrawdownWarningPct   float64 `json:"max_drawdown_warning_pct,omitempty"`  // Drawdown % for a warning (default 20)
+	MaxDrawdownCriticalPct  float64 `json:"max_drawdown_critical_pct,omitempty"` // Drawdown % for critical (default 40)
+	APIErrorsPerHour        int     `json:"api_errors_per_hour,omitempty"`       // Failed copy signals within 1h before alerting (default 5)
+}
+
+// ValidAlertTypes alert types that can be configured per trader
+var ValidAlertTypes = []string{AlertTypeConsecutiveLoss, AlertTypeLowWinRate, AlertTypeMaxDrawdown, AlertTypeAPIError}
+
+// DefaultTraderAlertConfig returns the built-in alert thresholds
+func DefaultTraderAlertConfig() TraderAlertConfig {
+	return TraderAlertConfig{
+		ConsecutiveLossWarning:  3,
+		ConsecutiveLossCritical: 5,
+		MinWinRatePct:           30,
+		MinTradesForWinRate:     10,
+		MaxDrawdownWarningPct:   20,
+		MaxDrawdownCriticalPct:  40,
+		APIErrorsPerHour:        5,
+	}
+}
+
+// WithDefaults returns a copy with unset thresholds filled from DefaultTraderAlertConfig
+func (c TraderAlertConfig) WithDefaults() TraderAlertConfig {
+	d := DefaultTraderAlertConfig()
+	if c.ConsecutiveLossWarning <= 0 {
+		c.ConsecutiveLossWarning = d.ConsecutiveLossWarning
+	}
+	if c.ConsecutiveLossCritical <= 0 {
+		c.ConsecutiveLossCritical = d.ConsecutiveLossCritical
+	}
+	if c.MinWinRatePct <= 0 {
+		c.MinWinRatePct = d.MinWinRatePct
+	}
+	if c.MinTradesForWinRate <= 0 {
+		c.MinTradesForWinRate = d.MinTradesForWinRate
+	}
+	if c.MaxDrawdownWarningPct <= 0 {
+		c.MaxDrawdownWarningPct = d.MaxDrawdownWarningPct
+	}
+	if c.MaxDrawdownCriticalPct <= 0 {
+		c.MaxDrawdownCriticalPct = d.MaxDrawdownCriticalPct
+	}
+	if c.APIErrorsPerHour <= 0 {
+		c.APIErrorsPerHour = d.APIErrorsPerHour
+	}
+	return c
+}
+
+// IsEnabled reports whether the given alert type is active for this trader
+func (c TraderAlertConfig) IsEnabled(alertType string) bool {
+	for _, t := range c.DisabledTypes {
+		if t == alertType {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseTraderAlertConfig parses the stored JSON; empty input yields the defaults
+func ParseTraderAlertConfig(raw string) (TraderAlertConfig, error) {
+	var cfg TraderAlertConfig
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+			return DefaultTraderAlertConfig(), fmt.Errorf("failed to parse alert config: %w", err)
+		}
+	}
+	return cfg.WithDefaults(), nil
+}
+
+// GetAlertConfig gets a trader's alert configuration with defaults applied
+func (s *TraderStore) GetAlertConfig(userID, traderID string) (TraderAlertConfig, error) {
+	var raw string
+	err := s.db.QueryRow(`SELECT COALESCE(alert_config, '') FROM traders WHERE id = ? AND user_id = ?`, traderID, userID).Scan(&raw)
+	if err != nil {
+		return DefaultTraderAlertConfig(), err
+	}
+	return ParseTraderAlertConfig(raw)
+}
+
+// UpdateAlertConfig updates a trader's alert configuration
+func (s *TraderStore) UpdateAlertConfig(userID, id string, cfg TraderAlertConfig) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.Exec(`UPDATE traders SET alert_config = ? WHERE id = ? AND user_id = ?`, string(data), id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("trader not found: %s", id)
+	}
+	return nil
+}
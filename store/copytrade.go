@@ -3,6 +3,8 @@ package store
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -23,10 +25,169 @@ type CopyTradeConfig struct {
 	MaxTradeWarn   float64 `json:"max_trade_warn"`   // 大额预警阈值 (0=不预警)
 	Enabled        bool    `json:"enabled"`          // 是否启用
 
+	// 高级选项（JSON 存储在 options_json 列，新增选项无需改表）
+	Options CopyTradeOptions `json:"options"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// CopyTradeOptions 跟单高级选项
+// 所有字段零值即默认行为，旧配置无需迁移
+type CopyTradeOptions struct {
+	// 领航员权益 <= 0 时的处理策略: "skip"(默认) | "fixed_notional" | "equity_override"
+	ZeroEquityPolicy     string  `json:"zero_equity_policy,omitempty"`
+	FixedNotional        float64 `json:"fixed_notional,omitempty"`         // 固定跟单金额 (USDT)
+	LeaderEquityOverride float64 `json:"leader_equity_override,omitempty"` // 手动指定的领航员权益 (USDT)
+
+	MaxHoldHours    int     `json:"max_hold_hours,omitempty"`    // 最大持仓时间（小时，0=不限制）
+	TrialTradeLimit int     `json:"trial_trade_limit,omitempty"` // 试用模式：只跟随前 N 笔开仓（0=不限制）
+	LeaderBudget    float64 `json:"leader_budget,omitempty"`     // 领航员资金预算 (USDT，0=不限制)
+
+	DriftCheckMinutes int    `json:"drift_check_minutes,omitempty"` // 仓位映射一致性检查间隔（分钟，0=默认，<0=关闭）
+	ReduceMode        string `json:"reduce_mode,omitempty"`         // 减仓模式："ratio"(默认) | "scaled_absolute"
+	OutOfWindowFills  string `json:"out_of_window_fills,omitempty"` // 窗口外成交处理："drop"(默认) | "keep"
+	Profiling         bool   `json:"profiling,omitempty"`           // 性能诊断：统计关键路径耗时
+
+	ReopenCooldownSeconds int `json:"reopen_cooldown_seconds,omitempty"` // 平仓后快速重新开仓冷却（秒，0=不限制）
+
+	TradingWindows []CopyTradeTimeWindow `json:"trading_windows,omitempty"` // 交易时段（为空=全天）
+	Timezone       string                `json:"timezone,omitempty"`        // 交易时段时区（IANA，空=UTC）
+
+	StateUnavailablePolicy     string `json:"state_unavailable_policy,omitempty"`      // 启动时领航员状态不可用："refuse"(默认) | "degraded"
+	DuplicateDecisionWindowSec int    `json:"duplicate_decision_window_sec,omitempty"` // 重复决策保护窗口（秒，0=关闭）
+	ClosedPnLInference         bool   `json:"closed_pnl_inference,omitempty"`          // 方向不确定时用 ClosedPnL 辅助判断开/平
+
+	Shadow *CopyTradeShadowOptions `json:"shadow,omitempty"` // 影子跟单：用另一组参数模拟记录（不下单）
+
+	EntryOrderType      string `json:"entry_order_type,omitempty"`      // 入场订单类型："market"(默认) | "limit"(领航员成交价)
+	LimitTimeoutSeconds int    `json:"limit_timeout_seconds,omitempty"` // 限价单等待成交时间（秒，0=默认 30）
+	LimitTimeoutAction  string `json:"limit_timeout_action,omitempty"`  // 限价超时处理："cancel"(默认) | "market"
+	FreshEquityOnOpen   bool   `json:"fresh_equity_on_open,omitempty"`  // 开仓前同步刷新领航员权益（绕过缓存）
+
+	UnlistedSymbolPolicy string `json:"unlisted_symbol_policy,omitempty"` // 跟随者交易所未上架的币种："skip"(默认) | "attempt"
+	StrictAddMatching    bool   `json:"strict_add_matching,omitempty"`    // 严格加仓匹配：加仓目标不确定时跳过（不兜底到唯一 active 仓位）
+	NegativeEquityPolicy string `json:"negative_equity_policy,omitempty"` // 跟随者权益 <= 0："exits_only"(默认) | "pause_all"
+
+	SymbolMaxBaseSize map[string]float64 `json:"symbol_max_base_size,omitempty"` // 单币种最大持仓（基础币数量，key 为 BTCUSDT 格式）
+
+	DedupScope  string `json:"dedup_scope,omitempty"`  // 去重范围："engine"(默认) | "account"(同一交易所账户的 trader 共享)
+	InitialSync string `json:"initial_sync,omitempty"` // 启动时领航员已有仓位："ignore"(默认) | "mirror"(按比例跟开)
+
+	ReconnectReconcile string `json:"reconnect_reconcile,omitempty"` // WebSocket 重连后："reconcile"(默认，补跟断线期间的变化) | "off"
+	ReduceMinPolicy    string `json:"reduce_min_policy,omitempty"`   // 减仓数量低于最小下单量："bump"(默认，提高到最小下单量) | "close"(全量平仓)
+	ConfirmAddHolding  bool   `json:"confirm_add_holding,omitempty"` // 加仓前绕过缓存确认领航员仍持仓，否则放弃加仓
+
+	WarningHistoryLimit int `json:"warning_history_limit,omitempty"` // 预警记录每个 trader 保留条数（0=默认 1000，<0=不保存）
+
+	VolatilityLeverageScaling bool    `json:"volatility_leverage_scaling,omitempty"` // 高波动降杠杆（跟随者杠杆可能低于领航员）
+	VolatilityReference       float64 `json:"volatility_reference,omitempty"`        // 参考波动率：近 1 小时价格振幅比例（0=默认 0.05）
+
+	OKXInstrumentMap map[string]string `json:"okx_instrument_map,omitempty"` // OKX 非 USDT 计价合约 → 跟随者币种（如 BTC-USD-SWAP → BTCUSDT），未映射的跳过
+	EmptyStatePolicy string            `json:"empty_state_policy,omitempty"` // 可疑空持仓状态："refetch"(默认，重新拉取一次) | "trust"
+
+	AdaptiveRatio *CopyTradeAdaptiveRatioOptions `json:"adaptive_ratio,omitempty"` // 自适应跟单系数：按领航员近期表现缩放 copy_ratio（默认关闭）
+
+	CloseMatchStrategy string  `json:"close_match_strategy,omitempty"` // 多仓位平仓/减仓配对："best_fit"(默认) | "first"
+	MinAccountEquity   float64 `json:"min_account_equity,omitempty"`   // 启动跟单的最低账户权益（0=默认 最小跟单金额×10，<0=不检查）
+	AddSizingMode      string  `json:"add_sizing_mode,omitempty"`      // 加仓金额计算："fill_value"(默认) | "match_increase_ratio"(按领航员加仓倍数放大现有仓位)
+
+	HealthWeights      *CopyTradeHealthWeightsOptions `json:"health_weights,omitempty"`       // 引擎健康度评分权重（未配置使用默认值）
+	PhantomClosePolicy string                         `json:"phantom_close_policy,omitempty"` // 领航员仓位消失但无平仓成交（疑似仓位划转）："mirror"(默认，按平仓跟随) | "ignore"(继续持有)
+
+	EquitySnapshotIntervalSeconds int `json:"equity_snapshot_interval_seconds,omitempty"` // 权益快照最小写入间隔（秒，0=默认 10，<0=每次执行都写入）
+
+	CrossCheckState           bool    `json:"cross_check_state,omitempty"`            // 流式模式定时用 REST 交叉校验领航员状态
+	CrossCheckIntervalSeconds int     `json:"cross_check_interval_seconds,omitempty"` // 交叉校验间隔（秒，0=默认 60）
+	CrossCheckThreshold       float64 `json:"cross_check_threshold,omitempty"`        // 持仓数量相对差异阈值（0=默认 0.05）
+
+	MaxMarginFraction float64 `json:"max_margin_fraction,omitempty"` // 单笔开仓/加仓占用保证金上限：可用保证金的比例（0=不限制，与 max_trade_warn 预警不同，会阻止）
+	MarginCapPolicy   string  `json:"margin_cap_policy,omitempty"`   // 超出保证金上限："clamp"(默认，缩小到上限) | "skip"(跳过信号)
+
+	ProtectiveStopPct float64 `json:"protective_stop_pct,omitempty"` // 新开仓保护性止损距成交价比例（0=不挂，0.05=5%），止损失败时回滚开仓
+
+	SymbolEnabled map[string]bool `json:"symbol_enabled,omitempty"` // 按币种暂停跟随（false=不开仓/加仓，未列出=跟随），可通过 /api/copytrade/symbol 运行时修改
+
+	DecisionStallSeconds int    `json:"decision_stall_seconds,omitempty"` // 决策通道持续满载多久判定为消费者失效（秒，0=默认 30）
+	DecisionStallPolicy  string `json:"decision_stall_policy,omitempty"`  // 消费者失效时："alert"(默认，只告警) | "pause"(同时暂停开仓/加仓)
+
+	MirrorLeaderOrders      bool `json:"mirror_leader_orders,omitempty"`       // 按领航员开仓挂单提前挂限价单建仓（仅 Hyperliquid，默认关闭）
+	LeaderOrdersPollSeconds int  `json:"leader_orders_poll_seconds,omitempty"` // 领航员挂单轮询间隔（秒，0=默认 15）
+
+	PollIntervalSeconds int `json:"poll_interval_seconds,omitempty"` // 轮询模式拉取成交间隔（秒，0=默认 3；多领航员跟单时调大以避免 OKX 限频）
+
+	CapAtTargetAllocation bool `json:"cap_at_target_allocation,omitempty"` // 跟随者持仓已达到按比例目标仓位时跳过加仓
+
+	// 跟单金额模式: "proportional"(默认) | "fixed"(每次开仓/加仓按 fixed_trade_usd 固定金额)
+	CopyMode      string  `json:"copy_mode,omitempty"`
+	FixedTradeUSD float64 `json:"fixed_trade_usd,omitempty"` // 固定金额模式下每次开仓/加仓的跟单金额 (USDT)
+
+	// 币种白名单/黑名单（btc / BTCUSDT 均可）：白名单非空时只跟随名单内币种，黑名单优先
+	SymbolWhitelist []string `json:"symbol_whitelist,omitempty"`
+	SymbolBlacklist []string `json:"symbol_blacklist,omitempty"`
+
+	MaxLeverage int `json:"max_leverage,omitempty"` // 跟单杠杆上限（0=不限制），同步领航员杠杆时超过上限的截断为上限
+
+	DryRun bool `json:"dry_run,omitempty"` // 模拟运行：生成决策、更新映射但不下单（信号日志状态为 dry_run）
+
+	// 多领航员跟单：非空时按列表同时跟随多个领航员（顶层 leader_id/provider_type 不再使用）
+	Leaders []CopyTradeLeaderSpec `json:"leaders,omitempty"`
+
+	Inverse bool `json:"inverse,omitempty"` // 反向跟单：领航员开多 → 跟随者开空（反之亦然），减仓/平仓作用于反方向仓位
+
+	MaxOpenPositions int `json:"max_open_positions,omitempty"` // 最大同时持有的跟单仓位数（0=不限制）
+
+	CopyDelaySeconds int `json:"copy_delay_seconds,omitempty"` // 延迟跟单秒数（0=立即跟随）：延迟期内领航员平仓则不跟随该开仓
+
+	SyncTPSL bool `json:"sync_tpsl,omitempty"` // 新开仓同步领航员仓位的止盈/止损触发价
+
+	FollowCloses *bool `json:"follow_closes,omitempty"` // 跟随领航员减仓/平仓（nil=跟随；false=只跟开仓/加仓，平仓由跟随者自行管理）
+
+	MaxSlippagePct float64 `json:"max_slippage_pct,omitempty"` // 滑点保护：当前价相对领航员成交价偏离超过该比例（0.02=2%）时不开仓/加仓（0=不检查）
+}
+
+// CopyTradeLeaderSpec 多领航员跟单中的单个领航员
+type CopyTradeLeaderSpec struct {
+	LeaderID     string  `json:"leader_id"`
+	ProviderType string  `json:"provider_type"`    // "hyperliquid" | "okx"
+	Weight       float64 `json:"weight,omitempty"` // 权重：该领航员的跟单系数 = copy_ratio × weight（0=1）
+}
+
+// CopyTradeHealthWeightsOptions 引擎健康度评分权重（0 值使用默认值）
+type CopyTradeHealthWeightsOptions struct {
+	ReconnectPenalty  float64 `json:"reconnect_penalty,omitempty"`   // 每次重连扣分（默认 5）
+	ReconnectMax      float64 `json:"reconnect_max,omitempty"`       // 重连最多扣分（默认 20）
+	StaleAfterSeconds int     `json:"stale_after_seconds,omitempty"` // 领航员状态多久未更新开始扣分（默认 120）
+	StaleMax          float64 `json:"stale_max,omitempty"`           // 状态过期最多扣分（默认 30）
+	MinExecutions     int     `json:"min_executions,omitempty"`      // 计算失败率的最少执行次数（默认 3）
+	FailureMax        float64 `json:"failure_max,omitempty"`         // 失败率 100% 时扣分（默认 30）
+	DedupPenalty      float64 `json:"dedup_penalty,omitempty"`       // 每次去重异常扣分（默认 2）
+	DedupMax          float64 `json:"dedup_max,omitempty"`           // 去重异常最多扣分（默认 20）
+}
+
+// CopyTradeAdaptiveRatioOptions 自适应跟单系数参数（0 值使用默认值）
+// 有效系数 = copy_ratio × clamp(1 + 领航员近期收益率 × sensitivity, min_multiplier, max_multiplier)
+type CopyTradeAdaptiveRatioOptions struct {
+	WindowHours   int     `json:"window_hours,omitempty"`   // 统计窗口（小时，0=168）
+	Sensitivity   float64 `json:"sensitivity,omitempty"`    // 收益率敏感度（0=5）
+	MinMultiplier float64 `json:"min_multiplier,omitempty"` // 表现系数下限（0=0.5）
+	MaxMultiplier float64 `json:"max_multiplier,omitempty"` // 表现系数上限（0=1.5）
+}
+
+// CopyTradeShadowOptions 影子跟单参数（与实盘配置对比，只记录假设结果）
+type CopyTradeShadowOptions struct {
+	Label        string  `json:"label,omitempty"`          // 影子配置名称（如 "ratio-0.5"）
+	CopyRatio    float64 `json:"copy_ratio"`               // 影子跟单系数
+	MinTradeWarn float64 `json:"min_trade_warn,omitempty"` // 影子最小跟单金额（0=与实盘相同）
+}
+
+// CopyTradeTimeWindow 交易时间窗口（"HH:MM"，Start > End 表示跨午夜）
+type CopyTradeTimeWindow struct {
+	Weekdays []int  `json:"weekdays,omitempty"` // 0=周日 … 6=周六，为空=每天
+	Start    string `json:"start"`
+	End      string `json:"end"`
+}
+
 func (s *CopyTradeStore) initTables() error {
 	// 创建跟单配置表
 	_, err := s.db.Exec(`
@@ -61,8 +222,7 @@ func (s *CopyTradeStore) initTables() error {
 		return err
 	}
 
-	// 给 traders 表添加 decision_mode 字段
-	s.db.Exec(`ALTER TABLE traders ADD COLUMN decision_mode TEXT DEFAULT 'ai'`)
+	// traders.decision_mode / options_json 等新增字段由 verifySchema 补齐
 
 	return nil
 }
@@ -72,10 +232,11 @@ func (s *CopyTradeStore) Create(config *CopyTradeConfig) error {
 	_, err := s.db.Exec(`
 		INSERT INTO copy_trade_configs 
 			(trader_id, provider_type, leader_id, copy_ratio, sync_leverage, sync_margin_mode, 
-			 min_trade_warn, max_trade_warn, enabled)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			 min_trade_warn, max_trade_warn, enabled, options_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, config.TraderID, config.ProviderType, config.LeaderID, config.CopyRatio,
-		config.SyncLeverage, config.SyncMarginMode, config.MinTradeWarn, config.MaxTradeWarn, config.Enabled)
+		config.SyncLeverage, config.SyncMarginMode, config.MinTradeWarn, config.MaxTradeWarn, config.Enabled,
+		config.Options.toJSON())
 	return err
 }
 
@@ -90,11 +251,12 @@ func (s *CopyTradeStore) Update(config *CopyTradeConfig) error {
 			sync_margin_mode = ?,
 			min_trade_warn = ?,
 			max_trade_warn = ?,
-			enabled = ?
+			enabled = ?,
+			options_json = ?
 		WHERE trader_id = ?
 	`, config.ProviderType, config.LeaderID, config.CopyRatio,
 		config.SyncLeverage, config.SyncMarginMode, config.MinTradeWarn, config.MaxTradeWarn,
-		config.Enabled, config.TraderID)
+		config.Enabled, config.Options.toJSON(), config.TraderID)
 	return err
 }
 
@@ -103,8 +265,8 @@ func (s *CopyTradeStore) Upsert(config *CopyTradeConfig) error {
 	_, err := s.db.Exec(`
 		INSERT INTO copy_trade_configs 
 			(trader_id, provider_type, leader_id, copy_ratio, sync_leverage, sync_margin_mode, 
-			 min_trade_warn, max_trade_warn, enabled)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			 min_trade_warn, max_trade_warn, enabled, options_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(trader_id) DO UPDATE SET
 			provider_type = excluded.provider_type,
 			leader_id = excluded.leader_id,
@@ -113,9 +275,11 @@ func (s *CopyTradeStore) Upsert(config *CopyTradeConfig) error {
 			sync_margin_mode = excluded.sync_margin_mode,
 			min_trade_warn = excluded.min_trade_warn,
 			max_trade_warn = excluded.max_trade_warn,
-			enabled = excluded.enabled
+			enabled = excluded.enabled,
+			options_json = excluded.options_json
 	`, config.TraderID, config.ProviderType, config.LeaderID, config.CopyRatio,
-		config.SyncLeverage, config.SyncMarginMode, config.MinTradeWarn, config.MaxTradeWarn, config.Enabled)
+		config.SyncLeverage, config.SyncMarginMode, config.MinTradeWarn, config.MaxTradeWarn, config.Enabled,
+		config.Options.toJSON())
 	return err
 }
 
@@ -128,21 +292,22 @@ func (s *CopyTradeStore) Delete(traderID string) error {
 // GetByTraderID 根据 trader_id 获取跟单配置
 func (s *CopyTradeStore) GetByTraderID(traderID string) (*CopyTradeConfig, error) {
 	var config CopyTradeConfig
-	var createdAt, updatedAt string
+	var optionsJSON, createdAt, updatedAt string
 
 	err := s.db.QueryRow(`
 		SELECT trader_id, provider_type, leader_id, copy_ratio, sync_leverage, sync_margin_mode,
-		       min_trade_warn, max_trade_warn, enabled, created_at, updated_at
+		       min_trade_warn, max_trade_warn, enabled, COALESCE(options_json, '{}'), created_at, updated_at
 		FROM copy_trade_configs WHERE trader_id = ?
 	`, traderID).Scan(
 		&config.TraderID, &config.ProviderType, &config.LeaderID, &config.CopyRatio,
 		&config.SyncLeverage, &config.SyncMarginMode, &config.MinTradeWarn, &config.MaxTradeWarn,
-		&config.Enabled, &createdAt, &updatedAt,
+		&config.Enabled, &optionsJSON, &createdAt, &updatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	config.Options = parseCopyTradeOptions(optionsJSON)
 	config.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
 	config.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
 
@@ -153,7 +318,7 @@ func (s *CopyTradeStore) GetByTraderID(traderID string) (*CopyTradeConfig, error
 func (s *CopyTradeStore) ListEnabled() ([]*CopyTradeConfig, error) {
 	rows, err := s.db.Query(`
 		SELECT trader_id, provider_type, leader_id, copy_ratio, sync_leverage, sync_margin_mode,
-		       min_trade_warn, max_trade_warn, enabled, created_at, updated_at
+		       min_trade_warn, max_trade_warn, enabled, COALESCE(options_json, '{}'), created_at, updated_at
 		FROM copy_trade_configs WHERE enabled = 1
 	`)
 	if err != nil {
@@ -164,17 +329,18 @@ func (s *CopyTradeStore) ListEnabled() ([]*CopyTradeConfig, error) {
 	var configs []*CopyTradeConfig
 	for rows.Next() {
 		var config CopyTradeConfig
-		var createdAt, updatedAt string
+		var optionsJSON, createdAt, updatedAt string
 
 		err := rows.Scan(
 			&config.TraderID, &config.ProviderType, &config.LeaderID, &config.CopyRatio,
 			&config.SyncLeverage, &config.SyncMarginMode, &config.MinTradeWarn, &config.MaxTradeWarn,
-			&config.Enabled, &createdAt, &updatedAt,
+			&config.Enabled, &optionsJSON, &createdAt, &updatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
 
+		config.Options = parseCopyTradeOptions(optionsJSON)
 		config.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
 		config.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
 
@@ -231,6 +397,7 @@ type CopyTradeSignalLog struct {
 	WarningsJSON string    `json:"warnings_json"`
 	Status       string    `json:"status"` // pending | executed | failed | skipped
 	ErrorMessage string    `json:"error_message"`
+	CloseTrigger string    `json:"close_trigger,omitempty"` // 领航员平仓触发来源：take_profit | stop_loss | liquidation（空=手动/未知）
 	CreatedAt    time.Time `json:"created_at"`
 }
 
@@ -253,6 +420,7 @@ func (s *CopyTradeStore) initSignalLogTable() error {
 			warnings_json TEXT,
 			status TEXT DEFAULT 'pending',
 			error_message TEXT,
+			close_trigger TEXT DEFAULT '',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			UNIQUE(trader_id, signal_id)
 		)
@@ -265,7 +433,39 @@ func (s *CopyTradeStore) initSignalLogTable() error {
 	s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_signal_logs_trader ON copy_trade_signal_logs(trader_id)`)
 	s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_signal_logs_time ON copy_trade_signal_logs(created_at)`)
 
-	return nil
+	// 信号日志小时汇总（原始日志压缩后保留计数）
+	_, err = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS copy_trade_signal_rollups (
+			trader_id TEXT NOT NULL,
+			hour TEXT NOT NULL,
+			status TEXT NOT NULL,
+			action TEXT NOT NULL,
+			count INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (trader_id, hour, status, action)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_signal_rollups_hour ON copy_trade_signal_rollups(hour)`)
+
+	// 影子跟单（A/B 对比）
+	if err := s.initShadowTables(); err != nil {
+		return err
+	}
+
+	// 领航员平仓盈亏（已实现收益率分布）
+	if err := s.initLeaderPnLTable(); err != nil {
+		return err
+	}
+
+	// 已处理成交（去重记录持久化）
+	if err := s.initSeenFillTable(); err != nil {
+		return err
+	}
+
+	// 跟单预警记录
+	return s.initWarningTable()
 }
 
 // SaveSignalLog 保存信号日志
@@ -273,14 +473,14 @@ func (s *CopyTradeStore) SaveSignalLog(log *CopyTradeSignalLog) error {
 	_, err := s.db.Exec(`
 		INSERT INTO copy_trade_signal_logs 
 			(trader_id, leader_id, provider_type, signal_id, symbol, action, position_side,
-			 leader_price, leader_value, copy_size, followed, follow_reason, warnings_json, status, error_message)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			 leader_price, leader_value, copy_size, followed, follow_reason, warnings_json, status, error_message, close_trigger)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(trader_id, signal_id) DO UPDATE SET
 			status = excluded.status,
 			error_message = excluded.error_message
 	`, log.TraderID, log.LeaderID, log.ProviderType, log.SignalID, log.Symbol, log.Action,
 		log.PositionSide, log.LeaderPrice, log.LeaderValue, log.CopySize, log.Followed,
-		log.FollowReason, log.WarningsJSON, log.Status, log.ErrorMessage)
+		log.FollowReason, log.WarningsJSON, log.Status, log.ErrorMessage, log.CloseTrigger)
 	return err
 }
 
@@ -289,7 +489,7 @@ func (s *CopyTradeStore) GetRecentSignalLogs(traderID string, limit int) ([]*Cop
 	rows, err := s.db.Query(`
 		SELECT id, trader_id, leader_id, provider_type, signal_id, symbol, action, position_side,
 		       leader_price, leader_value, copy_size, followed, follow_reason, warnings_json, status, 
-		       COALESCE(error_message, ''), created_at
+		       COALESCE(error_message, ''), COALESCE(close_trigger, ''), created_at
 		FROM copy_trade_signal_logs 
 		WHERE trader_id = ?
 		ORDER BY created_at DESC
@@ -309,7 +509,7 @@ func (s *CopyTradeStore) GetRecentSignalLogs(traderID string, limit int) ([]*Cop
 			&log.ID, &log.TraderID, &log.LeaderID, &log.ProviderType, &log.SignalID,
 			&log.Symbol, &log.Action, &log.PositionSide, &log.LeaderPrice, &log.LeaderValue,
 			&log.CopySize, &log.Followed, &log.FollowReason, &log.WarningsJSON,
-			&log.Status, &log.ErrorMessage, &createdAt,
+			&log.Status, &log.ErrorMessage, &log.CloseTrigger, &createdAt,
 		)
 		if err != nil {
 			return nil, err
@@ -322,6 +522,133 @@ func (s *CopyTradeStore) GetRecentSignalLogs(traderID string, limit int) ([]*Cop
 	return logs, nil
 }
 
+// CountCloseTriggers 统计已执行的平仓/减仓按领航员触发来源的分布（空来源计为 "manual"）
+// 用于判断领航员主要是止盈离场还是被止损/强平
+func (s *CopyTradeStore) CountCloseTriggers(traderID string) (map[string]int, error) {
+	rows, err := s.db.Query(`
+		SELECT COALESCE(NULLIF(close_trigger, ''), 'manual'), COUNT(*)
+		FROM copy_trade_signal_logs
+		WHERE trader_id = ? AND status = 'executed' AND (action LIKE 'close_%' OR action LIKE 'reduce_%')
+		GROUP BY 1
+	`, traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var trigger string
+		var count int
+		if err := rows.Scan(&trigger, &count); err != nil {
+			return nil, err
+		}
+		counts[trigger] = count
+	}
+	return counts, rows.Err()
+}
+
+// CopyTradeSignalRollup 信号日志小时汇总
+type CopyTradeSignalRollup struct {
+	TraderID string `json:"trader_id"`
+	Hour     string `json:"hour"` // "2006-01-02 15:00:00"（UTC，与 created_at 一致）
+	Status   string `json:"status"`
+	Action   string `json:"action"`
+	Count    int    `json:"count"`
+}
+
+// CompactSignalLogs 将 before 之前的原始信号日志按小时汇总到 rollup 表并删除原始记录
+// 返回删除的原始日志条数
+func (s *CopyTradeStore) CompactSignalLogs(before time.Time) (int64, error) {
+	cutoff := before.UTC().Format("2006-01-02 15:04:05")
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO copy_trade_signal_rollups (trader_id, hour, status, action, count)
+		SELECT trader_id, strftime('%Y-%m-%d %H:00:00', created_at), COALESCE(status, ''), action, COUNT(*)
+		FROM copy_trade_signal_logs
+		WHERE created_at < ?
+		GROUP BY 1, 2, 3, 4
+		ON CONFLICT(trader_id, hour, status, action) DO UPDATE SET
+			count = count + excluded.count
+	`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("rollup signal logs: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM copy_trade_signal_logs WHERE created_at < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("delete compacted signal logs: %w", err)
+	}
+	deleted, _ := result.RowsAffected()
+
+	return deleted, tx.Commit()
+}
+
+// GetSignalRollups 查询 since 之后的信号小时汇总（traderID 为空 = 全部）
+func (s *CopyTradeStore) GetSignalRollups(traderID string, since time.Time) ([]*CopyTradeSignalRollup, error) {
+	query := `SELECT trader_id, hour, status, action, count
+		FROM copy_trade_signal_rollups
+		WHERE hour >= ?`
+	args := []interface{}{since.UTC().Format("2006-01-02 15:04:05")}
+	if traderID != "" {
+		query += " AND trader_id = ?"
+		args = append(args, traderID)
+	}
+	query += " ORDER BY hour DESC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rollups []*CopyTradeSignalRollup
+	for rows.Next() {
+		var r CopyTradeSignalRollup
+		if err := rows.Scan(&r.TraderID, &r.Hour, &r.Status, &r.Action, &r.Count); err != nil {
+			return nil, err
+		}
+		rollups = append(rollups, &r)
+	}
+	return rollups, rows.Err()
+}
+
+// CountSignalsByStatus 统计 since 之后各状态的信号数（原始日志 + 已压缩的小时汇总）
+// since 应对齐到整点，否则该小时内已压缩的部分不计入
+func (s *CopyTradeStore) CountSignalsByStatus(since time.Time) (map[string]int, error) {
+	sinceStr := since.UTC().Format("2006-01-02 15:04:05")
+	rows, err := s.db.Query(`
+		SELECT status, SUM(n) FROM (
+			SELECT COALESCE(status, '') AS status, COUNT(*) AS n
+			FROM copy_trade_signal_logs WHERE created_at >= ? GROUP BY 1
+			UNION ALL
+			SELECT status, SUM(count) AS n
+			FROM copy_trade_signal_rollups WHERE hour >= ? GROUP BY 1
+		) GROUP BY status
+	`, sinceStr, sinceStr)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
 // ============================================================================
 // 仓位映射（跟单仓位生命周期管理）
 // ============================================================================
@@ -337,7 +664,7 @@ type CopyTradePositionMapping struct {
 	Symbol      string `json:"symbol"`        // LINKUSDT
 	Side        string `json:"side"`          // long | short
 	MarginMode  string `json:"margin_mode"`   // cross | isolated
-	Status      string `json:"status"`        // active | closed
+	Status      string `json:"status"`        // active | ignored | closed | detached
 
 	// 开仓信息
 	OpenedAt      time.Time `json:"opened_at"`       // 跟单开仓时间
@@ -346,13 +673,22 @@ type CopyTradePositionMapping struct {
 	LastKnownSize float64   `json:"last_known_size"` // 领航员上次已知持仓数量（用于精确匹配 posId）
 
 	// 平仓信息（平仓时填充）
-	ClosedAt   *time.Time `json:"closed_at"`   // 平仓时间
-	ClosePrice float64    `json:"close_price"` // 平仓价格
+	ClosedAt    *time.Time `json:"closed_at"`    // 平仓时间
+	ClosePrice  float64    `json:"close_price"`  // 平仓价格
+	RealizedPnL float64    `json:"realized_pnl"` // 跟随者已实现盈亏 (USDT)
 
 	// 累计统计（加仓/减仓时更新）
-	AddCount    int       `json:"add_count"`    // 累计加仓次数
-	ReduceCount int       `json:"reduce_count"` // 累计减仓次数
-	UpdatedAt   time.Time `json:"updated_at"`   // 最后更新时间
+	AddCount        int       `json:"add_count"`        // 累计加仓次数
+	ReduceCount     int       `json:"reduce_count"`     // 累计减仓次数
+	ReducedFraction float64   `json:"reduced_fraction"` // 本轮（开仓或最近一次加仓后）领航员累计减仓比例，用于分批止盈收尾
+	OpenQty         float64   `json:"open_qty"`         // 跟随者剩余持仓数量（开仓/加仓按实际成交累计，减仓/平仓扣减）
+	AvgEntryPrice   float64   `json:"avg_entry_price"`  // 跟随者持仓均价（开仓/加仓按实际成交数量加权）
+	PnLEstimated    bool      `json:"pnl_estimated"`    // 数量/均价/盈亏含估算值（执行器未返回确认成交时按跟单金额和领航员成交价估算）
+	UpdatedAt       time.Time `json:"updated_at"`       // 最后更新时间
+
+	// 接管信息（接管跟随者已有持仓时填充，0=非接管仓位）
+	ExpectedSize float64 `json:"expected_size"` // 接管时按跟单比例应有的跟随者持仓（基础币数量）
+	AdoptedSize  float64 `json:"adopted_size"`  // 接管时跟随者实际持仓（基础币数量）
 }
 
 // initPositionMappingTable 初始化仓位映射表
@@ -371,12 +707,20 @@ func (s *CopyTradeStore) initPositionMappingTable() error {
 			opened_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			open_price REAL DEFAULT 0,
 			open_size_usd REAL DEFAULT 0,
+			last_known_size REAL DEFAULT 0,
 			
 			closed_at DATETIME,
 			close_price REAL DEFAULT 0,
+			realized_pnl REAL DEFAULT 0,
 			
 			add_count INTEGER DEFAULT 0,
 			reduce_count INTEGER DEFAULT 0,
+			reduced_fraction REAL DEFAULT 0,
+			open_qty REAL DEFAULT 0,
+			avg_entry_price REAL DEFAULT 0,
+			pnl_estimated INTEGER DEFAULT 0,
+			expected_size REAL DEFAULT 0,
+			adopted_size REAL DEFAULT 0,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			
 			UNIQUE(trader_id, leader_pos_id)
@@ -390,30 +734,74 @@ func (s *CopyTradeStore) initPositionMappingTable() error {
 	s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_mapping_trader_status ON copy_trade_position_mappings(trader_id, status)`)
 	s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_mapping_trader_symbol ON copy_trade_position_mappings(trader_id, symbol, side, status)`)
 
-	// 添加 last_known_size 字段（如果不存在）
-	s.db.Exec(`ALTER TABLE copy_trade_position_mappings ADD COLUMN last_known_size REAL DEFAULT 0`)
+	// 旧库缺失的 last_known_size / realized_pnl 由 verifySchema 补齐
 
 	return nil
 }
 
 // SavePositionMapping 保存仓位映射（开仓时调用）
+// 同一 leader_pos_id 可能被复用（如 Hyperliquid 虚拟 posId "BTCUSDT_long"）：
+// 已平仓的旧映射先归档（leader_pos_id 追加 #id 后缀），保留历史盈亏统计，再插入新映射；
+// 仍为 active/ignored 的映射则整体重置为新仓位
 func (s *CopyTradeStore) SavePositionMapping(mapping *CopyTradePositionMapping) error {
-	_, err := s.db.Exec(`
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := archiveClosedMapping(tx, mapping.TraderID, mapping.LeaderPosID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
 		INSERT INTO copy_trade_position_mappings 
 			(trader_id, leader_pos_id, leader_id, symbol, side, margin_mode, status,
-			 opened_at, open_price, open_size_usd, last_known_size, add_count, reduce_count, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, 'active', ?, ?, ?, ?, 0, 0, CURRENT_TIMESTAMP)
+			 opened_at, open_price, open_size_usd, last_known_size, open_qty, avg_entry_price, pnl_estimated,
+			 add_count, reduce_count, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, 'active', ?, ?, ?, ?, ?, ?, ?, 0, 0, CURRENT_TIMESTAMP)
 		ON CONFLICT(trader_id, leader_pos_id) DO UPDATE SET
+			leader_id = excluded.leader_id,
+			symbol = excluded.symbol,
+			side = excluded.side,
+			margin_mode = excluded.margin_mode,
 			status = 'active',
 			opened_at = excluded.opened_at,
 			open_price = excluded.open_price,
 			open_size_usd = excluded.open_size_usd,
 			last_known_size = excluded.last_known_size,
+			closed_at = NULL,
+			close_price = 0,
+			realized_pnl = 0,
 			add_count = 0,
 			reduce_count = 0,
+			reduced_fraction = 0,
+			open_qty = excluded.open_qty,
+			avg_entry_price = excluded.avg_entry_price,
+			pnl_estimated = excluded.pnl_estimated,
+			expected_size = 0,
+			adopted_size = 0,
 			updated_at = CURRENT_TIMESTAMP
 	`, mapping.TraderID, mapping.LeaderPosID, mapping.LeaderID, mapping.Symbol,
-		mapping.Side, mapping.MarginMode, mapping.OpenedAt, mapping.OpenPrice, mapping.OpenSizeUSD, mapping.LastKnownSize)
+		mapping.Side, mapping.MarginMode, mapping.OpenedAt, mapping.OpenPrice, mapping.OpenSizeUSD, mapping.LastKnownSize,
+		mapping.OpenQty, mapping.AvgEntryPrice, mapping.PnLEstimated); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AdoptPositionMapping 接管跟随者已有持仓：保存为 active 映射并记录应有/实际持仓
+// 之后减仓按实际持仓对账（见 copytrade 引擎），适合用户手动按任意数量开的仓位
+func (s *CopyTradeStore) AdoptPositionMapping(mapping *CopyTradePositionMapping) error {
+	if err := s.SavePositionMapping(mapping); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`
+		UPDATE copy_trade_position_mappings 
+		SET expected_size = ?, adopted_size = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE trader_id = ? AND leader_pos_id = ? AND status = 'active'
+	`, mapping.ExpectedSize, mapping.AdoptedSize, mapping.TraderID, mapping.LeaderPosID)
 	return err
 }
 
@@ -427,21 +815,53 @@ func (s *CopyTradeStore) GetActiveMapping(traderID, leaderPosID string) (*CopyTr
 //   - active: 已跟随的仓位 → 继续跟随
 //   - ignored: 启动时的历史仓位 → 不跟随
 //   - closed: 已平仓 → 可以重新开仓
+//   - detached: 领航员已平仓、跟随者自行管理（只跟开仓模式）→ 与 closed 相同
 //   - nil: 无映射 → 新开仓
 func (s *CopyTradeStore) GetMapping(traderID, leaderPosID string) (*CopyTradePositionMapping, error) {
 	return s.getMappingByStatus(traderID, leaderPosID, "")
 }
 
+// GetMappingsByPosIDs 批量查询多个 posId 的映射（posId -> mapping）
+// 语义与逐个调用 GetMapping 一致：只返回 active/ignored，同一 posId 优先 active
+func (s *CopyTradeStore) GetMappingsByPosIDs(traderID string, leaderPosIDs []string) (map[string]*CopyTradePositionMapping, error) {
+	result := make(map[string]*CopyTradePositionMapping, len(leaderPosIDs))
+	if len(leaderPosIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(leaderPosIDs)), ",")
+	query := `SELECT ` + mappingColumns + `
+		FROM copy_trade_position_mappings
+		WHERE trader_id = ? AND leader_pos_id IN (` + placeholders + `) AND status IN ('active', 'ignored')
+	`
+	args := make([]interface{}, 0, len(leaderPosIDs)+1)
+	args = append(args, traderID)
+	for _, id := range leaderPosIDs {
+		args = append(args, id)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mappings, err := scanMappings(rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mappings {
+		if existing, ok := result[m.LeaderPosID]; ok && existing.Status == "active" {
+			continue
+		}
+		result[m.LeaderPosID] = m
+	}
+	return result, nil
+}
+
 // getMappingByStatus 内部方法：按状态查询映射
 func (s *CopyTradeStore) getMappingByStatus(traderID, leaderPosID, status string) (*CopyTradePositionMapping, error) {
-	var mapping CopyTradePositionMapping
-	var openedAt, updatedAt string
-	var closedAt sql.NullString
-
-	query := `
-		SELECT id, trader_id, leader_pos_id, leader_id, symbol, side, margin_mode, status,
-		       opened_at, open_price, open_size_usd, last_known_size, closed_at, close_price,
-		       add_count, reduce_count, updated_at
+	query := `SELECT ` + mappingColumns + `
 		FROM copy_trade_position_mappings
 		WHERE trader_id = ? AND leader_pos_id = ?
 	`
@@ -455,49 +875,127 @@ func (s *CopyTradeStore) getMappingByStatus(traderID, leaderPosID, status string
 		query += " AND status IN ('active', 'ignored') ORDER BY CASE status WHEN 'active' THEN 1 WHEN 'ignored' THEN 2 END LIMIT 1"
 	}
 
-	err := s.db.QueryRow(query, args...).Scan(
+	mapping, err := scanMapping(s.db.QueryRow(query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // 无映射，返回 nil
+		}
+		return nil, err
+	}
+
+	return mapping, nil
+}
+
+// mappingColumns 仓位映射查询列（与 scanMapping 顺序一致）
+const mappingColumns = `id, trader_id, leader_pos_id, leader_id, symbol, side, margin_mode, status,
+		       opened_at, open_price, open_size_usd, last_known_size, closed_at, close_price,
+		       COALESCE(realized_pnl, 0), add_count, reduce_count, COALESCE(reduced_fraction, 0),
+		       COALESCE(open_qty, 0), COALESCE(avg_entry_price, 0), COALESCE(pnl_estimated, 0),
+		       COALESCE(expected_size, 0), COALESCE(adopted_size, 0), updated_at`
+
+// rowScanner 兼容 *sql.Row 和 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMapping 扫描一行仓位映射
+func scanMapping(row rowScanner) (*CopyTradePositionMapping, error) {
+	var mapping CopyTradePositionMapping
+	var openedAt, updatedAt string
+	var closedAt sql.NullString
+
+	err := row.Scan(
 		&mapping.ID, &mapping.TraderID, &mapping.LeaderPosID, &mapping.LeaderID,
 		&mapping.Symbol, &mapping.Side, &mapping.MarginMode, &mapping.Status,
 		&openedAt, &mapping.OpenPrice, &mapping.OpenSizeUSD, &mapping.LastKnownSize, &closedAt, &mapping.ClosePrice,
-		&mapping.AddCount, &mapping.ReduceCount, &updatedAt,
+		&mapping.RealizedPnL, &mapping.AddCount, &mapping.ReduceCount, &mapping.ReducedFraction,
+		&mapping.OpenQty, &mapping.AvgEntryPrice, &mapping.PnLEstimated, &mapping.ExpectedSize, &mapping.AdoptedSize, &updatedAt,
 	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil // 无映射，返回 nil
-		}
 		return nil, err
 	}
 
-	mapping.OpenedAt, _ = time.Parse("2006-01-02 15:04:05", openedAt)
-	mapping.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
+	mapping.OpenedAt = parseMappingTime(openedAt)
+	mapping.UpdatedAt = parseMappingTime(updatedAt)
 	if closedAt.Valid {
-		t, _ := time.Parse("2006-01-02 15:04:05", closedAt.String)
+		t := parseMappingTime(closedAt.String)
 		mapping.ClosedAt = &t
 	}
 
 	return &mapping, nil
 }
 
+// parseMappingTime 解析映射表时间字段
+// SQLite 驱动读取 DATETIME 时返回 RFC3339，旧数据可能是 CURRENT_TIMESTAMP 格式
+func parseMappingTime(s string) time.Time {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t
+	}
+	t, _ := time.Parse("2006-01-02 15:04:05", s)
+	return t
+}
+
+// scanMappings 扫描多行仓位映射
+func scanMappings(rows *sql.Rows) ([]*CopyTradePositionMapping, error) {
+	var mappings []*CopyTradePositionMapping
+	for rows.Next() {
+		mapping, err := scanMapping(rows)
+		if err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+// archiveClosedMapping 归档已平仓的映射，释放 leader_pos_id 供新仓位使用
+func archiveClosedMapping(tx *sql.Tx, traderID, leaderPosID string) error {
+	_, err := tx.Exec(`
+		UPDATE copy_trade_position_mappings
+		SET leader_pos_id = leader_pos_id || '#' || id
+		WHERE trader_id = ? AND leader_pos_id = ? AND status IN ('closed', 'detached')
+	`, traderID, leaderPosID)
+	if err != nil {
+		return fmt.Errorf("archive closed mapping: %w", err)
+	}
+	return nil
+}
+
 // SaveIgnoredPosition 保存历史仓位（启动跟单时调用）
 // 标记为 ignored 状态，后续这些仓位的操作都不跟随
+// 重启时若同一 posId 的旧映射已平仓（领航员停机期间重新开仓），归档旧映射后重新标记为 ignored；
+// active/ignored 映射保持不变
 func (s *CopyTradeStore) SaveIgnoredPosition(traderID, leaderID, leaderPosID, symbol, side, marginMode string) error {
-	_, err := s.db.Exec(`
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := archiveClosedMapping(tx, traderID, leaderPosID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
 		INSERT INTO copy_trade_position_mappings 
 			(trader_id, leader_pos_id, leader_id, symbol, side, margin_mode, status,
 			 opened_at, open_price, open_size_usd, add_count, reduce_count, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, 'ignored', CURRENT_TIMESTAMP, 0, 0, 0, 0, CURRENT_TIMESTAMP)
 		ON CONFLICT(trader_id, leader_pos_id) DO NOTHING
-	`, traderID, leaderPosID, leaderID, symbol, side, marginMode)
-	return err
+	`, traderID, leaderPosID, leaderID, symbol, side, marginMode); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// IncrementAddCount 增加加仓次数（加仓时调用）
-func (s *CopyTradeStore) IncrementAddCount(traderID, leaderPosID string) error {
+// IncrementAddCount 增加加仓次数（加仓时调用），加仓金额累加到 open_size_usd
+func (s *CopyTradeStore) IncrementAddCount(traderID, leaderPosID string, addSizeUSD float64) error {
 	_, err := s.db.Exec(`
 		UPDATE copy_trade_position_mappings 
-		SET add_count = add_count + 1, updated_at = CURRENT_TIMESTAMP
+		SET add_count = add_count + 1, open_size_usd = open_size_usd + ?, reduced_fraction = 0, updated_at = CURRENT_TIMESTAMP
 		WHERE trader_id = ? AND leader_pos_id = ? AND status = 'active'
-	`, traderID, leaderPosID)
+	`, addSizeUSD, traderID, leaderPosID)
 	return err
 }
 
@@ -511,6 +1009,47 @@ func (s *CopyTradeStore) IncrementReduceCount(traderID, leaderPosID string) erro
 	return err
 }
 
+// UpdateReducedFraction 更新本轮累计减仓比例（减仓后调用，加仓时由 IncrementAddCount 重置为 0）
+func (s *CopyTradeStore) UpdateReducedFraction(traderID, leaderPosID string, fraction float64) error {
+	_, err := s.db.Exec(`
+		UPDATE copy_trade_position_mappings 
+		SET reduced_fraction = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE trader_id = ? AND leader_pos_id = ? AND status = 'active'
+	`, fraction, traderID, leaderPosID)
+	return err
+}
+
+// UpdateOpenQty 更新跟随者持仓数量和均价（加仓后调用）
+func (s *CopyTradeStore) UpdateOpenQty(traderID, leaderPosID string, qty, avgEntryPrice float64) error {
+	_, err := s.db.Exec(`
+		UPDATE copy_trade_position_mappings 
+		SET open_qty = ?, avg_entry_price = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE trader_id = ? AND leader_pos_id = ? AND status = 'active'
+	`, qty, avgEntryPrice, traderID, leaderPosID)
+	return err
+}
+
+// RecordReduceFill 记录减仓成交：更新剩余持仓数量，并把该笔减仓的已实现盈亏累加到 realized_pnl
+// 平仓时 CloseMapping 写入的 realized_pnl 应包含此前减仓累计的盈亏
+func (s *CopyTradeStore) RecordReduceFill(traderID, leaderPosID string, remainingQty, realizedPnL float64) error {
+	_, err := s.db.Exec(`
+		UPDATE copy_trade_position_mappings 
+		SET open_qty = ?, realized_pnl = COALESCE(realized_pnl, 0) + ?, updated_at = CURRENT_TIMESTAMP
+		WHERE trader_id = ? AND leader_pos_id = ? AND status = 'active'
+	`, remainingQty, realizedPnL, traderID, leaderPosID)
+	return err
+}
+
+// MarkPnLEstimated 标记映射的数量/均价/盈亏含估算成交（执行器未返回确认成交时调用）
+func (s *CopyTradeStore) MarkPnLEstimated(traderID, leaderPosID string) error {
+	_, err := s.db.Exec(`
+		UPDATE copy_trade_position_mappings 
+		SET pnl_estimated = 1, updated_at = CURRENT_TIMESTAMP
+		WHERE trader_id = ? AND leader_pos_id = ? AND status = 'active'
+	`, traderID, leaderPosID)
+	return err
+}
+
 // UpdateLastKnownSize 更新领航员上次已知持仓数量（加仓/减仓后调用）
 // 用于精确匹配：通过 size 变化确定是哪个 posId 发生了操作
 func (s *CopyTradeStore) UpdateLastKnownSize(traderID, leaderPosID string, size float64) error {
@@ -522,25 +1061,111 @@ func (s *CopyTradeStore) UpdateLastKnownSize(traderID, leaderPosID string, size
 	return err
 }
 
+// UpdateMappingMarginMode 领航员切换仓位保证金模式后更新映射
+// 按模式区分 key 的仓位（无 posId 的 OKX 逐仓）切换后 key 随之变化，newPosID 为新 key（不变时与 leaderPosID 相同）
+func (s *CopyTradeStore) UpdateMappingMarginMode(traderID, leaderPosID, newPosID, marginMode string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if newPosID != leaderPosID {
+		if err := archiveClosedMapping(tx, traderID, newPosID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`
+		UPDATE copy_trade_position_mappings 
+		SET leader_pos_id = ?, margin_mode = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE trader_id = ? AND leader_pos_id = ? AND status = 'active'
+	`, newPosID, marginMode, traderID, leaderPosID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeletePositionMapping 删除仓位映射（跟单自检的临时映射）
+func (s *CopyTradeStore) DeletePositionMapping(traderID, leaderPosID string) error {
+	_, err := s.db.Exec(`DELETE FROM copy_trade_position_mappings WHERE trader_id = ? AND leader_pos_id = ?`, traderID, leaderPosID)
+	return err
+}
+
 // CloseMapping 关闭仓位映射（平仓时调用）
-func (s *CopyTradeStore) CloseMapping(traderID, leaderPosID string, closePrice float64) error {
+// realizedPnL 为跟随者该笔跟单仓位的累计已实现盈亏（含此前减仓已累计的部分），用于跟单独立统计
+func (s *CopyTradeStore) CloseMapping(traderID, leaderPosID string, closePrice, realizedPnL float64) error {
 	_, err := s.db.Exec(`
 		UPDATE copy_trade_position_mappings 
-		SET status = 'closed', closed_at = CURRENT_TIMESTAMP, close_price = ?, updated_at = CURRENT_TIMESTAMP
+		SET status = 'closed', closed_at = CURRENT_TIMESTAMP, close_price = ?, realized_pnl = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE trader_id = ? AND leader_pos_id = ? AND status = 'active'
+	`, closePrice, realizedPnL, traderID, leaderPosID)
+	return err
+}
+
+// DetachMapping 领航员已平仓但不跟随平仓（只跟开仓模式）时调用
+// 跟随者仍持有该仓位、自行管理，映射标记为 detached：不再匹配领航员的任何操作，
+// posId 被复用（Hyperliquid 重新开仓）时与 closed 一样视为新开仓
+func (s *CopyTradeStore) DetachMapping(traderID, leaderPosID string, closePrice float64) error {
+	_, err := s.db.Exec(`
+		UPDATE copy_trade_position_mappings 
+		SET status = 'detached', closed_at = CURRENT_TIMESTAMP, close_price = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE trader_id = ? AND leader_pos_id = ? AND status = 'active'
 	`, closePrice, traderID, leaderPosID)
 	return err
 }
 
+// ExpireMapping 跟随者主动平仓（如超过最大持仓时间）后调用
+// 领航员仍持有该仓位，因此标记为 ignored：后续该仓位的加仓/减仓不再跟随，
+// 领航员平仓后由 MarkIgnoredAsClosed 转为 closed
+func (s *CopyTradeStore) ExpireMapping(traderID, leaderPosID string, closePrice, realizedPnL float64) error {
+	_, err := s.db.Exec(`
+		UPDATE copy_trade_position_mappings 
+		SET status = 'ignored', closed_at = CURRENT_TIMESTAMP, close_price = ?, realized_pnl = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE trader_id = ? AND leader_pos_id = ? AND status = 'active'
+	`, closePrice, realizedPnL, traderID, leaderPosID)
+	return err
+}
+
+// SumActiveOpenSize 统计某 trader 跟随某领航员的活跃仓位累计开仓金额（开仓 + 加仓）
+// 用于领航员资金预算控制，仓位平仓后释放
+func (s *CopyTradeStore) SumActiveOpenSize(traderID, leaderID string) (float64, error) {
+	var total float64
+	err := s.db.QueryRow(`
+		SELECT COALESCE(SUM(open_size_usd), 0) FROM copy_trade_position_mappings
+		WHERE trader_id = ? AND leader_id = ? AND status = 'active'
+	`, traderID, leaderID).Scan(&total)
+	return total, err
+}
+
+// CountFollowedOpens 统计某 trader 对某领航员已跟随的开仓次数（不含启动时的 ignored 历史仓位）
+func (s *CopyTradeStore) CountFollowedOpens(traderID, leaderID string) (int, error) {
+	var count int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM copy_trade_position_mappings
+		WHERE trader_id = ? AND leader_id = ? AND open_size_usd > 0
+	`, traderID, leaderID).Scan(&count)
+	return count, err
+}
+
 // ListActiveMappings 列出某 trader 所有活跃映射（调试/展示）
 func (s *CopyTradeStore) ListActiveMappings(traderID string) ([]*CopyTradePositionMapping, error) {
-	return s.listMappings(traderID, "active", 0)
+	return s.listMappings(traderID, "", "active", 0)
+}
+
+// ListActiveMappingsForLeader 列出某 trader 跟随指定领航员的活跃映射（leaderID 为空 = 全部领航员）
+func (s *CopyTradeStore) ListActiveMappingsForLeader(traderID, leaderID string) ([]*CopyTradePositionMapping, error) {
+	return s.listMappings(traderID, leaderID, "active", 0)
 }
 
 // ListIgnoredMappings 列出某 trader 所有 ignored 映射
 // 用于检测历史仓位是否已被领航员平仓
 func (s *CopyTradeStore) ListIgnoredMappings(traderID string) ([]*CopyTradePositionMapping, error) {
-	return s.listMappings(traderID, "ignored", 0)
+	return s.listMappings(traderID, "", "ignored", 0)
+}
+
+// ListIgnoredMappingsForLeader 列出某 trader 跟随指定领航员的 ignored 映射（leaderID 为空 = 全部领航员）
+func (s *CopyTradeStore) ListIgnoredMappingsForLeader(traderID, leaderID string) ([]*CopyTradePositionMapping, error) {
+	return s.listMappings(traderID, leaderID, "ignored", 0)
 }
 
 // MarkIgnoredAsClosed 将 ignored 状态的映射标记为 closed
@@ -557,66 +1182,72 @@ func (s *CopyTradeStore) MarkIgnoredAsClosed(traderID, leaderPosID string) error
 // FindActiveBySymbolSide 查找某 symbol+side 的所有活跃映射
 // 用于平仓/减仓时的反向查找：从本地映射出发，对比领航员持仓判断动作
 func (s *CopyTradeStore) FindActiveBySymbolSide(traderID, symbol, side string) ([]*CopyTradePositionMapping, error) {
-	query := `
-		SELECT id, trader_id, leader_pos_id, leader_id, symbol, side, margin_mode, status,
-		       opened_at, open_price, open_size_usd, last_known_size, closed_at, close_price,
-		       add_count, reduce_count, updated_at
+	return s.FindActiveBySymbolSideForLeader(traderID, "", symbol, side)
+}
+
+// FindActiveBySymbolSideForLeader 查找跟随指定领航员的某 symbol+side 活跃映射（leaderID 为空 = 全部领航员）
+// 多领航员跟单时不同领航员的同币种同方向仓位互不混淆
+func (s *CopyTradeStore) FindActiveBySymbolSideForLeader(traderID, leaderID, symbol, side string) ([]*CopyTradePositionMapping, error) {
+	query := `SELECT ` + mappingColumns + `
 		FROM copy_trade_position_mappings
 		WHERE trader_id = ? AND symbol = ? AND side = ? AND status = 'active'
+		  AND (? = '' OR leader_id = ?)
 		ORDER BY opened_at ASC
 	`
 
-	rows, err := s.db.Query(query, traderID, symbol, side)
+	rows, err := s.db.Query(query, traderID, symbol, side, leaderID, leaderID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var mappings []*CopyTradePositionMapping
-	for rows.Next() {
-		var mapping CopyTradePositionMapping
-		var openedAt, updatedAt string
-		var closedAt sql.NullString
+	return scanMappings(rows)
+}
 
-		err := rows.Scan(
-			&mapping.ID, &mapping.TraderID, &mapping.LeaderPosID, &mapping.LeaderID,
-			&mapping.Symbol, &mapping.Side, &mapping.MarginMode, &mapping.Status,
-			&openedAt, &mapping.OpenPrice, &mapping.OpenSizeUSD, &mapping.LastKnownSize, &closedAt, &mapping.ClosePrice,
-			&mapping.AddCount, &mapping.ReduceCount, &updatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
+// FindLastClosedBySymbolSide 查找某 symbol+side 最近一次平仓的映射（含已归档）
+// 用于判断领航员平仓后是否快速重新开仓
+func (s *CopyTradeStore) FindLastClosedBySymbolSide(traderID, symbol, side string) (*CopyTradePositionMapping, error) {
+	return s.FindLastClosedBySymbolSideForLeader(traderID, "", symbol, side)
+}
 
-		mapping.OpenedAt, _ = time.Parse("2006-01-02 15:04:05", openedAt)
-		mapping.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
-		if closedAt.Valid {
-			t, _ := time.Parse("2006-01-02 15:04:05", closedAt.String)
-			mapping.ClosedAt = &t
-		}
+// FindLastClosedBySymbolSideForLeader 查找跟随指定领航员的某 symbol+side 最近一次平仓映射（leaderID 为空 = 全部领航员）
+func (s *CopyTradeStore) FindLastClosedBySymbolSideForLeader(traderID, leaderID, symbol, side string) (*CopyTradePositionMapping, error) {
+	query := `SELECT ` + mappingColumns + `
+		FROM copy_trade_position_mappings
+		WHERE trader_id = ? AND symbol = ? AND side = ? AND status = 'closed' AND closed_at IS NOT NULL
+		  AND (? = '' OR leader_id = ?)
+		ORDER BY closed_at DESC, id DESC
+		LIMIT 1
+	`
 
-		mappings = append(mappings, &mapping)
+	mapping, err := scanMapping(s.db.QueryRow(query, traderID, symbol, side, leaderID, leaderID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
 	}
-
-	return mappings, nil
+	return mapping, nil
 }
 
 // ListAllMappings 列出某 trader 所有映射（含历史）
 func (s *CopyTradeStore) ListAllMappings(traderID string, limit int) ([]*CopyTradePositionMapping, error) {
-	return s.listMappings(traderID, "", limit)
+	return s.listMappings(traderID, "", "", limit)
 }
 
 // listMappings 内部方法：查询映射列表
-func (s *CopyTradeStore) listMappings(traderID, status string, limit int) ([]*CopyTradePositionMapping, error) {
-	query := `
-		SELECT id, trader_id, leader_pos_id, leader_id, symbol, side, margin_mode, status,
-		       opened_at, open_price, open_size_usd, last_known_size, closed_at, close_price,
-		       add_count, reduce_count, updated_at
+func (s *CopyTradeStore) listMappings(traderID, leaderID, status string, limit int) ([]*CopyTradePositionMapping, error) {
+	query := `SELECT ` + mappingColumns + `
 		FROM copy_trade_position_mappings
 		WHERE trader_id = ?
 	`
 	args := []interface{}{traderID}
 
+	if leaderID != "" {
+		query += " AND leader_id = ?"
+		args = append(args, leaderID)
+	}
+
 	if status != "" {
 		query += " AND status = ?"
 		args = append(args, status)
@@ -635,33 +1266,110 @@ func (s *CopyTradeStore) listMappings(traderID, status string, limit int) ([]*Co
 	}
 	defer rows.Close()
 
-	var mappings []*CopyTradePositionMapping
-	for rows.Next() {
-		var mapping CopyTradePositionMapping
-		var openedAt, updatedAt string
-		var closedAt sql.NullString
+	return scanMappings(rows)
+}
 
-		err := rows.Scan(
-			&mapping.ID, &mapping.TraderID, &mapping.LeaderPosID, &mapping.LeaderID,
-			&mapping.Symbol, &mapping.Side, &mapping.MarginMode, &mapping.Status,
-			&openedAt, &mapping.OpenPrice, &mapping.OpenSizeUSD, &mapping.LastKnownSize, &closedAt, &mapping.ClosePrice,
-			&mapping.AddCount, &mapping.ReduceCount, &updatedAt,
-		)
-		if err != nil {
+// ============================================================================
+// 跟单仓位统计（只统计跟单产生的仓位，不含 AI 交易）
+// ============================================================================
+
+// CopyTradeLeaderStats 按领航员汇总的跟单表现
+type CopyTradeLeaderStats struct {
+	LeaderID             string  `json:"leader_id"`
+	ActivePositions      int     `json:"active_positions"`        // 当前跟随中的仓位
+	ClosedPositions      int     `json:"closed_positions"`        // 已平仓的跟单仓位
+	WinPositions         int     `json:"win_positions"`           // 盈利仓位数
+	WinRate              float64 `json:"win_rate"`                // 胜率 %
+	RealizedPnL          float64 `json:"realized_pnl"`            // 跟随者累计已实现盈亏
+	EstimatedPositions   int     `json:"estimated_positions"`     // 已平仓中盈亏含估算成交的仓位数（执行器未返回确认成交）
+	OpenSizeUSD          float64 `json:"open_size_usd"`           // 累计开仓金额
+	AvgLeaderReturnPct   float64 `json:"avg_leader_return_pct"`   // 领航员平均收益率 %（按开平仓价格）
+	AvgFollowerReturnPct float64 `json:"avg_follower_return_pct"` // 跟随者平均收益率 %（盈亏/开仓金额）
+	TrackingErrorPct     float64 `json:"tracking_error_pct"`      // 平均跟踪误差 %（两者收益率差的绝对值）
+}
+
+// GetLeaderStats 按领航员统计跟单仓位表现
+// 只统计真正跟随过的仓位（open_size_usd > 0），启动时的 ignored 历史仓位不计入；
+// 主动平仓后转为 ignored 的仓位按已平仓统计
+func (s *CopyTradeStore) GetLeaderStats(traderID string) ([]*CopyTradeLeaderStats, error) {
+	rows, err := s.db.Query(`
+		SELECT leader_id, side, status, open_price, close_price, open_size_usd, COALESCE(realized_pnl, 0),
+		       COALESCE(pnl_estimated, 0)
+		FROM copy_trade_position_mappings
+		WHERE trader_id = ? AND status IN ('active', 'closed', 'ignored') AND open_size_usd > 0
+		ORDER BY opened_at ASC
+	`, traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statsMap := make(map[string]*CopyTradeLeaderStats)
+	var order []string
+	trackingSum := make(map[string]float64)
+
+	for rows.Next() {
+		var leaderID, side, status string
+		var openPrice, closePrice, openSizeUSD, realizedPnL float64
+		var estimated bool
+		if err := rows.Scan(&leaderID, &side, &status, &openPrice, &closePrice, &openSizeUSD, &realizedPnL, &estimated); err != nil {
 			return nil, err
 		}
 
-		mapping.OpenedAt, _ = time.Parse("2006-01-02 15:04:05", openedAt)
-		mapping.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
-		if closedAt.Valid {
-			t, _ := time.Parse("2006-01-02 15:04:05", closedAt.String)
-			mapping.ClosedAt = &t
+		st, ok := statsMap[leaderID]
+		if !ok {
+			st = &CopyTradeLeaderStats{LeaderID: leaderID}
+			statsMap[leaderID] = st
+			order = append(order, leaderID)
 		}
 
-		mappings = append(mappings, &mapping)
+		if status == "active" {
+			st.ActivePositions++
+			continue
+		}
+
+		st.ClosedPositions++
+		st.RealizedPnL += realizedPnL
+		st.OpenSizeUSD += openSizeUSD
+		if realizedPnL > 0 {
+			st.WinPositions++
+		}
+		if estimated {
+			st.EstimatedPositions++
+		}
+
+		var leaderReturn float64
+		if openPrice > 0 && closePrice > 0 {
+			leaderReturn = (closePrice - openPrice) / openPrice * 100
+			if side == "short" {
+				leaderReturn = -leaderReturn
+			}
+		}
+		followerReturn := realizedPnL / openSizeUSD * 100
+
+		st.AvgLeaderReturnPct += leaderReturn
+		st.AvgFollowerReturnPct += followerReturn
+		diff := leaderReturn - followerReturn
+		if diff < 0 {
+			diff = -diff
+		}
+		trackingSum[leaderID] += diff
 	}
 
-	return mappings, nil
+	result := make([]*CopyTradeLeaderStats, 0, len(order))
+	for _, leaderID := range order {
+		st := statsMap[leaderID]
+		if st.ClosedPositions > 0 {
+			n := float64(st.ClosedPositions)
+			st.WinRate = float64(st.WinPositions) / n * 100
+			st.AvgLeaderReturnPct /= n
+			st.AvgFollowerReturnPct /= n
+			st.TrackingErrorPct = trackingSum[leaderID] / n
+		}
+		result = append(result, st)
+	}
+
+	return result, nil
 }
 
 // ============================================================================
@@ -674,6 +1382,27 @@ func (c *CopyTradeConfig) ToJSON() string {
 	return string(b)
 }
 
+// toJSON 将高级选项序列化为 JSON（存储用）
+func (o CopyTradeOptions) toJSON() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// parseCopyTradeOptions 解析高级选项，解析失败时返回默认值
+func parseCopyTradeOptions(s string) CopyTradeOptions {
+	var opts CopyTradeOptions
+	if s == "" {
+		return opts
+	}
+	if err := json.Unmarshal([]byte(s), &opts); err != nil {
+		return CopyTradeOptions{}
+	}
+	return opts
+}
+
 // FromJSON 从 JSON 字符串解析配置
 func CopyTradeConfigFromJSON(jsonStr string) (*CopyTradeConfig, error) {
 	var config CopyTradeConfig
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ============================================================================
+// 领航员已实现收益率分布
+// ============================================================================
+//
+// 领航员每笔减仓/平仓成交的 ClosedPnL 按跟单仓位映射记录下来，
+// 仓位平仓后与映射的开仓数据关联：
+//   领航员已实现收益率 = Σ ClosedPnL / (Σ 平仓数量 × 领航员开仓价) × 100
+// 按领航员汇总成分布，与跟随者收益率对比，判断是否吃到了领航员的收益。
+
+// CopyTradeReturnBucket 收益率分布区间 [Min, Max)（%），Min/Max 为空的一端不设限
+type CopyTradeReturnBucket struct {
+	Label string   `json:"label"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+	Count int      `json:"count"`
+}
+
+// CopyTradeLeaderReturnDistribution 单个领航员已平仓仓位的已实现收益率分布
+type CopyTradeLeaderReturnDistribution struct {
+	LeaderID        string                  `json:"leader_id"`
+	Positions       int                     `json:"positions"`         // 有领航员平仓盈亏记录的已平仓仓位数
+	AvgReturnPct    float64                 `json:"avg_return_pct"`    // 平均收益率 %
+	MedianReturnPct float64                 `json:"median_return_pct"` // 收益率中位数 %
+	MinReturnPct    float64                 `json:"min_return_pct"`
+	MaxReturnPct    float64                 `json:"max_return_pct"`
+	WinRate         float64                 `json:"win_rate"` // 收益率 > 0 的仓位占比 %
+	Buckets         []CopyTradeReturnBucket `json:"buckets"`
+}
+
+// leaderReturnBucketEdges 收益率分布区间边界（%）
+var leaderReturnBucketEdges = []float64{-10, -5, -2, 0, 2, 5, 10}
+
+func (s *CopyTradeStore) initLeaderPnLTable() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS copy_trade_leader_pnl (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			trader_id TEXT NOT NULL,
+			mapping_id INTEGER NOT NULL,
+			fill_id TEXT NOT NULL,
+			closed_pnl REAL DEFAULT 0,
+			closed_size REAL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(trader_id, fill_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_leader_pnl_mapping ON copy_trade_leader_pnl(mapping_id)`)
+	return nil
+}
+
+// SaveLeaderClosedPnL 记录领航员一笔减仓/平仓成交的平仓盈亏，关联到该仓位当前的跟单映射
+// 映射不存在（未跟随的仓位）时不记录；同一成交重复记录时忽略
+func (s *CopyTradeStore) SaveLeaderClosedPnL(traderID, leaderPosID, fillID string, closedPnL, closedSize float64) error {
+	_, err := s.db.Exec(`
+		INSERT OR IGNORE INTO copy_trade_leader_pnl (trader_id, mapping_id, fill_id, closed_pnl, closed_size)
+		SELECT trader_id, id, ?, ?, ? FROM copy_trade_position_mappings
+		WHERE trader_id = ? AND leader_pos_id = ? AND status IN ('active', 'ignored')
+	`, fillID, closedPnL, closedSize, traderID, leaderPosID)
+	return err
+}
+
+// GetLeaderReturnDistribution 按领航员统计已平仓跟单仓位的领航员已实现收益率分布
+// 只统计真正跟随过（open_size_usd > 0）且记录到领航员平仓盈亏的仓位
+func (s *CopyTradeStore) GetLeaderReturnDistribution(traderID string) ([]*CopyTradeLeaderReturnDistribution, error) {
+	rows, err := s.db.Query(`
+		SELECT m.leader_id, m.open_price, SUM(p.closed_pnl), SUM(p.closed_size)
+		FROM copy_trade_position_mappings m
+		JOIN copy_trade_leader_pnl p ON p.mapping_id = m.id AND p.trader_id = m.trader_id
+		WHERE m.trader_id = ? AND m.status = 'closed' AND m.open_size_usd > 0
+		GROUP BY m.id
+		ORDER BY m.opened_at ASC
+	`, traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	returns := make(map[string][]float64)
+	var order []string
+	for rows.Next() {
+		var leaderID string
+		var openPrice, closedPnL, closedSize float64
+		if err := rows.Scan(&leaderID, &openPrice, &closedPnL, &closedSize); err != nil {
+			return nil, err
+		}
+		if openPrice <= 0 || closedSize <= 0 {
+			continue
+		}
+		if _, ok := returns[leaderID]; !ok {
+			order = append(order, leaderID)
+		}
+		returns[leaderID] = append(returns[leaderID], closedPnL/(closedSize*openPrice)*100)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]*CopyTradeLeaderReturnDistribution, 0, len(order))
+	for _, leaderID := range order {
+		result = append(result, buildReturnDistribution(leaderID, returns[leaderID]))
+	}
+	return result, nil
+}
+
+// buildReturnDistribution 汇总收益率样本
+func buildReturnDistribution(leaderID string, returns []float64) *CopyTradeLeaderReturnDistribution {
+	d := &CopyTradeLeaderReturnDistribution{
+		LeaderID:     leaderID,
+		Positions:    len(returns),
+		MinReturnPct: math.Inf(1),
+		MaxReturnPct: math.Inf(-1),
+		Buckets:      newReturnBuckets(),
+	}
+
+	var sum float64
+	var wins int
+	for _, r := range returns {
+		sum += r
+		if r > 0 {
+			wins++
+		}
+		d.MinReturnPct = math.Min(d.MinReturnPct, r)
+		d.MaxReturnPct = math.Max(d.MaxReturnPct, r)
+		i := sort.SearchFloat64s(leaderReturnBucketEdges, r)
+		if i < len(leaderReturnBucketEdges) && leaderReturnBucketEdges[i] == r {
+			i++ // 边界值归入右侧区间 [Min, Max)
+		}
+		d.Buckets[i].Count++
+	}
+
+	n := float64(len(returns))
+	d.AvgReturnPct = sum / n
+	d.WinRate = float64(wins) / n * 100
+
+	sorted := append([]float64(nil), returns...)
+	sort.Float64s(sorted)
+	if mid := len(sorted) / 2; len(sorted)%2 == 1 {
+		d.MedianReturnPct = sorted[mid]
+	} else {
+		d.MedianReturnPct = (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return d
+}
+
+// newReturnBuckets 按边界生成空的分布区间
+func newReturnBuckets() []CopyTradeReturnBucket {
+	edges := leaderReturnBucketEdges
+	buckets := make([]CopyTradeReturnBucket, 0, len(edges)+1)
+	buckets = append(buckets, CopyTradeReturnBucket{Label: fmt.Sprintf("< %g%%", edges[0]), Max: &edges[0]})
+	for i := 0; i+1 < len(edges); i++ {
+		buckets = append(buckets, CopyTradeReturnBucket{
+			Label: fmt.Sprintf("%g%% ~ %g%%", edges[i], edges[i+1]),
+			Min:   &edges[i],
+			Max:   &edges[i+1],
+		})
+	}
+	last := len(edges) - 1
+	return append(buckets, CopyTradeReturnBucket{Label: fmt.Sprintf(">= %g%%", edges[last]), Min: &edges[last]})
+}
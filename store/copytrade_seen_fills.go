@@ -0,0 +1,60 @@
+package store
+
+import (
+	"time"
+)
+
+// ============================================================================
+// 已处理成交（去重记录持久化）
+// ============================================================================
+//
+// 引擎内存中的 seenFills 重启后丢失，启动时只能从最近 5 分钟的成交重建基线；
+// 持久化后重启前已处理的成交（在 TTL 内）不会被再次跟随。
+
+// seenFillTimeFormat seen_at 统一存 UTC，便于按字符串比较
+const seenFillTimeFormat = "2006-01-02 15:04:05"
+
+func (s *CopyTradeStore) initSeenFillTable() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS copy_trade_seen_fills (
+			trader_id TEXT NOT NULL,
+			fill_id TEXT NOT NULL,
+			seen_at TEXT NOT NULL,
+			PRIMARY KEY (trader_id, fill_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_seen_fills_time ON copy_trade_seen_fills(trader_id, seen_at)`)
+	return nil
+}
+
+// MarkFillSeen 记录成交已处理（重复记录时刷新时间）
+func (s *CopyTradeStore) MarkFillSeen(traderID, fillID string, seenAt time.Time) error {
+	_, err := s.db.Exec(`
+		INSERT INTO copy_trade_seen_fills (trader_id, fill_id, seen_at) VALUES (?, ?, ?)
+		ON CONFLICT(trader_id, fill_id) DO UPDATE SET seen_at = excluded.seen_at
+	`, traderID, fillID, seenAt.UTC().Format(seenFillTimeFormat))
+	return err
+}
+
+// IsFillSeen 成交是否在 since 之后被处理过
+func (s *CopyTradeStore) IsFillSeen(traderID, fillID string, since time.Time) (bool, error) {
+	var n int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM copy_trade_seen_fills WHERE trader_id = ? AND fill_id = ? AND seen_at >= ?
+	`, traderID, fillID, since.UTC().Format(seenFillTimeFormat)).Scan(&n)
+	return n > 0, err
+}
+
+// CleanSeenFills 删除 before 之前的已处理成交记录，返回删除条数
+func (s *CopyTradeStore) CleanSeenFills(traderID string, before time.Time) (int64, error) {
+	res, err := s.db.Exec(`
+		DELETE FROM copy_trade_seen_fills WHERE trader_id = ? AND seen_at < ?
+	`, traderID, before.UTC().Format(seenFillTimeFormat))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,230 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// 影子跟单记录的配置变体
+const (
+	ShadowVariantLive   = "live"   // 实盘配置（按领航员成交价的假设结果，便于与影子同口径对比）
+	ShadowVariantShadow = "shadow" // 影子配置
+)
+
+// ShadowDecision 影子跟单决策记录（不下单，只记录假设成交）
+type ShadowDecision struct {
+	ID          int64     `json:"id"`
+	TraderID    string    `json:"trader_id"`
+	LeaderID    string    `json:"leader_id"`
+	Variant     string    `json:"variant"` // live | shadow
+	Label       string    `json:"label"`
+	LeaderPosID string    `json:"leader_pos_id"`
+	Symbol      string    `json:"symbol"`
+	Side        string    `json:"side"`
+	Action      string    `json:"action"` // open | add | reduce | close
+	Price       float64   `json:"price"`
+	Notional    float64   `json:"notional"`
+	Quantity    float64   `json:"quantity"`
+	RealizedPnL float64   `json:"realized_pnl"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ShadowPosition 影子跟单的假设持仓（按领航员仓位 ID）
+type ShadowPosition struct {
+	TraderID    string  `json:"trader_id"`
+	Variant     string  `json:"variant"`
+	LeaderPosID string  `json:"leader_pos_id"`
+	Symbol      string  `json:"symbol"`
+	Side        string  `json:"side"`
+	Quantity    float64 `json:"quantity"`
+	EntryPrice  float64 `json:"entry_price"`
+}
+
+// ShadowSummary 单个配置变体的假设盈亏汇总
+type ShadowSummary struct {
+	Variant       string  `json:"variant"`
+	Label         string  `json:"label"`
+	Decisions     int     `json:"decisions"`
+	Opens         int     `json:"opens"`
+	Closes        int     `json:"closes"`
+	TotalNotional float64 `json:"total_notional"` // 开仓 + 加仓累计金额
+	RealizedPnL   float64 `json:"realized_pnl"`
+	OpenPositions int     `json:"open_positions"`
+	OpenNotional  float64 `json:"open_notional"` // 未平假设持仓按开仓均价计算的金额
+}
+
+func (s *CopyTradeStore) initShadowTables() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS copy_trade_shadow_decisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			trader_id TEXT NOT NULL,
+			leader_id TEXT NOT NULL,
+			variant TEXT NOT NULL,
+			label TEXT DEFAULT '',
+			leader_pos_id TEXT DEFAULT '',
+			symbol TEXT NOT NULL,
+			side TEXT NOT NULL,
+			action TEXT NOT NULL,
+			price REAL DEFAULT 0,
+			notional REAL DEFAULT 0,
+			quantity REAL DEFAULT 0,
+			realized_pnl REAL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_shadow_decisions_trader ON copy_trade_shadow_decisions(trader_id, variant)`)
+
+	_, err = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS copy_trade_shadow_positions (
+			trader_id TEXT NOT NULL,
+			variant TEXT NOT NULL,
+			leader_pos_id TEXT NOT NULL,
+			symbol TEXT NOT NULL,
+			side TEXT NOT NULL,
+			quantity REAL DEFAULT 0,
+			entry_price REAL DEFAULT 0,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (trader_id, variant, leader_pos_id)
+		)
+	`)
+	return err
+}
+
+// GetShadowPosition 获取影子假设持仓（不存在返回 nil）
+func (s *CopyTradeStore) GetShadowPosition(traderID, variant, leaderPosID string) (*ShadowPosition, error) {
+	p := &ShadowPosition{TraderID: traderID, Variant: variant, LeaderPosID: leaderPosID}
+	err := s.db.QueryRow(`
+		SELECT symbol, side, quantity, entry_price FROM copy_trade_shadow_positions
+		WHERE trader_id = ? AND variant = ? AND leader_pos_id = ?
+	`, traderID, variant, leaderPosID).Scan(&p.Symbol, &p.Side, &p.Quantity, &p.EntryPrice)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// RecordShadowDecision 记录影子决策并更新假设持仓（pos 为 nil 或数量为 0 时删除持仓），同一事务内完成
+func (s *CopyTradeStore) RecordShadowDecision(d *ShadowDecision, pos *ShadowPosition) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO copy_trade_shadow_decisions
+		(trader_id, leader_id, variant, label, leader_pos_id, symbol, side, action, price, notional, quantity, realized_pnl)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.TraderID, d.LeaderID, d.Variant, d.Label, d.LeaderPosID, d.Symbol, d.Side, d.Action,
+		d.Price, d.Notional, d.Quantity, d.RealizedPnL)
+	if err != nil {
+		return fmt.Errorf("failed to insert shadow decision: %w", err)
+	}
+
+	if pos == nil || pos.Quantity <= 0 {
+		_, err = tx.Exec(`DELETE FROM copy_trade_shadow_positions WHERE trader_id = ? AND variant = ? AND leader_pos_id = ?`,
+			d.TraderID, d.Variant, d.LeaderPosID)
+	} else {
+		_, err = tx.Exec(`
+			INSERT INTO copy_trade_shadow_positions (trader_id, variant, leader_pos_id, symbol, side, quantity, entry_price, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(trader_id, variant, leader_pos_id) DO UPDATE SET
+				quantity = excluded.quantity, entry_price = excluded.entry_price, updated_at = CURRENT_TIMESTAMP
+		`, pos.TraderID, pos.Variant, pos.LeaderPosID, pos.Symbol, pos.Side, pos.Quantity, pos.EntryPrice)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update shadow position: %w", err)
+	}
+	return tx.Commit()
+}
+
+// GetShadowComparison 按配置变体汇总假设盈亏（live 在前）
+func (s *CopyTradeStore) GetShadowComparison(traderID string) ([]ShadowSummary, error) {
+	rows, err := s.db.Query(`
+		SELECT variant, MAX(label), COUNT(*),
+			SUM(CASE WHEN action = 'open' THEN 1 ELSE 0 END),
+			SUM(CASE WHEN action = 'close' THEN 1 ELSE 0 END),
+			COALESCE(SUM(CASE WHEN action IN ('open', 'add') THEN notional ELSE 0 END), 0),
+			COALESCE(SUM(realized_pnl), 0)
+		FROM copy_trade_shadow_decisions
+		WHERE trader_id = ?
+		GROUP BY variant
+		ORDER BY CASE variant WHEN 'live' THEN 0 ELSE 1 END
+	`, traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []ShadowSummary
+	index := make(map[string]int)
+	for rows.Next() {
+		var sum ShadowSummary
+		if err := rows.Scan(&sum.Variant, &sum.Label, &sum.Decisions, &sum.Opens, &sum.Closes,
+			&sum.TotalNotional, &sum.RealizedPnL); err != nil {
+			return nil, err
+		}
+		index[sum.Variant] = len(result)
+		result = append(result, sum)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	posRows, err := s.db.Query(`
+		SELECT variant, COUNT(*), COALESCE(SUM(quantity * entry_price), 0)
+		FROM copy_trade_shadow_positions WHERE trader_id = ? GROUP BY variant
+	`, traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer posRows.Close()
+	for posRows.Next() {
+		var variant string
+		var count int
+		var notional float64
+		if err := posRows.Scan(&variant, &count, &notional); err != nil {
+			return nil, err
+		}
+		if i, ok := index[variant]; ok {
+			result[i].OpenPositions = count
+			result[i].OpenNotional = notional
+		}
+	}
+	return result, posRows.Err()
+}
+
+// GetRecentShadowDecisions 获取最近的影子决策记录
+func (s *CopyTradeStore) GetRecentShadowDecisions(traderID string, limit int) ([]*ShadowDecision, error) {
+	rows, err := s.db.Query(`
+		SELECT id, trader_id, leader_id, variant, COALESCE(label, ''), COALESCE(leader_pos_id, ''),
+			symbol, side, action, price, notional, quantity, realized_pnl, created_at
+		FROM copy_trade_shadow_decisions
+		WHERE trader_id = ?
+		ORDER BY id DESC LIMIT ?
+	`, traderID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*ShadowDecision
+	for rows.Next() {
+		d := &ShadowDecision{}
+		var createdAt string
+		if err := rows.Scan(&d.ID, &d.TraderID, &d.LeaderID, &d.Variant, &d.Label, &d.LeaderPosID,
+			&d.Symbol, &d.Side, &d.Action, &d.Price, &d.Notional, &d.Quantity, &d.RealizedPnL, &createdAt); err != nil {
+			return nil, err
+		}
+		d.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,312 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestCompactSignalLogs 压缩后原始日志删除，按状态计数保持不变
+func TestCompactSignalLogs(t *testing.T) {
+	st, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	ct := st.CopyTrade()
+
+	now := time.Now().UTC().Truncate(time.Hour)
+	dayStart := now.Add(-10 * time.Hour)
+	insert := func(id int, status string, at time.Time) {
+		t.Helper()
+		if _, err := st.db.Exec(`
+			INSERT INTO copy_trade_signal_logs (trader_id, leader_id, provider_type, signal_id, symbol, action, position_side, status, created_at)
+			VALUES ('t1', 'leader', 'okx', ?, 'BTCUSDT', 'open', 'long', ?, ?)
+		`, fmt.Sprintf("s%d", id), status, at.Format("2006-01-02 15:04:05")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	insert(1, "executed", dayStart.Add(time.Hour+time.Minute))
+	insert(2, "executed", dayStart.Add(time.Hour+2*time.Minute))
+	insert(3, "skipped", dayStart.Add(2*time.Hour))
+	insert(4, "failed", now.Add(-time.Minute))
+	insert(5, "executed", dayStart.Add(-time.Hour)) // 统计窗口之前
+
+	before, err := ct.CountSignalsByStatus(dayStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := ct.CompactSignalLogs(now.Add(-5 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 4 {
+		t.Errorf("deleted = %d, want 4", deleted)
+	}
+
+	after, err := ct.CountSignalsByStatus(dayStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"executed": 2, "skipped": 1, "failed": 1}
+	for status, n := range want {
+		if before[status] != n || after[status] != n {
+			t.Errorf("%s: before=%d after=%d, want %d", status, before[status], after[status], n)
+		}
+	}
+
+	rollups, err := ct.GetSignalRollups("t1", dayStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rollups) != 2 {
+		t.Fatalf("rollups = %d, want 2", len(rollups))
+	}
+	hour := dayStart.Add(time.Hour).Format("2006-01-02 15:04:05")
+	for _, r := range rollups {
+		if r.Status == "executed" && (r.Hour != hour || r.Count != 2) {
+			t.Errorf("executed rollup = %+v, want hour=%s count=2", r, hour)
+		}
+	}
+
+	// 重复压缩幂等：没有新的原始日志可压缩
+	if deleted, err := ct.CompactSignalLogs(now.Add(-5 * time.Hour)); err != nil || deleted != 0 {
+		t.Errorf("second compaction deleted=%d err=%v", deleted, err)
+	}
+}
+
+// TestCopyTradeWarnings 预警记录按时间倒序查询、按类型过滤，裁剪后只保留最近 N 条
+func TestCopyTradeWarnings(t *testing.T) {
+	st, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	ct := st.CopyTrade()
+
+	start := time.Now().UTC().Truncate(time.Second)
+	for i := 0; i < 5; i++ {
+		warnType := "low_value"
+		if i%2 == 1 {
+			warnType = "high_value"
+		}
+		if err := ct.SaveWarning(&CopyTradeWarning{
+			TraderID: "t1", Type: warnType, Symbol: "BTCUSDT", Message: fmt.Sprintf("w%d", i),
+			CreatedAt: start.Add(time.Duration(i) * time.Second),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ct.SaveWarning(&CopyTradeWarning{TraderID: "t2", Type: "low_value", Message: "other"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ct.GetRecentWarnings("t1", "", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0].Message != "w4" || got[2].Message != "w2" {
+		t.Fatalf("recent warnings = %+v, want w4..w2", got)
+	}
+	if !got[0].CreatedAt.Equal(start.Add(4 * time.Second)) {
+		t.Errorf("created_at = %v, want %v", got[0].CreatedAt, start.Add(4*time.Second))
+	}
+
+	high, err := ct.GetRecentWarnings("t1", "high_value", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(high) != 2 {
+		t.Errorf("high_value warnings = %d, want 2", len(high))
+	}
+
+	deleted, err := ct.TrimWarnings("t1", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 3 {
+		t.Errorf("trimmed %d, want 3", deleted)
+	}
+	if left, _ := ct.GetRecentWarnings("t1", "", 10); len(left) != 2 || left[1].Message != "w3" {
+		t.Errorf("after trim = %+v, want w4, w3", left)
+	}
+	if other, _ := ct.GetRecentWarnings("t2", "", 10); len(other) != 1 {
+		t.Errorf("other trader warnings = %d, want 1 (untouched)", len(other))
+	}
+}
+
+// TestCountCloseTriggers 已执行的平仓/减仓按触发来源统计，空来源计为 manual
+func TestCountCloseTriggers(t *testing.T) {
+	st, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	ct := st.CopyTrade()
+
+	n := 0
+	save := func(action, status, trigger string) {
+		t.Helper()
+		n++
+		if err := ct.SaveSignalLog(&CopyTradeSignalLog{
+			TraderID: "t1", LeaderID: "leader", ProviderType: "okx", SignalID: fmt.Sprintf("s%d", n),
+			Symbol: "BTCUSDT", Action: action, PositionSide: "long", Status: status, CloseTrigger: trigger,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("close_long", "executed", "take_profit")
+	save("reduce_long", "executed", "take_profit")
+	save("close_long", "executed", "stop_loss")
+	save("close_long", "executed", "")
+	save("close_long", "failed", "stop_loss") // 未执行不计入
+	save("open_long", "executed", "")         // 开仓不计入
+
+	counts, err := ct.CountCloseTriggers("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"take_profit": 2, "stop_loss": 1, "manual": 1}
+	if len(counts) != len(want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+	for k, n := range want {
+		if counts[k] != n {
+			t.Errorf("%s = %d, want %d", k, counts[k], n)
+		}
+	}
+
+	logs, err := ct.GetRecentSignalLogs("t1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagged := 0
+	for _, l := range logs {
+		if l.CloseTrigger != "" {
+			tagged++
+		}
+	}
+	if tagged != 4 {
+		t.Errorf("logs with close_trigger = %d, want 4", tagged)
+	}
+}
+
+// TestGetLeaderReturnDistribution 领航员平仓盈亏按映射关联开仓价，汇总为按领航员的收益率分布
+func TestGetLeaderReturnDistribution(t *testing.T) {
+	st, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	ct := st.CopyTrade()
+
+	open := func(posID, leaderID string, openPrice float64) {
+		t.Helper()
+		if err := ct.SavePositionMapping(&CopyTradePositionMapping{
+			TraderID: "t1", LeaderPosID: posID, LeaderID: leaderID, Symbol: "BTCUSDT", Side: "long",
+			MarginMode: "cross", OpenedAt: time.Now(), OpenPrice: openPrice, OpenSizeUSD: 100,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record := func(posID, fillID string, pnl, size float64) {
+		t.Helper()
+		if err := ct.SaveLeaderClosedPnL("t1", posID, fillID, pnl, size); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// p1：分两次平仓，合计盈利 30 / (3 × 100) = +10%
+	open("p1", "leaderA", 100)
+	record("p1", "f1", 10, 1)
+	record("p1", "f2", 20, 2)
+	record("p1", "f2", 20, 2) // 重复成交忽略
+	ct.CloseMapping("t1", "p1", 110, 5)
+
+	// p2：亏损 -3 / (1 × 100) = -3%
+	open("p2", "leaderA", 100)
+	record("p2", "f3", -3, 1)
+	ct.CloseMapping("t1", "p2", 97, -1)
+
+	// p3：未平仓不计入；未跟随的仓位不记录
+	open("p3", "leaderA", 100)
+	record("p3", "f4", 50, 1)
+	record("unknown", "f5", 50, 1)
+
+	// 另一领航员：+1%
+	open("p4", "leaderB", 200)
+	record("p4", "f6", 2, 1)
+	ct.CloseMapping("t1", "p4", 202, 1)
+
+	dists, err := ct.GetLeaderReturnDistribution("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dists) != 2 || dists[0].LeaderID != "leaderA" || dists[1].LeaderID != "leaderB" {
+		t.Fatalf("distributions = %+v, want leaderA, leaderB", dists)
+	}
+
+	a := dists[0]
+	if a.Positions != 2 || math.Abs(a.AvgReturnPct-3.5) > 1e-9 || math.Abs(a.MedianReturnPct-3.5) > 1e-9 ||
+		math.Abs(a.MinReturnPct+3) > 1e-9 || math.Abs(a.MaxReturnPct-10) > 1e-9 || a.WinRate != 50 {
+		t.Errorf("leaderA = %+v", a)
+	}
+	counts := make(map[string]int)
+	for _, b := range a.Buckets {
+		counts[b.Label] = b.Count
+	}
+	// +10% 落在 [10, +∞)，-3% 落在 [-5, -2)
+	if counts[">= 10%"] != 1 || counts["-5% ~ -2%"] != 1 || len(a.Buckets) != 8 {
+		t.Errorf("leaderA buckets = %+v", a.Buckets)
+	}
+
+	if b := dists[1]; b.Positions != 1 || math.Abs(b.AvgReturnPct-1) > 1e-9 {
+		t.Errorf("leaderB = %+v", b)
+	}
+}
+
+// TestSeenFills 已处理成交按 trader 隔离，过期记录可清理
+func TestSeenFills(t *testing.T) {
+	st, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	ct := st.CopyTrade()
+
+	now := time.Now()
+	if err := ct.MarkFillSeen("t1", "f1", now.Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ct.MarkFillSeen("t1", "f2", now); err != nil {
+		t.Fatal(err)
+	}
+
+	since := now.Add(-time.Hour)
+	for _, c := range []struct {
+		trader, fill string
+		want         bool
+	}{{"t1", "f2", true}, {"t1", "f1", false}, {"t2", "f2", false}, {"t1", "f3", false}} {
+		if got, err := ct.IsFillSeen(c.trader, c.fill, since); err != nil || got != c.want {
+			t.Errorf("IsFillSeen(%s, %s) = %v err=%v, want %v", c.trader, c.fill, got, err, c.want)
+		}
+	}
+
+	// 重复标记刷新时间
+	if err := ct.MarkFillSeen("t1", "f1", now); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := ct.IsFillSeen("t1", "f1", since); !got {
+		t.Error("re-marked fill not seen")
+	}
+
+	if err := ct.MarkFillSeen("t1", "old", now.Add(-3*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := ct.CleanSeenFills("t1", since); err != nil || n != 1 {
+		t.Errorf("CleanSeenFills = %d err=%v, want 1", n, err)
+	}
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"time"
+)
+
+// DefaultWarningHistoryLimit 每个 trader 默认保留的预警记录数
+const DefaultWarningHistoryLimit = 1000
+
+// CopyTradeWarning 跟单预警记录
+type CopyTradeWarning struct {
+	ID           int64     `json:"id"`
+	TraderID     string    `json:"trader_id"`
+	Type         string    `json:"type"`
+	Symbol       string    `json:"symbol"`
+	Message      string    `json:"message"`
+	SignalAction string    `json:"signal_action"`
+	SignalValue  float64   `json:"signal_value"`
+	Executed     bool      `json:"executed"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (s *CopyTradeStore) initWarningTable() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS copy_trade_warnings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			trader_id TEXT NOT NULL,
+			type TEXT NOT NULL,
+			symbol TEXT DEFAULT '',
+			message TEXT DEFAULT '',
+			signal_action TEXT DEFAULT '',
+			signal_value REAL DEFAULT 0,
+			executed BOOLEAN DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_warnings_trader ON copy_trade_warnings(trader_id, id)`)
+	return nil
+}
+
+// SaveWarning 保存预警记录
+func (s *CopyTradeStore) SaveWarning(w *CopyTradeWarning) error {
+	createdAt := w.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO copy_trade_warnings (trader_id, type, symbol, message, signal_action, signal_value, executed, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, w.TraderID, w.Type, w.Symbol, w.Message, w.SignalAction, w.SignalValue, w.Executed,
+		createdAt.UTC().Format("2006-01-02 15:04:05"))
+	return err
+}
+
+// GetRecentWarnings 获取最近的预警记录（warnType 为空时不过滤类型），按时间倒序
+func (s *CopyTradeStore) GetRecentWarnings(traderID, warnType string, limit int) ([]*CopyTradeWarning, error) {
+	rows, err := s.db.Query(`
+		SELECT id, trader_id, type, symbol, message, signal_action, signal_value, executed, created_at
+		FROM copy_trade_warnings
+		WHERE trader_id = ? AND (? = '' OR type = ?)
+		ORDER BY id DESC
+		LIMIT ?
+	`, traderID, warnType, warnType, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var warnings []*CopyTradeWarning
+	for rows.Next() {
+		var w CopyTradeWarning
+		var createdAt string
+		if err := rows.Scan(&w.ID, &w.TraderID, &w.Type, &w.Symbol, &w.Message,
+			&w.SignalAction, &w.SignalValue, &w.Executed, &createdAt); err != nil {
+			return nil, err
+		}
+		w.CreatedAt = parseMappingTime(createdAt)
+		warnings = append(warnings, &w)
+	}
+	return warnings, rows.Err()
+}
+
+// TrimWarnings 只保留 trader 最近 keep 条预警记录，返回删除的条数
+func (s *CopyTradeStore) TrimWarnings(traderID string, keep int) (int64, error) {
+	res, err := s.db.Exec(`
+		DELETE FROM copy_trade_warnings
+		WHERE trader_id = ? AND id NOT IN (
+			SELECT id FROM copy_trade_warnings WHERE trader_id = ? ORDER BY id DESC LIMIT ?
+		)
+	`, traderID, traderID, keep)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
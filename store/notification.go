@@ -0,0 +1,106 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// NotificationStore notification channel storage
+type NotificationStore struct {
+	db          *sql.DB
+	encryptFunc func(string) string
+	decryptFunc func(string) string
+}
+
+// NotificationConfig per-user notification settings (followed trades and critical alerts)
+type NotificationConfig struct {
+	UserID           string    `json:"user_id"`
+	Enabled          bool      `json:"enabled"`
+	TelegramBotToken string    `json:"telegram_bot_token,omitempty"` // Never returned by the API
+	TelegramChatID   string    `json:"telegram_chat_id"`
+	MinTradeUSD      float64   `json:"min_trade_usd"` // Only notify followed trades at least this large (0 = all); failures are always sent
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// Ready reports whether notifications can be delivered
+func (c *NotificationConfig) Ready() bool {
+	return c != nil && c.Enabled && c.TelegramBotToken != "" && c.TelegramChatID != ""
+}
+
+func (s *NotificationStore) initTables() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS notification_configs (
+			user_id TEXT PRIMARY KEY,
+			enabled BOOLEAN DEFAULT 0,
+			telegram_bot_token TEXT DEFAULT '',
+			telegram_chat_id TEXT DEFAULT '',
+			min_trade_usd REAL DEFAULT 0,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	return err
+}
+
+func (s *NotificationStore) encrypt(plaintext string) string {
+	if s.encryptFunc != nil {
+		return s.encryptFunc(plaintext)
+	}
+	return plaintext
+}
+
+func (s *NotificationStore) decrypt(encrypted string) string {
+	if s.decryptFunc != nil {
+		return s.decryptFunc(encrypted)
+	}
+	return encrypted
+}
+
+// Get gets a user's notification config; a user without a config gets a disabled one
+func (s *NotificationStore) Get(userID string) (*NotificationConfig, error) {
+	cfg := &NotificationConfig{UserID: userID}
+	var token, updatedAt string
+	err := s.db.QueryRow(`
+		SELECT enabled, telegram_bot_token, telegram_chat_id, min_trade_usd, updated_at
+		FROM notification_configs WHERE user_id = ?
+	`, userID).Scan(&cfg.Enabled, &token, &cfg.TelegramChatID, &cfg.MinTradeUSD, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
+	if token != "" {
+		cfg.TelegramBotToken = s.decrypt(token)
+	}
+	return cfg, nil
+}
+
+// GetByTrader gets the notification config of the trader's owner
+func (s *NotificationStore) GetByTrader(traderID string) (*NotificationConfig, error) {
+	var userID string
+	if err := s.db.QueryRow(`SELECT user_id FROM traders WHERE id = ?`, traderID).Scan(&userID); err != nil {
+		return nil, err
+	}
+	return s.Get(userID)
+}
+
+// Upsert saves a user's notification config. An empty bot token keeps the stored one.
+func (s *NotificationStore) Upsert(cfg *NotificationConfig) error {
+	token := ""
+	if cfg.TelegramBotToken != "" {
+		token = s.encrypt(cfg.TelegramBotToken)
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO notification_configs (user_id, enabled, telegram_bot_token, telegram_chat_id, min_trade_usd, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(user_id) DO UPDATE SET
+			enabled = excluded.enabled,
+			telegram_bot_token = CASE WHEN excluded.telegram_bot_token != '' THEN excluded.telegram_bot_token ELSE telegram_bot_token END,
+			telegram_chat_id = excluded.telegram_chat_id,
+			min_trade_usd = excluded.min_trade_usd,
+			updated_at = CURRENT_TIMESTAMP
+	`, cfg.UserID, cfg.Enabled, token, cfg.TelegramChatID, cfg.MinTradeUSD)
+	return err
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNotificationConfig(t *testing.T) {
+	st, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	ns := st.Notification()
+
+	cfg, err := ns.Get("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Enabled || cfg.Ready() {
+		t.Fatalf("default config = %+v, want disabled", cfg)
+	}
+
+	if err := ns.Upsert(&NotificationConfig{UserID: "u1", Enabled: true, TelegramBotToken: "tok", TelegramChatID: "42", MinTradeUSD: 100}); err != nil {
+		t.Fatal(err)
+	}
+	// Empty token keeps the stored one
+	if err := ns.Upsert(&NotificationConfig{UserID: "u1", Enabled: true, TelegramChatID: "43"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = ns.Get("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Ready() || cfg.TelegramBotToken != "tok" || cfg.TelegramChatID != "43" || cfg.MinTradeUSD != 0 {
+		t.Errorf("config = %+v", cfg)
+	}
+
+	if other, _ := ns.Get("u2"); other.Ready() {
+		t.Error("config leaked to another user")
+	}
+}
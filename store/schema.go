@@ -0,0 +1,115 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"nofx/logger"
+)
+
+// schemaColumn 表字段定义
+type schemaColumn struct {
+	Table      string
+	Column     string
+	Definition string // ALTER TABLE ADD COLUMN 使用的定义
+}
+
+// migratedColumns 后续版本新增的字段，旧库缺失时自动补齐
+var migratedColumns = []schemaColumn{
+	{"traders", "decision_mode", "TEXT DEFAULT 'ai'"},
+	{"copy_trade_configs", "options_json", "TEXT DEFAULT '{}'"},
+	{"copy_trade_position_mappings", "last_known_size", "REAL DEFAULT 0"},
+	{"copy_trade_position_mappings", "realized_pnl", "REAL DEFAULT 0"},
+	{"copy_trade_position_mappings", "reduced_fraction", "REAL DEFAULT 0"},
+	{"copy_trade_position_mappings", "open_qty", "REAL DEFAULT 0"},
+	{"copy_trade_position_mappings", "avg_entry_price", "REAL DEFAULT 0"},
+	{"copy_trade_position_mappings", "pnl_estimated", "INTEGER DEFAULT 0"},
+	{"copy_trade_position_mappings", "expected_size", "REAL DEFAULT 0"},
+	{"copy_trade_position_mappings", "adopted_size", "REAL DEFAULT 0"},
+	{"copy_trade_signal_logs", "close_trigger", "TEXT DEFAULT ''"},
+}
+
+// requiredColumns 运行时读写依赖的字段，缺失且无法补齐时拒绝启动
+var requiredColumns = map[string][]string{
+	"copy_trade_configs": {
+		"trader_id", "provider_type", "leader_id", "copy_ratio", "sync_leverage", "sync_margin_mode",
+		"min_trade_warn", "max_trade_warn", "enabled", "options_json", "created_at", "updated_at",
+	},
+	"copy_trade_signal_logs": {
+		"trader_id", "leader_id", "provider_type", "signal_id", "symbol", "action", "position_side",
+		"leader_price", "leader_value", "copy_size", "followed", "follow_reason", "warnings_json",
+		"status", "error_message", "close_trigger", "created_at",
+	},
+	"copy_trade_position_mappings": {
+		"id", "trader_id", "leader_pos_id", "leader_id", "symbol", "side", "margin_mode", "status",
+		"opened_at", "open_price", "open_size_usd", "last_known_size", "closed_at", "close_price",
+		"realized_pnl", "add_count", "reduce_count", "reduced_fraction", "open_qty", "avg_entry_price",
+		"pnl_estimated", "expected_size", "adopted_size", "updated_at",
+	},
+	"traders": {"id", "decision_mode"},
+}
+
+// verifySchema 启动自检：补齐缺失字段，并确认运行时依赖的字段全部存在
+func (s *Store) verifySchema() error {
+	for _, col := range migratedColumns {
+		columns, err := tableColumns(s.db, col.Table)
+		if err != nil {
+			return err
+		}
+		if len(columns) == 0 {
+			return fmt.Errorf("table %s does not exist", col.Table)
+		}
+		if columns[col.Column] {
+			continue
+		}
+
+		if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", col.Table, col.Column, col.Definition)); err != nil {
+			return fmt.Errorf("add column %s.%s: %w", col.Table, col.Column, err)
+		}
+		logger.Infof("✅ Schema migration: added column %s.%s", col.Table, col.Column)
+	}
+
+	for table, required := range requiredColumns {
+		columns, err := tableColumns(s.db, table)
+		if err != nil {
+			return err
+		}
+		if len(columns) == 0 {
+			return fmt.Errorf("table %s does not exist", table)
+		}
+
+		var missing []string
+		for _, name := range required {
+			if !columns[name] {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("table %s is incompatible, missing columns: %s", table, strings.Join(missing, ", "))
+		}
+	}
+
+	return nil
+}
+
+// tableColumns 读取表字段（表不存在时返回空集合）
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, fmt.Errorf("read schema of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var cid, notnull, pk int
+		var name, ctype string
+		var dflt interface{}
+		if err := rows.Scan(&cid, &name, &ctype, &notnull, &dflt, &pk); err != nil {
+			return nil, fmt.Errorf("read schema of %s: %w", table, err)
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
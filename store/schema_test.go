@@ -0,0 +1,56 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestVerifySchemaMigratesOldDatabase 旧库缺失新增字段时自动补齐，字段不兼容时拒绝启动
+func TestVerifySchemaMigratesOldDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 旧版本的映射表：没有 last_known_size / realized_pnl
+	if _, err := db.Exec(`
+		CREATE TABLE copy_trade_position_mappings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			trader_id TEXT NOT NULL, leader_pos_id TEXT NOT NULL, leader_id TEXT NOT NULL,
+			symbol TEXT NOT NULL, side TEXT NOT NULL, margin_mode TEXT NOT NULL, status TEXT DEFAULT 'active',
+			opened_at DATETIME, open_price REAL DEFAULT 0, open_size_usd REAL DEFAULT 0,
+			closed_at DATETIME, close_price REAL DEFAULT 0,
+			add_count INTEGER DEFAULT 0, reduce_count INTEGER DEFAULT 0, updated_at DATETIME,
+			UNIQUE(trader_id, leader_pos_id)
+		)`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	st, err := New(path)
+	if err != nil {
+		t.Fatalf("open old database: %v", err)
+	}
+	defer st.Close()
+
+	columns, err := tableColumns(st.db, "copy_trade_position_mappings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"last_known_size", "realized_pnl"} {
+		if !columns[name] {
+			t.Errorf("column %s not migrated", name)
+		}
+	}
+
+	// 不兼容：缺少无法自动补齐的字段
+	if _, err := st.db.Exec(`ALTER TABLE copy_trade_position_mappings DROP COLUMN reduce_count`); err != nil {
+		t.Fatal(err)
+	}
+	err = st.verifySchema()
+	if err == nil || !strings.Contains(err.Error(), "reduce_count") {
+		t.Errorf("verifySchema error = %v, want missing reduce_count", err)
+	}
+}
@@ -13,7 +13,11 @@ import (
 
 // Store unified data storage interface
 type Store struct {
-	db *sql.DB
+	db     *sql.DB
+	dbPath string
+
+	// Optional read-only connection pool for heavy read queries (dashboard)
+	readDB *sql.DB
 
 	// Sub-stores (lazy initialization)
 	user      *UserStore
@@ -27,6 +31,8 @@ type Store struct {
 	equity    *EquityStore
 	copyTrade *CopyTradeStore
 
+	notification *NotificationStore
+
 	// Encryption functions
 	encryptFunc func(string) string
 	decryptFunc func(string) string
@@ -70,7 +76,7 @@ func New(dbPath string) (*Store, error) {
 		return nil, fmt.Errorf("failed to set busy_timeout: %w", err)
 	}
 
-	s := &Store{db: db}
+	s := &Store{db: db, dbPath: dbPath}
 
 	// Initialize all table structures
 	if err := s.initTables(); err != nil {
@@ -112,6 +118,10 @@ func (s *Store) SetCryptoFuncs(encrypt, decrypt func(string) string) {
 	if s.trader != nil {
 		s.trader.decryptFunc = decrypt
 	}
+	if s.notification != nil {
+		s.notification.encryptFunc = encrypt
+		s.notification.decryptFunc = decrypt
+	}
 }
 
 // initTables initializes all database tables
@@ -153,6 +163,12 @@ func (s *Store) initTables() error {
 	if err := s.CopyTrade().initPositionMappingTable(); err != nil {
 		return fmt.Errorf("failed to initialize copy trade position mapping table: %w", err)
 	}
+	if err := s.Notification().initTables(); err != nil {
+		return fmt.Errorf("failed to initialize notification tables: %w", err)
+	}
+	if err := s.verifySchema(); err != nil {
+		return fmt.Errorf("database schema check failed: %w", err)
+	}
 	return nil
 }
 
@@ -287,8 +303,28 @@ func (s *Store) CopyTrade() *CopyTradeStore {
 	return s.copyTrade
 }
 
+// Notification gets notification config storage
+func (s *Store) Notification() *NotificationStore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.notification == nil {
+		s.notification = &NotificationStore{
+			db:          s.db,
+			encryptFunc: s.encryptFunc,
+			decryptFunc: s.decryptFunc,
+		}
+	}
+	return s.notification
+}
+
 // Close closes database connection
 func (s *Store) Close() error {
+	s.mu.Lock()
+	if s.readDB != nil {
+		s.readDB.Close()
+		s.readDB = nil
+	}
+	s.mu.Unlock()
 	return s.db.Close()
 }
 
@@ -298,6 +334,53 @@ func (s *Store) DB() *sql.DB {
 	return s.db
 }
 
+// OpenReadDB opens a separate read-only connection pool for heavy read queries.
+// The main connection is limited to a single connection, so long aggregate
+// queries on it block the trading path; readers on their own pool only share
+// SQLite's file lock, which allows concurrent reads.
+func (s *Store) OpenReadDB(maxConns int) error {
+	if s.dbPath == "" {
+		return fmt.Errorf("database path unknown")
+	}
+	if maxConns <= 0 {
+		maxConns = 1
+	}
+
+	dsn := "file:" + s.dbPath + "?mode=ro&_pragma=busy_timeout(5000)&_pragma=query_only(1)"
+	readDB, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open read-only database: %w", err)
+	}
+	readDB.SetMaxOpenConns(maxConns)
+	readDB.SetMaxIdleConns(maxConns)
+
+	if err := readDB.Ping(); err != nil {
+		readDB.Close()
+		return fmt.Errorf("failed to open read-only database: %w", err)
+	}
+
+	s.mu.Lock()
+	old := s.readDB
+	s.readDB = readDB
+	s.mu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+
+	logger.Infof("✅ Read-only database connection enabled (max %d connections)", maxConns)
+	return nil
+}
+
+// ReadDB returns the read-only connection pool, or the main connection if none is configured
+func (s *Store) ReadDB() *sql.DB {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.readDB != nil {
+		return s.readDB
+	}
+	return s.db
+}
+
 // Transaction executes transaction
 func (s *Store) Transaction(fn func(tx *sql.Tx) error) error {
 	tx, err := s.db.Begin()
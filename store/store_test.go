@@ -0,0 +1,37 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestOpenReadDB 只读连接可以读取主连接写入的数据，且拒绝写入
+func TestOpenReadDB(t *testing.T) {
+	st, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	if st.ReadDB() != st.DB() {
+		t.Fatal("ReadDB should fall back to the main connection")
+	}
+	if err := st.OpenReadDB(2); err != nil {
+		t.Fatal(err)
+	}
+	if st.ReadDB() == st.DB() {
+		t.Fatal("ReadDB should use the read-only pool")
+	}
+
+	if _, err := st.DB().Exec(`INSERT INTO copy_trade_signal_rollups (trader_id, hour, status, action, count) VALUES ('t1', '2024-01-01 00:00:00', 'executed', 'open', 3)`); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	if err := st.ReadDB().QueryRow(`SELECT count FROM copy_trade_signal_rollups WHERE trader_id = 't1'`).Scan(&count); err != nil || count != 3 {
+		t.Fatalf("read via read-only pool: count=%d err=%v", count, err)
+	}
+
+	if _, err := st.ReadDB().Exec(`DELETE FROM copy_trade_signal_rollups`); err == nil {
+		t.Error("write through read-only pool should fail")
+	}
+}
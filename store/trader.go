@@ -101,6 +101,7 @@ func (s *TraderStore) initTables() error {
 		`ALTER TABLE traders ADD COLUMN system_prompt_template TEXT DEFAULT 'default'`,
 		`ALTER TABLE traders ADD COLUMN strategy_id TEXT DEFAULT ''`,
 		`ALTER TABLE traders ADD COLUMN show_in_competition BOOLEAN DEFAULT 1`,
+		`ALTER TABLE traders ADD COLUMN alert_config TEXT DEFAULT ''`,
 	}
 	for _, q := range alterQueries {
 		s.db.Exec(q)
@@ -121,6 +121,8 @@ type AutoTrader struct {
 	peakPnLCacheMutex     sync.RWMutex       // Cache read-write lock
 	lastBalanceSyncTime   time.Time          // Last balance sync time
 	userID                string             // User ID
+	execFill              *executedFill      // Confirmed fills of the current external decision (see LastExecutedFill)
+	execFillMutex         sync.Mutex         // Protects execFill
 }
 
 // NewAutoTrader creates an automatic trader
@@ -825,6 +827,7 @@ func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, act
 // This is a public method that can be called by other modules
 func (at *AutoTrader) ExecuteDecision(d *decision.Decision) error {
 	logger.Infof("[%s] Executing external decision: %s %s", at.name, d.Action, d.Symbol)
+	at.beginExecutedFill(d.Symbol)
 
 	// Create a minimal action record for tracking
 	actionRecord := &store.DecisionAction{
@@ -995,10 +998,13 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 	}
 
 	// Open position
-	order, err := at.trader.OpenLong(decision.Symbol, quantity, decision.Leverage)
+	// Open position (market by default, limit entry when the decision requests it)
+	order, openedQty, err := at.openPosition(decision, "long", quantity, currentPrice)
 	if err != nil {
 		return err
 	}
+	quantity = openedQty
+	actionRecord.Quantity = quantity
 
 	// Record order ID
 	if orderID, ok := order["orderId"].(int64); ok {
@@ -1007,8 +1013,10 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 
 	logger.Infof("  ✓ Position opened successfully, order ID: %v, quantity: %.4f", order["orderId"], quantity)
 
-	// Record order to database and poll for confirmation
-	at.recordAndConfirmOrder(order, decision.Symbol, "open_long", quantity, currentPrice, decision.Leverage, 0)
+	// Record order to database and poll for confirmation (nil when only a partial limit fill was kept, already recorded)
+	if order != nil {
+		at.recordAndConfirmOrder(order, decision.Symbol, "open_long", quantity, currentPrice, decision.Leverage, 0)
+	}
 
 	// Record position opening time
 	posKey := decision.Symbol + "_long"
@@ -1172,10 +1180,13 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 	}
 
 	// Open position
-	order, err := at.trader.OpenShort(decision.Symbol, quantity, decision.Leverage)
+	// Open position (market by default, limit entry when the decision requests it)
+	order, openedQty, err := at.openPosition(decision, "short", quantity, currentPrice)
 	if err != nil {
 		return err
 	}
+	quantity = openedQty
+	actionRecord.Quantity = quantity
 
 	// Record order ID
 	if orderID, ok := order["orderId"].(int64); ok {
@@ -1184,8 +1195,10 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 
 	logger.Infof("  ✓ Position opened successfully, order ID: %v, quantity: %.4f", order["orderId"], quantity)
 
-	// Record order to database and poll for confirmation
-	at.recordAndConfirmOrder(order, decision.Symbol, "open_short", quantity, currentPrice, decision.Leverage, 0)
+	// Record order to database and poll for confirmation (nil when only a partial limit fill was kept, already recorded)
+	if order != nil {
+		at.recordAndConfirmOrder(order, decision.Symbol, "open_short", quantity, currentPrice, decision.Leverage, 0)
+	}
 
 	// Record position opening time
 	posKey := decision.Symbol + "_short"
@@ -1646,6 +1659,56 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 	}, nil
 }
 
+// SupportsSymbol checks whether the exchange lists the symbol
+// Exchanges without the SymbolSupportTrader capability are assumed to support every symbol
+func (at *AutoTrader) SupportsSymbol(symbol string) (bool, error) {
+	if st, ok := at.trader.(SymbolSupportTrader); ok {
+		return st.SupportsSymbol(symbol)
+	}
+	return true, nil
+}
+
+// MinOrderQuantity returns the exchange minimum order quantity in base units
+// Exchanges without the MinQuantityTrader capability report 0 (unknown)
+func (at *AutoTrader) MinOrderQuantity(symbol string) (float64, error) {
+	if mt, ok := at.trader.(MinQuantityTrader); ok {
+		return mt.MinOrderQuantity(symbol)
+	}
+	return 0, nil
+}
+
+// SetLeverage sets the leverage for a symbol on the exchange
+func (at *AutoTrader) SetLeverage(symbol string, leverage int) error {
+	return at.trader.SetLeverage(symbol, leverage)
+}
+
+// SetMarginMode sets cross (true) or isolated (false) margin for a symbol on the exchange
+func (at *AutoTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
+	return at.trader.SetMarginMode(symbol, isCrossMargin)
+}
+
+// SetProtectiveStop places a stop-loss order covering the current position on symbol/side ("long" | "short")
+func (at *AutoTrader) SetProtectiveStop(symbol, side string, stopPrice float64) error {
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return fmt.Errorf("failed to get positions: %w", err)
+	}
+	for _, pos := range positions {
+		if pos["symbol"] != symbol || pos["side"] != side {
+			continue
+		}
+		quantity, _ := pos["positionAmt"].(float64)
+		if quantity < 0 {
+			quantity = -quantity
+		}
+		if quantity == 0 {
+			break
+		}
+		return at.trader.SetStopLoss(symbol, strings.ToUpper(side), quantity, stopPrice)
+	}
+	return fmt.Errorf("no %s position for %s", side, symbol)
+}
+
 // GetPositions gets position list (for API)
 func (at *AutoTrader) GetPositions() ([]map[string]interface{}, error) {
 	positions, err := at.trader.GetPositions()
@@ -1941,6 +2004,7 @@ func (at *AutoTrader) recordAndConfirmOrder(orderResult map[string]interface{},
 	var actualPrice = price  // fallback to market price
 	var actualQty = quantity // fallback to requested quantity
 	var fee float64
+	var confirmed bool
 
 	// Wait for order to be filled and get actual fill data
 	time.Sleep(500 * time.Millisecond)
@@ -1962,6 +2026,7 @@ func (at *AutoTrader) recordAndConfirmOrder(orderResult map[string]interface{},
 					fee = commission
 				}
 				logger.Infof("  ✅ Order filled: avgPrice=%.6f, qty=%.6f, fee=%.6f", actualPrice, actualQty, fee)
+				confirmed = true
 				break
 			} else if statusStr == "CANCELED" || statusStr == "EXPIRED" || statusStr == "REJECTED" {
 				logger.Infof("  ⚠️ Order %s, skipping position record", statusStr)
@@ -1973,6 +2038,7 @@ func (at *AutoTrader) recordAndConfirmOrder(orderResult map[string]interface{},
 
 	logger.Infof("  📝 Recording position (ID: %s, action: %s, price: %.6f, qty: %.6f, fee: %.4f)",
 		orderID, action, actualPrice, actualQty, fee)
+	at.addExecutedFill(symbol, actualQty, actualPrice, confirmed)
 
 	// Record position change with actual fill data
 	at.recordPositionChange(orderID, symbol, positionSide, action, actualQty, actualPrice, leverage, entryPrice, fee)
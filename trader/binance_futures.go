@@ -897,6 +897,43 @@ func (t *FuturesTrader) CheckMinNotional(symbol string, quantity float64) error
 	return nil
 }
 
+// SupportsSymbol checks whether the symbol is listed and trading (implements SymbolSupportTrader)
+func (t *FuturesTrader) SupportsSymbol(symbol string) (bool, error) {
+	exchangeInfo, err := t.client.NewExchangeInfoService().Do(context.Background())
+	if err != nil {
+		return false, fmt.Errorf("failed to get trading rules: %w", err)
+	}
+
+	for _, s := range exchangeInfo.Symbols {
+		if s.Symbol == symbol {
+			return s.Status == "TRADING", nil
+		}
+	}
+	return false, nil
+}
+
+// MinOrderQuantity returns the LOT_SIZE minimum quantity (implements MinQuantityTrader)
+func (t *FuturesTrader) MinOrderQuantity(symbol string) (float64, error) {
+	exchangeInfo, err := t.client.NewExchangeInfoService().Do(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get trading rules: %w", err)
+	}
+
+	for _, s := range exchangeInfo.Symbols {
+		if s.Symbol != symbol {
+			continue
+		}
+		for _, filter := range s.Filters {
+			if filter["filterType"] == "LOT_SIZE" {
+				minQty, _ := filter["minQty"].(string)
+				return strconv.ParseFloat(minQty, 64)
+			}
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("symbol %s not found", symbol)
+}
+
 // GetSymbolPrecision gets the quantity precision for a trading pair
 func (t *FuturesTrader) GetSymbolPrecision(symbol string) (int, error) {
 	exchangeInfo, err := t.client.NewExchangeInfoService().Do(context.Background())
@@ -0,0 +1,45 @@
+package trader
+
+// executedFill accumulates the order fills of one external decision (a limit entry with market
+// fallback can produce two orders). Unconfirmed orders (status polling timed out) make the total unusable.
+type executedFill struct {
+	symbol      string
+	qty         float64
+	notional    float64
+	unconfirmed bool
+}
+
+// beginExecutedFill starts tracking fills for an external decision on symbol
+func (at *AutoTrader) beginExecutedFill(symbol string) {
+	at.execFillMutex.Lock()
+	defer at.execFillMutex.Unlock()
+	at.execFill = &executedFill{symbol: symbol}
+}
+
+// addExecutedFill adds a recorded order fill to the tracked decision
+func (at *AutoTrader) addExecutedFill(symbol string, qty, price float64, confirmed bool) {
+	at.execFillMutex.Lock()
+	defer at.execFillMutex.Unlock()
+	f := at.execFill
+	if f == nil || f.symbol != symbol {
+		return
+	}
+	if !confirmed || qty <= 0 || price <= 0 {
+		f.unconfirmed = true
+		return
+	}
+	f.qty += qty
+	f.notional += qty * price
+}
+
+// LastExecutedFill returns the confirmed fill (average price, quantity) of the last external decision on symbol.
+// ok is false when no order was confirmed filled by the exchange; callers fall back to their own estimate.
+func (at *AutoTrader) LastExecutedFill(symbol string) (price, qty float64, ok bool) {
+	at.execFillMutex.Lock()
+	defer at.execFillMutex.Unlock()
+	f := at.execFill
+	if f == nil || f.symbol != symbol || f.unconfirmed || f.qty <= 0 {
+		return 0, 0, false
+	}
+	return f.notional / f.qty, f.qty, true
+}
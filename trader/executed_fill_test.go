@@ -0,0 +1,36 @@
+package trader
+
+import "testing"
+
+// TestLastExecutedFill confirmed fills of one external decision are averaged; an unconfirmed order or
+// another symbol makes the fill unavailable
+func TestLastExecutedFill(t *testing.T) {
+	at := &AutoTrader{}
+	if _, _, ok := at.LastExecutedFill("BTCUSDT"); ok {
+		t.Fatal("fill reported before any decision")
+	}
+
+	// Limit entry partially filled, remainder sent as market
+	at.beginExecutedFill("BTCUSDT")
+	at.addExecutedFill("BTCUSDT", 1, 100, true)
+	at.addExecutedFill("BTCUSDT", 3, 104, true)
+	if price, qty, ok := at.LastExecutedFill("BTCUSDT"); !ok || qty != 4 || price != 103 {
+		t.Errorf("fill = %v x %v (ok=%v), want 103 x 4", price, qty, ok)
+	}
+	if _, _, ok := at.LastExecutedFill("ETHUSDT"); ok {
+		t.Error("fill reported for another symbol")
+	}
+
+	at.beginExecutedFill("BTCUSDT")
+	at.addExecutedFill("BTCUSDT", 1, 100, true)
+	at.addExecutedFill("BTCUSDT", 1, 100, false)
+	if _, _, ok := at.LastExecutedFill("BTCUSDT"); ok {
+		t.Error("fill reported with an unconfirmed order")
+	}
+
+	// New decision without any recorded order (e.g. position too small to reduce)
+	at.beginExecutedFill("BTCUSDT")
+	if _, _, ok := at.LastExecutedFill("BTCUSDT"); ok {
+		t.Error("stale fill reported for a new decision")
+	}
+}
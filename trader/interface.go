@@ -94,3 +94,31 @@ type Trader interface {
 	// Returns accurate exit price, fees, and close reason for positions closed externally
 	GetClosedPnL(startTime time.Time, limit int) ([]ClosedPnLRecord, error)
 }
+
+// LimitOrderTrader Optional capability: limit-price entry orders
+// Traders that don't implement it fall back to market entry
+type LimitOrderTrader interface {
+	// OpenLongLimit Place a limit order to open long position, returns orderId
+	OpenLongLimit(symbol string, quantity, price float64, leverage int) (map[string]interface{}, error)
+
+	// OpenShortLimit Place a limit order to open short position, returns orderId
+	OpenShortLimit(symbol string, quantity, price float64, leverage int) (map[string]interface{}, error)
+
+	// CancelOrder Cancel a single pending order
+	CancelOrder(symbol string, orderID string) error
+}
+
+// SymbolSupportTrader Optional capability: check whether a symbol is listed on the exchange
+// Traders that don't implement it are assumed to support every symbol
+type SymbolSupportTrader interface {
+	// SupportsSymbol Returns false (with nil error) when the exchange doesn't list the symbol;
+	// a non-nil error means the check itself failed and the result is unknown
+	SupportsSymbol(symbol string) (bool, error)
+}
+
+// MinQuantityTrader Optional capability: minimum order quantity for a symbol
+// Traders that don't implement it leave quantities unchecked (the exchange rejects sub-minimum orders)
+type MinQuantityTrader interface {
+	// MinOrderQuantity Returns the minimum order quantity in base units (e.g. BTC); 0 means unknown
+	MinOrderQuantity(symbol string) (float64, error)
+}
@@ -0,0 +1,129 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/logger"
+	"time"
+)
+
+// Default wait time for a limit entry order before the timeout action applies
+const defaultLimitTimeoutSec = 30
+
+// limitEntryPollInterval order status polling interval while waiting for a limit entry to fill
+var limitEntryPollInterval = time.Second
+
+// openPosition opens a position with the entry order type requested by the decision.
+// Market (default): OpenLong/OpenShort with the given quantity.
+// Limit: place at decision.LimitPrice and wait up to LimitTimeoutSec; on timeout the order is
+// canceled and the unfilled remainder is either dropped ("cancel") or sent as market ("market").
+// Returns the order to record and the quantity actually opened.
+func (at *AutoTrader) openPosition(dec *decision.Decision, side string, quantity, currentPrice float64) (map[string]interface{}, float64, error) {
+	openMarket := func(qty float64) (map[string]interface{}, error) {
+		if side == "long" {
+			return at.trader.OpenLong(dec.Symbol, qty, dec.Leverage)
+		}
+		return at.trader.OpenShort(dec.Symbol, qty, dec.Leverage)
+	}
+
+	if dec.OrderType != decision.OrderTypeLimit || dec.LimitPrice <= 0 {
+		order, err := openMarket(quantity)
+		return order, quantity, err
+	}
+
+	lt, ok := at.trader.(LimitOrderTrader)
+	if !ok {
+		logger.Infof("  ⚠️ Exchange %s does not support limit entry, using market order", at.exchange)
+		order, err := openMarket(quantity)
+		return order, quantity, err
+	}
+
+	// Keep the same notional at the limit price
+	limitQty := quantity
+	if currentPrice > 0 {
+		limitQty = quantity * currentPrice / dec.LimitPrice
+	}
+
+	var order map[string]interface{}
+	var err error
+	if side == "long" {
+		order, err = lt.OpenLongLimit(dec.Symbol, limitQty, dec.LimitPrice, dec.Leverage)
+	} else {
+		order, err = lt.OpenShortLimit(dec.Symbol, limitQty, dec.LimitPrice, dec.Leverage)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	orderID := fmt.Sprintf("%v", order["orderId"])
+
+	timeoutSec := dec.LimitTimeoutSec
+	if timeoutSec <= 0 {
+		timeoutSec = defaultLimitTimeoutSec
+	}
+	logger.Infof("  ⏳ Limit entry %s %s @ %.6f qty=%.6f, waiting up to %ds (order %s)",
+		side, dec.Symbol, dec.LimitPrice, limitQty, timeoutSec, orderID)
+
+	filled, final := at.waitLimitFill(dec.Symbol, orderID, time.Duration(timeoutSec)*time.Second)
+	if final == "FILLED" {
+		if filled <= 0 {
+			filled = limitQty
+		}
+		logger.Infof("  ✓ Limit entry filled: %s %s qty=%.6f", side, dec.Symbol, filled)
+		return order, filled, nil
+	}
+
+	// Timed out (or canceled by exchange): cancel and check how much filled before cancel
+	if final == "" {
+		if err := lt.CancelOrder(dec.Symbol, orderID); err != nil {
+			logger.Infof("  ⚠️ Failed to cancel limit entry order %s: %v", orderID, err)
+		}
+		if status, err := at.trader.GetOrderStatus(dec.Symbol, orderID); err == nil {
+			filled = getFloatFromMap(status, "executedQty")
+		}
+	}
+
+	remaining := limitQty - filled
+	if dec.LimitTimeoutAction == decision.LimitTimeoutMarket && remaining > 0 {
+		logger.Infof("  ⏱ Limit entry not filled in %ds (filled %.6f/%.6f), sending remaining %.6f as market",
+			timeoutSec, filled, limitQty, remaining)
+		if filled > 0 {
+			at.recordAndConfirmOrder(order, dec.Symbol, "open_"+side, filled, dec.LimitPrice, dec.Leverage, 0)
+		}
+		marketOrder, err := openMarket(remaining)
+		if err != nil {
+			if filled > 0 {
+				logger.Infof("  ⚠️ Market fallback failed, keeping partial limit fill %.6f: %v", filled, err)
+				return nil, filled, nil
+			}
+			return nil, 0, err
+		}
+		return marketOrder, filled + remaining, nil
+	}
+
+	if filled > 0 {
+		logger.Infof("  ⏱ Limit entry partially filled in %ds (%.6f/%.6f), remainder canceled", timeoutSec, filled, limitQty)
+		return order, filled, nil
+	}
+	return nil, 0, fmt.Errorf("%w: %s %s @ %.6f after %ds", decision.ErrLimitNotFilled, side, dec.Symbol, dec.LimitPrice, timeoutSec)
+}
+
+// waitLimitFill polls order status until filled, closed by the exchange, or timeout.
+// Returns filled quantity and the final status ("" on timeout).
+func (at *AutoTrader) waitLimitFill(symbol, orderID string, timeout time.Duration) (float64, string) {
+	deadline := time.Now().Add(timeout)
+	var filled float64
+	for time.Now().Before(deadline) {
+		time.Sleep(limitEntryPollInterval)
+		status, err := at.trader.GetOrderStatus(symbol, orderID)
+		if err != nil {
+			logger.Infof("  ⚠️ Failed to query limit entry order %s: %v", orderID, err)
+			continue
+		}
+		filled = getFloatFromMap(status, "executedQty")
+		switch st, _ := status["status"].(string); st {
+		case "FILLED", "CANCELED", "REJECTED", "EXPIRED":
+			return filled, st
+		}
+	}
+	return filled, ""
+}
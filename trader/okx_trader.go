@@ -8,8 +8,10 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"nofx/logger"
 	"strconv"
@@ -484,6 +486,32 @@ func (t *OKXTrader) ensureMgnModeInPositions(positions []map[string]interface{})
 	return result
 }
 
+// errOKXInstrumentNotFound the instrument is not listed as a SWAP on OKX
+var errOKXInstrumentNotFound = errors.New("instrument info not found")
+
+// SupportsSymbol checks whether the symbol is listed as a SWAP instrument (implements SymbolSupportTrader)
+func (t *OKXTrader) SupportsSymbol(symbol string) (bool, error) {
+	if _, err := t.getInstrument(symbol); err != nil {
+		if errors.Is(err, errOKXInstrumentNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// MinOrderQuantity returns the minimum order size converted from contracts to base units (implements MinQuantityTrader)
+func (t *OKXTrader) MinOrderQuantity(symbol string) (float64, error) {
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return 0, err
+	}
+	if inst.CtVal <= 0 {
+		return 0, nil
+	}
+	return inst.MinSz * inst.CtVal, nil
+}
+
 // getInstrument gets instrument info
 func (t *OKXTrader) getInstrument(symbol string) (*OKXInstrument, error) {
 	instId := t.convertSymbol(symbol)
@@ -500,6 +528,10 @@ func (t *OKXTrader) getInstrument(symbol string) (*OKXInstrument, error) {
 	path := fmt.Sprintf("%s?instType=SWAP&instId=%s", okxInstrumentsPath, instId)
 	data, err := t.doRequest("GET", path, nil)
 	if err != nil {
+		// 51001: Instrument ID does not exist
+		if strings.Contains(err.Error(), "code=51001") {
+			return nil, fmt.Errorf("%w: %s", errOKXInstrumentNotFound, instId)
+		}
 		return nil, err
 	}
 
@@ -519,7 +551,7 @@ func (t *OKXTrader) getInstrument(symbol string) (*OKXInstrument, error) {
 	}
 
 	if len(instruments) == 0 {
-		return nil, fmt.Errorf("instrument info not found: %s", instId)
+		return nil, fmt.Errorf("%w: %s", errOKXInstrumentNotFound, instId)
 	}
 
 	inst := instruments[0]
@@ -820,6 +852,96 @@ func (t *OKXTrader) OpenShort(symbol string, quantity float64, leverage int) (ma
 	}, nil
 }
 
+// OpenLongLimit places a limit order to open long position at the given price
+func (t *OKXTrader) OpenLongLimit(symbol string, quantity, price float64, leverage int) (map[string]interface{}, error) {
+	return t.openLimit(symbol, "buy", "long", quantity, price, leverage)
+}
+
+// OpenShortLimit places a limit order to open short position at the given price
+func (t *OKXTrader) OpenShortLimit(symbol string, quantity, price float64, leverage int) (map[string]interface{}, error) {
+	return t.openLimit(symbol, "sell", "short", quantity, price, leverage)
+}
+
+// openLimit places a limit open order (pending SL/TP orders are kept, unlike market open)
+func (t *OKXTrader) openLimit(symbol, side, posSide string, quantity, price float64, leverage int) (map[string]interface{}, error) {
+	if price <= 0 {
+		return nil, fmt.Errorf("invalid limit price: %.8f", price)
+	}
+	if err := t.setLeverageForSide(symbol, leverage, posSide); err != nil {
+		logger.Infof("  ⚠️ Failed to set leverage: %v", err)
+	}
+
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instrument info: %w", err)
+	}
+
+	sz := quantity / inst.CtVal
+	szStr := t.formatSize(sz, inst)
+
+	// Round price to tick size
+	px := price
+	if inst.TickSz > 0 {
+		px = math.Round(price/inst.TickSz) * inst.TickSz
+	}
+	pxStr := strconv.FormatFloat(px, 'f', -1, 64)
+
+	logger.Infof("  📊 OKX limit open %s: quantity=%.6f, contracts=%s, px=%s", posSide, quantity, szStr, pxStr)
+
+	body := map[string]interface{}{
+		"instId":  t.convertSymbol(symbol),
+		"tdMode":  t.getMgnMode(),
+		"side":    side,
+		"posSide": posSide,
+		"ordType": "limit",
+		"px":      pxStr,
+		"sz":      szStr,
+		"clOrdId": genOkxClOrdID(),
+		"tag":     okxTag,
+	}
+
+	data, err := t.doRequest("POST", okxOrderPath, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to place limit %s order: %w", posSide, err)
+	}
+
+	var orders []struct {
+		OrdId string `json:"ordId"`
+		SCode string `json:"sCode"`
+		SMsg  string `json:"sMsg"`
+	}
+	if err := json.Unmarshal(data, &orders); err != nil {
+		return nil, fmt.Errorf("failed to parse order response: %w", err)
+	}
+	if len(orders) == 0 || orders[0].SCode != "0" {
+		msg := "unknown error"
+		if len(orders) > 0 {
+			msg = orders[0].SMsg
+		}
+		return nil, fmt.Errorf("failed to place limit %s order: %s", posSide, msg)
+	}
+
+	logger.Infof("✓ OKX limit %s order placed: %s size: %s px: %s (Order ID: %s)", posSide, symbol, szStr, pxStr, orders[0].OrdId)
+
+	return map[string]interface{}{
+		"orderId": orders[0].OrdId,
+		"symbol":  symbol,
+		"status":  "NEW",
+	}, nil
+}
+
+// CancelOrder cancels a single pending order
+func (t *OKXTrader) CancelOrder(symbol string, orderID string) error {
+	body := map[string]interface{}{
+		"instId": t.convertSymbol(symbol),
+		"ordId":  orderID,
+	}
+	if _, err := t.doRequest("POST", okxCancelOrderPath, body); err != nil {
+		return fmt.Errorf("failed to cancel order %s: %w", orderID, err)
+	}
+	return nil
+}
+
 // CloseLong closes long position
 func (t *OKXTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
 	instId := t.convertSymbol(symbol)
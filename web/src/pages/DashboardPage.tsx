@@ -40,6 +40,8 @@ interface TraderStats {
   total_trades: number
   win_rate: number
   profit_factor: number
+  sharpe_ratio?: number
+  sortino_ratio?: number
   current_equity: number
   initial_balance: number
   return_rate: number
@@ -643,8 +643,13 @@ export interface CopyTradeConfig {
 
 export interface CopyTradeStats {
   signals_received: number;
+  signals_deduped: number;
   signals_followed: number;
   signals_skipped: number;
+  opens_suppressed?: number;
+  paused?: boolean;
+  total_copied_notional?: number;
+  avg_execution_latency_ms?: number;
   decisions_generated: number;
   warnings_count: number;
   last_signal_time: string;